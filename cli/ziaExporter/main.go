@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/zscaler/terraform-provider-zia/v4/zia"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+	ziaSDK "github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia"
+)
+
+func getEnvVarOrFail(k string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	log.Fatalf("[ERROR] Couldn't find environment variable %s\n", k)
+	return ""
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func main() {
+	command, args := "export", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "export":
+		runExport(args)
+	case "drift":
+		runDrift(args)
+	case "simulate":
+		runSimulate(args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\nUsage:\n  ziaExporter [export] [flags]\n  ziaExporter drift -state terraform.tfstate [flags]\n  ziaExporter simulate -policy firewall_filtering [transaction flags]\n", command)
+		os.Exit(2)
+	}
+}
+
+// newService builds the SDK client from the environment, like
+// cli/ziaActivator.go. The returned function ends a legacy session.
+func newService(ctx context.Context) (*zscaler.Service, func()) {
+	log.Printf("[INFO] Initializing ZIA client")
+
+	useLegacy := strings.ToLower(os.Getenv("ZSCALER_USE_LEGACY_CLIENT")) == "true"
+	userAgent := fmt.Sprintf("(%s %s) cli/ziaExporter", runtime.GOOS, runtime.GOARCH)
+
+	var service *zscaler.Service
+
+	if useLegacy {
+		log.Printf("[INFO] Using Legacy Client mode")
+
+		ziaCfg, err := ziaSDK.NewConfiguration(
+			ziaSDK.WithZiaUsername(getEnvVarOrFail("ZIA_USERNAME")),
+			ziaSDK.WithZiaPassword(getEnvVarOrFail("ZIA_PASSWORD")),
+			ziaSDK.WithZiaAPIKey(getEnvVarOrFail("ZIA_API_KEY")),
+			ziaSDK.WithZiaCloud(getEnvVarOrFail("ZIA_CLOUD")),
+			ziaSDK.WithUserAgent(userAgent),
+		)
+		if err != nil {
+			log.Fatalf("Error creating ZIA configuration: %v", err)
+		}
+
+		service, err = zscaler.NewLegacyZiaClient(ziaCfg)
+		if err != nil {
+			log.Fatalf("Error creating ZIA legacy client: %v", err)
+		}
+	} else {
+		log.Printf("[INFO] Using OneAPI Client mode")
+
+		opts := []zscaler.ConfigSetter{
+			zscaler.WithClientID(getEnvVarOrFail("ZSCALER_CLIENT_ID")),
+			zscaler.WithClientSecret(getEnvVarOrFail("ZSCALER_CLIENT_SECRET")),
+			zscaler.WithVanityDomain(getEnvVarOrFail("ZSCALER_VANITY_DOMAIN")),
+			zscaler.WithUserAgentExtra(userAgent),
+		}
+		// ZSCALER_CLOUD is optional: unset or empty selects the default production cloud.
+		if cloud := strings.TrimSpace(os.Getenv("ZSCALER_CLOUD")); cloud != "" {
+			opts = append(opts, zscaler.WithZscalerCloud(cloud))
+		}
+
+		cfg, err := zscaler.NewConfiguration(opts...)
+		if err != nil {
+			log.Fatalf("[ERROR] Failed to build OneAPI configuration: %v", err)
+		}
+
+		service, err = zscaler.NewOneAPIClient(cfg)
+		if err != nil {
+			log.Fatalf("[ERROR] Failed to initialize OneAPI client: %v", err)
+		}
+	}
+
+	logout := func() {
+		if useLegacy && service.LegacyClient != nil && service.LegacyClient.ZiaClient != nil {
+			log.Printf("[INFO] Destroying session...\n")
+			if err := service.LegacyClient.ZiaClient.Logout(ctx); err != nil {
+				log.Printf("[WARN] Logout failed: %v\n", err)
+			}
+		}
+	}
+	return service, logout
+}
+
+func runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	outputDir := flags.String("output", "zia-export", "directory the generated .tf files are written to")
+	resources := flags.String("resources", "", "comma separated resource types to export (default: all)")
+	exclude := flags.String("exclude", "", "comma separated resource types to skip")
+	_ = flags.Parse(args)
+
+	ctx := context.Background()
+	service, logout := newService(ctx)
+	defer logout()
+
+	report, err := zia.ExportTenant(ctx, service, zia.ExportOptions{
+		OutputDir:     *outputDir,
+		ResourceTypes: splitList(*resources),
+		Exclude:       splitList(*exclude),
+	})
+	if report != nil {
+		printReport(report)
+	}
+	if err != nil {
+		log.Fatalf("[ERROR] Export failed: %v", err)
+	}
+
+	log.Printf("[INFO] Configuration written to %s. Run `terraform plan` there to review the imports.", *outputDir)
+}
+
+func runDrift(args []string) {
+	flags := flag.NewFlagSet("drift", flag.ExitOnError)
+	statePath := flags.String("state", "terraform.tfstate", "Terraform state file to compare with the tenant")
+	format := flags.String("format", "json", "report format: json or markdown")
+	output := flags.String("output", "", "file the report is written to (default: stdout)")
+	exitCode := flags.Bool("detailed-exitcode", false, "exit with status 2 when drift is found")
+	_ = flags.Parse(args)
+
+	if *format != "json" && *format != "markdown" {
+		log.Fatalf("[ERROR] Unsupported report format %q", *format)
+	}
+
+	state, err := os.ReadFile(*statePath)
+	if err != nil {
+		log.Fatalf("[ERROR] Reading state: %v", err)
+	}
+
+	ctx := context.Background()
+	service, logout := newService(ctx)
+
+	report, err := zia.ReportDrift(ctx, service, state)
+	logout()
+	if err != nil {
+		log.Fatalf("[ERROR] Drift report failed: %v", err)
+	}
+
+	var content []byte
+	if *format == "markdown" {
+		content = []byte(report.Markdown())
+	} else {
+		content, err = json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("[ERROR] Encoding report: %v", err)
+		}
+		content = append(content, '\n')
+	}
+
+	if *output == "" {
+		_, _ = os.Stdout.Write(content)
+	} else if err := os.WriteFile(*output, content, 0o644); err != nil {
+		log.Fatalf("[ERROR] Writing report: %v", err)
+	}
+
+	if *exitCode && report.HasDrift() {
+		os.Exit(2)
+	}
+}
+
+func runSimulate(args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	policy := flags.String("policy", "", "policy to evaluate: "+strings.Join(zia.SimulatedPolicies, ", "))
+	format := flags.String("format", "text", "output format: text or json")
+	var tx zia.PolicyTransaction
+	flags.StringVar(&tx.User, "user", "", "ID or name of the user")
+	groups := flags.String("groups", "", "comma separated IDs or names of the groups of the user")
+	flags.StringVar(&tx.Department, "department", "", "ID or name of the department of the user")
+	flags.StringVar(&tx.Location, "location", "", "ID or name of the location")
+	locationGroups := flags.String("location-groups", "", "comma separated IDs or names of the location groups of the location")
+	flags.StringVar(&tx.SrcIP, "src-ip", "", "source IP address")
+	flags.StringVar(&tx.DstIP, "dst-ip", "", "destination IP address")
+	flags.IntVar(&tx.DstPort, "dst-port", 0, "destination port")
+	flags.StringVar(&tx.Protocol, "protocol", "", "network protocol (TCP, UDP) or web protocol (HTTP, HTTPS)")
+	flags.StringVar(&tx.URLCategory, "url-category", "", "URL category of the destination")
+	flags.StringVar(&tx.CloudApp, "cloud-app", "", "cloud or network application")
+	flags.StringVar(&tx.DayOfWeek, "day", "", "day of the week, MON to SUN")
+	flags.StringVar(&tx.TimeOfDay, "time", "", "time of day, HH:MM")
+	flags.StringVar(&tx.RequestMethod, "request-method", "", "HTTP request method")
+	_ = flags.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		log.Fatalf("[ERROR] Unsupported output format %q", *format)
+	}
+	tx.Groups = splitList(*groups)
+	tx.LocationGroups = splitList(*locationGroups)
+
+	ctx := context.Background()
+	service, logout := newService(ctx)
+
+	result, err := zia.SimulatePolicy(ctx, service, *policy, tx)
+	logout()
+	if err != nil {
+		log.Fatalf("[ERROR] Simulation failed: %v", err)
+	}
+
+	if *format == "json" {
+		content, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatalf("[ERROR] Encoding result: %v", err)
+		}
+		_, _ = os.Stdout.Write(append(content, '\n'))
+		return
+	}
+	for _, step := range result.Trace {
+		fmt.Printf("%-13s %5d  %-40s %s\n", step.Result, step.Order, step.RuleName, step.Reason)
+	}
+	switch {
+	case !result.Matched:
+		fmt.Println("no rule matches")
+	case result.Conclusive:
+		fmt.Printf("matches rule %d %q: %s\n", result.RuleID, result.RuleName, result.Action)
+	default:
+		fmt.Printf("matches rule %d %q: %s, unless an indeterminate rule above matches first\n", result.RuleID, result.RuleName, result.Action)
+	}
+}
+
+func printReport(report *zia.ExportReport) {
+	exported := make([]string, 0, len(report.Exported))
+	for t := range report.Exported {
+		exported = append(exported, t)
+	}
+	sort.Strings(exported)
+	for _, t := range exported {
+		fmt.Printf("exported %-50s %d\n", t, report.Exported[t])
+	}
+
+	skipped := make([]string, 0, len(report.Skipped))
+	for t, reason := range report.Skipped {
+		if reason != "not selected" {
+			skipped = append(skipped, t)
+		}
+	}
+	sort.Strings(skipped)
+	for _, t := range skipped {
+		fmt.Printf("skipped  %-50s %s\n", t, report.Skipped[t])
+	}
+
+	for _, err := range report.Errors {
+		fmt.Printf("error    %v\n", err)
+	}
+}
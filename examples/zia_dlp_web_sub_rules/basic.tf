@@ -32,9 +32,7 @@ resource "zia_dlp_web_rules" "this" {
   dlp_engines {
       id = [data.zia_dlp_engines.this.id]
     }
-  locations {
-    id = [data.zia_location_management.this.id]
-  }
+  location_ids = [data.zia_location_management.this.id]
   labels {
       id = [data.zia_rule_labels.this.id]
     }
@@ -23,7 +23,5 @@ resource "zia_file_type_control_rules" "this" {
     file_types         = ["FTCATEGORY_MS_WORD", "FTCATEGORY_MS_POWERPOINT", "FTCATEGORY_PDF_DOCUMENT", "FTCATEGORY_MS_EXCEL"]
     protocols          = ["FOHTTP_RULE", "FTP_RULE", "HTTPS_RULE", "HTTP_RULE"]
     cloud_applications = tolist([for app in data.zia_cloud_applications.this.applications : app["app"]])
-    groups {
-        id = [12006683]
-    }
+    group_ids = [12006683]
 }
@@ -14,9 +14,7 @@ resource "zia_firewall_dns_rule" "this" {
     departments {
         id = [ data.zia_department_management.engineering.id ]
     }
-    groups {
-        id = [ data.zia_group_management.normal_internet.id ]
-    }
+    group_ids = [ data.zia_group_management.normal_internet.id ]
     time_windows {
         id = [ data.zia_firewall_filtering_time_window.work_hours.id ]
     }
@@ -5,9 +5,7 @@ resource "zia_firewall_filtering_rule" "example" {
     state = "ENABLED"
     order = 1
     enable_full_logging = true
-    groups {
-        id = [ data.zia_group_management.normal_internet.id ]
-    }
+    group_ids = [ data.zia_group_management.normal_internet.id ]
 }
 
 data "zia_group_management" "normal_internet" {
@@ -32,9 +32,7 @@ resource "zia_firewall_ips_rule" "example" {
     departments {
         id = [ data.zia_department_management.engineering.id ]
     }
-    groups {
-        id = [ data.zia_group_management.normal_internet.id ]
-    }
+    group_ids = [ data.zia_group_management.normal_internet.id ]
     time_windows {
         id = [ data.zia_firewall_filtering_time_window.work_hours.id ]
     }
@@ -30,9 +30,7 @@ resource "zia_nat_control_rules" "this" {
     nw_services {
         id = [462370, 17472664]
     }
-    locations {
-        id = [256000852, -3]
-    }
+    location_ids = [256000852, -3]
     location_groups {
         id = [8061257, 8061256]
     }
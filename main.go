@@ -1,11 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
 	"github.com/zscaler/terraform-provider-zia/v4/zia"
 	"github.com/zscaler/terraform-provider-zia/v4/zia/common"
 )
@@ -27,9 +28,25 @@ Version %s
 https://registry.terraform.io/providers/zscaler/zia/latest/docs
 
 `, common.Version())
-	plugin.Serve(&plugin.ServeOpts{
-		ProviderFunc: zia.ZIAProvider,
-		ProviderAddr: "registry.terraform.io/zscaler/zia",
-		Debug:        debug,
-	})
+
+	ctx := context.Background()
+	providerServer, err := zia.ProviderServerFactory(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var serveOpts []tf5server.ServeOpt
+	if debug {
+		serveOpts = append(serveOpts, tf5server.WithManagedDebug())
+	}
+
+	if err := tf5server.Serve("registry.terraform.io/zscaler/zia", providerServer, serveOpts...); err != nil {
+		log.Fatal(err)
+	}
+	zia.LogReadCacheStats()
+	// go-plugin kills the provider two seconds after asking it to stop. The
+	// locks go first, so a waiting run is not held up until their leases
+	// expire when the activation uses up the window.
+	zia.ReleaseTenantLocks()
+	zia.ActivatePendingChanges()
 }
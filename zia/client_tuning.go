@@ -0,0 +1,265 @@
+package zia
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/zscaler/zscaler-sdk-go/v3/logger"
+	rl "github.com/zscaler/zscaler-sdk-go/v3/ratelimiter"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia"
+)
+
+const (
+	defaultCacheTTLSeconds        = 600
+	defaultCacheTTISeconds        = 480
+	defaultRateLimitWindowSeconds = 10
+)
+
+// clientTuning holds the client_tuning settings. Zero values leave the SDK
+// default in place, except for the cache, which stays enabled unless
+// cacheDisabled is set.
+type clientTuning struct {
+	cacheDisabled   bool
+	cacheTTL        int // seconds
+	cacheTTI        int // seconds
+	cacheMaxSizeMB  int
+	getRateLimit    int // GET requests per rateLimitWindow
+	writeRateLimit  int // POST/PUT/DELETE requests per rateLimitWindow
+	rateLimitWindow int // seconds
+	maxIdleConns    int // idle connections kept per host
+	caBundle        string
+}
+
+// readClientTuning reads the client_tuning block. Every setting falls back to
+// its ZSCALER_* environment variable when the block leaves it unset.
+func readClientTuning(d configValues) clientTuning {
+	t := clientTuning{
+		cacheTTL:        defaultCacheTTLSeconds,
+		cacheTTI:        defaultCacheTTISeconds,
+		rateLimitWindow: defaultRateLimitWindowSeconds,
+	}
+	var block map[string]interface{}
+	if val, ok := d.GetOk("client_tuning"); ok {
+		if blocks := val.([]interface{}); len(blocks) > 0 && blocks[0] != nil {
+			block = blocks[0].(map[string]interface{})
+		}
+	}
+
+	// cache_enabled defaults to true in the schema, so the environment is
+	// only consulted when the block is absent.
+	if block != nil {
+		enabled, ok := block["cache_enabled"].(bool)
+		t.cacheDisabled = ok && !enabled
+	} else if os.Getenv("ZSCALER_CACHE_ENABLED") != "" {
+		t.cacheDisabled = strings.ToLower(os.Getenv("ZSCALER_CACHE_ENABLED")) == "false"
+	}
+
+	settingInt(block, "cache_ttl_seconds", "ZSCALER_CACHE_TTL_SECONDS", &t.cacheTTL)
+	settingInt(block, "cache_tti_seconds", "ZSCALER_CACHE_TTI_SECONDS", &t.cacheTTI)
+	settingInt(block, "cache_max_size_mb", "ZSCALER_CACHE_MAX_SIZE_MB", &t.cacheMaxSizeMB)
+	settingInt(block, "get_rate_limit", "ZSCALER_GET_RATE_LIMIT", &t.getRateLimit)
+	settingInt(block, "write_rate_limit", "ZSCALER_WRITE_RATE_LIMIT", &t.writeRateLimit)
+	settingInt(block, "rate_limit_window_seconds", "ZSCALER_RATE_LIMIT_WINDOW_SECONDS", &t.rateLimitWindow)
+	settingInt(block, "max_idle_conns_per_host", "ZSCALER_MAX_IDLE_CONNS_PER_HOST", &t.maxIdleConns)
+
+	settingString(block, "ca_bundle", "ZSCALER_CA_BUNDLE", &t.caBundle)
+	return t
+}
+
+// settingInt sets *dst from the block attribute key when it is set, or from
+// the environment variable env otherwise.
+func settingInt(block map[string]interface{}, key, env string, dst *int) {
+	if val, ok := block[key].(int); ok && val > 0 {
+		*dst = val
+		return
+	}
+	raw := os.Getenv(env)
+	if raw == "" {
+		return
+	}
+	val, err := strconv.Atoi(raw)
+	if err != nil || val < 0 {
+		log.Printf("[WARN] Ignoring %s=%q: expected a non-negative integer", env, raw)
+		return
+	}
+	if val > 0 {
+		*dst = val
+	}
+}
+
+// settingString sets *dst from the block attribute key when it is set, or
+// from the environment variable env otherwise.
+func settingString(block map[string]interface{}, key, env string, dst *string) {
+	if val, ok := block[key].(string); ok && val != "" {
+		*dst = val
+		return
+	}
+	*dst = os.Getenv(env)
+}
+
+func (t clientTuning) v2Setters() []zia.ConfigSetter {
+	setters := []zia.ConfigSetter{
+		zia.WithCache(!t.cacheDisabled),
+		zia.WithCacheTtl(time.Duration(t.cacheTTL) * time.Second),
+		zia.WithCacheTti(time.Duration(t.cacheTTI) * time.Second),
+	}
+	if t.cacheMaxSizeMB > 0 {
+		setters = append(setters, zia.WithCacheMaxSizeMB(int64(t.cacheMaxSizeMB)))
+	}
+	return setters
+}
+
+func (t clientTuning) v3Setters() []zscaler.ConfigSetter {
+	setters := []zscaler.ConfigSetter{
+		zscaler.WithCache(!t.cacheDisabled),
+		zscaler.WithCacheTtl(time.Duration(t.cacheTTL) * time.Second),
+		zscaler.WithCacheTti(time.Duration(t.cacheTTI) * time.Second),
+	}
+	if t.cacheMaxSizeMB > 0 {
+		setters = append(setters, zscaler.WithCacheMaxSizeMB(int64(t.cacheMaxSizeMB)))
+	}
+	return setters
+}
+
+// tunesTransport reports whether any setting applies to the HTTP transport.
+func (t clientTuning) tunesTransport() bool {
+	return t.getRateLimit > 0 || t.writeRateLimit > 0 || t.maxIdleConns > 0 || t.caBundle != ""
+}
+
+// rootCAs returns the system pool extended with the certificates of the CA
+// bundle.
+func (t clientTuning) rootCAs() (*x509.CertPool, error) {
+	pem, err := os.ReadFile(t.caBundle)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", t.caBundle)
+	}
+	return pool, nil
+}
+
+// tuneHTTPClient returns a copy of an SDK HTTP client whose transport applies
+// the connection pool size, CA bundle and rate limits. The layers below the
+// retry policy are replaced, so every retry is still rate limited.
+func (t clientTuning) tuneHTTPClient(client *http.Client) (*http.Client, error) {
+	if !t.tunesTransport() {
+		return client, nil
+	}
+	return withInnerTransport(client, func(base http.RoundTripper) (http.RoundTripper, error) {
+		var limiter *rl.RateLimitTransport
+		if limited, ok := base.(*rl.RateLimitTransport); ok {
+			copied := *limited
+			limiter = &copied
+			base = limited.Base
+		}
+		transport, ok := base.(*http.Transport)
+		if !ok || transport == nil {
+			transport = http.DefaultTransport.(*http.Transport)
+		}
+		transport = transport.Clone()
+		if t.maxIdleConns > 0 {
+			transport.MaxIdleConnsPerHost = t.maxIdleConns
+			if transport.MaxIdleConns != 0 && transport.MaxIdleConns < t.maxIdleConns {
+				transport.MaxIdleConns = t.maxIdleConns
+			}
+		}
+		if t.caBundle != "" {
+			pool, err := t.rootCAs()
+			if err != nil {
+				return nil, err
+			}
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			}
+			transport.TLSClientConfig.RootCAs = pool
+		}
+
+		if t.getRateLimit > 0 || t.writeRateLimit > 0 {
+			if limiter == nil {
+				limiter = &rl.RateLimitTransport{Logger: logger.GetDefaultLogger("zia-provider: ")}
+			}
+			limiter.Limiter = rl.NewRateLimiter(t.getRateLimitOrDefault(), t.writeRateLimitOrDefault(), t.rateLimitWindow, t.rateLimitWindow)
+			limiter.WaitFunc = nil
+		}
+		if limiter == nil {
+			return transport, nil
+		}
+		limiter.Base = transport
+		return limiter, nil
+	})
+}
+
+// withInnerTransport returns a copy of an SDK HTTP client with the transport
+// below its retry policy replaced by replace(current). The SDK builds its
+// clients as retryablehttp standard clients over an optional rate limiting
+// transport; the copy keeps the retry policy and leaves the original client
+// untouched. Clients of any other shape are returned unchanged.
+func withInnerTransport(client *http.Client, replace func(http.RoundTripper) (http.RoundTripper, error)) (*http.Client, error) {
+	if client == nil {
+		return nil, nil
+	}
+	rt, ok := client.Transport.(*retryablehttp.RoundTripper)
+	if !ok || rt.Client == nil || rt.Client.HTTPClient == nil {
+		log.Printf("[WARN] Unexpected SDK HTTP client transport %T, transport settings not applied", client.Transport)
+		return client, nil
+	}
+
+	inner := *rt.Client.HTTPClient
+	transport, err := replace(inner.Transport)
+	if err != nil {
+		return nil, err
+	}
+	inner.Transport = transport
+
+	// retryablehttp.Client carries sync.Once fields, so the copy is built
+	// field by field instead of by value.
+	retryable := &retryablehttp.Client{
+		HTTPClient:      &inner,
+		Logger:          rt.Client.Logger,
+		RetryWaitMin:    rt.Client.RetryWaitMin,
+		RetryWaitMax:    rt.Client.RetryWaitMax,
+		RetryMax:        rt.Client.RetryMax,
+		RequestLogHook:  rt.Client.RequestLogHook,
+		ResponseLogHook: rt.Client.ResponseLogHook,
+		CheckRetry:      rt.Client.CheckRetry,
+		Backoff:         rt.Client.Backoff,
+		ErrorHandler:    rt.Client.ErrorHandler,
+		PrepareRetry:    rt.Client.PrepareRetry,
+	}
+	copied := *client
+	copied.Transport = &retryablehttp.RoundTripper{Client: retryable}
+	return &copied, nil
+}
+
+// getRateLimitOrDefault returns the GET limit, or the SDK's own ZIA limit of
+// 20 per 10 seconds scaled to the window when only writes are tuned.
+func (t clientTuning) getRateLimitOrDefault() int {
+	if t.getRateLimit > 0 {
+		return t.getRateLimit
+	}
+	return max(1, 2*t.rateLimitWindow)
+}
+
+// writeRateLimitOrDefault returns the POST/PUT/DELETE limit, or the SDK's own
+// ZIA limit of 10 per 10 seconds scaled to the window when only reads are
+// tuned.
+func (t clientTuning) writeRateLimitOrDefault() int {
+	if t.writeRateLimit > 0 {
+		return t.writeRateLimit
+	}
+	return max(1, t.rateLimitWindow)
+}
@@ -0,0 +1,95 @@
+package zia
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/zscaler/zscaler-sdk-go/v3/logger"
+	rl "github.com/zscaler/zscaler-sdk-go/v3/ratelimiter"
+)
+
+func TestReadClientTuning(t *testing.T) {
+	t.Setenv("ZSCALER_CACHE_ENABLED", "false")
+	t.Setenv("ZSCALER_CACHE_TTL_SECONDS", "30")
+	t.Setenv("ZSCALER_GET_RATE_LIMIT", "not-a-number")
+	t.Setenv("ZSCALER_CA_BUNDLE", "/etc/env-ca.pem")
+
+	got := readClientTuning(frameworkConfigValues{})
+	want := clientTuning{cacheDisabled: true, cacheTTL: 30, cacheTTI: defaultCacheTTISeconds, rateLimitWindow: defaultRateLimitWindowSeconds, caBundle: "/etc/env-ca.pem"}
+	if got != want {
+		t.Errorf("from the environment: got %+v, want %+v", got, want)
+	}
+
+	// Block settings win; the cache stays enabled because the block is set.
+	got = readClientTuning(frameworkConfigValues{"client_tuning": []interface{}{map[string]interface{}{
+		"cache_tti_seconds": 60,
+		"write_rate_limit":  2,
+		"ca_bundle":         "/etc/block-ca.pem",
+	}}})
+	want = clientTuning{cacheTTL: 30, cacheTTI: 60, writeRateLimit: 2, rateLimitWindow: defaultRateLimitWindowSeconds, caBundle: "/etc/block-ca.pem"}
+	if got != want {
+		t.Errorf("from the block: got %+v, want %+v", got, want)
+	}
+}
+
+func TestTuneHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caBundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caBundle, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The shape the SDK builds: a retryablehttp standard client over a rate
+	// limiting transport.
+	retryable := retryablehttp.NewClient()
+	retryable.Logger = nil
+	retryable.RetryMax = 0
+	sdkLimiter := rl.NewRateLimiter(20, 10, 10, 10)
+	retryable.HTTPClient.Transport = &rl.RateLimitTransport{
+		Base:    &http.Transport{MaxIdleConnsPerHost: 4},
+		Limiter: sdkLimiter,
+		Logger:  logger.GetDefaultLogger("test: "),
+	}
+	sdkClient := retryable.StandardClient()
+
+	if _, err := sdkClient.Get(server.URL); err == nil {
+		t.Fatal("expected the untuned client to reject the test certificate")
+	}
+
+	tuning := clientTuning{getRateLimit: 5, rateLimitWindow: 1, maxIdleConns: 32, caBundle: caBundle}
+	tuned, err := tuning.tuneHTTPClient(sdkClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := tuned.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the CA bundle to be trusted: %v", err)
+	}
+	resp.Body.Close()
+
+	inner := tuned.Transport.(*retryablehttp.RoundTripper).Client
+	if inner.RetryMax != 0 || inner.CheckRetry == nil {
+		t.Error("expected the retry policy to be kept")
+	}
+	limited := inner.HTTPClient.Transport.(*rl.RateLimitTransport)
+	if limited.Limiter == sdkLimiter {
+		t.Error("expected the SDK rate limiter to be replaced")
+	}
+	if got := limited.Base.(*http.Transport).MaxIdleConnsPerHost; got != 32 {
+		t.Errorf("expected 32 idle connections per host, got %d", got)
+	}
+	if retryable.HTTPClient.Transport.(*rl.RateLimitTransport).Base.(*http.Transport).MaxIdleConnsPerHost != 4 {
+		t.Error("expected the SDK client to be left untouched")
+	}
+
+	if _, err := (clientTuning{caBundle: filepath.Join(t.TempDir(), "missing.pem")}).tuneHTTPClient(sdkClient); err == nil {
+		t.Error("expected a missing CA bundle to fail")
+	}
+}
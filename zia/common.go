@@ -1,14 +1,15 @@
 package zia
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sort"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/cloudappcontrol"
@@ -71,6 +72,23 @@ func setIDsSchemaTypeCustom(maxItems *int, desc string) *schema.Schema {
 	}
 }
 
+// setIntIDsSchemaType is the flat form of setIDsSchemaTypeCustom: a set of
+// IDs set directly as `location_ids = [...]` instead of in an ID block.
+func setIntIDsSchemaType(maxItems *int, desc string) *schema.Schema {
+	ids := &schema.Schema{
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Description: desc,
+		Elem: &schema.Schema{
+			Type: schema.TypeInt,
+		},
+	}
+	if maxItems != nil && *maxItems > 0 {
+		ids.MaxItems = *maxItems
+	}
+	return ids
+}
+
 // setCustomKeyIDsSchema mirrors setIDsSchemaTypeCustom (single block containing a
 // set of IDs) but lets the caller name the inner list attribute when the API key
 // is not the generic "id" (e.g. "zapp_id", "group_id").
@@ -286,6 +304,17 @@ func expandIDNameExtensionsSet(d *schema.ResourceData, key string) []common.IDNa
 	return []common.IDNameExtensions{}
 }
 
+// expandIDNameExtensionsIDs expands a flat set of IDs such as location_ids.
+func expandIDNameExtensionsIDs(d *schema.ResourceData, key string) []common.IDNameExtensions {
+	result := []common.IDNameExtensions{}
+	if v, ok := d.GetOk(key); ok {
+		for _, id := range v.(*schema.Set).List() {
+			result = append(result, common.IDNameExtensions{ID: id.(int)})
+		}
+	}
+	return result
+}
+
 // TEMPORARY FUNCTION UNTIL NEXT GO SDK RELEASE
 func expandCloudApplicationInstanceSet(d *schema.ResourceData, key string) []cloudappcontrol.CloudAppInstances {
 	setInterface, ok := d.GetOk(key)
@@ -591,6 +620,18 @@ func flattenIDExtensionsListIDs(list []common.IDNameExtensions) []interface{} {
 	}
 }
 
+// flattenIDExtensionsIDs is the flat counterpart of flattenIDExtensionsListIDs.
+func flattenIDExtensionsIDs(list []common.IDNameExtensions) []interface{} {
+	ids := []interface{}{}
+	for _, item := range list {
+		if item.ID == 0 && item.Name == "" {
+			continue
+		}
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
 // TEMPORARY FUNCTION UNTIL NEXT GO SDK RELEASE
 func flattenIDCloudAppInstance(list []cloudappcontrol.CloudAppInstances) []interface{} {
 	if len(list) == 0 {
@@ -1251,18 +1292,46 @@ func sortOrders(ruleOrderMap map[int]orderWithState) RuleIDOrderPairList {
 type orderWithState struct {
 	order OrderRule
 	done  bool
+	// cycle is the done channel of the ordering cycle that plans this
+	// rule's registration; waitForReorder blocks on it.
+	cycle chan struct{}
+}
+
+// ruleOrderFailure is a rule the ordering engine could not place, reported
+// as a diagnostic on the rule's own resource while it waits on the cycle
+// that gave up on it.
+type ruleOrderFailure struct {
+	ruleID int
+	reason string
 }
 
 type listrules struct {
-	orders      map[string]map[int]orderWithState
-	orderer     map[string]int
+	// orders holds every rule registered per resource type during this
+	// run. Rules stay registered after their cycle so later cycles keep
+	// them anchored at their declared position instead of treating them
+	// as unmanaged.
+	orders map[string]map[int]orderWithState
+	// reorderDone is the done channel of the next cycle per resource
+	// type; registrations join it until the engine snapshots the plan.
 	reorderDone map[string]chan struct{}
+	// running marks the resource types that have an engine goroutine.
+	running map[string]bool
+	// lastChange is when a rule of the type last registered or finished
+	// its write. The engine plans only after reorderSettleInterval of
+	// quiet, so a burst of parallel Creates is ordered in one cycle.
+	lastChange map[string]time.Time
+	// failures holds, per waiting rule ID, the placements the last cycle
+	// gave up on.
+	failures map[string]map[int][]ruleOrderFailure
 	sync.Mutex
 }
 
 var rules = listrules{
 	orders:      make(map[string]map[int]orderWithState),
 	reorderDone: make(map[string]chan struct{}),
+	running:     make(map[string]bool),
+	lastChange:  make(map[string]time.Time),
+	failures:    make(map[string]map[int][]ruleOrderFailure),
 }
 
 type RuleIDOrderPair struct {
@@ -1281,282 +1350,307 @@ func (p RuleIDOrderPairList) Less(i, j int) bool {
 }
 func (p RuleIDOrderPairList) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
 
-// reorderTickInterval controls how often the reorder ticker fires.
-// Default is 30 seconds. Tests can override this to speed up reorder cycles.
-var reorderTickInterval = 30 * time.Second
-
-// maxNoProgressTicks bounds how long reorderAll will keep retrying when
-// the loop has stopped making forward progress (no new rules registering
-// AND no successful PUTs AND skipped set not shrinking). Each tick is
-// reorderTickInterval (30s default), so 6 ticks ≈ 3 min. This protects
-// against configurations that can never converge (e.g. declared orders
-// with permanent gaps, or some Create calls failing so the maximum
-// declared order can never be reached). It is NOT used while creates
-// are still in flight — see the progress detection in reorderAll.
-const maxNoProgressTicks = 6
-
-// maxStuckOnSkippedTicks is the FAST early-exit when we've reconciled
-// every in-range rule and the only remaining work is rules whose
-// declared order exceeds the API's current orderable count. This is
-// the deadlock case caused by terraform parallelism: 10 rules POST,
-// 10 register and block on waitForReorder, but some of those declared
-// orders > apiOrderable, so reorder has nothing to do for them. We
-// must return so waitForReorder unblocks and the next batch of POSTs
-// can extend apiOrderable. Without this, we spent ~3 minutes per
-// batch on the slower maxNoProgressTicks safety net for what is
-// actually a normal mid-apply state. 2 ticks ≈ 60s lets the cache
-// catch up to in-flight PUTs before we declare "nothing more to do".
-const maxStuckOnSkippedTicks = 2
-
-// maxNonImprovingPutTicks is the oscillation breaker (SUP-4231). When the
-// declared orders are unsatisfiable — duplicate order values in the tenant,
-// or unmanaged rules interleaved with managed ones — every PUT displaces a
-// neighbouring rule, so each pass keeps issuing PUTs forever without the
-// at-target count ever surpassing its best. Because putsIssued > 0 counts
-// as progress for maxNoProgressTicks, no other exit condition can fire and
-// the cycle livelocks until terraform's own context deadline kills it
-// (observed as a 2.5h hang). After this many consecutive passes that issue
-// PUTs without improving on the best at-target count, the loop logs the
-// still-misplaced rules and returns so the apply can complete; the
-// unplaced rules surface as drift on the next plan.
-const maxNonImprovingPutTicks = 6
-
-// reorderAll runs as a background goroutine and reconciles the desired
-// order of every rule registered for resourceType against the API's
-// current state. It is intentionally diff-based:
-//
-//   - Each pass calls getCurrent ONCE to fetch {ruleID -> {Order, Rank}}
-//     for every rule the API knows about.
-//   - For each registered rule we compare the API's current Order/Rank
-//     to the desired Order/Rank and SKIP the rule entirely when they
-//     already match — no per-rule GET, no PUT, no API traffic at all.
-//   - Only rules whose position actually drifted from desired call into
-//     updateOrder. This is the property the user has been asking for
-//     since SUP-3988 was first opened: don't PUT a rule whose order
-//     hasn't changed.
-//
-// Rules whose desired Order is outside the API's orderable range
-// (1..count, where count is the number of rules in the API with
-// Order >= 1) are deferred to the next tick rather than being PUT —
-// the API rejects out-of-range orders with INVALID_INPUT_ARGUMENT.
-// This typically means more rules are still being POSTed by terraform.
+// reorderSettleInterval is how long the registry for a resource type must
+// be quiet (no rule registering or finishing its write) before the engine
+// plans the cycle. Parallel Creates/Updates of one policy type land within
+// this window and are ordered together. Tests override it.
+var reorderSettleInterval = 5 * time.Second
+
+// maxReorderPasses bounds how many plan-and-apply passes a cycle makes.
+// The first pass normally converges; the others absorb API behaviour the
+// plan could not predict (for example an update displacing a neighbour).
+// Whatever is still misplaced afterwards is reported as a diagnostic.
+const maxReorderPasses = 3
+
+type OrderRule struct {
+	Order int
+	Rank  int
+}
+
+// orderMove is one order/rank update the engine issues.
+type orderMove struct {
+	ID   int
+	From OrderRule
+	To   OrderRule
+}
+
+// orderPlan is the outcome of planRuleOrder for one snapshot of a policy.
+type orderPlan struct {
+	// target lists the rule ID expected at each position (index 0 is
+	// order 1), managed and unmanaged rules alike.
+	target []int
+	// moves are the updates needed to reach target, in the order they
+	// must be issued.
+	moves []orderMove
+	// deferred are managed rules that cannot be placed in this snapshot:
+	// not visible in the API yet, or declared beyond the orderable range
+	// because other rules of the apply have not been created yet.
+	deferred []int
+	// conflicts are managed rules that can never be placed as declared.
+	conflicts map[int]string
+}
+
+// planRuleOrder computes the complete target permutation of a policy and
+// the minimal updates to reach it. current is the API view of every rule
+// of the type ({ruleID -> Order/Rank}); desired is the declared position
+// of each managed rule.
 //
-// Progress detection: a pass made progress if size grew (new rule
-// registered), OR a PUT was issued successfully, OR the deferred set
-// shrank. Without progress, noProgressTicks accumulates and the loop
-// eventually returns so waitForReorder can unblock — preventing the
-// goroutine from leaking when declared orders are unreachable.
-func reorderAll(
+// Managed rules take their declared order. Every other orderable rule —
+// unmanaged ones and managed ones that are deferred — keeps its current
+// relative order and fills the remaining positions, which is exactly
+// where the API's insert-and-shift semantics leave it. Only managed rules
+// are ever updated, in ascending target order: once positions 1..k-1 hold
+// their targets, moving the rule for position k can no longer disturb
+// them, so each misplaced rule is updated exactly once and rules already
+// in place are never touched.
+func planRuleOrder(current map[int]OrderRule, desired map[int]OrderRule) orderPlan {
+	plan := orderPlan{conflicts: map[int]string{}}
+
+	// Orderable rules as the API sees them, positions 1..n.
+	var listed RuleIDOrderPairList
+	for id, c := range current {
+		if c.Order >= 1 {
+			listed = append(listed, RuleIDOrderPair{ID: id, Order: OrderRule{Order: c.Order}})
+		}
+	}
+	sort.Sort(listed)
+	n := len(listed)
+
+	var candidates RuleIDOrderPairList
+	for id, want := range desired {
+		if _, visible := current[id]; !visible || want.Order < 1 || want.Order > n {
+			plan.deferred = append(plan.deferred, id)
+			continue
+		}
+		candidates = append(candidates, RuleIDOrderPair{ID: id, Order: OrderRule{Order: want.Order}})
+	}
+	sort.Ints(plan.deferred)
+	sort.Sort(candidates)
+
+	slots := make([]int, n)
+	assigned := make(map[int]bool, len(candidates))
+	for _, c := range candidates {
+		if holder := slots[c.Order.Order-1]; holder != 0 {
+			plan.conflicts[c.ID] = fmt.Sprintf("declared order %d is also declared by rule %d", c.Order.Order, holder)
+			continue
+		}
+		slots[c.Order.Order-1] = c.ID
+		assigned[c.ID] = true
+	}
+
+	next := 0
+	for _, l := range listed {
+		if assigned[l.ID] {
+			continue
+		}
+		for next < n && slots[next] != 0 {
+			next++
+		}
+		if next == n {
+			break
+		}
+		slots[next] = l.ID
+	}
+	plan.target = slots
+
+	// Simulate the API's insert-and-shift so later positions are judged
+	// against where earlier moves leave them. believed starts at the API
+	// order and follows each rule a simulated move shifts.
+	sim := make([]int, 0, n)
+	believed := make(map[int]int, len(current))
+	for _, l := range listed {
+		sim = append(sim, l.ID)
+	}
+	for id, c := range current {
+		believed[id] = c.Order
+	}
+	for k := 1; k <= n; k++ {
+		id := slots[k-1]
+		if !assigned[id] {
+			continue
+		}
+		cur := current[id]
+		want := OrderRule{Order: k, Rank: desired[id].Rank}
+		if believed[id] == k && cur.Rank == want.Rank {
+			continue
+		}
+		plan.moves = append(plan.moves, orderMove{ID: id, From: cur, To: want})
+		before := append([]int(nil), sim...)
+		for i, v := range sim {
+			if v == id {
+				sim = append(sim[:i], sim[i+1:]...)
+				break
+			}
+		}
+		if k-1 > len(sim) {
+			sim = append(sim, id)
+		} else {
+			sim = append(sim[:k-1], append([]int{id}, sim[k-1:]...)...)
+		}
+		for i, v := range sim {
+			if i >= len(before) || before[i] != v {
+				believed[v] = i + 1
+			}
+		}
+	}
+	return plan
+}
+
+// applyRuleOrder drives one cycle for resourceType: plan against a fresh
+// GetAll, issue the moves, and re-plan to verify, up to maxReorderPasses.
+// A stable policy costs one GetAll and zero updates. It returns the rules
+// it could not place and the rules it deferred, each keyed by rule ID with
+// the reason.
+func applyRuleOrder(
 	resourceType string,
+	desired map[int]OrderRule,
 	getCurrent func() (map[int]OrderRule, error),
 	updateOrder func(id int, order OrderRule) error,
 	beforeReorder func(),
-) {
-	ticker := time.NewTicker(reorderTickInterval)
-	defer ticker.Stop()
-	lastSize := -1
-	cleanPasses := 0
-	noProgressTicks := 0
-	stuckOnSkippedTicks := 0
-	nonImprovingPutTicks := 0
-	bestAtTarget := -1
-	prevSkipped := -1
-	prevAtTarget := -1
-	for {
-		select {
-		case <-ticker.C:
-			rules.Lock()
-			size := len(rules.orders[resourceType])
-			allDone := true
-			for _, v := range rules.orders[resourceType] {
-				if !v.done {
-					allDone = false
-					break
-				}
+) (unplaced, deferred map[int]string) {
+	putErrs := map[int]error{}
+	for pass := 1; ; pass++ {
+		current, err := getCurrent()
+		if err != nil {
+			unplaced := make(map[int]string, len(desired))
+			for id := range desired {
+				unplaced[id] = fmt.Sprintf("reading the current %s order failed: %v", resourceType, err)
 			}
-			if !(allDone && size > 0) {
-				rules.Unlock()
-				continue
+			return unplaced, nil
+		}
+		plan := planRuleOrder(current, desired)
+
+		if len(plan.moves) == 0 || pass > maxReorderPasses {
+			deferred := make(map[int]string, len(plan.deferred))
+			for _, id := range plan.deferred {
+				if _, visible := current[id]; !visible {
+					deferred[id] = fmt.Sprintf("the rule is not listed by the %s API", resourceType)
+				} else {
+					deferred[id] = fmt.Sprintf("declared order %d is outside 1..%d, the orderable rules of the policy", desired[id].Order, len(plan.target))
+				}
+				log.Printf("[INFO] rule order: deferring %s rule %d: %s", resourceType, id, deferred[id])
 			}
-			sorted := sortOrders(rules.orders[resourceType])
-			rules.Unlock()
-
-			// One GetAll per pass. This is the cache-friendly call
-			// path: with the SDK cache-invalidation fix, any prior
-			// PUT in this pass invalidates the parent collection so
-			// subsequent passes always see the latest state.
-			current, err := getCurrent()
-			if err != nil {
-				log.Printf("[ERROR] reorderAll: getCurrent failed for %s: %v", resourceType, err)
-				continue
+			unplaced := make(map[int]string, len(plan.conflicts)+len(plan.moves))
+			for id, reason := range plan.conflicts {
+				unplaced[id] = reason
 			}
-			count := 0
-			for _, c := range current {
-				if c.Order >= 1 {
-					count++
+			for _, m := range plan.moves {
+				reason := fmt.Sprintf("still at order %d (rank %d) instead of order %d (rank %d) after %d passes; rules not managed by Terraform may be occupying the declared positions", m.From.Order, m.From.Rank, m.To.Order, m.To.Rank, maxReorderPasses)
+				if err := putErrs[m.ID]; err != nil {
+					reason = fmt.Sprintf("updating its order failed: %v", err)
 				}
+				unplaced[m.ID] = reason
 			}
+			log.Printf("[INFO] rule order: %s cycle finished after %d pass(es) — %d managed, %d deferred, %d unplaced", resourceType, pass, len(desired), len(deferred), len(unplaced))
+			return unplaced, deferred
+		}
 
-			if beforeReorder != nil {
-				beforeReorder()
+		if beforeReorder != nil {
+			beforeReorder()
+		}
+		for _, m := range plan.moves {
+			log.Printf("[INFO] rule order: %s — moving rule %d from order %d (rank %d) to order %d (rank %d)", resourceType, m.ID, m.From.Order, m.From.Rank, m.To.Order, m.To.Rank)
+			if err := updateOrder(m.ID, m.To); err != nil {
+				log.Printf("[ERROR] rule order: update for rule %d failed in %s: %v", m.ID, resourceType, err)
+				putErrs[m.ID] = err
+				continue
 			}
+			delete(putErrs, m.ID)
+		}
+	}
+}
 
-			puterrs := 0
-			skipped := 0
-			putsIssued := 0
-			alreadyAtTarget := 0
-			var putDetails []string
-			for _, v := range sorted {
-				desired := v.Order
-				cur, exists := current[v.ID]
-
-				// Rule POSTed but not yet visible to GetAll
-				// (cache lag / API eventual consistency).
-				// Skip and try next tick.
-				if !exists {
-					skipped++
-					continue
-				}
-
-				// Out-of-range desired position. Defer — usually
-				// means more rules are still being POSTed and
-				// count will grow on the next tick.
-				if desired.Order < 1 || desired.Order > count {
-					log.Printf("[INFO] reorderAll: deferring rule %d for %s (desired order %d, orderable range 1..%d)", v.ID, resourceType, desired.Order, count)
-					skipped++
-					continue
-				}
-
-				// Diff check: skip rules already at the right
-				// Order AND Rank — no GET, no PUT, no traffic.
-				// This is the core optimization: with N rules
-				// fully in place, a stable pass costs ONE GetAll
-				// (the one we already did above) and ZERO PUTs.
-				if cur.Order == desired.Order && cur.Rank == desired.Rank {
-					alreadyAtTarget++
-					continue
-				}
-
-				log.Printf("[INFO] reorderAll: %s — moving rule %d from order %d (rank %d) to order %d (rank %d)", resourceType, v.ID, cur.Order, cur.Rank, desired.Order, desired.Rank)
-				if err := updateOrder(v.ID, desired); err != nil {
-					log.Printf("[ERROR] reorderAll: PUT for rule %d failed in %s: %v", v.ID, resourceType, err)
-					puterrs++
-					continue
-				}
-				putDetails = append(putDetails, fmt.Sprintf("rule %d (API order %d, declared order %d)", v.ID, cur.Order, desired.Order))
-				putsIssued++
-			}
+// runOrderEngine is the per-resource-type goroutine started by the first
+// registration. It waits for the registry to settle, snapshots the declared
+// orders of every rule registered so far, applies them in one cycle and
+// releases that cycle's waiters. It keeps cycling while rules register
+// during a cycle and exits when none are left waiting.
+func runOrderEngine(
+	resourceType string,
+	getCurrent func() (map[int]OrderRule, error),
+	updateOrder func(id int, order OrderRule) error,
+	beforeReorder func(),
+) {
+	for {
+		waitForSettledRegistry(resourceType)
 
-			log.Printf("[INFO] reorderAll: %s pass — registered=%d apiOrderable=%d alreadyAtTarget=%d putsIssued=%d skipped=%d errors=%d", resourceType, size, count, alreadyAtTarget, putsIssued, skipped, puterrs)
-
-			// Convergence: a clean pass = nothing to do AND no
-			// errors AND size didn't grow. We require two
-			// consecutive clean passes so any in-flight PUT from
-			// the previous pass has time to be reflected by GetAll.
-			cleanPass := putsIssued == 0 && skipped == 0 && puterrs == 0 && size == lastSize
-			if cleanPass {
-				cleanPasses++
-				if cleanPasses >= 2 {
-					log.Printf("[INFO] reorder complete for %s: %d rules", resourceType, size)
-					return
-				}
-			} else {
-				cleanPasses = 0
-			}
+		rules.Lock()
+		doneCh := rules.reorderDone[resourceType]
+		rules.reorderDone[resourceType] = make(chan struct{})
+		desired := make(map[int]OrderRule, len(rules.orders[resourceType]))
+		for id, r := range rules.orders[resourceType] {
+			desired[id] = r.order
+		}
+		rules.Unlock()
 
-			// Fast deadlock-breaker. The terraform-parallelism
-			// deadlock looks like this:
-			//
-			//   - N rules registered and marked done.
-			//   - All in-range rules are at their target (we did
-			//     the PUTs).
-			//   - K rules have declared orders > apiOrderable,
-			//     so they're permanently skipped this cycle.
-			//
-			// With Create blocked on waitForReorder, the next
-			// batch of POSTs can't extend apiOrderable until we
-			// return. Returning here lets terraform schedule the
-			// next batch; the new rule's registration will start
-			// a fresh reorder cycle that picks up the previously
-			// skipped ones (they stay in the registry — done=true
-			// rules are diff-checked again on subsequent cycles).
-			//
-			// We require the condition to hold for two consecutive
-			// passes so in-flight PUTs from the previous pass have
-			// time to settle in the GetAll view (otherwise a
-			// mid-flight pass might falsely report "nothing
-			// changed" while a PUT is still propagating through
-			// the cache).
-			inRangeAtTarget := alreadyAtTarget == size-skipped
-			progressedThisPass := putsIssued > 0 || (prevAtTarget >= 0 && alreadyAtTarget > prevAtTarget)
-			if skipped > 0 && inRangeAtTarget && !progressedThisPass && puterrs == 0 {
-				stuckOnSkippedTicks++
-				if stuckOnSkippedTicks >= maxStuckOnSkippedTicks {
-					log.Printf("[INFO] reorderAll: %s — %d in-range rule(s) at target, %d deferred (declared orders > apiOrderable=%d). Returning so the next batch of Creates can extend the orderable range.", resourceType, alreadyAtTarget, skipped, count)
-					return
-				}
-			} else {
-				stuckOnSkippedTicks = 0
-			}
+		unplaced, deferred := applyRuleOrder(resourceType, desired, getCurrent, updateOrder, beforeReorder)
 
-			// Oscillation breaker (SUP-4231). A PUT ping-pong — every pass
-			// issues PUTs for the same rules because each placement
-			// displaces a neighbour — never produces a clean pass and
-			// counts as "progress" for the no-progress net below, so
-			// without this check the loop runs until terraform's context
-			// deadline kills it. A pass "improves" only when its at-target
-			// count exceeds the best seen for the current registration set;
-			// after maxNonImprovingPutTicks consecutive PUT-issuing passes
-			// with no improvement, log the contested rules and return so
-			// the apply completes (the unplaced rules show as drift on the
-			// next plan).
-			improved := alreadyAtTarget > bestAtTarget
-			if improved {
-				bestAtTarget = alreadyAtTarget
+		rules.Lock()
+		more := false
+		for _, r := range rules.orders[resourceType] {
+			if r.cycle == rules.reorderDone[resourceType] {
+				more = true
+				break
 			}
+		}
+		for id, reason := range deferred {
+			r := rules.orders[resourceType][id]
 			switch {
-			case size != lastSize:
-				// New registrations legitimately reshuffle the target
-				// set; restart the oscillation window.
-				bestAtTarget = alreadyAtTarget
-				nonImprovingPutTicks = 0
-			case putsIssued > 0 && !improved && skipped == 0 && puterrs == 0:
-				nonImprovingPutTicks++
-				if nonImprovingPutTicks >= maxNonImprovingPutTicks {
-					log.Printf("[WARN] reorderAll: %s — %d pass(es) kept re-issuing order updates without converging (best %d/%d rules at target). The declared orders appear unsatisfiable (duplicate order values in the tenant, or rules not managed by Terraform occupying positions in the declared range). Giving up so the apply can complete; re-run terraform plan to see the remaining differences. Contested in the last pass: %s", resourceType, nonImprovingPutTicks, bestAtTarget, size, strings.Join(putDetails, "; "))
-					return
-				}
-			default:
-				nonImprovingPutTicks = 0
+			case !more:
+				// No cycle follows this one, so nothing will place the rule.
+				unplaced[id] = reason
+			case r.cycle == doneCh:
+				// Keep the rule's resource waiting on the next cycle, which
+				// sees the rules registered since and may place it.
+				r.cycle = rules.reorderDone[resourceType]
+				rules.orders[resourceType][id] = r
 			}
-
-			// Slower no-progress safety net for genuinely
-			// impossible configurations (e.g. declared order=100
-			// with only 1 orderable rule). Real progress = size
-			// grew, OR a PUT was issued, OR the deferred set
-			// shrank.
-			realProgress := size > lastSize || putsIssued > 0
-			if !realProgress && prevSkipped >= 0 && skipped < prevSkipped {
-				realProgress = true
+		}
+		if rules.failures[resourceType] == nil {
+			rules.failures[resourceType] = map[int][]ruleOrderFailure{}
+		}
+		for id, r := range rules.orders[resourceType] {
+			if r.cycle == doneCh {
+				delete(rules.failures[resourceType], id)
 			}
-			if realProgress {
-				noProgressTicks = 0
-			} else if skipped > 0 || puterrs > 0 {
-				noProgressTicks++
-			} else {
-				noProgressTicks = 0
+		}
+		for id, reason := range unplaced {
+			// A rule registered by an earlier cycle has already returned.
+			// Its Read stores the live order, so the next plan shows the
+			// difference and the rule's own apply reports it.
+			if rules.orders[resourceType][id].cycle != doneCh {
+				log.Printf("[WARN] rule order: %s rule %d could not be placed at its declared order: %s", resourceType, id, reason)
+				continue
 			}
+			rules.failures[resourceType][id] = []ruleOrderFailure{{ruleID: id, reason: reason}}
+		}
+		if !more {
+			rules.running[resourceType] = false
+		}
+		rules.Unlock()
+		close(doneCh)
+		if !more {
+			return
+		}
+	}
+}
 
-			if noProgressTicks >= maxNoProgressTicks {
-				log.Printf("[WARN] reorderAll: no progress for %d ticks for %s — giving up. Likely the declared orders exceed the count of orderable rules (gaps in order numbers, or earlier Create failures). Re-run terraform apply to retry.", noProgressTicks, resourceType)
-				return
+// waitForSettledRegistry blocks until no rule of resourceType is still
+// writing and the registry has been quiet for reorderSettleInterval.
+func waitForSettledRegistry(resourceType string) {
+	for {
+		time.Sleep(reorderSettleInterval / 5)
+		rules.Lock()
+		settled := time.Since(rules.lastChange[resourceType]) >= reorderSettleInterval
+		for _, r := range rules.orders[resourceType] {
+			if !r.done {
+				settled = false
+				break
 			}
-
-			lastSize = size
-			prevSkipped = skipped
-			prevAtTarget = alreadyAtTarget
-		default:
-			time.Sleep(reorderTickInterval / 2)
+		}
+		rules.Unlock()
+		if settled {
+			return
 		}
 	}
 }
@@ -1566,72 +1660,173 @@ func markOrderRuleAsDone(id int, resourceType string) {
 	r := rules.orders[resourceType][id]
 	r.done = true
 	rules.orders[resourceType][id] = r
+	if rules.lastChange == nil {
+		rules.lastChange = map[string]time.Time{}
+	}
+	rules.lastChange[resourceType] = time.Now()
 	rules.Unlock()
 }
 
-type OrderRule struct {
-	Order int
-	Rank  int
-}
-
+// reorderWithBeforeReorder registers the declared order of a rule that was
+// just created or updated and makes sure an ordering engine is running for
+// resourceType. getCurrent must return every rule of the policy, managed or
+// not; updateOrder moves one rule. The functions of the registration that
+// starts the engine serve all of its cycles, so they must not depend on
+// the caller's request context. Callers mark the rule done once their
+// write has finished and then block in waitForReorder.
 func reorderWithBeforeReorder(order OrderRule, id int, resourceType string, getCurrent func() (map[int]OrderRule, error), updateOrder func(id int, order OrderRule) error, beforeReorder func()) {
 	rules.Lock()
-	shouldCallReorder := false
-	if rules.orderer == nil {
-		rules.orderer = map[string]int{}
-		rules.reorderDone = map[string]chan struct{}{}
-	}
 	if rules.orders == nil {
 		rules.orders = map[string]map[int]orderWithState{}
 	}
-	if _, ok := rules.orderer[resourceType]; ok {
-		// Check if the previous reorder goroutine has already finished
-		select {
-		case <-rules.reorderDone[resourceType]:
-			// Channel is closed — previous reorder finished.
-			// Start a new reorder cycle for late-arriving rules.
-			log.Printf("[INFO] previous reorder for %s completed, starting new cycle for rule:%d", resourceType, id)
-			rules.reorderDone[resourceType] = make(chan struct{})
-			shouldCallReorder = true
-		default:
-			// Reorder goroutine still running — just register, don't start another
-			shouldCallReorder = false
-		}
-	} else {
-		rules.orderer[resourceType] = id
-		shouldCallReorder = true
-		rules.reorderDone[resourceType] = make(chan struct{})
+	if rules.reorderDone == nil {
+		rules.reorderDone = map[string]chan struct{}{}
+	}
+	if rules.running == nil {
+		rules.running = map[string]bool{}
+	}
+	if rules.lastChange == nil {
+		rules.lastChange = map[string]time.Time{}
+	}
+	if rules.failures == nil {
+		rules.failures = map[string]map[int][]ruleOrderFailure{}
 	}
 	if rules.orders[resourceType] == nil {
 		rules.orders[resourceType] = map[int]orderWithState{}
 	}
-	rules.orders[resourceType][id] = orderWithState{order, false}
-	// Capture the done channel while still holding the lock: concurrent
-	// registrations write rules.reorderDone under the lock, so reading the
-	// map after Unlock races with them.
-	doneCh := rules.reorderDone[resourceType]
+	if rules.reorderDone[resourceType] == nil {
+		rules.reorderDone[resourceType] = make(chan struct{})
+	}
+	rules.orders[resourceType][id] = orderWithState{order: order, cycle: rules.reorderDone[resourceType]}
+	rules.lastChange[resourceType] = time.Now()
+	start := !rules.running[resourceType]
+	rules.running[resourceType] = true
 	rules.Unlock()
-	if shouldCallReorder {
-		log.Printf("[INFO] starting to reorder the rules, delegating to rule:%d, order:%d", id, order)
-		go func() {
-			reorderAll(resourceType, getCurrent, updateOrder, beforeReorder)
-			close(doneCh)
-		}()
+
+	if start {
+		log.Printf("[INFO] starting the rule order engine for %s, triggered by rule:%d, order:%d", resourceType, id, order)
+		go runOrderEngine(resourceType, getCurrent, updateOrder, beforeReorder)
 	}
 }
 
-// waitForReorder blocks until the reorder goroutine for the given resource type
-// has completed. All rules should call this after markOrderRuleAsDone and before
-// reading the resource, to ensure the final order is reflected in the API.
-func waitForReorder(resourceType string) {
+// waitForReorder blocks until the ordering cycle that planned rule id has
+// finished, so the Read that follows reflects the final order. It returns
+// an error diagnostic for every rule that cycle could not place and that
+// this rule is responsible for reporting.
+func waitForReorder(resourceType string, id int) diag.Diagnostics {
 	rules.Lock()
-	ch := rules.reorderDone[resourceType]
+	ch := rules.orders[resourceType][id].cycle
 	rules.Unlock()
-	if ch != nil {
+	if ch == nil {
+		return nil
+	}
+	// A deferred rule is moved on to the next cycle; follow it until the
+	// cycle that places it, or gives up on it, has finished.
+	for {
 		<-ch
+		rules.Lock()
+		next := rules.orders[resourceType][id].cycle
+		rules.Unlock()
+		if next == ch {
+			break
+		}
+		ch = next
 	}
+
+	rules.Lock()
+	failures := rules.failures[resourceType][id]
+	rules.Unlock()
+
+	var diags diag.Diagnostics
+	for _, f := range failures {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("%s rule %d could not be placed at its declared order", resourceType, f.ruleID),
+			Detail:   f.reason,
+		})
+	}
+	return diags
 }
 
 func reorder(order OrderRule, id int, resourceType string, getCurrent func() (map[int]OrderRule, error), updateOrder func(id int, order OrderRule) error) {
 	reorderWithBeforeReorder(order, id, resourceType, getCurrent, updateOrder, nil)
 }
+
+// schemaMigration moves the state of a resource from one schema version to
+// the next.
+type schemaMigration struct {
+	// previous rebuilds the schema the migration starts from out of the one
+	// it produces.
+	previous func(map[string]*schema.Schema) map[string]*schema.Schema
+	// upgrade rewrites a state of the previous version.
+	upgrade schema.StateUpgradeFunc
+}
+
+// versionSchema sets the SchemaVersion and StateUpgraders of r from its
+// migrations, oldest first. The schema of every past version is derived from
+// the current one, so each migration only describes what it changed and new
+// migrations are appended without touching the older ones.
+func versionSchema(r *schema.Resource, migrations ...schemaMigration) *schema.Resource {
+	r.SchemaVersion = len(migrations)
+	r.StateUpgraders = make([]schema.StateUpgrader, len(migrations))
+	past := r.Schema
+	for version := len(migrations) - 1; version >= 0; version-- {
+		past = migrations[version].previous(past)
+		r.StateUpgraders[version] = schema.StateUpgrader{
+			Version: version,
+			Type:    (&schema.Resource{Schema: past, Timeouts: r.Timeouts}).CoreConfigSchema().ImpliedType(),
+			Upgrade: migrations[version].upgrade,
+		}
+	}
+	return r
+}
+
+// idBlockMigration moves ID blocks such as `locations { id = [...] }` to flat
+// ID sets such as `location_ids = [...]`. lists maps each block to its list;
+// resources without one of the blocks are left alone.
+func idBlockMigration(lists map[string]string) schemaMigration {
+	return schemaMigration{
+		previous: func(current map[string]*schema.Schema) map[string]*schema.Schema {
+			past := make(map[string]*schema.Schema, len(current))
+			for key, s := range current {
+				past[key] = s
+			}
+			for block, list := range lists {
+				s, ok := past[list]
+				if !ok {
+					continue
+				}
+				delete(past, list)
+				maxItems := s.MaxItems
+				past[block] = setIDsSchemaTypeCustom(&maxItems, s.Description)
+			}
+			return past
+		},
+		upgrade: func(_ context.Context, raw map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+			for block, list := range lists {
+				value, ok := raw[block]
+				if !ok {
+					continue
+				}
+				delete(raw, block)
+				ids := []interface{}{}
+				items, _ := value.([]interface{})
+				for _, item := range items {
+					if m, ok := item.(map[string]interface{}); ok {
+						blockIDs, _ := m["id"].([]interface{})
+						ids = append(ids, blockIDs...)
+					}
+				}
+				raw[list] = ids
+			}
+			return raw, nil
+		},
+	}
+}
+
+// ruleIDBlocksV0 is version 1 of the ordered rule resources: the locations
+// and groups blocks became location_ids and group_ids.
+var ruleIDBlocksV0 = idBlockMigration(map[string]string{
+	"locations": "location_ids",
+	"groups":    "group_ids",
+})
@@ -0,0 +1,400 @@
+// Package cassette records the HTTP traffic of an acceptance test to a file
+// and replays it later without a tenant.
+//
+// A Recorder works at the level of the SDK's outer *http.Client, above its
+// retry and rate limiting layers, so a cassette holds one interaction per
+// SDK call and replays without any backoff. In replay mode requests are
+// matched on method, path and query string; the Nth request for a key gets
+// the Nth recorded response for it. Hosts are not recorded, so a cassette
+// recorded against one cloud or vanity domain replays against any other.
+//
+// Credentials, session cookies, tokens and other secrets are scrubbed from
+// requests and responses before a cassette is written.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mode selects what a Recorder does with requests.
+type Mode string
+
+const (
+	// ModeOff passes requests through untouched.
+	ModeOff Mode = ""
+	// ModeRecord sends requests to the API and records them.
+	ModeRecord Mode = "record"
+	// ModePlay serves requests from a cassette and never touches the network.
+	ModePlay Mode = "play"
+)
+
+// EnvMode is the environment variable ModeFromEnv reads.
+const EnvMode = "ZIA_VCR_TF_ACC"
+
+// Redacted replaces every scrubbed value.
+const Redacted = "REDACTED"
+
+// ModeFromEnv returns the mode set in ZIA_VCR_TF_ACC.
+func ModeFromEnv() (Mode, error) {
+	switch mode := Mode(strings.ToLower(os.Getenv(EnvMode))); mode {
+	case ModeOff, ModeRecord, ModePlay:
+		return mode, nil
+	default:
+		return ModeOff, fmt.Errorf("invalid %s value %q, expected %q or %q", EnvMode, mode, ModeRecord, ModePlay)
+	}
+}
+
+// Cassette is the file format of a recording.
+type Cassette struct {
+	// Env holds environment variables the recording depends on, such as
+	// ZSCALER_USE_LEGACY_CLIENT, so replay can restore them.
+	Env map[string]string `json:"env,omitempty"`
+	// Names are the generated resource names handed out during the
+	// recording, in order.
+	Names        []string      `json:"names,omitempty"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request/response pair.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the recorded part of an HTTP request.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response is the recorded part of an HTTP response.
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Recorder records to or replays from a single cassette file.
+type Recorder struct {
+	mode Mode
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+	played   map[string]int
+	names    int
+}
+
+// Start opens the cassette at path. In ModePlay the file must exist; in
+// ModeRecord it is overwritten by Stop.
+func Start(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		mode:     mode,
+		path:     path,
+		played:   map[string]int{},
+		cassette: Cassette{Env: map[string]string{}},
+	}
+	if mode != ModePlay {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("decoding cassette %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Mode returns the mode the recorder was started in.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Env returns a recorded environment variable.
+func (r *Recorder) Env(key string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	val, ok := r.cassette.Env[key]
+	return val, ok
+}
+
+// SetEnv records an environment variable the recording depends on.
+func (r *Recorder) SetEnv(key, val string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Env[key] = val
+}
+
+// Name returns the name to use in place of a freshly generated one. While
+// recording it stores generated and returns it; while replaying it returns
+// the name recorded at the same position, so resource names match the
+// recorded traffic.
+func (r *Recorder) Name(generated string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch r.mode {
+	case ModeRecord:
+		r.cassette.Names = append(r.cassette.Names, generated)
+	case ModePlay:
+		if r.names < len(r.cassette.Names) {
+			generated = r.cassette.Names[r.names]
+		}
+	}
+	r.names++
+	return generated
+}
+
+// Stop writes the cassette when recording.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// RoundTrip records the request sent through inner, or replays it.
+func (r *Recorder) RoundTrip(req *http.Request, inner http.RoundTripper) (*http.Response, error) {
+	switch r.mode {
+	case ModeRecord:
+		return r.record(req, inner)
+	case ModePlay:
+		return r.play(req)
+	default:
+		return inner.RoundTrip(req)
+	}
+}
+
+func (r *Recorder) record(req *http.Request, inner http.RoundTripper) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+	resp, err := inner.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    requestKeyURL(req.URL),
+			Body:   scrubBody(string(reqBody)),
+		},
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: scrubHeaders(resp.Header),
+			Body:    scrubBody(string(respBody)),
+		},
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) play(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+	key := req.Method + " " + requestKeyURL(req.URL)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matches []Interaction
+	for _, i := range r.cassette.Interactions {
+		if i.Request.Method+" "+i.Request.URL == key {
+			matches = append(matches, i)
+		}
+	}
+	n := r.played[key]
+	r.played[key]++
+	switch {
+	case n < len(matches):
+	case len(matches) > 0 && req.Method == http.MethodGet:
+		// Reads are repeated a varying number of times by Terraform's
+		// refresh and plan; serve the latest recorded state.
+		n = len(matches) - 1
+	default:
+		return nil, fmt.Errorf("cassette %s has no recorded interaction %d for %s", r.path, n+1, key)
+	}
+	recorded := matches[n].Response
+	header := http.Header{}
+	for k, v := range recorded.Headers {
+		header[k] = append([]string(nil), v...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Transport is an http.RoundTripper that routes requests through the
+// Recorder returned by Active, or straight to Inner when it returns nil.
+// SDK clients outlive a single test, so the provider wraps their transports
+// once and tests switch the active recorder.
+type Transport struct {
+	Inner  http.RoundTripper
+	Active func() *Recorder
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	inner := t.Inner
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	if t.Active != nil {
+		if r := t.Active(); r != nil {
+			return r.RoundTrip(req, inner)
+		}
+	}
+	return inner.RoundTrip(req)
+}
+
+// requestKeyURL returns the path and sorted query of u, which is what
+// requests are matched on.
+func requestKeyURL(u *url.URL) string {
+	query := u.Query()
+	if len(query) == 0 {
+		return u.Path
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	return u.Path + "?" + strings.Join(parts, "&")
+}
+
+// sensitiveKeys are the JSON and form field names whose values are scrubbed,
+// compared case-insensitively.
+var sensitiveKeys = map[string]bool{
+	"access_token":     true,
+	"apikey":           true,
+	"api_key":          true,
+	"authorization":    true,
+	"client_assertion": true,
+	"client_secret":    true,
+	"clientsecret":     true,
+	"jsessionid":       true,
+	"password":         true,
+	"presharedkey":     true,
+	"refresh_token":    true,
+	"token":            true,
+}
+
+// recordedHeaders are the response headers kept in a cassette.
+var recordedHeaders = []string{"Content-Type", "Retry-After", "Set-Cookie"}
+
+var jsessionID = regexp.MustCompile(`(?i)(JSESSIONID=)[^;]*`)
+
+func scrubHeaders(h http.Header) http.Header {
+	out := http.Header{}
+	for _, k := range recordedHeaders {
+		for _, v := range h.Values(k) {
+			if k == "Set-Cookie" {
+				v = jsessionID.ReplaceAllString(v, "${1}"+Redacted)
+			}
+			out.Add(k, v)
+		}
+	}
+	return out
+}
+
+// scrubBody redacts sensitive values in a JSON or form-encoded body. Other
+// bodies are returned unchanged.
+func scrubBody(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if trimmed == "" {
+		return body
+	}
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		var v interface{}
+		dec := json.NewDecoder(strings.NewReader(trimmed))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err == nil {
+			data, err := json.Marshal(scrubJSON(v))
+			if err == nil {
+				return string(data)
+			}
+		}
+		return body
+	}
+	if form, err := url.ParseQuery(trimmed); err == nil && strings.Contains(trimmed, "=") {
+		for k := range form {
+			if sensitiveKeys[strings.ToLower(k)] {
+				form.Set(k, Redacted)
+			}
+		}
+		return form.Encode()
+	}
+	return body
+}
+
+func scrubJSON(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if sensitiveKeys[strings.ToLower(k)] {
+				if _, isString := item.(string); isString {
+					val[k] = Redacted
+				}
+				continue
+			}
+			val[k] = scrubJSON(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = scrubJSON(item)
+		}
+	}
+	return v
+}
@@ -0,0 +1,110 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestScrubBody(t *testing.T) {
+	cases := map[string]string{
+		`{"username":"admin","password":"secret","apiKey":"abc"}`:         `{"apiKey":"REDACTED","password":"REDACTED","username":"admin"}`,
+		`[{"name":"vpn","preSharedKey":"psk"}]`:                           `[{"name":"vpn","preSharedKey":"REDACTED"}]`,
+		`{"access_token":"jwt","expires_in":3600}`:                        `{"access_token":"REDACTED","expires_in":3600}`,
+		"client_id=id&client_secret=secret&grant_type=client_credentials": "client_id=id&client_secret=REDACTED&grant_type=client_credentials",
+		"": "",
+	}
+	for in, want := range cases {
+		if got := scrubBody(in); got != want {
+			t.Errorf("scrubBody(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestScrubHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Set-Cookie", "JSESSIONID=abc123; Path=/; Secure")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Request-Id", "drop-me")
+
+	got := scrubHeaders(h)
+	if got.Get("Set-Cookie") != "JSESSIONID=REDACTED; Path=/; Secure" {
+		t.Errorf("unexpected Set-Cookie %q", got.Get("Set-Cookie"))
+	}
+	if got.Get("X-Request-Id") != "" {
+		t.Error("expected unlisted headers to be dropped")
+	}
+}
+
+func TestRequestKeyURL(t *testing.T) {
+	u, _ := url.Parse("https://zsapi.zscaler.net/api/v1/ruleLabels?pageSize=1000&page=1&search=a%20b")
+	if got, want := requestKeyURL(u), "/api/v1/ruleLabels?page=1&pageSize=1000&search=a+b"; got != want {
+		t.Errorf("requestKeyURL = %q, want %q", got, want)
+	}
+}
+
+func TestRecordAndPlay(t *testing.T) {
+	version := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			version++
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"version":`+strconv.Itoa(version)+`}`)
+	}))
+	path := filepath.Join(t.TempDir(), "test.json")
+
+	rec, err := Start(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &Transport{Active: func() *Recorder { return rec }}}
+	send := func(method string) string {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+"/api/v1/thing", strings.NewReader(`{"password":"secret"}`))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	send(http.MethodGet)
+	send(http.MethodPut)
+	send(http.MethodGet)
+	if rec.Name("generated") != "generated" {
+		t.Fatal("expected the generated name while recording")
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	rec, err = Start(path, ModePlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Name("other") != "generated" {
+		t.Fatal("expected the recorded name while replaying")
+	}
+	for i, want := range []string{`{"version":0}`, `{"version":1}`, `{"version":1}`, `{"version":1}`} {
+		method := http.MethodGet
+		if i == 1 {
+			method = http.MethodPut
+		}
+		if got := strings.TrimSpace(send(method)); got != want {
+			t.Errorf("request %d: got %s, want %s", i, got, want)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/api/v1/thing", nil)
+	if _, err := client.Do(req); err == nil || !strings.Contains(err.Error(), "no recorded interaction 2 for PUT /api/v1/thing") {
+		t.Fatalf("expected an unrecorded write to fail, got %v", err)
+	}
+}
@@ -0,0 +1,657 @@
+// Package fakezia is an in-process fake of the ZIA API for tests that cannot
+// reach a live tenant. It speaks the legacy (username/password/API key)
+// protocol, keeps every object in memory and mimics the parts of the real
+// service the provider depends on: session cookies, paginated list
+// endpoints, rule order/rank semantics, lastModifiedTime staleness checks,
+// the activation status endpoints and injectable EDIT_LOCK_NOT_AVAILABLE,
+// STALE_CONFIGURATION_ERROR and 429 responses.
+//
+// The provider's tests point the legacy client at a running server through a
+// test-only hook, with ZSCALER_USE_LEGACY_CLIENT=true plus any non-empty
+// ZIA_USERNAME, ZIA_PASSWORD, ZIA_CLOUD and a ZIA_API_KEY of at least 12
+// alphanumeric characters.
+package fakezia
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	apiPrefix   = "/api/v1/"
+	authPath    = "authenticatedSession"
+	defaultRank = 7
+)
+
+// Activation states reported by GET /status.
+const (
+	ActivationActive  = "ACTIVE"
+	ActivationPending = "PENDING"
+)
+
+// DefaultCollections are the list endpoints registered by NewServer. The
+// value reports whether the collection is an ordered rule set.
+var DefaultCollections = map[string]bool{
+	"bandwidthControlRules":    true,
+	"fileTypeRules":            true,
+	"firewallDnsRules":         true,
+	"firewallFilteringRules":   true,
+	"firewallIpsRules":         true,
+	"forwardingRules":          true,
+	"sandboxRules":             true,
+	"sslInspectionRules":       true,
+	"urlFilteringRules":        true,
+	"webDlpRules":              true,
+	"adminUsers":               false,
+	"bandwidthClasses":         false,
+	"departments":              false,
+	"dlpDictionaries":          false,
+	"dlpEngines":               false,
+	"dlpNotificationTemplates": false,
+	"greTunnels":               false,
+	"groups":                   false,
+	"ipDestinationGroups":      false,
+	"ipSourceGroups":           false,
+	"locations":                false,
+	"networkApplicationGroups": false,
+	"networkServiceGroups":     false,
+	"networkServices":          false,
+	"ruleLabels":               false,
+	"staticIP":                 false,
+	"timeWindows":              false,
+	"users":                    false,
+	"vpnCredentials":           false,
+}
+
+// Fault is an error response the server returns instead of handling a request.
+type Fault struct {
+	Status     int
+	Code       string
+	Message    string
+	RetryAfter int // seconds; only sent when non-zero
+}
+
+// EditLockNotAvailable is returned by ZIA while another admin session holds
+// the configuration edit lock.
+var EditLockNotAvailable = Fault{
+	Status:  http.StatusConflict,
+	Code:    "EDIT_LOCK_NOT_AVAILABLE",
+	Message: "Failed to acquire the edit lock. Please try again later.",
+}
+
+// StaleConfiguration is returned by ZIA when an update carries an outdated
+// lastModifiedTime.
+var StaleConfiguration = Fault{
+	Status:  http.StatusConflict,
+	Code:    "STALE_CONFIGURATION_ERROR",
+	Message: "The configuration was modified by another admin. Refresh and try again.",
+}
+
+// RateLimited returns the 429 response ZIA sends when a per-endpoint rate
+// limit is exceeded.
+func RateLimited(retryAfter int) Fault {
+	return Fault{
+		Status:     http.StatusTooManyRequests,
+		Code:       "RATE_LIMIT_EXCEEDED",
+		Message:    fmt.Sprintf("Rate Limit (1/SECOND) exceeded. Retry after %d seconds", retryAfter),
+		RetryAfter: retryAfter,
+	}
+}
+
+// Request is a request the server has received, recorded for assertions.
+type Request struct {
+	Method string
+	Path   string
+}
+
+type injectedFault struct {
+	method    string
+	path      string
+	remaining int
+	fault     Fault
+}
+
+type collection struct {
+	ordered bool
+	objects []map[string]interface{}
+}
+
+// Server is a fake ZIA API served over httptest.
+type Server struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	collections  map[string]*collection
+	singletons   map[string]map[string]interface{}
+	faults       []*injectedFault
+	sessions     map[string]bool
+	requests     []Request
+	nextID       int
+	clock        int64
+	activation   string
+	activations  int
+	sessionCount int
+}
+
+// NewServer starts a fake ZIA API with DefaultCollections registered. The
+// caller must Close it.
+func NewServer() *Server {
+	s := &Server{
+		collections: map[string]*collection{},
+		singletons:  map[string]map[string]interface{}{},
+		sessions:    map[string]bool{},
+		nextID:      1000,
+		clock:       1700000000,
+		activation:  ActivationActive,
+	}
+	for name, ordered := range DefaultCollections {
+		s.Register(name, ordered)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Register adds an empty list endpoint at path, relative to /api/v1.
+func (s *Server) Register(path string, ordered bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path = strings.Trim(path, "/")
+	if _, ok := s.collections[path]; !ok {
+		s.collections[path] = &collection{ordered: ordered}
+	}
+}
+
+// SetSingleton serves obj from path for GET and replaces it on PUT, the way
+// ZIA's settings endpoints behave.
+func (s *Server) SetSingleton(path string, obj map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.singletons[strings.Trim(path, "/")] = cloneObject(obj)
+}
+
+// Seed stores objects in a registered collection as if they had been created
+// out of band. Objects without an "id" get one assigned; seeding does not
+// change the activation status. It returns the stored copies.
+func (s *Server) Seed(path string, objs ...map[string]interface{}) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.collections[strings.Trim(path, "/")]
+	if c == nil {
+		panic(fmt.Sprintf("fakezia: collection %q is not registered", path))
+	}
+	var out []map[string]interface{}
+	for _, obj := range objs {
+		obj = cloneObject(obj)
+		if _, ok := obj["id"]; !ok {
+			obj["id"] = s.newID()
+		}
+		c.objects = append(c.objects, obj)
+		out = append(out, cloneObject(obj))
+	}
+	if c.ordered {
+		c.sort()
+	}
+	return out
+}
+
+// Objects returns a copy of the objects in a collection, in list order.
+func (s *Server) Objects(path string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.collections[strings.Trim(path, "/")]
+	if c == nil {
+		return nil
+	}
+	out := make([]map[string]interface{}, 0, len(c.objects))
+	for _, obj := range c.objects {
+		out = append(out, cloneObject(obj))
+	}
+	return out
+}
+
+// InjectFault makes the next count requests whose method and path (relative
+// to /api/v1, compared by prefix) match fail with f. An empty method matches
+// every method; a count of zero or less makes the fault permanent until
+// ClearFaults is called.
+func (s *Server) InjectFault(method, path string, count int, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &injectedFault{
+		method:    strings.ToUpper(method),
+		path:      strings.Trim(path, "/"),
+		remaining: count,
+		fault:     f,
+	})
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Requests returns the requests received so far, excluding authentication.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ActivationStatus returns the status GET /status would report.
+func (s *Server) ActivationStatus() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.activation
+}
+
+// Activations returns how many times POST /status/activate was called.
+func (s *Server) Activations() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.activations
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, apiPrefix) {
+		writeError(w, http.StatusNotFound, "RESOURCE_NOT_FOUND", "Resource not found")
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if path == authPath {
+		s.serveAuth(w, r)
+		return
+	}
+	if !s.sessions[r.Header.Get("JSessionID")] {
+		writeError(w, http.StatusUnauthorized, "AUTHENTICATION_FAILED", "SESSION_NOT_VALID")
+		return
+	}
+
+	s.requests = append(s.requests, Request{Method: r.Method, Path: path})
+	if f := s.takeFault(r.Method, path); f != nil {
+		if f.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(f.RetryAfter))
+		}
+		writeError(w, f.Status, f.Code, f.Message)
+		return
+	}
+
+	switch {
+	case path == "status":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": s.activation})
+		return
+	case path == "status/activate" && r.Method == http.MethodPost:
+		s.activation = ActivationActive
+		s.activations++
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": s.activation})
+		return
+	}
+
+	if obj, ok := s.singletons[path]; ok {
+		s.serveSingleton(w, r, path, obj)
+		return
+	}
+	if c, ok := s.collections[path]; ok {
+		s.serveCollection(w, r, c)
+		return
+	}
+	if i := strings.LastIndex(path, "/"); i > 0 {
+		if c, ok := s.collections[path[:i]]; ok {
+			if id, err := strconv.Atoi(path[i+1:]); err == nil {
+				s.serveObject(w, r, c, id)
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "RESOURCE_NOT_FOUND", fmt.Sprintf("Resource %s not found", path))
+}
+
+func (s *Server) serveAuth(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var creds struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			APIKey   string `json:"apiKey"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&creds); err != nil || creds.Username == "" || creds.Password == "" || creds.APIKey == "" {
+			writeError(w, http.StatusUnauthorized, "AUTHENTICATION_FAILED", "Invalid credentials")
+			return
+		}
+		s.sessionCount++
+		id := fmt.Sprintf("fakezia-%d", s.sessionCount)
+		s.sessions[id] = true
+		w.Header().Set("Set-Cookie", "JSESSIONID="+id+"; Path=/; Secure; HttpOnly")
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"authType":           "ADMIN_LOGIN",
+			"obfuscateApiKey":    true,
+			"passwordExpiryTime": 0,
+			"passwordExpiryDays": 0,
+			"source":             "fakezia",
+		})
+	case http.MethodGet:
+		cookie, err := r.Cookie("JSESSIONID")
+		if err != nil || !s.sessions[cookie.Value] {
+			writeError(w, http.StatusUnauthorized, "AUTHENTICATION_FAILED", "SESSION_NOT_VALID")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"authType": "ADMIN_LOGIN"})
+	case http.MethodDelete:
+		delete(s.sessions, r.Header.Get("JSessionID"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+	}
+}
+
+func (s *Server) serveSingleton(w http.ResponseWriter, r *http.Request, path string, obj map[string]interface{}) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, obj)
+	case http.MethodPut:
+		body, ok := decodeObject(w, r)
+		if !ok {
+			return
+		}
+		s.singletons[path] = body
+		s.activation = ActivationPending
+		writeJSON(w, http.StatusOK, body)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+	}
+}
+
+func (s *Server) serveCollection(w http.ResponseWriter, r *http.Request, c *collection) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, c.list(r))
+	case http.MethodPost:
+		obj, ok := decodeObject(w, r)
+		if !ok {
+			return
+		}
+		if name, _ := obj["name"].(string); name != "" && c.findByName(name) != nil {
+			writeError(w, http.StatusBadRequest, "DUPLICATE_ITEM", fmt.Sprintf("Name %q already in use", name))
+			return
+		}
+		obj["id"] = s.newID()
+		s.stamp(obj)
+		if c.ordered {
+			if err := c.place(obj, len(c.objects)); err != nil {
+				writeError(w, http.StatusBadRequest, "INVALID_INPUT_ARGUMENT", err.Error())
+				return
+			}
+		} else {
+			c.objects = append(c.objects, obj)
+		}
+		s.activation = ActivationPending
+		writeJSON(w, http.StatusOK, obj)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+	}
+}
+
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, c *collection, id int) {
+	idx := c.indexOf(id)
+	if idx < 0 {
+		writeError(w, http.StatusNotFound, "RESOURCE_NOT_FOUND", fmt.Sprintf("Resource with ID %d not found", id))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, c.objects[idx])
+	case http.MethodPut:
+		obj, ok := decodeObject(w, r)
+		if !ok {
+			return
+		}
+		current := c.objects[idx]
+		if sent := intValue(obj["lastModifiedTime"]); sent != 0 && sent != intValue(current["lastModifiedTime"]) {
+			writeError(w, StaleConfiguration.Status, StaleConfiguration.Code, StaleConfiguration.Message)
+			return
+		}
+		obj["id"] = id
+		s.stamp(obj)
+		if c.ordered {
+			previous := append([]map[string]interface{}(nil), c.objects...)
+			c.objects = append(c.objects[:idx:idx], c.objects[idx+1:]...)
+			if err := c.place(obj, idx); err != nil {
+				c.objects = previous
+				writeError(w, http.StatusBadRequest, "INVALID_INPUT_ARGUMENT", err.Error())
+				return
+			}
+		} else {
+			c.objects[idx] = obj
+		}
+		s.activation = ActivationPending
+		writeJSON(w, http.StatusOK, obj)
+	case http.MethodDelete:
+		c.objects = append(c.objects[:idx:idx], c.objects[idx+1:]...)
+		if c.ordered {
+			c.renumber()
+		}
+		s.activation = ActivationPending
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+	}
+}
+
+// takeFault returns the first injected fault matching the request, consuming
+// one of its remaining uses.
+func (s *Server) takeFault(method, path string) *Fault {
+	for i, f := range s.faults {
+		if f.method != "" && f.method != method {
+			continue
+		}
+		if !strings.HasPrefix(path, f.path) {
+			continue
+		}
+		if f.remaining > 0 {
+			f.remaining--
+			if f.remaining == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		fault := f.fault
+		return &fault
+	}
+	return nil
+}
+
+func (s *Server) newID() int {
+	s.nextID++
+	return s.nextID
+}
+
+// stamp sets lastModifiedTime to a strictly increasing value so that
+// replaying an older copy of the object is detected as stale.
+func (s *Server) stamp(obj map[string]interface{}) {
+	s.clock++
+	obj["lastModifiedTime"] = s.clock
+}
+
+// list returns the collection filtered by the search query parameter and cut
+// to the requested page.
+func (c *collection) list(r *http.Request) []map[string]interface{} {
+	query := r.URL.Query()
+	search := strings.ToLower(query.Get("search"))
+	out := []map[string]interface{}{}
+	for _, obj := range c.objects {
+		if search != "" {
+			name, _ := obj["name"].(string)
+			if !strings.Contains(strings.ToLower(name), search) {
+				continue
+			}
+		}
+		out = append(out, obj)
+	}
+	pageSize, _ := strconv.Atoi(query.Get("pageSize"))
+	page, _ := strconv.Atoi(query.Get("page"))
+	if pageSize <= 0 {
+		return out
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * pageSize
+	if start >= len(out) {
+		return []map[string]interface{}{}
+	}
+	end := start + pageSize
+	if end > len(out) {
+		end = len(out)
+	}
+	return out[start:end]
+}
+
+func (c *collection) indexOf(id int) int {
+	for i, obj := range c.objects {
+		if intValue(obj["id"]) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *collection) findByName(name string) map[string]interface{} {
+	for _, obj := range c.objects {
+		if n, _ := obj["name"].(string); strings.EqualFold(n, name) {
+			return obj
+		}
+	}
+	return nil
+}
+
+// place inserts a rule at the position its order asks for, shifting the rules
+// below it down, and renumbers the set. Rules with a non-positive order, such
+// as ZIA's default rules, stay at the end of the list. An order past the end
+// of the list appends the rule. fallback is the list index used when the
+// rule does not carry an order. Like ZIA, it rejects a placement that puts a
+// rule above a rule with a higher admin rank (lower rank number).
+func (c *collection) place(obj map[string]interface{}, fallback int) error {
+	if _, ok := obj["rank"]; !ok {
+		obj["rank"] = defaultRank
+	}
+	ordered, defaults := c.split()
+	order := intValue(obj["order"])
+	switch {
+	case order < 0:
+		defaults = append(defaults, obj)
+	default:
+		idx := order - 1
+		if order == 0 {
+			idx = fallback
+		}
+		if idx > len(ordered) {
+			idx = len(ordered)
+		}
+		ordered = append(ordered[:idx], append([]map[string]interface{}{obj}, ordered[idx:]...)...)
+	}
+	for i := 1; i < len(ordered); i++ {
+		if intValue(ordered[i]["rank"]) < intValue(ordered[i-1]["rank"]) {
+			return fmt.Errorf("Rule with rank %d is not allowed at order %d", intValue(ordered[i]["rank"]), i+1)
+		}
+	}
+	c.objects = append(ordered, defaults...)
+	c.renumber()
+	return nil
+}
+
+// split separates the ordered rules from the default rules.
+func (c *collection) split() (ordered, defaults []map[string]interface{}) {
+	for _, obj := range c.objects {
+		if intValue(obj["order"]) < 0 {
+			defaults = append(defaults, obj)
+		} else {
+			ordered = append(ordered, obj)
+		}
+	}
+	return ordered, defaults
+}
+
+// renumber makes the orders of the non-default rules contiguous from 1.
+func (c *collection) renumber() {
+	n := 1
+	for _, obj := range c.objects {
+		if intValue(obj["order"]) < 0 {
+			continue
+		}
+		obj["order"] = n
+		n++
+	}
+}
+
+// sort orders seeded rules by their order, default rules last.
+func (c *collection) sort() {
+	sort.SliceStable(c.objects, func(i, j int) bool {
+		oi, oj := intValue(c.objects[i]["order"]), intValue(c.objects[j]["order"])
+		if (oi < 0) != (oj < 0) {
+			return oj < 0
+		}
+		return oi < oj
+	})
+	c.renumber()
+}
+
+func decodeObject(w http.ResponseWriter, r *http.Request) (map[string]interface{}, bool) {
+	var obj map[string]interface{}
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil || obj == nil {
+		writeError(w, http.StatusBadRequest, "INVALID_INPUT_ARGUMENT", "Request body is invalid")
+		return nil, false
+	}
+	return obj, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{"code": code, "message": message})
+}
+
+// intValue reads an integer stored either by Seed or by decodeObject.
+func intValue(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	case json.Number:
+		i, _ := n.Int64()
+		return int(i)
+	case string:
+		i, _ := strconv.Atoi(n)
+		return i
+	}
+	return 0
+}
+
+func cloneObject(obj map[string]interface{}) map[string]interface{} {
+	data, _ := json.Marshal(obj)
+	var out map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	_ = dec.Decode(&out)
+	if out == nil {
+		out = map[string]interface{}{}
+	}
+	return out
+}
@@ -0,0 +1,222 @@
+package fakezia
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type testClient struct {
+	t       *testing.T
+	server  *Server
+	session string
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+	server := NewServer()
+	t.Cleanup(server.Close)
+
+	c := &testClient{t: t, server: server}
+	resp := c.raw(http.MethodPost, "authenticatedSession", map[string]string{
+		"username": "admin@fakezia.test",
+		"password": "secret",
+		"apiKey":   "0123456789ab",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("authenticate: status %d", resp.StatusCode)
+	}
+	cookie := resp.Cookies()
+	if len(cookie) != 1 || cookie[0].Name != "JSESSIONID" {
+		t.Fatalf("authenticate: expected a JSESSIONID cookie, got %v", resp.Header.Get("Set-Cookie"))
+	}
+	c.session = cookie[0].Value
+	return c
+}
+
+func (c *testClient) raw(method, path string, body interface{}) *http.Response {
+	c.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, c.server.URL+"/api/v1/"+path, &buf)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.session != "" {
+		req.Header.Set("JSessionID", c.session)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func (c *testClient) do(method, path string, body interface{}, wantStatus int) map[string]interface{} {
+	c.t.Helper()
+	resp := c.raw(method, path, body)
+	var out map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	if resp.StatusCode != wantStatus {
+		c.t.Fatalf("%s %s: expected status %d, got %d: %v", method, path, wantStatus, resp.StatusCode, out)
+	}
+	return out
+}
+
+func (c *testClient) list(path string) []map[string]interface{} {
+	c.t.Helper()
+	resp := c.raw(http.MethodGet, path, nil)
+	var out []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		c.t.Fatalf("GET %s: %v", path, err)
+	}
+	return out
+}
+
+func ruleNames(rules []map[string]interface{}) []string {
+	var names []string
+	for _, r := range rules {
+		names = append(names, fmt.Sprintf("%v@%v", r["name"], r["order"]))
+	}
+	return names
+}
+
+func TestServer_RequiresSession(t *testing.T) {
+	c := newTestClient(t)
+	c.session = ""
+	c.do(http.MethodGet, "ruleLabels", nil, http.StatusUnauthorized)
+}
+
+func TestServer_CRUD(t *testing.T) {
+	c := newTestClient(t)
+
+	created := c.do(http.MethodPost, "ruleLabels", map[string]interface{}{"name": "label-a"}, http.StatusOK)
+	id := intValue(created["id"])
+	if id == 0 {
+		t.Fatalf("expected an id, got %v", created)
+	}
+	c.do(http.MethodPost, "ruleLabels", map[string]interface{}{"name": "label-a"}, http.StatusBadRequest)
+
+	path := fmt.Sprintf("ruleLabels/%d", id)
+	got := c.do(http.MethodGet, path, nil, http.StatusOK)
+	if got["name"] != "label-a" {
+		t.Fatalf("unexpected object: %v", got)
+	}
+
+	c.do(http.MethodPut, path, map[string]interface{}{"name": "label-b"}, http.StatusOK)
+	if found := c.list("ruleLabels?search=LABEL-B"); len(found) != 1 {
+		t.Fatalf("expected search to find the renamed label, got %v", found)
+	}
+
+	c.raw(http.MethodDelete, path, nil)
+	c.do(http.MethodGet, path, nil, http.StatusNotFound)
+}
+
+func TestServer_Pagination(t *testing.T) {
+	c := newTestClient(t)
+	for i := 0; i < 5; i++ {
+		c.server.Seed("ipDestinationGroups", map[string]interface{}{"name": fmt.Sprintf("group-%d", i)})
+	}
+	if got := len(c.list("ipDestinationGroups?pageSize=2&page=3")); got != 1 {
+		t.Fatalf("expected 1 object on the last page, got %d", got)
+	}
+	if got := len(c.list("ipDestinationGroups?pageSize=2&page=4")); got != 0 {
+		t.Fatalf("expected an empty page past the end, got %d", got)
+	}
+}
+
+func TestServer_RuleOrdering(t *testing.T) {
+	c := newTestClient(t)
+	c.server.Seed("firewallFilteringRules", map[string]interface{}{"name": "Default", "order": -1, "rank": 7})
+
+	c.do(http.MethodPost, "firewallFilteringRules", map[string]interface{}{"name": "a", "order": 1, "rank": 7}, http.StatusOK)
+	c.do(http.MethodPost, "firewallFilteringRules", map[string]interface{}{"name": "b", "order": 10, "rank": 7}, http.StatusOK)
+	c2 := c.do(http.MethodPost, "firewallFilteringRules", map[string]interface{}{"name": "c", "order": 1, "rank": 7}, http.StatusOK)
+
+	want := "[c@1 a@2 b@3 Default@-1]"
+	if got := fmt.Sprint(ruleNames(c.server.Objects("firewallFilteringRules"))); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	// Moving c to the bottom renumbers the others.
+	c2["order"] = 3
+	c.do(http.MethodPut, fmt.Sprintf("firewallFilteringRules/%d", intValue(c2["id"])), c2, http.StatusOK)
+	want = "[a@1 b@2 c@3 Default@-1]"
+	if got := fmt.Sprint(ruleNames(c.server.Objects("firewallFilteringRules"))); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	// A rank 0 rule cannot sit below rank 7 rules.
+	resp := c.do(http.MethodPost, "firewallFilteringRules", map[string]interface{}{"name": "d", "order": 3, "rank": 0}, http.StatusBadRequest)
+	if resp["code"] != "INVALID_INPUT_ARGUMENT" || resp["message"] != "Rule with rank 0 is not allowed at order 3" {
+		t.Fatalf("unexpected error: %v", resp)
+	}
+	c.do(http.MethodPost, "firewallFilteringRules", map[string]interface{}{"name": "d", "order": 1, "rank": 0}, http.StatusOK)
+}
+
+func TestServer_StaleConfiguration(t *testing.T) {
+	c := newTestClient(t)
+	created := c.do(http.MethodPost, "ruleLabels", map[string]interface{}{"name": "label"}, http.StatusOK)
+	path := fmt.Sprintf("ruleLabels/%d", intValue(created["id"]))
+
+	c.do(http.MethodPut, path, created, http.StatusOK)
+	resp := c.do(http.MethodPut, path, created, http.StatusConflict)
+	if resp["code"] != "STALE_CONFIGURATION_ERROR" {
+		t.Fatalf("expected STALE_CONFIGURATION_ERROR, got %v", resp)
+	}
+
+	delete(created, "lastModifiedTime")
+	c.do(http.MethodPut, path, created, http.StatusOK)
+}
+
+func TestServer_InjectFault(t *testing.T) {
+	c := newTestClient(t)
+	c.server.InjectFault(http.MethodPost, "ruleLabels", 1, EditLockNotAvailable)
+	c.server.InjectFault("", "locations", 1, RateLimited(3))
+
+	resp := c.do(http.MethodPost, "ruleLabels", map[string]interface{}{"name": "label"}, http.StatusConflict)
+	if resp["code"] != "EDIT_LOCK_NOT_AVAILABLE" {
+		t.Fatalf("expected EDIT_LOCK_NOT_AVAILABLE, got %v", resp)
+	}
+	c.do(http.MethodPost, "ruleLabels", map[string]interface{}{"name": "label"}, http.StatusOK)
+
+	limited := c.raw(http.MethodGet, "locations", nil)
+	if limited.StatusCode != http.StatusTooManyRequests || limited.Header.Get("Retry-After") != "3" {
+		t.Fatalf("expected 429 with Retry-After 3, got %d %q", limited.StatusCode, limited.Header.Get("Retry-After"))
+	}
+	if got := c.raw(http.MethodGet, "locations", nil).StatusCode; got != http.StatusOK {
+		t.Fatalf("expected the fault to be consumed, got %d", got)
+	}
+}
+
+func TestServer_Activation(t *testing.T) {
+	c := newTestClient(t)
+	if got := c.do(http.MethodGet, "status", nil, http.StatusOK)["status"]; got != ActivationActive {
+		t.Fatalf("expected ACTIVE, got %v", got)
+	}
+	c.do(http.MethodPost, "ruleLabels", map[string]interface{}{"name": "label"}, http.StatusOK)
+	if got := c.do(http.MethodGet, "status", nil, http.StatusOK)["status"]; got != ActivationPending {
+		t.Fatalf("expected PENDING after a change, got %v", got)
+	}
+	c.do(http.MethodPost, "status/activate", map[string]interface{}{}, http.StatusOK)
+	if c.server.ActivationStatus() != ActivationActive || c.server.Activations() != 1 {
+		t.Fatalf("expected one activation, got %s/%d", c.server.ActivationStatus(), c.server.Activations())
+	}
+}
+
+func TestServer_Singleton(t *testing.T) {
+	c := newTestClient(t)
+	c.server.SetSingleton("advancedSettings", map[string]interface{}{"enableOffice365": false})
+	c.do(http.MethodPut, "advancedSettings", map[string]interface{}{"enableOffice365": true}, http.StatusOK)
+	if got := c.do(http.MethodGet, "advancedSettings", nil, http.StatusOK)["enableOffice365"]; got != true {
+		t.Fatalf("expected the updated setting, got %v", got)
+	}
+}
@@ -2,12 +2,36 @@ package method
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
 )
 
+// NameSource can replace the names GenerateRandomSourcesTypeAndName hands
+// out. Cassette replay uses it so the names match the recorded traffic.
+type NameSource interface {
+	Name(generated string) string
+}
+
+var (
+	nameSourceMu sync.Mutex
+	nameSource   NameSource
+)
+
+// SetNameSource installs s for the running test; nil restores random names.
+func SetNameSource(s NameSource) {
+	nameSourceMu.Lock()
+	defer nameSourceMu.Unlock()
+	nameSource = s
+}
+
 func GenerateRandomSourcesTypeAndName(sourceType string) (string, string, string) {
 	name := acctest.RandStringFromCharSet(10, acctest.CharSetAlpha)
+	nameSourceMu.Lock()
+	if nameSource != nil {
+		name = nameSource.Name(name)
+	}
+	nameSourceMu.Unlock()
 	resource := fmt.Sprintf("%s.%s", sourceType, name)
 	dataSource := fmt.Sprintf("data.%s.%s", sourceType, name)
 	return resource, dataSource, name
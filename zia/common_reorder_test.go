@@ -2,9 +2,12 @@ package zia
 
 import (
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 )
 
 // resetReorderState clears the global reorder state between tests.
@@ -12,13 +15,15 @@ func resetReorderState() {
 	rules.Lock()
 	defer rules.Unlock()
 	rules.orders = make(map[string]map[int]orderWithState)
-	rules.orderer = nil
 	rules.reorderDone = nil
+	rules.running = nil
+	rules.lastChange = nil
+	rules.failures = nil
 }
 
 // =====================================================
 // fakeAPI: a minimal in-memory stand-in for the ZIA
-// rule API used by reorderAll's getCurrent/updateOrder.
+// rule API used by the order engine's getCurrent/updateOrder.
 // =====================================================
 //
 // Tests preSeed each rule with its "current" (API-side)
@@ -165,6 +170,82 @@ func TestRuleIDOrderPairList_SameOrder_TiebreakByID(t *testing.T) {
 	}
 }
 
+// =====================================================
+// Target permutation planning
+// =====================================================
+
+func TestPlanRuleOrder_UnmanagedRulesFillRemainingPositions(t *testing.T) {
+	// Rules 1..4 sit at orders 1..4; rule 4 is managed and declared at
+	// order 2. The unmanaged rules keep their relative order around it.
+	current := map[int]OrderRule{
+		1: {Order: 1, Rank: 7},
+		2: {Order: 2, Rank: 7},
+		3: {Order: 3, Rank: 7},
+		4: {Order: 4, Rank: 7},
+	}
+	plan := planRuleOrder(current, map[int]OrderRule{4: {Order: 2, Rank: 7}})
+
+	wantTarget := []int{1, 4, 2, 3}
+	for i, id := range wantTarget {
+		if plan.target[i] != id {
+			t.Fatalf("target = %v, want %v", plan.target, wantTarget)
+		}
+	}
+	if len(plan.moves) != 1 || plan.moves[0].ID != 4 || plan.moves[0].To != (OrderRule{Order: 2, Rank: 7}) {
+		t.Errorf("expected a single move of rule 4 to order 2, got %+v", plan.moves)
+	}
+}
+
+func TestPlanRuleOrder_ShiftedRulesAreMovedOnce(t *testing.T) {
+	// Reversing three managed rules: after rule 3 moves to order 1 the
+	// API shifts rules 1 and 2 down, so rule 2 must still be moved but
+	// rule 1 ends up at order 3 without an update of its own.
+	current := map[int]OrderRule{
+		1: {Order: 1, Rank: 7},
+		2: {Order: 2, Rank: 7},
+		3: {Order: 3, Rank: 7},
+	}
+	desired := map[int]OrderRule{
+		1: {Order: 3, Rank: 7},
+		2: {Order: 2, Rank: 7},
+		3: {Order: 1, Rank: 7},
+	}
+	plan := planRuleOrder(current, desired)
+
+	var moved []int
+	for _, m := range plan.moves {
+		moved = append(moved, m.ID)
+	}
+	if len(moved) != 2 || moved[0] != 3 || moved[1] != 2 {
+		t.Errorf("expected moves for rules [3 2] in target order, got %v", moved)
+	}
+}
+
+func TestPlanRuleOrder_DeferredAndConflicts(t *testing.T) {
+	current := map[int]OrderRule{
+		1: {Order: 1, Rank: 7},
+		2: {Order: 2, Rank: 7},
+		3: {Order: 3, Rank: 7},
+	}
+	desired := map[int]OrderRule{
+		2: {Order: 1, Rank: 7},
+		3: {Order: 1, Rank: 7},  // duplicate of rule 2
+		9: {Order: 2, Rank: 7},  // not visible in the API yet
+		1: {Order: 10, Rank: 7}, // beyond the orderable range
+	}
+	plan := planRuleOrder(current, desired)
+
+	if len(plan.deferred) != 2 || plan.deferred[0] != 1 || plan.deferred[1] != 9 {
+		t.Errorf("expected rules [1 9] deferred, got %v", plan.deferred)
+	}
+	if reason, ok := plan.conflicts[3]; !ok || !strings.Contains(reason, "rule 2") {
+		t.Errorf("expected rule 3 to conflict with rule 2, got %q", reason)
+	}
+	if _, ok := plan.conflicts[2]; ok {
+		t.Error("the lowest rule ID should keep a duplicated order")
+	}
+}
+
 // =====================================================
 // Registration & Done Logic Tests
 // =====================================================
@@ -189,8 +270,8 @@ func TestMarkOrderRuleAsDone(t *testing.T) {
 
 func TestReorderWithBeforeReorder_FirstRule_RegistersWithDoneFalse(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 100 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 100 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := newFakeAPI()
 	api.preSeed(100, 999, 7)
@@ -216,7 +297,7 @@ func TestReorderWithBeforeReorder_FirstRule_RegistersWithDoneFalse(t *testing.T)
 	}
 
 	markOrderRuleAsDone(100, "test_reg")
-	waitForReorder("test_reg")
+	waitForReorder("test_reg", 100)
 }
 
 // =====================================================
@@ -225,12 +306,12 @@ func TestReorderWithBeforeReorder_FirstRule_RegistersWithDoneFalse(t *testing.T)
 
 func TestReorder_AllRulesRegisteredBeforeTick(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 100 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 100 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := newFakeAPI()
 	// Each rule already exists in API at a "wrong" current position
-	// (Order=999) so reorderAll will need to PUT it to its desired
+	// (Order=999) so the engine will need to PUT it to its desired
 	// 1..5 position.
 	for i := 1; i <= 5; i++ {
 		api.preSeed(100+i, 999, 7)
@@ -246,7 +327,7 @@ func TestReorder_AllRulesRegisteredBeforeTick(t *testing.T) {
 		markOrderRuleAsDone(100+i, "test_all_before")
 	}
 
-	waitForReorder("test_all_before")
+	waitForReorder("test_all_before", 105)
 
 	for i := 1; i <= 5; i++ {
 		got := api.putsFor(100 + i)
@@ -258,8 +339,8 @@ func TestReorder_AllRulesRegisteredBeforeTick(t *testing.T) {
 
 func TestReorder_LateArrivingRules_NewCycleStarted(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 100 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 100 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := newFakeAPI()
 	api.preSeed(101, 999, 7)
@@ -269,7 +350,7 @@ func TestReorder_LateArrivingRules_NewCycleStarted(t *testing.T) {
 	reorderWithBeforeReorder(OrderRule{Order: 2, Rank: 7}, 102, "test_late", api.getCurrent, api.updateOrder, nil)
 	markOrderRuleAsDone(101, "test_late")
 	markOrderRuleAsDone(102, "test_late")
-	waitForReorder("test_late")
+	waitForReorder("test_late", 102)
 
 	if api.putsFor(101) != 1 || api.putsFor(102) != 1 {
 		t.Fatalf("first cycle: each rule should PUT exactly once; got 101=%d 102=%d", api.putsFor(101), api.putsFor(102))
@@ -286,7 +367,7 @@ func TestReorder_LateArrivingRules_NewCycleStarted(t *testing.T) {
 	markOrderRuleAsDone(103, "test_late")
 	markOrderRuleAsDone(104, "test_late")
 	markOrderRuleAsDone(105, "test_late")
-	waitForReorder("test_late")
+	waitForReorder("test_late", 105)
 
 	for _, id := range []int{103, 104, 105} {
 		if api.putsFor(id) != 1 {
@@ -305,8 +386,8 @@ func TestReorder_LateArrivingRules_NewCycleStarted(t *testing.T) {
 
 func TestReorder_MultipleResourceTypes_Independent(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 100 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 100 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	dnsAPI := newFakeAPI()
 	sslAPI := newFakeAPI()
@@ -327,8 +408,8 @@ func TestReorder_MultipleResourceTypes_Independent(t *testing.T) {
 
 	var wg sync.WaitGroup
 	wg.Add(2)
-	go func() { waitForReorder("dns_test"); wg.Done() }()
-	go func() { waitForReorder("ssl_test"); wg.Done() }()
+	go func() { waitForReorder("dns_test", 202); wg.Done() }()
+	go func() { waitForReorder("ssl_test", 302); wg.Done() }()
 	wg.Wait()
 
 	if dnsAPI.putsFor(201) != 1 || dnsAPI.putsFor(202) != 1 {
@@ -341,8 +422,8 @@ func TestReorder_MultipleResourceTypes_Independent(t *testing.T) {
 
 func TestReorder_ReadMustHappenAfterWaitForReorder(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 100 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 100 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := newFakeAPI()
 	api.preSeed(100, 999, 7)
@@ -373,7 +454,7 @@ func TestReorder_ReadMustHappenAfterWaitForReorder(t *testing.T) {
 	)
 
 	markOrderRuleAsDone(100, "test_read_order")
-	waitForReorder("test_read_order")
+	waitForReorder("test_read_order", 100)
 	simulateRead()
 
 	mu.Lock()
@@ -388,8 +469,8 @@ func TestReorder_ReadMustHappenAfterWaitForReorder(t *testing.T) {
 
 func TestReorder_SequentialRulesSimulateUpdate(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 100 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 100 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := newFakeAPI()
 
@@ -403,7 +484,7 @@ func TestReorder_SequentialRulesSimulateUpdate(t *testing.T) {
 		)
 
 		markOrderRuleAsDone(ruleID, "test_sequential_update")
-		waitForReorder("test_sequential_update")
+		waitForReorder("test_sequential_update", ruleID)
 
 		if api.putsFor(ruleID) != 1 {
 			t.Errorf("rule %d: expected 1 PUT, got %d", ruleID, api.putsFor(ruleID))
@@ -413,8 +494,8 @@ func TestReorder_SequentialRulesSimulateUpdate(t *testing.T) {
 
 func TestReorder_ConcurrentRegistration(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 100 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 100 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := newFakeAPI()
 	for i := 1; i <= 5; i++ {
@@ -432,7 +513,7 @@ func TestReorder_ConcurrentRegistration(t *testing.T) {
 			)
 			time.Sleep(50 * time.Millisecond)
 			markOrderRuleAsDone(400+idx, "test_concurrent")
-			waitForReorder("test_concurrent")
+			waitForReorder("test_concurrent", 400+idx)
 		}(i)
 	}
 	wg.Wait()
@@ -450,12 +531,12 @@ func TestReorder_ConcurrentRegistration(t *testing.T) {
 
 // TestReorder_SkipsRulesAlreadyAtTargetOrder is the "no work to do"
 // case the user explicitly asked for. Every registered rule's API
-// state already matches its desired Order/Rank, so reorderAll MUST
+// state already matches its desired Order/Rank, so the engine MUST
 // issue zero PUTs — no GET-then-PUT, no waste.
 func TestReorder_SkipsRulesAlreadyAtTargetOrder(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 50 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 50 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := newFakeAPI()
 	// Pre-seed each rule already at its target order.
@@ -472,7 +553,7 @@ func TestReorder_SkipsRulesAlreadyAtTargetOrder(t *testing.T) {
 	for i := 1; i <= 10; i++ {
 		markOrderRuleAsDone(800+i, "test_no_op")
 	}
-	waitForReorder("test_no_op")
+	waitForReorder("test_no_op", 810)
 
 	if api.putsTotal() != 0 {
 		t.Fatalf("expected ZERO PUTs when every rule is already at its target order; got %d", api.putsTotal())
@@ -484,8 +565,8 @@ func TestReorder_SkipsRulesAlreadyAtTargetOrder(t *testing.T) {
 // the 2 drifted rules should be PUT.
 func TestReorder_OnlyDriftedRulesArePut(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 50 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 50 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := newFakeAPI()
 	for i := 1; i <= 10; i++ {
@@ -504,7 +585,7 @@ func TestReorder_OnlyDriftedRulesArePut(t *testing.T) {
 	for i := 1; i <= 10; i++ {
 		markOrderRuleAsDone(900+i, "test_partial_drift")
 	}
-	waitForReorder("test_partial_drift")
+	waitForReorder("test_partial_drift", 910)
 
 	if api.putsFor(903) != 1 {
 		t.Errorf("drifted rule 903: expected 1 PUT, got %d", api.putsFor(903))
@@ -526,9 +607,9 @@ func TestReorder_OnlyDriftedRulesArePut(t *testing.T) {
 //
 //   - A rule declares Order=10 but the API only has 9 orderable
 //     positions when the rule's reorder pass runs.
-//   - reorderAll must NOT issue a PUT for order=10 (API would reject
+//   - the engine must NOT issue a PUT for order=10 (API would reject
 //     with INVALID_INPUT_ARGUMENT).
-//   - Instead, reorderAll bails so waitForReorder unblocks and the
+//   - Instead, the cycle defers it so waitForReorder unblocks and the
 //     terraform engine can schedule the next batch of POSTs.
 //   - The next rule's registration (which extends apiOrderable) starts
 //     a fresh reorder cycle that PUTs the previously deferred rule.
@@ -538,8 +619,8 @@ func TestReorder_OnlyDriftedRulesArePut(t *testing.T) {
 // the next batch's first Create kicks off a new cycle.
 func TestReorder_DefersOutOfRangePut(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 50 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 50 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := newFakeAPI()
 	// 9 filler rules already in API at orders 1..9, plus our test
@@ -556,10 +637,12 @@ func TestReorder_DefersOutOfRangePut(t *testing.T) {
 	)
 	markOrderRuleAsDone(600, "test_out_of_range")
 
-	// Cycle 1: rule 600 is deferred (desired=10 > count=9). After
-	// maxStuckOnSkippedTicks (2 ticks * 50ms ≈ 100ms), reorderAll
-	// bails so terraform can schedule the next batch.
-	waitForReorder("test_out_of_range")
+	// Cycle 1: rule 600 is deferred (desired=10 > count=9). No other
+	// rule is waiting, so it is the last cycle and the rule is reported.
+	diags := waitForReorder("test_out_of_range", 600)
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "declared order 10 is outside 1..9") {
+		t.Fatalf("cycle 1: expected the out-of-range order reported on rule 600, got %+v", diags)
+	}
 
 	if api.putsFor(600) != 0 {
 		t.Fatalf("cycle 1: expected ZERO PUTs while desired (10) > count (9); got %d", api.putsFor(600))
@@ -577,21 +660,88 @@ func TestReorder_DefersOutOfRangePut(t *testing.T) {
 	// Bump count again so 601's desired=11 is also in range.
 	api.preSeed(50000+11, 11, 7)
 	markOrderRuleAsDone(601, "test_out_of_range")
-	waitForReorder("test_out_of_range")
+	waitForReorder("test_out_of_range", 601)
 
 	if api.putsFor(600) < 1 {
 		t.Fatalf("cycle 2: expected at least one PUT for the previously deferred rule after count caught up; got %d", api.putsFor(600))
 	}
 }
 
+// TestReorder_DeferredRuleWaitsForNextCycle registers a rule while the
+// cycle deferring another one is running. The deferred rule's resource
+// waits on the next cycle, which places it, and gets no diagnostic.
+func TestReorder_DeferredRuleWaitsForNextCycle(t *testing.T) {
+	resetReorderState()
+	reorderSettleInterval = 50 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
+
+	api := newFakeAPI()
+	for i := 1; i <= 9; i++ {
+		api.preSeed(51000+i, i, 7)
+	}
+	api.preSeed(610, 10, 7)
+
+	var once sync.Once
+	getCurrent := func() (map[int]OrderRule, error) {
+		current, err := api.getCurrent()
+		once.Do(func() {
+			// The next rule of the apply is created while cycle 1 runs.
+			api.preSeed(611, 11, 7)
+			reorderWithBeforeReorder(OrderRule{Order: 10, Rank: 7}, 611, "test_deferred_wait", api.getCurrent, api.updateOrder, nil)
+			markOrderRuleAsDone(611, "test_deferred_wait")
+		})
+		return current, err
+	}
+	reorderWithBeforeReorder(OrderRule{Order: 11, Rank: 7}, 610, "test_deferred_wait", getCurrent, api.updateOrder, nil)
+	markOrderRuleAsDone(610, "test_deferred_wait")
+
+	if diags := waitForReorder("test_deferred_wait", 610); diags.HasError() {
+		t.Fatalf("expected the deferred rule placed by the next cycle, got %+v", diags)
+	}
+	api.mu.Lock()
+	got := api.state[610]
+	api.mu.Unlock()
+	if got.Order != 11 {
+		t.Errorf("expected rule 610 at order 11 once its wait returns, got %d", got.Order)
+	}
+}
+
+// TestReorder_EarlierCycleFailureNotReportedOnOtherRules checks that a rule
+// from an earlier cycle that a later cycle cannot place is not reported on
+// an unrelated rule waiting on that later cycle.
+func TestReorder_EarlierCycleFailureNotReportedOnOtherRules(t *testing.T) {
+	resetReorderState()
+	reorderSettleInterval = 50 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
+
+	api := newFakeAPI()
+	api.preSeed(620, 1, 7)
+	reorderWithBeforeReorder(OrderRule{Order: 1, Rank: 7}, 620, "test_earlier_cycle", api.getCurrent, api.updateOrder, nil)
+	markOrderRuleAsDone(620, "test_earlier_cycle")
+	if diags := waitForReorder("test_earlier_cycle", 620); diags.HasError() {
+		t.Fatalf("cycle 1: unexpected diagnostics %+v", diags)
+	}
+
+	// Rule 620 disappears from the API; cycle 2 cannot place it.
+	api.mu.Lock()
+	delete(api.state, 620)
+	api.mu.Unlock()
+	api.preSeed(621, 1, 7)
+	reorderWithBeforeReorder(OrderRule{Order: 1, Rank: 7}, 621, "test_earlier_cycle", api.getCurrent, api.updateOrder, nil)
+	markOrderRuleAsDone(621, "test_earlier_cycle")
+	if diags := waitForReorder("test_earlier_cycle", 621); diags.HasError() {
+		t.Errorf("cycle 2: rule 620's failure must not be reported on rule 621, got %+v", diags)
+	}
+}
+
 // TestReorder_NoProgress_GivesUp ensures the reorder loop bails out
 // instead of looping forever when the configuration can never
 // converge (e.g. declared Order=100 but only 1 orderable rule will
 // ever exist).
 func TestReorder_NoProgress_GivesUp(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 20 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 20 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := newFakeAPI()
 	api.preSeed(70000, 1, 7) // single orderable rule, count stays at 1.
@@ -605,14 +755,14 @@ func TestReorder_NoProgress_GivesUp(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		waitForReorder("test_no_progress")
+		waitForReorder("test_no_progress", 700)
 		close(done)
 	}()
 
 	select {
 	case <-done:
 	case <-time.After(2 * time.Second):
-		t.Fatal("reorderAll did not give up on no-progress within 2s — it would loop forever")
+		t.Fatal("the order engine did not finish within 2s — it would loop forever")
 	}
 
 	if api.putsFor(700) != 0 {
@@ -621,15 +771,14 @@ func TestReorder_NoProgress_GivesUp(t *testing.T) {
 }
 
 // =====================================================
-// Oscillation breaker (SUP-4231)
+// Unsatisfiable orders (SUP-4231)
 // =====================================================
 
 // pingPongAPI simulates unsatisfiable order data: every successful update
 // of a rule displaces a designated neighbour by one position, exactly like
 // the insert-shift behaviour observed when duplicate order values or
 // unmanaged interleaved rules make the declared orders unachievable. Two
-// mutually-displacing rules never settle, so without the oscillation
-// breaker the reorder loop runs forever.
+// mutually-displacing rules never settle.
 type pingPongAPI struct {
 	fakeAPI
 	displaces map[int]int // updating key displaces value by +1
@@ -648,16 +797,15 @@ func (p *pingPongAPI) updateOrder(id int, ord OrderRule) error {
 	return nil
 }
 
-// TestReorder_OscillationBreaker_ExitsBoundedTime reproduces the SUP-4231
+// TestReorder_Unsatisfiable_ReportsDiagnostic reproduces the SUP-4231
 // livelock in miniature: rules 901 and 902 displace each other on every
-// update, so every pass issues PUTs and no pass ever converges. The loop
-// must exit in bounded time via the oscillation breaker instead of running
-// until an external deadline kills it. Before the breaker existed, this
-// test hung until the safety timeout.
-func TestReorder_OscillationBreaker_ExitsBoundedTime(t *testing.T) {
+// update, so no pass ever converges. The cycle must stop after
+// maxReorderPasses and report the rules it could not place as error
+// diagnostics instead of a log line.
+func TestReorder_Unsatisfiable_ReportsDiagnostic(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 50 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 50 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := &pingPongAPI{fakeAPI: *newFakeAPI(), displaces: map[int]int{901: 902, 902: 901}}
 	// Unregistered filler rules so the orderable count covers the
@@ -673,36 +821,44 @@ func TestReorder_OscillationBreaker_ExitsBoundedTime(t *testing.T) {
 	markOrderRuleAsDone(901, "test_oscillation")
 	markOrderRuleAsDone(902, "test_oscillation")
 
+	var diags901, diags902 diag.Diagnostics
 	done := make(chan struct{})
 	go func() {
-		waitForReorder("test_oscillation")
+		diags901 = waitForReorder("test_oscillation", 901)
+		diags902 = waitForReorder("test_oscillation", 902)
 		close(done)
 	}()
 	select {
 	case <-done:
 	case <-time.After(30 * time.Second):
-		t.Fatal("oscillating reorder did not exit in bounded time — the oscillation breaker did not fire")
+		t.Fatal("oscillating reorder did not exit in bounded time")
 	}
 
-	// The livelock genuinely happened: the rules were re-PUT across at
-	// least maxNonImprovingPutTicks passes before the breaker gave up.
-	if api.putsTotal() < maxNonImprovingPutTicks {
-		t.Errorf("expected sustained oscillation before the breaker fired; got %d total puts (901=%d 902=%d)", api.putsTotal(), api.putsFor(901), api.putsFor(902))
+	// Every pass re-issues updates; the cycle stops after the bound.
+	if got := api.putsTotal(); got < maxReorderPasses || got > 2*maxReorderPasses {
+		t.Errorf("expected between %d and %d updates over %d passes; got %d (901=%d 902=%d)", maxReorderPasses, 2*maxReorderPasses, maxReorderPasses, got, api.putsFor(901), api.putsFor(902))
 	}
-	// And the exit came from the breaker, not from convergence: at least
-	// one rule is still off target.
 	api.mu.Lock()
 	converged := api.state[901] == (OrderRule{Order: 5, Rank: 7}) && api.state[902] == (OrderRule{Order: 6, Rank: 7})
 	api.mu.Unlock()
 	if converged {
-		t.Error("expected the ping-pong to be unresolvable; both rules at target means the fake did not oscillate")
+		t.Fatal("expected the ping-pong to be unresolvable; both rules at target means the fake did not oscillate")
+	}
+	// Whichever rule was left off target is reported on its own resource.
+	if !diags901.HasError() && !diags902.HasError() {
+		t.Fatal("expected an error diagnostic for the rule left off target")
+	}
+	for _, d := range append(diags901, diags902...) {
+		if !strings.Contains(d.Summary, "test_oscillation rule 90") {
+			t.Errorf("diagnostic summary should name the rule, got %q", d.Summary)
+		}
 	}
 }
 
 // transientDisplacementAPI displaces a victim rule a limited number of
 // times and then behaves normally — modelling ordinary insert-shift noise
-// during a converging apply. The breaker must NOT fire for this case; the
-// loop must fully converge exactly as it does today.
+// during a converging apply. The extra passes must absorb it and the
+// cycle must fully converge without reporting anything.
 type transientDisplacementAPI struct {
 	fakeAPI
 	displacer     int
@@ -726,8 +882,8 @@ func (a *transientDisplacementAPI) updateOrder(id int, ord OrderRule) error {
 
 func TestReorder_TransientDisplacement_StillConverges(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 50 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 50 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	api := &transientDisplacementAPI{fakeAPI: *newFakeAPI(), displacer: 912, victim: 911, displacements: 2}
 	for i := 1; i <= 5; i++ {
@@ -741,9 +897,10 @@ func TestReorder_TransientDisplacement_StillConverges(t *testing.T) {
 	markOrderRuleAsDone(911, "test_transient")
 	markOrderRuleAsDone(912, "test_transient")
 
+	var diags diag.Diagnostics
 	done := make(chan struct{})
 	go func() {
-		waitForReorder("test_transient")
+		diags = waitForReorder("test_transient", 912)
 		close(done)
 	}()
 	select {
@@ -752,15 +909,18 @@ func TestReorder_TransientDisplacement_StillConverges(t *testing.T) {
 		t.Fatal("transient displacement did not converge in bounded time")
 	}
 
-	// Full convergence — the breaker must not have cut this run short.
+	if diags.HasError() {
+		t.Errorf("expected no diagnostics for a converging run, got %+v", diags)
+	}
+	// Full convergence — the pass bound must not have cut this run short.
 	api.mu.Lock()
 	got911, got912 := api.state[911], api.state[912]
 	api.mu.Unlock()
 	if got911 != (OrderRule{Order: 1, Rank: 7}) {
-		t.Errorf("rule 911: expected converged order {1 7}, got %+v — the oscillation breaker fired on a converging run", got911)
+		t.Errorf("rule 911: expected converged order {1 7}, got %+v — the pass bound cut a converging run short", got911)
 	}
 	if got912 != (OrderRule{Order: 2, Rank: 7}) {
-		t.Errorf("rule 912: expected converged order {2 7}, got %+v — the oscillation breaker fired on a converging run", got912)
+		t.Errorf("rule 912: expected converged order {2 7}, got %+v — the pass bound cut a converging run short", got912)
 	}
 }
 
@@ -795,8 +955,8 @@ func TestCloudAppRuleResourceType_DistinctKeysPerType(t *testing.T) {
 // drifted rule and no cross-type interference.
 func TestReorder_CloudAppControl_PerTypeIsolation(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 50 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 50 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	collabAPI := newFakeAPI()  // ENTERPRISE_COLLABORATION endpoint
 	hostingAPI := newFakeAPI() // HOSTING_PROVIDER endpoint
@@ -821,7 +981,7 @@ func TestReorder_CloudAppControl_PerTypeIsolation(t *testing.T) {
 				collabAPI.getCurrent, collabAPI.updateOrder, nil,
 			)
 			markOrderRuleAsDone(1000+idx, collabKey)
-			waitForReorder(collabKey)
+			waitForReorder(collabKey, 1000+idx)
 		}(i)
 		go func(idx int) {
 			defer wg.Done()
@@ -830,7 +990,7 @@ func TestReorder_CloudAppControl_PerTypeIsolation(t *testing.T) {
 				hostingAPI.getCurrent, hostingAPI.updateOrder, nil,
 			)
 			markOrderRuleAsDone(2000+idx, hostingKey)
-			waitForReorder(hostingKey)
+			waitForReorder(hostingKey, 2000+idx)
 		}(i)
 	}
 	wg.Wait()
@@ -869,8 +1029,8 @@ func TestReorder_CloudAppControl_PerTypeIsolation(t *testing.T) {
 // get — do not wire the resource this way.
 func TestReorder_CloudAppControl_SharedKeyMixesTypes_Characterization(t *testing.T) {
 	resetReorderState()
-	reorderTickInterval = 50 * time.Millisecond
-	defer func() { reorderTickInterval = 30 * time.Second }()
+	reorderSettleInterval = 50 * time.Millisecond
+	defer func() { reorderSettleInterval = 5 * time.Second }()
 
 	hostingAPI := newFakeAPI()
 	sharedKey := "test_shared_cac_key"
@@ -901,7 +1061,7 @@ func TestReorder_CloudAppControl_SharedKeyMixesTypes_Characterization(t *testing
 
 	done := make(chan struct{})
 	go func() {
-		waitForReorder(sharedKey)
+		waitForReorder(sharedKey, 2005)
 		close(done)
 	}()
 	select {
@@ -0,0 +1,71 @@
+package zia
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRuleIDBlocksV0_UpgradesState(t *testing.T) {
+	r := resourceURLFilteringRules()
+	if r.SchemaVersion != 1 || len(r.StateUpgraders) != 1 || r.StateUpgraders[0].Version != 0 {
+		t.Fatalf("expected one upgrader from version 0, got version %d with %d upgraders", r.SchemaVersion, len(r.StateUpgraders))
+	}
+
+	// The state as the version 0 schema stored it, decoded from JSON.
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"id": "1042",
+		"name": "Block Gambling",
+		"locations": [{"id": [101, 102]}],
+		"groups": [],
+		"departments": [{"id": [7]}]
+	}`), &raw); err != nil {
+		t.Fatal(err)
+	}
+	upgraded, err := r.StateUpgraders[0].Upgrade(context.Background(), raw, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"id":           "1042",
+		"name":         "Block Gambling",
+		"location_ids": []interface{}{101.0, 102.0},
+		"group_ids":    []interface{}{},
+		"departments":  []interface{}{map[string]interface{}{"id": []interface{}{7.0}}},
+	}
+	if !reflect.DeepEqual(upgraded, want) {
+		t.Errorf("got %#v, want %#v", upgraded, want)
+	}
+
+	// The upgraded state decodes with the current schema.
+	if _, err := schema.JSONMapToStateValue(upgraded, r.CoreConfigSchema()); err != nil {
+		t.Errorf("upgraded state does not match the current schema: %v", err)
+	}
+}
+
+func TestRuleIDBlocksV0_PastSchema(t *testing.T) {
+	for name, r := range ZIAProvider().ResourcesMap {
+		if r.SchemaVersion == 0 {
+			continue
+		}
+		past := r.StateUpgraders[0].Type
+		for block, list := range map[string]string{"locations": "location_ids", "groups": "group_ids"} {
+			if _, ok := r.Schema[list]; !ok {
+				continue
+			}
+			if !past.HasAttribute(block) || past.HasAttribute(list) {
+				t.Errorf("%s: expected the version 0 type to have %s instead of %s", name, block, list)
+				continue
+			}
+			want := cty.Set(cty.Object(map[string]cty.Type{"id": cty.Set(cty.Number)}))
+			if got := past.AttributeType(block); !got.Equals(want) {
+				t.Errorf("%s: expected %s to be %#v, got %#v", name, block, want, got)
+			}
+		}
+	}
+}
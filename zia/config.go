@@ -1,6 +1,9 @@
 package zia
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,7 +16,6 @@ import (
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/zscaler/terraform-provider-zia/v4/zia/common"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia"
@@ -43,10 +45,30 @@ type (
 		// receive an inert *Client and fail with a descriptive error if they
 		// attempt an API call.
 		skipCredentialsValidation bool
-		zscalerSDKClientV3        *zscaler.Client
-		logger                    hclog.Logger
-		TerraformVersion          string // New field for Terraform version
-		ProviderVersion           string // New field for Provider version
+		// activationMode is either activationModePerResource or
+		// activationModeEndOfApply; see deferredActivation.
+		activationMode        string
+		activationQuietPeriod int
+		// readCache serves resource Reads from per-type GetAll snapshots;
+		// see readCache.
+		readCache bool
+		// tuning holds the client_tuning settings of the SDK clients.
+		tuning clientTuning
+		// tenantLock holds the tenant_lock settings; see tenantLock.
+		tenantLock tenantLockSettings
+		// editLock holds the edit_lock settings; see editLockTransport.
+		editLock editLockSettings
+		// journalPath names the file API writes are journaled to; see
+		// journal. journal is opened from it when the Client is built.
+		journalPath        string
+		journal            *journal
+		zscalerSDKClientV3 *zscaler.Client
+		// oneAPIConfig is the OneAPI configuration the V3 client was built
+		// from; nil for the legacy and sandbox-only clients.
+		oneAPIConfig     *zscaler.Configuration
+		logger           hclog.Logger
+		TerraformVersion string // New field for Terraform version
+		ProviderVersion  string // New field for Provider version
 
 		// Options for Legacy V2 SDK
 		Username   string
@@ -54,9 +76,17 @@ type (
 		APIKey     string
 		ZIABaseURL string
 		UserAgent  string
+		// testingBaseURL replaces the https://zsapi.<cloud>.net base URL of
+		// the legacy client. It is copied from fakeAPIBaseURL.
+		testingBaseURL string
 	}
 )
 
+// fakeAPIBaseURL points the legacy client at a fakezia server. Only tests set
+// it; it is never read from the environment, so the credentials of a real
+// run cannot be sent anywhere but the Zscaler cloud.
+var fakeAPIBaseURL string
+
 type Client struct {
 	Service *zscaler.Service
 	// skipCredentialsValidation marks this client as inert: the provider was
@@ -64,9 +94,37 @@ type Client struct {
 	// resource/data source CRUD function is wrapped (see ZIAProvider) to
 	// return a descriptive error instead of dereferencing the nil Service.
 	skipCredentialsValidation bool
+	// activation is set when the provider defers activation to the end of
+	// the apply. It is nil in the default per-resource mode.
+	activation *deferredActivation
+	// oneAPIConfig lets ephemeral resources mint OneAPI tokens with the
+	// provider credentials. It is nil unless OneAPI credentials are in use.
+	oneAPIConfig *zscaler.Configuration
+	// ruleOrders is the plan registry of the declared rule orders (see
+	// validateRuleOrder).
+	ruleOrders plannedRuleOrders
+	// readCache is nil unless the provider was configured with read_cache.
+	readCache *readCache
+	// tenantLock is nil unless the provider was configured with tenant_lock.
+	tenantLock *tenantLock
+	// editLock is the edit_lock policy the edit lock diagnostics describe.
+	editLock editLockSettings
+	// journal is nil unless the provider was configured with journal_path.
+	journal *journal
+	// configKey is the Config.clientKey of the configuration the client was
+	// built from.
+	configKey string
+}
+
+// configValues is the part of *schema.ResourceData that NewConfig reads. The
+// plugin framework half of the provider implements it over its own
+// configuration so both halves share the same defaults and env fallbacks.
+type configValues interface {
+	GetOk(key string) (interface{}, bool)
+	Get(key string) interface{}
 }
 
-func NewConfig(d *schema.ResourceData) *Config {
+func NewConfig(d configValues) *Config {
 	// defaults
 	config := Config{
 		backoff:        true,
@@ -75,6 +133,9 @@ func NewConfig(d *schema.ResourceData) *Config {
 		retryCount:     100, // Deliberately above the SDK default so bulk applies survive sustained rate limiting.
 		logLevel:       int(hclog.Error),
 		requestTimeout: 1800, // 30 minutes - needed for GetAll() with 1000s of firewall rules
+
+		activationMode:        activationModePerResource,
+		activationQuietPeriod: defaultActivationQuietPeriod,
 	}
 	logLevel := hclog.Level(config.logLevel)
 	if os.Getenv("TF_LOG") != "" {
@@ -97,6 +158,36 @@ func NewConfig(d *schema.ResourceData) *Config {
 		config.skipCredentialsValidation = strings.ToLower(os.Getenv("ZSCALER_SKIP_CREDENTIALS_VALIDATION")) == "true"
 	}
 
+	if val, ok := d.GetOk("activation"); ok {
+		if blocks := val.([]interface{}); len(blocks) > 0 && blocks[0] != nil {
+			activationBlock := blocks[0].(map[string]interface{})
+			if mode, ok := activationBlock["mode"].(string); ok && mode != "" {
+				config.activationMode = mode
+			}
+			if quiet, ok := activationBlock["quiet_period_seconds"].(int); ok && quiet > 0 {
+				config.activationQuietPeriod = quiet
+			}
+		}
+	} else if os.Getenv("ZSCALER_ACTIVATION_MODE") != "" {
+		config.activationMode = strings.ToLower(os.Getenv("ZSCALER_ACTIVATION_MODE"))
+	}
+
+	if val, ok := d.GetOk("read_cache"); ok {
+		config.readCache = val.(bool)
+	} else if os.Getenv("ZSCALER_READ_CACHE") != "" {
+		config.readCache = strings.ToLower(os.Getenv("ZSCALER_READ_CACHE")) == "true"
+	}
+
+	config.tuning = readClientTuning(d)
+	config.tenantLock = readTenantLockSettings(d)
+	config.editLock = readEditLockSettings(d)
+
+	if val, ok := d.GetOk("journal_path"); ok {
+		config.journalPath = val.(string)
+	} else {
+		config.journalPath = os.Getenv("ZSCALER_JOURNAL_PATH")
+	}
+
 	if val, ok := d.GetOk("client_id"); ok {
 		config.clientID = val.(string)
 	}
@@ -132,6 +223,8 @@ func NewConfig(d *schema.ResourceData) *Config {
 		config.cloud = os.Getenv("ZSCALER_CLOUD")
 	}
 
+	config.testingBaseURL = fakeAPIBaseURL
+
 	if val, ok := d.GetOk("sandbox_token"); ok {
 		config.sandboxToken = val.(string)
 	}
@@ -222,6 +315,19 @@ func NewConfig(d *schema.ResourceData) *Config {
 	return &config
 }
 
+// clientKey identifies the settings a Client is built from. Secrets are only
+// hashed into the key, never kept in it.
+func (c *Config) clientKey() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q|%q|%q|%q|%q|%q|%q|%q|%q|%q|%q|%q|%q|%q|%d|%t|%d|%d|%d|%d|%t|%q|%d|%t|%+v|%+v|%+v|%q",
+		c.clientID, c.clientSecret, c.privateKey, c.vanityDomain, c.cloud,
+		c.sandboxToken, c.sandboxCloud, c.httpProxy,
+		c.Username, c.Password, c.APIKey, c.ZIABaseURL, c.testingBaseURL, c.TerraformVersion,
+		c.retryCount, c.backoff, c.minWait, c.maxWait, c.logLevel, c.requestTimeout,
+		c.useLegacyClient, c.activationMode, c.activationQuietPeriod, c.readCache, c.tuning, c.tenantLock, c.editLock, c.journalPath)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // loadClients initializes SDK clients based on configuration
 func (c *Config) loadClients() diag.Diagnostics {
 	if c.useLegacyClient {
@@ -266,19 +372,59 @@ func generateUserAgent(terraformVersion string) string {
 	)
 }
 
+// wrapHTTPTransport, when set, wraps the transport of every SDK HTTP client
+// the provider builds. Acceptance tests set it to record and replay API
+// traffic with the cassette package.
+var wrapHTTPTransport func(http.RoundTripper) http.RoundTripper
+
+// wrapHTTPClient returns a copy of client whose transport is wrapped by
+// wrapHTTPTransport, or client itself when no wrapper is set.
+func wrapHTTPClient(client *http.Client) *http.Client {
+	if wrapHTTPTransport == nil || client == nil {
+		return client
+	}
+	wrapped := *client
+	wrapped.Transport = wrapHTTPTransport(client.Transport)
+	return &wrapped
+}
+
+// tuneHTTPClient applies client_tuning, the edit_lock policy,
+// wrapHTTPTransport and the journal to an SDK HTTP client.
+func (c *Config) tuneHTTPClient(client *http.Client) (*http.Client, error) {
+	tuned, err := c.tuning.tuneHTTPClient(client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client_tuning: %v", err)
+	}
+	return c.journal.wrapHTTPClient(wrapHTTPClient(c.editLock.waitHTTPClient(tuned)))
+}
+
+// tunesHTTPClients reports whether tuneHTTPClient changes anything.
+func (c *Config) tunesHTTPClients() bool {
+	return wrapHTTPTransport != nil || c.tuning.tunesTransport() || c.editLock.policy == editLockPolicyWait || c.journal != nil
+}
+
+// tuneHTTPClients applies tuneHTTPClient to the HTTP clients of a OneAPI
+// configuration.
+func (c *Config) tuneHTTPClients(config *zscaler.Configuration) error {
+	for _, client := range []**http.Client{&config.HTTPClient, &config.ZIAHTTPClient} {
+		tuned, err := c.tuneHTTPClient(*client)
+		if err != nil {
+			return err
+		}
+		*client = tuned
+	}
+	return nil
+}
+
 func zscalerSDKV2Client(c *Config) (*zscaler.Service, error) {
 	customUserAgent := generateUserAgent(c.TerraformVersion)
 
 	// Start with base configuration setters
-	setters := []zia.ConfigSetter{
-		zia.WithCache(true),                // Enable caching to reduce API calls
-		zia.WithCacheTtl(10 * time.Minute), // Cache entries for 10 minutes
-		zia.WithCacheTti(8 * time.Minute),  // Idle timeout of 8 minutes
-		zia.WithHttpClientPtr(http.DefaultClient),
+	setters := append(c.tuning.v2Setters(),
 		zia.WithRateLimitMaxRetries(int32(c.retryCount)),
-		zia.WithRequestTimeout(time.Duration(c.requestTimeout) * time.Second),
+		zia.WithRequestTimeout(time.Duration(c.requestTimeout)*time.Second),
 		zia.WithUserAgent(customUserAgent), // Set the custom user agent
-	}
+	)
 
 	// Apply credentials and mandatory parameters
 	setters = append(
@@ -323,6 +469,25 @@ func zscalerSDKV2Client(c *Config) (*zscaler.Service, error) {
 		return nil, fmt.Errorf("failed to create ZIA configuration: %v", err)
 	}
 	ziaCfg.UserAgent = customUserAgent
+	if c.tunesHTTPClients() {
+		// The ZIA configuration is a process-wide singleton; wrap the HTTP
+		// client only for the client built here.
+		base := ziaCfg.HTTPClient
+		tuned, err := c.tuneHTTPClient(base)
+		if err != nil {
+			return nil, err
+		}
+		ziaCfg.HTTPClient = tuned
+		defer func() { ziaCfg.HTTPClient = base }()
+	}
+	if c.testingBaseURL != "" {
+		baseURL, err := url.Parse(c.testingBaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fake API base URL: %v", err)
+		}
+		log.Printf("[INFO] Using ZIA testing base URL %s", baseURL)
+		ziaCfg.BaseURL = baseURL
+	}
 	// Initialize ZIA client
 	wrappedV2Client, err := zscaler.NewLegacyZiaClient(ziaCfg)
 	if err != nil {
@@ -337,17 +502,13 @@ func zscalerSDKV3Client(c *Config) (*zscaler.Client, error) {
 	customUserAgent := generateUserAgent(c.TerraformVersion)
 
 	// Start with base configuration setters
-	setters := []zscaler.ConfigSetter{
-		zscaler.WithCache(true),                // Enable caching to reduce API calls
-		zscaler.WithCacheTtl(10 * time.Minute), // Cache entries for 10 minutes
-		zscaler.WithCacheTti(8 * time.Minute),  // Idle timeout of 8 minutes
-		zscaler.WithHttpClientPtr(http.DefaultClient),
+	setters := append(c.tuning.v3Setters(),
 		zscaler.WithRateLimitMaxRetries(int32(c.retryCount)),
-		zscaler.WithRequestTimeout(time.Duration(c.requestTimeout) * time.Second),
-		zscaler.WithRateLimitMinWait(time.Duration(c.minWait) * time.Second),
-		zscaler.WithRateLimitMaxWait(time.Duration(c.maxWait) * time.Second),
+		zscaler.WithRequestTimeout(time.Duration(c.requestTimeout)*time.Second),
+		zscaler.WithRateLimitMinWait(time.Duration(c.minWait)*time.Second),
+		zscaler.WithRateLimitMaxWait(time.Duration(c.maxWait)*time.Second),
 		zscaler.WithUserAgentExtra(customUserAgent),
-	}
+	)
 
 	// Enable SDK debug logging when Terraform debug logging is enabled
 	tfLog := os.Getenv("TF_LOG")
@@ -397,6 +558,9 @@ func zscalerSDKV3Client(c *Config) (*zscaler.Client, error) {
 		}
 
 		config.UserAgent = customUserAgent
+		if err := c.tuneHTTPClients(config); err != nil {
+			return nil, err
+		}
 
 		// Create Sandbox-only client
 		v3Client, err := zscaler.NewOneAPIClient(config)
@@ -446,17 +610,69 @@ func zscalerSDKV3Client(c *Config) (*zscaler.Client, error) {
 	}
 
 	config.UserAgent = customUserAgent
+	if err := c.tuneHTTPClients(config); err != nil {
+		return nil, err
+	}
 
 	// Initialize the client with the configuration
 	v3Client, err := zscaler.NewOneAPIClient(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Zscaler API client: %v", err)
 	}
+	c.oneAPIConfig = config
 
 	return v3Client.Client, nil
 }
 
 func (c *Config) Client() (*Client, error) {
+	if err := c.editLock.validate(); err != nil {
+		return nil, err
+	}
+	journal, err := openJournal(c.journalPath)
+	if err != nil {
+		return nil, err
+	}
+	if journal != nil {
+		log.Printf("[INFO] Journaling API writes to %s as run %s", journal.path, journal.run)
+		c.journal = journal
+	}
+	client, err := c.newClient()
+	if err != nil {
+		return nil, err
+	}
+	client.editLock = c.editLock
+	client.journal = journal
+	if c.editLock.policy == editLockPolicyWait {
+		log.Printf("[INFO] Waiting up to %ds for the edit lock when an admin holds it", c.editLock.maxWait)
+	}
+	lock, err := c.newTenantLock()
+	if err != nil {
+		return nil, err
+	}
+	if lock != nil {
+		log.Printf("[INFO] Serializing writes to the tenant with the %s tenant lock %s", c.tenantLock.backend, lock.key)
+		client.tenantLock = lock
+	}
+	if c.activationMode == activationModeEndOfApply {
+		log.Printf("[INFO] Configuration activation deferred to the end of the apply (quiet period %ds)", c.activationQuietPeriod)
+		service := client.Service
+		client.activation = newDeferredActivation(time.Duration(c.activationQuietPeriod)*time.Second, func(ctx context.Context) error {
+			if err := activateConfiguration(ctx, service); err != nil {
+				return err
+			}
+			// The changes of the run are live; the next run may start.
+			lock.release(ctx)
+			return nil
+		})
+	}
+	if c.readCache {
+		log.Printf("[INFO] Serving resource reads from per-type list snapshots")
+		client.readCache = newReadCache()
+	}
+	return client, nil
+}
+
+func (c *Config) newClient() (*Client, error) {
 	// Handle Sandbox-only credentials
 	if c.sandboxToken != "" && c.sandboxCloud != "" && c.clientID == "" && c.clientSecret == "" && c.privateKey == "" {
 		v3Client, err := zscalerSDKV3Client(c)
@@ -485,6 +701,7 @@ func (c *Config) Client() (*Client, error) {
 		return nil, fmt.Errorf("failed to initialize v3 client: %w", err)
 	}
 	return &Client{
-		Service: zscaler.NewService(v3Client, nil),
+		Service:      zscaler.NewService(v3Client, nil),
+		oneAPIConfig: c.oneAPIConfig,
 	}, nil
 }
@@ -6,7 +6,7 @@ func TestAccDataSourceAdminRoles_Basic(t *testing.T) {
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckAdminRolesDestroy,
 		Steps: []resource.TestStep{
 			{
@@ -17,7 +17,7 @@ func TestAccDataSourceAdminUsers_Basic(t *testing.T) {
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckAdminUsersDestroy,
 		Steps: []resource.TestStep{
 			{
@@ -15,9 +15,9 @@ func TestAccDataSourceSubscriptionAlerts_Basic(t *testing.T) {
 	initialName := "tf-acc-test-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckSubscriptionAlertsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckSubscriptionAlertsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckSubscriptionAlertsConfigure(resourceTypeAndName, initialName, variable.AlertDescription),
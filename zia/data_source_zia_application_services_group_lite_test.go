@@ -8,8 +8,8 @@ import (
 
 func TestAccDataSourceFWApplicationServicesGroupLite_Basic(t *testing.T) {
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDataSourceFWApplicationServicesGroupLiteConfig_basic,
@@ -10,8 +10,8 @@ func TestAccDataSourceATPMalwareInspection_Basic(t *testing.T) {
 	resourceName := "data.zia_atp_malware_inspection.this"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDataSourceATPMalwareInspectionConfig_basic,
@@ -10,8 +10,8 @@ func TestAccDataSourceATPMalwarePolicy_Basic(t *testing.T) {
 	resourceName := "data.zia_atp_malware_policy.this"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDataSourceATPMalwarePolicyConfig_basic,
@@ -10,8 +10,8 @@ func TestAccDataSourceATPMalwareProtocols_Basic(t *testing.T) {
 	resourceName := "data.zia_atp_malware_protocols.this"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDataSourceATPMalwareProtocolsConfig_basic,
@@ -10,8 +10,8 @@ func TestAccDataSourceATPMalwareSettings_Basic(t *testing.T) {
 	resourceName := "data.zia_atp_malware_settings.this"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDataSourceATPMalwareSettingsConfig_basic,
@@ -12,9 +12,9 @@ func TestAccDataSourceBandwdithClasses_Basic(t *testing.T) {
 	resourceTypeAndName, dataSourceTypeAndName, generatedName := method.GenerateRandomSourcesTypeAndName(resourcetype.BandwdithClasses)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckBandwdithClassesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckBandwdithClassesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckBandwdithClassesConfigure(resourceTypeAndName, generatedName),
@@ -17,9 +17,9 @@ func TestAccDataSourceBandwdithControlRules_Basic(t *testing.T) {
 	ruleLabelHCL := testAccCheckRuleLabelsConfigure(ruleLabelTypeAndName, ruleLabelGeneratedName, variable.RuleLabelDescription)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckBandwdithControlRulesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckBandwdithControlRulesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckBandwdithControlRulesConfigure(resourceTypeAndName, generatedName, generatedName, variable.BandwdithControlRuleDescription, variable.BandwdithControlRulestate, ruleLabelTypeAndName, ruleLabelHCL),
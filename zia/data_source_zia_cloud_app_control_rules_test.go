@@ -17,9 +17,9 @@ func TestAccDataSourceCloudAppControlRules_Basic(t *testing.T) {
 	ruleLabelHCL := testAccCheckRuleLabelsConfigure(ruleLabelTypeAndName, ruleLabelGeneratedName, variable.RuleLabelDescription)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckCloudAppControlRulesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckCloudAppControlRulesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckCloudAppControlRulesConfigure(resourceTypeAndName, generatedName, generatedName, variable.CloudAppControlRuleDescription, variable.CloudAppControlRuleState, ruleLabelTypeAndName, ruleLabelHCL),
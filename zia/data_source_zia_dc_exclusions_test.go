@@ -14,9 +14,9 @@ func TestAccDataSourceDCExclusions_Basic(t *testing.T) {
 	description := variable.DCExclusionsDescription + "-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckDCExclusionsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckDCExclusionsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDCExclusionsConfigure(resourceTypeAndName, description),
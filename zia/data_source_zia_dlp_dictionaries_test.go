@@ -15,9 +15,9 @@ func TestAccDataSourceDLPDictionaries_Basic(t *testing.T) {
 	initialName := "tf-acc-test-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckDLPDictionariesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckDLPDictionariesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDLPDictionariesConfigure(resourceTypeAndName, initialName, variable.DLPDictionaryDescription),
@@ -13,8 +13,8 @@ var idmProfileLiteNames = []string{
 
 func TestAccDataSourceDLPIDMProfileLite_Basic(t *testing.T) {
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDataSourceDLPIDMProfileLite_basic(),
@@ -17,9 +17,9 @@ func TestAccDataSourceDLPNotificationTemplates_Basic(t *testing.T) {
 	resourceTypeAndName, dataSourceTypeAndName, generatedName := method.GenerateRandomSourcesTypeAndName(resourcetype.DLPNotificationTemplates)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckDLPNotificationTemplateDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckDLPNotificationTemplateDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDLPNotificationTemplateConfigure(resourceTypeAndName, generatedName),
@@ -15,9 +15,9 @@ func TestAccDataSourceDNSApplicationGroups_Basic(t *testing.T) {
 	initialName := "tf-acc-test-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckDNSApplicationGroupsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckDNSApplicationGroupsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDNSApplicationGroupsConfigure(resourceTypeAndName, initialName, variable.DNSAppGroupDescription),
@@ -13,9 +13,9 @@ func TestAccDataSourceEmailProfile_Basic(t *testing.T) {
 	resourceTypeAndName, dataSourceTypeAndName, generatedName := method.GenerateRandomSourcesTypeAndName(resourcetype.EmailProfile)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckEmailProfileDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckEmailProfileDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckEmailProfileConfigure(resourceTypeAndName, generatedName, variable.EmailProfileDescription),
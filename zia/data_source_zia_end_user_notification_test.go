@@ -12,8 +12,8 @@ func TestAccDataSourceEndUserNotification_Basic(t *testing.T) {
 	resourceName := "data.zia_end_user_notification.this"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDataSourceEndUserNotificationConfig_basic,
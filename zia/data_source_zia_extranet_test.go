@@ -13,9 +13,9 @@ func TestAccDataSourceExtranet_Basic(t *testing.T) {
 	resourceTypeAndName, dataSourceTypeAndName, generatedName := method.GenerateRandomSourcesTypeAndName(resourcetype.Extranet)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckExtranetDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckExtranetDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckExtranetConfigure(resourceTypeAndName, generatedName, variable.ExtranetDescription),
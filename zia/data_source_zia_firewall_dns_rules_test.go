@@ -21,9 +21,9 @@ func TestAccDataSourceFirewallDNSRules_Basic(t *testing.T) {
 	sourceIPGroupHCL := testAccCheckFWIPSourceGroupsConfigure(sourceIPGroupTypeAndName, sourceIPGroupGeneratedName, variable.FWSRCGroupDescription)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckRuleLabelsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckRuleLabelsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckFirewallDNSRulesConfigure(
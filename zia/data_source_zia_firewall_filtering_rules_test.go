@@ -28,7 +28,7 @@ func TestAccDataSourceFirewallFilteringRule_Basic(t *testing.T) {
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckFirewallFilteringRuleDestroy,
 		Steps: []resource.TestStep{
 			{
@@ -26,9 +26,9 @@ func TestAccDataSourceFirewallIPSRules_Basic(t *testing.T) {
 	dstIPGroupHCL := testAccCheckFWIPDestinationGroupsConfigure(dstIPGroupTypeAndName, dstIPGroupGeneratedName, variable.FWDSTGroupDescription, variable.FWDSTGroupTypeDSTNFQDN)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckFirewallIPSRulesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckFirewallIPSRulesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckFirewallIPSRulesConfigure(
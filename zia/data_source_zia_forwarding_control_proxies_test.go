@@ -14,9 +14,9 @@ func TestAccDataSourceForwardingControlProxies_Basic(t *testing.T) {
 	resourceTypeAndName, dataSourceTypeAndName, generatedName := method.GenerateRandomSourcesTypeAndName(resourcetype.ForwardingControlProxies)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckForwardingControlProxiesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckForwardingControlProxiesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckForwardingControlProxiesConfigure(resourceTypeAndName, generatedName, variable.ProxyDescription, variable.ProxyType, variable.ProxyAddress, variable.ProxyPort, variable.ProxyInsertXauHeader, variable.ProxyBase64EncodeXauHeader),
@@ -15,7 +15,7 @@ func TestAccDataForwardingControlZPAGateway_Basic(t *testing.T) {
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckForwardingControlZPAGatewayDestroy,
 		Steps: []resource.TestStep{
 			{
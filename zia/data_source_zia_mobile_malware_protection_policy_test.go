@@ -10,8 +10,8 @@ func TestAccDataSourceMobileMalwareProtectionPolicy_Basic(t *testing.T) {
 	resourceName := "data.zia_mobile_malware_protection_policy.this"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDataSourceMobileMalwareProtectionPolicyConfig_basic,
@@ -0,0 +1,122 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceNSSFeedFormatPreview() *schema.Resource {
+	logTypes := make([]string, 0, len(nssLogFields))
+	for logType := range nssLogFields {
+		logTypes = append(logTypes, logType)
+	}
+	sort.Strings(logTypes)
+
+	return &schema.Resource{
+		ReadContext: dataSourceNSSFeedFormatPreviewRead,
+		Schema: map[string]*schema.Schema{
+			"nss_log_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(logTypes, false),
+				Description:  "The type of logs to render: WEBLOG, FWLOG, DNSLOG, TUNNEL, ENDPOINT_DLP or CASB_FILELOG",
+			},
+			"feed_output_format": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Output format of the feed, as set on zia_cloud_nss_feed",
+			},
+			"custom_escaped_character": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringMatch(nssEscapedCharacterPattern, "must be ASCII_<code> with a code from 0 to 127"),
+				},
+				Description: "Characters hex encoded when they appear in the encoded URL, host or referer",
+			},
+			"json_array_toggle": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Whether the records are streamed as one JSON array",
+			},
+			"records": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeMap, Elem: &schema.Schema{Type: schema.TypeString}},
+				Description: "Records to render instead of the sample records, as maps of field name to value",
+			},
+			"rendered_records": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Every record rendered with the output format",
+			},
+			"output": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The records as the SIEM receives them",
+			},
+			"valid_json": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the output is valid JSON: the whole array with json_array_toggle, every record otherwise",
+			},
+		},
+	}
+}
+
+func dataSourceNSSFeedFormatPreviewRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logType := d.Get("nss_log_type").(string)
+	log.Printf("[INFO] Rendering %s records with the NSS feed output format\n", logType)
+
+	tokens, err := parseNSSFeedFormat(d.Get("feed_output_format").(string))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("invalid feed_output_format: %w", err))
+	}
+	diags := nssFeedFieldWarnings(tokens, logType)
+	escaped, err := nssEscapedCharacters(SetToStringList(d, "custom_escaped_character"))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	records := nssSampleRecords[logType]
+	if raw := d.Get("records").([]interface{}); len(raw) > 0 {
+		records = make([]map[string]string, len(raw))
+		for i, r := range raw {
+			records[i] = map[string]string{}
+			for field, value := range r.(map[string]interface{}) {
+				records[i][field] = value.(string)
+			}
+			fields := make([]string, 0, len(records[i]))
+			for field := range records[i] {
+				fields = append(fields, field)
+			}
+			diags = append(diags, nssUnknownFieldWarnings(fmt.Sprintf("records[%d]", i), fields, logType)...)
+		}
+	}
+
+	rendered := make([]string, len(records))
+	for i, record := range records {
+		withTime := nssSampleTimeFields(nssSampleTime)
+		for field, value := range record {
+			withTime[field] = value
+		}
+		rendered[i] = renderNSSRecord(tokens, withTime, escaped)
+	}
+	output, validJSON := nssFeedOutput(rendered, d.Get("json_array_toggle").(bool))
+
+	d.SetId(fmt.Sprintf("%s_preview", logType))
+	_ = d.Set("output", output)
+	_ = d.Set("valid_json", validJSON)
+	if err := d.Set("rendered_records", rendered); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
@@ -0,0 +1,167 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceNSSFeedPreset() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceNSSFeedPresetRead,
+		Schema: map[string]*schema.Schema{
+			"siem": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(nssFeedPresetSIEMs(), false),
+				Description:  "The SIEM receiving the feed: SPLUNK, AZURE_SENTINEL, QRADAR, CHRONICLE or ELASTIC",
+			},
+			"nss_log_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice([]string{"WEBLOG", "FWLOG", "DNSLOG"}, false),
+				Description:  "The type of logs streamed: WEBLOG, FWLOG or DNSLOG",
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Version of the preset. Defaults to the latest version; pin it to keep the format unchanged across provider upgrades",
+			},
+			"fields": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Fields of the record to override or add, as key to format, such as user = \"%s{login}\"",
+			},
+			"exclude_fields": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Keys of the preset fields to leave out of the record",
+			},
+			"authentication_token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Token of the SIEM collector, put in connection_headers for Splunk and Elastic",
+			},
+			"feed_output_format": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The output format, with the field changes applied",
+			},
+			"preset_feed_output_format": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The output format of the preset, without the field changes",
+			},
+			"nss_feed_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"siem_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"json_array_toggle": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"custom_escaped_character": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"max_batch_size": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"oauth_authentication": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"connection_headers": {
+				Type:      schema.TypeList,
+				Computed:  true,
+				Sensitive: true,
+				Elem:      &schema.Schema{Type: schema.TypeString},
+			},
+			"changes": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The fields that differ from the preset",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"field": {Type: schema.TypeString, Computed: true},
+						"preset": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Format of the field in the preset; empty for an added field",
+						},
+						"value": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Format of the field in feed_output_format; empty for an excluded field",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceNSSFeedPresetRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	siem := d.Get("siem").(string)
+	logType := d.Get("nss_log_type").(string)
+
+	preset, err := findNSSFeedPreset(siem, d.Get("version").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	log.Printf("[INFO] Building the %s feed for %s logs from preset version %s\n", siem, logType, preset.version)
+
+	overrides := map[string]string{}
+	for key, value := range d.Get("fields").(map[string]interface{}) {
+		overrides[key] = value.(string)
+	}
+	fields, changes, err := preset.applyFields(logType, overrides, SetToStringList(d, "exclude_fields"))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	format := preset.format(logType, fields)
+	tokens, err := parseNSSFeedFormat(format)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("invalid fields: %w", err))
+	}
+	diags := nssFeedFieldWarnings(tokens, logType)
+
+	var headers []string
+	if token := d.Get("authentication_token").(string); token != "" && preset.authHeader != "" {
+		headers = append(headers, fmt.Sprintf(preset.authHeader, token))
+	}
+	flattened := make([]interface{}, len(changes))
+	for i, c := range changes {
+		flattened[i] = map[string]interface{}{"field": c.key, "preset": c.preset, "value": c.value}
+	}
+
+	d.SetId(fmt.Sprintf("%s_%s_v%s", siem, logType, preset.version))
+	_ = d.Set("version", preset.version)
+	_ = d.Set("feed_output_format", format)
+	_ = d.Set("preset_feed_output_format", preset.format(logType, preset.fields[logType]))
+	_ = d.Set("nss_feed_type", preset.nssFeedType)
+	_ = d.Set("siem_type", preset.siemType)
+	_ = d.Set("json_array_toggle", preset.jsonArrayToggle)
+	_ = d.Set("custom_escaped_character", preset.escapedChars)
+	_ = d.Set("max_batch_size", preset.maxBatchSize)
+	_ = d.Set("oauth_authentication", preset.oauth)
+	_ = d.Set("connection_headers", headers)
+	if err := d.Set("changes", flattened); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
@@ -0,0 +1,91 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourcePolicyAnalysis() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourcePolicyAnalysisRead,
+		Schema: map[string]*schema.Schema{
+			"rule_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(analyzedRuleTypeNames(), false),
+				Description:  "The rules to analyze: firewall_filtering, firewall_dns, firewall_ips, url_filtering, ssl_inspection or file_type_control",
+			},
+			"rule_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of rules analyzed",
+			},
+			"findings": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Enabled rules that can never match, or refer to deleted or empty groups or an ended validity period, in policy order",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"rule_id":   {Type: schema.TypeInt, Computed: true},
+						"rule_name": {Type: schema.TypeString, Computed: true},
+						"order":     {Type: schema.TypeInt, Computed: true},
+						"finding": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "shadowed, duplicate, deleted_reference, empty_group or expired",
+						},
+						"related_rule_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "ID of the earlier rule a shadowed or duplicate rule is covered by",
+						},
+						"related_rule_name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Name of the earlier rule a shadowed or duplicate rule is covered by",
+						},
+						"detail": {Type: schema.TypeString, Computed: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourcePolicyAnalysisRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zClient := meta.(*Client)
+
+	ruleType := d.Get("rule_type").(string)
+	log.Printf("[INFO] Analyzing %s rules\n", ruleType)
+
+	count, findings, err := analyzePolicy(ctx, zClient.Service, ruleType, time.Now())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	flattened := make([]interface{}, len(findings))
+	for i, f := range findings {
+		flattened[i] = map[string]interface{}{
+			"rule_id":           f.ruleID,
+			"rule_name":         f.ruleName,
+			"order":             f.order,
+			"finding":           f.kind,
+			"related_rule_id":   f.relatedRuleID,
+			"related_rule_name": f.relatedRuleName,
+			"detail":            f.detail,
+		}
+	}
+
+	d.SetId(fmt.Sprintf("%s_analysis", ruleType))
+	_ = d.Set("rule_count", count)
+	if err := d.Set("findings", flattened); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourcePolicySimulation() *schema.Resource {
+	optionalString := func(description string) *schema.Schema {
+		return &schema.Schema{Type: schema.TypeString, Optional: true, Description: description}
+	}
+	return &schema.Resource{
+		ReadContext: dataSourcePolicySimulationRead,
+		Schema: map[string]*schema.Schema{
+			"policy": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(SimulatedPolicies, false),
+				Description:  "The policy to evaluate: firewall_filtering, url_filtering or ssl_inspection",
+			},
+			"user":       optionalString("ID or name of the user"),
+			"department": optionalString("ID or name of the department of the user"),
+			"location":   optionalString("ID or name of the location the transaction comes from"),
+			"groups": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs or names of the groups of the user",
+			},
+			"location_groups": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs or names of the location groups of the location",
+			},
+			"src_ip": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsIPAddress,
+				Description:  "Source IP address",
+			},
+			"dst_ip": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsIPAddress,
+				Description:  "Destination IP address",
+			},
+			"dst_port": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IsPortNumber,
+				Description:  "Destination port",
+			},
+			"protocol":       optionalString("Network protocol (TCP or UDP) for firewall filtering, or web protocol (such as HTTPS) for URL filtering"),
+			"url_category":   optionalString("URL category of the destination, such as FINANCE"),
+			"cloud_app":      optionalString("Cloud application or network application, such as CHATGPT_AI"),
+			"request_method": optionalString("HTTP request method. When not set, the request methods of URL filtering rules are not checked"),
+			"day_of_week": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"MON", "TUE", "WED", "THU", "FRI", "SAT", "SUN"}, false),
+				Description:  "Day of the week, evaluated against time windows",
+			},
+			"time_of_day": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringMatch(timeOfDayPattern, "must be a time of day in HH:MM format"),
+				Description:  "Time of day in HH:MM format, evaluated against time windows",
+			},
+			"matched": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether a rule matches the transaction",
+			},
+			"rule_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the matching rule",
+			},
+			"rule_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the matching rule",
+			},
+			"action": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Action of the matching rule",
+			},
+			"conclusive": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "False when a rule before the match restricts an attribute that cannot be evaluated offline, and could match instead",
+			},
+			"trace": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The rules evaluated up to the match, in order",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"rule_id":   {Type: schema.TypeInt, Computed: true},
+						"rule_name": {Type: schema.TypeString, Computed: true},
+						"order":     {Type: schema.TypeInt, Computed: true},
+						"result": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "matched, skipped or indeterminate",
+						},
+						"reason": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Why the rule was skipped, or which conditions could not be evaluated",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourcePolicySimulationRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zClient := meta.(*Client)
+
+	tx := PolicyTransaction{
+		User:           d.Get("user").(string),
+		Groups:         SetToStringList(d, "groups"),
+		Department:     d.Get("department").(string),
+		Location:       d.Get("location").(string),
+		LocationGroups: SetToStringList(d, "location_groups"),
+		SrcIP:          d.Get("src_ip").(string),
+		DstIP:          d.Get("dst_ip").(string),
+		DstPort:        d.Get("dst_port").(int),
+		Protocol:       d.Get("protocol").(string),
+		URLCategory:    d.Get("url_category").(string),
+		CloudApp:       d.Get("cloud_app").(string),
+		DayOfWeek:      d.Get("day_of_week").(string),
+		TimeOfDay:      d.Get("time_of_day").(string),
+		RequestMethod:  d.Get("request_method").(string),
+	}
+	policy := d.Get("policy").(string)
+	log.Printf("[INFO] Simulating %s policy for transaction %+v\n", policy, tx)
+
+	result, err := SimulatePolicy(ctx, zClient.Service, policy, tx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	trace := make([]interface{}, len(result.Trace))
+	for i, step := range result.Trace {
+		trace[i] = map[string]interface{}{
+			"rule_id":   step.RuleID,
+			"rule_name": step.RuleName,
+			"order":     step.Order,
+			"result":    step.Result,
+			"reason":    step.Reason,
+		}
+	}
+
+	d.SetId(fmt.Sprintf("%s_simulation", policy))
+	_ = d.Set("matched", result.Matched)
+	_ = d.Set("rule_id", result.RuleID)
+	_ = d.Set("rule_name", result.RuleName)
+	_ = d.Set("action", result.Action)
+	_ = d.Set("conclusive", result.Conclusive)
+	if err := d.Set("trace", trace); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
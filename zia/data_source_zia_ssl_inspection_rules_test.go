@@ -17,9 +17,9 @@ func TestAccDataSourceSSLInspectionRules_Basic(t *testing.T) {
 	ruleLabelHCL := testAccCheckRuleLabelsConfigure(ruleLabelTypeAndName, ruleLabelGeneratedName, variable.RuleLabelDescription)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckRuleLabelsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckRuleLabelsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckSSLInspectionRulesConfigure(
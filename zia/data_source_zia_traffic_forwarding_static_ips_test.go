@@ -16,9 +16,9 @@ func TestAccDataSourceTrafficForwardingStaticIP_Basic(t *testing.T) {
 	rIP, _ := acctest.RandIpAddress("121.234.54.0/25")
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckTrafficForwardingStaticIPDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckTrafficForwardingStaticIPDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckTrafficForwardingStaticIPConfigure(resourceTypeAndName, generatedName, rIP, variable.StaticRoutableIP, variable.StaticGeoOverride),
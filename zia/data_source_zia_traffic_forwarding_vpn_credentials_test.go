@@ -12,7 +12,7 @@ func TestAccDataSourceTrafficForwardingVPNCredentials_Basic(t *testing.T) {
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckTrafficForwardingVPNCredentialsDestroy,
 		Steps: []resource.TestStep{
 			{
@@ -15,9 +15,9 @@ func TestAccDataSourceURLCategories_Basic(t *testing.T) {
 	initialName := "tf-acc-test-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckURLCategoriesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckURLCategoriesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckURLCategoriesConfigure(resourceTypeAndName, initialName, variable.CustomCategory),
@@ -10,8 +10,8 @@ func TestAccDataSourceURLFilteringCloudAppSettings_Basic(t *testing.T) {
 	resourceName := "data.zia_url_filtering_and_cloud_app_settings.this"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDataSourceURLFilteringCloludAppSettingsConfig_basic,
@@ -18,7 +18,7 @@ func TestAccDataSourceUserManagement_Basic(t *testing.T) {
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckUserManagementDestroy,
 		Steps: []resource.TestStep{
 			{
@@ -6,7 +6,7 @@ func TestAccDataSourceVZENNode_Basic(t *testing.T) {
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckVZENNodeDestroy,
 		Steps: []resource.TestStep{
 			{
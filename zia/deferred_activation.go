@@ -0,0 +1,160 @@
+package zia
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// activationModePerResource activates after every resource write when
+	// ZIA_ACTIVATION is set. This is the historical behaviour and the default.
+	activationModePerResource = "per_resource"
+	// activationModeEndOfApply records writes on the client and activates once
+	// after the last write of the apply.
+	activationModeEndOfApply = "end_of_apply"
+
+	defaultActivationQuietPeriod = 10
+
+	// stopActivationTimeout bounds the activation made once the provider
+	// server has stopped and the tenant locks have been released. go-plugin
+	// kills the plugin two seconds after asking it to stop.
+	stopActivationTimeout = time.Second
+)
+
+// deferredActivation coordinates a single activation for all writes issued
+// through one configured provider instance.
+//
+// Terraform gives a provider no signal that an apply has finished. Every
+// resource Create, Update and Delete is bracketed by beginWrite/endWrite.
+// When the last in-flight write finishes and the client is dirty, that write
+// waits for quietPeriod; if no other write started in the meantime it
+// performs the activation before returning, so a failure is reported on a
+// resource. Writes that start while the window is open hand the
+// responsibility to whichever of them finishes last. A dependency chain with
+// a gap longer than quietPeriod activates in the middle of the apply; those
+// activations are counted as extra ones.
+//
+// Changes are left pending only when the last activation failed, which was
+// reported on its resource, or when Terraform stopped the provider during
+// the quiet period. finish retries them when the provider stops, but by
+// then go-plugin kills the plugin within two seconds and there is no
+// resource to report on, so it is bounded and its failures are only logged.
+type deferredActivation struct {
+	quietPeriod time.Duration
+	activate    func(context.Context) error
+
+	inFlight    int
+	dirty       bool
+	generation  uint64
+	activations int
+	sync.Mutex
+}
+
+func newDeferredActivation(quietPeriod time.Duration, activate func(context.Context) error) *deferredActivation {
+	return &deferredActivation{
+		quietPeriod: quietPeriod,
+		activate:    activate,
+	}
+}
+
+// beginWrite records the start of a resource write.
+func (a *deferredActivation) beginWrite() {
+	a.Lock()
+	defer a.Unlock()
+	a.inFlight++
+	a.generation++
+}
+
+// markDirty records that a write left pending changes in the tenant.
+func (a *deferredActivation) markDirty() {
+	a.Lock()
+	defer a.Unlock()
+	a.dirty = true
+}
+
+// endWrite records the end of a resource write and, if it was the last one
+// and the quiet period elapses without new writes, activates the pending
+// changes.
+func (a *deferredActivation) endWrite(ctx context.Context) error {
+	a.Lock()
+	a.inFlight--
+	if a.inFlight > 0 || !a.dirty {
+		a.Unlock()
+		return nil
+	}
+	generation := a.generation
+	a.Unlock()
+
+	log.Printf("[INFO] No ZIA writes in flight; activating pending changes if none start within %s", a.quietPeriod)
+	select {
+	case <-time.After(a.quietPeriod):
+	case <-ctx.Done():
+		log.Printf("[WARN] Deferred activation abandoned: %v. Pending changes must be activated manually", ctx.Err())
+		return nil
+	}
+
+	a.Lock()
+	if a.inFlight > 0 || a.generation != generation || !a.dirty {
+		a.Unlock()
+		return nil
+	}
+	a.dirty = false
+	a.Unlock()
+
+	if err := a.activate(ctx); err != nil {
+		a.markDirty()
+		return err
+	}
+	a.Lock()
+	a.activations++
+	a.Unlock()
+	return nil
+}
+
+// finish activates the changes still pending when the provider stops or is
+// reconfigured, that is the writes whose activation failed or was cut short.
+func (a *deferredActivation) finish(ctx context.Context) error {
+	a.Lock()
+	dirty := a.dirty
+	a.dirty = false
+	a.Unlock()
+
+	if dirty {
+		log.Printf("[INFO] Activating the changes still pending at the end of the apply")
+		if err := a.activate(ctx); err != nil {
+			a.markDirty()
+			return err
+		}
+		a.Lock()
+		a.activations++
+		a.Unlock()
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	if a.activations > 1 {
+		log.Printf("[WARN] Deferred activation activated %d times; %d of them were extra activations after a quiet period in the middle of the apply. Raise quiet_period_seconds to avoid them", a.activations, a.activations-1)
+	}
+	return nil
+}
+
+// ActivatePendingChanges activates the changes still pending on every
+// configured client with deferred activation. main calls it once the
+// provider server has stopped, after the tenant locks are released. It
+// gives up after stopActivationTimeout.
+func ActivatePendingChanges() {
+	ctx, cancel := context.WithTimeout(context.Background(), stopActivationTimeout)
+	defer cancel()
+	configuredClients.Lock()
+	defer configuredClients.Unlock()
+	for _, client := range configuredClients.m {
+		if client.activation == nil {
+			continue
+		}
+		if err := client.activation.finish(ctx); err != nil {
+			log.Printf("[ERROR] Activating the pending changes at the end of the apply failed; they must be activated manually: %v", err)
+		}
+	}
+}
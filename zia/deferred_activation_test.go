@@ -0,0 +1,198 @@
+package zia
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDeferredActivation_ParallelWritesActivateOnce(t *testing.T) {
+	var calls int32
+	a := newDeferredActivation(50*time.Millisecond, func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			a.beginWrite()
+			time.Sleep(time.Duration(i) * 5 * time.Millisecond)
+			a.markDirty()
+			if err := a.endWrite(context.Background()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected exactly 1 activation, got %d", got)
+	}
+}
+
+func TestDeferredActivation_WriteDuringQuietPeriodTakesOver(t *testing.T) {
+	var calls int32
+	a := newDeferredActivation(100*time.Millisecond, func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	a.beginWrite()
+	a.markDirty()
+	first := make(chan error, 1)
+	go func() { first <- a.endWrite(context.Background()) }()
+
+	// A second write starts while the first one is waiting out the quiet period.
+	time.Sleep(30 * time.Millisecond)
+	a.beginWrite()
+	a.markDirty()
+	if err := <-first; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 0 {
+		t.Fatalf("expected no activation while a write is in flight, got %d", got)
+	}
+
+	if err := a.endWrite(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected exactly 1 activation, got %d", got)
+	}
+}
+
+func TestDeferredActivation_CleanWritesDoNotActivate(t *testing.T) {
+	a := newDeferredActivation(10*time.Millisecond, func(context.Context) error {
+		t.Fatal("activation must not run when no write marked the client dirty")
+		return nil
+	})
+	a.beginWrite()
+	if err := a.endWrite(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDeferredActivation_FailureKeepsChangesPending(t *testing.T) {
+	var calls int32
+	a := newDeferredActivation(10*time.Millisecond, func(context.Context) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return errors.New("rate limited")
+		}
+		return nil
+	})
+
+	a.beginWrite()
+	a.markDirty()
+	if err := a.endWrite(context.Background()); err == nil {
+		t.Fatal("expected the activation error to be returned")
+	}
+
+	// The next write retries the activation even though it made no change itself.
+	a.beginWrite()
+	if err := a.endWrite(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("expected the activation to be retried, got %d calls", got)
+	}
+}
+
+func TestDeferredActivation_FinishActivatesWritesAfterQuietPeriod(t *testing.T) {
+	var calls int32
+	a := newDeferredActivation(time.Hour, func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	// The write's quiet period is cut short, as when Terraform stops the
+	// provider right after the last write.
+	ctx, cancel := context.WithCancel(context.Background())
+	a.beginWrite()
+	a.markDirty()
+	cancel()
+	if err := a.endWrite(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := a.finish(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected the pending changes activated at the end, got %d activations", got)
+	}
+
+	// Nothing is pending any more.
+	if err := a.finish(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected no second activation, got %d", got)
+	}
+}
+
+func TestActivatePendingChanges_FitsTheKillWindow(t *testing.T) {
+	a := newDeferredActivation(time.Hour, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	a.markDirty()
+	key := &schema.Provider{}
+	configuredClients.Lock()
+	configuredClients.m[key] = &Client{activation: a}
+	configuredClients.Unlock()
+	t.Cleanup(func() {
+		configuredClients.Lock()
+		delete(configuredClients.m, key)
+		configuredClients.Unlock()
+	})
+
+	// go-plugin kills the plugin two seconds after asking it to stop.
+	start := time.Now()
+	ActivatePendingChanges()
+	if elapsed := time.Since(start); elapsed >= 2*time.Second {
+		t.Fatalf("expected the activation to give up within the kill window, took %s", elapsed)
+	}
+}
+
+func TestDeferredActivation_GapInChainCountsExtraActivation(t *testing.T) {
+	var calls int32
+	a := newDeferredActivation(10*time.Millisecond, func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		a.beginWrite()
+		a.markDirty()
+		if err := a.endWrite(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := a.finish(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("expected one activation per quiet period and none at the end, got %d", got)
+	}
+	if a.activations != 2 {
+		t.Errorf("expected 2 counted activations, got %d", a.activations)
+	}
+}
+
+func TestShouldActivate_EndOfApplyModeIgnoresEnv(t *testing.T) {
+	t.Setenv("ZIA_ACTIVATION", "false")
+	if shouldActivate(&Client{}) {
+		t.Fatal("per-resource mode must honour ZIA_ACTIVATION")
+	}
+	client := &Client{activation: newDeferredActivation(time.Second, nil)}
+	if !shouldActivate(client) {
+		t.Fatal("end_of_apply mode must always record writes")
+	}
+}
@@ -0,0 +1,381 @@
+package zia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	ctyjson "github.com/hashicorp/go-cty/cty/json"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+)
+
+// DriftReport lists the differences between a Terraform state file and the
+// live tenant configuration.
+type DriftReport struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// Changed lists managed objects whose attributes were changed outside
+	// Terraform.
+	Changed []DriftChangedResource `json:"changed"`
+	// Deleted lists managed objects that no longer exist in the tenant.
+	Deleted []DriftResource `json:"deleted"`
+	// Unmanaged lists objects of managed resource types that are not in
+	// the state.
+	Unmanaged []DriftUnmanagedObject `json:"unmanaged"`
+	// Skipped lists state entries that could not be checked, with the
+	// reason.
+	Skipped []DriftSkipped `json:"skipped"`
+}
+
+// DriftResource identifies a resource instance of the state.
+type DriftResource struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+}
+
+// DriftChangedResource is a managed object with out-of-band changes.
+type DriftChangedResource struct {
+	DriftResource
+	Attributes []DriftAttribute `json:"attributes"`
+}
+
+// DriftAttribute is one changed attribute with its state and live values.
+type DriftAttribute struct {
+	Name  string      `json:"name"`
+	State interface{} `json:"state"`
+	Live  interface{} `json:"live"`
+}
+
+// DriftUnmanagedObject is an object of a managed type missing from the
+// state. For rules, InManagedOrderRange reports whether the rule sits
+// between the lowest and highest order of the managed rules, where it
+// affects their evaluation.
+type DriftUnmanagedObject struct {
+	Type                string `json:"type"`
+	ID                  string `json:"id"`
+	Name                string `json:"name"`
+	Order               int    `json:"order,omitempty"`
+	InManagedOrderRange bool   `json:"in_managed_order_range,omitempty"`
+}
+
+// DriftSkipped is a state entry or resource type that was not checked.
+type DriftSkipped struct {
+	Address string `json:"address"`
+	Reason  string `json:"reason"`
+}
+
+// HasDrift reports whether the report contains any drift.
+func (r *DriftReport) HasDrift() bool {
+	return len(r.Changed) > 0 || len(r.Deleted) > 0 || len(r.Unmanaged) > 0
+}
+
+const driftSensitiveValue = "(sensitive)"
+
+// tfState is the part of the Terraform state format (version 4) the drift
+// report reads.
+type tfState struct {
+	Version   int               `json:"version"`
+	Resources []tfStateResource `json:"resources"`
+}
+
+type tfStateResource struct {
+	Module    string            `json:"module"`
+	Mode      string            `json:"mode"`
+	Type      string            `json:"type"`
+	Name      string            `json:"name"`
+	Provider  string            `json:"provider"`
+	Instances []tfStateInstance `json:"instances"`
+}
+
+type tfStateInstance struct {
+	IndexKey      interface{}            `json:"index_key"`
+	SchemaVersion int                    `json:"schema_version"`
+	Attributes    map[string]interface{} `json:"attributes"`
+}
+
+func (r tfStateResource) address(instance tfStateInstance) string {
+	address := r.Type + "." + r.Name
+	if r.Module != "" {
+		address = r.Module + "." + address
+	}
+	switch key := instance.IndexKey.(type) {
+	case string:
+		address += fmt.Sprintf("[%q]", key)
+	case float64:
+		address += fmt.Sprintf("[%d]", int(key))
+	}
+	return address
+}
+
+// ReportDrift compares the ZIA resources of a Terraform state file with the
+// live tenant. Every object in the state is refreshed with its resource's
+// Read function, and every managed resource type that the exporter can
+// list is checked for objects missing from the state.
+func ReportDrift(ctx context.Context, service *zscaler.Service, stateJSON []byte) (*DriftReport, error) {
+	return reportDrift(ctx, ZIAProvider().ResourcesMap, &Client{Service: service}, stateJSON)
+}
+
+func reportDrift(ctx context.Context, resources map[string]*schema.Resource, client *Client, stateJSON []byte) (*DriftReport, error) {
+	var state tfState
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		return nil, fmt.Errorf("reading state: %w", err)
+	}
+	if state.Version != 4 {
+		return nil, fmt.Errorf("unsupported state format version %d", state.Version)
+	}
+
+	report := &DriftReport{
+		GeneratedAt: time.Now().UTC(),
+		Changed:     []DriftChangedResource{},
+		Deleted:     []DriftResource{},
+		Unmanaged:   []DriftUnmanagedObject{},
+		Skipped:     []DriftSkipped{},
+	}
+
+	// Managed IDs and rule order ranges per resource type.
+	managed := map[string]map[string]bool{}
+	orders := map[string][2]int{}
+
+	for _, res := range state.Resources {
+		if res.Mode != "managed" || !strings.Contains(res.Provider, "zscaler/zia") {
+			continue
+		}
+		r, ok := resources[res.Type]
+		if !ok {
+			for _, instance := range res.Instances {
+				report.Skipped = append(report.Skipped, DriftSkipped{Address: res.address(instance), Reason: "resource type is not supported by the drift report"})
+			}
+			continue
+		}
+		if managed[res.Type] == nil {
+			managed[res.Type] = map[string]bool{}
+		}
+
+		for _, instance := range res.Instances {
+			address := res.address(instance)
+			id, _ := instance.Attributes["id"].(string)
+			managed[res.Type][id] = true
+			if order, ok := instance.Attributes["order"].(float64); ok && order > 0 {
+				orders[res.Type] = driftWidenRange(orders[res.Type], int(order))
+			}
+
+			before, after, err := driftRefresh(ctx, r, client, instance)
+			if err != nil {
+				report.Skipped = append(report.Skipped, DriftSkipped{Address: address, Reason: err.Error()})
+				continue
+			}
+			if after.Id() == "" {
+				log.Printf("[INFO] %s (%s) no longer exists", address, id)
+				report.Deleted = append(report.Deleted, DriftResource{Address: address, Type: res.Type, ID: id})
+				continue
+			}
+			if attrs := driftAttributes(r.Schema, before, after); len(attrs) > 0 {
+				report.Changed = append(report.Changed, DriftChangedResource{
+					DriftResource: DriftResource{Address: address, Type: res.Type, ID: id},
+					Attributes:    attrs,
+				})
+			}
+		}
+	}
+
+	types := make([]string, 0, len(managed))
+	for t := range managed {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, resourceType := range types {
+		list, ok := exportListers[resourceType]
+		if !ok {
+			continue
+		}
+		objects, err := list(ctx, client.Service)
+		if err != nil {
+			report.Skipped = append(report.Skipped, DriftSkipped{Address: resourceType, Reason: fmt.Sprintf("listing failed: %v", err)})
+			continue
+		}
+		orderRange, ordered := orders[resourceType]
+		for _, obj := range objects {
+			if managed[resourceType][obj.ID] {
+				continue
+			}
+			report.Unmanaged = append(report.Unmanaged, DriftUnmanagedObject{
+				Type:                resourceType,
+				ID:                  obj.ID,
+				Name:                obj.Name,
+				Order:               obj.Order,
+				InManagedOrderRange: ordered && obj.Order >= orderRange[0] && obj.Order <= orderRange[1],
+			})
+		}
+	}
+
+	return report, nil
+}
+
+func driftWidenRange(r [2]int, order int) [2]int {
+	if r[0] == 0 || order < r[0] {
+		r[0] = order
+	}
+	if order > r[1] {
+		r[1] = order
+	}
+	return r
+}
+
+// driftRefresh builds the ResourceData of a state instance and refreshes a
+// copy of it with the resource's Read function.
+func driftRefresh(ctx context.Context, r *schema.Resource, client *Client, instance tfStateInstance) (*schema.ResourceData, *schema.ResourceData, error) {
+	attributes, err := driftUpgradeState(ctx, r, client, instance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ty := r.CoreConfigSchema().ImpliedType()
+	known := make(map[string]interface{}, len(attributes))
+	for name, value := range attributes {
+		if ty.HasAttribute(name) {
+			known[name] = value
+		}
+	}
+	raw, err := json.Marshal(known)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, err := ctyjson.Unmarshal(raw, ty)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding state: %w", err)
+	}
+	is, err := r.ShimInstanceStateFromValue(value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding state: %w", err)
+	}
+
+	before := r.Data(is.DeepCopy())
+	after := r.Data(is.DeepCopy())
+	if diags := r.ReadContext(ctx, after, client); diags.HasError() {
+		for _, d := range diags {
+			if d.Severity == diag.Error {
+				return nil, nil, fmt.Errorf("%s: %s", d.Summary, d.Detail)
+			}
+		}
+	}
+	return before, after, nil
+}
+
+// driftUpgradeState applies the resource's state upgraders to instances
+// written by an older provider version.
+func driftUpgradeState(ctx context.Context, r *schema.Resource, client *Client, instance tfStateInstance) (map[string]interface{}, error) {
+	attributes := instance.Attributes
+	for _, upgrader := range r.StateUpgraders {
+		if upgrader.Version < instance.SchemaVersion {
+			continue
+		}
+		upgraded, err := upgrader.Upgrade(ctx, attributes, client)
+		if err != nil {
+			return nil, fmt.Errorf("upgrading state from schema version %d: %w", upgrader.Version, err)
+		}
+		attributes = upgraded
+	}
+	return attributes, nil
+}
+
+// driftAttributes returns the configurable top-level attributes whose value
+// differs between before and after.
+func driftAttributes(schemaMap map[string]*schema.Schema, before, after *schema.ResourceData) []DriftAttribute {
+	keys := make([]string, 0, len(schemaMap))
+	for key := range schemaMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var attrs []DriftAttribute
+	for _, key := range keys {
+		s := schemaMap[key]
+		if key == "id" || !exportConfigurable(s) || s.WriteOnly {
+			continue
+		}
+		stateValue := exportNormalize(before.Get(key))
+		liveValue := exportNormalize(after.Get(key))
+		if exportIsDefault(s, stateValue) && exportIsDefault(s, liveValue) {
+			continue
+		}
+		if reflect.DeepEqual(stateValue, liveValue) {
+			continue
+		}
+		if s.Sensitive {
+			stateValue, liveValue = driftSensitiveValue, driftSensitiveValue
+		}
+		attrs = append(attrs, DriftAttribute{Name: key, State: stateValue, Live: liveValue})
+	}
+	return attrs
+}
+
+// Markdown renders the report for humans.
+func (r *DriftReport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# ZIA drift report\n\nGenerated at %s.\n\n", r.GeneratedAt.Format(time.RFC3339))
+	if !r.HasDrift() {
+		b.WriteString("No drift detected.\n")
+	}
+
+	if len(r.Changed) > 0 {
+		b.WriteString("## Changed outside Terraform\n\n| Resource | ID | Attribute | State | Live |\n|---|---|---|---|---|\n")
+		for _, c := range r.Changed {
+			for _, a := range c.Attributes {
+				fmt.Fprintf(&b, "| `%s` | %s | `%s` | %s | %s |\n", c.Address, c.ID, a.Name, driftMarkdownValue(a.State), driftMarkdownValue(a.Live))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Deleted) > 0 {
+		b.WriteString("## Deleted outside Terraform\n\n| Resource | ID |\n|---|---|\n")
+		for _, d := range r.Deleted {
+			fmt.Fprintf(&b, "| `%s` | %s |\n", d.Address, d.ID)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Unmanaged) > 0 {
+		b.WriteString("## Not managed by Terraform\n\n| Type | ID | Name | Order | Inside managed order range |\n|---|---|---|---|---|\n")
+		for _, u := range r.Unmanaged {
+			order, inRange := "", ""
+			if u.Order > 0 {
+				order = fmt.Sprint(u.Order)
+				inRange = "no"
+				if u.InManagedOrderRange {
+					inRange = "**yes**"
+				}
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n", u.Type, u.ID, driftMarkdownEscape(u.Name), order, inRange)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Skipped) > 0 {
+		b.WriteString("## Not checked\n\n| Resource | Reason |\n|---|---|\n")
+		for _, s := range r.Skipped {
+			fmt.Fprintf(&b, "| `%s` | %s |\n", s.Address, driftMarkdownEscape(s.Reason))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func driftMarkdownValue(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return driftMarkdownEscape(fmt.Sprint(v))
+	}
+	return "`" + strings.ReplaceAll(string(raw), "`", "'") + "`"
+}
+
+func driftMarkdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
@@ -0,0 +1,137 @@
+package zia
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+)
+
+const driftTestState = `{
+  "version": 4,
+  "terraform_version": "1.9.0",
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "zia_firewall_filtering_rule",
+      "name": "allow",
+      "provider": "provider[\"registry.terraform.io/zscaler/zia\"]",
+      "instances": [
+        {
+          "schema_version": 0,
+          "attributes": {"id": "1", "name": "Allow", "order": 1, "action": "ALLOW", "password": "a", "removed_attribute": true}
+        }
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "zia_firewall_filtering_rule",
+      "name": "block",
+      "module": "module.policy",
+      "provider": "module.policy.provider[\"registry.terraform.io/zscaler/zia\"]",
+      "instances": [
+        {
+          "index_key": "web",
+          "schema_version": 0,
+          "attributes": {"id": "2", "name": "Block", "order": 3, "action": "BLOCK_DROP", "password": "b"}
+        }
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "zia_rule_labels",
+      "name": "label",
+      "provider": "provider[\"registry.terraform.io/zscaler/zia\"]",
+      "instances": [{"schema_version": 0, "attributes": {"id": "9"}}]
+    },
+    {
+      "mode": "data",
+      "type": "zia_location_management",
+      "name": "hq",
+      "provider": "provider[\"registry.terraform.io/zscaler/zia\"]",
+      "instances": [{"schema_version": 0, "attributes": {"id": "5"}}]
+    }
+  ]
+}`
+
+func TestReportDrift(t *testing.T) {
+	live := map[string]map[string]interface{}{
+		"1": {"name": "Allow", "order": 1, "action": "BLOCK_DROP", "password": "changed"},
+	}
+	rule := &schema.Resource{
+		ReadContext: func(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+			obj, ok := live[d.Id()]
+			if !ok {
+				d.SetId("")
+				return nil
+			}
+			for k, v := range obj {
+				_ = d.Set(k, v)
+			}
+			return nil
+		},
+		Schema: map[string]*schema.Schema{
+			"name":     {Type: schema.TypeString, Required: true},
+			"order":    {Type: schema.TypeInt, Required: true},
+			"action":   {Type: schema.TypeString, Optional: true},
+			"password": {Type: schema.TypeString, Optional: true, Sensitive: true},
+		},
+	}
+
+	saved := exportListers["zia_firewall_filtering_rule"]
+	defer func() { exportListers["zia_firewall_filtering_rule"] = saved }()
+	exportListers["zia_firewall_filtering_rule"] = func(context.Context, *zscaler.Service) ([]exportObject, error) {
+		return []exportObject{
+			{ID: "1", Name: "Allow", Order: 1},
+			{ID: "3", Name: "Console rule", Order: 2},
+			{ID: "4", Name: "Far away", Order: 10},
+		}, nil
+	}
+
+	report, err := reportDrift(context.Background(), map[string]*schema.Resource{"zia_firewall_filtering_rule": rule}, &Client{}, []byte(driftTestState))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(report.Changed) != 1 || report.Changed[0].Address != "zia_firewall_filtering_rule.allow" {
+		t.Fatalf("unexpected changed resources: %+v", report.Changed)
+	}
+	attrs := report.Changed[0].Attributes
+	if len(attrs) != 2 || attrs[0].Name != "action" || attrs[0].State != "ALLOW" || attrs[0].Live != "BLOCK_DROP" {
+		t.Errorf("unexpected changed attributes: %+v", attrs)
+	}
+	if attrs[1].Name != "password" || attrs[1].Live != driftSensitiveValue {
+		t.Errorf("sensitive attribute not redacted: %+v", attrs[1])
+	}
+
+	if len(report.Deleted) != 1 || report.Deleted[0].Address != `module.policy.zia_firewall_filtering_rule.block["web"]` {
+		t.Errorf("unexpected deleted resources: %+v", report.Deleted)
+	}
+
+	if len(report.Unmanaged) != 2 {
+		t.Fatalf("unexpected unmanaged objects: %+v", report.Unmanaged)
+	}
+	if !report.Unmanaged[0].InManagedOrderRange || report.Unmanaged[1].InManagedOrderRange {
+		t.Errorf("order range not evaluated correctly: %+v", report.Unmanaged)
+	}
+
+	if len(report.Skipped) != 1 || report.Skipped[0].Address != "zia_rule_labels.label" {
+		t.Errorf("unexpected skipped entries: %+v", report.Skipped)
+	}
+
+	md := report.Markdown()
+	for _, want := range []string{"## Changed outside Terraform", "`\"BLOCK_DROP\"`", "## Deleted outside Terraform", "| Console rule | 2 | **yes** |"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown report does not contain %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestReportDrift_RejectsOldStateFormat(t *testing.T) {
+	if _, err := reportDrift(context.Background(), nil, &Client{}, []byte(`{"version": 3}`)); err == nil {
+		t.Error("expected an error for state format version 3")
+	}
+}
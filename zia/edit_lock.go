@@ -0,0 +1,245 @@
+package zia
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
+)
+
+const (
+	editLockPolicyFail = "fail"
+	editLockPolicyWait = "wait"
+
+	defaultEditLockMaxWait = 900
+)
+
+// editLockInitialBackoff and editLockMaxBackoff bound the jittered exponential backoff between the
+// attempts of a write that found the edit lock taken.
+var (
+	editLockInitialBackoff = 2 * time.Second
+	editLockMaxBackoff     = 60 * time.Second
+)
+
+// editLockSettings holds the edit_lock settings.
+type editLockSettings struct {
+	policy  string
+	maxWait int // seconds
+}
+
+// readEditLockSettings reads the edit_lock block, falling back to the
+// ZSCALER_EDIT_LOCK_* environment variables. The policy defaults to fail.
+func readEditLockSettings(d configValues) editLockSettings {
+	s := editLockSettings{maxWait: defaultEditLockMaxWait}
+	var block map[string]interface{}
+	if val, ok := d.GetOk("edit_lock"); ok {
+		if blocks := val.([]interface{}); len(blocks) > 0 && blocks[0] != nil {
+			block = blocks[0].(map[string]interface{})
+		}
+	}
+
+	settingString(block, "policy", "ZSCALER_EDIT_LOCK_POLICY", &s.policy)
+	settingInt(block, "max_wait_seconds", "ZSCALER_EDIT_LOCK_MAX_WAIT_SECONDS", &s.maxWait)
+	s.policy = strings.ToLower(s.policy)
+	if s.policy == "" {
+		s.policy = editLockPolicyFail
+	}
+	return s
+}
+
+func (s editLockSettings) validate() error {
+	if s.policy != editLockPolicyFail && s.policy != editLockPolicyWait {
+		return fmt.Errorf("edit_lock: unknown policy %q, expected %q or %q", s.policy, editLockPolicyFail, editLockPolicyWait)
+	}
+	return nil
+}
+
+// waitHTTPClient returns a copy of client that waits out edit lock conflicts
+// under the wait policy, or client itself under the fail policy.
+func (s editLockSettings) waitHTTPClient(client *http.Client) *http.Client {
+	if s.policy != editLockPolicyWait || client == nil {
+		return client
+	}
+	wrapped := *client
+	wrapped.Transport = &editLockTransport{base: client.Transport, maxWait: time.Duration(s.maxWait) * time.Second}
+	return &wrapped
+}
+
+// editLockTransport retries POST, PUT and DELETE requests rejected because
+// another admin holds the edit lock of the tenant. It wraps the transport of
+// the SDK's HTTP client, so each attempt still gets the short retries of its
+// retryablehttp policy; between attempts it backs off exponentially with
+// jitter until maxWait has elapsed, then hands the last conflict back to the
+// SDK.
+//
+// The SDK's request loop sits above the HTTP client and retries edit lock
+// conflicts itself, sending the request again under the same context. Those
+// retries share the deadline of the first one, so a write waits for the lock
+// for maxWait in total rather than maxWait per SDK retry.
+type editLockTransport struct {
+	base    http.RoundTripper
+	maxWait time.Duration
+
+	// deadlines holds the deadline of every write waiting for the lock,
+	// keyed by editLockWrite.
+	deadlines sync.Map
+}
+
+// editLockWrite identifies the SDK's retries of one write.
+type editLockWrite struct {
+	ctx    context.Context
+	method string
+	url    string
+}
+
+// deadline returns the time the write of req stops waiting for the lock,
+// and a function that forgets it once the write got past the lock. Writes
+// under a context that never ends each get their own deadline.
+func (t *editLockTransport) deadline(req *http.Request) (time.Time, func()) {
+	if req.Context().Done() == nil {
+		return time.Now().Add(t.maxWait), func() {}
+	}
+	key := editLockWrite{ctx: req.Context(), method: req.Method, url: req.URL.String()}
+	deadline, loaded := t.deadlines.LoadOrStore(key, time.Now().Add(t.maxWait))
+	if !loaded {
+		// A write that gave up keeps its deadline for the SDK's retries
+		// until its context ends.
+		context.AfterFunc(req.Context(), func() { t.deadlines.Delete(key) })
+	}
+	return deadline.(time.Time), func() { t.deadlines.Delete(key) }
+}
+
+func (t *editLockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete:
+	default:
+		return t.base.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	deadline, forget := t.deadline(req)
+	backoff := editLockInitialBackoff
+	for attempt := 1; ; attempt++ {
+		attemptReq := req.Clone(req.Context())
+		if body != nil {
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err != nil || !errorx.IsEditLockError(resp) {
+			forget()
+			return resp, err
+		}
+
+		wait := jitter(backoff)
+		if time.Now().Add(wait).After(deadline) {
+			log.Printf("[WARN] Edit lock still not available after %s, giving up on %s %s", t.maxWait, req.Method, req.URL.Path)
+			return resp, nil
+		}
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		holder := editLockHolder(string(respBody))
+		if holder == "" {
+			holder = "another admin"
+		}
+		log.Printf("[WARN] Edit lock held by %s (attempt %d of %s %s), retrying in %s", holder, attempt, req.Method, req.URL.Path, wait.Round(time.Millisecond))
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		backoff = min(2*backoff, editLockMaxBackoff)
+	}
+}
+
+// jitter spreads d by up to 20% either way, so runs blocked by the same
+// console session do not retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * (0.8 + 0.4*rand.Float64()))
+}
+
+var (
+	editLockHolderEmail  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	editLockHolderPhrase = regexp.MustCompile(`(?i)(?:held|locked|in use) by ([^,.;"]+)`)
+)
+
+// editLockHolder returns the admin named by an edit lock error, or "" when
+// the API does not reveal who holds the lock.
+func editLockHolder(message string) string {
+	if holder := editLockHolderEmail.FindString(message); holder != "" {
+		return holder
+	}
+	if m := editLockHolderPhrase.FindStringSubmatch(message); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	return ""
+}
+
+// isEditLockMessage reports whether an error message is one of the API's
+// edit lock conflicts.
+func isEditLockMessage(message string) bool {
+	return strings.Contains(message, "EDIT_LOCK_NOT_AVAILABLE") ||
+		strings.Contains(message, "Resource Access Blocked") ||
+		strings.Contains(message, "Failed during enter Org barrier")
+}
+
+// editLockExplanation describes an edit lock conflict and what the edit_lock
+// policy did about it.
+func editLockExplanation(settings editLockSettings, message string) string {
+	who := "Another admin"
+	if holder := editLockHolder(message); holder != "" {
+		who = holder
+	}
+	explanation := fmt.Sprintf("%s held the ZIA edit lock of the tenant, usually because of an unsaved change in the admin console.", who)
+	if settings.policy == editLockPolicyWait {
+		return explanation + fmt.Sprintf(" The provider waited up to %ds for the lock (edit_lock.max_wait_seconds).", settings.maxWait)
+	}
+	return explanation + " Set edit_lock { policy = \"wait\" } in the provider configuration to wait for the lock instead of failing."
+}
+
+// trackWritesForEditLock explains edit lock conflicts in the diagnostics of
+// a resource's Create, Update and Delete.
+func trackWritesForEditLock(r *schema.Resource) {
+	wrap := func(f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			diags := f(ctx, d, meta)
+			client, ok := meta.(*Client)
+			if !ok {
+				return diags
+			}
+			for i := range diags {
+				if diags[i].Severity == diag.Error && isEditLockMessage(diags[i].Summary+" "+diags[i].Detail) {
+					diags[i].Detail = strings.TrimSpace(diags[i].Detail + "\n\n" + editLockExplanation(client.editLock, diags[i].Summary+" "+diags[i].Detail))
+				}
+			}
+			return diags
+		}
+	}
+
+	r.CreateContext = wrap(r.CreateContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+}
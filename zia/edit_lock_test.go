@@ -0,0 +1,184 @@
+package zia
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const testEditLockBody = `{"code":"EDIT_LOCK_NOT_AVAILABLE","message":"Edit lock is held by jane.doe@acme.com"}`
+
+func shortEditLockBackoff(t *testing.T) {
+	t.Helper()
+	initial, maxBackoff := editLockInitialBackoff, editLockMaxBackoff
+	editLockInitialBackoff, editLockMaxBackoff = 10*time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { editLockInitialBackoff, editLockMaxBackoff = initial, maxBackoff })
+}
+
+func TestEditLockTransport(t *testing.T) {
+	shortEditLockBackoff(t)
+	var locked atomic.Int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if locked.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = io.WriteString(w, testEditLockBody)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := editLockSettings{policy: editLockPolicyWait, maxWait: 5}.waitHTTPClient(server.Client())
+
+	locked.Store(3)
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"name":"rule"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the write to succeed once the lock was released, got %d", resp.StatusCode)
+	}
+	if len(bodies) != 4 || bodies[3] != `{"name":"rule"}` {
+		t.Fatalf("expected 4 attempts that all resend the body, got %q", bodies)
+	}
+
+	// Reads are not retried, and a lock that outlives max_wait is returned.
+	locked.Store(1)
+	if resp, err = client.Get(server.URL); err != nil || resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected the GET conflict to be returned as is, got %v, %v", resp, err)
+	}
+	resp.Body.Close()
+
+	client = &http.Client{Transport: &editLockTransport{base: server.Client().Transport, maxWait: 15 * time.Millisecond}}
+	locked.Store(100)
+	resp, err = client.Post(server.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict || string(body) != testEditLockBody {
+		t.Fatalf("expected the last conflict after max_wait, got %d %q", resp.StatusCode, body)
+	}
+
+	if got := (editLockSettings{policy: editLockPolicyFail}).waitHTTPClient(server.Client()); got != server.Client() {
+		t.Error("expected the fail policy to leave the client unchanged")
+	}
+}
+
+func TestEditLockTransport_SDKRetriesShareTheWait(t *testing.T) {
+	shortEditLockBackoff(t)
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, testEditLockBody)
+	}))
+	defer server.Close()
+	transport := &editLockTransport{base: server.Client().Transport, maxWait: 50 * time.Millisecond}
+	client := &http.Client{Transport: transport}
+
+	// The SDK sends a write again under the same context after the transport
+	// handed the conflict back; the retry must not wait all over again.
+	ctx, cancel := context.WithCancel(context.Background())
+	post := func() {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/firewallFilteringRules", strings.NewReader(`{}`))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	post()
+	time.Sleep(50 * time.Millisecond) // the SDK's own backoff
+	first := attempts.Load()
+	post()
+	if got := attempts.Load() - first; got != 1 {
+		t.Fatalf("expected the SDK retry to be sent once without waiting, got %d attempts", got)
+	}
+
+	cancel()
+	for i := 0; ; i++ {
+		empty := true
+		transport.deadlines.Range(func(any, any) bool { empty = false; return false })
+		if empty {
+			break
+		}
+		if i == 100 {
+			t.Fatal("expected the deadline to be forgotten once the context ended")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEditLockHolder(t *testing.T) {
+	cases := map[string]string{
+		testEditLockBody: "jane.doe@acme.com",
+		`{"code":"EDIT_LOCK_NOT_AVAILABLE","message":"Configuration is locked by admin Jane Doe."}`: "admin Jane Doe",
+		`{"code":"EDIT_LOCK_NOT_AVAILABLE","message":"Edit lock not available"}`:                    "",
+	}
+	for message, want := range cases {
+		if got := editLockHolder(message); got != want {
+			t.Errorf("editLockHolder(%s) = %q, want %q", message, got, want)
+		}
+	}
+}
+
+func TestTrackWritesForEditLock(t *testing.T) {
+	r := &schema.Resource{
+		CreateContext: func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+			return diag.Errorf("error creating resource: %s", testEditLockBody)
+		},
+		UpdateContext: func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+			return diag.Errorf("error updating resource: DUPLICATE_ITEM")
+		},
+	}
+	trackWritesForEditLock(r)
+
+	diags := r.CreateContext(context.Background(), nil, &Client{editLock: editLockSettings{policy: editLockPolicyFail}})
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, "jane.doe@acme.com held the ZIA edit lock") ||
+		!strings.Contains(diags[0].Detail, `policy = "wait"`) {
+		t.Errorf("expected the fail policy to name the holder and suggest waiting, got %+v", diags)
+	}
+
+	diags = r.CreateContext(context.Background(), nil, &Client{editLock: editLockSettings{policy: editLockPolicyWait, maxWait: 300}})
+	if !strings.Contains(diags[0].Detail, "waited up to 300s") {
+		t.Errorf("expected the wait policy to report the wait, got %+v", diags)
+	}
+
+	if diags = r.UpdateContext(context.Background(), nil, &Client{}); diags[0].Detail != "" {
+		t.Errorf("expected other errors to be left alone, got %+v", diags)
+	}
+}
+
+func TestReadEditLockSettings(t *testing.T) {
+	if got := readEditLockSettings(frameworkConfigValues{}); got != (editLockSettings{policy: editLockPolicyFail, maxWait: defaultEditLockMaxWait}) {
+		t.Errorf("expected fail by default, got %+v", got)
+	}
+
+	t.Setenv("ZSCALER_EDIT_LOCK_POLICY", "WAIT")
+	t.Setenv("ZSCALER_EDIT_LOCK_MAX_WAIT_SECONDS", "60")
+	if got := readEditLockSettings(frameworkConfigValues{}); got != (editLockSettings{policy: editLockPolicyWait, maxWait: 60}) {
+		t.Errorf("from the environment: got %+v", got)
+	}
+	got := readEditLockSettings(frameworkConfigValues{"edit_lock": []interface{}{map[string]interface{}{"policy": "fail", "max_wait_seconds": 30}}})
+	if got != (editLockSettings{policy: editLockPolicyFail, maxWait: 30}) {
+		t.Errorf("from the block: got %+v", got)
+	}
+
+	if err := (editLockSettings{policy: "retry"}).validate(); err == nil {
+		t.Error("expected an unknown policy to fail")
+	}
+}
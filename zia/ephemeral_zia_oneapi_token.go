@@ -0,0 +1,87 @@
+package zia
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+)
+
+var (
+	_ ephemeral.EphemeralResource              = &oneAPITokenEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithConfigure = &oneAPITokenEphemeralResource{}
+)
+
+// NewOneAPITokenEphemeralResource issues a OneAPI access token with the
+// provider credentials, for use by other providers or provisioners that call
+// Zscaler APIs, without the token being written to plan or state.
+func NewOneAPITokenEphemeralResource() ephemeral.EphemeralResource {
+	return &oneAPITokenEphemeralResource{}
+}
+
+type oneAPITokenEphemeralResource struct {
+	client *Client
+}
+
+type oneAPITokenModel struct {
+	AccessToken types.String `tfsdk:"access_token"`
+	TokenType   types.String `tfsdk:"token_type"`
+	ExpiresAt   types.String `tfsdk:"expires_at"`
+}
+
+func (r *oneAPITokenEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_oneapi_token"
+}
+
+func (r *oneAPITokenEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Issues a OneAPI access token using the provider credentials. The token is never stored in plan or state.",
+		Attributes: map[string]schema.Attribute{
+			"access_token": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The OneAPI bearer token.",
+			},
+			"token_type": schema.StringAttribute{
+				Computed:    true,
+				Description: "The token type, normally Bearer.",
+			},
+			"expires_at": schema.StringAttribute{
+				Computed:    true,
+				Description: "Expiry of the token in RFC 3339 format.",
+			},
+		},
+	}
+}
+
+func (r *oneAPITokenEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	r.client = frameworkClient(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *oneAPITokenEphemeralResource) Open(ctx context.Context, _ ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	if frameworkInertClient(r.client, &resp.Diagnostics) {
+		return
+	}
+	if r.client == nil || r.client.oneAPIConfig == nil {
+		resp.Diagnostics.AddError("OneAPI credentials required",
+			"zia_oneapi_token requires the provider to authenticate through OneAPI with client_id and either client_secret or private_key. "+
+				"It is not available with the legacy API client or sandbox-only credentials.")
+		return
+	}
+
+	token, err := zscaler.Authenticate(ctx, r.client.oneAPIConfig, r.client.oneAPIConfig.Logger)
+	if err != nil {
+		resp.Diagnostics.AddError("error issuing OneAPI token", err.Error())
+		return
+	}
+
+	model := oneAPITokenModel{
+		AccessToken: types.StringValue(token.AccessToken),
+		TokenType:   types.StringValue(token.TokenType),
+		ExpiresAt:   types.StringValue(token.Expiry.UTC().Format(time.RFC3339)),
+	}
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &model)...)
+}
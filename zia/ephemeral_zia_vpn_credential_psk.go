@@ -0,0 +1,105 @@
+package zia
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	defaultVPNCredentialPSKLength = 32
+	vpnCredentialPSKAlphanumeric  = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	vpnCredentialPSKSpecial       = "!#%*+-.=_~"
+)
+
+var _ ephemeral.EphemeralResource = &vpnCredentialPSKEphemeralResource{}
+
+// NewVPNCredentialPSKEphemeralResource generates a pre-shared key for
+// zia_traffic_forwarding_vpn_credentials.pre_shared_key_wo without the value
+// ever being written to plan or state.
+func NewVPNCredentialPSKEphemeralResource() ephemeral.EphemeralResource {
+	return &vpnCredentialPSKEphemeralResource{}
+}
+
+type vpnCredentialPSKEphemeralResource struct{}
+
+type vpnCredentialPSKModel struct {
+	Length  types.Int64  `tfsdk:"length"`
+	Special types.Bool   `tfsdk:"special"`
+	Value   types.String `tfsdk:"value"`
+}
+
+func (r *vpnCredentialPSKEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_vpn_credential_psk"
+}
+
+func (r *vpnCredentialPSKEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Generates a random pre-shared key for a VPN credential. The key is never stored in plan or state.",
+		Attributes: map[string]schema.Attribute{
+			"length": schema.Int64Attribute{
+				Optional:    true,
+				Description: "Length of the generated key. Defaults to 32.",
+				Validators: []validator.Int64{
+					int64validator.Between(8, 128),
+				},
+			},
+			"special": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Include special characters in the generated key. Defaults to false.",
+			},
+			"value": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The generated pre-shared key.",
+			},
+		},
+	}
+}
+
+func (r *vpnCredentialPSKEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var model vpnCredentialPSKModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	length := defaultVPNCredentialPSKLength
+	if !model.Length.IsNull() && !model.Length.IsUnknown() {
+		length = int(model.Length.ValueInt64())
+	}
+	charset := vpnCredentialPSKAlphanumeric
+	if model.Special.ValueBool() {
+		charset += vpnCredentialPSKSpecial
+	}
+
+	psk, err := generateSecret(length, charset)
+	if err != nil {
+		resp.Diagnostics.AddError("error generating pre-shared key", err.Error())
+		return
+	}
+	model.Value = types.StringValue(psk)
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &model)...)
+}
+
+// generateSecret returns a random string of length characters drawn from
+// charset using crypto/rand.
+func generateSecret(length int, charset string) (string, error) {
+	max := big.NewInt(int64(len(charset)))
+	secret := make([]byte, length)
+	for i := range secret {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		secret[i] = charset[n.Int64()]
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,28 @@
+package zia
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateSecret(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		secret, err := generateSecret(defaultVPNCredentialPSKLength, vpnCredentialPSKAlphanumeric)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(secret) != defaultVPNCredentialPSKLength {
+			t.Fatalf("expected %d characters, got %d", defaultVPNCredentialPSKLength, len(secret))
+		}
+		for _, c := range secret {
+			if !strings.ContainsRune(vpnCredentialPSKAlphanumeric, c) {
+				t.Fatalf("unexpected character %q in %q", c, secret)
+			}
+		}
+		if seen[secret] {
+			t.Fatalf("generated the same key twice: %q", secret)
+		}
+		seen[secret] = true
+	}
+}
@@ -0,0 +1,576 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+)
+
+// ExportOptions controls ExportTenant.
+type ExportOptions struct {
+	// OutputDir receives one <resource type>.tf file per exported type.
+	OutputDir string
+	// ResourceTypes restricts the export to the given resource types. All
+	// exportable types are exported when it is empty.
+	ResourceTypes []string
+	// Exclude lists resource types that are never exported.
+	Exclude []string
+}
+
+// ExportReport summarizes an ExportTenant run.
+type ExportReport struct {
+	// Exported is the number of resources written per resource type.
+	Exported map[string]int
+	// Skipped maps resource types that were not exported to the reason.
+	Skipped map[string]string
+	// Errors holds the objects that were listed but could not be read.
+	Errors []error
+}
+
+// exportReferenceTypes maps the name of an ID reference attribute or block
+// (e.g. a rule's `location_ids = [...]` or `departments { id = [...] }`) to
+// the resource type the IDs belong to. IDs of exported objects are rendered
+// as references to their resource address; all others stay literal.
+var exportReferenceTypes = map[string]string{
+	"bandwidth_classes":           "zia_bandwidth_classes",
+	"dest_ip_groups":              "zia_firewall_filtering_destination_groups",
+	"dlp_dictionaries":            "zia_dlp_dictionaries",
+	"dlp_engines":                 "zia_dlp_engines",
+	"email_recipient_profiles":    "zia_email_profile",
+	"http_header_action_profiles": "zia_http_header_action_profile",
+	"http_header_profiles":        "zia_http_header_profile",
+	"location_ids":                "zia_location_management",
+	"locations":                   "zia_location_management",
+	"nw_application_groups":       "zia_firewall_filtering_network_application_groups",
+	"nw_service_groups":           "zia_firewall_filtering_network_service_groups",
+	"nw_services":                 "zia_firewall_filtering_network_service",
+	"proxy_gateways":              "zia_forwarding_control_proxies",
+	"source_ip_groups":            "zia_firewall_filtering_ip_source_groups",
+	"src_ip_groups":               "zia_firewall_filtering_ip_source_groups",
+	"url_categories":              "zia_url_categories",
+	"vpn_credentials":             "zia_traffic_forwarding_vpn_credentials",
+	"workload_groups":             "zia_workload_groups",
+	"zpa_gateway":                 "zia_forwarding_control_zpa_gateway",
+}
+
+// exportedResource is one object read through its resource type.
+type exportedResource struct {
+	Type     string
+	Label    string
+	ImportID string
+	Resource *schema.Resource
+	Data     *schema.ResourceData
+}
+
+func (r *exportedResource) address() hcl.Traversal {
+	return hcl.Traversal{
+		hcl.TraverseRoot{Name: r.Type},
+		hcl.TraverseAttr{Name: r.Label},
+	}
+}
+
+// ExportTenant reads every exportable object of the tenant through the
+// provider's own resource implementations and writes Terraform
+// configuration with import blocks for them to opts.OutputDir.
+func ExportTenant(ctx context.Context, service *zscaler.Service, opts ExportOptions) (*ExportReport, error) {
+	files, report := exportTenant(ctx, ZIAProvider().ResourcesMap, &Client{Service: service}, opts)
+
+	if err := os.MkdirAll(opts.OutputDir, 0o755); err != nil {
+		return report, err
+	}
+	for name, content := range files {
+		path := filepath.Join(opts.OutputDir, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return report, fmt.Errorf("writing %s: %w", path, err)
+		}
+	}
+	return report, nil
+}
+
+func exportTenant(ctx context.Context, resources map[string]*schema.Resource, client *Client, opts ExportOptions) (map[string][]byte, *ExportReport) {
+	report := &ExportReport{
+		Exported: map[string]int{},
+		Skipped:  map[string]string{},
+	}
+
+	selected := map[string]bool{}
+	for _, t := range opts.ResourceTypes {
+		selected[t] = true
+	}
+	excluded := map[string]bool{}
+	for _, t := range opts.Exclude {
+		excluded[t] = true
+	}
+
+	types := make([]string, 0, len(resources))
+	for t := range resources {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	exporter := newTenantExporter()
+	for _, resourceType := range types {
+		switch {
+		case len(selected) > 0 && !selected[resourceType], excluded[resourceType]:
+			report.Skipped[resourceType] = "not selected"
+			continue
+		case exportListers[resourceType] == nil:
+			report.Skipped[resourceType] = "no exporter available for this resource type"
+			continue
+		}
+
+		objects, err := exportListers[resourceType](ctx, client.Service)
+		if err != nil {
+			report.Skipped[resourceType] = fmt.Sprintf("listing failed: %v", err)
+			continue
+		}
+		log.Printf("[INFO] Exporting %d %s objects", len(objects), resourceType)
+
+		r := resources[resourceType]
+		for _, obj := range objects {
+			d, err := exportReadObject(ctx, r, client, obj.ID)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Errorf("%s %q (%s): %w", resourceType, obj.Name, obj.ID, err))
+				continue
+			}
+			if d == nil {
+				continue
+			}
+			exporter.add(resourceType, obj, r, d)
+			report.Exported[resourceType]++
+		}
+	}
+
+	return exporter.render(), report
+}
+
+// exportReadObject reads one object the way terraform import does: the
+// resource's importer followed by its Read. It returns nil when the object
+// disappeared in the meantime.
+func exportReadObject(ctx context.Context, r *schema.Resource, client *Client, id string) (*schema.ResourceData, error) {
+	d := r.Data(nil)
+	d.SetId(id)
+
+	if r.Importer != nil && r.Importer.StateContext != nil {
+		imported, err := r.Importer.StateContext(ctx, d, client)
+		if err != nil {
+			return nil, err
+		}
+		if len(imported) == 0 {
+			return nil, fmt.Errorf("import returned no state")
+		}
+		d = imported[0]
+	}
+
+	if diags := r.ReadContext(ctx, d, client); diags.HasError() {
+		for _, diag := range diags {
+			return nil, fmt.Errorf("%s: %s", diag.Summary, diag.Detail)
+		}
+	}
+	if d.Id() == "" {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// tenantExporter assigns resource labels and renders the exported objects,
+// resolving ID references between them.
+type tenantExporter struct {
+	resources []*exportedResource
+	labels    map[string]map[string]bool
+	addresses map[string]map[string]*exportedResource
+}
+
+func newTenantExporter() *tenantExporter {
+	return &tenantExporter{
+		labels:    map[string]map[string]bool{},
+		addresses: map[string]map[string]*exportedResource{},
+	}
+}
+
+func (e *tenantExporter) add(resourceType string, obj exportObject, r *schema.Resource, d *schema.ResourceData) {
+	if e.labels[resourceType] == nil {
+		e.labels[resourceType] = map[string]bool{}
+		e.addresses[resourceType] = map[string]*exportedResource{}
+	}
+
+	label := exportLabel(obj.Name, obj.ID)
+	for i := 2; e.labels[resourceType][label]; i++ {
+		label = fmt.Sprintf("%s_%d", exportLabel(obj.Name, obj.ID), i)
+	}
+	e.labels[resourceType][label] = true
+
+	res := &exportedResource{
+		Type:     resourceType,
+		Label:    label,
+		ImportID: obj.ID,
+		Resource: r,
+		Data:     d,
+	}
+	e.resources = append(e.resources, res)
+	e.addresses[resourceType][obj.ID] = res
+	if d.Id() != obj.ID {
+		e.addresses[resourceType][d.Id()] = res
+	}
+}
+
+var exportLabelInvalid = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// exportLabel derives a Terraform resource label from an object name.
+func exportLabel(name, id string) string {
+	label := strings.Trim(exportLabelInvalid.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if label == "" {
+		label = "id_" + strings.Trim(exportLabelInvalid.ReplaceAllString(strings.ToLower(id), "_"), "_")
+	}
+	if label[0] >= '0' && label[0] <= '9' {
+		label = "r_" + label
+	}
+	return label
+}
+
+// render returns the generated files keyed by file name.
+func (e *tenantExporter) render() map[string][]byte {
+	byType := map[string]*hclwrite.File{}
+	var order []string
+	for _, res := range e.resources {
+		f, ok := byType[res.Type]
+		if !ok {
+			f = hclwrite.NewEmptyFile()
+			byType[res.Type] = f
+			order = append(order, res.Type)
+		} else {
+			f.Body().AppendNewline()
+		}
+		e.renderResource(f.Body(), res)
+	}
+
+	files := map[string][]byte{}
+	for _, t := range order {
+		files[t+".tf"] = hclwrite.Format(byType[t].Bytes())
+	}
+	if len(order) > 0 {
+		files["versions.tf"] = []byte(exportVersionsFile)
+	}
+	return files
+}
+
+const exportVersionsFile = `terraform {
+  required_version = ">= 1.5"
+  required_providers {
+    zia = {
+      source = "zscaler/zia"
+    }
+  }
+}
+`
+
+func (e *tenantExporter) renderResource(body *hclwrite.Body, res *exportedResource) {
+	importBlock := body.AppendNewBlock("import", nil)
+	importBlock.Body().SetAttributeTraversal("to", res.address())
+	importBlock.Body().SetAttributeValue("id", cty.StringVal(res.ImportID))
+	body.AppendNewline()
+
+	// The top-level id is the object ID, which the import block carries.
+	schemaMap := make(map[string]*schema.Schema, len(res.Resource.Schema))
+	values := make(map[string]interface{}, len(res.Resource.Schema))
+	for key, s := range res.Resource.Schema {
+		if key == "id" && !s.Required {
+			continue
+		}
+		schemaMap[key] = s
+		values[key] = res.Data.Get(key)
+	}
+	block := body.AppendNewBlock("resource", []string{res.Type, res.Label})
+	e.renderBody(block.Body(), res, schemaMap, values)
+}
+
+// renderBody writes the configurable attributes of values, attributes first
+// and nested blocks after them, each in alphabetical order.
+func (e *tenantExporter) renderBody(body *hclwrite.Body, self *exportedResource, schemaMap map[string]*schema.Schema, values map[string]interface{}) {
+	keys := make([]string, 0, len(schemaMap))
+	for key := range schemaMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	written := map[string]bool{}
+	conflicts := func(s *schema.Schema) bool {
+		for _, other := range s.ConflictsWith {
+			if written[other] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var blocks []string
+	for _, key := range keys {
+		s := schemaMap[key]
+		if !exportConfigurable(s) || conflicts(s) {
+			continue
+		}
+		if _, nested := s.Elem.(*schema.Resource); nested {
+			blocks = append(blocks, key)
+			continue
+		}
+		if s.Sensitive || s.WriteOnly {
+			if s.Required {
+				body.AppendUnstructuredTokens(exportComment(fmt.Sprintf("%s is sensitive and must be set before applying", key)))
+			}
+			continue
+		}
+
+		value := exportNormalize(values[key])
+		if !s.Required && exportIsDefault(s, value) {
+			continue
+		}
+		written[key] = true
+		if tokens, ok := e.referenceTokens(self, key, s, value); ok {
+			body.SetAttributeRaw(key, tokens)
+			continue
+		}
+		body.SetAttributeValue(key, exportValue(s, value))
+	}
+
+	for _, key := range blocks {
+		s := schemaMap[key]
+		elem := s.Elem.(*schema.Resource)
+		items, _ := exportNormalize(values[key]).([]interface{})
+		for _, item := range items {
+			itemValues, ok := item.(map[string]interface{})
+			if !ok || exportBlockEmpty(elem.Schema, itemValues) {
+				continue
+			}
+			nested := body.AppendNewBlock(key, nil)
+			if refType, ok := exportReferenceTypes[key]; ok {
+				e.renderReferenceBlock(nested.Body(), self, refType, elem.Schema, itemValues)
+				continue
+			}
+			e.renderBody(nested.Body(), self, elem.Schema, itemValues)
+		}
+	}
+}
+
+// renderReferenceBlock renders ID blocks such as `locations { id = [...] }`
+// with their IDs resolved against the exported objects of refType.
+func (e *tenantExporter) renderReferenceBlock(body *hclwrite.Body, self *exportedResource, refType string, schemaMap map[string]*schema.Schema, values map[string]interface{}) {
+	idSchema, ok := schemaMap["id"]
+	if !ok {
+		e.renderBody(body, self, schemaMap, values)
+		return
+	}
+
+	rest := make(map[string]*schema.Schema, len(schemaMap))
+	for key, s := range schemaMap {
+		if key != "id" {
+			rest[key] = s
+		}
+	}
+
+	value := exportNormalize(values["id"])
+	if !exportIsDefault(idSchema, value) {
+		if tokens, ok := e.resolveTokens(self, refType, value); ok {
+			body.SetAttributeRaw("id", tokens)
+		} else {
+			body.SetAttributeValue("id", exportValue(idSchema, value))
+		}
+	}
+	e.renderBody(body, self, rest, values)
+}
+
+// referenceTokens resolves top-level reference attributes such as
+// url_categories = [...].
+func (e *tenantExporter) referenceTokens(self *exportedResource, key string, s *schema.Schema, value interface{}) (hclwrite.Tokens, bool) {
+	refType, ok := exportReferenceTypes[key]
+	if !ok {
+		return nil, false
+	}
+	if _, primitive := s.Elem.(*schema.Schema); !primitive && s.Type != schema.TypeString && s.Type != schema.TypeInt {
+		return nil, false
+	}
+	return e.resolveTokens(self, refType, value)
+}
+
+// resolveTokens renders value, an ID or a list of IDs, with every ID of an
+// exported refType object replaced by a reference to it. It reports false
+// when no ID could be resolved.
+func (e *tenantExporter) resolveTokens(self *exportedResource, refType string, value interface{}) (hclwrite.Tokens, bool) {
+	targets := e.addresses[refType]
+	if len(targets) == 0 {
+		return nil, false
+	}
+
+	resolve := func(v interface{}) (hclwrite.Tokens, bool) {
+		target, ok := targets[fmt.Sprint(v)]
+		if !ok || target == self {
+			return hclwrite.TokensForValue(exportPrimitive(v)), false
+		}
+		traversal := append(target.address(), hcl.TraverseAttr{Name: "id"})
+		return hclwrite.TokensForTraversal(traversal), true
+	}
+
+	list, isList := value.([]interface{})
+	if !isList {
+		return resolve(value)
+	}
+
+	resolved := false
+	elems := make([]hclwrite.Tokens, 0, len(list))
+	for _, v := range list {
+		tokens, ok := resolve(v)
+		resolved = resolved || ok
+		elems = append(elems, tokens)
+	}
+	return hclwrite.TokensForTuple(elems), resolved
+}
+
+// exportConfigurable reports whether an attribute belongs in configuration.
+func exportConfigurable(s *schema.Schema) bool {
+	if s.Deprecated != "" {
+		return false
+	}
+	return s.Required || s.Optional
+}
+
+// exportNormalize turns sets into lists, recursively. Sets of primitives
+// are sorted so the output is stable between runs.
+func exportNormalize(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case *schema.Set:
+		list := exportNormalize(typed.List()).([]interface{})
+		sort.SliceStable(list, func(i, j int) bool {
+			a, aInt := list[i].(int)
+			b, bInt := list[j].(int)
+			if aInt && bInt {
+				return a < b
+			}
+			_, aMap := list[i].(map[string]interface{})
+			_, bMap := list[j].(map[string]interface{})
+			if aMap || bMap {
+				return false
+			}
+			return fmt.Sprint(list[i]) < fmt.Sprint(list[j])
+		})
+		return list
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, item := range typed {
+			out[i] = exportNormalize(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for k, item := range typed {
+			out[k] = exportNormalize(item)
+		}
+		return out
+	}
+	return v
+}
+
+// exportIsDefault reports whether value is what the attribute holds when it
+// is left out of the configuration.
+func exportIsDefault(s *schema.Schema, value interface{}) bool {
+	if s.Default != nil {
+		return fmt.Sprint(s.Default) == fmt.Sprint(value)
+	}
+	switch typed := value.(type) {
+	case nil:
+		return true
+	case string:
+		return typed == ""
+	case int:
+		return typed == 0
+	case float64:
+		return typed == 0
+	case bool:
+		return !typed
+	case []interface{}:
+		return len(typed) == 0
+	case map[string]interface{}:
+		return len(typed) == 0
+	}
+	return false
+}
+
+func exportBlockEmpty(schemaMap map[string]*schema.Schema, values map[string]interface{}) bool {
+	for key, s := range schemaMap {
+		if exportConfigurable(s) && !exportIsDefault(s, exportNormalize(values[key])) {
+			return false
+		}
+	}
+	return true
+}
+
+// exportValue converts a normalized ResourceData value to cty.
+func exportValue(s *schema.Schema, value interface{}) cty.Value {
+	switch typed := value.(type) {
+	case []interface{}:
+		elems := make([]cty.Value, 0, len(typed))
+		for _, v := range typed {
+			elems = append(elems, exportPrimitive(v))
+		}
+		if len(elems) == 0 {
+			return cty.ListValEmpty(exportElemType(s))
+		}
+		return cty.TupleVal(elems)
+	case map[string]interface{}:
+		if len(typed) == 0 {
+			return cty.MapValEmpty(cty.String)
+		}
+		attrs := make(map[string]cty.Value, len(typed))
+		for k, v := range typed {
+			attrs[k] = exportPrimitive(v)
+		}
+		return cty.ObjectVal(attrs)
+	}
+	return exportPrimitive(value)
+}
+
+func exportElemType(s *schema.Schema) cty.Type {
+	if elem, ok := s.Elem.(*schema.Schema); ok {
+		switch elem.Type {
+		case schema.TypeInt, schema.TypeFloat:
+			return cty.Number
+		case schema.TypeBool:
+			return cty.Bool
+		}
+	}
+	return cty.String
+}
+
+func exportPrimitive(v interface{}) cty.Value {
+	switch typed := v.(type) {
+	case string:
+		return cty.StringVal(typed)
+	case int:
+		return cty.NumberIntVal(int64(typed))
+	case int64:
+		return cty.NumberIntVal(typed)
+	case float64:
+		return cty.NumberFloatVal(typed)
+	case bool:
+		return cty.BoolVal(typed)
+	case nil:
+		return cty.NullVal(cty.String)
+	}
+	return cty.StringVal(fmt.Sprint(v))
+}
+
+func exportComment(text string) hclwrite.Tokens {
+	return hclwrite.Tokens{
+		{Type: hclsyntax.TokenComment, Bytes: []byte("# " + text + "\n")},
+	}
+}
@@ -0,0 +1,262 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/adminuserrolemgmt/admins"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/adminuserrolemgmt/roles"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/alerts"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/bandwidth_control/bandwidth_classes"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/bandwidth_control/bandwidth_control_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/dlp/dlp_engines"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/dlp/dlp_notification_templates"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/dlp/dlp_web_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/dlp/dlpdictionaries"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/email_profiles"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/endpoint_dlp/endpoint_dlp_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/filetypecontrol"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewalldnscontrolpolicies"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/dns_application_groups"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/filteringrules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/ipdestinationgroups"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/ipsourcegroups"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/networkapplicationgroups"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/networkservicegroups"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/networkservices"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/forwarding_control_policy/forwarding_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/forwarding_control_policy/proxies"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/forwarding_control_policy/zpa_gateways"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/http_header_control/http_header_action_profile"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/http_header_control/http_header_profile"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/ips_control_policies/ips_policies"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/location/locationmanagement"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/nat_control_policies"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/sandbox/sandbox_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/sslinspection"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/traffic_capture"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/trafficforwarding/dc_exclusions"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/trafficforwarding/extranet"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/trafficforwarding/gretunnels"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/trafficforwarding/staticips"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/trafficforwarding/sub_clouds"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/trafficforwarding/vpncredentials"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/urlcategories"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/urlfilteringpolicies"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/workloadgroups"
+)
+
+// exportObject is one tenant object found by an exportLister: the ID its
+// resource type imports by, a name used to derive the resource label and,
+// for rules, the rule order.
+type exportObject struct {
+	ID    string
+	Name  string
+	Order int
+}
+
+// exportLister enumerates the objects of one resource type with the SDK
+// GetAll functions the data sources use.
+type exportLister func(ctx context.Context, service *zscaler.Service) ([]exportObject, error)
+
+// exportListers maps every exportable resource type to its lister. Resource
+// types of ZIAProvider().ResourcesMap without an entry are reported as
+// skipped by the exporter.
+var exportListers = map[string]exportLister{
+	// Rules
+	"zia_bandwidth_control_rule":  exportListAll(bandwidth_control_rules.GetAll),
+	"zia_dlp_web_rules":           exportListAll(dlp_web_rules.GetAll),
+	"zia_endpoint_dlp_rules":      exportListAll(endpoint_dlp_rules.GetAll),
+	"zia_file_type_control_rules": exportListAll(filetypecontrol.GetAll),
+	"zia_firewall_dns_rule":       exportListAll(firewalldnscontrolpolicies.GetAll),
+	"zia_firewall_filtering_rule": exportListAll(func(ctx context.Context, service *zscaler.Service) ([]filteringrules.FirewallFilteringRules, error) {
+		return filteringrules.GetAll(ctx, service, nil)
+	}),
+	"zia_firewall_ips_rule":       exportListAll(ips_policies.GetAll),
+	"zia_forwarding_control_rule": exportListAll(forwarding_rules.GetAll),
+	"zia_nat_control_rules":       exportListAll(nat_control_policies.GetAll),
+	"zia_sandbox_rules":           exportListAll(sandbox_rules.GetAll),
+	"zia_ssl_inspection_rules":    exportListAll(sslinspection.GetAll),
+	"zia_traffic_capture_rules": exportListAll(func(ctx context.Context, service *zscaler.Service) ([]traffic_capture.TrafficCaptureRules, error) {
+		return traffic_capture.GetAll(ctx, service, nil)
+	}),
+	"zia_url_filtering_rules": exportListAll(urlfilteringpolicies.GetAll),
+
+	// Objects referenced by rules
+	"zia_bandwidth_classes":      exportListAll(bandwidth_classes.GetAll),
+	"zia_dns_application_groups": exportListAll(dns_application_groups.GetAll),
+	"zia_dlp_dictionaries": exportListFiltered(dlpdictionaries.GetAll, func(d dlpdictionaries.DlpDictionary) bool {
+		return d.Custom
+	}),
+	"zia_dlp_engines": exportListFiltered(dlp_engines.GetAll, func(e dlp_engines.DLPEngines) bool {
+		return e.CustomDlpEngine
+	}),
+	"zia_dlp_notification_templates": exportListAll(dlp_notification_templates.GetAll),
+	"zia_email_profile": exportListAll(func(ctx context.Context, service *zscaler.Service) ([]email_profiles.EmailProfiles, error) {
+		return email_profiles.GetAll(ctx, service, nil)
+	}),
+	"zia_firewall_filtering_destination_groups": exportListAll(func(ctx context.Context, service *zscaler.Service) ([]ipdestinationgroups.IPDestinationGroups, error) {
+		return ipdestinationgroups.GetAll(ctx, service, "")
+	}),
+	"zia_firewall_filtering_ip_source_groups":           exportListAll(ipsourcegroups.GetAll),
+	"zia_firewall_filtering_network_application_groups": exportListAll(networkapplicationgroups.GetAllNetworkApplicationGroups),
+	"zia_firewall_filtering_network_service": exportListFiltered(func(ctx context.Context, service *zscaler.Service) ([]networkservices.NetworkServices, error) {
+		return networkservices.GetAllNetworkServices(ctx, service, nil, nil)
+	}, func(s networkservices.NetworkServices) bool {
+		return s.Type == "CUSTOM"
+	}),
+	"zia_firewall_filtering_network_service_groups": exportListAll(networkservicegroups.GetAllNetworkServiceGroups),
+	"zia_forwarding_control_proxies":                exportListAll(proxies.GetAll),
+	"zia_forwarding_control_zpa_gateway":            exportListAll(zpa_gateways.GetAll),
+	"zia_http_header_action_profile":                exportListAll(http_header_action_profile.GetAll),
+	"zia_http_header_profile":                       exportListAll(http_header_profile.GetAll),
+	"zia_location_management": exportListAll(func(ctx context.Context, service *zscaler.Service) ([]locationmanagement.Locations, error) {
+		locations, err := locationmanagement.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		sublocations, err := locationmanagement.GetAllSublocations(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		return append(locations, sublocations...), nil
+	}),
+	"zia_url_categories": exportListAll(func(ctx context.Context, service *zscaler.Service) ([]urlcategories.URLCategory, error) {
+		return urlcategories.GetAll(ctx, service, true, false, "ALL")
+	}),
+	"zia_workload_groups": exportListAll(workloadgroups.GetAll),
+
+	// Administration and traffic forwarding
+	"zia_admin_roles":        exportListAll(roles.GetAllAdminRoles),
+	"zia_admin_users":        exportListAll(admins.GetAllAdminUsers),
+	"zia_dc_exclusions":      exportListAll(dc_exclusions.GetAll),
+	"zia_subscription_alert": exportListAll(alerts.GetAll),
+	"zia_extranet": exportListAll(func(ctx context.Context, service *zscaler.Service) ([]extranet.Extranet, error) {
+		return extranet.GetAll(ctx, service, nil)
+	}),
+	"zia_sub_cloud":                          exportListAll(sub_clouds.GetAll),
+	"zia_traffic_forwarding_gre_tunnel":      exportListAll(gretunnels.GetAll),
+	"zia_traffic_forwarding_static_ip":       exportListAll(staticips.GetAll),
+	"zia_traffic_forwarding_vpn_credentials": exportListAll(vpncredentials.GetAll),
+
+	// Tenant-wide settings, imported by a fixed ID
+	"zia_advanced_settings":                    exportSingleton("advanced_settings"),
+	"zia_advanced_threat_settings":             exportSingleton("advanced_threat_settings"),
+	"zia_atp_malicious_urls":                   exportSingleton("all_urls"),
+	"zia_atp_malware_inspection":               exportSingleton("inspection"),
+	"zia_atp_malware_policy":                   exportSingleton("policy"),
+	"zia_atp_malware_protocols":                exportSingleton("protocol"),
+	"zia_atp_malware_settings":                 exportSingleton("malware_settings"),
+	"zia_atp_security_exceptions":              exportSingleton("bypass_url"),
+	"zia_auth_settings_urls":                   exportSingleton("all_urls"),
+	"zia_browser_control_policy":               exportSingleton("browser_settings"),
+	"zia_dlp_global_options":                   exportSingleton("dlp_global_options"),
+	"zia_end_user_notification":                exportSingleton("enduser_notification"),
+	"zia_ftp_control_policy":                   exportSingleton("ftp_control"),
+	"zia_mobile_malware_protection_policy":     exportSingleton("mobile_settings"),
+	"zia_sandbox_behavioral_analysis_v2":       exportSingleton("sandbox_settings"),
+	"zia_security_settings":                    exportSingleton("all_urls"),
+	"zia_url_filtering_and_cloud_app_settings": exportSingleton("app_setting"),
+}
+
+// exportListAll adapts an SDK GetAll function. Predefined, default and
+// non-editable objects are left out since Terraform cannot manage them.
+func exportListAll[T any](list func(context.Context, *zscaler.Service) ([]T, error)) exportLister {
+	return exportListFiltered(list, nil)
+}
+
+// exportListFiltered is exportListAll with an additional filter; only items
+// for which keep returns true are exported.
+func exportListFiltered[T any](list func(context.Context, *zscaler.Service) ([]T, error), keep func(T) bool) exportLister {
+	return func(ctx context.Context, service *zscaler.Service) ([]exportObject, error) {
+		items, err := list(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		objects := make([]exportObject, 0, len(items))
+		for _, item := range items {
+			if keep != nil && !keep(item) {
+				continue
+			}
+			obj, ok := exportObjectFrom(item)
+			if !ok {
+				continue
+			}
+			objects = append(objects, obj)
+		}
+		return objects, nil
+	}
+}
+
+func exportSingleton(id string) exportLister {
+	return func(context.Context, *zscaler.Service) ([]exportObject, error) {
+		return []exportObject{{ID: id, Name: id}}, nil
+	}
+}
+
+var (
+	exportIDFields   = []string{"ID", "DcID"}
+	exportNameFields = []string{"Name", "ConfiguredName", "LoginName", "FQDN", "IPAddress", "IpAddress", "SourceIP", "Email", "DcName"}
+	exportSkipFields = []string{"Predefined", "DefaultRule", "IsNonEditable"}
+)
+
+// exportObjectFrom reads the ID and a display name from an SDK struct. It
+// returns false for objects flagged as predefined or non-editable, and for
+// objects without an ID.
+func exportObjectFrom(item interface{}) (exportObject, bool) {
+	v := reflect.Indirect(reflect.ValueOf(item))
+	if v.Kind() != reflect.Struct {
+		return exportObject{}, false
+	}
+
+	for _, name := range exportSkipFields {
+		if f := v.FieldByName(name); f.IsValid() && f.Kind() == reflect.Bool && f.Bool() {
+			return exportObject{}, false
+		}
+	}
+
+	var obj exportObject
+	for _, name := range exportIDFields {
+		if id := exportFieldString(v.FieldByName(name)); id != "" && id != "0" {
+			obj.ID = id
+			break
+		}
+	}
+	if obj.ID == "" {
+		return exportObject{}, false
+	}
+	for _, name := range exportNameFields {
+		if n := exportFieldString(v.FieldByName(name)); n != "" {
+			obj.Name = n
+			break
+		}
+	}
+	if f := v.FieldByName("Order"); f.IsValid() && f.Kind() == reflect.Int {
+		obj.Order = int(f.Int())
+	}
+	return obj, true
+}
+
+func exportFieldString(f reflect.Value) string {
+	if !f.IsValid() {
+		return ""
+	}
+	switch f.Kind() {
+	case reflect.String:
+		return f.String()
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(f.Int(), 10)
+	case reflect.Ptr:
+		if f.IsNil() {
+			return ""
+		}
+		return exportFieldString(f.Elem())
+	case reflect.Struct:
+		// Embedded references such as common.IDNameExtensions.
+		return exportFieldString(f.FieldByName("Name"))
+	}
+	return fmt.Sprint(f.Interface())
+}
@@ -0,0 +1,149 @@
+package zia
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+)
+
+func TestExportTenant_ResolvesReferences(t *testing.T) {
+	locations := map[string]map[string]interface{}{
+		"101": {"name": "HQ Office", "description": "Main site"},
+		"102": {"name": "HQ-Office", "description": ""},
+	}
+	locationResource := &schema.Resource{
+		Importer: &schema.ResourceImporter{StateContext: schema.ImportStatePassthroughContext},
+		ReadContext: func(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+			for k, v := range locations[d.Id()] {
+				_ = d.Set(k, v)
+			}
+			return nil
+		},
+		Schema: map[string]*schema.Schema{
+			"name":        {Type: schema.TypeString, Required: true},
+			"description": {Type: schema.TypeString, Optional: true},
+			"location_id": {Type: schema.TypeInt, Computed: true},
+		},
+	}
+
+	maxItems := 0
+	ruleResource := &schema.Resource{
+		ReadContext: func(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+			_ = d.Set("name", "Block all")
+			_ = d.Set("order", 1)
+			_ = d.Set("enabled", false)
+			_ = d.Set("password", "secret")
+			_ = d.Set("locations", []interface{}{map[string]interface{}{"id": []interface{}{101, 999}}})
+			return nil
+		},
+		Schema: map[string]*schema.Schema{
+			"name":      {Type: schema.TypeString, Required: true},
+			"order":     {Type: schema.TypeInt, Required: true},
+			"enabled":   {Type: schema.TypeBool, Optional: true, Default: true},
+			"password":  {Type: schema.TypeString, Optional: true, Sensitive: true},
+			"rule_id":   {Type: schema.TypeInt, Computed: true},
+			"locations": setIDsSchemaTypeCustom(&maxItems, "locations"),
+		},
+	}
+
+	// Stand in for the real listers of the two types.
+	savedLocations, savedRules := exportListers["zia_location_management"], exportListers["zia_firewall_filtering_rule"]
+	defer func() {
+		exportListers["zia_location_management"], exportListers["zia_firewall_filtering_rule"] = savedLocations, savedRules
+	}()
+	exportListers["zia_location_management"] = func(context.Context, *zscaler.Service) ([]exportObject, error) {
+		return []exportObject{{ID: "101", Name: "HQ Office"}, {ID: "102", Name: "HQ-Office"}}, nil
+	}
+	exportListers["zia_firewall_filtering_rule"] = func(context.Context, *zscaler.Service) ([]exportObject, error) {
+		return []exportObject{{ID: "7", Name: "Block all"}}, nil
+	}
+
+	resources := map[string]*schema.Resource{
+		"zia_firewall_filtering_rule": ruleResource,
+		"zia_location_management":     locationResource,
+		"zia_test_unlisted":           {Schema: map[string]*schema.Schema{}},
+	}
+	files, report := exportTenant(context.Background(), resources, &Client{}, ExportOptions{})
+
+	if len(report.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", report.Errors)
+	}
+	if report.Exported["zia_location_management"] != 2 || report.Exported["zia_firewall_filtering_rule"] != 1 {
+		t.Errorf("unexpected export counts: %v", report.Exported)
+	}
+	if _, ok := report.Skipped["zia_test_unlisted"]; !ok {
+		t.Error("resource type without a lister was not reported as skipped")
+	}
+
+	locationsFile := string(files["zia_location_management.tf"])
+	for _, want := range []string{
+		"to = zia_location_management.hq_office\n",
+		"to = zia_location_management.hq_office_2\n",
+		`id = "101"`,
+		`description = "Main site"`,
+	} {
+		if !strings.Contains(locationsFile, want) {
+			t.Errorf("zia_location_management.tf does not contain %q:\n%s", want, locationsFile)
+		}
+	}
+	if strings.Contains(locationsFile, "location_id") {
+		t.Errorf("computed attribute exported:\n%s", locationsFile)
+	}
+
+	rulesFile := string(files["zia_firewall_filtering_rule.tf"])
+	for _, want := range []string{
+		`resource "zia_firewall_filtering_rule" "block_all"`,
+		"enabled = false",
+		"id = [zia_location_management.hq_office.id, 999]",
+	} {
+		if !strings.Contains(rulesFile, want) {
+			t.Errorf("zia_firewall_filtering_rule.tf does not contain %q:\n%s", want, rulesFile)
+		}
+	}
+	if strings.Contains(rulesFile, "secret") {
+		t.Errorf("sensitive value exported:\n%s", rulesFile)
+	}
+	if _, ok := files["versions.tf"]; !ok {
+		t.Error("versions.tf was not generated")
+	}
+}
+
+func TestExportLabel(t *testing.T) {
+	cases := map[[2]string]string{
+		{"HQ Office", "1"}:          "hq_office",
+		{"  --Mixed.Case--  ", "1"}: "mixed_case",
+		{"10.0.0.1", "5"}:           "r_10_0_0_1",
+		{"", "CUSTOM_01"}:           "id_custom_01",
+	}
+	for in, want := range cases {
+		if got := exportLabel(in[0], in[1]); got != want {
+			t.Errorf("exportLabel(%q, %q) = %q, want %q", in[0], in[1], got, want)
+		}
+	}
+}
+
+func TestExportObjectFrom(t *testing.T) {
+	type rule struct {
+		ID         int
+		Name       string
+		Predefined bool
+	}
+	if obj, ok := exportObjectFrom(rule{ID: 3, Name: "r"}); !ok || obj.ID != "3" || obj.Name != "r" {
+		t.Errorf("unexpected object %+v, %v", obj, ok)
+	}
+	if _, ok := exportObjectFrom(rule{ID: 4, Predefined: true}); ok {
+		t.Error("predefined objects must not be exported")
+	}
+
+	type category struct {
+		ID             string
+		ConfiguredName string
+	}
+	if obj, ok := exportObjectFrom(category{ID: "CUSTOM_01", ConfiguredName: "Partners"}); !ok || obj.Name != "Partners" {
+		t.Errorf("unexpected object %+v, %v", obj, ok)
+	}
+}
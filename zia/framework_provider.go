@@ -0,0 +1,374 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
+	sdkdiag "github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	sdkschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/terraform-provider-zia/v4/zia/common"
+)
+
+// ProviderServerFactory returns the provider server Terraform talks to: the
+// SDKv2 provider from ZIAProvider and the plugin framework provider muxed
+// behind a single protocol 5 server. Resources move from the former to the
+// latter one at a time; the two must never register the same type name.
+func ProviderServerFactory(ctx context.Context) (func() tfprotov5.ProviderServer, error) {
+	return newMuxServerFactory(ctx, ZIAProvider())
+}
+
+func newMuxServerFactory(ctx context.Context, sdkProvider *sdkschema.Provider) (func() tfprotov5.ProviderServer, error) {
+	providers := []func() tfprotov5.ProviderServer{
+		sdkProvider.GRPCProvider,
+		providerserver.NewProtocol5(NewFrameworkProvider(sdkProvider)),
+	}
+
+	muxServer, err := tf5muxserver.NewMuxServer(ctx, providers...)
+	if err != nil {
+		return nil, err
+	}
+	return muxServer.ProviderServer, nil
+}
+
+// frameworkProvider is the plugin framework half of the provider. Its
+// provider schema is derived from the SDKv2 one so the two always agree, as
+// the mux server requires.
+type frameworkProvider struct {
+	sdkProvider *sdkschema.Provider
+}
+
+var (
+	_ provider.Provider                       = &frameworkProvider{}
+	_ provider.ProviderWithEphemeralResources = &frameworkProvider{}
+	_ provider.ProviderWithFunctions          = &frameworkProvider{}
+)
+
+func NewFrameworkProvider(sdkProvider *sdkschema.Provider) provider.Provider {
+	return &frameworkProvider{sdkProvider: sdkProvider}
+}
+
+func (p *frameworkProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "zia"
+	resp.Version = common.Version()
+}
+
+func (p *frameworkProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	attributes, blocks, err := frameworkProviderSchema(p.sdkProvider.Schema)
+	if err != nil {
+		resp.Diagnostics.AddError("failed converting the provider schema", err.Error())
+		return
+	}
+	resp.Schema = schema.Schema{
+		Attributes: attributes,
+		Blocks:     blocks,
+	}
+}
+
+func (p *frameworkProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	values, err := frameworkConfigValuesFrom(req.Config.Raw)
+	if err != nil {
+		resp.Diagnostics.AddError("failed configuring the provider", err.Error())
+		return
+	}
+
+	terraformVersion := req.TerraformVersion
+	if terraformVersion == "" {
+		terraformVersion = "0.11+compatible"
+	}
+
+	meta, sdkDiags := providerConfigure(p.sdkProvider, values, terraformVersion)
+	// Warnings were already reported by the SDKv2 half, which the mux server
+	// configures first; only errors are surfaced here.
+	for _, d := range sdkDiags {
+		if d.Severity == sdkdiag.Error {
+			resp.Diagnostics.AddError("failed configuring the provider", fmt.Sprintf("%s: %s", d.Summary, d.Detail))
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.ResourceData = meta
+	resp.DataSourceData = meta
+	resp.EphemeralResourceData = meta
+}
+
+func (p *frameworkProvider) Resources(_ context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		NewRuleLabelsResource,
+	}
+}
+
+func (p *frameworkProvider) DataSources(_ context.Context) []func() datasource.DataSource {
+	return nil
+}
+
+func (p *frameworkProvider) EphemeralResources(_ context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewVPNCredentialPSKEphemeralResource,
+		NewOneAPITokenEphemeralResource,
+	}
+}
+
+func (p *frameworkProvider) Functions(_ context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewCountryCodeFunction,
+		NewExclusionTimeFunction,
+		NewNormalizeURLFunction,
+		NewTimeZoneFunction,
+	}
+}
+
+// frameworkProviderSchema converts the SDKv2 provider schema. Only the
+// shapes used by the provider block are supported: primitive attributes and
+// single-level list blocks; any other shape is an error.
+func frameworkProviderSchema(sdk map[string]*sdkschema.Schema) (map[string]schema.Attribute, map[string]schema.Block, error) {
+	attributes := map[string]schema.Attribute{}
+	blocks := map[string]schema.Block{}
+
+	for name, s := range sdk {
+		if s.Type == sdkschema.TypeList {
+			if elem, ok := s.Elem.(*sdkschema.Resource); ok {
+				nested, nestedBlocks, err := frameworkProviderSchema(elem.Schema)
+				if err != nil {
+					return nil, nil, fmt.Errorf("provider block %q: %w", name, err)
+				}
+				if len(nestedBlocks) > 0 {
+					return nil, nil, fmt.Errorf("provider block %q: nested blocks are not supported", name)
+				}
+				blocks[name] = schema.ListNestedBlock{
+					Description:        s.Description,
+					DeprecationMessage: s.Deprecated,
+					NestedObject: schema.NestedBlockObject{
+						Attributes: nested,
+					},
+				}
+				continue
+			}
+		}
+
+		switch s.Type {
+		case sdkschema.TypeString:
+			attributes[name] = schema.StringAttribute{
+				Optional:           s.Optional,
+				Required:           s.Required,
+				Sensitive:          s.Sensitive,
+				Description:        s.Description,
+				DeprecationMessage: s.Deprecated,
+			}
+		case sdkschema.TypeInt:
+			attributes[name] = schema.Int64Attribute{
+				Optional:           s.Optional,
+				Required:           s.Required,
+				Sensitive:          s.Sensitive,
+				Description:        s.Description,
+				DeprecationMessage: s.Deprecated,
+			}
+		case sdkschema.TypeBool:
+			attributes[name] = schema.BoolAttribute{
+				Optional:           s.Optional,
+				Required:           s.Required,
+				Sensitive:          s.Sensitive,
+				Description:        s.Description,
+				DeprecationMessage: s.Deprecated,
+			}
+		default:
+			return nil, nil, fmt.Errorf("provider attribute %q: unsupported type %s", name, s.Type)
+		}
+	}
+
+	return attributes, blocks, nil
+}
+
+// frameworkConfigValues exposes the raw provider configuration through the
+// configValues interface, with the same zero-value semantics as GetOk on
+// *schema.ResourceData.
+type frameworkConfigValues map[string]interface{}
+
+func (v frameworkConfigValues) GetOk(key string) (interface{}, bool) {
+	val, ok := v[key]
+	if !ok {
+		return nil, false
+	}
+	switch typed := val.(type) {
+	case string:
+		return val, typed != ""
+	case int:
+		return val, typed != 0
+	case bool:
+		return val, typed
+	case []interface{}:
+		return val, len(typed) > 0
+	}
+	return val, true
+}
+
+func (v frameworkConfigValues) Get(key string) interface{} {
+	return v[key]
+}
+
+func frameworkConfigValuesFrom(raw tftypes.Value) (frameworkConfigValues, error) {
+	converted, err := frameworkConfigValue(raw)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := converted.(map[string]interface{})
+	if !ok {
+		return frameworkConfigValues{}, nil
+	}
+	return values, nil
+}
+
+// frameworkConfigValue converts a configuration value into the Go types
+// *schema.ResourceData would return for it. Null and unknown values are
+// omitted.
+func frameworkConfigValue(raw tftypes.Value) (interface{}, error) {
+	if raw.IsNull() || !raw.IsKnown() {
+		return nil, nil
+	}
+
+	typ := raw.Type()
+	switch {
+	case typ.Is(tftypes.String):
+		var s string
+		err := raw.As(&s)
+		return s, err
+	case typ.Is(tftypes.Number):
+		var n big.Float
+		if err := raw.As(&n); err != nil {
+			return nil, err
+		}
+		i, _ := n.Int64()
+		return int(i), nil
+	case typ.Is(tftypes.Bool):
+		var b bool
+		err := raw.As(&b)
+		return b, err
+	case typ.Is(tftypes.List{}):
+		var elems []tftypes.Value
+		if err := raw.As(&elems); err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, 0, len(elems))
+		for _, elem := range elems {
+			converted, err := frameworkConfigValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, converted)
+		}
+		return list, nil
+	case typ.Is(tftypes.Object{}):
+		var attrs map[string]tftypes.Value
+		if err := raw.As(&attrs); err != nil {
+			return nil, err
+		}
+		obj := make(map[string]interface{}, len(attrs))
+		for name, attr := range attrs {
+			converted, err := frameworkConfigValue(attr)
+			if err != nil {
+				return nil, err
+			}
+			if converted != nil {
+				obj[name] = converted
+			}
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("unsupported provider configuration type %s", typ)
+}
+
+// frameworkClient extracts the provider client handed to a framework
+// resource or data source by Configure.
+func frameworkClient(providerData interface{}, diags *diag.Diagnostics) *Client {
+	if providerData == nil {
+		return nil
+	}
+	client, ok := providerData.(*Client)
+	if !ok {
+		diags.AddError("unexpected provider data", fmt.Sprintf("expected *Client, got %T", providerData))
+		return nil
+	}
+	return client
+}
+
+// frameworkInertClient is the framework counterpart of the
+// guardResourceAgainstInertClient wrapper: it reports the same error when the
+// provider was configured with skip_credentials_validation.
+func frameworkInertClient(client *Client, diags *diag.Diagnostics) bool {
+	if client == nil || !client.skipCredentialsValidation {
+		return false
+	}
+	for _, d := range inertClientDiag() {
+		diags.AddError(d.Summary, d.Detail)
+	}
+	return true
+}
+
+// trackFrameworkWrite is the framework counterpart of
+// trackWritesForTenantLock, trackWritesForEditLock and
+// trackWritesForDeferredActivation. Use as
+//
+//	defer trackFrameworkWrite(ctx, client, &resp.Diagnostics)()
+//	if resp.Diagnostics.HasError() {
+//		return
+//	}
+func trackFrameworkWrite(ctx context.Context, client *Client, diags *diag.Diagnostics) func() {
+	if client == nil {
+		return func() {}
+	}
+	if err := client.tenantLock.acquire(ctx); err != nil {
+		diags.AddError("error acquiring the tenant lock", err.Error())
+		return func() {}
+	}
+	if client.activation != nil {
+		client.activation.beginWrite()
+	}
+	return func() {
+		explainFrameworkEditLock(client, diags)
+		if client.activation == nil {
+			return
+		}
+		if err := client.activation.endWrite(ctx); err != nil {
+			diags.AddError("deferred configuration activation failed",
+				fmt.Sprintf("The changes made during this apply were saved but not activated: %v", err))
+		}
+	}
+}
+
+// explainFrameworkEditLock is the framework counterpart of
+// trackWritesForEditLock. Framework diagnostics cannot be amended, so the
+// explanation is added as an error of its own.
+func explainFrameworkEditLock(client *Client, diags *diag.Diagnostics) {
+	for _, d := range diags.Errors() {
+		if message := d.Summary() + " " + d.Detail(); isEditLockMessage(message) {
+			diags.AddError("the ZIA edit lock was not available", editLockExplanation(client.editLock, message))
+			return
+		}
+	}
+}
+
+// frameworkActivate mirrors the shouldActivate/triggerActivation block found
+// in the SDKv2 resources.
+func frameworkActivate(ctx context.Context, client *Client, diags *diag.Diagnostics) {
+	if !shouldActivate(client) {
+		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+		return
+	}
+	if err := triggerActivation(ctx, client); err != nil {
+		diags.AddError("error triggering activation", err.Error())
+	}
+}
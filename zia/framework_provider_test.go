@@ -0,0 +1,75 @@
+package zia
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	sdkschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestFrameworkConfigValues_MatchSDKConfig(t *testing.T) {
+	activationType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"mode":                 tftypes.String,
+		"quiet_period_seconds": tftypes.Number,
+	}}
+	configType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"client_id":                   tftypes.String,
+		"vanity_domain":               tftypes.String,
+		"max_retries":                 tftypes.Number,
+		"use_legacy_client":           tftypes.Bool,
+		"skip_credentials_validation": tftypes.Bool,
+		"http_proxy":                  tftypes.String,
+		"activation":                  tftypes.List{ElementType: activationType},
+	}}
+	raw := tftypes.NewValue(configType, map[string]tftypes.Value{
+		"client_id":                   tftypes.NewValue(tftypes.String, "id"),
+		"vanity_domain":               tftypes.NewValue(tftypes.String, nil),
+		"max_retries":                 tftypes.NewValue(tftypes.Number, big.NewFloat(7)),
+		"use_legacy_client":           tftypes.NewValue(tftypes.Bool, false),
+		"skip_credentials_validation": tftypes.NewValue(tftypes.Bool, nil),
+		"http_proxy":                  tftypes.NewValue(tftypes.String, nil),
+		"activation": tftypes.NewValue(tftypes.List{ElementType: activationType}, []tftypes.Value{
+			tftypes.NewValue(activationType, map[string]tftypes.Value{
+				"mode":                 tftypes.NewValue(tftypes.String, activationModeEndOfApply),
+				"quiet_period_seconds": tftypes.NewValue(tftypes.Number, nil),
+			}),
+		}),
+	})
+
+	values, err := frameworkConfigValuesFrom(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := values.GetOk("use_legacy_client"); ok {
+		t.Error("GetOk must report false for a zero value, like *schema.ResourceData")
+	}
+
+	config := NewConfig(values)
+	if config.clientID != "id" {
+		t.Errorf("clientID = %q, want %q", config.clientID, "id")
+	}
+	if config.retryCount != 7 {
+		t.Errorf("retryCount = %d, want 7", config.retryCount)
+	}
+	if config.activationMode != activationModeEndOfApply {
+		t.Errorf("activationMode = %q, want %q", config.activationMode, activationModeEndOfApply)
+	}
+	if config.activationQuietPeriod != defaultActivationQuietPeriod {
+		t.Errorf("activationQuietPeriod = %d, want the default %d", config.activationQuietPeriod, defaultActivationQuietPeriod)
+	}
+}
+
+func TestFrameworkProviderSchema(t *testing.T) {
+	if _, _, err := frameworkProviderSchema(ZIAProvider().Schema); err != nil {
+		t.Fatalf("expected the provider schema to convert, got %v", err)
+	}
+
+	_, _, err := frameworkProviderSchema(map[string]*sdkschema.Schema{
+		"headers": {Type: sdkschema.TypeMap, Optional: true, Elem: &sdkschema.Schema{Type: sdkschema.TypeString}},
+	})
+	if err == nil || !strings.Contains(err.Error(), `provider attribute "headers": unsupported type`) {
+		t.Errorf("expected an unsupported type to be an error, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// multiLineStringType is the framework counterpart of the
+// normalizeMultiLineString/noChangeInMultiLineText pair used by SDKv2
+// resources: values that normalize to the same text are semantically equal,
+// so whitespace-only differences neither show in plans nor overwrite state.
+type multiLineStringType struct {
+	basetypes.StringType
+}
+
+var _ basetypes.StringTypable = multiLineStringType{}
+
+func (t multiLineStringType) Equal(o attr.Type) bool {
+	other, ok := o.(multiLineStringType)
+	return ok && t.StringType.Equal(other.StringType)
+}
+
+func (t multiLineStringType) String() string {
+	return "multiLineStringType"
+}
+
+func (t multiLineStringType) ValueFromString(_ context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return multiLineStringValue{StringValue: in}, nil
+}
+
+func (t multiLineStringType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+	return multiLineStringValue{StringValue: stringValue}, nil
+}
+
+func (t multiLineStringType) ValueType(_ context.Context) attr.Value {
+	return multiLineStringValue{}
+}
+
+type multiLineStringValue struct {
+	basetypes.StringValue
+}
+
+var _ basetypes.StringValuableWithSemanticEquals = multiLineStringValue{}
+
+func (v multiLineStringValue) Equal(o attr.Value) bool {
+	other, ok := o.(multiLineStringValue)
+	return ok && v.StringValue.Equal(other.StringValue)
+}
+
+func (v multiLineStringValue) Type(_ context.Context) attr.Type {
+	return multiLineStringType{}
+}
+
+func (v multiLineStringValue) StringSemanticEquals(_ context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	newValue, ok := newValuable.(multiLineStringValue)
+	if !ok {
+		diags.AddError("semantic equality check error", fmt.Sprintf("unexpected value type %T", newValuable))
+		return false, diags
+	}
+	return normalizeMultiLineString(v.ValueString()) == normalizeMultiLineString(newValue.ValueString()), diags
+}
+
+func newMultiLineStringValue(s string) multiLineStringValue {
+	return multiLineStringValue{StringValue: basetypes.NewStringValue(s)}
+}
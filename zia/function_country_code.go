@@ -0,0 +1,50 @@
+package zia
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &countryCodeFunction{}
+
+func NewCountryCodeFunction() function.Function {
+	return &countryCodeFunction{}
+}
+
+// countryCodeFunction implements provider::zia::country_code. It validates
+// the code with the same check as validateISOCountryCodes and converts it the
+// way processCountries does before rules are sent to the API.
+type countryCodeFunction struct{}
+
+func (f *countryCodeFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "country_code"
+}
+
+func (f *countryCodeFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Convert an ISO-3166 Alpha-2 country code to its ZIA form",
+		Description: "Returns the ZIA API form of an ISO-3166 Alpha-2 country code, e.g. \"US\" becomes \"COUNTRY_US\". ANY and NONE are returned unchanged.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "code",
+				Description: "ISO-3166 Alpha-2 country code, ANY or NONE.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *countryCodeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var code string
+	resp.Error = req.Arguments.Get(ctx, &code)
+	if resp.Error != nil {
+		return
+	}
+
+	if err := checkISOCountryCode(code); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, processCountry(code))
+}
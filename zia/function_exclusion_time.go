@@ -0,0 +1,68 @@
+package zia
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &exclusionTimeFunction{}
+
+func NewExclusionTimeFunction() function.Function {
+	return &exclusionTimeFunction{}
+}
+
+// exclusionTimeFunction implements provider::zia::exclusion_time. It accepts
+// the two time formats the provider itself parses: the UTC exclusion format
+// of zia_dc_exclusions and zia_sub_cloud, and the RFC1123 format of rule
+// validity_start_time / validity_end_time.
+type exclusionTimeFunction struct{}
+
+func (f *exclusionTimeFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "exclusion_time"
+}
+
+func (f *exclusionTimeFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Convert a ZIA time string to Unix epoch seconds",
+		Description: "Parses a time in the \"MM/DD/YYYY HH:MM:SS am/pm\" UTC format used by exclusion windows, " +
+			"or in RFC1123 format (Mon, 02 Jan 2006 15:04:05 MST), and returns it as Unix epoch seconds.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "time",
+				Description: "Time in \"MM/DD/YYYY HH:MM:SS am/pm\" (UTC) or RFC1123 format.",
+			},
+		},
+		Return: function.Int64Return{},
+	}
+}
+
+func (f *exclusionTimeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value string
+	resp.Error = req.Arguments.Get(ctx, &value)
+	if resp.Error != nil {
+		return
+	}
+
+	epoch, err := parseExclusionTime(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, int64(epoch))
+}
+
+// parseExclusionTime tries ParseExclusionTimeUTC first and falls back to
+// ConvertRFC1123ToEpoch, reporting the error for the format the value most
+// likely intended.
+func parseExclusionTime(value string) (int, error) {
+	epoch, err := ParseExclusionTimeUTC(value)
+	if err == nil {
+		return epoch, nil
+	}
+	if !strings.Contains(value, ",") {
+		return 0, err
+	}
+	return ConvertRFC1123ToEpoch(strings.TrimSpace(value))
+}
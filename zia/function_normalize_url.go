@@ -0,0 +1,70 @@
+package zia
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &normalizeURLFunction{}
+
+func NewNormalizeURLFunction() function.Function {
+	return &normalizeURLFunction{}
+}
+
+// normalizeURLFunction implements provider::zia::normalize_url with
+// normalizeURLCategoryURL, the normalization zia_url_category_urls matches
+// configured and stored URLs by.
+type normalizeURLFunction struct{}
+
+func (f *normalizeURLFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "normalize_url"
+}
+
+func (f *normalizeURLFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Normalize a URL category entry",
+		Description: "Returns a custom URL category entry with surrounding whitespace and an http:// or https:// " +
+			"scheme removed, the host lower-cased and a trailing slash on a bare host dropped.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "url",
+				Description: "URL or domain to normalize.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *normalizeURLFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var url string
+	resp.Error = req.Arguments.Get(ctx, &url)
+	if resp.Error != nil {
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, normalizeURLCategoryURL(url))
+}
+
+// normalizeURLCategoryURL removes surrounding whitespace and the http:// or
+// https:// scheme of a custom URL category entry, lower-cases the host and
+// drops a trailing slash on a bare host. Paths and leading-dot wildcards are
+// kept as written.
+func normalizeURLCategoryURL(raw string) string {
+	u := strings.TrimSpace(raw)
+	for _, scheme := range []string{"http://", "https://"} {
+		if len(u) >= len(scheme) && strings.EqualFold(u[:len(scheme)], scheme) {
+			u = u[len(scheme):]
+			break
+		}
+	}
+
+	host, rest := u, ""
+	if i := strings.IndexAny(u, "/?#"); i >= 0 {
+		host, rest = u[:i], u[i:]
+	}
+	if rest == "/" {
+		rest = ""
+	}
+	return strings.ToLower(host) + rest
+}
@@ -0,0 +1,50 @@
+package zia
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &timeZoneFunction{}
+
+func NewTimeZoneFunction() function.Function {
+	return &timeZoneFunction{}
+}
+
+// timeZoneFunction implements provider::zia::time_zone. It applies the same
+// check as validateTimeZone, so a module can reject a bad value before it
+// reaches a rule's validity_time_zone_id.
+type timeZoneFunction struct{}
+
+func (f *timeZoneFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "time_zone"
+}
+
+func (f *timeZoneFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Validate an IANA time zone",
+		Description: "Returns the given IANA time zone name unchanged, or fails if the provider would reject it.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "time_zone",
+				Description: "IANA time zone name, e.g. \"Europe/Vilnius\".",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *timeZoneFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var tz string
+	resp.Error = req.Arguments.Get(ctx, &tz)
+	if resp.Error != nil {
+		return
+	}
+
+	if err := checkTimeZone(tz); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, tz)
+}
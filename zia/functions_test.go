@@ -0,0 +1,120 @@
+package zia
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func runStringFunction(t *testing.T, f function.Function, arg string) (attr.Value, *function.FuncError) {
+	t.Helper()
+	ctx := context.Background()
+	definition := function.DefinitionResponse{}
+	f.Definition(ctx, function.DefinitionRequest{}, &definition)
+
+	resp := function.RunResponse{Result: function.NewResultData(definition.Definition.Return.GetType().ValueType(ctx))}
+	f.Run(ctx, function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(arg)}),
+	}, &resp)
+	return resp.Result.Value(), resp.Error
+}
+
+func TestCountryCodeFunction(t *testing.T) {
+	cases := map[string]string{
+		"US":   "COUNTRY_US",
+		"BR":   "COUNTRY_BR",
+		"ANY":  "ANY",
+		"NONE": "NONE",
+	}
+	for in, want := range cases {
+		got, err := runStringFunction(t, NewCountryCodeFunction(), in)
+		if err != nil {
+			t.Fatalf("country_code(%q): unexpected error: %s", in, err)
+		}
+		if !got.Equal(types.StringValue(want)) {
+			t.Errorf("country_code(%q) = %s, want %q", in, got, want)
+		}
+		if processed := processCountries([]string{in}); processed[0] != want {
+			t.Errorf("processCountries disagrees for %q: %q", in, processed[0])
+		}
+	}
+
+	if _, err := runStringFunction(t, NewCountryCodeFunction(), "XX"); err == nil {
+		t.Error("country_code(\"XX\"): expected an error")
+	}
+}
+
+func TestExclusionTimeFunction(t *testing.T) {
+	cases := map[string]int64{
+		"02/19/2026 11:59:00 pm":        1771545540,
+		"Thu, 19 Feb 2026 23:59:00 UTC": 1771545540,
+	}
+	for in, want := range cases {
+		got, err := runStringFunction(t, NewExclusionTimeFunction(), in)
+		if err != nil {
+			t.Fatalf("exclusion_time(%q): unexpected error: %s", in, err)
+		}
+		if !got.Equal(types.Int64Value(want)) {
+			t.Errorf("exclusion_time(%q) = %s, want %d", in, got, want)
+		}
+	}
+	if got := FormatExclusionTimeUTC(1771545540); got != "02/19/2026 11:59:00 pm" {
+		t.Errorf("FormatExclusionTimeUTC round trip: %q", got)
+	}
+
+	for _, in := range []string{"", "2026-02-19", "Thu, 19 Feb 2026"} {
+		if _, err := runStringFunction(t, NewExclusionTimeFunction(), in); err == nil {
+			t.Errorf("exclusion_time(%q): expected an error", in)
+		}
+	}
+}
+
+func TestNormalizeURLFunction(t *testing.T) {
+	cases := map[string]string{
+		"example.com":                  "example.com",
+		" https://Example.COM/ ":       "example.com",
+		"HTTP://www.Example.com/Path/": "www.example.com/Path/",
+		".Example.com":                 ".example.com",
+		"example.com:8080/a?b=C":       "example.com:8080/a?b=C",
+	}
+	for in, want := range cases {
+		got, err := runStringFunction(t, NewNormalizeURLFunction(), in)
+		if err != nil {
+			t.Fatalf("normalize_url(%q): unexpected error: %s", in, err)
+		}
+		if !got.Equal(types.StringValue(want)) {
+			t.Errorf("normalize_url(%q) = %s, want %q", in, got, want)
+		}
+		// zia_url_category_urls treats an entry and its normalized form as
+		// the same URL.
+		if urls := sortedCategoryURLs([]string{in, want}); len(urls) != 1 || urlListDigest(urls) != urlListDigest([]string{want}) {
+			t.Errorf("expected zia_url_category_urls to match %q with %q, got %v", in, want, urls)
+		}
+	}
+}
+
+func TestTimeZoneFunction(t *testing.T) {
+	got, err := runStringFunction(t, NewTimeZoneFunction(), "UTC")
+	if err != nil {
+		t.Fatalf("time_zone(\"UTC\"): unexpected error: %s", err)
+	}
+	if !got.Equal(types.StringValue("UTC")) {
+		t.Errorf("time_zone(\"UTC\") = %s", got)
+	}
+
+	if _, err := runStringFunction(t, NewTimeZoneFunction(), "Mars/Olympus_Mons"); err == nil {
+		t.Error("time_zone(\"Mars/Olympus_Mons\"): expected an error")
+	}
+}
+
+func TestURLCategoriesURLsCompareAsWritten(t *testing.T) {
+	if !stringListsSetEqual([]interface{}{"b.com", "example.com"}, []interface{}{"example.com", "b.com"}) {
+		t.Error("expected reordered URL lists to compare equal")
+	}
+	if stringListsSetEqual([]interface{}{"https://Example.com/"}, []interface{}{"example.com"}) {
+		t.Error("expected URLs that only normalize to the same value to compare unequal")
+	}
+}
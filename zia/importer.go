@@ -0,0 +1,163 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+)
+
+// importNamePrefix marks an import ID that is an object name, for names that
+// would otherwise read as a numeric ID or a composite key.
+const importNamePrefix = "name:"
+
+// importCandidate is an object an import ID can resolve to.
+type importCandidate struct {
+	id   int
+	name string
+	// keys are further import IDs the object answers to, such as
+	// "<location_name>/<sublocation_name>" for a sublocation. The first key
+	// names the object in errors, as it is more telling than the name.
+	keys []string
+}
+
+// importLister returns the objects an import ID that is not numeric is
+// matched against.
+type importLister func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error)
+
+// importByName returns an importer accepting a numeric ID, "name:<name>" or a
+// bare name, resolving names against the objects returned by getAll. The
+// objects must have int ID and string Name fields.
+func importByName[T any](idAttribute string, getAll func(context.Context, *zscaler.Service) ([]T, error)) *schema.ResourceImporter {
+	// Check T when the provider is built rather than on the first import.
+	namedImportCandidates[T](nil)
+	return importByKey(idAttribute, func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+		items, err := getAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		return namedImportCandidates(items), nil
+	})
+}
+
+// importByKey returns an importer accepting a numeric ID, "name:<name>", or
+// any name or key of the candidates returned by list. The resolved ID becomes
+// the resource ID and is also set on idAttribute.
+func importByKey(idAttribute string, list importLister) *schema.ResourceImporter {
+	return &schema.ResourceImporter{
+		StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+			service := meta.(*Client).Service
+			id, err := resolveImportID(d.Id(), func() ([]importCandidate, error) {
+				return list(ctx, service)
+			})
+			if err != nil {
+				return nil, err
+			}
+			d.SetId(strconv.Itoa(id))
+			_ = d.Set(idAttribute, id)
+			return []*schema.ResourceData{d}, nil
+		},
+	}
+}
+
+// importSingleton returns the importer of a tenant-wide settings resource,
+// which reads the settings into state under the fixed ID whatever import ID
+// was given.
+func importSingleton(id string, read schema.ReadContextFunc) *schema.ResourceImporter {
+	return &schema.ResourceImporter{
+		StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+			d.SetId(id)
+			if diags := read(ctx, d, meta); diags.HasError() {
+				return nil, fmt.Errorf("failed to read %s during import: %s", id, diags[0].Summary)
+			}
+			d.SetId(id)
+			return []*schema.ResourceData{d}, nil
+		},
+	}
+}
+
+// resolveImportID resolves an import ID to the ID of an object. Numeric IDs
+// are taken as is; "name:<name>" matches object names only, and anything else
+// matches names and keys. Exact matches win over case-insensitive ones. An
+// import ID that matches several objects is an error listing them, so the
+// object can be imported by ID instead.
+func resolveImportID(importID string, list func() ([]importCandidate, error)) (int, error) {
+	if id, err := strconv.Atoi(importID); err == nil {
+		return id, nil
+	}
+	key, namesOnly := strings.CutPrefix(importID, importNamePrefix)
+	if key == "" {
+		return 0, fmt.Errorf("invalid import ID %q: expected a numeric ID, %s<name> or a name", importID, importNamePrefix)
+	}
+	candidates, err := list()
+	if err != nil {
+		return 0, fmt.Errorf("listing objects to import %q: %w", importID, err)
+	}
+
+	for _, match := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		strings.EqualFold,
+	} {
+		var matches []importCandidate
+		for _, c := range candidates {
+			if c.matches(key, namesOnly, match) {
+				matches = append(matches, c)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0].id, nil
+		}
+		sort.Slice(matches, func(i, j int) bool { return matches[i].id < matches[j].id })
+		lines := make([]string, len(matches))
+		for i, c := range matches {
+			label := c.name
+			if len(c.keys) > 0 {
+				label = c.keys[0]
+			}
+			lines[i] = fmt.Sprintf("  - %d (%s)", c.id, label)
+		}
+		return 0, fmt.Errorf("import ID %q is ambiguous, it matches %d objects; import one of them by ID instead:\n%s",
+			importID, len(matches), strings.Join(lines, "\n"))
+	}
+	return 0, fmt.Errorf("no object found matching import ID %q", importID)
+}
+
+func (c importCandidate) matches(key string, namesOnly bool, match func(a, b string) bool) bool {
+	if match(c.name, key) {
+		return true
+	}
+	if namesOnly {
+		return false
+	}
+	for _, k := range c.keys {
+		if match(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// namedImportCandidates returns the ID and Name fields of SDK objects as
+// import candidates. It panics when T has no int ID and string Name fields.
+func namedImportCandidates[T any](items []T) []importCandidate {
+	typ := reflect.TypeFor[T]()
+	id, okID := typ.FieldByName("ID")
+	name, okName := typ.FieldByName("Name")
+	if !okID || id.Type.Kind() != reflect.Int || !okName || name.Type.Kind() != reflect.String {
+		panic(fmt.Sprintf("namedImportCandidates: %s has no int ID and string Name fields", typ))
+	}
+	candidates := make([]importCandidate, len(items))
+	for i := range items {
+		v := reflect.ValueOf(items[i])
+		candidates[i] = importCandidate{id: int(v.FieldByIndex(id.Index).Int()), name: v.FieldByIndex(name.Index).String()}
+	}
+	return candidates
+}
@@ -0,0 +1,124 @@
+package zia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResolveImportID(t *testing.T) {
+	candidates := []importCandidate{
+		{id: 10, name: "Branch"},
+		{id: 11, name: "other", keys: []string{"Branch/other"}},
+		{id: 20, name: "HQ"},
+		{id: 21, name: "other", keys: []string{"HQ/other"}},
+		{id: 30, name: "42"},
+	}
+	listed := 0
+	list := func() ([]importCandidate, error) {
+		listed++
+		return candidates, nil
+	}
+
+	cases := map[string]int{
+		"7":            7,
+		"Branch":       10,
+		"branch":       10,
+		"name:HQ":      20,
+		"HQ/other":     21,
+		"hq/OTHER":     21,
+		"name:42":      30,
+		"Branch/other": 11,
+	}
+	for importID, want := range cases {
+		got, err := resolveImportID(importID, list)
+		if err != nil || got != want {
+			t.Errorf("resolveImportID(%q) = %d, %v, want %d", importID, got, err, want)
+		}
+	}
+	if listed != len(cases)-1 {
+		t.Errorf("expected numeric IDs to be taken without listing, listed %d times", listed)
+	}
+
+	_, err := resolveImportID("other", list)
+	if err == nil || !strings.Contains(err.Error(), "matches 2 objects") ||
+		!strings.Contains(err.Error(), "11 (Branch/other)") || !strings.Contains(err.Error(), "21 (HQ/other)") {
+		t.Errorf("expected an ambiguous name to list its candidates, got %v", err)
+	}
+	if _, err := resolveImportID("name:HQ/other", list); err == nil {
+		t.Error("expected name: to match names only, not keys")
+	}
+	if _, err := resolveImportID("Remote", list); err == nil || !strings.Contains(err.Error(), `"Remote"`) {
+		t.Errorf("expected an unknown name to fail, got %v", err)
+	}
+	if _, err := resolveImportID("name:", list); err == nil {
+		t.Error("expected an empty name to fail")
+	}
+	failing := func() ([]importCandidate, error) { return nil, errors.New("boom") }
+	if _, err := resolveImportID("HQ", failing); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected the list error, got %v", err)
+	}
+}
+
+func TestImportByName_FakeZIA(t *testing.T) {
+	server, client := configureFakeZIA(t)
+	ctx := context.Background()
+	r := resourceFWIPDestinationGroups()
+
+	seeded := server.Seed("ipDestinationGroups",
+		map[string]interface{}{"name": "tf-import-unique", "type": "DSTN_FQDN"},
+		map[string]interface{}{"name": "tf-import-dup", "type": "DSTN_FQDN"},
+		map[string]interface{}{"name": "tf-import-dup", "type": "DSTN_IP"},
+	)
+	unique := fmt.Sprint(seeded[0]["id"])
+
+	importID := func(id string) (*schema.ResourceData, error) {
+		d := r.TestResourceData()
+		d.SetId(id)
+		states, err := r.Importer.StateContext(ctx, d, client)
+		if err != nil {
+			return nil, err
+		}
+		return states[0], nil
+	}
+
+	for _, id := range []string{"tf-import-unique", "name:tf-import-unique"} {
+		d, err := importID(id)
+		if err != nil {
+			t.Fatalf("import %q: %v", id, err)
+		}
+		if d.Id() != unique || strconv.Itoa(d.Get("group_id").(int)) != unique {
+			t.Errorf("import %q: expected ID %v, got %q", id, unique, d.Id())
+		}
+	}
+
+	_, err := importID("tf-import-dup")
+	if err == nil || !strings.Contains(err.Error(), "matches 2 objects") {
+		t.Fatalf("expected the duplicate name to be ambiguous, got %v", err)
+	}
+	for _, obj := range seeded[1:] {
+		if !strings.Contains(err.Error(), fmt.Sprint(obj["id"])) {
+			t.Errorf("expected candidate %v in %q", obj["id"], err)
+		}
+	}
+}
+
+func TestImportSingleton(t *testing.T) {
+	var readID string
+	importer := importSingleton("app_setting", func(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+		readID = d.Id()
+		return nil
+	})
+	d := (&schema.Resource{Schema: map[string]*schema.Schema{}}).TestResourceData()
+	d.SetId("anything")
+	states, err := importer.StateContext(context.Background(), d, nil)
+	if err != nil || states[0].Id() != "app_setting" || readID != "app_setting" {
+		t.Errorf("expected the read and the state to use the fixed ID, got %v, %q, %q", err, states[0].Id(), readID)
+	}
+}
@@ -0,0 +1,357 @@
+package zia
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const journalRedacted = "(sensitive)"
+
+// journal appends one JSON line per API write to the file named by
+// journal_path, giving change management an auditable record of what each
+// run changed in the tenant.
+//
+// Writes made by a resource are buffered until its Create, Update or Delete
+// returns, so their records carry the resource ID and the planned diff.
+// Activations are numbered within a run; every write record names the
+// activation that published it, which is the next one to succeed after it.
+type journal struct {
+	path string
+	run  string
+
+	mu         sync.Mutex
+	file       *os.File
+	activation int // number of the next activation of the run
+}
+
+// journalRecord is one line of the journal. It has no Terraform address:
+// the plugin protocol sends providers the resource type and values, never
+// the address, so resources are identified by type, ID and name.
+type journalRecord struct {
+	Time         time.Time                `json:"time"`
+	Run          string                   `json:"run"`
+	Operation    string                   `json:"operation,omitempty"`
+	ResourceType string                   `json:"resource_type,omitempty"`
+	ResourceID   string                   `json:"resource_id,omitempty"`
+	ResourceName string                   `json:"resource_name,omitempty"`
+	Method       string                   `json:"method"`
+	Path         string                   `json:"path"`
+	Status       int                      `json:"status,omitempty"`
+	Error        string                   `json:"error,omitempty"`
+	LatencyMS    int64                    `json:"latency_ms"`
+	Retries      int                      `json:"retries"`
+	Diff         map[string]journalChange `json:"diff,omitempty"`
+	Activation   int                      `json:"activation"`
+}
+
+// journalChange is the planned change of one attribute.
+type journalChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// journalWrite collects the API writes of one resource Create, Update or
+// Delete until it returns.
+type journalWrite struct {
+	resourceType string
+	operation    string
+	// id is set on writes journaled at once rather than on commitWrite.
+	id string
+
+	mu    sync.Mutex
+	calls []journalRecord
+}
+
+type journalWriteKey struct{}
+
+type journalAttemptsKey struct{}
+
+// openJournal opens the journal at path for appending, or returns nil when
+// path is empty.
+func openJournal(path string) (*journal, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening journal_path: %w", err)
+	}
+	run := make([]byte, 8)
+	_, _ = rand.Read(run)
+	return &journal{path: path, run: hex.EncodeToString(run), file: file, activation: 1}, nil
+}
+
+// beginWrite returns a context under which the API writes of a resource
+// operation are collected for commitWrite. It is a no-op on a nil journal.
+func (j *journal) beginWrite(ctx context.Context, resourceType, operation string) (context.Context, *journalWrite) {
+	if j == nil {
+		return ctx, nil
+	}
+	w := &journalWrite{resourceType: resourceType, operation: operation}
+	return context.WithValue(ctx, journalWriteKey{}, w), w
+}
+
+// journalReorderContext returns a context under which the order updates
+// the rule order engine makes to rule id are journaled at once as reorder
+// records. The engine outlives the resource operation that started it, so
+// its writes cannot wait for that operation's commitWrite.
+func journalReorderContext(ctx context.Context, resourceType string, id int) context.Context {
+	w := &journalWrite{resourceType: resourceType, operation: "reorder", id: strconv.Itoa(id)}
+	return context.WithValue(ctx, journalWriteKey{}, w)
+}
+
+// commitWrite appends the records of the API writes collected by w.
+func (j *journal) commitWrite(w *journalWrite, id, name string, diff map[string]journalChange) {
+	if j == nil || w == nil {
+		return
+	}
+	w.mu.Lock()
+	calls := w.calls
+	w.calls = nil
+	w.mu.Unlock()
+	for _, rec := range calls {
+		rec.Operation = w.operation
+		rec.ResourceType = w.resourceType
+		rec.ResourceID = id
+		rec.ResourceName = name
+		rec.Diff = diff
+		j.append(rec)
+	}
+}
+
+// record journals one API write. Activations are appended at once; other
+// writes wait for the resource operation they belong to, if any.
+func (j *journal) record(ctx context.Context, rec journalRecord) {
+	j.mu.Lock()
+	rec.Run = j.run
+	rec.Activation = j.activation
+	if strings.HasSuffix(rec.Path, "/status/activate") {
+		rec.Operation = "activate"
+		if rec.Error == "" && rec.Status < 300 {
+			j.activation++
+		}
+		j.mu.Unlock()
+		j.append(rec)
+		return
+	}
+	j.mu.Unlock()
+
+	if w, ok := ctx.Value(journalWriteKey{}).(*journalWrite); ok {
+		if w.id != "" {
+			rec.Operation = w.operation
+			rec.ResourceType = w.resourceType
+			rec.ResourceID = w.id
+			j.append(rec)
+			return
+		}
+		w.mu.Lock()
+		w.calls = append(w.calls, rec)
+		w.mu.Unlock()
+		return
+	}
+	j.append(rec)
+}
+
+func (j *journal) append(rec journalRecord) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		log.Printf("[WARN] Encoding journal record failed: %v", err)
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		log.Printf("[WARN] Writing journal %s failed: %v", j.path, err)
+	}
+}
+
+// wrapHTTPClient returns a copy of client that journals its API writes, or
+// client itself on a nil journal.
+func (j *journal) wrapHTTPClient(client *http.Client) (*http.Client, error) {
+	if j == nil || client == nil {
+		return client, nil
+	}
+	// Count the attempts below the SDK retry policy, so each record knows how
+	// often its request was retried.
+	counted, err := withInnerTransport(client, func(base http.RoundTripper) (http.RoundTripper, error) {
+		return journalAttemptTransport{base: base}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	wrapped := *counted
+	wrapped.Transport = &journalTransport{journal: j, base: counted.Transport}
+	return &wrapped, nil
+}
+
+// journalTransport journals the POST, PUT and DELETE requests of an SDK
+// client.
+type journalTransport struct {
+	journal *journal
+	base    http.RoundTripper
+}
+
+func (t *journalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete:
+	default:
+		return t.base.RoundTrip(req)
+	}
+	// Legacy session logins and logouts change nothing in the tenant.
+	if strings.HasSuffix(req.URL.Path, "/authenticatedSession") {
+		return t.base.RoundTrip(req)
+	}
+	attempts := new(atomic.Int32)
+	req = req.WithContext(context.WithValue(req.Context(), journalAttemptsKey{}, attempts))
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	rec := journalRecord{
+		Time:      start.UTC(),
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		LatencyMS: time.Since(start).Milliseconds(),
+		Retries:   max(0, int(attempts.Load())-1),
+	}
+	if resp != nil {
+		rec.Status = resp.StatusCode
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	t.journal.record(req.Context(), rec)
+	return resp, err
+}
+
+// journalAttemptTransport counts the attempts of a journaled request.
+type journalAttemptTransport struct {
+	base http.RoundTripper
+}
+
+func (t journalAttemptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if attempts, ok := req.Context().Value(journalAttemptsKey{}).(*atomic.Int32); ok {
+		attempts.Add(1)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// trackWritesForJournal journals the API writes of a resource's Create,
+// Update and Delete with the resource ID and the planned diff. It is a no-op
+// unless the provider was configured with journal_path.
+func trackWritesForJournal(r *schema.Resource, resourceType string) {
+	wrap := func(operation string, f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			client, ok := meta.(*Client)
+			if !ok || client.journal == nil {
+				return f(ctx, d, meta)
+			}
+			diff := journalDiff(r.Schema, d, operation == "delete")
+			id := d.Id()
+			name, _ := d.Get("name").(string)
+			ctx, w := client.journal.beginWrite(ctx, resourceType, operation)
+			diags := f(ctx, d, meta)
+			if d.Id() != "" {
+				id = d.Id()
+			}
+			client.journal.commitWrite(w, id, name, diff)
+			return diags
+		}
+	}
+
+	r.CreateContext = wrap("create", r.CreateContext)
+	r.UpdateContext = wrap("update", r.UpdateContext)
+	r.DeleteContext = wrap("delete", r.DeleteContext)
+}
+
+// journalDiff returns the planned changes of d, or the state being removed
+// when deleting, with the values of Sensitive attributes redacted.
+func journalDiff(s map[string]*schema.Schema, d *schema.ResourceData, deleting bool) map[string]journalChange {
+	diff := map[string]journalChange{}
+	for key, attr := range s {
+		if deleting {
+			if val, ok := d.GetOk(key); ok {
+				diff[key] = journalChange{Old: redactJournalValue(attr, journalValue(val))}
+			}
+			continue
+		}
+		if !d.HasChange(key) {
+			continue
+		}
+		before, after := d.GetChange(key)
+		diff[key] = journalChange{
+			Old: redactJournalValue(attr, journalValue(before)),
+			New: redactJournalValue(attr, journalValue(after)),
+		}
+	}
+	return diff
+}
+
+// journalValue converts sets to lists so attribute values encode as JSON.
+func journalValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case *schema.Set:
+		return journalValue(v.List())
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = journalValue(item)
+		}
+		return list
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = journalValue(item)
+		}
+		return m
+	}
+	return val
+}
+
+// redactJournalValue replaces the values of Sensitive attributes, including
+// those nested in blocks, with a placeholder.
+func redactJournalValue(attr *schema.Schema, val interface{}) interface{} {
+	if attr.Sensitive {
+		if val == nil || val == "" {
+			return val
+		}
+		return journalRedacted
+	}
+	elem, ok := attr.Elem.(*schema.Resource)
+	if !ok {
+		return val
+	}
+	list, ok := val.([]interface{})
+	if !ok {
+		return val
+	}
+	for i, item := range list {
+		block, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, nested := range elem.Schema {
+			if v, ok := block[key]; ok {
+				block[key] = redactJournalValue(nested, v)
+			}
+		}
+		list[i] = block
+	}
+	return list
+}
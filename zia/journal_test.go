@@ -0,0 +1,141 @@
+package zia
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/terraform-provider-zia/v4/zia/common/testing/fakezia"
+)
+
+func readJournal(t *testing.T, path string) []journalRecord {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []journalRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid journal line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestJournal_RecordsResourceWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	t.Setenv("ZSCALER_JOURNAL_PATH", path)
+	t.Setenv("ZIA_ACTIVATION", "true")
+	server, client := configureFakeZIA(t)
+	ctx := context.Background()
+	r := ZIAProvider().ResourcesMap["zia_firewall_filtering_destination_groups"]
+
+	server.InjectFault(http.MethodPost, "ipDestinationGroups", 1, fakezia.RateLimited(1))
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":        "tf-journal-dst",
+		"description": "journaled",
+		"type":        "DSTN_FQDN",
+		"addresses":   []interface{}{"example.com"},
+	})
+	if diags := r.CreateContext(ctx, d, client); diags.HasError() {
+		t.Fatalf("create: %v", diags)
+	}
+	id := d.Id()
+	if diags := r.DeleteContext(ctx, d, client); diags.HasError() {
+		t.Fatalf("delete: %v", diags)
+	}
+
+	var writes []journalRecord
+	activations := 0
+	for _, rec := range readJournal(t, path) {
+		if rec.Run != client.journal.run {
+			t.Errorf("expected every record to name the run, got %q", rec.Run)
+		}
+		if rec.Operation == "activate" {
+			activations++
+			continue
+		}
+		writes = append(writes, rec)
+	}
+	if activations != 2 || len(writes) != 2 {
+		t.Fatalf("expected a create, a delete and two activations, got %d writes and %d activations", len(writes), activations)
+	}
+
+	create, del := writes[0], writes[1]
+	if create.Operation != "create" || create.ResourceType != "zia_firewall_filtering_destination_groups" ||
+		create.ResourceID != id || create.ResourceName != "tf-journal-dst" || create.Method != http.MethodPost {
+		t.Errorf("unexpected create record %+v", create)
+	}
+	if create.Status != http.StatusOK || create.Retries != 1 || create.Activation != 1 {
+		t.Errorf("expected the create to succeed after one retry and be published by activation 1, got %+v", create)
+	}
+	if change := create.Diff["description"]; change.Old != "" || change.New != "journaled" {
+		t.Errorf("expected the planned description in the diff, got %+v", create.Diff)
+	}
+	if del.Operation != "delete" || del.ResourceID != id || del.Activation != 2 || del.Diff["name"].Old != "tf-journal-dst" {
+		t.Errorf("unexpected delete record %+v", del)
+	}
+}
+
+func TestJournalDiff_RedactsSensitive(t *testing.T) {
+	r := &schema.Resource{Schema: map[string]*schema.Schema{
+		"name":     {Type: schema.TypeString, Optional: true},
+		"password": {Type: schema.TypeString, Optional: true, Sensitive: true},
+		"vpn_credentials": {Type: schema.TypeList, Optional: true, Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+			"fqdn":           {Type: schema.TypeString, Optional: true},
+			"pre_shared_key": {Type: schema.TypeString, Optional: true, Sensitive: true},
+		}}},
+	}}
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":            "branch",
+		"password":        "hunter2",
+		"vpn_credentials": []interface{}{map[string]interface{}{"fqdn": "branch@acme.com", "pre_shared_key": "secret"}},
+	})
+
+	diff := journalDiff(r.Schema, d, false)
+	line, _ := json.Marshal(diff)
+	want := `{"name":{"old":"","new":"branch"},"password":{"old":"","new":"(sensitive)"},` +
+		`"vpn_credentials":{"old":[],"new":[{"fqdn":"branch@acme.com","pre_shared_key":"(sensitive)"}]}}`
+	if string(line) != want {
+		t.Errorf("got %s, want %s", line, want)
+	}
+}
+
+func TestJournal_RecordsReorderWritesAtOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The engine keeps the context of the rule that started it, whose write
+	// has already been committed by the time a later cycle moves rules.
+	ctx, w := j.beginWrite(context.Background(), "zia_firewall_filtering_rule", "create")
+	j.commitWrite(w, "7", "first", nil)
+	j.record(journalReorderContext(ctx, "zia_firewall_filtering_rule", 9), journalRecord{
+		Method: http.MethodPut,
+		Path:   "/zia/api/v1/firewallFilteringRules/9",
+		Status: http.StatusOK,
+	})
+
+	records := readJournal(t, path)
+	if len(records) != 1 {
+		t.Fatalf("expected the reorder to be journaled at once, got %d records", len(records))
+	}
+	if rec := records[0]; rec.Operation != "reorder" || rec.ResourceType != "zia_firewall_filtering_rule" || rec.ResourceID != "9" || rec.Diff != nil {
+		t.Errorf("unexpected reorder record %+v", rec)
+	}
+	if len(w.calls) != 0 {
+		t.Errorf("expected nothing left on the committed write, got %d records", len(w.calls))
+	}
+}
@@ -0,0 +1,227 @@
+package zia
+
+import (
+	"strconv"
+	"time"
+)
+
+// nssTimeFields are the fields of the time a record was logged, available in
+// every log type.
+var nssTimeFields = []string{"time", "tz", "ss", "mm", "hh", "dd", "mth", "yy", "mon", "day", "epochtime"}
+
+// nssEncodedFields are the fields in which NSS hex encodes the custom escaped
+// characters.
+var nssEncodedFields = map[string]bool{"eurl": true, "ehost": true, "ereferer": true}
+
+// nssLogFields are the fields of the log types whose feed output format is
+// checked, by nss_log_type. The catalog is not exhaustive: a field it does not
+// list is reported as a warning, not rejected. TUNNEL is the tunnel log of a MULTIFEEDLOG feed,
+// and is only used by zia_nss_feed_format_preview.
+var nssLogFields = map[string][]string{
+	"WEBLOG": {
+		"recordid", "login", "elogin", "dept", "edepartment", "location", "elocation", "cip", "cintip", "sip",
+		"proto", "action", "reason", "url", "eurl", "host", "ehost", "referer", "ereferer", "ua", "eua",
+		"reqmethod", "respcode", "totalsize", "reqsize", "respsize", "reqhdrsize", "resphdrsize",
+		"reqdatasize", "respdatasize", "urlcat", "urlsupercat", "urlclass", "urlcatmethod", "appname",
+		"appclass", "app_status", "malwarecat", "malwareclass", "threatname", "threatseverity", "riskscore",
+		"dlpeng", "dlpdict", "dlpidentifier", "dlpmd5", "filetype", "fileclass", "filename", "filesubtype",
+		"upload_filetype", "upload_filename", "upload_fileclass", "bamd5", "sha256", "bwthrottle",
+		"bwclassname", "bwrulename", "contenttype", "unscannabletype", "deviceowner", "devicehostname",
+		"devicemodel", "deviceostype", "deviceosversion", "deviceappversion", "keyprotectiontype",
+		"ruletype", "rulelabel", "product", "vendor", "ssldecrypted", "clienttranstime", "servertranstime",
+		"mobappname", "mobappcat", "mobdevtype", "campaign", "reqversion", "dlprulename", "srcip_country", "dstip_country", "clientsslcipher", "serversslcipher", "clienttlsversion",
+		"servertlsversion", "externalspr", "is_ssluntrusted", "is_sslselfsigned", "is_sslexpired",
+		"productversion", "company", "useragenttoken", "trafficredirectmethod", "location_id",
+		"userlocationname", "bypassed_traffic", "cloudname", "datacenter", "datacentercity",
+		"datacentercountry", "flow_type", "forward_gateway_name", "forward_type", "sdid", "user_agent_class",
+	},
+	"FWLOG": {
+		"recordid", "login", "elogin", "dept", "edepartment", "location", "elocation", "csip", "cdip",
+		"csport", "cdport", "ssip", "sdip", "ssport", "sdport", "tsip", "tsport", "ttype", "ipproto",
+		"action", "nwapp", "nwsvc", "rulelabel", "erulelabel", "ipcat", "srcip_country", "destcountry",
+		"threatcat", "threatname", "ethreatname", "threat_score", "threat_severity", "ipsrulelabel",
+		"eipsrulelabel", "ipscat", "avgduration", "duration", "durationms", "numsessions", "inbytes", "outbytes", "stateful", "aggregate", "dnat",
+		"dnatrulelabel", "ednatrulelabel", "bypassed_session", "bypassed_etime", "flow_type",
+		"devicehostname", "deviceowner", "external_deviceid", "ztunnelversion", "deviceostype",
+		"devicemodel", "deviceosversion", "deviceappversion", "location_id", "datacenter",
+		"datacentercity", "datacentercountry", "product", "vendor",
+	},
+	"DNSLOG": {
+		"recordid", "login", "elogin", "dept", "edepartment", "location", "elocation", "cip", "sip",
+		"sport", "req", "reqtype", "res", "restype", "reqaction", "resaction", "reqrulelabel",
+		"resrulelabel", "dnsapp", "dnsappcat", "category", "durationms", "protocol", "istcp", "error",
+		"ecs_prefix", "ecs_slot", "dnsgw_slot", "dnsgw_flags", "dnsgw_srv_proto", "srv_dip", "srv_dport",
+		"srv_rsp", "devicehostname", "deviceowner", "deviceostype", "devicemodel", "deviceosversion",
+		"deviceappversion", "location_id", "datacenter", "datacentercity", "datacentercountry", "product",
+		"vendor",
+	},
+	"TUNNEL": {
+		"recordtype", "tunneltype", "event", "eventreason", "locationname", "elocationname",
+		"vpncredentialname", "evpncredentialname", "sourceip", "destinationip", "sourceport",
+		"destinationport", "txbytes", "rxbytes", "txpackets", "rxpackets", "dpdrec", "olocationid",
+		"ikeversion", "lifetime", "spi_in", "spi_out", "algo", "authentication", "authtype",
+		"recordid", "datacenter", "datacentercity", "datacentercountry", "product", "vendor",
+	},
+	"ENDPOINT_DLP": {
+		"recordid", "user", "department", "actiontaken", "activitytype", "channel", "confirmaction",
+		"confirmjust", "dataidcount", "deviceappcount", "devicehostname", "deviceosversion",
+		"deviceowner", "deviceplatform", "devicetype", "devicename", "dlpdictcount", "dlpdictnames",
+		"dlpenginenames", "expectedaction", "filedoctype", "filedstpath", "filemd5", "filesha",
+		"filesrcpath", "filetypecategory", "filetypename", "itemdstname", "itemname", "itemsrcname",
+		"itemtype", "logtype", "numdlpdictids", "numdlpengineids", "othermatchcount", "scantime",
+		"severity", "srctype", "dsttype", "triggeredrulelabel", "product", "vendor",
+	},
+	"CASB_FILELOG": {
+		"recordid", "company", "applicationname", "tenant", "user", "owner", "department", "filename",
+		"filesize", "filemd5", "fileid", "filetype", "fileclass", "filepath", "collaborationscope",
+		"extownername", "extcollabnames", "intcollabnames", "lastmodtime", "filescantime", "policy",
+		"ruletype", "rulelabel", "action", "dlpdict", "dlpeng", "dlpidentifier", "threatname",
+		"malware", "malwareclass", "sharedlink", "product", "vendor",
+	},
+}
+
+// nssSampleTime is when the sample records of zia_nss_feed_format_preview
+// were logged.
+var nssSampleTime = time.Date(2026, time.January, 15, 9, 30, 5, 0, time.UTC)
+
+// nssSampleRecords are the records zia_nss_feed_format_preview renders when
+// none are given.
+var nssSampleRecords = map[string][]map[string]string{
+	"WEBLOG": {
+		{
+			"recordid": "7351205412349083649", "login": "alice@acme.com", "elogin": "alice@acme.com",
+			"dept": "Finance", "edepartment": "Finance", "location": "San Jose HQ", "elocation": "San Jose HQ",
+			"cip": "10.10.1.25", "cintip": "203.0.113.10", "sip": "93.184.216.34", "proto": "HTTPS",
+			"action": "Allowed", "reason": "Allowed", "url": "www.example.com/search?q=a,b\"c",
+			"eurl": "www.example.com/search?q=a,b\"c", "host": "www.example.com", "ehost": "www.example.com",
+			"referer": "www.example.com/", "ereferer": "www.example.com/", "ua": "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+			"eua": "Mozilla/5.0 (Windows NT 10.0; Win64; x64)", "reqmethod": "GET", "respcode": "200",
+			"totalsize": "5230", "reqsize": "830", "respsize": "4400", "urlcat": "Professional Services",
+			"urlsupercat": "Business and Economy", "urlclass": "Business Use", "appname": "General Browsing",
+			"appclass": "General Browsing", "app_status": "N/A", "riskscore": "0", "filetype": "None",
+			"fileclass": "None", "contenttype": "text/html", "deviceowner": "alice",
+			"devicehostname": "ALICE-LAPTOP", "keyprotectiontype": "N/A", "ruletype": "None",
+			"rulelabel": "None", "product": "NSS", "vendor": "Zscaler",
+		},
+		{
+			"recordid": "7351205412349083650", "login": "bob@acme.com", "elogin": "bob@acme.com",
+			"dept": "Engineering", "edepartment": "Engineering", "location": "Road Warrior",
+			"elocation": "Road Warrior", "cip": "192.168.1.7", "cintip": "198.51.100.7", "sip": "198.51.100.80",
+			"proto": "HTTP", "action": "Blocked", "reason": "Malware Site", "url": "malware.test/payload.exe",
+			"eurl": "malware.test/payload.exe", "host": "malware.test", "ehost": "malware.test", "referer": "None",
+			"ereferer": "None", "ua": "curl/8.5.0", "eua": "curl/8.5.0", "reqmethod": "GET", "respcode": "403",
+			"totalsize": "912", "reqsize": "312", "respsize": "600", "urlcat": "Malicious Content",
+			"urlsupercat": "Security", "urlclass": "Security Risk", "appname": "General Browsing",
+			"appclass": "General Browsing", "malwarecat": "Virus", "malwareclass": "Virus",
+			"threatname": "Win32.Trojan.Test", "threatseverity": "Critical (90-100)", "riskscore": "100",
+			"filetype": "EXE", "fileclass": "Executable", "contenttype": "application/octet-stream",
+			"deviceowner": "bob", "devicehostname": "BOB-DESKTOP", "ruletype": "URL Filtering",
+			"rulelabel": "Block Malware", "product": "NSS", "vendor": "Zscaler",
+		},
+	},
+	"FWLOG": {
+		{
+			"recordid": "7351205412349090001", "login": "alice@acme.com", "elogin": "alice@acme.com",
+			"dept": "Finance", "edepartment": "Finance", "location": "San Jose HQ", "elocation": "San Jose HQ",
+			"csip": "10.10.1.25", "cdip": "93.184.216.34", "csport": "53122", "cdport": "443",
+			"ssip": "203.0.113.10", "sdip": "93.184.216.34", "ssport": "41233", "sdport": "443",
+			"tsip": "203.0.113.10", "tsport": "41233", "ttype": "GRE", "ipproto": "TCP", "action": "Allow",
+			"nwapp": "https", "nwsvc": "HTTPS", "rulelabel": "Allow Web", "erulelabel": "Allow Web",
+			"ipcat": "Professional Services", "srcip_country": "United States", "destcountry": "United States",
+			"avgduration": "1200", "duration": "1", "durationms": "1200", "numsessions": "1",
+			"inbytes": "4400", "outbytes": "830", "stateful": "Yes", "aggregate": "No",
+			"devicehostname": "ALICE-LAPTOP", "deviceowner": "alice", "product": "NSS", "vendor": "Zscaler",
+		},
+		{
+			"recordid": "7351205412349090002", "login": "bob@acme.com", "elogin": "bob@acme.com",
+			"dept": "Engineering", "edepartment": "Engineering", "location": "Road Warrior",
+			"elocation": "Road Warrior", "csip": "192.168.1.7", "cdip": "198.51.100.25", "csport": "60123",
+			"cdport": "3389", "ssip": "198.51.100.7", "sdip": "198.51.100.25", "ssport": "40211",
+			"sdport": "3389", "tsip": "198.51.100.7", "tsport": "40211", "ttype": "ZTUNNEL", "ipproto": "TCP",
+			"action": "Drop", "nwapp": "rdp", "nwsvc": "RDP", "rulelabel": "Block RDP", "erulelabel": "Block RDP",
+			"ipcat": "Miscellaneous or Unknown", "srcip_country": "Germany", "destcountry": "Netherlands",
+			"threatcat": "None", "threatname": "None", "durationms": "0", "numsessions": "1",
+			"inbytes": "0", "outbytes": "60", "stateful": "Yes", "aggregate": "No",
+			"devicehostname": "BOB-DESKTOP", "deviceowner": "bob", "product": "NSS", "vendor": "Zscaler",
+		},
+	},
+	"DNSLOG": {
+		{
+			"recordid": "7351205412349095001", "login": "alice@acme.com", "elogin": "alice@acme.com",
+			"dept": "Finance", "edepartment": "Finance", "location": "San Jose HQ", "elocation": "San Jose HQ",
+			"cip": "10.10.1.25", "sip": "185.46.212.88", "sport": "53", "req": "www.example.com",
+			"reqtype": "A record", "res": "93.184.216.34", "restype": "IPv4", "reqaction": "REQ_ALLOW",
+			"resaction": "RES_ALLOW", "reqrulelabel": "Allow DNS", "resrulelabel": "Allow DNS",
+			"dnsapp": "None", "dnsappcat": "None", "category": "Professional Services", "durationms": "12",
+			"protocol": "UDP", "istcp": "0", "devicehostname": "ALICE-LAPTOP", "deviceowner": "alice",
+			"product": "NSS", "vendor": "Zscaler",
+		},
+		{
+			"recordid": "7351205412349095002", "login": "bob@acme.com", "elogin": "bob@acme.com",
+			"dept": "Engineering", "edepartment": "Engineering", "location": "Road Warrior",
+			"elocation": "Road Warrior", "cip": "192.168.1.7", "sip": "185.46.212.88", "sport": "53",
+			"req": "tunnel.example.net", "reqtype": "TXT record", "res": "None", "restype": "None",
+			"reqaction": "REQ_BLOCK", "resaction": "None", "reqrulelabel": "Block DNS Tunnels",
+			"dnsapp": "DNS Tunnel", "dnsappcat": "DNS Tunnels", "category": "Miscellaneous or Unknown",
+			"durationms": "3", "protocol": "UDP", "istcp": "0", "error": "None",
+			"devicehostname": "BOB-DESKTOP", "deviceowner": "bob", "product": "NSS", "vendor": "Zscaler",
+		},
+	},
+	"TUNNEL": {
+		{
+			"recordtype": "Tunnel Event", "tunneltype": "IPSEC IKEV2", "event": "Tunnel is up",
+			"eventreason": "None", "locationname": "San Jose HQ", "elocationname": "San Jose HQ",
+			"vpncredentialname": "sjc-hq@acme.com", "evpncredentialname": "sjc-hq@acme.com",
+			"sourceip": "203.0.113.10", "destinationip": "165.225.48.10", "sourceport": "4500",
+			"destinationport": "4500", "ikeversion": "2", "recordid": "7351205412349097001",
+			"product": "NSS", "vendor": "Zscaler",
+		},
+		{
+			"recordtype": "Tunnel Samples", "tunneltype": "GRE", "locationname": "London Office",
+			"elocationname": "London Office", "sourceip": "198.51.100.44", "destinationip": "165.225.80.10",
+			"txbytes": "10485760", "rxbytes": "52428800", "txpackets": "8000", "rxpackets": "36000",
+			"recordid": "7351205412349097002", "product": "NSS", "vendor": "Zscaler",
+		},
+	},
+	"ENDPOINT_DLP": {
+		{
+			"recordid": "7351205412349099001", "user": "alice@acme.com", "department": "Finance",
+			"actiontaken": "block", "activitytype": "Upload", "channel": "Removable Storage",
+			"devicehostname": "ALICE-LAPTOP", "deviceowner": "alice", "deviceplatform": "Windows",
+			"devicetype": "Laptop", "dlpdictcount": "1", "dlpdictnames": "Credit Cards",
+			"dlpenginenames": "PCI", "expectedaction": "block", "filedoctype": "Spreadsheet",
+			"filemd5": "d41d8cd98f00b204e9800998ecf8427e", "filesrcpath": "C:\\Users\\alice\\cards.xlsx",
+			"filedstpath": "E:\\cards.xlsx", "filetypename": "xlsx", "itemname": "cards.xlsx",
+			"itemtype": "File", "severity": "High", "triggeredrulelabel": "Block Card Data",
+			"product": "NSS", "vendor": "Zscaler",
+		},
+	},
+	"CASB_FILELOG": {
+		{
+			"recordid": "7351205412349099501", "company": "Acme", "applicationname": "OneDrive",
+			"tenant": "acme.onmicrosoft.com", "user": "alice@acme.com", "owner": "alice@acme.com",
+			"department": "Finance", "filename": "Q4 forecast.xlsx", "filesize": "48213",
+			"filemd5": "9e107d9d372bb6826bd81d3542a419d6", "filetype": "xlsx", "fileclass": "Office Documents",
+			"collaborationscope": "External", "extcollabnames": "partner@example.com", "policy": "DLP",
+			"ruletype": "SaaS Security", "rulelabel": "Quarantine Shared Finance Files", "action": "Quarantine",
+			"dlpdict": "Financial Statements", "dlpeng": "Finance", "product": "NSS", "vendor": "Zscaler",
+		},
+	},
+}
+
+// nssSampleTimeFields returns the time fields of a record logged at t.
+func nssSampleTimeFields(t time.Time) map[string]string {
+	return map[string]string{
+		"time":      t.Format("Mon Jan 2 15:04:05 2006"),
+		"tz":        "GMT",
+		"ss":        strconv.Itoa(t.Second()),
+		"mm":        strconv.Itoa(t.Minute()),
+		"hh":        strconv.Itoa(t.Hour()),
+		"dd":        strconv.Itoa(t.Day()),
+		"mth":       strconv.Itoa(int(t.Month())),
+		"yy":        strconv.Itoa(t.Year()),
+		"mon":       t.Format("Jan"),
+		"day":       t.Format("Mon"),
+		"epochtime": strconv.FormatInt(t.Unix(), 10),
+	}
+}
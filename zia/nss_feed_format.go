@@ -0,0 +1,216 @@
+package zia
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// nssFormatToken is a piece of a feed output format: literal text, or a
+// field printed with a Go verb such as %02d.
+type nssFormatToken struct {
+	literal string
+	field   string
+	verb    string
+}
+
+// nssFieldPattern matches the start of a field token, such as %s{login} or
+// %02d{mth}, up to its opening brace.
+var nssFieldPattern = regexp.MustCompile(`^%([-0]?[0-9]*)([a-z]+)\{`)
+
+// nssConversions maps the conversions NSS accepts in a field token to the Go
+// verb printing them.
+var nssConversions = map[string]string{
+	"s": "s", "d": "d", "u": "d", "ld": "d", "lu": "d", "lld": "d", "llu": "d", "f": "f", "x": "x",
+}
+
+var nssEscapedCharacterPattern = regexp.MustCompile(`^ASCII_([0-9]|[1-9][0-9]|1[01][0-9]|12[0-7])$`)
+
+// parseNSSFeedFormat splits a feed output format into tokens. Braces outside
+// a field token must be escaped as \{ and \}; a % that does not start a field
+// token is printed as is.
+func parseNSSFeedFormat(format string) ([]nssFormatToken, error) {
+	var tokens []nssFormatToken
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			tokens = append(tokens, nssFormatToken{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(format); {
+		c := format[i]
+		switch {
+		case c == '\\' && i+1 < len(format) && (format[i+1] == '{' || format[i+1] == '}'):
+			literal.WriteByte(format[i+1])
+			i += 2
+		case c == '{' || c == '}':
+			return nil, fmt.Errorf("unescaped %q at offset %d: write \\%c for a literal brace", c, i, c)
+		case c == '%':
+			m := nssFieldPattern.FindStringSubmatch(format[i:])
+			if m == nil {
+				literal.WriteByte(c)
+				i++
+				continue
+			}
+			verb, ok := nssConversions[m[2]]
+			if !ok {
+				return nil, fmt.Errorf("unsupported conversion %%%s at offset %d", m[2], i)
+			}
+			end := strings.IndexByte(format[i+len(m[0]):], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated field token at offset %d", i)
+			}
+			field := format[i+len(m[0]) : i+len(m[0])+end]
+			if field == "" || strings.ContainsAny(field, "{%\\ ") {
+				return nil, fmt.Errorf("invalid field name %q at offset %d", field, i)
+			}
+			flush()
+			tokens = append(tokens, nssFormatToken{field: field, verb: "%" + m[1] + verb})
+			i += len(m[0]) + end + 1
+		default:
+			literal.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return tokens, nil
+}
+
+// nssFeedFieldWarnings warns about the fields of tokens that are not in the
+// catalog of logType. The catalog may miss fields Zscaler has added, so they
+// are not rejected: NSS prints a field a log type does not have as None. Log
+// types without a field catalog are not checked.
+func nssFeedFieldWarnings(tokens []nssFormatToken, logType string) diag.Diagnostics {
+	fields := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if t.field != "" {
+			fields = append(fields, t.field)
+		}
+	}
+	return nssUnknownFieldWarnings("feed_output_format", fields, logType)
+}
+
+// nssUnknownFieldWarnings warns about the fields not in the catalog of
+// logType, naming them in what.
+func nssUnknownFieldWarnings(what string, fields []string, logType string) diag.Diagnostics {
+	unknown := unknownNSSFields(fields, logType)
+	if len(unknown) == 0 {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("%s uses fields that are not known for %s logs", what, logType),
+		Detail:   fmt.Sprintf("Unknown fields: %s. NSS prints a field the log type does not have as None; ignore this warning if the fields are valid.", strings.Join(unknown, ", ")),
+	}}
+}
+
+func unknownNSSFields(fields []string, logType string) []string {
+	catalog, ok := nssLogFields[logType]
+	if !ok {
+		return nil
+	}
+	known := make(map[string]bool, len(catalog)+len(nssTimeFields))
+	for _, f := range catalog {
+		known[f] = true
+	}
+	for _, f := range nssTimeFields {
+		known[f] = true
+	}
+	seen := map[string]bool{}
+	var unknown []string
+	for _, f := range fields {
+		if !known[f] && !seen[f] {
+			seen[f] = true
+			unknown = append(unknown, "{"+f+"}")
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// nssEscapedCharacters decodes custom_escaped_character values such as
+// ASCII_44.
+func nssEscapedCharacters(values []string) ([]byte, error) {
+	chars := make([]byte, 0, len(values))
+	for _, v := range values {
+		m := nssEscapedCharacterPattern.FindStringSubmatch(v)
+		if m == nil {
+			return nil, fmt.Errorf("invalid custom escaped character %q: expected ASCII_<code> with a code from 0 to 127", v)
+		}
+		code, _ := strconv.Atoi(m[1])
+		chars = append(chars, byte(code))
+	}
+	return chars, nil
+}
+
+// renderNSSRecord prints a record the way NSS does. A field the record does
+// not have prints as None, or 0 for a number; the characters in escaped are
+// hex encoded in the encoded URL, host and referer fields.
+func renderNSSRecord(tokens []nssFormatToken, record map[string]string, escaped []byte) string {
+	var out strings.Builder
+	for _, t := range tokens {
+		if t.field == "" {
+			out.WriteString(t.literal)
+			continue
+		}
+		value, ok := record[t.field]
+		switch t.verb[len(t.verb)-1] {
+		case 'd', 'x':
+			n, _ := strconv.ParseInt(value, 10, 64)
+			fmt.Fprintf(&out, t.verb, n)
+		case 'f':
+			f, _ := strconv.ParseFloat(value, 64)
+			fmt.Fprintf(&out, t.verb, f)
+		default:
+			if !ok {
+				value = "None"
+			}
+			if nssEncodedFields[t.field] {
+				value = nssHexEncode(value, escaped)
+			}
+			fmt.Fprintf(&out, t.verb, value)
+		}
+	}
+	return out.String()
+}
+
+func nssHexEncode(value string, escaped []byte) string {
+	if len(escaped) == 0 {
+		return value
+	}
+	var out strings.Builder
+	for i := 0; i < len(value); i++ {
+		if strings.IndexByte(string(escaped), value[i]) >= 0 {
+			fmt.Fprintf(&out, "%%%02X", value[i])
+			continue
+		}
+		out.WriteByte(value[i])
+	}
+	return out.String()
+}
+
+// nssFeedOutput joins rendered records into what the SIEM receives, and
+// reports whether a JSON feed is valid JSON: the whole array when records are
+// streamed as a JSON array, every record otherwise.
+func nssFeedOutput(records []string, jsonArray bool) (string, bool) {
+	trimmed := make([]string, len(records))
+	for i, r := range records {
+		trimmed[i] = strings.TrimRight(r, "\r\n")
+	}
+	if jsonArray {
+		output := "[" + strings.Join(trimmed, ",") + "]"
+		return output, json.Valid([]byte(output))
+	}
+	valid := true
+	for _, r := range trimmed {
+		valid = valid && json.Valid([]byte(r))
+	}
+	return strings.Join(trimmed, "\n") + "\n", valid
+}
@@ -0,0 +1,137 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestParseNSSFeedFormat(t *testing.T) {
+	cases := []struct {
+		format string
+		fields []string
+		err    string
+	}{
+		{`\{"user":"%s{elogin}","month":"%02d{mth}"\}`, []string{"elogin", "mth"}, ""},
+		{`100% %s{login}`, []string{"login"}, ""},
+		{`{"user":"%s{login}"}`, nil, `unescaped '{' at offset 0`},
+		{`%s{login`, nil, "unterminated field token at offset 0"},
+		{`%q{login}`, nil, "unsupported conversion %q"},
+		{`%s{}`, nil, `invalid field name ""`},
+	}
+	for _, c := range cases {
+		tokens, err := parseNSSFeedFormat(c.format)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%s: expected an error containing %q, got %v", c.format, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.format, err)
+			continue
+		}
+		var fields []string
+		for _, tok := range tokens {
+			if tok.field != "" {
+				fields = append(fields, tok.field)
+			}
+		}
+		if strings.Join(fields, ",") != strings.Join(c.fields, ",") {
+			t.Errorf("%s: expected fields %v, got %v", c.format, c.fields, fields)
+		}
+	}
+}
+
+func TestRenderNSSRecord(t *testing.T) {
+	tokens, err := parseNSSFeedFormat(`\{"url":"%s{eurl}","size":%d{totalsize},"date":"%d{yy}-%02d{mth}-%02d{dd}","risk":"%s{threatname}"\}` + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := nssFeedFieldWarnings(tokens, "WEBLOG"); len(diags) > 0 {
+		t.Fatal(diags)
+	}
+	record := nssSampleTimeFields(nssSampleTime)
+	record["eurl"] = `acme.com/?q=a,b"c`
+	record["totalsize"] = "512"
+
+	unescaped := renderNSSRecord(tokens, record, nil)
+	if _, valid := nssFeedOutput([]string{unescaped}, false); valid {
+		t.Errorf("expected a quote in the URL to break the JSON, got %s", unescaped)
+	}
+
+	escaped, err := nssEscapedCharacters([]string{"ASCII_34", "ASCII_44"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := renderNSSRecord(tokens, record, escaped)
+	want := `{"url":"acme.com/?q=a%2Cb%22c","size":512,"date":"2026-01-15","risk":"None"}` + "\n"
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	output, valid := nssFeedOutput([]string{got, got}, true)
+	if !valid || !strings.HasPrefix(output, `[{"url"`) || strings.Contains(output, "\n") {
+		t.Errorf("expected a valid JSON array, got %s", output)
+	}
+}
+
+func TestValidateCloudNSSFeedFormat(t *testing.T) {
+	cases := []struct {
+		config map[string]interface{}
+		err    string
+	}{
+		{map[string]interface{}{"nss_log_type": "FWLOG", "feed_output_format": `\{"src":"%s{csip}","user":"%s{elogin}"\}`}, ""},
+		{map[string]interface{}{"nss_log_type": "ADMIN_AUDIT", "feed_output_format": `%s{anything}`}, ""},
+		{map[string]interface{}{"nss_log_type": "WEBLOG", "feed_output_format": `{%s{login}}`}, "unescaped '{'"},
+		{map[string]interface{}{"nss_feed_type": "CSV", "json_array_toggle": true}, ""},
+		{map[string]interface{}{"custom_escaped_character": []interface{}{"ASCII_300"}}, "must be ASCII_<code>"},
+	}
+	r := resourceCloudNSSFeed()
+	for i, c := range cases {
+		config := terraform.NewResourceConfigRaw(c.config)
+		var err error
+		if diags := r.Validate(config); diags.HasError() {
+			err = fmt.Errorf("%v", diags)
+		} else {
+			_, err = r.Diff(context.Background(), nil, config, &Client{})
+		}
+		if c.err == "" && err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("case %d: expected an error containing %q, got %v", i, c.err, err)
+		}
+	}
+}
+
+func TestWarnCloudNSSFeedFields(t *testing.T) {
+	r := resourceCloudNSSFeed()
+	warn := func(logType, format string) diag.Diagnostics {
+		t.Helper()
+		attrs := map[string]cty.Value{}
+		for key, typ := range r.CoreConfigSchema().ImpliedType().AttributeTypes() {
+			attrs[key] = cty.NullVal(typ)
+		}
+		attrs["nss_log_type"] = cty.StringVal(logType)
+		attrs["feed_output_format"] = cty.StringVal(format)
+		resp := &schema.ValidateResourceConfigFuncResponse{}
+		for _, f := range r.ValidateRawResourceConfigFuncs {
+			f(context.Background(), schema.ValidateResourceConfigFuncRequest{RawConfig: cty.ObjectVal(attrs)}, resp)
+		}
+		return resp.Diagnostics
+	}
+
+	diags := warn("FWLOG", `\{"url":"%s{eurl}","user":"%s{elogin}"\}`)
+	if len(diags) != 1 || diags[0].Severity != diag.Warning || !strings.Contains(diags[0].Detail, "Unknown fields: {eurl}.") {
+		t.Errorf("expected a warning naming {eurl}, got %v", diags)
+	}
+	if diags := warn("WEBLOG", `%s{mobappname},%s{mobappcat},%s{mobdevtype},%s{campaign},%s{reqversion},%s{dlprulename}`); len(diags) > 0 {
+		t.Errorf("expected the mobile, campaign and DLP rule fields to be known, got %v", diags)
+	}
+}
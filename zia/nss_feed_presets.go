@@ -0,0 +1,245 @@
+package zia
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// nssPresetField is a key of a preset record and the format printing its
+// value, such as {"user", "%s{elogin}"}.
+type nssPresetField struct {
+	key, format string
+}
+
+// nssFeedPreset is the feed configuration a SIEM expects, at one version.
+// Versions are never changed once released, so a pinned version keeps the
+// same output across provider upgrades.
+type nssFeedPreset struct {
+	siem, version string
+	// style is how records are framed: "hec" wraps the fields in a Splunk
+	// HTTP Event Collector event, "json" prints them as one JSON object and
+	// "leef" as a QRadar LEEF event.
+	style           string
+	nssFeedType     string
+	siemType        string
+	jsonArrayToggle bool
+	escapedChars    []string
+	maxBatchSize    int
+	oauth           bool
+	authHeader      string
+	fields          map[string][]nssPresetField
+	hecSourcetypes  map[string]string
+	leefEventIDs    map[string]string
+}
+
+var nssPresetTimestamp = "%d{yy}-%02d{mth}-%02d{dd} %02d{hh}:%02d{mm}:%02d{ss}"
+
+// nssPresetFieldsV1 are the fields of the formats Zscaler publishes for
+// Splunk, by nss_log_type.
+var nssPresetFieldsV1 = map[string][]nssPresetField{
+	"WEBLOG": {
+		{"datetime", nssPresetTimestamp}, {"reason", "%s{reason}"}, {"event_id", "%d{recordid}"},
+		{"protocol", "%s{proto}"}, {"action", "%s{action}"}, {"transactionsize", "%d{totalsize}"},
+		{"responsesize", "%d{respsize}"}, {"requestsize", "%d{reqsize}"}, {"urlcategory", "%s{urlcat}"},
+		{"serverip", "%s{sip}"}, {"requestmethod", "%s{reqmethod}"}, {"refererURL", "%s{ereferer}"},
+		{"useragent", "%s{eua}"}, {"product", "NSS"}, {"location", "%s{elocation}"}, {"ClientIP", "%s{cip}"},
+		{"status", "%s{respcode}"}, {"user", "%s{elogin}"}, {"url", "%s{eurl}"}, {"vendor", "Zscaler"},
+		{"hostname", "%s{ehost}"}, {"clientpublicIP", "%s{cintip}"}, {"threatcategory", "%s{malwarecat}"},
+		{"threatname", "%s{threatname}"}, {"filetype", "%s{filetype}"}, {"appname", "%s{appname}"},
+		{"app_status", "%s{app_status}"}, {"pagerisk", "%d{riskscore}"}, {"threatseverity", "%s{threatseverity}"},
+		{"department", "%s{edepartment}"}, {"urlsupercategory", "%s{urlsupercat}"}, {"appclass", "%s{appclass}"},
+		{"dlpengine", "%s{dlpeng}"}, {"urlclass", "%s{urlclass}"}, {"threatclass", "%s{malwareclass}"},
+		{"dlpdictionaries", "%s{dlpdict}"}, {"fileclass", "%s{fileclass}"}, {"bwthrottle", "%s{bwthrottle}"},
+		{"contenttype", "%s{contenttype}"}, {"unscannabletype", "%s{unscannabletype}"},
+		{"deviceowner", "%s{deviceowner}"}, {"devicehostname", "%s{devicehostname}"},
+		{"keyprotectiontype", "%s{keyprotectiontype}"},
+	},
+	"FWLOG": {
+		{"datetime", "%s{time}"}, {"user", "%s{elogin}"}, {"department", "%s{dept}"},
+		{"locationname", "%s{location}"}, {"cdport", "%d{cdport}"}, {"csport", "%d{csport}"},
+		{"sdport", "%d{sdport}"}, {"ssport", "%d{ssport}"}, {"csip", "%s{csip}"}, {"cdip", "%s{cdip}"},
+		{"ssip", "%s{ssip}"}, {"sdip", "%s{sdip}"}, {"tsip", "%s{tsip}"}, {"tunsport", "%d{tsport}"},
+		{"tuntype", "%s{ttype}"}, {"action", "%s{action}"}, {"dnat", "%s{dnat}"}, {"stateful", "%s{stateful}"},
+		{"aggregate", "%s{aggregate}"}, {"nwsvc", "%s{nwsvc}"}, {"nwapp", "%s{nwapp}"}, {"proto", "%s{ipproto}"},
+		{"ipcat", "%s{ipcat}"}, {"destcountry", "%s{destcountry}"}, {"avgduration", "%d{avgduration}"},
+		{"rulelabel", "%s{erulelabel}"}, {"inbytes", "%ld{inbytes}"}, {"outbytes", "%ld{outbytes}"},
+		{"duration", "%d{duration}"}, {"durationms", "%d{durationms}"}, {"numsessions", "%d{numsessions}"},
+		{"ipsrulelabel", "%s{ipsrulelabel}"}, {"threatcat", "%s{threatcat}"}, {"threatname", "%s{ethreatname}"},
+		{"deviceowner", "%s{deviceowner}"}, {"devicehostname", "%s{devicehostname}"},
+		{"threat_score", "%d{threat_score}"}, {"threat_severity", "%s{threat_severity}"},
+	},
+	"DNSLOG": {
+		{"datetime", "%s{time}"}, {"user", "%s{elogin}"}, {"department", "%s{edepartment}"},
+		{"location", "%s{elocation}"}, {"reqaction", "%s{reqaction}"}, {"resaction", "%s{resaction}"},
+		{"reqrulelabel", "%s{reqrulelabel}"}, {"resrulelabel", "%s{resrulelabel}"}, {"dns_reqtype", "%s{reqtype}"},
+		{"dns_req", "%s{req}"}, {"dns_resp", "%s{res}"}, {"srv_dport", "%d{sport}"}, {"durationms", "%d{durationms}"},
+		{"clt_sip", "%s{cip}"}, {"srv_dip", "%s{sip}"}, {"category", "%s{category}"}, {"odeviceowner", "%s{deviceowner}"},
+		{"odevicehostname", "%s{devicehostname}"},
+	},
+}
+
+// nssPresetFieldsV2 adds the device and data center of the record to
+// nssPresetFieldsV1.
+var nssPresetFieldsV2 = func() map[string][]nssPresetField {
+	added := []nssPresetField{
+		{"deviceostype", "%s{deviceostype}"}, {"deviceosversion", "%s{deviceosversion}"},
+		{"devicemodel", "%s{devicemodel}"}, {"datacenter", "%s{datacenter}"},
+	}
+	fields := map[string][]nssPresetField{}
+	for logType, v1 := range nssPresetFieldsV1 {
+		fields[logType] = append(append([]nssPresetField{}, v1...), added...)
+	}
+	return fields
+}()
+
+var nssHECSourcetypes = map[string]string{"WEBLOG": "zscalernss-web", "FWLOG": "zscalernss-fw", "DNSLOG": "zscalernss-dns"}
+
+// nssJSONEscapedChars keeps quotes, commas and backslashes in URLs from
+// breaking JSON records.
+var nssJSONEscapedChars = []string{"ASCII_34", "ASCII_44", "ASCII_92"}
+
+// nssFeedPresets are the built-in presets, oldest version first per SIEM.
+var nssFeedPresets = []nssFeedPreset{
+	{
+		siem: "SPLUNK", version: "1", style: "hec", nssFeedType: "JSON", siemType: "SPLUNK",
+		jsonArrayToggle: true, escapedChars: nssJSONEscapedChars, maxBatchSize: 512,
+		authHeader: "Authorization:Splunk %s", fields: nssPresetFieldsV1, hecSourcetypes: nssHECSourcetypes,
+	},
+	{
+		siem: "SPLUNK", version: "2", style: "hec", nssFeedType: "JSON", siemType: "SPLUNK",
+		jsonArrayToggle: true, escapedChars: nssJSONEscapedChars, maxBatchSize: 512,
+		authHeader: "Authorization:Splunk %s", fields: nssPresetFieldsV2, hecSourcetypes: nssHECSourcetypes,
+	},
+	{
+		siem: "AZURE_SENTINEL", version: "1", style: "json", nssFeedType: "JSON", siemType: "AZURE_SENTINEL",
+		jsonArrayToggle: true, escapedChars: nssJSONEscapedChars, maxBatchSize: 512, oauth: true,
+		fields: nssPresetFieldsV2,
+	},
+	{
+		siem: "QRADAR", version: "1", style: "leef", nssFeedType: "QRADAR", siemType: "OTHER",
+		maxBatchSize: 64, fields: nssPresetFieldsV1,
+		leefEventIDs: map[string]string{"WEBLOG": "%s{action}", "FWLOG": "%s{action}", "DNSLOG": "%s{reqaction}"},
+	},
+	{
+		siem: "CHRONICLE", version: "1", style: "hec", nssFeedType: "JSON", siemType: "OTHER",
+		jsonArrayToggle: true, escapedChars: nssJSONEscapedChars, maxBatchSize: 512,
+		fields: nssPresetFieldsV1, hecSourcetypes: nssHECSourcetypes,
+	},
+	{
+		siem: "ELASTIC", version: "1", style: "json", nssFeedType: "JSON", siemType: "OTHER",
+		escapedChars: nssJSONEscapedChars, maxBatchSize: 1024, authHeader: "Authorization:ApiKey %s",
+		fields: nssPresetFieldsV2,
+	},
+}
+
+// nssFeedPresetSIEMs lists the SIEMs with a preset.
+func nssFeedPresetSIEMs() []string {
+	var siems []string
+	for _, p := range nssFeedPresets {
+		if len(siems) == 0 || siems[len(siems)-1] != p.siem {
+			siems = append(siems, p.siem)
+		}
+	}
+	return siems
+}
+
+// findNSSFeedPreset returns the preset of a SIEM at a version, or at its
+// latest version when version is empty.
+func findNSSFeedPreset(siem, version string) (*nssFeedPreset, error) {
+	var found *nssFeedPreset
+	var versions []string
+	for i := range nssFeedPresets {
+		p := &nssFeedPresets[i]
+		if p.siem != siem {
+			continue
+		}
+		versions = append(versions, p.version)
+		if version == "" || p.version == version {
+			found = p
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no preset for SIEM %s", siem)
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no version %s of the %s preset; available versions: %s", version, siem, strings.Join(versions, ", "))
+	}
+	return found, nil
+}
+
+// nssPresetFieldChange is a field of a preset record that was overridden,
+// added or excluded.
+type nssPresetFieldChange struct {
+	key, preset, value string
+}
+
+// applyFields returns the fields of a log type with the overrides applied in
+// place, the added fields appended by key, and the excluded fields removed.
+func (p *nssFeedPreset) applyFields(logType string, overrides map[string]string, exclude []string) ([]nssPresetField, []nssPresetFieldChange, error) {
+	base, ok := p.fields[logType]
+	if !ok {
+		return nil, nil, fmt.Errorf("the %s preset has no format for %s logs", p.siem, logType)
+	}
+	excluded := map[string]bool{}
+	for _, key := range exclude {
+		excluded[key] = true
+	}
+
+	var fields []nssPresetField
+	var changes []nssPresetFieldChange
+	seen := map[string]bool{}
+	for _, f := range base {
+		seen[f.key] = true
+		switch value, overridden := overrides[f.key]; {
+		case excluded[f.key]:
+			changes = append(changes, nssPresetFieldChange{key: f.key, preset: f.format})
+		case overridden && value != f.format:
+			changes = append(changes, nssPresetFieldChange{key: f.key, preset: f.format, value: value})
+			fields = append(fields, nssPresetField{f.key, value})
+		default:
+			fields = append(fields, f)
+		}
+	}
+
+	var added []string
+	for key := range overrides {
+		if !seen[key] && !excluded[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		changes = append(changes, nssPresetFieldChange{key: key, value: overrides[key]})
+		fields = append(fields, nssPresetField{key, overrides[key]})
+	}
+	for _, key := range exclude {
+		if !seen[key] {
+			return nil, nil, fmt.Errorf("cannot exclude %q: the %s preset for %s logs has no such field", key, p.siem, logType)
+		}
+	}
+	return fields, changes, nil
+}
+
+// format builds the feed output format printing fields.
+func (p *nssFeedPreset) format(logType string, fields []nssPresetField) string {
+	switch p.style {
+	case "leef":
+		pairs := make([]string, len(fields))
+		for i, f := range fields {
+			pairs[i] = f.key + "=" + f.format
+		}
+		return "%s{mon} %02d{dd} %02d{hh}:%02d{mm}:%02d{ss} zscaler-nss: LEEF:1.0|Zscaler|NSS|4.1|" +
+			p.leefEventIDs[logType] + "|" + strings.Join(pairs, "\t") + "\n"
+	default:
+		pairs := make([]string, len(fields))
+		for i, f := range fields {
+			pairs[i] = fmt.Sprintf(`"%s":"%s"`, f.key, f.format)
+		}
+		body := `\{` + strings.Join(pairs, ",") + `\}`
+		if p.style == "hec" {
+			body = fmt.Sprintf(`\{ "sourcetype" : "%s", "event" : %s\}`, p.hecSourcetypes[logType], body)
+		}
+		return body + "\n"
+	}
+}
@@ -0,0 +1,76 @@
+package zia
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNSSFeedPresets_RenderValidRecords(t *testing.T) {
+	for _, p := range nssFeedPresets {
+		for logType, fields := range p.fields {
+			format := p.format(logType, fields)
+			tokens, err := parseNSSFeedFormat(format)
+			if err != nil {
+				t.Errorf("%s v%s %s: %v", p.siem, p.version, logType, err)
+				continue
+			}
+			if diags := nssFeedFieldWarnings(tokens, logType); len(diags) > 0 {
+				t.Errorf("%s v%s %s: %v", p.siem, p.version, logType, diags)
+			}
+			if p.nssFeedType != "JSON" {
+				continue
+			}
+			escaped, _ := nssEscapedCharacters(p.escapedChars)
+			var records []string
+			for _, sample := range nssSampleRecords[logType] {
+				record := nssSampleTimeFields(nssSampleTime)
+				for k, v := range sample {
+					record[k] = v
+				}
+				records = append(records, renderNSSRecord(tokens, record, escaped))
+			}
+			if output, valid := nssFeedOutput(records, p.jsonArrayToggle); !valid {
+				t.Errorf("%s v%s %s: expected valid JSON, got %s", p.siem, p.version, logType, output)
+			}
+		}
+	}
+}
+
+func TestFindNSSFeedPreset(t *testing.T) {
+	p, err := findNSSFeedPreset("SPLUNK", "")
+	if err != nil || p.version != "2" {
+		t.Fatalf("expected the latest Splunk preset, got %+v, %v", p, err)
+	}
+	if p, err = findNSSFeedPreset("SPLUNK", "1"); err != nil || p.version != "1" {
+		t.Errorf("expected the pinned Splunk preset, got %+v, %v", p, err)
+	}
+	if _, err = findNSSFeedPreset("SPLUNK", "9"); err == nil || !strings.Contains(err.Error(), "available versions: 1, 2") {
+		t.Errorf("expected the available versions to be listed, got %v", err)
+	}
+}
+
+func TestNSSFeedPreset_ApplyFields(t *testing.T) {
+	p, _ := findNSSFeedPreset("ELASTIC", "1")
+	fields, changes, err := p.applyFields("DNSLOG",
+		map[string]string{"user": "%s{login}", "category": "%s{category}", "ecs": "%s{ecs_prefix}"},
+		[]string{"odeviceowner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []nssPresetFieldChange{
+		{key: "user", preset: "%s{elogin}", value: "%s{login}"},
+		{key: "odeviceowner", preset: "%s{deviceowner}"},
+		{key: "ecs", value: "%s{ecs_prefix}"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("expected changes %+v, got %+v", want, changes)
+	}
+	if fields[1] != (nssPresetField{"user", "%s{login}"}) || fields[len(fields)-1].key != "ecs" {
+		t.Errorf("expected the override in place and the addition last, got %+v", fields)
+	}
+
+	if _, _, err := p.applyFields("DNSLOG", nil, []string{"nope"}); err == nil || !strings.Contains(err.Error(), `cannot exclude "nope"`) {
+		t.Errorf("expected an unknown excluded field to be rejected, got %v", err)
+	}
+}
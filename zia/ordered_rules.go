@@ -0,0 +1,338 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
+)
+
+// OrderedRuleAdapter is the policy-specific part of an ordered rule resource:
+// the API calls of one policy and where its rules sit. createOrderedRule,
+// updateOrderedRule and deleteOrderedRule do the rest, so that every policy
+// places, reorders and activates its rules the same way.
+type OrderedRuleAdapter[T any] interface {
+	// ResourceType keys the rule order engine and the starting order. It
+	// includes the scope of policies ordered per rule type or parent rule.
+	ResourceType() string
+	// Ranked reports whether rules are parked at rank 7 while the engine
+	// moves them into place, for policies whose API rejects a rank that does
+	// not fit the order.
+	Ranked() bool
+	// GetAll returns the rules the engine orders, without default rules
+	// pinned outside the order sequence.
+	GetAll(ctx context.Context) ([]T, error)
+	Get(ctx context.Context, id int) (*T, error)
+	Create(ctx context.Context, rule *T) (int, error)
+	Update(ctx context.Context, id int, rule *T) error
+	Delete(ctx context.Context, id int) error
+	// Order returns the ID of a rule and where it sits. Rules without an
+	// admin rank report rank 0.
+	Order(rule *T) (int, OrderRule)
+	SetOrder(rule *T, order OrderRule)
+	// StripReadOnly clears the fields of a rule read back from the API that
+	// must not be sent when only its order changes, such as
+	// LastModifiedTime, which fails with STALE_CONFIGURATION_ERROR.
+	StripReadOnly(rule *T)
+}
+
+// orderedRuleAfterLast is implemented by adapters of policies whose new rules
+// start one past the highest order rather than at it.
+type orderedRuleAfterLast interface {
+	startAfterLastRule()
+}
+
+// orderedRuleStartingOrders holds the order new rules are created at, per
+// resource type. It is looked up once, so parallel creates do not each list
+// the policy.
+var orderedRuleStartingOrders = struct {
+	sync.Mutex
+	orders map[string]int
+}{orders: map[string]int{}}
+
+var orderedRuleRankError = regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
+
+// orderedRuleRetryInterval is how long createOrderedRule and
+// updateOrderedRule wait before they retry a rejected write or read a new
+// rule again. Tests override it.
+var orderedRuleRetryInterval = 10 * time.Second
+
+func orderedRuleStartingOrder[T any](ctx context.Context, a OrderedRuleAdapter[T]) int {
+	orderedRuleStartingOrders.Lock()
+	defer orderedRuleStartingOrders.Unlock()
+
+	key := a.ResourceType()
+	if order := orderedRuleStartingOrders.orders[key]; order != 0 {
+		return order
+	}
+	rules, _ := a.GetAll(ctx)
+	order := 0
+	for i := range rules {
+		if _, o := a.Order(&rules[i]); o.Order > order {
+			order = o.Order
+		}
+	}
+	if _, ok := a.(orderedRuleAfterLast); ok && order != 0 {
+		order++
+	}
+	if order == 0 {
+		order = 1
+	}
+	orderedRuleStartingOrders.orders[key] = order
+	return order
+}
+
+// createOrderedRule creates rule at the bottom of its policy, then has the
+// rule order engine move it to the order and rank it declares. The resource
+// ID is set as soon as the rule exists, so a failed reorder leaves it in
+// state to be fixed by the next apply.
+//
+// Within the Create timeout, an INVALID_INPUT_ARGUMENT that
+// failFastOnErrorCodes does not stop is retried, and a new rule the API
+// does not return yet is read again before it is placed.
+func createOrderedRule[T any](ctx context.Context, d *schema.ResourceData, zClient *Client, a OrderedRuleAdapter[T], rule *T, idAttribute string) diag.Diagnostics {
+	if p, ok := a.(predefinedRuleAdapter[T]); ok && adoptsPredefined(d) {
+		return adoptPredefinedRule(ctx, d, zClient, a, p, rule, idAttribute)
+	}
+
+	timeout := d.Timeout(schema.TimeoutCreate)
+	start := time.Now()
+	startingOrder := orderedRuleStartingOrder(ctx, a)
+	startWithoutLocking := time.Now()
+
+	_, intended := a.Order(rule)
+	placeholder := OrderRule{Order: startingOrder, Rank: intended.Rank}
+	if a.Ranked() && intended.Rank < 7 {
+		// always start rank 7 rules at the next available order after all ranked rules
+		placeholder.Rank = 7
+	}
+	a.SetOrder(rule, placeholder)
+
+	var id int
+	for {
+		var err error
+		id, err = a.Create(ctx, rule)
+		if err == nil {
+			break
+		}
+		if !orderedRuleWriteRetryable(err) || time.Since(start) >= timeout {
+			return diag.FromErr(orderedRuleWriteError(ctx, a, "creating", intended, err))
+		}
+		log.Printf("[INFO] Creating %s rule got INVALID_INPUT_ARGUMENT, retrying in %s: %v\n", a.ResourceType(), orderedRuleRetryInterval, err)
+		select {
+		case <-ctx.Done():
+			return diag.FromErr(ctx.Err())
+		case <-time.After(orderedRuleRetryInterval):
+		}
+	}
+	log.Printf("[INFO] Created %s rule. took:%s, without locking:%s, ID: %d\n", a.ResourceType(), time.Since(start), time.Since(startWithoutLocking), id)
+
+	d.SetId(strconv.Itoa(id))
+	_ = d.Set(idAttribute, id)
+
+	for {
+		_, err := a.Get(ctx, id)
+		if err == nil {
+			break
+		}
+		if time.Since(start) >= timeout {
+			return diag.FromErr(fmt.Errorf("error reading %s rule %d after creating it: %s", a.ResourceType(), id, err))
+		}
+		log.Printf("[INFO] Reading new %s rule %d failed, retrying in %s: %v\n", a.ResourceType(), id, orderedRuleRetryInterval, err)
+		select {
+		case <-ctx.Done():
+			return diag.FromErr(ctx.Err())
+		case <-time.After(orderedRuleRetryInterval):
+		}
+	}
+
+	return placeOrderedRule(ctx, zClient, a, id, intended)
+}
+
+// orderedRuleWriteRetryable reports whether a rejected write is worth
+// retrying: an INVALID_INPUT_ARGUMENT without the structured error code
+// failFastOnErrorCodes stops on, other than a rank that does not fit the
+// order or a missing ICAP receiver, which no retry fixes.
+func orderedRuleWriteRetryable(err error) bool {
+	msg := err.Error()
+	return failFastOnErrorCodes(err) == nil &&
+		strings.Contains(msg, "INVALID_INPUT_ARGUMENT") &&
+		!orderedRuleRankError.MatchString(msg) &&
+		!strings.Contains(msg, "ICAP Receiver with id")
+}
+
+// updateOrderedRule writes rule and has the rule order engine move it to the
+// order and rank it declares. The rule is written at the bottom of its policy
+// first: writing the declared order directly makes parallel updates fight
+// over positions, and a new rank may not fit the current order. A rule
+// deleted outside Terraform is removed from state. Within the Update timeout,
+// a retryable INVALID_INPUT_ARGUMENT is retried as on create.
+func updateOrderedRule[T any](ctx context.Context, d *schema.ResourceData, zClient *Client, a OrderedRuleAdapter[T], id int, rule *T) diag.Diagnostics {
+	if _, err := a.Get(ctx, id); err != nil {
+		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
+			log.Printf("[WARN] Removing %s rule %d from state because it no longer exists in ZIA", a.ResourceType(), id)
+			d.SetId("")
+			return nil
+		}
+	}
+	if p, ok := a.(predefinedRuleAdapter[T]); ok && adoptsPredefined(d) {
+		return updatePredefinedRule(ctx, d, zClient, a, p, id, rule)
+	}
+
+	_, intended := a.Order(rule)
+	existing, err := a.GetAll(ctx)
+	if err != nil {
+		log.Printf("[ERROR] error getting all %s rules: %v", a.ResourceType(), err)
+	}
+	// Park the rule at the last existing order; the API rejects an order
+	// beyond it, which a declared order may well be.
+	bottom := intended
+	highest := 0
+	for i := range existing {
+		if _, o := a.Order(&existing[i]); o.Order > highest {
+			highest = o.Order
+		}
+	}
+	if highest > 0 {
+		bottom.Order = highest
+	}
+	if a.Ranked() {
+		// always start rank 7 rules at the next available order after all ranked rules
+		bottom.Rank = 7
+	}
+	a.SetOrder(rule, bottom)
+
+	timeout := d.Timeout(schema.TimeoutUpdate)
+	start := time.Now()
+	for {
+		err := a.Update(ctx, id, rule)
+		if err == nil {
+			break
+		}
+		if !orderedRuleWriteRetryable(err) || time.Since(start) >= timeout {
+			return diag.FromErr(orderedRuleWriteError(ctx, a, "updating", intended, err))
+		}
+		log.Printf("[INFO] Updating %s rule %d got INVALID_INPUT_ARGUMENT, retrying in %s: %v\n", a.ResourceType(), id, orderedRuleRetryInterval, err)
+		select {
+		case <-ctx.Done():
+			return diag.FromErr(ctx.Err())
+		case <-time.After(orderedRuleRetryInterval):
+		}
+	}
+
+	return placeOrderedRule(ctx, zClient, a, id, intended)
+}
+
+// deleteOrderedRule deletes a rule and activates the change. The engine needs
+// no pass: the API closes the gap the rule leaves. An adopted predefined rule
+// is restored instead.
+func deleteOrderedRule[T any](ctx context.Context, d *schema.ResourceData, zClient *Client, a OrderedRuleAdapter[T], id int) diag.Diagnostics {
+	if _, ok := a.(predefinedRuleAdapter[T]); ok && adoptsPredefined(d) {
+		return restorePredefinedRule(ctx, d, zClient, a, id)
+	}
+
+	log.Printf("[INFO] Deleting %s rule ID: %d\n", a.ResourceType(), id)
+	if err := a.Delete(ctx, id); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("")
+	log.Printf("[INFO] %s rule %d deleted", a.ResourceType(), id)
+
+	return activateIfEnabled(ctx, zClient)
+}
+
+// placeOrderedRule registers a written rule with the rule order engine, waits
+// for its cycle and activates the change.
+func placeOrderedRule[T any](ctx context.Context, zClient *Client, a OrderedRuleAdapter[T], id int, intended OrderRule) diag.Diagnostics {
+	resourceType := a.ResourceType()
+	// The engine may run these closures in later cycles, after this Create
+	// or Update has returned and Terraform has cancelled its context.
+	engineCtx := context.WithoutCancel(ctx)
+	reorderWithBeforeReorder(intended, id, resourceType,
+		func() (map[int]OrderRule, error) {
+			rules, err := a.GetAll(engineCtx)
+			if err != nil {
+				return nil, err
+			}
+			m := make(map[int]OrderRule, len(rules))
+			for i := range rules {
+				ruleID, order := a.Order(&rules[i])
+				m[ruleID] = order
+			}
+			return m, nil
+		},
+		func(id int, order OrderRule) error {
+			ctx := journalReorderContext(engineCtx, resourceType, id)
+			rule, err := a.Get(ctx, id)
+			if err != nil {
+				return err
+			}
+			a.StripReadOnly(rule)
+			a.SetOrder(rule, order)
+			return a.Update(ctx, id, rule)
+		},
+		nil, // Remove beforeReorder function to avoid adding too many rules to the map
+	)
+
+	markOrderRuleAsDone(id, resourceType)
+	if diags := waitForReorder(resourceType, id); diags.HasError() {
+		return diags
+	}
+
+	return activateIfEnabled(ctx, zClient)
+}
+
+// orderedRuleWriteError describes a failed create or update. Errors with a
+// fail-fast code are returned as they are; otherwise a rank that does not
+// fit the order is explained with the current order and rank of every rule
+// of the policy.
+func orderedRuleWriteError[T any](ctx context.Context, a OrderedRuleAdapter[T], action string, intended OrderRule, err error) error {
+	if customErr := failFastOnErrorCodes(err); customErr != nil {
+		return customErr
+	}
+	if orderedRuleRankError.MatchString(err.Error()) {
+		return fmt.Errorf("error %s %s rule: please check the order %d vs rank %d, current rules: %s, err: %s",
+			action, a.ResourceType(), intended.Order, intended.Rank, currentOrderVsRankWording(ctx, a), err)
+	}
+	return fmt.Errorf("error %s %s rule: %s", action, a.ResourceType(), err)
+}
+
+func currentOrderVsRankWording[T any](ctx context.Context, a OrderedRuleAdapter[T]) string {
+	rules, err := a.GetAll(ctx)
+	if err != nil {
+		return ""
+	}
+	orders := make([]OrderRule, len(rules))
+	for i := range rules {
+		_, orders[i] = a.Order(&rules[i])
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].Order < orders[j].Order })
+	wording := make([]string, len(orders))
+	for i, o := range orders {
+		wording[i] = fmt.Sprintf("Rank %d VS Order %d", o.Rank, o.Order)
+	}
+	return strings.Join(wording, ", ")
+}
+
+// activateIfEnabled activates the configuration after a write when
+// ZIA_ACTIVATION is set or the provider defers activation to the end of the
+// apply.
+func activateIfEnabled(ctx context.Context, zClient *Client) diag.Diagnostics {
+	if !shouldActivate(zClient) {
+		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+		return nil
+	}
+	if err := triggerActivation(ctx, zClient); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
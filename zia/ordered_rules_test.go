@@ -0,0 +1,345 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type fakeOrderedRule struct {
+	ID    int
+	Order int
+	Rank  int
+}
+
+// fakeRuleAdapter is an in-memory OrderedRuleAdapter. It records the order and
+// rank of every write so tests can check where a rule was parked before the
+// engine moved it.
+type fakeRuleAdapter struct {
+	mu        sync.Mutex
+	key       string
+	ranked    bool
+	rules     map[int]fakeOrderedRule
+	nextID    int
+	writes    []fakeOrderedRule
+	createErr error
+	// rejects are returned by the next creates, one each, before createErr.
+	rejects []error
+	// getMisses is how many reads fail before a created rule is returned.
+	getMisses int
+	// onGetAll, when set, runs before every GetAll.
+	onGetAll func()
+}
+
+type fakeRuleAdapterAfterLast struct{ *fakeRuleAdapter }
+
+func (fakeRuleAdapterAfterLast) startAfterLastRule() {}
+
+func newFakeRuleAdapter(key string, ranked bool, existing ...fakeOrderedRule) *fakeRuleAdapter {
+	f := &fakeRuleAdapter{key: key, ranked: ranked, rules: map[int]fakeOrderedRule{}, nextID: 1000}
+	for _, r := range existing {
+		f.rules[r.ID] = r
+	}
+	return f
+}
+
+func (f *fakeRuleAdapter) ResourceType() string { return f.key }
+func (f *fakeRuleAdapter) Ranked() bool         { return f.ranked }
+
+func (f *fakeRuleAdapter) GetAll(ctx context.Context) ([]fakeOrderedRule, error) {
+	if f.onGetAll != nil {
+		f.onGetAll()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]fakeOrderedRule, 0, len(f.rules))
+	for _, r := range f.rules {
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (f *fakeRuleAdapter) Get(ctx context.Context, id int) (*fakeOrderedRule, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.rules[id]
+	if ok && f.getMisses > 0 {
+		f.getMisses--
+		ok = false
+	}
+	if !ok {
+		return nil, fmt.Errorf("rule %d not found", id)
+	}
+	return &r, nil
+}
+
+func (f *fakeRuleAdapter) Create(_ context.Context, rule *fakeOrderedRule) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.rejects) > 0 {
+		err := f.rejects[0]
+		f.rejects = f.rejects[1:]
+		return 0, err
+	}
+	if f.createErr != nil {
+		return 0, f.createErr
+	}
+	f.nextID++
+	rule.ID = f.nextID
+	f.rules[rule.ID] = *rule
+	f.writes = append(f.writes, *rule)
+	return rule.ID, nil
+}
+
+func (f *fakeRuleAdapter) Update(ctx context.Context, id int, rule *fakeOrderedRule) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rule.ID = id
+	f.rules[id] = *rule
+	f.writes = append(f.writes, *rule)
+	return nil
+}
+
+func (f *fakeRuleAdapter) Delete(_ context.Context, id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.rules, id)
+	return nil
+}
+
+func (f *fakeRuleAdapter) Order(rule *fakeOrderedRule) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (f *fakeRuleAdapter) SetOrder(rule *fakeOrderedRule, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (f *fakeRuleAdapter) StripReadOnly(*fakeOrderedRule) {}
+
+func (f *fakeRuleAdapter) rule(id int) fakeOrderedRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rules[id]
+}
+
+func setupOrderedRuleTest(t *testing.T) *schema.ResourceData {
+	t.Helper()
+	t.Setenv("ZIA_ACTIVATION", "false")
+	resetReorderState()
+	reorderSettleInterval = 50 * time.Millisecond
+	retry := orderedRuleRetryInterval
+	orderedRuleRetryInterval = time.Millisecond
+	t.Cleanup(func() {
+		reorderSettleInterval = 5 * time.Second
+		orderedRuleRetryInterval = retry
+	})
+	orderedRuleStartingOrders.Lock()
+	orderedRuleStartingOrders.orders = map[string]int{}
+	orderedRuleStartingOrders.Unlock()
+
+	return schema.TestResourceDataRaw(t, map[string]*schema.Schema{
+		"rule_id": {Type: schema.TypeInt, Computed: true},
+	}, map[string]interface{}{})
+}
+
+func TestCreateOrderedRule_ParksRankedRuleAtBottomThenPlacesIt(t *testing.T) {
+	d := setupOrderedRuleTest(t)
+	a := newFakeRuleAdapter("fake_ranked", true,
+		fakeOrderedRule{ID: 1, Order: 1, Rank: 7},
+		fakeOrderedRule{ID: 2, Order: 2, Rank: 7},
+		fakeOrderedRule{ID: 3, Order: 3, Rank: 7},
+	)
+
+	rule := fakeOrderedRule{Order: 1, Rank: 3}
+	if diags := createOrderedRule(context.Background(), d, &Client{}, OrderedRuleAdapter[fakeOrderedRule](a), &rule, "rule_id"); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if len(a.writes) == 0 {
+		t.Fatal("expected the rule to be created")
+	}
+	if created := a.writes[0]; created.Order != 3 || created.Rank != 7 {
+		t.Errorf("expected the rule to be created at order 3 rank 7, got order %d rank %d", created.Order, created.Rank)
+	}
+
+	id, _ := strconv.Atoi(d.Id())
+	if id == 0 || d.Get("rule_id").(int) != id {
+		t.Fatalf("expected the ID to be set, got %q and rule_id %v", d.Id(), d.Get("rule_id"))
+	}
+	if got := a.rule(id); got.Order != 1 || got.Rank != 3 {
+		t.Errorf("expected the rule to end at order 1 rank 3, got order %d rank %d", got.Order, got.Rank)
+	}
+}
+
+func TestCreateOrderedRule_StartsAfterLastRule(t *testing.T) {
+	d := setupOrderedRuleTest(t)
+	fake := newFakeRuleAdapter("fake_after_last", false,
+		fakeOrderedRule{ID: 1, Order: 1},
+		fakeOrderedRule{ID: 2, Order: 2},
+	)
+
+	rule := fakeOrderedRule{Order: 3}
+	if diags := createOrderedRule(context.Background(), d, &Client{}, OrderedRuleAdapter[fakeOrderedRule](fakeRuleAdapterAfterLast{fake}), &rule, "rule_id"); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if created := fake.writes[0]; created.Order != 3 {
+		t.Errorf("expected the rule to be created at order 3, got %d", created.Order)
+	}
+}
+
+func TestUpdateOrderedRule_ParksAtBottomThenPlaces(t *testing.T) {
+	d := setupOrderedRuleTest(t)
+	a := newFakeRuleAdapter("fake_update", true,
+		fakeOrderedRule{ID: 1, Order: 1, Rank: 7},
+		fakeOrderedRule{ID: 2, Order: 2, Rank: 7},
+		fakeOrderedRule{ID: 3, Order: 3, Rank: 7},
+		fakeOrderedRule{ID: 4, Order: 4, Rank: 7},
+	)
+	d.SetId("2")
+
+	rule := fakeOrderedRule{Order: 3, Rank: 7}
+	if diags := updateOrderedRule(context.Background(), d, &Client{}, OrderedRuleAdapter[fakeOrderedRule](a), 2, &rule); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if first := a.writes[0]; first.ID != 2 || first.Order != 4 || first.Rank != 7 {
+		t.Errorf("expected the rule to be written at order 4 rank 7 first, got %+v", first)
+	}
+	if got := a.rule(2); got.Order != 3 {
+		t.Errorf("expected the rule to end at order 3, got %d", got.Order)
+	}
+	if d.Id() != "2" {
+		t.Errorf("expected the ID to be kept, got %q", d.Id())
+	}
+}
+
+func TestUpdateOrderedRule_ParksAtLastOrderWhenDeclaredBeyondIt(t *testing.T) {
+	d := setupOrderedRuleTest(t)
+	a := newFakeRuleAdapter("fake_update_beyond", false,
+		fakeOrderedRule{ID: 1, Order: 1},
+		fakeOrderedRule{ID: 2, Order: 2},
+		fakeOrderedRule{ID: 3, Order: 3},
+	)
+	d.SetId("2")
+
+	// Order 10 cannot be placed in a policy of three rules, but the rule must
+	// still be parked at order 3, which the API accepts.
+	rule := fakeOrderedRule{Order: 10}
+	diags := updateOrderedRule(context.Background(), d, &Client{}, OrderedRuleAdapter[fakeOrderedRule](a), 2, &rule)
+	if first := a.writes[0]; first.ID != 2 || first.Order != 3 {
+		t.Errorf("expected the rule to be written at order 3 first, got %+v", first)
+	}
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "declared order 10 is outside 1..3") {
+		t.Errorf("expected the declared order to be reported as out of range, got %v", diags)
+	}
+}
+
+func TestCreateOrderedRule_RankErrorListsCurrentRules(t *testing.T) {
+	d := setupOrderedRuleTest(t)
+	a := newFakeRuleAdapter("fake_rank_error", true,
+		fakeOrderedRule{ID: 2, Order: 2, Rank: 7},
+		fakeOrderedRule{ID: 1, Order: 1, Rank: 0},
+	)
+	a.createErr = fmt.Errorf("Rule with rank 3 is not allowed at order 1")
+
+	rule := fakeOrderedRule{Order: 1, Rank: 3}
+	diags := createOrderedRule(context.Background(), d, &Client{}, OrderedRuleAdapter[fakeOrderedRule](a), &rule, "rule_id")
+	if !diags.HasError() {
+		t.Fatal("expected an error")
+	}
+	msg := diags[0].Summary
+	for _, want := range []string{"please check the order 1 vs rank 3", "Rank 0 VS Order 1, Rank 7 VS Order 2"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected %q in %q", want, msg)
+		}
+	}
+	if d.Id() != "" {
+		t.Errorf("expected no ID after a failed create, got %q", d.Id())
+	}
+}
+
+func TestCreateOrderedRule_RetriesWithinTimeout(t *testing.T) {
+	d := setupOrderedRuleTest(t)
+	a := newFakeRuleAdapter("fake_create_retry", true, fakeOrderedRule{ID: 1, Order: 1, Rank: 7})
+	a.rejects = []error{fmt.Errorf("INVALID_INPUT_ARGUMENT: try again"), fmt.Errorf("INVALID_INPUT_ARGUMENT: try again")}
+	a.getMisses = 2
+
+	rule := fakeOrderedRule{Order: 2, Rank: 7}
+	if diags := createOrderedRule(context.Background(), d, &Client{}, OrderedRuleAdapter[fakeOrderedRule](a), &rule, "rule_id"); diags.HasError() {
+		t.Fatalf("expected the create to be retried, got %v", diags)
+	}
+	if len(a.rules) != 2 || a.getMisses != 0 {
+		t.Errorf("expected one rule created and read until found, got %d rules and %d misses left", len(a.rules), a.getMisses)
+	}
+
+	// Fail-fast codes are not retried and are reported as they are, ahead of
+	// the rank explanation.
+	a.rejects = nil
+	a.createErr = fmt.Errorf(`{"code":"INVALID_INPUT_ARGUMENT","message":"Rule with rank 3 is not allowed at order 1"}`)
+	rule = fakeOrderedRule{Order: 1, Rank: 3}
+	diags := createOrderedRule(context.Background(), setupOrderedRuleTest(t), &Client{}, OrderedRuleAdapter[fakeOrderedRule](a), &rule, "rule_id")
+	if !diags.HasError() || strings.Contains(diags[0].Summary, "please check the order") {
+		t.Fatalf("expected the fail-fast error unchanged, got %v", diags)
+	}
+}
+
+func TestPlaceOrderedRule_LaterCycleOutlivesFirstRequest(t *testing.T) {
+	setupOrderedRuleTest(t)
+	a := newFakeRuleAdapter("fake_detached", false,
+		fakeOrderedRule{ID: 1, Order: 1},
+		fakeOrderedRule{ID: 2, Order: 2},
+		fakeOrderedRule{ID: 3, Order: 3},
+	)
+
+	// The second rule registers while the first rule's cycle is running, so
+	// the engine places it in a second cycle, after the first rule's request
+	// has returned and its context has been cancelled.
+	second := make(chan diag.Diagnostics, 1)
+	var once sync.Once
+	a.onGetAll = func() {
+		once.Do(func() {
+			go func() {
+				second <- placeOrderedRule(context.Background(), &Client{}, OrderedRuleAdapter[fakeOrderedRule](a), 3, OrderRule{Order: 2})
+			}()
+			for {
+				rules.Lock()
+				_, registered := rules.orders["fake_detached"][3]
+				rules.Unlock()
+				if registered {
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if diags := placeOrderedRule(ctx, &Client{}, OrderedRuleAdapter[fakeOrderedRule](a), 1, OrderRule{Order: 1}); diags.HasError() {
+		t.Fatalf("unexpected error placing the first rule: %v", diags)
+	}
+	cancel()
+
+	if diags := <-second; diags.HasError() {
+		t.Fatalf("expected the second cycle to run after the first request returned, got %v", diags)
+	}
+	if got := a.rule(3); got.Order != 2 {
+		t.Errorf("expected rule 3 to end at order 2, got %d", got.Order)
+	}
+}
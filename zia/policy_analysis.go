@@ -0,0 +1,349 @@
+package zia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/filetypecontrol"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewalldnscontrolpolicies"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/filteringrules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/ipdestinationgroups"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/ipsourcegroups"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/networkapplicationgroups"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/networkservicegroups"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/ips_control_policies/ips_policies"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/sslinspection"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/urlfilteringpolicies"
+)
+
+// analyzedRuleTypes maps the rule types zia_policy_analysis accepts to the
+// listing of their rules.
+var analyzedRuleTypes = map[string]func(ctx context.Context, service *zscaler.Service) (interface{}, error){
+	"firewall_filtering": func(ctx context.Context, service *zscaler.Service) (interface{}, error) {
+		return filteringrules.GetAll(ctx, service, nil)
+	},
+	"firewall_dns": func(ctx context.Context, service *zscaler.Service) (interface{}, error) {
+		return firewalldnscontrolpolicies.GetAll(ctx, service)
+	},
+	"firewall_ips": func(ctx context.Context, service *zscaler.Service) (interface{}, error) {
+		return ips_policies.GetAll(ctx, service)
+	},
+	"url_filtering": func(ctx context.Context, service *zscaler.Service) (interface{}, error) {
+		return urlfilteringpolicies.GetAll(ctx, service)
+	},
+	"ssl_inspection": func(ctx context.Context, service *zscaler.Service) (interface{}, error) {
+		return sslinspection.GetAll(ctx, service)
+	},
+	"file_type_control": func(ctx context.Context, service *zscaler.Service) (interface{}, error) {
+		return filetypecontrol.GetAll(ctx, service)
+	},
+}
+
+// analysisIgnoredFields are the JSON fields of a rule that do not decide
+// which traffic it matches: its identity, place and what it does on a match.
+// Every other field is a condition.
+var analysisIgnoredFields = map[string]bool{
+	"id": true, "name": true, "order": true, "rank": true, "description": true, "state": true,
+	"accessControl": true, "lastModifiedTime": true, "lastModifiedBy": true, "labels": true,
+	"defaultRule": true, "predefined": true, "action": true, "filteringAction": true,
+	"enableFullLogging": true, "capturePCAP": true, "isEunEnabled": true, "isWebEUNEnabled": true,
+	"eunTemplateId": true, "browserEunTemplateId": true, "redirectIp": true, "blockResponseCode": true,
+	"dnsGateway": true, "zpaIpGroup": true, "ednsEcsObject": true, "defaultDnsRuleNameUsed": true,
+	"blockOverride": true, "overrideUsers": true, "overrideGroups": true, "endUserNotificationUrl": true,
+	"timeQuota": true, "sizeQuota": true, "cbiProfile": true, "cbiProfileId": true, "ciparule": true,
+}
+
+// analysisGroupFields are the JSON fields referring to groups whose members
+// the analysis checks, keyed by the kind of group.
+var analysisGroupFields = map[string][]string{
+	"source IP group":           {"srcIpGroups", "sourceIpGroups"},
+	"destination IP group":      {"destIpGroups"},
+	"network service group":     {"nwServiceGroups"},
+	"network application group": {"nwApplicationGroups"},
+}
+
+// policyAnalysisFinding is a rule that can never match, or refers to
+// something that makes part of it dead.
+type policyAnalysisFinding struct {
+	ruleID, order   int
+	ruleName        string
+	kind            string
+	relatedRuleID   int
+	relatedRuleName string
+	detail          string
+}
+
+// analyzedRule is a rule reduced to its conditions. A condition missing from
+// conditions matches anything. A list condition matches any of its values; a
+// scalar condition, such as a negation flag, is kept as one JSON value that
+// must be equal for one rule to cover another.
+type analyzedRule struct {
+	id, order  int
+	name       string
+	disabled   bool
+	last       bool
+	conditions map[string][]string
+	scalars    map[string]string
+	fields     map[string]interface{}
+}
+
+// analysisGroups holds the members of every group of a kind, by ID.
+type analysisGroups map[string]map[int]int
+
+func analyzePolicy(ctx context.Context, service *zscaler.Service, ruleType string, now time.Time) (int, []policyAnalysisFinding, error) {
+	listRules, ok := analyzedRuleTypes[ruleType]
+	if !ok {
+		return 0, nil, fmt.Errorf("unsupported rule type %q", ruleType)
+	}
+	list, err := listRules(ctx, service)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error listing %s rules: %w", ruleType, err)
+	}
+	var raw []map[string]interface{}
+	if err := roundTripJSON(list, &raw); err != nil {
+		return 0, nil, err
+	}
+	rules := make([]analyzedRule, len(raw))
+	for i, fields := range raw {
+		rules[i] = newAnalyzedRule(fields)
+	}
+
+	groups, err := loadAnalysisGroups(ctx, service, rules)
+	if err != nil {
+		return 0, nil, err
+	}
+	return len(rules), analyzePolicyRules(rules, groups, now), nil
+}
+
+func newAnalyzedRule(fields map[string]interface{}) analyzedRule {
+	rule := analyzedRule{
+		id:         jsonInt(fields["id"]),
+		order:      jsonInt(fields["order"]),
+		name:       fmt.Sprint(fields["name"]),
+		disabled:   fields["state"] == "DISABLED",
+		last:       fields["defaultRule"] == true,
+		conditions: map[string][]string{},
+		scalars:    map[string]string{},
+		fields:     fields,
+	}
+	for key, value := range fields {
+		if analysisIgnoredFields[key] || isZeroJSON(value) {
+			continue
+		}
+		list, ok := value.([]interface{})
+		if !ok {
+			encoded, _ := json.Marshal(value)
+			rule.scalars[key] = string(encoded)
+			continue
+		}
+		values := make([]string, 0, len(list))
+		anyValue := false
+		for _, item := range list {
+			v := analysisValue(item)
+			if v == "ANY" || v == "ANY_RULE" {
+				anyValue = true
+			}
+			values = append(values, v)
+		}
+		if !anyValue {
+			sort.Strings(values)
+			rule.conditions[key] = values
+		}
+	}
+	return rule
+}
+
+// analysisValue identifies an item of a list condition: an object by its ID.
+func analysisValue(item interface{}) string {
+	if m, ok := item.(map[string]interface{}); ok {
+		if id, ok := m["id"]; ok {
+			return strconv.Itoa(jsonInt(id))
+		}
+	}
+	if s, ok := item.(string); ok {
+		return s
+	}
+	encoded, _ := json.Marshal(item)
+	return string(encoded)
+}
+
+func jsonInt(v interface{}) int {
+	if f, ok := v.(float64); ok {
+		return int(f)
+	}
+	return 0
+}
+
+// covers reports whether every transaction b matches is matched by a.
+func (a *analyzedRule) covers(b *analyzedRule) bool {
+	if !reflect.DeepEqual(a.scalars, b.scalars) {
+		return false
+	}
+	for key, values := range a.conditions {
+		narrower, ok := b.conditions[key]
+		if !ok || !subsetOf(narrower, values) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *analyzedRule) sameConditions(b *analyzedRule) bool {
+	return reflect.DeepEqual(a.scalars, b.scalars) && reflect.DeepEqual(a.conditions, b.conditions)
+}
+
+func subsetOf(values, of []string) bool {
+	set := make(map[string]bool, len(of))
+	for _, v := range of {
+		set[v] = true
+	}
+	for _, v := range values {
+		if !set[v] {
+			return false
+		}
+	}
+	return true
+}
+
+// analyzePolicyRules reports, for the enabled rules in policy order, the
+// rules covered by an earlier rule, the references to deleted or empty
+// groups, and the rules whose validity ended before now.
+func analyzePolicyRules(rules []analyzedRule, groups analysisGroups, now time.Time) []policyAnalysisFinding {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].last != rules[j].last {
+			return !rules[i].last
+		}
+		return rules[i].order < rules[j].order
+	})
+
+	groupKinds := make([]string, 0, len(analysisGroupFields))
+	for kind := range analysisGroupFields {
+		groupKinds = append(groupKinds, kind)
+	}
+	sort.Strings(groupKinds)
+
+	var findings []policyAnalysisFinding
+	var earlier []*analyzedRule
+	for i := range rules {
+		rule := &rules[i]
+		if rule.disabled {
+			continue
+		}
+		finding := func(kind, detail string) policyAnalysisFinding {
+			return policyAnalysisFinding{ruleID: rule.id, order: rule.order, ruleName: rule.name, kind: kind, detail: detail}
+		}
+
+		if !rule.last {
+			for _, prior := range earlier {
+				if !prior.covers(rule) {
+					continue
+				}
+				f := finding("shadowed", fmt.Sprintf("every transaction it matches is matched first by rule %q at order %d", prior.name, prior.order))
+				if prior.sameConditions(rule) {
+					f = finding("duplicate", fmt.Sprintf("it has the same conditions as rule %q at order %d", prior.name, prior.order))
+				}
+				f.relatedRuleID, f.relatedRuleName = prior.id, prior.name
+				findings = append(findings, f)
+				break
+			}
+		}
+
+		for _, kind := range groupKinds {
+			for _, key := range analysisGroupFields[kind] {
+				for _, v := range rule.conditions[key] {
+					id, _ := strconv.Atoi(v)
+					members, ok := groups[kind][id]
+					switch {
+					case groups[kind] == nil:
+					case !ok:
+						findings = append(findings, finding("deleted_reference", fmt.Sprintf("%s %d in %s no longer exists", kind, id, key)))
+					case members == 0:
+						findings = append(findings, finding("empty_group", fmt.Sprintf("%s %d in %s has no members", kind, id, key)))
+					}
+				}
+			}
+		}
+
+		if rule.fields["enforceTimeValidity"] == true {
+			if end := jsonInt(rule.fields["validityEndTime"]); end != 0 && time.Unix(int64(end), 0).Before(now) {
+				findings = append(findings, finding("expired", fmt.Sprintf("its validity ended on %s", time.Unix(int64(end), 0).UTC().Format(time.RFC3339))))
+			}
+		}
+
+		earlier = append(earlier, rule)
+	}
+	return findings
+}
+
+// loadAnalysisGroups lists the members of the kinds of groups the rules
+// refer to.
+func loadAnalysisGroups(ctx context.Context, service *zscaler.Service, rules []analyzedRule) (analysisGroups, error) {
+	referenced := map[string]bool{}
+	for i := range rules {
+		for kind, keys := range analysisGroupFields {
+			for _, key := range keys {
+				if len(rules[i].conditions[key]) > 0 {
+					referenced[kind] = true
+				}
+			}
+		}
+	}
+
+	groups := analysisGroups{}
+	if referenced["source IP group"] {
+		list, err := ipsourcegroups.GetAll(ctx, service)
+		if err != nil {
+			return nil, fmt.Errorf("error listing source IP groups: %w", err)
+		}
+		groups["source IP group"] = map[int]int{}
+		for _, g := range list {
+			groups["source IP group"][g.ID] = len(g.IPAddresses)
+		}
+	}
+	if referenced["destination IP group"] {
+		list, err := ipdestinationgroups.GetAll(ctx, service, "")
+		if err != nil {
+			return nil, fmt.Errorf("error listing destination IP groups: %w", err)
+		}
+		groups["destination IP group"] = map[int]int{}
+		for _, g := range list {
+			groups["destination IP group"][g.ID] = len(g.Addresses) + len(g.IPCategories) + len(g.Countries)
+		}
+	}
+	if referenced["network service group"] {
+		list, err := networkservicegroups.GetAllNetworkServiceGroups(ctx, service)
+		if err != nil {
+			return nil, fmt.Errorf("error listing network service groups: %w", err)
+		}
+		groups["network service group"] = map[int]int{}
+		for _, g := range list {
+			groups["network service group"][g.ID] = len(g.Services)
+		}
+	}
+	if referenced["network application group"] {
+		list, err := networkapplicationgroups.GetAllNetworkApplicationGroups(ctx, service)
+		if err != nil {
+			return nil, fmt.Errorf("error listing network application groups: %w", err)
+		}
+		groups["network application group"] = map[int]int{}
+		for _, g := range list {
+			groups["network application group"][g.ID] = len(g.NetworkApplications)
+		}
+	}
+	return groups, nil
+}
+
+func analyzedRuleTypeNames() []string {
+	names := make([]string, 0, len(analyzedRuleTypes))
+	for name := range analyzedRuleTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
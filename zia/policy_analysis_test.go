@@ -0,0 +1,95 @@
+package zia
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/common"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/filteringrules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/urlfilteringpolicies"
+)
+
+func analyzedRules(t *testing.T, rules interface{}) []analyzedRule {
+	t.Helper()
+	var raw []map[string]interface{}
+	if err := roundTripJSON(rules, &raw); err != nil {
+		t.Fatal(err)
+	}
+	analyzed := make([]analyzedRule, len(raw))
+	for i, fields := range raw {
+		analyzed[i] = newAnalyzedRule(fields)
+	}
+	return analyzed
+}
+
+func TestAnalyzePolicyRules_Shadowing(t *testing.T) {
+	rules := analyzedRules(t, []filteringrules.FirewallFilteringRules{
+		{ID: 9, Name: "Default", Order: -1, Action: "ALLOW", State: "ENABLED", DefaultRule: true},
+		{ID: 1, Name: "Block Finance", Order: 1, Action: "BLOCK_DROP", State: "ENABLED",
+			Departments: []common.IDNameExtensions{{ID: 10}, {ID: 11}}, DestCountries: []string{"COUNTRY_RU", "COUNTRY_KP"}},
+		{ID: 2, Name: "Block Finance RU", Order: 2, Action: "BLOCK_DROP", State: "ENABLED",
+			Departments: []common.IDNameExtensions{{ID: 10, Name: "Finance"}}, DestCountries: []string{"COUNTRY_RU"}},
+		{ID: 3, Name: "Copy", Order: 3, Action: "ALLOW", State: "ENABLED",
+			Departments: []common.IDNameExtensions{{ID: 11}, {ID: 10}}, DestCountries: []string{"COUNTRY_KP", "COUNTRY_RU"}},
+		{ID: 4, Name: "Not RU", Order: 4, Action: "ALLOW", State: "ENABLED",
+			Departments: []common.IDNameExtensions{{ID: 10}}, DestCountries: []string{"COUNTRY_RU"}, ExcludeSrcCountries: true},
+		{ID: 5, Name: "Any Protocol", Order: 5, Action: "ALLOW", State: "ENABLED", NwApplications: []string{"ANY"}},
+		{ID: 6, Name: "Off", Order: 6, Action: "ALLOW", State: "DISABLED"},
+		{ID: 7, Name: "DNS", Order: 7, Action: "ALLOW", State: "ENABLED", DestAddresses: []string{"8.8.8.8"}},
+	})
+
+	findings := analyzePolicyRules(rules, analysisGroups{}, time.Now())
+	want := []struct {
+		rule, related int
+		kind          string
+	}{
+		{2, 1, "shadowed"},
+		{3, 1, "duplicate"},
+		{7, 5, "shadowed"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), findings)
+	}
+	for i, w := range want {
+		f := findings[i]
+		if f.ruleID != w.rule || f.relatedRuleID != w.related || f.kind != w.kind {
+			t.Errorf("finding %d: expected rule %d %s by rule %d, got %+v", i, w.rule, w.kind, w.related, f)
+		}
+	}
+}
+
+func TestAnalyzePolicyRules_ReferencesAndValidity(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	rules := analyzedRules(t, []filteringrules.FirewallFilteringRules{
+		{ID: 1, Name: "Groups", Order: 1, Action: "ALLOW", State: "ENABLED",
+			SrcIpGroups: []common.IDNameExtensions{{ID: 20}, {ID: 21}}, NwServiceGroups: []common.IDNameExtensions{{ID: 30}}},
+	})
+	rules = append(rules, analyzedRules(t, []urlfilteringpolicies.URLFilteringRule{
+		{ID: 2, Name: "Expired", Order: 2, Action: "ALLOW", State: "ENABLED", Users: []common.IDNameExtensions{{ID: 100}},
+			EnforceTimeValidity: true, ValidityEndTime: int(now.Add(-time.Hour).Unix())},
+		{ID: 3, Name: "Valid", Order: 3, Action: "ALLOW", State: "ENABLED", Users: []common.IDNameExtensions{{ID: 101}},
+			EnforceTimeValidity: true, ValidityEndTime: int(now.Add(time.Hour).Unix())},
+	})...)
+	groups := analysisGroups{
+		"source IP group":       {20: 3},
+		"network service group": {30: 0},
+	}
+
+	findings := analyzePolicyRules(rules, groups, now)
+	want := []struct {
+		rule int
+		kind string
+	}{
+		{1, "empty_group"},
+		{1, "deleted_reference"},
+		{2, "expired"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), findings)
+	}
+	for i, w := range want {
+		if findings[i].ruleID != w.rule || findings[i].kind != w.kind {
+			t.Errorf("finding %d: expected rule %d %s, got %+v", i, w.rule, w.kind, findings[i])
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
+)
+
+// policySet describes an authoritative policy resource, such as
+// zia_firewall_filtering_policy, which owns the whole ordered rule list of a
+// policy. Each rule block has the schema of the rule resource of the policy
+// and is expanded and read through it. The order of a rule is its position in
+// the list, so the rule order engine is not involved.
+type policySet[T any] struct {
+	// id is the fixed ID of the policy resource.
+	id string
+	// ruleType is the rule resource of the policy, whose read cache snapshots
+	// are dropped while the policy is written.
+	ruleType string
+	rule     func() *schema.Resource
+	adapter  func(service *zscaler.Service) OrderedRuleAdapter[T]
+	expand   func(d *schema.ResourceData) T
+	name     func(rule *T) string
+}
+
+// policySetOmitted are the attributes of a rule resource that rule blocks do
+// not have: the order is the list position and predefined rules are not
+// adopted by a policy.
+var policySetOmitted = map[string]bool{"id": true, "order": true, "adopt_predefined": true, "predefined_defaults": true}
+
+func policySetResource[T any](p policySet[T]) *schema.Resource {
+	return &schema.Resource{
+		CreateContext: p.create,
+		ReadContext:   p.read,
+		UpdateContext: p.update,
+		DeleteContext: p.delete,
+		CustomizeDiff: p.customizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+		},
+		Importer: importSingleton(p.id, p.read),
+
+		Schema: map[string]*schema.Schema{
+			"rule": {
+				Type:        schema.TypeList,
+				Required:    true,
+				MinItems:    1,
+				Description: "The rules of the policy, in order. Rules are matched to the rules of the tenant by name, so renaming a rule replaces it.",
+				Elem: &schema.Resource{
+					Schema: policySetRuleSchema(p.rule().Schema),
+				},
+			},
+			"delete_unmanaged": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Delete the rules of the policy that are not in the list. Predefined and default rules are never deleted.",
+			},
+			"unmanaged_rule_ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Description: "The IDs of the rules of the policy that are not in the list, other than predefined and default rules. They are ordered after the listed rules.",
+			},
+		},
+	}
+}
+
+// policySetRuleSchema returns the schema of a rule block: the schema of the
+// rule resource without the omitted attributes. ForceNew is dropped, since a
+// changed rule must not replace the whole policy.
+func policySetRuleSchema(ruleSchema map[string]*schema.Schema) map[string]*schema.Schema {
+	block := make(map[string]*schema.Schema, len(ruleSchema))
+	for key, s := range ruleSchema {
+		if policySetOmitted[key] {
+			continue
+		}
+		block[key] = withoutForceNew(s)
+	}
+	return block
+}
+
+func withoutForceNew(s *schema.Schema) *schema.Schema {
+	copied := *s
+	copied.ForceNew = false
+	if elem, ok := s.Elem.(*schema.Resource); ok {
+		nested := make(map[string]*schema.Schema, len(elem.Schema))
+		for key, n := range elem.Schema {
+			nested[key] = withoutForceNew(n)
+		}
+		copied.Elem = &schema.Resource{Schema: nested}
+	}
+	return &copied
+}
+
+// policySetRuleData returns a ResourceData of the rule resource holding the
+// values of a rule block, for the expand and read functions of the rule.
+func policySetRuleData(r *schema.Resource, block map[string]interface{}) *schema.ResourceData {
+	sub := r.Data(nil)
+	for key, value := range block {
+		if _, ok := r.Schema[key]; ok {
+			_ = sub.Set(key, value)
+		}
+	}
+	return sub
+}
+
+func policySetRuleBlock(r *schema.Resource, sub *schema.ResourceData) map[string]interface{} {
+	block := map[string]interface{}{}
+	for key := range r.Schema {
+		if !policySetOmitted[key] {
+			block[key] = sub.Get(key)
+		}
+	}
+	return block
+}
+
+func policySetBlocks(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	blocks := make([]map[string]interface{}, 0, len(list))
+	for _, b := range list {
+		if m, ok := b.(map[string]interface{}); ok {
+			blocks = append(blocks, m)
+		}
+	}
+	return blocks
+}
+
+func isPredefinedRule[T any](a OrderedRuleAdapter[T], rule *T) bool {
+	p, ok := a.(predefinedRuleAdapter[T])
+	return ok && p.IsPredefined(rule)
+}
+
+func (p policySet[T]) customizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	blocks := policySetBlocks(d.Get("rule"))
+	if err := validatePolicySetRules(blocks, func(i int) bool {
+		return d.NewValueKnown(fmt.Sprintf("rule.%d.rank", i))
+	}); err != nil {
+		return err
+	}
+	if d.Get("delete_unmanaged").(bool) && len(d.Get("unmanaged_rule_ids").([]interface{})) > 0 {
+		return d.SetNew("unmanaged_rule_ids", []int{})
+	}
+	return nil
+}
+
+// validatePolicySetRules checks that rule names are unique, since rules are
+// matched by name, and that ranks do not decrease along the list: the API
+// rejects a rule ordered above a rule of a higher rank.
+func validatePolicySetRules(blocks []map[string]interface{}, rankKnown func(i int) bool) error {
+	names := map[string]int{}
+	rank, rankedBy := 0, ""
+	for i, block := range blocks {
+		name, _ := block["name"].(string)
+		if name != "" {
+			if prev, ok := names[name]; ok {
+				return fmt.Errorf("rules %d and %d are both named %q: rules are matched by name, so names must be unique", prev+1, i+1, name)
+			}
+			names[name] = i
+		}
+		r, ok := block["rank"].(int)
+		if !ok || !rankKnown(i) {
+			continue
+		}
+		if r < rank {
+			return fmt.Errorf("rule %q has rank %d but follows rule %q of rank %d: ranks must not decrease along the list", name, r, rankedBy, rank)
+		}
+		rank, rankedBy = r, name
+	}
+	return nil
+}
+
+func (p policySet[T]) create(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := p.apply(ctx, d, meta); diags.HasError() {
+		return diags
+	}
+	d.SetId(p.id)
+	return nil
+}
+
+func (p policySet[T]) update(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return p.apply(ctx, d, meta)
+}
+
+// apply makes the policy match the rule list. Rules dropped from the list,
+// and with delete_unmanaged the rules never in it, are deleted first so they
+// do not hold positions the list needs. Then each rule is created or updated
+// at its position from the top down: placing a rule only moves the rules
+// below it, so the rules above keep the positions already given to them.
+func (p policySet[T]) apply(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zClient := meta.(*Client)
+	a := p.adapter(zClient.Service)
+	zClient.readCache.beginWrite(p.ruleType)
+	defer zClient.readCache.endWrite(p.ruleType)
+
+	current, err := a.GetAll(ctx)
+	if err != nil {
+		return diag.Errorf("error listing %s rules: %s", a.ResourceType(), err)
+	}
+	byName := make(map[string]*T, len(current))
+	for i := range current {
+		byName[p.name(&current[i])] = &current[i]
+	}
+
+	blocks := policySetBlocks(d.Get("rule"))
+	listed := make(map[string]bool, len(blocks))
+	for _, block := range blocks {
+		name := block["name"].(string)
+		if existing, ok := byName[name]; ok && isPredefinedRule(a, existing) {
+			return diag.Errorf("%s rule %q is a predefined or default rule: remove it from the list and manage it with the rule resource and adopt_predefined", a.ResourceType(), name)
+		}
+		listed[name] = true
+	}
+
+	old, _ := d.GetChange("rule")
+	previous := map[string]map[string]interface{}{}
+	owned := map[int]bool{}
+	for _, block := range policySetBlocks(old) {
+		previous[block["name"].(string)] = block
+		owned[block["rule_id"].(int)] = true
+	}
+
+	deleteUnmanaged := d.Get("delete_unmanaged").(bool)
+	for i := range current {
+		id, _ := a.Order(&current[i])
+		if listed[p.name(&current[i])] || !(owned[id] || deleteUnmanaged) || isPredefinedRule(a, &current[i]) {
+			continue
+		}
+		log.Printf("[INFO] Deleting %s rule ID: %d\n", a.ResourceType(), id)
+		if err := a.Delete(ctx, id); err != nil {
+			return diag.Errorf("error deleting %s rule %d: %s", a.ResourceType(), id, err)
+		}
+	}
+
+	ruleRes := p.rule()
+	ids := make(map[string]int, len(blocks))
+	for i, block := range blocks {
+		name := block["name"].(string)
+		id := 0
+		if existing, ok := byName[name]; ok {
+			id, _ = a.Order(existing)
+		}
+		ids[name] = id
+		rule := p.policyRule(a, ruleRes, block, id, i+1)
+		_, position := a.Order(&rule)
+
+		if id != 0 {
+			if prior, ok := previous[name]; ok && reflect.DeepEqual(rule, p.policyRule(a, ruleRes, prior, id, i+1)) {
+				if live, err := a.Get(ctx, id); err == nil {
+					if _, o := a.Order(live); o == position {
+						continue
+					}
+				}
+			}
+			log.Printf("[INFO] Updating %s rule %q at order %d, ID: %d\n", a.ResourceType(), name, position.Order, id)
+			if err := a.Update(ctx, id, &rule); err != nil {
+				return diag.FromErr(orderedRuleWriteError(ctx, a, "updating", position, err))
+			}
+			continue
+		}
+
+		id, err := a.Create(ctx, &rule)
+		if err != nil {
+			return diag.FromErr(orderedRuleWriteError(ctx, a, "creating", position, err))
+		}
+		ids[name] = id
+		log.Printf("[INFO] Created %s rule %q at order %d, ID: %d\n", a.ResourceType(), name, position.Order, id)
+	}
+
+	if diags := activateIfEnabled(ctx, zClient); diags.HasError() {
+		return diags
+	}
+	return p.readRules(ctx, d, zClient, a, ids)
+}
+
+// policyRule expands a rule block into the rule written at order.
+func (p policySet[T]) policyRule(a OrderedRuleAdapter[T], ruleRes *schema.Resource, block map[string]interface{}, id, order int) T {
+	sub := policySetRuleData(ruleRes, block)
+	_ = sub.Set("rule_id", id)
+	rule := p.expand(sub)
+	_, o := a.Order(&rule)
+	o.Order = order
+	a.SetOrder(&rule, o)
+	return rule
+}
+
+func (p policySet[T]) read(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zClient := meta.(*Client)
+	return p.readRules(ctx, d, zClient, p.adapter(zClient.Service), nil)
+}
+
+// readRules reads the listed rules in the order of the policy, each through
+// the Read of the rule resource. A rule is identified by the rule_id of its
+// block, or by name before it has one. After apply, ids maps the name of
+// every block to the ID of its rule instead: the planned rule_id of a block
+// is the one of the block at the same position in the prior state, so it
+// names the wrong rule once rules are inserted or moved. An imported policy
+// has no list yet and takes every rule other than predefined and default
+// rules.
+func (p policySet[T]) readRules(ctx context.Context, d *schema.ResourceData, zClient *Client, a OrderedRuleAdapter[T], ids map[string]int) diag.Diagnostics {
+	rules, err := a.GetAll(ctx)
+	if err != nil {
+		return diag.Errorf("error listing %s rules: %s", a.ResourceType(), err)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		_, oi := a.Order(&rules[i])
+		_, oj := a.Order(&rules[j])
+		return oi.Order < oj.Order
+	})
+
+	blocks := policySetBlocks(d.Get("rule"))
+	byID := map[int]map[string]interface{}{}
+	byName := map[string]map[string]interface{}{}
+	for _, block := range blocks {
+		name := block["name"].(string)
+		id, _ := block["rule_id"].(int)
+		if ids != nil {
+			id = ids[name]
+		}
+		if id != 0 {
+			byID[id] = block
+		} else {
+			byName[name] = block
+		}
+	}
+	imported := len(blocks) == 0
+
+	// The rule Reads share one list of the policy, with or without the
+	// provider read cache.
+	readClient := &Client{Service: zClient.Service, readCache: newReadCache()}
+	ruleRes := p.rule()
+	list := []interface{}{}
+	unmanaged := []int{}
+	for i := range rules {
+		id, _ := a.Order(&rules[i])
+		block, ok := byID[id]
+		if !ok {
+			block, ok = byName[p.name(&rules[i])]
+		}
+		predefined := isPredefinedRule(a, &rules[i])
+		if !ok && !(imported && !predefined) {
+			if !predefined {
+				unmanaged = append(unmanaged, id)
+			}
+			continue
+		}
+
+		sub := policySetRuleData(ruleRes, block)
+		sub.SetId(strconv.Itoa(id))
+		_ = sub.Set("rule_id", id)
+		if diags := ruleRes.ReadContext(ctx, sub, readClient); diags.HasError() {
+			return diags
+		}
+		if sub.Id() == "" {
+			continue
+		}
+		list = append(list, policySetRuleBlock(ruleRes, sub))
+	}
+
+	if err := d.Set("rule", list); err != nil {
+		return diag.FromErr(err)
+	}
+	_ = d.Set("unmanaged_rule_ids", unmanaged)
+	return nil
+}
+
+// delete deletes the listed rules. Rules already gone are skipped.
+func (p policySet[T]) delete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zClient := meta.(*Client)
+	a := p.adapter(zClient.Service)
+	zClient.readCache.beginWrite(p.ruleType)
+	defer zClient.readCache.endWrite(p.ruleType)
+
+	for _, block := range policySetBlocks(d.Get("rule")) {
+		id, _ := block["rule_id"].(int)
+		if id == 0 {
+			continue
+		}
+		log.Printf("[INFO] Deleting %s rule ID: %d\n", a.ResourceType(), id)
+		if err := a.Delete(ctx, id); err != nil {
+			if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
+				continue
+			}
+			return diag.Errorf("error deleting %s rule %d: %s", a.ResourceType(), id, err)
+		}
+	}
+	d.SetId("")
+
+	return activateIfEnabled(ctx, zClient)
+}
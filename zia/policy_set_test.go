@@ -0,0 +1,328 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+)
+
+type fakePolicyRule struct {
+	ID         int
+	Name       string
+	Order      int
+	Rank       int
+	Action     string
+	Predefined bool
+}
+
+// fakePolicyAPI is an in-memory policy that orders rules like the API: a rule
+// written at an order is inserted there and the rules below it move down.
+type fakePolicyAPI struct {
+	rules  []fakePolicyRule
+	nextID int
+	writes int
+}
+
+func (f *fakePolicyAPI) ResourceType() string { return "fake_policy_rules" }
+func (f *fakePolicyAPI) Ranked() bool         { return true }
+
+func (f *fakePolicyAPI) GetAll(context.Context) ([]fakePolicyRule, error) {
+	out := make([]fakePolicyRule, len(f.rules))
+	for i, r := range f.rules {
+		r.Order = i + 1
+		out[i] = r
+	}
+	return out, nil
+}
+
+func (f *fakePolicyAPI) Get(ctx context.Context, id int) (*fakePolicyRule, error) {
+	rules, _ := f.GetAll(ctx)
+	for i := range rules {
+		if rules[i].ID == id {
+			return &rules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("rule %d not found", id)
+}
+
+func (f *fakePolicyAPI) insert(rule fakePolicyRule) {
+	at := rule.Order - 1
+	if at < 0 || at > len(f.rules) {
+		at = len(f.rules)
+	}
+	f.rules = append(f.rules[:at], append([]fakePolicyRule{rule}, f.rules[at:]...)...)
+	f.writes++
+}
+
+func (f *fakePolicyAPI) remove(id int) bool {
+	for i, r := range f.rules {
+		if r.ID == id {
+			f.rules = append(f.rules[:i], f.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakePolicyAPI) Create(_ context.Context, rule *fakePolicyRule) (int, error) {
+	f.nextID++
+	rule.ID = f.nextID
+	f.insert(*rule)
+	return rule.ID, nil
+}
+
+func (f *fakePolicyAPI) Update(_ context.Context, id int, rule *fakePolicyRule) error {
+	if !f.remove(id) {
+		return fmt.Errorf("rule %d not found", id)
+	}
+	f.insert(*rule)
+	return nil
+}
+
+func (f *fakePolicyAPI) Delete(_ context.Context, id int) error {
+	if !f.remove(id) {
+		return fmt.Errorf("rule %d not found", id)
+	}
+	return nil
+}
+
+func (f *fakePolicyAPI) Order(rule *fakePolicyRule) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (f *fakePolicyAPI) SetOrder(rule *fakePolicyRule, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (f *fakePolicyAPI) StripReadOnly(*fakePolicyRule) {}
+
+func (f *fakePolicyAPI) IsPredefined(rule *fakePolicyRule) bool { return rule.Predefined }
+
+func (f *fakePolicyAPI) GetByName(ctx context.Context, name string) (*fakePolicyRule, error) {
+	rules, _ := f.GetAll(ctx)
+	for i := range rules {
+		if rules[i].Name == name {
+			return &rules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no rule named %q", name)
+}
+
+func (f *fakePolicyAPI) names() []string {
+	names := make([]string, len(f.rules))
+	for i, r := range f.rules {
+		names[i] = r.Name
+	}
+	return names
+}
+
+func fakePolicyResource(api *fakePolicyAPI) *schema.Resource {
+	rule := func() *schema.Resource {
+		return &schema.Resource{
+			ReadContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+				r, err := api.Get(ctx, d.Get("rule_id").(int))
+				if err != nil {
+					d.SetId("")
+					return nil
+				}
+				_ = d.Set("name", r.Name)
+				_ = d.Set("order", r.Order)
+				_ = d.Set("rank", r.Rank)
+				_ = d.Set("action", r.Action)
+				return nil
+			},
+			Schema: map[string]*schema.Schema{
+				"rule_id": {Type: schema.TypeInt, Computed: true},
+				"name":    {Type: schema.TypeString, Required: true, ForceNew: true},
+				"order":   {Type: schema.TypeInt, Required: true},
+				"rank":    {Type: schema.TypeInt, Optional: true, Default: 7},
+				"action":  {Type: schema.TypeString, Optional: true},
+			},
+		}
+	}
+	return policySetResource(policySet[fakePolicyRule]{
+		id:       "fake_policy",
+		ruleType: "fake_rule",
+		rule:     rule,
+		adapter:  func(*zscaler.Service) OrderedRuleAdapter[fakePolicyRule] { return api },
+		expand: func(d *schema.ResourceData) fakePolicyRule {
+			return fakePolicyRule{
+				ID:     d.Get("rule_id").(int),
+				Name:   d.Get("name").(string),
+				Order:  d.Get("order").(int),
+				Rank:   d.Get("rank").(int),
+				Action: d.Get("action").(string),
+			}
+		},
+		name: func(rule *fakePolicyRule) string { return rule.Name },
+	})
+}
+
+func applyFakePolicy(t *testing.T, r *schema.Resource, state *terraform.InstanceState, raw map[string]interface{}) *terraform.InstanceState {
+	t.Helper()
+	ctx := context.Background()
+	var diff *terraform.InstanceDiff
+	if raw == nil {
+		diff = &terraform.InstanceDiff{Destroy: true}
+	} else {
+		var err error
+		if diff, err = r.Diff(ctx, state, terraform.NewResourceConfigRaw(raw), &Client{}); err != nil {
+			t.Fatalf("unexpected plan error: %v", err)
+		}
+	}
+	next, diags := r.Apply(ctx, state, diff, &Client{})
+	if diags.HasError() {
+		t.Fatalf("unexpected apply error: %v", diags)
+	}
+	return next
+}
+
+func policyRules(names ...string) []interface{} {
+	rules := make([]interface{}, len(names))
+	for i, name := range names {
+		rules[i] = map[string]interface{}{"name": name, "action": "ALLOW"}
+	}
+	return rules
+}
+
+func TestPolicySet_OwnsRuleList(t *testing.T) {
+	t.Setenv("ZIA_ACTIVATION", "false")
+	api := &fakePolicyAPI{nextID: 100, rules: []fakePolicyRule{
+		{ID: 1, Name: "Other", Rank: 7},
+		{ID: 2, Name: "Default", Rank: 7, Predefined: true},
+	}}
+	r := fakePolicyResource(api)
+
+	state := applyFakePolicy(t, r, nil, map[string]interface{}{"rule": policyRules("A", "B")})
+	if got, want := api.names(), []string{"A", "B", "Other", "Default"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("create: expected the listed rules on top, got %v", got)
+	}
+	if state.ID != "fake_policy" || state.Attributes["rule.#"] != "2" || state.Attributes["rule.1.rule_id"] != "102" {
+		t.Fatalf("create: unexpected state %v", state.Attributes)
+	}
+	if state.Attributes["unmanaged_rule_ids.#"] != "1" || state.Attributes["unmanaged_rule_ids.0"] != "1" {
+		t.Errorf("create: expected rule 1 to be reported unmanaged, got %v", state.Attributes)
+	}
+
+	// Moving B to the top also leaves A in place, so A is not written.
+	writes := api.writes
+	state = applyFakePolicy(t, r, state, map[string]interface{}{"rule": policyRules("B", "A"), "delete_unmanaged": true})
+	if got, want := api.names(), []string{"B", "A", "Default"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("update: expected the unmanaged rule deleted and the predefined rule kept, got %v", got)
+	}
+	if api.writes-writes != 1 {
+		t.Errorf("update: expected one write, got %d", api.writes-writes)
+	}
+	if state.Attributes["rule.0.name"] != "B" || state.Attributes["unmanaged_rule_ids.#"] != "0" {
+		t.Errorf("update: unexpected state %v", state.Attributes)
+	}
+
+	state = applyFakePolicy(t, r, state, map[string]interface{}{"rule": policyRules("B")})
+	if got, want := api.names(), []string{"B", "Default"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("update: expected the dropped rule deleted, got %v", got)
+	}
+
+	applyFakePolicy(t, r, state, nil)
+	if got, want := api.names(), []string{"Default"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delete: expected only the predefined rule left, got %v", got)
+	}
+}
+
+func TestPolicySet_InsertsRuleAtTop(t *testing.T) {
+	t.Setenv("ZIA_ACTIVATION", "false")
+	api := &fakePolicyAPI{nextID: 100}
+	r := fakePolicyResource(api)
+
+	state := applyFakePolicy(t, r, nil, map[string]interface{}{"rule": policyRules("A", "B")})
+	state = applyFakePolicy(t, r, state, map[string]interface{}{"rule": policyRules("C", "A", "B")})
+	if got, want := api.names(), []string{"C", "A", "B"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected C on top, got %v", got)
+	}
+	want := map[string]string{
+		"rule.#":      "3",
+		"rule.0.name": "C", "rule.0.rule_id": "103",
+		"rule.1.name": "A", "rule.1.rule_id": "101",
+		"rule.2.name": "B", "rule.2.rule_id": "102",
+		"unmanaged_rule_ids.#": "0",
+	}
+	for key, value := range want {
+		if state.Attributes[key] != value {
+			t.Errorf("expected %s = %s, got %q", key, value, state.Attributes[key])
+		}
+	}
+
+	// The next plan has nothing to change.
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{"rule": policyRules("C", "A", "B")}), &Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && !diff.Empty() {
+		t.Errorf("expected an empty plan, got %v", diff.Attributes)
+	}
+}
+
+func TestPolicySet_RejectsPredefinedRule(t *testing.T) {
+	t.Setenv("ZIA_ACTIVATION", "false")
+	api := &fakePolicyAPI{rules: []fakePolicyRule{{ID: 2, Name: "Default", Rank: 7, Predefined: true}}}
+	r := fakePolicyResource(api)
+
+	diff, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{"rule": policyRules("Default")}), &Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, diags := r.Apply(context.Background(), nil, diff, &Client{})
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "predefined or default rule") {
+		t.Fatalf("expected a predefined rule to be rejected, got %v", diags)
+	}
+	if api.writes != 0 {
+		t.Errorf("expected no write, got %d", api.writes)
+	}
+}
+
+func TestPolicySet_ImportTakesCustomRules(t *testing.T) {
+	api := &fakePolicyAPI{rules: []fakePolicyRule{
+		{ID: 1, Name: "A", Rank: 7, Action: "BLOCK"},
+		{ID: 2, Name: "Default", Rank: 7, Predefined: true},
+	}}
+	r := fakePolicyResource(api)
+
+	imported, err := r.Importer.StateContext(context.Background(), r.Data(nil), &Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := imported[0]
+	if d.Id() != "fake_policy" || d.Get("rule.#").(int) != 1 || d.Get("rule.0.action").(string) != "BLOCK" {
+		t.Errorf("expected only the custom rule to be imported, got %v", d.Get("rule"))
+	}
+}
+
+func TestValidatePolicySetRules(t *testing.T) {
+	known := func(int) bool { return true }
+	cases := []struct {
+		name   string
+		blocks []map[string]interface{}
+		known  func(int) bool
+		err    string
+	}{
+		{"valid", []map[string]interface{}{{"name": "A", "rank": 0}, {"name": "B", "rank": 7}}, known, ""},
+		{"duplicate name", []map[string]interface{}{{"name": "A", "rank": 7}, {"name": "A", "rank": 7}}, known, "rules 1 and 2 are both named"},
+		{"decreasing rank", []map[string]interface{}{{"name": "A", "rank": 7}, {"name": "B", "rank": 3}}, known, `rule "B" has rank 3 but follows rule "A" of rank 7`},
+		{"unknown rank", []map[string]interface{}{{"name": "A", "rank": 7}, {"name": "B", "rank": 0}}, func(i int) bool { return i == 0 }, ""},
+	}
+	for _, c := range cases {
+		err := validatePolicySetRules(c.blocks, c.known)
+		if c.err == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+		}
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%s: expected an error containing %q, got %v", c.name, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,617 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/common"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/filteringrules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/networkservicegroups"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/networkservices"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/timewindow"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/sslinspection"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/urlfilteringpolicies"
+)
+
+// The policies SimulatePolicy evaluates.
+const (
+	SimulatedFirewallFiltering = "firewall_filtering"
+	SimulatedURLFiltering      = "url_filtering"
+	SimulatedSSLInspection     = "ssl_inspection"
+)
+
+// SimulatedPolicies lists the policies SimulatePolicy evaluates.
+var SimulatedPolicies = []string{SimulatedFirewallFiltering, SimulatedURLFiltering, SimulatedSSLInspection}
+
+// PolicyTransaction is the transaction a policy simulation evaluates. Users,
+// groups, departments, locations and location groups are given by ID or name.
+// Attributes left empty match only rules that do not restrict them.
+type PolicyTransaction struct {
+	User           string   `json:"user,omitempty"`
+	Groups         []string `json:"groups,omitempty"`
+	Department     string   `json:"department,omitempty"`
+	Location       string   `json:"location,omitempty"`
+	LocationGroups []string `json:"location_groups,omitempty"`
+	SrcIP          string   `json:"src_ip,omitempty"`
+	DstIP          string   `json:"dst_ip,omitempty"`
+	DstPort        int      `json:"dst_port,omitempty"`
+	// Protocol is the network protocol of firewall filtering rules, such as
+	// TCP or UDP, or the web protocol of URL filtering rules, such as HTTPS.
+	Protocol    string `json:"protocol,omitempty"`
+	URLCategory string `json:"url_category,omitempty"`
+	CloudApp    string `json:"cloud_app,omitempty"`
+	// DayOfWeek (MON to SUN) and TimeOfDay (HH:MM) are evaluated against
+	// time windows.
+	DayOfWeek string `json:"day_of_week,omitempty"`
+	TimeOfDay string `json:"time_of_day,omitempty"`
+	// RequestMethod is checked against the request methods of URL filtering
+	// rules only when it is given.
+	RequestMethod string `json:"request_method,omitempty"`
+}
+
+// PolicySimulation is the outcome of a policy simulation: the first rule the
+// transaction matches, and a trace of every rule evaluated up to it.
+//
+// Conclusive is false when a rule before the match restricts an attribute the
+// simulator cannot evaluate offline, such as IP groups or devices, and matches
+// otherwise: the transaction may match that rule instead.
+type PolicySimulation struct {
+	Policy     string                 `json:"policy"`
+	Matched    bool                   `json:"matched"`
+	RuleID     int                    `json:"rule_id,omitempty"`
+	RuleName   string                 `json:"rule_name,omitempty"`
+	Action     string                 `json:"action,omitempty"`
+	Conclusive bool                   `json:"conclusive"`
+	Trace      []PolicySimulationStep `json:"trace"`
+}
+
+// PolicySimulationStep is one evaluated rule. Result is matched, skipped or
+// indeterminate.
+type PolicySimulationStep struct {
+	RuleID   int    `json:"rule_id"`
+	RuleName string `json:"rule_name"`
+	Order    int    `json:"order"`
+	Result   string `json:"result"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// simulatedRule is a rule of any simulated policy, reduced to what the
+// simulator evaluates.
+type simulatedRule struct {
+	id       int
+	name     string
+	order    int
+	action   string
+	disabled bool
+	// last is set for default rules, which are evaluated after all others.
+	last     bool
+	criteria []simulationCriterion
+}
+
+// simulationCriterion is one condition of a rule. check reports whether a
+// transaction meets it, and why not. A condition the simulator cannot evaluate
+// offline has no check.
+type simulationCriterion struct {
+	attribute string
+	check     func(tx *PolicyTransaction) (bool, string)
+}
+
+// policySimulationData holds what a simulation reads from the tenant besides
+// the rules: the network services and time windows rules refer to by ID.
+type policySimulationData struct {
+	services      map[int]simulatedService
+	serviceGroups map[int][]simulatedService
+	timeWindows   map[int]timewindow.TimeWindow
+}
+
+// simulatedService is a network service reduced to its protocol and
+// destination ports.
+type simulatedService struct {
+	protocol string
+	tcp, udp []networkservices.NetworkPorts
+}
+
+// SimulatePolicy evaluates tx against the rules of policy, in order, as the
+// policy would: the first enabled rule whose conditions all hold is the
+// match. Default rules are evaluated last.
+func SimulatePolicy(ctx context.Context, service *zscaler.Service, policy string, tx PolicyTransaction) (*PolicySimulation, error) {
+	rules, err := simulatedRules(ctx, service, policy)
+	if err != nil {
+		return nil, err
+	}
+	return simulatePolicy(policy, rules, &tx), nil
+}
+
+func simulatedRules(ctx context.Context, service *zscaler.Service, policy string) ([]simulatedRule, error) {
+	switch policy {
+	case SimulatedFirewallFiltering:
+		rules, err := filteringrules.GetAll(ctx, service, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error listing firewall filtering rules: %w", err)
+		}
+		data, err := loadPolicySimulationData(ctx, service, true)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]simulatedRule, len(rules))
+		for i := range rules {
+			out[i] = simulatedFirewallRule(&rules[i], data)
+		}
+		return out, nil
+	case SimulatedURLFiltering:
+		rules, err := urlfilteringpolicies.GetAll(ctx, service)
+		if err != nil {
+			return nil, fmt.Errorf("error listing url filtering rules: %w", err)
+		}
+		data, err := loadPolicySimulationData(ctx, service, false)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]simulatedRule, len(rules))
+		for i := range rules {
+			out[i] = simulatedURLFilteringRule(&rules[i], data)
+		}
+		return out, nil
+	case SimulatedSSLInspection:
+		rules, err := sslinspection.GetAll(ctx, service)
+		if err != nil {
+			return nil, fmt.Errorf("error listing ssl inspection rules: %w", err)
+		}
+		data, err := loadPolicySimulationData(ctx, service, false)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]simulatedRule, len(rules))
+		for i := range rules {
+			out[i] = simulatedSSLInspectionRule(&rules[i], data)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unsupported policy %q, expected one of %s", policy, strings.Join(SimulatedPolicies, ", "))
+}
+
+func loadPolicySimulationData(ctx context.Context, service *zscaler.Service, withServices bool) (*policySimulationData, error) {
+	data := &policySimulationData{
+		services:      map[int]simulatedService{},
+		serviceGroups: map[int][]simulatedService{},
+		timeWindows:   map[int]timewindow.TimeWindow{},
+	}
+	windows, err := timewindow.GetAll(ctx, service)
+	if err != nil {
+		return nil, fmt.Errorf("error listing time windows: %w", err)
+	}
+	for _, w := range windows {
+		data.timeWindows[w.ID] = w
+	}
+	if !withServices {
+		return data, nil
+	}
+
+	services, err := networkservices.GetAllNetworkServices(ctx, service, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing network services: %w", err)
+	}
+	for _, s := range services {
+		data.services[s.ID] = simulatedService{protocol: s.Protocol, tcp: s.DestTCPPorts, udp: s.DestUDPPorts}
+	}
+	groups, err := networkservicegroups.GetAllNetworkServiceGroups(ctx, service)
+	if err != nil {
+		return nil, fmt.Errorf("error listing network service groups: %w", err)
+	}
+	for _, g := range groups {
+		for _, s := range g.Services {
+			data.serviceGroups[g.ID] = append(data.serviceGroups[g.ID], simulatedService{tcp: s.DestTCPPorts, udp: s.DestUDPPorts})
+		}
+	}
+	return data, nil
+}
+
+// simulatePolicy evaluates the rules in policy order.
+func simulatePolicy(policy string, rules []simulatedRule, tx *PolicyTransaction) *PolicySimulation {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].last != rules[j].last {
+			return !rules[i].last
+		}
+		return rules[i].order < rules[j].order
+	})
+
+	result := &PolicySimulation{Policy: policy, Conclusive: true, Trace: []PolicySimulationStep{}}
+	for _, rule := range rules {
+		step := PolicySimulationStep{RuleID: rule.id, RuleName: rule.name, Order: rule.order}
+		matched, reason, unevaluated := evaluateSimulatedRule(&rule, tx)
+		switch {
+		case !matched:
+			step.Result, step.Reason = "skipped", reason
+		case len(unevaluated) > 0:
+			step.Result = "indeterminate"
+			step.Reason = fmt.Sprintf("all other conditions match, but %s cannot be evaluated offline", strings.Join(unevaluated, ", "))
+			result.Conclusive = false
+		default:
+			step.Result = "matched"
+		}
+		result.Trace = append(result.Trace, step)
+
+		if step.Result == "matched" {
+			result.Matched = true
+			result.RuleID, result.RuleName, result.Action = rule.id, rule.name, rule.action
+			break
+		}
+	}
+	return result
+}
+
+// evaluateSimulatedRule reports whether tx meets every condition of rule that
+// can be evaluated, or the first that it does not meet, and the conditions
+// that cannot be evaluated.
+func evaluateSimulatedRule(rule *simulatedRule, tx *PolicyTransaction) (bool, string, []string) {
+	if rule.disabled {
+		return false, "rule is disabled", nil
+	}
+	var unevaluated []string
+	for _, c := range rule.criteria {
+		if c.check == nil {
+			unevaluated = append(unevaluated, c.attribute)
+			continue
+		}
+		if ok, reason := c.check(tx); !ok {
+			return false, reason, nil
+		}
+	}
+	return true, "", unevaluated
+}
+
+func simulatedFirewallRule(r *filteringrules.FirewallFilteringRules, data *policySimulationData) simulatedRule {
+	return simulatedRule{
+		id: r.ID, name: r.Name, order: r.Order, action: r.Action, disabled: r.State == "DISABLED", last: r.DefaultRule,
+		criteria: simulationCriteria(append(simulatedIdentityCriteria(r.Locations, r.LocationsGroups, r.Users, r.Groups, r.Departments, r.TimeWindows, data),
+			ipCriterion("src_ips", r.SrcIps, func(tx *PolicyTransaction) string { return tx.SrcIP }),
+			ipCriterion("dest_addresses", r.DestAddresses, func(tx *PolicyTransaction) string { return tx.DstIP }),
+			valueCriterion("dest_ip_categories", r.DestIpCategories, func(tx *PolicyTransaction) string { return tx.URLCategory }),
+			valueCriterion("nw_applications", r.NwApplications, func(tx *PolicyTransaction) string { return tx.CloudApp }),
+			serviceCriterion(r.NwServices, r.NwServiceGroups, data),
+			unevaluatedCriterion("src_ip_groups", len(r.SrcIpGroups)),
+			unevaluatedCriterion("dest_ip_groups", len(r.DestIpGroups)),
+			unevaluatedCriterion("source_countries", len(r.SourceCountries)),
+			unevaluatedCriterion("dest_countries", len(r.DestCountries)),
+			unevaluatedCriterion("nw_application_groups", len(r.NwApplicationGroups)),
+			unevaluatedCriterion("app_services", len(r.AppServices)+len(r.AppServiceGroups)),
+			unevaluatedCriterion("devices", len(r.Devices)+len(r.DeviceGroups)+len(r.DeviceTrustLevels)),
+			unevaluatedCriterion("workload_groups", len(r.WorkloadGroups)),
+			unevaluatedCriterion("zpa_app_segments", len(r.ZPAAppSegments)),
+			unevaluatedCriterion("end_point_applications", len(r.EndPointApplications)+len(r.EndPointApplicationGroups)),
+		)),
+	}
+}
+
+func simulatedURLFilteringRule(r *urlfilteringpolicies.URLFilteringRule, data *policySimulationData) simulatedRule {
+	validity := 0
+	if r.EnforceTimeValidity {
+		validity = 1
+	}
+	categories := append(append([]string{}, r.URLCategories...), r.URLCategories2...)
+	return simulatedRule{
+		id: r.ID, name: r.Name, order: r.Order, action: r.Action, disabled: r.State == "DISABLED",
+		criteria: simulationCriteria(append(simulatedIdentityCriteria(r.Locations, r.LocationGroups, r.Users, r.Groups, r.Departments, r.TimeWindows, data),
+			valueCriterion("url_categories", categories, func(tx *PolicyTransaction) string { return tx.URLCategory }),
+			protocolCriterion(r.Protocols),
+			requestMethodCriterion(r.RequestMethods),
+			unevaluatedCriterion("source_ip_groups", len(r.SourceIPGroups)),
+			unevaluatedCriterion("source_countries", len(r.SourceCountries)),
+			unevaluatedCriterion("user_agent_types", len(r.UserAgentTypes)),
+			unevaluatedCriterion("user_risk_score_levels", len(r.UserRiskScoreLevels)),
+			unevaluatedCriterion("devices", len(r.Devices)+len(r.DeviceGroups)+len(r.DeviceTrustLevels)),
+			unevaluatedCriterion("workload_groups", len(r.WorkloadGroups)),
+			unevaluatedCriterion("enforce_time_validity", validity),
+		)),
+	}
+}
+
+func simulatedSSLInspectionRule(r *sslinspection.SSLInspectionRules, data *policySimulationData) simulatedRule {
+	return simulatedRule{
+		id: r.ID, name: r.Name, order: r.Order, action: r.Action.Type, disabled: r.State == "DISABLED", last: r.DefaultRule,
+		criteria: simulationCriteria(append(simulatedIdentityCriteria(r.Locations, r.LocationGroups, r.Users, r.Groups, r.Departments, r.TimeWindows, data),
+			valueCriterion("url_categories", r.URLCategories, func(tx *PolicyTransaction) string { return tx.URLCategory }),
+			valueCriterion("cloud_applications", r.CloudApplications, func(tx *PolicyTransaction) string { return tx.CloudApp }),
+			unevaluatedCriterion("source_ip_groups", len(r.SourceIPGroups)),
+			unevaluatedCriterion("dest_ip_groups", len(r.DestIpGroups)),
+			unevaluatedCriterion("platforms", len(r.Platforms)),
+			unevaluatedCriterion("user_agent_types", len(r.UserAgentTypes)),
+			unevaluatedCriterion("devices", len(r.Devices)+len(r.DeviceGroups)+len(r.DeviceTrustLevels)),
+			unevaluatedCriterion("proxy_gateways", len(r.ProxyGateways)),
+			unevaluatedCriterion("workload_groups", len(r.WorkloadGroups)),
+			unevaluatedCriterion("zpa_app_segments", len(r.ZPAAppSegments)),
+			unevaluatedCriterion("end_point_applications", len(r.EndPointApplications)+len(r.EndPointApplicationGroups)),
+		)),
+	}
+}
+
+// simulatedIdentityCriteria returns the conditions every simulated policy
+// shares: who and where the transaction comes from, and when.
+func simulatedIdentityCriteria(locations, locationGroups, users, groups, departments, timeWindows []common.IDNameExtensions, data *policySimulationData) []*simulationCriterion {
+	return []*simulationCriterion{
+		idNameCriterion("locations", locations, func(tx *PolicyTransaction) []string { return nonEmpty(tx.Location) }),
+		idNameCriterion("location_groups", locationGroups, func(tx *PolicyTransaction) []string { return tx.LocationGroups }),
+		idNameCriterion("users", users, func(tx *PolicyTransaction) []string { return nonEmpty(tx.User) }),
+		idNameCriterion("groups", groups, func(tx *PolicyTransaction) []string { return tx.Groups }),
+		idNameCriterion("departments", departments, func(tx *PolicyTransaction) []string { return nonEmpty(tx.Department) }),
+		timeWindowCriterion(timeWindows, data),
+	}
+}
+
+// simulationCriteria drops the conditions a rule does not set.
+func simulationCriteria(criteria []*simulationCriterion) []simulationCriterion {
+	var out []simulationCriterion
+	for _, c := range criteria {
+		if c != nil {
+			out = append(out, *c)
+		}
+	}
+	return out
+}
+
+func unevaluatedCriterion(attribute string, restricted int) *simulationCriterion {
+	if restricted == 0 {
+		return nil
+	}
+	return &simulationCriterion{attribute: attribute}
+}
+
+// idNameCriterion matches when one of the values of the transaction is the
+// ID or the name of one of the rule's objects.
+func idNameCriterion(attribute string, objects []common.IDNameExtensions, values func(tx *PolicyTransaction) []string) *simulationCriterion {
+	if len(objects) == 0 {
+		return nil
+	}
+	names := make([]string, len(objects))
+	for i, o := range objects {
+		names[i] = o.Name
+		if names[i] == "" {
+			names[i] = strconv.Itoa(o.ID)
+		}
+	}
+	return &simulationCriterion{attribute: attribute, check: func(tx *PolicyTransaction) (bool, string) {
+		given := values(tx)
+		for _, v := range given {
+			for _, o := range objects {
+				if v == strconv.Itoa(o.ID) || (o.Name != "" && strings.EqualFold(v, o.Name)) {
+					return true, ""
+				}
+			}
+		}
+		if len(given) == 0 {
+			return false, fmt.Sprintf("rule requires %s %s, the transaction has none", attribute, strings.Join(names, ", "))
+		}
+		return false, fmt.Sprintf("%s is not one of %s %s", strings.Join(given, ", "), attribute, strings.Join(names, ", "))
+	}}
+}
+
+func valueCriterion(attribute string, allowed []string, value func(tx *PolicyTransaction) string) *simulationCriterion {
+	if len(allowed) == 0 {
+		return nil
+	}
+	return &simulationCriterion{attribute: attribute, check: func(tx *PolicyTransaction) (bool, string) {
+		v := value(tx)
+		if v != "" && (containsFold(allowed, v) || containsFold(allowed, "ANY")) {
+			return true, ""
+		}
+		if v == "" {
+			return false, fmt.Sprintf("rule requires %s %s, the transaction has none", attribute, strings.Join(allowed, ", "))
+		}
+		return false, fmt.Sprintf("%q is not one of %s %s", v, attribute, strings.Join(allowed, ", "))
+	}}
+}
+
+// ipCriterion matches an IP address against addresses, CIDRs and ranges.
+// FQDN entries cannot be resolved offline, so a rule listing only FQDNs is
+// not evaluated.
+func ipCriterion(attribute string, entries []string, value func(tx *PolicyTransaction) string) *simulationCriterion {
+	if len(entries) == 0 {
+		return nil
+	}
+	var ranges [][2]net.IP
+	for _, e := range entries {
+		if lo, hi, ok := parseIPRange(e); ok {
+			ranges = append(ranges, [2]net.IP{lo, hi})
+		}
+	}
+	if len(ranges) == 0 {
+		return &simulationCriterion{attribute: attribute}
+	}
+	return &simulationCriterion{attribute: attribute, check: func(tx *PolicyTransaction) (bool, string) {
+		ip := net.ParseIP(value(tx))
+		if ip == nil {
+			return false, fmt.Sprintf("rule requires %s %s, the transaction has no valid IP", attribute, strings.Join(entries, ", "))
+		}
+		for _, r := range ranges {
+			if ipBetween(ip, r[0], r[1]) {
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("%s is not in %s %s", ip, attribute, strings.Join(entries, ", "))
+	}}
+}
+
+// parseIPRange parses an address, a CIDR or a range such as
+// 10.0.0.1-10.0.0.9 into its first and last address.
+func parseIPRange(entry string) (net.IP, net.IP, bool) {
+	entry = strings.TrimSpace(entry)
+	if lo, hi, ok := strings.Cut(entry, "-"); ok {
+		first, last := net.ParseIP(strings.TrimSpace(lo)), net.ParseIP(strings.TrimSpace(hi))
+		return first, last, first != nil && last != nil
+	}
+	if _, network, err := net.ParseCIDR(entry); err == nil {
+		last := make(net.IP, len(network.IP))
+		for i := range network.IP {
+			last[i] = network.IP[i] | ^network.Mask[i]
+		}
+		return network.IP, last, true
+	}
+	ip := net.ParseIP(entry)
+	return ip, ip, ip != nil
+}
+
+func ipBetween(ip, first, last net.IP) bool {
+	if v4 := ip.To4(); v4 != nil && first.To4() != nil {
+		ip, first, last = v4, first.To4(), last.To4()
+	} else {
+		ip, first, last = ip.To16(), first.To16(), last.To16()
+	}
+	return ip != nil && first != nil && last != nil &&
+		string(ip) >= string(first) && string(ip) <= string(last)
+}
+
+// serviceCriterion matches the destination port and protocol against the
+// network services of a firewall rule.
+func serviceCriterion(services, groups []common.IDNameExtensions, data *policySimulationData) *simulationCriterion {
+	if len(services)+len(groups) == 0 {
+		return nil
+	}
+	var candidates []simulatedService
+	var names []string
+	for _, s := range services {
+		names = append(names, s.Name)
+		if svc, ok := data.services[s.ID]; ok {
+			candidates = append(candidates, svc)
+		}
+	}
+	for _, g := range groups {
+		names = append(names, g.Name)
+		candidates = append(candidates, data.serviceGroups[g.ID]...)
+	}
+	return &simulationCriterion{attribute: "nw_services", check: func(tx *PolicyTransaction) (bool, string) {
+		if tx.DstPort == 0 && tx.Protocol == "" {
+			return false, fmt.Sprintf("rule requires nw_services %s, the transaction has no port or protocol", strings.Join(names, ", "))
+		}
+		for _, svc := range candidates {
+			if svc.matches(tx.Protocol, tx.DstPort) {
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("%s/%d is not in nw_services %s", tx.Protocol, tx.DstPort, strings.Join(names, ", "))
+	}}
+}
+
+func (s simulatedService) matches(protocol string, port int) bool {
+	if len(s.tcp)+len(s.udp) == 0 {
+		return protocol != "" && strings.EqualFold(protocol, s.protocol)
+	}
+	inPorts := func(ports []networkservices.NetworkPorts) bool {
+		for _, p := range ports {
+			end := p.End
+			if end == 0 {
+				end = p.Start
+			}
+			if port >= p.Start && port <= end {
+				return true
+			}
+		}
+		return false
+	}
+	switch strings.ToUpper(protocol) {
+	case "TCP":
+		return inPorts(s.tcp)
+	case "UDP":
+		return inPorts(s.udp)
+	case "":
+		return inPorts(s.tcp) || inPorts(s.udp)
+	}
+	return false
+}
+
+// protocolCriterion matches the web protocol against the protocols of a URL
+// filtering rule, such as HTTPS_RULE.
+func protocolCriterion(protocols []string) *simulationCriterion {
+	if len(protocols) == 0 || containsFold(protocols, "ANY_RULE") {
+		return nil
+	}
+	return &simulationCriterion{attribute: "protocols", check: func(tx *PolicyTransaction) (bool, string) {
+		for _, p := range protocols {
+			if tx.Protocol != "" && strings.EqualFold(strings.TrimSuffix(p, "_RULE"), tx.Protocol) {
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("protocol %q is not one of protocols %s", tx.Protocol, strings.Join(protocols, ", "))
+	}}
+}
+
+// requestMethodCriterion matches the request method, when the transaction
+// gives one, against the request methods of a URL filtering rule.
+func requestMethodCriterion(methods []string) *simulationCriterion {
+	if len(methods) == 0 {
+		return nil
+	}
+	return &simulationCriterion{attribute: "request_methods", check: func(tx *PolicyTransaction) (bool, string) {
+		if tx.RequestMethod == "" || containsFold(methods, tx.RequestMethod) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("request method %q is not one of request_methods %s", tx.RequestMethod, strings.Join(methods, ", "))
+	}}
+}
+
+// timeWindowCriterion matches the day and time of the transaction against
+// the time windows of a rule. A window whose end is before its start spans
+// midnight.
+func timeWindowCriterion(windows []common.IDNameExtensions, data *policySimulationData) *simulationCriterion {
+	if len(windows) == 0 {
+		return nil
+	}
+	var names []string
+	for _, w := range windows {
+		names = append(names, w.Name)
+	}
+	return &simulationCriterion{attribute: "time_windows", check: func(tx *PolicyTransaction) (bool, string) {
+		minute, ok := parseTimeOfDay(tx.TimeOfDay)
+		if !ok || tx.DayOfWeek == "" {
+			return false, fmt.Sprintf("rule requires time_windows %s, the transaction has no day_of_week and time_of_day", strings.Join(names, ", "))
+		}
+		for _, ref := range windows {
+			w, ok := data.timeWindows[ref.ID]
+			if !ok {
+				continue
+			}
+			if !containsFold(w.DayOfWeek, tx.DayOfWeek) && !containsFold(w.DayOfWeek, "EVERYDAY") {
+				continue
+			}
+			start, end := int(w.StartTime), int(w.EndTime)
+			if (start <= end && minute >= start && minute <= end) || (start > end && (minute >= start || minute <= end)) {
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("%s %s is outside time_windows %s", tx.DayOfWeek, tx.TimeOfDay, strings.Join(names, ", "))
+	}}
+}
+
+var timeOfDayPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):([0-5][0-9])$`)
+
+// parseTimeOfDay returns the minute of the day of an HH:MM time.
+func parseTimeOfDay(s string) (int, bool) {
+	m := timeOfDayPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	return hour*60 + minute, true
+}
+
+func containsFold(values []string, v string) bool {
+	for _, s := range values {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func nonEmpty(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return []string{v}
+}
@@ -0,0 +1,124 @@
+package zia
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/common"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/filteringrules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/networkservices"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/timewindow"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/urlfilteringpolicies"
+)
+
+func TestSimulatePolicy_Firewall(t *testing.T) {
+	data := &policySimulationData{
+		services: map[int]simulatedService{
+			50: {protocol: "TCP", tcp: []networkservices.NetworkPorts{{Start: 443}}},
+		},
+		serviceGroups: map[int][]simulatedService{},
+		timeWindows:   map[int]timewindow.TimeWindow{},
+	}
+	rules := []filteringrules.FirewallFilteringRules{
+		{ID: 9, Name: "Default Firewall Filtering Rule", Order: -1, Action: "ALLOW", State: "ENABLED", DefaultRule: true},
+		{ID: 1, Name: "Disabled", Order: 1, Action: "BLOCK_DROP", State: "DISABLED"},
+		{ID: 2, Name: "Alice Only", Order: 2, Action: "ALLOW", State: "ENABLED", Users: []common.IDNameExtensions{{ID: 100, Name: "alice@acme.com"}}},
+		{ID: 3, Name: "IP Groups", Order: 3, Action: "BLOCK_RESET", State: "ENABLED", SrcIpGroups: []common.IDNameExtensions{{ID: 7}}},
+		{ID: 4, Name: "HTTPS To Internal", Order: 4, Action: "ALLOW", State: "ENABLED",
+			DestAddresses: []string{"10.0.0.0/8"}, NwServices: []common.IDNameExtensions{{ID: 50, Name: "HTTPS"}}},
+	}
+	simulated := make([]simulatedRule, len(rules))
+	for i := range rules {
+		simulated[i] = simulatedFirewallRule(&rules[i], data)
+	}
+
+	result := simulatePolicy(SimulatedFirewallFiltering, simulated, &PolicyTransaction{User: "bob@acme.com", DstIP: "10.1.2.3", DstPort: 443, Protocol: "TCP"})
+	if !result.Matched || result.RuleID != 4 || result.Action != "ALLOW" {
+		t.Fatalf("expected rule 4 to match, got %+v", result)
+	}
+	if result.Conclusive {
+		t.Errorf("expected the IP groups rule above the match to make the result inconclusive")
+	}
+	want := []string{"skipped", "skipped", "indeterminate", "matched"}
+	if len(result.Trace) != len(want) {
+		t.Fatalf("expected %d steps, got %+v", len(want), result.Trace)
+	}
+	for i, step := range result.Trace {
+		if step.Result != want[i] {
+			t.Errorf("step %d (%s): expected %s, got %s (%s)", i, step.RuleName, want[i], step.Result, step.Reason)
+		}
+	}
+	if !strings.Contains(result.Trace[1].Reason, "alice@acme.com") {
+		t.Errorf("expected the skip reason to name the users of the rule, got %q", result.Trace[1].Reason)
+	}
+
+	result = simulatePolicy(SimulatedFirewallFiltering, simulated, &PolicyTransaction{User: "alice@acme.com"})
+	if result.RuleID != 2 || !result.Conclusive {
+		t.Errorf("expected rule 2 to match conclusively by user name, got %+v", result)
+	}
+
+	result = simulatePolicy(SimulatedFirewallFiltering, simulated, &PolicyTransaction{DstIP: "192.168.1.1", DstPort: 443, Protocol: "TCP"})
+	if result.RuleID != 9 {
+		t.Errorf("expected the default rule to match last, got %+v", result)
+	}
+}
+
+func TestSimulatePolicy_URLFilteringTimeWindows(t *testing.T) {
+	data := &policySimulationData{timeWindows: map[int]timewindow.TimeWindow{
+		5: {ID: 5, Name: "Work hours", StartTime: 9 * 60, EndTime: 17 * 60, DayOfWeek: []string{"MON", "TUE"}},
+		6: {ID: 6, Name: "Night", StartTime: 22 * 60, EndTime: 6 * 60, DayOfWeek: []string{"EVERYDAY"}},
+	}}
+	rules := []urlfilteringpolicies.URLFilteringRule{
+		{ID: 1, Name: "Finance At Work", Order: 1, Action: "ALLOW", State: "ENABLED", URLCategories: []string{"FINANCE"},
+			Protocols: []string{"HTTPS_RULE"}, TimeWindows: []common.IDNameExtensions{{ID: 5, Name: "Work hours"}}},
+		{ID: 2, Name: "Block At Night", Order: 2, Action: "BLOCK", State: "ENABLED", Protocols: []string{"ANY_RULE"},
+			RequestMethods: []string{"GET"}, TimeWindows: []common.IDNameExtensions{{ID: 6, Name: "Night"}}},
+	}
+	simulated := make([]simulatedRule, len(rules))
+	for i := range rules {
+		simulated[i] = simulatedURLFilteringRule(&rules[i], data)
+	}
+
+	cases := []struct {
+		tx   PolicyTransaction
+		rule int
+	}{
+		{PolicyTransaction{URLCategory: "FINANCE", Protocol: "HTTPS", DayOfWeek: "MON", TimeOfDay: "10:30"}, 1},
+		{PolicyTransaction{URLCategory: "FINANCE", Protocol: "HTTP", DayOfWeek: "MON", TimeOfDay: "10:30"}, 0},
+		{PolicyTransaction{URLCategory: "FINANCE", Protocol: "HTTPS", DayOfWeek: "WED", TimeOfDay: "23:15"}, 2},
+		{PolicyTransaction{DayOfWeek: "SUN", TimeOfDay: "05:59"}, 2},
+		{PolicyTransaction{DayOfWeek: "SUN", TimeOfDay: "05:59", RequestMethod: "POST"}, 0},
+	}
+	for i, c := range cases {
+		result := simulatePolicy(SimulatedURLFiltering, simulated, &c.tx)
+		if result.RuleID != c.rule || result.Matched != (c.rule != 0) {
+			t.Errorf("case %d: expected rule %d, got %+v", i, c.rule, result)
+		}
+	}
+}
+
+func TestParseIPRange(t *testing.T) {
+	cases := []struct {
+		entry, ip string
+		in        bool
+	}{
+		{"10.0.0.0/24", "10.0.0.255", true},
+		{"10.0.0.0/24", "10.0.1.0", false},
+		{"192.168.0.10-192.168.0.20", "192.168.0.15", true},
+		{"192.168.0.10-192.168.0.20", "192.168.0.21", false},
+		{"172.16.0.1", "172.16.0.1", true},
+	}
+	for _, c := range cases {
+		first, last, ok := parseIPRange(c.entry)
+		if !ok {
+			t.Fatalf("%s: expected to parse", c.entry)
+		}
+		if got := ipBetween(net.ParseIP(c.ip), first, last); got != c.in {
+			t.Errorf("%s in %s: expected %v, got %v", c.ip, c.entry, c.in, got)
+		}
+	}
+	if _, _, ok := parseIPRange("*.acme.com"); ok {
+		t.Errorf("expected an FQDN not to parse")
+	}
+}
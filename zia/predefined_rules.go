@@ -0,0 +1,301 @@
+package zia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// predefinedRuleAdapter is implemented by the OrderedRuleAdapter of policies
+// that ship predefined or default rules. Those rules cannot be created or
+// deleted, so with adopt_predefined a resource takes over the existing rule
+// instead.
+type predefinedRuleAdapter[T any] interface {
+	// GetByName returns the rule named name, including default rules pinned
+	// outside the order sequence.
+	GetByName(ctx context.Context, name string) (*T, error)
+	IsPredefined(rule *T) bool
+}
+
+// predefinedRuleFixedFields are the JSON fields of a predefined rule that are
+// kept as found: the API does not let them change.
+var predefinedRuleFixedFields = []string{"id", "name", "order", "rank"}
+
+// withAdoptPredefined adds adopt_predefined to an ordered rule resource whose
+// adapter implements predefinedRuleAdapter. An adopted rule keeps its name and
+// place in the policy, and only the attributes set in configuration are
+// managed: the others keep the values of the predefined rule.
+func withAdoptPredefined(r *schema.Resource) *schema.Resource {
+	for key, s := range r.Schema {
+		if s.Computed && !s.Optional {
+			continue
+		}
+		adopted := *s
+		adopted.DiffSuppressFunc = suppressUnmanagedPredefined(key, s.DiffSuppressFunc)
+		r.Schema[key] = &adopted
+	}
+	r.Schema["adopt_predefined"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Description: "Adopt the existing predefined or default rule with this name instead of creating a rule. Only the attributes set in configuration are managed; the rule keeps its name, order and rank. Destroying the resource restores the rule as it was found.",
+	}
+	r.Schema["predefined_defaults"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The adopted predefined rule as it was found, restored when the resource is destroyed.",
+	}
+	return r
+}
+
+// suppressUnmanagedPredefined hides the diff of an attribute of an adopted
+// rule that is not set in configuration, and of its order and rank, which the
+// predefined rule keeps.
+func suppressUnmanagedPredefined(key string, next schema.SchemaDiffSuppressFunc) schema.SchemaDiffSuppressFunc {
+	return func(k, old, new string, d *schema.ResourceData) bool {
+		if adoptsPredefined(d) {
+			if key == "order" || key == "rank" {
+				return true
+			}
+			config := d.GetRawConfig()
+			if !config.IsNull() && config.IsKnown() && config.Type().HasAttribute(key) && predefinedRuleAttrUnset(config.GetAttr(key)) {
+				return true
+			}
+		}
+		return next != nil && next(k, old, new, d)
+	}
+}
+
+func adoptsPredefined(d interface{ Get(string) interface{} }) bool {
+	adopt, _ := d.Get("adopt_predefined").(bool)
+	return adopt
+}
+
+// adoptPredefinedRule takes over the predefined rule named like the resource.
+// The rule is recorded as found in predefined_defaults, then rule is written
+// over it, keeping the fields of the predefined rule that rule leaves unset.
+func adoptPredefinedRule[T any](ctx context.Context, d *schema.ResourceData, zClient *Client, a OrderedRuleAdapter[T], p predefinedRuleAdapter[T], rule *T, idAttribute string) diag.Diagnostics {
+	name := d.Get("name").(string)
+	existing, err := p.GetByName(ctx, name)
+	if err != nil {
+		return diag.Errorf("error finding predefined %s rule %q: %s", a.ResourceType(), name, err)
+	}
+	if !p.IsPredefined(existing) {
+		return diag.Errorf("%s rule %q is not a predefined or default rule; remove adopt_predefined to manage it as a regular rule", a.ResourceType(), name)
+	}
+	id, _ := a.Order(existing)
+
+	found, err := json.Marshal(existing)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	merged, err := mergePredefinedRule(existing, rule, predefinedRuleConfiguredFields(d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	a.StripReadOnly(merged)
+
+	if err := a.Update(ctx, id, merged); err != nil {
+		if customErr := failFastOnErrorCodes(err); customErr != nil {
+			return diag.FromErr(customErr)
+		}
+		return diag.Errorf("error adopting predefined %s rule %q: %s", a.ResourceType(), name, err)
+	}
+	log.Printf("[INFO] Adopted predefined %s rule %q, ID: %d\n", a.ResourceType(), name, id)
+
+	d.SetId(strconv.Itoa(id))
+	_ = d.Set(idAttribute, id)
+	_ = d.Set("predefined_defaults", string(found))
+
+	return activateIfEnabled(ctx, zClient)
+}
+
+// updatePredefinedRule writes rule over an adopted rule. Attributes absent
+// from configuration plan to the values read from the API, so rule already
+// carries them; only the fixed fields are taken from the live rule. A rule
+// imported and then switched to adopt_predefined is recorded as found here.
+func updatePredefinedRule[T any](ctx context.Context, d *schema.ResourceData, zClient *Client, a OrderedRuleAdapter[T], p predefinedRuleAdapter[T], id int, rule *T) diag.Diagnostics {
+	current, err := a.Get(ctx, id)
+	if err != nil {
+		return diag.Errorf("error reading predefined %s rule %d: %s", a.ResourceType(), id, err)
+	}
+	if !p.IsPredefined(current) {
+		return diag.Errorf("%s rule %d is not a predefined or default rule; remove adopt_predefined to manage it as a regular rule", a.ResourceType(), id)
+	}
+	if d.Get("predefined_defaults").(string) == "" {
+		found, err := json.Marshal(current)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		_ = d.Set("predefined_defaults", string(found))
+	}
+	merged, err := mergePredefinedRule(current, rule, nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	a.StripReadOnly(merged)
+
+	if err := a.Update(ctx, id, merged); err != nil {
+		if customErr := failFastOnErrorCodes(err); customErr != nil {
+			return diag.FromErr(customErr)
+		}
+		return diag.Errorf("error updating predefined %s rule %d: %s", a.ResourceType(), id, err)
+	}
+
+	return activateIfEnabled(ctx, zClient)
+}
+
+// restorePredefinedRule writes an adopted rule back as it was found and
+// removes it from state. A rule with nothing recorded is left as it is.
+func restorePredefinedRule[T any](ctx context.Context, d *schema.ResourceData, zClient *Client, a OrderedRuleAdapter[T], id int) diag.Diagnostics {
+	found := d.Get("predefined_defaults").(string)
+	if found == "" {
+		log.Printf("[WARN] No recorded defaults for predefined %s rule %d, leaving it as it is", a.ResourceType(), id)
+		d.SetId("")
+		return nil
+	}
+
+	var rule T
+	if err := json.Unmarshal([]byte(found), &rule); err != nil {
+		return diag.Errorf("error decoding the recorded defaults of predefined %s rule %d: %s", a.ResourceType(), id, err)
+	}
+	a.StripReadOnly(&rule)
+
+	log.Printf("[INFO] Restoring predefined %s rule ID: %d\n", a.ResourceType(), id)
+	if err := a.Update(ctx, id, &rule); err != nil {
+		return diag.Errorf("error restoring predefined %s rule %d: %s", a.ResourceType(), id, err)
+	}
+	d.SetId("")
+
+	return activateIfEnabled(ctx, zClient)
+}
+
+// mergePredefinedRule returns desired with the fixed fields of existing. With
+// a non-nil configured, the fields of existing that configuration does not
+// set and desired leaves at their zero value are kept too, so a value set
+// explicitly to false, 0 or "" still replaces the predefined one.
+func mergePredefinedRule[T any](existing, desired *T, configured map[string]bool) (*T, error) {
+	var base, over map[string]interface{}
+	if err := roundTripJSON(existing, &base); err != nil {
+		return nil, err
+	}
+	if err := roundTripJSON(desired, &over); err != nil {
+		return nil, err
+	}
+
+	merged := over
+	if configured != nil {
+		merged = make(map[string]interface{}, len(base))
+		for key, value := range base {
+			if configured[key] {
+				// Left out of desired when it is empty and tagged omitempty.
+				continue
+			}
+			merged[key] = value
+		}
+		for key, value := range over {
+			if configured[key] || !isZeroJSON(value) {
+				merged[key] = value
+			}
+		}
+	}
+	for _, key := range predefinedRuleFixedFields {
+		if value, ok := base[key]; ok {
+			merged[key] = value
+		} else {
+			delete(merged, key)
+		}
+	}
+
+	var rule T
+	if err := roundTripJSON(merged, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// predefinedRuleConfiguredFields returns the JSON fields of the attributes
+// set in the configuration of d. Attributes map to fields by camel casing
+// their name, as enable_full_logging maps to enableFullLogging, unless
+// predefinedRuleJSONFields names the field.
+func predefinedRuleConfiguredFields(d *schema.ResourceData) map[string]bool {
+	configured := map[string]bool{}
+	raw := d.GetRawConfig()
+	if raw.IsNull() || !raw.IsKnown() || !raw.Type().IsObjectType() {
+		return configured
+	}
+	for key := range raw.Type().AttributeTypes() {
+		if !predefinedRuleAttrUnset(raw.GetAttr(key)) {
+			configured[predefinedRuleJSONField(key)] = true
+		}
+	}
+	return configured
+}
+
+// predefinedRuleAttrUnset reports whether an attribute of the raw
+// configuration is left unset. Nested blocks that are not written arrive as
+// empty lists or sets of objects rather than null.
+func predefinedRuleAttrUnset(val cty.Value) bool {
+	if val.IsNull() {
+		return true
+	}
+	typ := val.Type()
+	if val.IsKnown() && (typ.IsListType() || typ.IsSetType()) && typ.ElementType().IsObjectType() {
+		return val.LengthInt() == 0
+	}
+	return false
+}
+
+// predefinedRuleJSONFields are the schema attributes of rule resources whose
+// JSON field in the SDK rule structs is not the camel cased attribute name.
+var predefinedRuleJSONFields = map[string]string{
+	"capture_pcap":       "capturePCAP",
+	"group_ids":          "groups",
+	"is_web_eun_enabled": "isWebEUNEnabled",
+	"location_ids":       "locations",
+}
+
+// predefinedRuleJSONField returns the JSON field of the SDK rule structs
+// that a schema attribute is expanded into.
+func predefinedRuleJSONField(key string) string {
+	if field, ok := predefinedRuleJSONFields[key]; ok {
+		return field
+	}
+	parts := strings.Split(key, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func roundTripJSON(in, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding rule: %w", err)
+	}
+	return nil
+}
+
+func isZeroJSON(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
@@ -0,0 +1,222 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+type fakePredefinedRule struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Order       int    `json:"order"`
+	Rank        int    `json:"rank"`
+	Action      string `json:"action,omitempty"`
+	Description string `json:"description,omitempty"`
+	Predefined  bool   `json:"predefined"`
+}
+
+// fakePredefinedAdapter is an in-memory policy holding predefined rules.
+type fakePredefinedAdapter struct {
+	rules   map[int]fakePredefinedRule
+	updates int
+}
+
+func (f *fakePredefinedAdapter) ResourceType() string { return "fake_predefined" }
+func (f *fakePredefinedAdapter) Ranked() bool         { return true }
+
+func (f *fakePredefinedAdapter) GetAll(context.Context) ([]fakePredefinedRule, error) {
+	var out []fakePredefinedRule
+	for _, r := range f.rules {
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (f *fakePredefinedAdapter) Get(_ context.Context, id int) (*fakePredefinedRule, error) {
+	r, ok := f.rules[id]
+	if !ok {
+		return nil, fmt.Errorf("rule %d not found", id)
+	}
+	return &r, nil
+}
+
+func (f *fakePredefinedAdapter) GetByName(_ context.Context, name string) (*fakePredefinedRule, error) {
+	for _, r := range f.rules {
+		if r.Name == name {
+			return &r, nil
+		}
+	}
+	return nil, fmt.Errorf("no rule named %q", name)
+}
+
+func (f *fakePredefinedAdapter) IsPredefined(rule *fakePredefinedRule) bool { return rule.Predefined }
+
+func (f *fakePredefinedAdapter) Create(context.Context, *fakePredefinedRule) (int, error) {
+	return 0, fmt.Errorf("predefined rules cannot be created")
+}
+
+func (f *fakePredefinedAdapter) Update(_ context.Context, id int, rule *fakePredefinedRule) error {
+	f.updates++
+	f.rules[id] = *rule
+	return nil
+}
+
+func (f *fakePredefinedAdapter) Delete(context.Context, int) error {
+	return fmt.Errorf("predefined rules cannot be deleted")
+}
+
+func (f *fakePredefinedAdapter) Order(rule *fakePredefinedRule) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (f *fakePredefinedAdapter) SetOrder(rule *fakePredefinedRule, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (f *fakePredefinedAdapter) StripReadOnly(rule *fakePredefinedRule) { rule.Predefined = false }
+
+func predefinedRuleTestData(t *testing.T, raw map[string]interface{}) *schema.ResourceData {
+	t.Helper()
+	t.Setenv("ZIA_ACTIVATION", "false")
+	r := withAdoptPredefined(&schema.Resource{Schema: map[string]*schema.Schema{
+		"rule_id":     {Type: schema.TypeInt, Computed: true},
+		"name":        {Type: schema.TypeString, Required: true},
+		"order":       {Type: schema.TypeInt, Required: true},
+		"description": {Type: schema.TypeString, Optional: true},
+	}})
+	return schema.TestResourceDataRaw(t, r.Schema, raw)
+}
+
+func TestMergePredefinedRule(t *testing.T) {
+	existing := &fakePredefinedRule{ID: 7, Name: "Default Rule", Order: 99, Rank: 7, Action: "ALLOW", Description: "shipped", Predefined: true}
+	desired := &fakePredefinedRule{Name: "Renamed", Order: 1, Action: "BLOCK"}
+
+	adopted, err := mergePredefinedRule(existing, desired, map[string]bool{"name": true, "order": true, "action": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fakePredefinedRule{ID: 7, Name: "Default Rule", Order: 99, Rank: 7, Action: "BLOCK", Description: "shipped", Predefined: true}
+	if *adopted != want {
+		t.Errorf("keeping unset fields: expected %+v, got %+v", want, *adopted)
+	}
+
+	updated, err := mergePredefinedRule(existing, desired, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = fakePredefinedRule{ID: 7, Name: "Default Rule", Order: 99, Rank: 7, Action: "BLOCK"}
+	if *updated != want {
+		t.Errorf("replacing unset fields: expected %+v, got %+v", want, *updated)
+	}
+
+	// An empty value set in configuration replaces the predefined one.
+	cleared, err := mergePredefinedRule(existing, desired, map[string]bool{"name": true, "action": true, "description": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = fakePredefinedRule{ID: 7, Name: "Default Rule", Order: 99, Rank: 7, Action: "BLOCK", Predefined: true}
+	if *cleared != want {
+		t.Errorf("clearing a configured field: expected %+v, got %+v", want, *cleared)
+	}
+}
+
+func TestPredefinedRuleConfiguredFields(t *testing.T) {
+	r := withAdoptPredefined(&schema.Resource{Schema: map[string]*schema.Schema{
+		"rule_id":      {Type: schema.TypeInt, Computed: true},
+		"name":         {Type: schema.TypeString, Required: true},
+		"order":        {Type: schema.TypeInt, Required: true},
+		"description":  {Type: schema.TypeString, Optional: true},
+		"location_ids": {Type: schema.TypeSet, Optional: true, Elem: &schema.Schema{Type: schema.TypeInt}},
+		"departments": {Type: schema.TypeSet, Optional: true, Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+			"id": {Type: schema.TypeSet, Optional: true, Elem: &schema.Schema{Type: schema.TypeInt}},
+		}}},
+	}})
+	// TestResourceDataRaw leaves the raw configuration null; Terraform sends it
+	// with every attribute, null when unset, and every block, empty when unset.
+	types := r.CoreConfigSchema().ImpliedType().AttributeTypes()
+	attrs := map[string]cty.Value{}
+	for key, typ := range types {
+		attrs[key] = cty.NullVal(typ)
+	}
+	attrs["name"] = cty.StringVal("Default Rule")
+	attrs["order"] = cty.NumberIntVal(1)
+	attrs["description"] = cty.StringVal("")
+	attrs["adopt_predefined"] = cty.True
+	attrs["location_ids"] = cty.SetVal([]cty.Value{cty.NumberIntVal(42)})
+	attrs["departments"] = cty.SetValEmpty(types["departments"].ElementType())
+	d := r.Data(&terraform.InstanceState{
+		Attributes: map[string]string{"adopt_predefined": "true"},
+		RawConfig:  cty.ObjectVal(attrs),
+	})
+
+	got := predefinedRuleConfiguredFields(d)
+	for _, field := range []string{"name", "order", "description", "adoptPredefined", "locations"} {
+		if !got[field] {
+			t.Errorf("expected %s to be configured, got %v", field, got)
+		}
+	}
+	for _, field := range []string{"ruleId", "departments"} {
+		if got[field] {
+			t.Errorf("expected %s to be unset, got %v", field, got)
+		}
+	}
+	if !r.Schema["departments"].DiffSuppressFunc("departments.#", "1", "0", d) {
+		t.Error("expected the diff of the unwritten departments block to be suppressed")
+	}
+	if field := predefinedRuleJSONField("enable_full_logging"); field != "enableFullLogging" {
+		t.Errorf("expected enableFullLogging, got %s", field)
+	}
+}
+
+func TestAdoptPredefinedRule_AdoptsAndRestores(t *testing.T) {
+	a := &fakePredefinedAdapter{rules: map[int]fakePredefinedRule{
+		7: {ID: 7, Name: "Default Rule", Order: 99, Rank: 7, Action: "ALLOW", Description: "shipped", Predefined: true},
+	}}
+	d := predefinedRuleTestData(t, map[string]interface{}{"name": "Default Rule", "order": 1, "adopt_predefined": true})
+
+	rule := fakePredefinedRule{Name: "Default Rule", Order: 1, Action: "BLOCK"}
+	if diags := createOrderedRule(context.Background(), d, &Client{}, OrderedRuleAdapter[fakePredefinedRule](a), &rule, "rule_id"); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "7" || d.Get("rule_id").(int) != 7 {
+		t.Fatalf("expected the predefined rule to be adopted, got ID %q", d.Id())
+	}
+	if got := a.rules[7]; got.Action != "BLOCK" || got.Description != "shipped" || got.Order != 99 {
+		t.Errorf("expected only the configured action to change, got %+v", got)
+	}
+	if !strings.Contains(d.Get("predefined_defaults").(string), `"action":"ALLOW"`) {
+		t.Errorf("expected the rule to be recorded as found, got %s", d.Get("predefined_defaults"))
+	}
+
+	if diags := deleteOrderedRule(context.Background(), d, &Client{}, OrderedRuleAdapter[fakePredefinedRule](a), 7); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("expected the rule to be removed from state, got ID %q", d.Id())
+	}
+	if got := a.rules[7]; got.Action != "ALLOW" || got.Name != "Default Rule" {
+		t.Errorf("expected the rule to be restored, got %+v", got)
+	}
+}
+
+func TestAdoptPredefinedRule_RejectsCustomRule(t *testing.T) {
+	a := &fakePredefinedAdapter{rules: map[int]fakePredefinedRule{
+		8: {ID: 8, Name: "Custom Rule", Order: 1, Rank: 7},
+	}}
+	d := predefinedRuleTestData(t, map[string]interface{}{"name": "Custom Rule", "order": 1, "adopt_predefined": true})
+
+	rule := fakePredefinedRule{Name: "Custom Rule", Order: 1}
+	diags := createOrderedRule(context.Background(), d, &Client{}, OrderedRuleAdapter[fakePredefinedRule](a), &rule, "rule_id")
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "not a predefined or default rule") {
+		t.Fatalf("expected a custom rule to be rejected, got %v", diags)
+	}
+	if a.updates != 0 {
+		t.Errorf("expected no write, got %d", a.updates)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -101,6 +102,183 @@ func ZIAProvider() *schema.Provider {
 					"call will fail with an explanatory error. Can also be sourced from the " +
 					"ZSCALER_SKIP_CREDENTIALS_VALIDATION environment variable.",
 			},
+			"activation": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Controls when the provider activates configuration changes.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"mode": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  activationModePerResource,
+							ValidateFunc: validation.StringInSlice([]string{
+								activationModePerResource,
+								activationModeEndOfApply,
+							}, false),
+							Description: "`per_resource` (default) activates after each resource write when the ZIA_ACTIVATION " +
+								"environment variable is set. `end_of_apply` records pending changes and activates exactly once " +
+								"after the last ZIA write of the run. Can also be sourced from the ZSCALER_ACTIVATION_MODE environment variable.",
+						},
+						"quiet_period_seconds": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Default:          defaultActivationQuietPeriod,
+							ValidateDiagFunc: intBetween(1, 300),
+							Description:      "In `end_of_apply` mode, how long the provider waits without any ZIA write in flight before it considers the apply finished and activates.",
+						},
+					},
+				},
+			},
+			"read_cache": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Description: "Serve resource reads from one list call per policy type instead of one call per object. " +
+					"The snapshot of a type is taken on first use, dropped whenever this provider writes an object of the type, " +
+					"and discarded at the end of the run, so changes made outside Terraform during a run may not be seen until the next one. " +
+					"Can also be sourced from the ZSCALER_READ_CACHE environment variable.",
+			},
+			"tenant_lock": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Serializes the writes of concurrent Terraform runs against one tenant with an advisory lock, taken before the first write and released after activation.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"backend": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice([]string{tenantLockBackendFile, tenantLockBackendHTTP}, false),
+							Description:  "`file` keeps the lock in a directory shared by the runs; `http` keeps it in a lease service. Can also be sourced from the ZSCALER_TENANT_LOCK_BACKEND environment variable.",
+						},
+						"path": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Directory of the file backend. Can also be sourced from the ZSCALER_TENANT_LOCK_PATH environment variable.",
+						},
+						"url": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Base URL of the lease service of the http backend. Can also be sourced from the ZSCALER_TENANT_LOCK_URL environment variable.",
+						},
+						"token": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Sensitive:   true,
+							Description: "Bearer token sent to the lease service. Can also be sourced from the ZSCALER_TENANT_LOCK_TOKEN environment variable.",
+						},
+						"key": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Lock key. Defaults to the vanity domain and cloud of the tenant. Can also be sourced from the ZSCALER_TENANT_LOCK_KEY environment variable.",
+						},
+						"timeout_seconds": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							ValidateDiagFunc: intBetween(1, 86400),
+							Description:      "How long a run waits for the lock before failing. Defaults to 3600. Can also be sourced from the ZSCALER_TENANT_LOCK_TIMEOUT_SECONDS environment variable.",
+						},
+						"lease_seconds": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							ValidateDiagFunc: intBetween(10, 3600),
+							Description:      "Lifetime of the lease, renewed while the run holds the lock. A run that dies without releasing the lock blocks others for at most this long. Defaults to 120. Can also be sourced from the ZSCALER_TENANT_LOCK_LEASE_SECONDS environment variable.",
+						},
+					},
+				},
+			},
+			"edit_lock": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "What a write does when an admin holds the ZIA edit lock (EDIT_LOCK_NOT_AVAILABLE), for instance while editing in the admin console.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"policy": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice([]string{editLockPolicyFail, editLockPolicyWait}, false),
+							Description:  "`fail` gives up after the SDK's short retries; `wait` retries with jittered exponential backoff for up to max_wait_seconds. Defaults to `fail`. Can also be sourced from the ZSCALER_EDIT_LOCK_POLICY environment variable.",
+						},
+						"max_wait_seconds": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							ValidateDiagFunc: intBetween(1, 86400),
+							Description:      "How long a write waits for the edit lock under the `wait` policy. Defaults to 900. Can also be sourced from the ZSCALER_EDIT_LOCK_MAX_WAIT_SECONDS environment variable.",
+						},
+					},
+				},
+			},
+			"journal_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Appends a JSON line for every POST, PUT and DELETE the provider issues to this file. Can also be sourced from the ZSCALER_JOURNAL_PATH environment variable.",
+			},
+			"client_tuning": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Tunes the response cache, rate limits and connections of the SDK clients. Unset settings keep the SDK defaults.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"cache_enabled": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+							Description: "Cache GET responses in the SDK. Disable it when changes made outside Terraform must be seen immediately. " +
+								"Can also be sourced from the ZSCALER_CACHE_ENABLED environment variable when the block is omitted.",
+						},
+						"cache_ttl_seconds": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							ValidateDiagFunc: intBetween(1, 86400),
+							Description:      "How long a cached response is served. Defaults to 600. Can also be sourced from the ZSCALER_CACHE_TTL_SECONDS environment variable.",
+						},
+						"cache_tti_seconds": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							ValidateDiagFunc: intBetween(1, 86400),
+							Description:      "How long an unused cached response is kept. Defaults to 480. Can also be sourced from the ZSCALER_CACHE_TTI_SECONDS environment variable.",
+						},
+						"cache_max_size_mb": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							ValidateDiagFunc: intBetween(1, 4096),
+							Description:      "Maximum size of the response cache in MB. Can also be sourced from the ZSCALER_CACHE_MAX_SIZE_MB environment variable.",
+						},
+						"get_rate_limit": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							ValidateDiagFunc: intBetween(1, 1000),
+							Description:      "Maximum GET requests per rate limit window. Can also be sourced from the ZSCALER_GET_RATE_LIMIT environment variable.",
+						},
+						"write_rate_limit": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							ValidateDiagFunc: intBetween(1, 1000),
+							Description:      "Maximum POST, PUT and DELETE requests per rate limit window. Can also be sourced from the ZSCALER_WRITE_RATE_LIMIT environment variable.",
+						},
+						"rate_limit_window_seconds": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							ValidateDiagFunc: intBetween(1, 3600),
+							Description:      "Length of the rate limit window. Defaults to 10. Can also be sourced from the ZSCALER_RATE_LIMIT_WINDOW_SECONDS environment variable.",
+						},
+						"max_idle_conns_per_host": {
+							Type:             schema.TypeInt,
+							Optional:         true,
+							ValidateDiagFunc: intBetween(1, 1000),
+							Description:      "Size of the idle connection pool per host. Can also be sourced from the ZSCALER_MAX_IDLE_CONNS_PER_HOST environment variable.",
+						},
+						"ca_bundle": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Path to a PEM file of CA certificates trusted in addition to the system roots, e.g. for a TLS inspecting proxy. Can also be sourced from the ZSCALER_CA_BUNDLE environment variable.",
+						},
+					},
+				},
+			},
 			"http_proxy": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -148,6 +326,7 @@ func ZIAProvider() *schema.Provider {
 			"zia_outbound_email_dlp":                            resourceOutboundEmailDLP(),
 			"zia_dlp_global_options":                            resourceDLPGlobalOptions(),
 			"zia_firewall_filtering_rule":                       resourceFirewallFilteringRules(),
+			"zia_firewall_filtering_policy":                     resourceFirewallFilteringPolicy(),
 			"zia_firewall_ips_rule":                             resourceFirewallIPSRules(),
 			"zia_firewall_dns_rule":                             resourceFirewallDNSRules(),
 			"zia_cloud_app_control_rule":                        resourceCloudAppControlRules(),
@@ -170,13 +349,14 @@ func ZIAProvider() *schema.Provider {
 			"zia_forwarding_control_zpa_gateway":                resourceForwardingControlZPAGateway(),
 			"zia_location_management":                           resourceLocationManagement(),
 			"zia_url_categories":                                resourceURLCategories(),
+			"zia_url_category_urls":                             resourceURLCategoryURLs(),
 			"zia_url_categories_predefined":                     resourceURLCategoriesPredefined(),
 			"zia_url_filtering_rules":                           resourceURLFilteringRules(),
+			"zia_url_filtering_policy":                          resourceURLFilteringPolicy(),
 			"zia_file_type_control_rules":                       resourceFileTypeControlRules(),
 			"zia_custom_file_types":                             resourceCustomFileTypes(),
 			"zia_user_management":                               resourceUserManagement(),
 			"zia_activation_status":                             resourceActivationStatus(),
-			"zia_rule_labels":                                   resourceRuleLabels(),
 			"zia_pac_files":                                     resourcePacFiles(),
 			"zia_auth_settings_urls":                            resourceAuthSettingsUrls(),
 			"zia_security_settings":                             resourceSecurityPolicySettings(),
@@ -185,6 +365,7 @@ func ZIAProvider() *schema.Provider {
 			"zia_sandbox_file_submission":                       resourceSandboxSubmission(),
 			"zia_sandbox_rules":                                 resourceSandboxRules(),
 			"zia_ssl_inspection_rules":                          resourceSSLInspectionRules(),
+			"zia_ssl_inspection_policy":                         resourceSSLInspectionPolicy(),
 			"zia_advanced_threat_settings":                      resourceAdvancedThreatSettings(),
 			"zia_atp_malicious_urls":                            resourceATPMaliciousUrls(),
 			"zia_atp_security_exceptions":                       resourceATPSecurityExceptions(),
@@ -298,6 +479,8 @@ func ZIAProvider() *schema.Provider {
 			"zia_sandbox_report":                                dataSourceSandboxReport(),
 			"zia_sandbox_rules":                                 dataSourceSandboxRules(),
 			"zia_ssl_inspection_rules":                          dataSourceSSLInspectionRules(),
+			"zia_policy_simulation":                             dataSourcePolicySimulation(),
+			"zia_policy_analysis":                               dataSourcePolicyAnalysis(),
 			"zia_forwarding_control_zpa_gateway":                dataSourceForwardingControlZPAGateway(),
 			"zia_forwarding_control_proxy_gateway":              dataSourceForwardingControlProxyGateway(),
 			"zia_cloud_browser_isolation_profile":               dataSourceCBIProfile(),
@@ -314,6 +497,8 @@ func ZIAProvider() *schema.Provider {
 			"zia_end_user_notification":                         dataSourceEndUserNotification(),
 			"zia_cloud_nss_feed":                                dataSourceCloudNSSFeed(),
 			"zia_nss_server":                                    dataSourceNSSServer(),
+			"zia_nss_feed_format_preview":                       dataSourceNSSFeedFormatPreview(),
+			"zia_nss_feed_preset":                               dataSourceNSSFeedPreset(),
 			"zia_subscription_alert":                            dataSourceSubscriptionAlerts(),
 			"zia_forwarding_control_proxies":                    dataSourceForwardingControlProxies(),
 			"zia_dedicated_ip_proxy":                            dataSourceDedicatedIPProxy(),
@@ -340,8 +525,13 @@ func ZIAProvider() *schema.Provider {
 	// Guard every resource and data source against the inert client returned
 	// when skip_credentials_validation is enabled, so an accidental API call
 	// yields a descriptive error instead of a nil-pointer panic.
-	for _, r := range p.ResourcesMap {
+	for name, r := range p.ResourcesMap {
 		guardResourceAgainstInertClient(r)
+		trackWritesForDeferredActivation(r)
+		trackWritesForReadCache(r, name)
+		trackWritesForEditLock(r)
+		trackWritesForJournal(r, name)
+		trackWritesForTenantLock(r)
 	}
 	for _, ds := range p.DataSourcesMap {
 		guardResourceAgainstInertClient(ds)
@@ -354,7 +544,7 @@ func ZIAProvider() *schema.Provider {
 			// We can therefore assume that if it's missing it's 0.10 or 0.11
 			terraformVersion = "0.11+compatible"
 		}
-		r, diags := providerConfigure(d, terraformVersion)
+		r, diags := providerConfigure(p, d, terraformVersion)
 		if diags.HasError() {
 			return nil, diag.Diagnostics{
 				diag.Diagnostic{
@@ -373,7 +563,7 @@ func ZIAProvider() *schema.Provider {
 	return p
 }
 
-func providerConfigure(d *schema.ResourceData, terraformVersion string) (interface{}, diag.Diagnostics) {
+func providerConfigure(p *schema.Provider, d configValues, terraformVersion string) (interface{}, diag.Diagnostics) {
 	log.Printf("[INFO] Initializing Zscaler client")
 
 	// Create configuration from schema
@@ -398,6 +588,26 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 		}
 	}
 
+	// The SDKv2 and plugin framework halves of the provider are configured
+	// one after the other with the same configuration. Hand both the same
+	// client so the API session, rate limiting and deferred activation state
+	// are shared.
+	key := config.clientKey()
+	configuredClients.Lock()
+	defer configuredClients.Unlock()
+	if client, ok := configuredClients.m[p]; ok {
+		if client.configKey == key {
+			// A cached client outlives the plan its rule orders were declared in.
+			client.ruleOrders.reset()
+			client.readCache.reset()
+			return client, nil
+		}
+		// The provider was configured again with other settings; the
+		// previous client is done.
+		retireClient(client)
+		delete(configuredClients.m, p)
+	}
+
 	// Load the correct SDK client (prioritizing V3)
 	if diags := config.loadClients(); diags.HasError() {
 		return nil, diags
@@ -408,10 +618,32 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 	if err != nil {
 		return nil, diag.Errorf("failed to configure Zscaler client: %v", err)
 	}
+	client.configKey = key
+	configuredClients.m[p] = client
 
 	return client, nil
 }
 
+// configuredClients holds the client built by providerConfigure for each
+// provider instance, so each instance keeps only the client of its latest
+// configuration.
+var configuredClients = struct {
+	m map[*schema.Provider]*Client
+	sync.Mutex
+}{m: map[*schema.Provider]*Client{}}
+
+// retireClient activates the pending changes of a client that is no longer
+// used and releases its tenant lock.
+func retireClient(client *Client) {
+	if client.activation != nil {
+		if err := client.activation.finish(context.Background()); err != nil {
+			log.Printf("[ERROR] Activating the pending changes of the previous configuration failed; they must be activated manually: %v", err)
+		}
+	}
+	client.readCache.logStats()
+	client.tenantLock.release(context.Background())
+}
+
 // inertClientDiag is the error returned when a resource or data source is
 // evaluated while the provider is in skip_credentials_validation mode.
 func inertClientDiag() diag.Diagnostics {
@@ -466,6 +698,88 @@ func guardResourceAgainstInertClient(r *schema.Resource) {
 	}
 }
 
+// trackWritesForDeferredActivation brackets a resource's Create, Update and
+// Delete with the client's deferred activation bookkeeping. It is a no-op
+// unless the provider was configured with activation mode "end_of_apply".
+func trackWritesForDeferredActivation(r *schema.Resource) {
+	wrap := func(f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			client, ok := meta.(*Client)
+			if !ok || client.activation == nil {
+				return f(ctx, d, meta)
+			}
+			client.activation.beginWrite()
+			diags := f(ctx, d, meta)
+			if err := client.activation.endWrite(ctx); err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "deferred configuration activation failed",
+					Detail:   fmt.Sprintf("The changes made during this apply were saved but not activated: %v", err),
+				})
+			}
+			return diags
+		}
+	}
+
+	r.CreateContext = wrap(r.CreateContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+}
+
+// trackWritesForReadCache brackets a resource's Create, Update and Delete
+// with the client's read cache bookkeeping, so the snapshots of resourceType
+// are dropped and bypassed while the write is in flight. It is a no-op
+// unless the provider was configured with read_cache.
+func trackWritesForReadCache(r *schema.Resource, resourceType string) {
+	wrap := func(f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			client, ok := meta.(*Client)
+			if !ok || client.readCache == nil {
+				return f(ctx, d, meta)
+			}
+			client.readCache.beginWrite(resourceType)
+			defer client.readCache.endWrite(resourceType)
+			return f(ctx, d, meta)
+		}
+	}
+
+	r.CreateContext = wrap(r.CreateContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+}
+
+// trackWritesForTenantLock makes a resource's Create, Update and Delete wait
+// for the client's tenant lock. It is the outermost write wrapper, so the
+// lock is held before any bookkeeping of the write starts. It is a no-op
+// unless the provider was configured with tenant_lock.
+func trackWritesForTenantLock(r *schema.Resource) {
+	wrap := func(f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+		if f == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			client, ok := meta.(*Client)
+			if !ok || client.tenantLock == nil {
+				return f(ctx, d, meta)
+			}
+			if err := client.tenantLock.acquire(ctx); err != nil {
+				return diag.FromErr(err)
+			}
+			return f(ctx, d, meta)
+		}
+	}
+
+	r.CreateContext = wrap(r.CreateContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+}
+
 func resourceFuncNoOp(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
 	return nil
 }
@@ -0,0 +1,138 @@
+package zia
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/terraform-provider-zia/v4/zia/common/testing/fakezia"
+)
+
+// configureFakeZIA points a provider at a fresh fakezia server and returns
+// the configured client.
+func configureFakeZIA(t *testing.T) (*fakezia.Server, *Client) {
+	t.Helper()
+	clearAuthEnv(t)
+	server := fakezia.NewServer()
+	t.Cleanup(server.Close)
+	t.Setenv("ZSCALER_USE_LEGACY_CLIENT", "true")
+	t.Setenv("ZIA_USERNAME", "admin@fakezia.test")
+	t.Setenv("ZIA_PASSWORD", "fakezia")
+	t.Setenv("ZIA_API_KEY", "0123456789abcdef")
+	t.Setenv("ZIA_CLOUD", "zscalertest")
+	useFakeAPIBaseURL(t, server.URL)
+
+	d := schema.TestResourceDataRaw(t, ZIAProvider().Schema, map[string]interface{}{})
+	meta, diags := providerConfigure(ZIAProvider(), d, "1.0-test")
+	if diags.HasError() {
+		t.Fatalf("configuring the provider against fakezia: %v", diags)
+	}
+	return server, meta.(*Client)
+}
+
+// useFakeAPIBaseURL points the legacy client at url for the rest of the test.
+func useFakeAPIBaseURL(t *testing.T, url string) {
+	t.Helper()
+	previous := fakeAPIBaseURL
+	fakeAPIBaseURL = url
+	t.Cleanup(func() { fakeAPIBaseURL = previous })
+}
+
+func TestFakeZIA_DestinationGroupLifecycle(t *testing.T) {
+	server, client := configureFakeZIA(t)
+	ctx := context.Background()
+	r := resourceFWIPDestinationGroups()
+
+	t.Setenv("ZIA_ACTIVATION", "true")
+
+	// The first write is rate limited; the SDK must honour Retry-After.
+	server.InjectFault(http.MethodPost, "ipDestinationGroups", 1, fakezia.RateLimited(1))
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":        "tf-fake-dst",
+		"description": "created against fakezia",
+		"type":        "DSTN_FQDN",
+		"addresses":   []interface{}{"example.com"},
+	})
+	if diags := r.CreateContext(ctx, d, client); diags.HasError() {
+		t.Fatalf("create: %v", diags)
+	}
+	if _, err := strconv.Atoi(d.Id()); err != nil {
+		t.Fatalf("expected a numeric id after create, got %q", d.Id())
+	}
+	if server.Activations() != 1 || server.ActivationStatus() != fakezia.ActivationActive {
+		t.Fatalf("expected create to activate once, got %d activations", server.Activations())
+	}
+
+	objs := server.Objects("ipDestinationGroups")
+	if len(objs) != 1 || objs[0]["name"] != "tf-fake-dst" {
+		t.Fatalf("expected the group on the server, got %v", objs)
+	}
+	if posts := countRequests(server, http.MethodPost, "ipDestinationGroups"); posts != 2 {
+		t.Fatalf("expected the rate limited POST to be retried once, got %d POSTs", posts)
+	}
+
+	if err := d.Set("description", "updated against fakezia"); err != nil {
+		t.Fatal(err)
+	}
+	if diags := r.UpdateContext(ctx, d, client); diags.HasError() {
+		t.Fatalf("update: %v", diags)
+	}
+	if got := server.Objects("ipDestinationGroups")[0]["description"]; got != "updated against fakezia" {
+		t.Fatalf("expected the updated description, got %v", got)
+	}
+
+	if diags := r.DeleteContext(ctx, d, client); diags.HasError() {
+		t.Fatalf("delete: %v", diags)
+	}
+	if objs := server.Objects("ipDestinationGroups"); len(objs) != 0 {
+		t.Fatalf("expected the group to be deleted, got %v", objs)
+	}
+}
+
+func countRequests(server *fakezia.Server, method, path string) int {
+	n := 0
+	for _, r := range server.Requests() {
+		if r.Method == method && r.Path == path {
+			n++
+		}
+	}
+	return n
+}
+
+func TestProviderConfigure_ReconfigureReplacesClient(t *testing.T) {
+	configureFakeZIA(t)
+	p := ZIAProvider()
+	configure := func(raw map[string]interface{}) *Client {
+		meta, diags := providerConfigure(p, schema.TestResourceDataRaw(t, p.Schema, raw), "1.0-test")
+		if diags.HasError() {
+			t.Fatalf("configuring the provider: %v", diags)
+		}
+		return meta.(*Client)
+	}
+
+	first := configure(map[string]interface{}{})
+	if again := configure(map[string]interface{}{}); again != first {
+		t.Error("expected the same configuration to reuse the client")
+	}
+	second := configure(map[string]interface{}{"max_retries": 3})
+	if second == first {
+		t.Fatal("expected new settings to build a new client")
+	}
+	configuredClients.Lock()
+	kept := configuredClients.m[p]
+	configuredClients.Unlock()
+	if kept != second {
+		t.Error("expected the provider instance to keep only its latest client")
+	}
+}
+
+func TestNewConfig_IgnoresBaseURLFromEnvironment(t *testing.T) {
+	t.Setenv("ZIA_TESTING_BASE_URL", "https://attacker.example")
+	config := NewConfig(schema.TestResourceDataRaw(t, ZIAProvider().Schema, map[string]interface{}{}))
+	if config.testingBaseURL != "" {
+		t.Errorf("expected the base URL to be ignored outside tests, got %q", config.testingBaseURL)
+	}
+}
@@ -30,7 +30,7 @@ func TestProviderConfigureSkipCredentialsValidation(t *testing.T) {
 		"skip_credentials_validation": true,
 	})
 
-	meta, diags := providerConfigure(d, "1.0-test")
+	meta, diags := providerConfigure(ZIAProvider(), d, "1.0-test")
 	if diags.HasError() {
 		t.Fatalf("expected no error in skip mode, got: %v", diags)
 	}
@@ -63,7 +63,7 @@ func TestProviderConfigureSkipCredentialsValidationEnvVar(t *testing.T) {
 
 	d := schema.TestResourceDataRaw(t, ZIAProvider().Schema, map[string]interface{}{})
 
-	meta, diags := providerConfigure(d, "1.0-test")
+	meta, diags := providerConfigure(ZIAProvider(), d, "1.0-test")
 	if diags.HasError() {
 		t.Fatalf("expected no error in env-var skip mode, got: %v", diags)
 	}
@@ -78,7 +78,7 @@ func TestProviderConfigureMissingCredentialsStillErrors(t *testing.T) {
 
 	d := schema.TestResourceDataRaw(t, ZIAProvider().Schema, map[string]interface{}{})
 
-	_, diags := providerConfigure(d, "1.0-test")
+	_, diags := providerConfigure(ZIAProvider(), d, "1.0-test")
 	if !diags.HasError() {
 		t.Fatal("expected configure to fail without credentials when skip mode is off")
 	}
@@ -6,30 +6,35 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/zscaler/terraform-provider-zia/v4/zia/common/resourcetype"
+	"github.com/zscaler/terraform-provider-zia/v4/zia/common/testing/fakezia"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 )
 
 var (
-	testSdkV3Client          *zscaler.Client
-	testAccProvider          *schema.Provider
-	testAccProviders         map[string]*schema.Provider
-	testAccProviderFactories map[string]func() (*schema.Provider, error)
+	testSdkV3Client                 *zscaler.Client
+	testAccProvider                 *schema.Provider
+	testAccProtoV5ProviderFactories map[string]func() (tfprotov5.ProviderServer, error)
 )
 
 func init() {
 	testAccProvider = ZIAProvider()
-	testAccProviders = map[string]*schema.Provider{
-		"zia": testAccProvider,
-	}
 
-	testAccProviderFactories = map[string]func() (*schema.Provider, error){
-		"zia": func() (*schema.Provider, error) {
-			return testAccProvider, nil
+	// Serve the muxed provider around testAccProvider so destroy checks can
+	// keep using testAccProvider.Meta().
+	testAccProtoV5ProviderFactories = map[string]func() (tfprotov5.ProviderServer, error){
+		"zia": func() (tfprotov5.ProviderServer, error) {
+			factory, err := newMuxServerFactory(context.Background(), testAccProvider)
+			if err != nil {
+				return nil, err
+			}
+			return factory(), nil
 		},
 	}
 }
@@ -41,6 +46,15 @@ func TestMain(m *testing.M) {
 	// see examples/okta_resource_set/basic.tf
 	os.Setenv("TF_VAR_hostname", fmt.Sprintf("%s.%s.%s", os.Getenv("ZSCALER_CLIENT_ID"), os.Getenv("ZSCALER_CLIENT_SECRET"), os.Getenv("ZSCALER_CLOUD")))
 
+	// ZIA_FAKE_API=true runs the acceptance tests against an in-process
+	// fakezia server instead of a live tenant.
+	if strings.ToLower(os.Getenv("ZIA_FAKE_API")) == "true" {
+		server := startFakeZIA()
+		code := m.Run()
+		server.Close()
+		os.Exit(code)
+	}
+
 	// NOTE: Acceptance test sweepers are necessary to prevent dangling
 	// resources.
 	// NOTE: Don't run sweepers if we are playing back VCR as nothing should be
@@ -92,6 +106,65 @@ func TestProvider_impl(t *testing.T) {
 	_ = ZIAProvider()
 }
 
+func TestProvider_MuxServer(t *testing.T) {
+	ctx := context.Background()
+	factory, err := ProviderServerFactory(ctx)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The mux server rejects provider schemas that differ between the SDKv2
+	// and plugin framework halves.
+	resp, err := factory().GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, d := range resp.Diagnostics {
+		t.Errorf("unexpected diagnostic: %s: %s", d.Summary, d.Detail)
+	}
+
+	if _, ok := resp.ResourceSchemas["zia_rule_labels"]; !ok {
+		t.Error("zia_rule_labels is not served by the muxed provider")
+	}
+	if _, ok := resp.ResourceSchemas["zia_firewall_filtering_rule"]; !ok {
+		t.Error("SDKv2 resources are not served by the muxed provider")
+	}
+	for _, name := range []string{"zia_vpn_credential_psk", "zia_oneapi_token"} {
+		if _, ok := resp.EphemeralResourceSchemas[name]; !ok {
+			t.Errorf("ephemeral resource %s is not served by the muxed provider", name)
+		}
+	}
+
+	for _, name := range []string{"country_code", "exclusion_time", "normalize_url", "time_zone"} {
+		if _, ok := resp.Functions[name]; !ok {
+			t.Errorf("function provider::zia::%s is not served by the muxed provider", name)
+		}
+	}
+
+	// Write-only arguments must reach Terraform as such.
+	writeOnly := map[string]string{
+		"zia_traffic_forwarding_vpn_credentials": "pre_shared_key_wo",
+		"zia_admin_users":                        "password_wo",
+		"zia_user_management":                    "password_wo",
+	}
+	for resourceType, attrName := range writeOnly {
+		s, ok := resp.ResourceSchemas[resourceType]
+		if !ok {
+			t.Errorf("%s is not served by the muxed provider", resourceType)
+			continue
+		}
+		found := false
+		for _, attr := range s.Block.Attributes {
+			if attr.Name == attrName {
+				found = attr.WriteOnly
+			}
+		}
+		if !found {
+			t.Errorf("%s.%s is not a write-only attribute", resourceType, attrName)
+		}
+	}
+}
+
 func testAccPreCheck(t *testing.T) func() {
 	return func() {
 		err := accPreCheck()
@@ -103,6 +176,15 @@ func testAccPreCheck(t *testing.T) func() {
 
 // accPreCheck checks if the necessary environment variables for acceptance tests are set.
 func accPreCheck() error {
+	if strings.ToLower(os.Getenv("ZSCALER_USE_LEGACY_CLIENT")) == "true" {
+		for _, key := range []string{"ZIA_USERNAME", "ZIA_PASSWORD", "ZIA_API_KEY", "ZIA_CLOUD"} {
+			if os.Getenv(key) == "" {
+				return fmt.Errorf("%s must be set for acceptance tests with the legacy client", key)
+			}
+		}
+		return nil
+	}
+
 	// Check for mandatory environment variables for client_id + client_secret authentication
 	if v := os.Getenv("ZSCALER_CLIENT_ID"); v == "" {
 		return errors.New("ZSCALER_CLIENT_ID must be set for acceptance tests")
@@ -246,3 +328,21 @@ func sdkV3ClientForTest() (*zscaler.Client, error) {
 	testSdkV3Client = client
 	return testSdkV3Client, nil
 }
+
+// startFakeZIA starts a fakezia server and points the legacy client at it,
+// so every acceptance test in the package runs offline.
+func startFakeZIA() *fakezia.Server {
+	server := fakezia.NewServer()
+	for key, val := range map[string]string{
+		"ZSCALER_USE_LEGACY_CLIENT": "true",
+		"ZIA_USERNAME":              "admin@fakezia.test",
+		"ZIA_PASSWORD":              "fakezia",
+		"ZIA_API_KEY":               "0123456789abcdef",
+		"ZIA_CLOUD":                 "zscalertest",
+	} {
+		os.Setenv(key, val)
+	}
+	fakeAPIBaseURL = server.URL
+	log.Printf("[INFO] Running acceptance tests against fakezia at %s", server.URL)
+	return server
+}
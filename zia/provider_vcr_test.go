@@ -0,0 +1,168 @@
+package zia
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/terraform-provider-zia/v4/zia/common/testing/cassette"
+	"github.com/zscaler/terraform-provider-zia/v4/zia/common/testing/method"
+)
+
+// vcrRecorder is the cassette recorder of the running test, if any.
+var (
+	vcrRecorder    atomic.Pointer[cassette.Recorder]
+	vcrInstallOnce sync.Once
+)
+
+// vcrPlayEnv are the credentials a replayed test configures the provider
+// with when the environment does not provide any. They never leave the
+// process: every request is served from the cassette.
+var vcrPlayEnv = map[string]string{
+	"ZIA_USERNAME":          "admin@vcr.test",
+	"ZIA_PASSWORD":          "vcr",
+	"ZIA_API_KEY":           "0123456789abcdef",
+	"ZIA_CLOUD":             "zscalertest",
+	"ZSCALER_CLIENT_ID":     "vcr",
+	"ZSCALER_CLIENT_SECRET": "vcr",
+	"ZSCALER_VANITY_DOMAIN": "vcr",
+}
+
+// testAccVCR records the API traffic of the test to
+// test-fixtures/cassettes/<test name>.json when ZIA_VCR_TF_ACC=record, and
+// replays it from there when ZIA_VCR_TF_ACC=play. Tests without a cassette
+// are skipped in play mode. Call it first in the test, before any resource
+// names are generated, so replay hands out the recorded names.
+func testAccVCR(t *testing.T) {
+	t.Helper()
+	mode, err := cassette.ModeFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode == cassette.ModeOff {
+		return
+	}
+
+	path := filepath.Join("test-fixtures", "cassettes", t.Name()+".json")
+	if _, err := os.Stat(path); mode == cassette.ModePlay && os.IsNotExist(err) {
+		t.Skipf("no cassette recorded at %s", path)
+	}
+	rec, err := cassette.Start(path, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mode == cassette.ModeRecord {
+		rec.SetEnv("ZSCALER_USE_LEGACY_CLIENT", os.Getenv("ZSCALER_USE_LEGACY_CLIENT"))
+	} else {
+		legacy, _ := rec.Env("ZSCALER_USE_LEGACY_CLIENT")
+		t.Setenv("ZSCALER_USE_LEGACY_CLIENT", legacy)
+		useFakeAPIBaseURL(t, "")
+		for key, val := range vcrPlayEnv {
+			if os.Getenv(key) == "" {
+				t.Setenv(key, val)
+			}
+		}
+	}
+
+	vcrInstallOnce.Do(func() {
+		wrapHTTPTransport = func(inner http.RoundTripper) http.RoundTripper {
+			return &cassette.Transport{Inner: inner, Active: vcrRecorder.Load}
+		}
+	})
+	// Every test authenticates with a client of its own, so each cassette
+	// is self-contained and replays in any order.
+	resetConfiguredClients()
+	vcrRecorder.Store(rec)
+	method.SetNameSource(rec)
+
+	t.Cleanup(func() {
+		method.SetNameSource(nil)
+		vcrRecorder.Store(nil)
+		resetConfiguredClients()
+		if err := rec.Stop(); err != nil {
+			t.Errorf("writing cassette %s: %v", path, err)
+		}
+	})
+}
+
+func resetConfiguredClients() {
+	configuredClients.Lock()
+	defer configuredClients.Unlock()
+	configuredClients.m = map[*schema.Provider]*Client{}
+}
+
+func TestVCR_ReplaysRecordedTraffic(t *testing.T) {
+	vcrInstallOnce.Do(func() {
+		wrapHTTPTransport = func(inner http.RoundTripper) http.RoundTripper {
+			return &cassette.Transport{Inner: inner, Active: vcrRecorder.Load}
+		}
+	})
+	t.Cleanup(func() { vcrRecorder.Store(nil) })
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	ctx := context.Background()
+	r := resourceFWIPDestinationGroups()
+
+	// Record a create against the fake API.
+	rec, err := cassette.Start(path, cassette.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vcrRecorder.Store(rec)
+	server, client := configureFakeZIA(t)
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":        "tf-vcr-dst",
+		"description": "recorded",
+		"type":        "DSTN_FQDN",
+		"addresses":   []interface{}{"example.com", "example.net"},
+	})
+	if diags := r.CreateContext(ctx, d, client); diags.HasError() {
+		t.Fatalf("create: %v", diags)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"fakezia-1", `"password":"fakezia"`, "0123456789abcdef"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains the unscrubbed secret %q", secret)
+		}
+	}
+
+	// Replay a read with the server gone.
+	rec, err = cassette.Start(path, cassette.ModePlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vcrRecorder.Store(rec)
+	resetConfiguredClients()
+	meta, diags := providerConfigure(ZIAProvider(), schema.TestResourceDataRaw(t, ZIAProvider().Schema, map[string]interface{}{}), "1.0-test")
+	if diags.HasError() {
+		t.Fatalf("configuring the provider in replay: %v", diags)
+	}
+	replayed := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{})
+	replayed.SetId(d.Id())
+	if err := replayed.Set("group_id", d.Get("group_id")); err != nil {
+		t.Fatal(err)
+	}
+	if diags := r.ReadContext(ctx, replayed, meta); diags.HasError() {
+		t.Fatalf("read: %v", diags)
+	}
+	if replayed.Get("name") != "tf-vcr-dst" || replayed.Get("description") != "recorded" {
+		t.Fatalf("unexpected replayed state: %v", replayed.State())
+	}
+	if got := replayed.Get("addresses").(*schema.Set).Len(); got != 2 {
+		t.Fatalf("expected 2 addresses, got %d", got)
+	}
+}
@@ -0,0 +1,233 @@
+package zia
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// readCache serves the Reads of one configured provider from a single GetAll
+// snapshot per policy type, so a refresh costs one list call per type instead
+// of one Get per rule. It is enabled with the provider's read_cache argument.
+//
+// Snapshots live for one run: the cache is emptied whenever the provider is
+// configured again. A write through any resource of a type drops that type's
+// snapshots, and Reads of the type bypass the cache until the write (and the
+// ordering cycle it waits for) has finished, so the Read that ends a Create
+// or Update always sees the API.
+type readCache struct {
+	mu sync.Mutex
+	// snapshots are keyed by kind: a resource type, optionally followed by
+	// "/" and a scope such as a rule type.
+	snapshots map[string]*readSnapshot
+	// writing counts the writes in flight per resource type.
+	writing map[string]int
+	stats   map[string]*readCacheStats
+}
+
+type readSnapshot struct {
+	ready chan struct{}
+	items interface{}
+	err   error
+}
+
+// readCacheStats counts what happened to the Reads of one kind.
+type readCacheStats struct {
+	lists, hits, misses, bypasses, invalidations int
+}
+
+func newReadCache() *readCache {
+	return &readCache{
+		snapshots: map[string]*readSnapshot{},
+		writing:   map[string]int{},
+		stats:     map[string]*readCacheStats{},
+	}
+}
+
+// resourceTypeOf returns the resource type a kind belongs to.
+func resourceTypeOf(kind string) string {
+	resourceType, _, _ := strings.Cut(kind, "/")
+	return resourceType
+}
+
+func (c *readCache) statsLocked(kind string) *readCacheStats {
+	s := c.stats[kind]
+	if s == nil {
+		s = &readCacheStats{}
+		c.stats[kind] = s
+	}
+	return s
+}
+
+// snapshot returns the items of kind, listing them on first use. It returns
+// false when the cache is disabled, a write of the type is in flight, or the
+// list failed; the caller then reads from the API.
+func (c *readCache) snapshot(kind string, list func() (interface{}, error)) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	if c.writing[resourceTypeOf(kind)] > 0 {
+		c.statsLocked(kind).bypasses++
+		c.mu.Unlock()
+		return nil, false
+	}
+	s, ok := c.snapshots[kind]
+	if !ok {
+		s = &readSnapshot{ready: make(chan struct{})}
+		c.snapshots[kind] = s
+		c.statsLocked(kind).lists++
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		s.items, s.err = list()
+		close(s.ready)
+	}
+	<-s.ready
+	if s.err != nil {
+		log.Printf("[WARN] read cache: listing %s failed, reading from the API: %v", kind, s.err)
+		c.mu.Lock()
+		if c.snapshots[kind] == s {
+			delete(c.snapshots, kind)
+		}
+		c.mu.Unlock()
+		return nil, false
+	}
+	return s.items, true
+}
+
+func (c *readCache) count(kind string, hit bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hit {
+		c.statsLocked(kind).hits++
+	} else {
+		c.statsLocked(kind).misses++
+	}
+}
+
+// invalidateLocked drops every snapshot of resourceType.
+func (c *readCache) invalidateLocked(resourceType string) {
+	for kind := range c.snapshots {
+		if resourceTypeOf(kind) == resourceType {
+			delete(c.snapshots, kind)
+			c.statsLocked(kind).invalidations++
+		}
+	}
+}
+
+// beginWrite records the start of a write through a resource of
+// resourceType.
+func (c *readCache) beginWrite(resourceType string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writing[resourceType]++
+	c.invalidateLocked(resourceType)
+}
+
+// endWrite records the end of a write started with beginWrite.
+func (c *readCache) endWrite(resourceType string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writing[resourceType]--
+	c.invalidateLocked(resourceType)
+}
+
+// reset logs the statistics of the run and empties the cache.
+func (c *readCache) reset() {
+	if c == nil {
+		return
+	}
+	c.logStats()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapshots = map[string]*readSnapshot{}
+	c.stats = map[string]*readCacheStats{}
+}
+
+func (c *readCache) logStats() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.stats) == 0 {
+		return
+	}
+	kinds := make([]string, 0, len(c.stats))
+	for kind := range c.stats {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	var total readCacheStats
+	for _, kind := range kinds {
+		s := c.stats[kind]
+		log.Printf("[INFO] read cache: %s — %d list(s), %d hit(s), %d miss(es), %d bypass(es), %d invalidation(s)", kind, s.lists, s.hits, s.misses, s.bypasses, s.invalidations)
+		total.lists += s.lists
+		total.hits += s.hits
+		total.misses += s.misses
+	}
+	log.Printf("[INFO] read cache: %d Read(s) served from %d list call(s) across %d kind(s), %d fell back to the API", total.hits, total.lists, len(kinds), total.misses)
+}
+
+// LogReadCacheStats logs the read cache statistics of every configured
+// client. main calls it once the provider server has stopped.
+func LogReadCacheStats() {
+	configuredClients.Lock()
+	defer configuredClients.Unlock()
+	for _, client := range configuredClients.m {
+		client.readCache.logStats()
+	}
+}
+
+// cachedList returns the items of kind from the client's read cache, or
+// from list when the cache cannot serve them.
+func cachedList[T any](zClient *Client, kind string, list func() ([]T, error)) ([]T, error) {
+	items, ok := zClient.readCache.snapshot(kind, func() (interface{}, error) {
+		return list()
+	})
+	if !ok {
+		return list()
+	}
+	return items.([]T), nil
+}
+
+// cachedRead returns the object with the given ID from the snapshot of kind.
+// It falls back to get when the cache cannot serve the snapshot or the
+// object is not in it, so not-found errors still come from the API.
+func cachedRead[T any](zClient *Client, kind string, id int, list func() ([]T, error), idOf func(*T) int, get func() (*T, error)) (*T, error) {
+	items, ok := zClient.readCache.snapshot(kind, func() (interface{}, error) {
+		return list()
+	})
+	if !ok {
+		return get()
+	}
+	all := items.([]T)
+	for i := range all {
+		if idOf(&all[i]) == id {
+			zClient.readCache.count(kind, true)
+			obj := all[i]
+			return &obj, nil
+		}
+	}
+	zClient.readCache.count(kind, false)
+	return get()
+}
+
+// readCacheKind returns the cache kind of a resource type whose rules are
+// listed per scope.
+func readCacheKind(resourceType string, scope interface{}) string {
+	return fmt.Sprintf("%s/%v", resourceType, scope)
+}
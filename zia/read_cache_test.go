@@ -0,0 +1,118 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestReadCache_SnapshotsAndInvalidation(t *testing.T) {
+	c := newReadCache()
+	lists := 0
+	list := func() (interface{}, error) {
+		lists++
+		return []int{1, 2, 3}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, ok := c.snapshot("zia_url_filtering_rules", list); !ok {
+			t.Fatal("expected the snapshot to be served")
+		}
+	}
+	if lists != 1 {
+		t.Fatalf("expected one list call, got %d", lists)
+	}
+
+	c.beginWrite("zia_url_filtering_rules")
+	if _, ok := c.snapshot("zia_url_filtering_rules", list); ok {
+		t.Fatal("expected reads to bypass the cache while a write is in flight")
+	}
+	if _, ok := c.snapshot("zia_cloud_app_control_rule/WEBMAIL", list); !ok {
+		t.Fatal("expected other types to stay cached during the write")
+	}
+	c.endWrite("zia_url_filtering_rules")
+	if _, ok := c.snapshot("zia_url_filtering_rules", list); !ok || lists != 3 {
+		t.Fatalf("expected a fresh snapshot after the write, %d list calls", lists)
+	}
+
+	c.beginWrite("zia_cloud_app_control_rule")
+	c.endWrite("zia_cloud_app_control_rule")
+	if got := c.stats["zia_cloud_app_control_rule/WEBMAIL"].invalidations; got != 1 {
+		t.Fatalf("expected scoped snapshots to be invalidated with their type, got %d", got)
+	}
+
+	c.reset()
+	if len(c.snapshots) != 0 || len(c.stats) != 0 {
+		t.Fatal("expected reset to empty the cache")
+	}
+
+	var disabled *readCache
+	if _, ok := disabled.snapshot("zia_url_filtering_rules", list); ok {
+		t.Fatal("expected a nil cache to serve nothing")
+	}
+}
+
+func TestReadCache_RefreshListsOncePerType(t *testing.T) {
+	t.Setenv("ZSCALER_READ_CACHE", "true")
+	server, client := configureFakeZIA(t)
+	if client.readCache == nil {
+		t.Fatal("expected ZSCALER_READ_CACHE to enable the read cache")
+	}
+	seeded := server.Seed("urlFilteringRules",
+		map[string]interface{}{"name": "one", "order": 1, "rank": 7, "state": "ENABLED", "action": "ALLOW"},
+		map[string]interface{}{"name": "two", "order": 2, "rank": 7, "state": "ENABLED", "action": "ALLOW"},
+		map[string]interface{}{"name": "three", "order": 3, "rank": 7, "state": "ENABLED", "action": "ALLOW"},
+	)
+
+	r := ZIAProvider().ResourcesMap["zia_url_filtering_rules"]
+	ctx := context.Background()
+	read := func(obj map[string]interface{}) *schema.ResourceData {
+		t.Helper()
+		d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{})
+		id, _ := strconv.Atoi(fmt.Sprint(obj["id"]))
+		d.SetId(strconv.Itoa(id))
+		_ = d.Set("rule_id", id)
+		if diags := r.ReadContext(ctx, d, client); diags.HasError() {
+			t.Fatalf("read: %v", diags)
+		}
+		return d
+	}
+
+	for _, obj := range seeded {
+		if d := read(obj); d.Get("name") != obj["name"] {
+			t.Fatalf("expected %v, got %v", obj["name"], d.Get("name"))
+		}
+	}
+	if got := countRequests(server, http.MethodGet, "urlFilteringRules"); got != 1 {
+		t.Fatalf("expected one list call for three reads, got %d", got)
+	}
+	for _, req := range server.Requests() {
+		if req.Method == http.MethodGet && strings.HasPrefix(req.Path, "urlFilteringRules/") {
+			t.Fatalf("expected no per-rule reads, got GET %s", req.Path)
+		}
+	}
+
+	// A write through the provider drops the snapshot.
+	d := read(seeded[0])
+	if diags := r.DeleteContext(ctx, d, client); diags.HasError() {
+		t.Fatalf("delete: %v", diags)
+	}
+	read(seeded[1])
+	if got := countRequests(server, http.MethodGet, "urlFilteringRules"); got != 2 {
+		t.Fatalf("expected the write to invalidate the snapshot, got %d list calls", got)
+	}
+}
+
+func TestReadCache_DisabledCacheIsNoOp(t *testing.T) {
+	var c *readCache
+	c.logStats()
+	c.reset()
+	if _, ok := c.snapshot("kind", func() (interface{}, error) { return nil, nil }); ok {
+		t.Error("expected a disabled cache to serve nothing")
+	}
+}
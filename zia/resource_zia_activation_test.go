@@ -4,7 +4,7 @@ package zia
 func TestAccResourceActivationStatus(t *testing.T) {
 	resourceName := "zia_activation_status.this"
 	resource.Test(t, resource.TestCase{
-		Providers:    testAccProviders, // Ensure you have a provider configuration for testing
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories, // Ensure you have a provider configuration for testing
 		CheckDestroy: testAccCheckActivationStatusDestroy,
 		Steps: []resource.TestStep{
 			{
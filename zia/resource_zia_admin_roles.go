@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -20,27 +19,7 @@ func resourceAdminRoles() *schema.Resource {
 		ReadContext:   resourceAdminRolesRead,
 		UpdateContext: resourceAdminRolesUpdate,
 		DeleteContext: resourceAdminRolesDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("role_id", idInt)
-				} else {
-					resp, err := roles.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("role_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importByName("role_id", roles.GetAllAdminRoles),
 		CustomizeDiff: adminRolesCustomizeDiff,
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -192,9 +171,7 @@ func resourceAdminRolesCreate(ctx context.Context, d *schema.ResourceData, meta
 	_ = d.Set("role_id", resp.ID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -270,9 +247,7 @@ func resourceAdminRolesUpdate(ctx context.Context, d *schema.ResourceData, meta
 	}
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -300,9 +275,7 @@ func resourceAdminRolesDelete(ctx context.Context, d *schema.ResourceData, meta
 	log.Printf("[INFO] zia role deleted")
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
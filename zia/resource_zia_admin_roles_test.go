@@ -9,7 +9,7 @@ func TestAccResourceAdminRolesBasic(t *testing.T) {
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckAdminRolesDestroy,
 		Steps: []resource.TestStep{
 			{
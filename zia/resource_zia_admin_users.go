@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/adminuserrolemgmt/admins"
 )
@@ -20,27 +20,17 @@ func resourceAdminUsers() *schema.Resource {
 		ReadContext:   resourceAdminUsersRead,
 		UpdateContext: resourceAdminUsersUpdate,
 		DeleteContext: resourceAdminUsersDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("admin_id", idInt)
-				} else {
-					resp, err := admins.GetAdminUsersByLoginName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("admin_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByKey("admin_id", func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+			users, err := admins.GetAllAdminUsers(ctx, service)
+			if err != nil {
+				return nil, err
+			}
+			candidates := make([]importCandidate, len(users))
+			for i, admin := range users {
+				candidates[i] = importCandidate{id: admin.ID, name: admin.LoginName, keys: []string{admin.UserName}}
+			}
+			return candidates, nil
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -108,11 +98,28 @@ func resourceAdminUsers() *schema.Resource {
 				Optional: true,
 			},
 			"password": {
-				Type:         schema.TypeString,
-				Description:  "The admin's password. If admin single sign-on (SSO) is disabled, then this field is mandatory for POST requests. This information is not provided in a GET response.",
+				Type:          schema.TypeString,
+				Description:   "The admin's password. If admin single sign-on (SSO) is disabled, then this field is mandatory for POST requests. This information is not provided in a GET response.",
+				Optional:      true,
+				Sensitive:     true,
+				ValidateFunc:  validation.StringLenBetween(8, 100),
+				ConflictsWith: []string{"password_wo"},
+			},
+			"password_wo": {
+				Type:          schema.TypeString,
+				Description:   "Write-only variant of password. Never stored in the plan or state. Change password_wo_version to apply a new value.",
+				Optional:      true,
+				Sensitive:     true,
+				WriteOnly:     true,
+				ValidateFunc:  validation.StringLenBetween(8, 100),
+				ConflictsWith: []string{"password"},
+				RequiredWith:  []string{"password_wo_version"},
+			},
+			"password_wo_version": {
+				Type:         schema.TypeInt,
+				Description:  "Version of password_wo. Changing it sends the current password_wo value to ZIA.",
 				Optional:     true,
-				Sensitive:    true,
-				ValidateFunc: validation.StringLenBetween(8, 100),
+				RequiredWith: []string{"password_wo"},
 			},
 			"is_password_login_allowed": {
 				Type:     schema.TypeBool,
@@ -163,9 +170,7 @@ func resourceAdminUsersCreate(ctx context.Context, d *schema.ResourceData, meta
 	_ = d.Set("admin_id", resp.ID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -268,9 +273,7 @@ func resourceAdminUsersUpdate(ctx context.Context, d *schema.ResourceData, meta
 	}
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -300,9 +303,7 @@ func resourceAdminUsersDelete(ctx context.Context, d *schema.ResourceData, meta
 	log.Printf("[INFO] admin user deleted")
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -324,7 +325,7 @@ func expandAdminUsers(d *schema.ResourceData) admins.AdminUsers {
 		IsNonEditable:               d.Get("is_non_editable").(bool),
 		Disabled:                    d.Get("disabled").(bool),
 		IsAuditor:                   d.Get("is_auditor").(bool),
-		Password:                    d.Get("password").(string),
+		Password:                    getSecretFromResourceData(d, "password"),
 		AdminScopeType:              d.Get("admin_scope_type").(string),
 		IsPasswordLoginAllowed:      d.Get("is_password_login_allowed").(bool),
 		IsSecurityReportCommEnabled: d.Get("is_security_report_comm_enabled").(bool),
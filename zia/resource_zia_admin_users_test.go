@@ -27,7 +27,7 @@ func TestAccResourceAdminUsersBasic(t *testing.T) {
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckAdminUsersDestroy,
 		Steps: []resource.TestStep{
 			{
@@ -17,16 +17,7 @@ func resourceAdvancedSettings() *schema.Resource {
 		CreateContext: resourceAdvancedSettingsCreate,
 		UpdateContext: resourceAdvancedSettingsUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceAdvancedSettingsRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("import read error: %v", diags)
-				}
-				d.SetId("advanced_settings")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("advanced_settings", resourceAdvancedSettingsRead),
 		Schema: map[string]*schema.Schema{
 			"auth_bypass_apps": {
 				Type:        schema.TypeSet,
@@ -397,8 +388,8 @@ func resourceAdvancedSettingsCreate(ctx context.Context, d *schema.ResourceData,
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -497,8 +488,8 @@ func resourceAdvancedSettingsUpdate(ctx context.Context, d *schema.ResourceData,
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
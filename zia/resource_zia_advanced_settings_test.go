@@ -12,9 +12,9 @@ func TestAccResourceAdvancedSettings_Basic(t *testing.T) {
 	resourceName := "zia_advanced_settings.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckResourceAdvancedSettingsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckResourceAdvancedSettingsDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create the resource with specific values
 			{
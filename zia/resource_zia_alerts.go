@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/alerts"
 )
@@ -19,40 +19,18 @@ func resourceSubscriptionAlerts() *schema.Resource {
 		ReadContext:   resourceSubscriptionAlertsRead,
 		UpdateContext: resourceSubscriptionAlertsUpdate,
 		DeleteContext: resourceSubscriptionAlertsDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				rawID := d.Id()
-
-				// Attempt direct lookup via ID
-				if idInt, err := strconv.Atoi(rawID); err == nil {
-					if sub, err := alerts.Get(ctx, service, idInt); err == nil && sub != nil {
-						d.Set("alert_id", sub.ID)
-						d.SetId(strconv.Itoa(sub.ID))
-						return []*schema.ResourceData{d}, nil
-					}
-				}
-
-				// Fallback to searching all entries
-				alertsList, err := alerts.GetAll(ctx, service)
-				if err != nil {
-					return nil, fmt.Errorf("failed to retrieve alert subscriptions: %w", err)
-				}
-
-				for _, a := range alertsList {
-					if strconv.Itoa(a.ID) == rawID || a.Email == rawID {
-						d.Set("alert_id", a.ID)
-						d.Set("email", a.Email)
-						d.SetId(strconv.Itoa(a.ID))
-						return []*schema.ResourceData{d}, nil
-					}
-				}
-
-				return nil, fmt.Errorf("alert subscription not found with ID or email '%s'", rawID)
-			},
-		},
+		Importer: importByKey("alert_id", func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+			subscriptions, err := alerts.GetAll(ctx, service)
+			if err != nil {
+				return nil, err
+			}
+			// Subscriptions have no name; they are imported by email.
+			candidates := make([]importCandidate, len(subscriptions))
+			for i, subscription := range subscriptions {
+				candidates[i] = importCandidate{id: subscription.ID, name: subscription.Email}
+			}
+			return candidates, nil
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -104,9 +82,7 @@ func resourceSubscriptionAlertsCreate(ctx context.Context, d *schema.ResourceDat
 	_ = d.Set("alert_id", resp.ID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -171,9 +147,7 @@ func resourceSubscriptionAlertsUpdate(ctx context.Context, d *schema.ResourceDat
 	}
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -201,9 +175,7 @@ func resourceSubscriptionAlertsDelete(ctx context.Context, d *schema.ResourceDat
 	log.Printf("[INFO] zia subscription alert deleted")
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
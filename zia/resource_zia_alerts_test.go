@@ -24,9 +24,9 @@ func TestAccResourceSubscriptionAlertsBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckSubscriptionAlertsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckSubscriptionAlertsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckSubscriptionAlertsConfigure(resourceTypeAndName, initialEmail, variable.AlertDescription),
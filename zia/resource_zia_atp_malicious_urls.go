@@ -65,8 +65,8 @@ func resourceATPMaliciousUrlsCreate(ctx context.Context, d *schema.ResourceData,
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -112,8 +112,8 @@ func resourceATPMaliciousUrlsUpdate(ctx context.Context, d *schema.ResourceData,
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -12,8 +12,8 @@ func TestAccResourceATPMaliciousUrls_basic(t *testing.T) {
 	resourceName := "zia_atp_malicious_urls.test"
 
 	resource.Test(t, resource.TestCase{
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckATPMaliciousUrlsDestroy,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckATPMaliciousUrlsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccResourceATPMaliciousUrlsConfig([]string{".example.com", ".test.com"}),
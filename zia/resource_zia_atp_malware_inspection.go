@@ -17,16 +17,7 @@ func resourceATPMalwareInspection() *schema.Resource {
 		CreateContext: resourceATPMalwareInspectionCreate,
 		UpdateContext: resourceATPMalwareInspectionUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceATPMalwareInspectionRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("failed to read atp malware inspection import: %s", diags[0].Summary)
-				}
-				d.SetId("inspection")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("inspection", resourceATPMalwareInspectionRead),
 		Schema: map[string]*schema.Schema{
 			"inspect_inbound": {
 				Type:        schema.TypeBool,
@@ -63,8 +54,8 @@ func resourceATPMalwareInspectionCreate(ctx context.Context, d *schema.ResourceD
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -115,8 +106,8 @@ func resourceATPMalwareInspectionUpdate(ctx context.Context, d *schema.ResourceD
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
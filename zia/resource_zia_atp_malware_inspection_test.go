@@ -12,9 +12,9 @@ func TestAccResourceATPMalwareInspection_Basic(t *testing.T) {
 	resourceName := "zia_atp_malware_inspection.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckResourceATPMalwareInspectionDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckResourceATPMalwareInspectionDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create the resource with specific values
 			{
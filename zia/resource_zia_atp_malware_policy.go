@@ -17,16 +17,7 @@ func resourceATPMalwarePolicy() *schema.Resource {
 		CreateContext: resourceATPMalwarePolicyCreate,
 		UpdateContext: resourceATPMalwarePolicyUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceATPMalwarePolicyRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("failed to read atp malware policy import: %s", diags[0].Summary)
-				}
-				d.SetId("policy")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("policy", resourceATPMalwarePolicyRead),
 		Schema: map[string]*schema.Schema{
 			"block_unscannable_files": {
 				Type:        schema.TypeBool,
@@ -63,8 +54,8 @@ func resourceATPMalwarePolicyCreate(ctx context.Context, d *schema.ResourceData,
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -115,8 +106,8 @@ func resourceATPMalwarePolicyUpdate(ctx context.Context, d *schema.ResourceData,
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
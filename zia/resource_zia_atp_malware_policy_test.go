@@ -12,9 +12,9 @@ func TestAccResourceATPMalwarePolicy_Basic(t *testing.T) {
 	resourceName := "zia_atp_malware_policy.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckResourceATPMalwarePolicyDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckResourceATPMalwarePolicyDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create the resource with specific values
 			{
@@ -17,16 +17,7 @@ func resourceATPMalwareProtocols() *schema.Resource {
 		CreateContext: resourceATPMalwareProtocolCreate,
 		UpdateContext: resourceATPMalwareProtocolUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceATPMalwareProtocolRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("failed to read atp malware protocol import: %s", diags[0].Summary)
-				}
-				d.SetId("protocol")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("protocol", resourceATPMalwareProtocolRead),
 		Schema: map[string]*schema.Schema{
 			"inspect_http": {
 				Type:        schema.TypeBool,
@@ -70,8 +61,8 @@ func resourceATPMalwareProtocolCreate(ctx context.Context, d *schema.ResourceDat
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -124,8 +115,8 @@ func resourceATPMalwareProtocolUpdate(ctx context.Context, d *schema.ResourceDat
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -12,9 +12,9 @@ func TestAccResourceATPMalwareProtocols_Basic(t *testing.T) {
 	resourceName := "zia_atp_malware_protocols.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckResourceATPMalwareProtocolsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckResourceATPMalwareProtocolsDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create the resource with specific values
 			{
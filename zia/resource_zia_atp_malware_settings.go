@@ -17,16 +17,7 @@ func resourceATPMalwareSettings() *schema.Resource {
 		CreateContext: resourceATPMalwareSettingsCreate,
 		UpdateContext: resourceATPMalwareSettingsUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceATPMalwareSettingsRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("failed to read atp malware settings import: %s", diags[0].Summary)
-				}
-				d.SetId("malware_settings")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("malware_settings", resourceATPMalwareSettingsRead),
 		Schema: map[string]*schema.Schema{
 			"virus_blocked": {
 				Type:        schema.TypeBool,
@@ -144,8 +135,8 @@ func resourceATPMalwareSettingsCreate(ctx context.Context, d *schema.ResourceDat
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -206,8 +197,8 @@ func resourceATPMalwareSettingsUpdate(ctx context.Context, d *schema.ResourceDat
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -6,7 +6,7 @@ func TestAccResourceATPMalwareSettings_Basic(t *testing.T) {
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckResourceATPMalwareSettingsDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create the resource with specific values
@@ -12,8 +12,8 @@ func TestAccResourceATPSecurityExceptions_basic(t *testing.T) {
 	resourceName := "zia_atp_security_exceptions.test"
 
 	resource.Test(t, resource.TestCase{
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckATPSecurityExceptionsDestroy,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckATPSecurityExceptionsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccResourceATPSecurityExceptionsConfig([]string{".example.com", ".test.com"}),
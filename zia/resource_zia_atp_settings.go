@@ -18,17 +18,7 @@ func resourceAdvancedThreatSettings() *schema.Resource {
 		CreateContext: resourceAdvancedThreatSettingsCreate,
 		UpdateContext: resourceAdvancedThreatSettingsUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceAdvancedThreatSettingsRead(ctx, d, meta)
-				if diags.HasError() {
-					// Convert diag.Diagnostics to an error for the importer
-					return nil, fmt.Errorf("failed to read advanced threat settings during import: %s", diags[0].Summary)
-				}
-				d.SetId("advanced_threat_settings")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("advanced_threat_settings", resourceAdvancedThreatSettingsRead),
 
 		Schema: map[string]*schema.Schema{
 			"risk_tolerance": {
@@ -287,8 +277,8 @@ func resourceAdvancedThreatSettingsCreate(ctx context.Context, d *schema.Resourc
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -391,8 +381,8 @@ func resourceAdvancedThreatSettingsUpdate(ctx context.Context, d *schema.Resourc
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -37,9 +37,9 @@ func TestAccResourceAdvancedThreatSettings_basic(t *testing.T) {
 	)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:          func() { testAccPreCheck(t) },
-		ProviderFactories: testAccProviderFactories,
-		CheckDestroy:      testAccCheckAdvancedThreatSettingsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckAdvancedThreatSettingsDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create resource with initial config
 			{
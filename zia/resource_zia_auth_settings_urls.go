@@ -2,9 +2,7 @@ package zia
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -17,27 +15,7 @@ func resourceAuthSettingsUrls() *schema.Resource {
 		CreateContext: resourceAuthSettingsUrlsCreate,
 		UpdateContext: resourceAuthSettingsUrlsUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				urls, err := user_authentication_settings.Get(ctx, service)
-				if err != nil {
-					return nil, fmt.Errorf("error fetching urls from exception list: %s", err)
-				}
-
-				if urls != nil {
-					if err := d.Set("urls", urls.URLs); err != nil {
-						return nil, fmt.Errorf("error setting urls: %s", err)
-					}
-				}
-
-				d.SetId("all_urls")
-
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("all_urls", resourceAuthSettingsUrlsRead),
 		Schema: map[string]*schema.Schema{
 			"urls": {
 				Type:     schema.TypeSet,
@@ -80,9 +58,7 @@ func resourceAuthSettingsUrlsCreate(ctx context.Context, d *schema.ResourceData,
 	}
 	d.SetId("all_urls")
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -12,8 +12,8 @@ func TestAccResourceAuthSettingsUrls_basic(t *testing.T) {
 	resourceName := "zia_auth_settings_urls.test"
 
 	resource.Test(t, resource.TestCase{
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckAuthSettingsUrlsDestroy,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckAuthSettingsUrlsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccResourceAuthSettingsUrlsConfig([]string{".example.com", ".test.com"}),
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -19,27 +18,7 @@ func resourceBandwdithClasses() *schema.Resource {
 		ReadContext:   resourceBandwdithClassesRead,
 		UpdateContext: resourceBandwdithClassesUpdate,
 		DeleteContext: resourceBandwdithClassesDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("class_id", idInt)
-				} else {
-					resp, err := bandwidth_classes.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("class_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importByName("class_id", bandwidth_classes.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -94,8 +73,7 @@ func resourceBandwdithClassesCreate(ctx context.Context, d *schema.ResourceData,
 	d.SetId(strconv.Itoa(resp.ID))
 	_ = d.Set("class_id", resp.ID)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -158,8 +136,7 @@ func resourceBandwdithClassesUpdate(ctx context.Context, d *schema.ResourceData,
 		return diag.FromErr(err)
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -186,8 +163,7 @@ func resourceBandwdithClassesDelete(ctx context.Context, d *schema.ResourceData,
 	d.SetId("")
 	log.Printf("[INFO] zia bandwidth class deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
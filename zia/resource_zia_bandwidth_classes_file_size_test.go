@@ -12,9 +12,9 @@ func TestAccResourceBandwdithClassesFileSize_Basic(t *testing.T) {
 	resourceName := "zia_bandwidth_classes_file_size.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckResourceBandwdithClassesFileSizeDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckResourceBandwdithClassesFileSizeDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create the resource with an initial file size
 			{
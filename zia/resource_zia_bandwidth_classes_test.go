@@ -23,9 +23,9 @@ func TestAccResourceBandwdithClasses_Basic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckBandwdithClassesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckBandwdithClassesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckBandwdithClassesConfigure(resourceTypeAndName, initialName),
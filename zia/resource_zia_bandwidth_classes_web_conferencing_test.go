@@ -13,9 +13,9 @@ func TestAccResourceBandwdithClassesWebConferencing_Basic(t *testing.T) {
 	resourceName := "zia_bandwidth_classes_web_conferencing.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckResourceBandwdithClassesWebConferencingDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckResourceBandwdithClassesWebConferencingDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccResourceBandwdithClassesWebConferencingConfig(
@@ -4,58 +4,92 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
-	"strconv"
 	"strings"
-	"sync"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/bandwidth_control/bandwidth_control_rules"
 )
 
-var (
-	bandwidthControlLock          sync.Mutex
-	bandwidthControlStartingOrder int
-)
+// bandwidthControlRuleAdapter is the OrderedRuleAdapter of bandwidth control
+// rules. The Default Bandwidth Control rule stays at order 125 and is left out
+// of the order.
+type bandwidthControlRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (bandwidthControlRuleAdapter) ResourceType() string { return "bandwidth_control_rule" }
+func (bandwidthControlRuleAdapter) Ranked() bool         { return false }
+
+func (a bandwidthControlRuleAdapter) GetAll(ctx context.Context) ([]bandwidth_control_rules.BandwidthControlRules, error) {
+	list, err := bandwidth_control_rules.GetAll(ctx, a.service)
+	if err != nil {
+		return nil, err
+	}
+	return filterOutBandwidthDefaultRule(list), nil
+}
+
+func (a bandwidthControlRuleAdapter) Get(ctx context.Context, id int) (*bandwidth_control_rules.BandwidthControlRules, error) {
+	return bandwidth_control_rules.Get(ctx, a.service, id)
+}
+
+func (a bandwidthControlRuleAdapter) Create(ctx context.Context, rule *bandwidth_control_rules.BandwidthControlRules) (int, error) {
+	resp, err := bandwidth_control_rules.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a bandwidthControlRuleAdapter) Update(ctx context.Context, id int, rule *bandwidth_control_rules.BandwidthControlRules) error {
+	_, err := bandwidth_control_rules.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a bandwidthControlRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := bandwidth_control_rules.Delete(ctx, a.service, id)
+	return err
+}
+
+func (bandwidthControlRuleAdapter) Order(rule *bandwidth_control_rules.BandwidthControlRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (bandwidthControlRuleAdapter) SetOrder(rule *bandwidth_control_rules.BandwidthControlRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (bandwidthControlRuleAdapter) StripReadOnly(rule *bandwidth_control_rules.BandwidthControlRules) {
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.DefaultRule = false
+	rule.AccessControl = ""
+}
+
+func (a bandwidthControlRuleAdapter) GetByName(ctx context.Context, name string) (*bandwidth_control_rules.BandwidthControlRules, error) {
+	return bandwidth_control_rules.GetByName(ctx, a.service, name)
+}
+
+func (bandwidthControlRuleAdapter) IsPredefined(rule *bandwidth_control_rules.BandwidthControlRules) bool {
+	return rule.DefaultRule
+}
 
 func resourceBandwdithControlRules() *schema.Resource {
-	return &schema.Resource{
+	return withAdoptPredefined(versionSchema(&schema.Resource{
 		CreateContext: resourceBandwdithControlRulesCreate,
 		ReadContext:   resourceBandwdithControlRulesRead,
 		UpdateContext: resourceBandwdithControlRulesUpdate,
 		DeleteContext: resourceBandwdithControlRulesDelete,
+		CustomizeDiff: validateRuleOrder("zia_bandwidth_control_rule"),
 		Importer: &schema.ResourceImporter{
 			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-
-				// Handle format "type:id" by extracting just the ID part
-				if strings.Contains(id, ":") {
-					parts := strings.Split(id, ":")
-					if len(parts) == 2 {
-						id = parts[1] // Use the ID part after the colon
-					}
+				// Handle format "type:id" by dropping the type
+				if ruleType, id, ok := strings.Cut(d.Id(), ":"); ok && ruleType+":" != importNamePrefix {
+					d.SetId(id)
 				}
-
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := bandwidth_control_rules.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
+				return importByName("rule_id", bandwidth_control_rules.GetAll).StateContext(ctx, d, meta)
 			},
 		},
 
@@ -110,133 +144,25 @@ func resourceBandwdithControlRules() *schema.Resource {
 				Description: "The maximum percentage of a location's bandwidth you want to be guaranteed for each selected bandwidth control rule",
 			},
 			"bandwidth_classes": setIDsSchemaTypeCustom(nil, "The bandwidth control rulees to which you want to apply this rule"),
-			"locations":         setIDsSchemaTypeCustom(intPtr(8), "Name-ID pairs of locations for which rule must be applied"),
+			"location_ids":      setIntIDsSchemaType(intPtr(8), "IDs of locations for which rule must be applied"),
 			"location_groups":   setIDsSchemaTypeCustom(intPtr(32), "Name-ID pairs of the location groups to which the rule must be applied."),
 			"labels":            setIDsSchemaTypeCustom(nil, "Labels that are applicable to the rule"),
 			"time_windows":      setIDsSchemaTypeCustom(nil, "The Name-ID pairs of time windows to which the bandwidth control rule must be applied"),
 			"protocols":         getURLProtocols(),
 		},
-	}
+	}, ruleIDBlocksV0))
 }
 
 func resourceBandwdithControlRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandBandwdithControlRules(d)
 	log.Printf("[INFO] Creating ZIA bandwitdh control rule\n%+v\n", req)
 
-	// Create timeout for the operation
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-
-	for {
-		bandwidthControlLock.Lock()
-		if bandwidthControlStartingOrder == 0 {
-			list, _ := bandwidth_control_rules.GetAll(ctx, service)
-			for _, r := range list {
-				// Ignore default rule
-				if r.Order == 125 || r.Name == "Default Bandwidth Control" {
-					continue
-				}
-				if r.Order > bandwidthControlStartingOrder {
-					bandwidthControlStartingOrder = r.Order
-				}
-			}
-			if bandwidthControlStartingOrder == 0 {
-				bandwidthControlStartingOrder = 1
-			}
-		}
-		bandwidthControlLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		order := req.Order
-		req.Order = bandwidthControlStartingOrder
-
-		resp, err := bandwidth_control_rules.Create(ctx, service, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			reg := regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				if reg.MatchString(err.Error()) {
-					return diag.FromErr(fmt.Errorf("error creating resource: %s, please check the order %d vs rank %d, current rules:%s , err:%s", req.Name, order, req.Rank, currentBandwidthControlOrderVsRankWording(ctx, zClient), err))
-				}
-				if time.Since(start) < timeout {
-					log.Printf("[INFO] Creating bandwidth control rule name: %v, got INVALID_INPUT_ARGUMENT\n", req.Name)
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-		}
-
-		log.Printf("[INFO] Created zia bandwidth control rule request. took:%s, without locking:%s,  ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-		reorderWithBeforeReorder(
-			OrderRule{Order: order, Rank: req.Rank},
-			resp.ID,
-			"bandwidth_control_rule",
-			func() (map[int]OrderRule, error) {
-				list, err := bandwidth_control_rules.GetAll(ctx, service)
-				if err != nil {
-					return nil, err
-				}
-				filteredList := filterOutBandwidthDefaultRule(list)
-				m := make(map[int]OrderRule, len(filteredList))
-				for _, r := range filteredList {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				rule, err := bandwidth_control_rules.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-				// Optional: avoid unnecessary updates if the current order is already correct
-				if rule.Order == order.Order {
-					return nil
-				}
-				// Strip read-only fields that cause "Request body is invalid" for predefined rules
-				rule.DefaultRule = false
-				rule.AccessControl = ""
-				rule.Order = order.Order
-				_, err = bandwidth_control_rules.Update(ctx, service, id, rule)
-				return err
-			},
-			nil)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		if diags := resourceBandwdithControlRulesRead(ctx, d, meta); diags.HasError() {
-			if time.Since(start) < timeout {
-				time.Sleep(10 * time.Second) // Wait before retrying
-				continue
-			}
-			return diags
-		}
-		markOrderRuleAsDone(resp.ID, "bandwidth_control_rule")
-		waitForReorder("bandwidth_control_rule")
-		break
+	if diags := createOrderedRule(ctx, d, zClient, bandwidthControlRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return resourceBandwdithControlRulesRead(ctx, d, meta)
 }
 
 func resourceBandwdithControlRulesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -247,7 +173,14 @@ func resourceBandwdithControlRulesRead(ctx context.Context, d *schema.ResourceDa
 	if !ok {
 		return diag.FromErr(fmt.Errorf("no bandwidth control rules id is set"))
 	}
-	resp, err := bandwidth_control_rules.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_bandwidth_control_rule", id,
+		func() ([]bandwidth_control_rules.BandwidthControlRules, error) {
+			return bandwidth_control_rules.GetAll(ctx, service)
+		},
+		func(r *bandwidth_control_rules.BandwidthControlRules) int { return r.ID },
+		func() (*bandwidth_control_rules.BandwidthControlRules, error) {
+			return bandwidth_control_rules.Get(ctx, service, id)
+		})
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing zia bandwidth control rules %s from state because it no longer exists in ZIA", d.Id())
@@ -279,7 +212,7 @@ func resourceBandwdithControlRulesRead(ctx context.Context, d *schema.ResourceDa
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -296,7 +229,6 @@ func resourceBandwdithControlRulesRead(ctx context.Context, d *schema.ResourceDa
 
 func resourceBandwdithControlRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -306,82 +238,9 @@ func resourceBandwdithControlRulesUpdate(ctx context.Context, d *schema.Resource
 	log.Printf("[INFO] Updating bandwidth control rule ID: %v\n", id)
 	req := expandBandwdithControlRules(d)
 
-	if _, err := bandwidth_control_rules.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
+	if diags := updateOrderedRule(ctx, d, zClient, bandwidthControlRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
-	timeout := d.Timeout(schema.TimeoutUpdate)
-	start := time.Now()
-
-	for {
-		_, err := bandwidth_control_rules.Update(ctx, service, id, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				log.Printf("[INFO] Updating bandwidth control rule ID: %v, got INVALID_INPUT_ARGUMENT\n", id)
-				if time.Since(start) < timeout {
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-		}
-
-		reorderWithBeforeReorder(OrderRule{Order: req.Order, Rank: req.Rank}, req.ID, "bandwidth_control_rule",
-			func() (map[int]OrderRule, error) {
-				list, err := bandwidth_control_rules.GetAll(ctx, service)
-				if err != nil {
-					return nil, err
-				}
-				filteredList := filterOutBandwidthDefaultRule(list)
-				m := make(map[int]OrderRule, len(filteredList))
-				for _, r := range filteredList {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				rule, err := bandwidth_control_rules.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-				// Optional: avoid unnecessary updates if the current order is already correct
-				if rule.Order == order.Order {
-					return nil
-				}
-				// Strip read-only fields that cause "Request body is invalid" for predefined rules
-				rule.DefaultRule = false
-				rule.AccessControl = ""
-				rule.Order = order.Order
-				_, err = bandwidth_control_rules.Update(ctx, service, id, rule)
-				return err
-			},
-			nil)
-
-		markOrderRuleAsDone(req.ID, "bandwidth_control_rule")
-		waitForReorder("bandwidth_control_rule")
-		break
-	}
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
 	return resourceBandwdithControlRulesRead(ctx, d, meta)
 }
 
@@ -393,24 +252,8 @@ func resourceBandwdithControlRulesDelete(ctx context.Context, d *schema.Resource
 	if !ok {
 		log.Printf("[ERROR] bandwidth control rule ID not set: %v\n", id)
 	}
-	log.Printf("[INFO] Deleting zia bandwidth control rule ID: %v\n", (d.Id()))
-
-	if _, err := bandwidth_control_rules.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] zia bandwidth control rule deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, bandwidthControlRuleAdapter{service}, id)
 }
 
 func expandBandwdithControlRules(d *schema.ResourceData) bandwidth_control_rules.BandwidthControlRules {
@@ -427,7 +270,7 @@ func expandBandwdithControlRules(d *schema.ResourceData) bandwidth_control_rules
 		Protocols:        SetToStringList(d, "protocols"),
 		BandwidthClasses: expandIDNameExtensionsSet(d, "bandwidth_classes"),
 		Labels:           expandIDNameExtensionsSet(d, "labels"),
-		Locations:        expandIDNameExtensionsSet(d, "locations"),
+		Locations:        expandIDNameExtensionsIDs(d, "location_ids"),
 		LocationGroups:   expandIDNameExtensionsSet(d, "location_groups"),
 		TimeWindows:      expandIDNameExtensionsSet(d, "time_windows"),
 	}
@@ -445,21 +288,3 @@ func filterOutBandwidthDefaultRule(rules []bandwidth_control_rules.BandwidthCont
 	}
 	return filteredRules
 }
-
-func currentBandwidthControlOrderVsRankWording(ctx context.Context, zClient *Client) string {
-	service := zClient.Service
-
-	list, err := bandwidth_control_rules.GetAll(ctx, service)
-	if err != nil {
-		return ""
-	}
-	result := ""
-	for i, r := range list {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("Rank %d VS Order %d", r.Rank, r.Order)
-
-	}
-	return result
-}
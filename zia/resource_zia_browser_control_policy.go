@@ -20,16 +20,7 @@ func resourceBrowserControlPolicy() *schema.Resource {
 		CreateContext: resourceBrowserControlPolicyCreate,
 		UpdateContext: resourceBrowserControlPolicyUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceBrowserControlPolicyRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("failed to read browser control policy import: %s", diags[0].Summary)
-				}
-				d.SetId("browser_settings")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("browser_settings", resourceBrowserControlPolicyRead),
 		Schema: map[string]*schema.Schema{
 			"plugin_check_frequency": {
 				Type:        schema.TypeString,
@@ -182,8 +173,8 @@ func resourceBrowserControlPolicyCreate(ctx context.Context, d *schema.ResourceD
 
 	time.Sleep(1 * time.Second)
 
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -267,8 +258,8 @@ func resourceBrowserControlPolicyUpdate(ctx context.Context, d *schema.ResourceD
 
 	time.Sleep(1 * time.Second)
 
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
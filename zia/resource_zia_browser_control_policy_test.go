@@ -14,9 +14,9 @@ func TestAccResourceBrowserControlPolicy_Basic(t *testing.T) {
 	resourceName := "zia_browser_control_policy.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckResourceBrowserControlPolicyDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckResourceBrowserControlPolicyDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create the resource with minimal configuration
 			{
@@ -6,29 +6,75 @@ import (
 	"log"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/common"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/saas_security_api/casb_dlp_rules"
 )
 
-var (
-	cloudCasbDlpRuleLock          sync.Mutex
-	cloudCasbDlpRuleStartingOrder int
-)
+// casbDlpRuleAdapter is the OrderedRuleAdapter of SaaS Security API DLP rules
+// of one rule type, which the API orders independently per type like Cloud App
+// Control rules.
+type casbDlpRuleAdapter struct {
+	service  *zscaler.Service
+	ruleType string
+}
+
+func (a casbDlpRuleAdapter) ResourceType() string { return "casb_dlp_rules:" + a.ruleType }
+func (casbDlpRuleAdapter) Ranked() bool           { return false }
+
+func (a casbDlpRuleAdapter) GetAll(ctx context.Context) ([]casb_dlp_rules.CasbDLPRules, error) {
+	return casb_dlp_rules.GetByRuleType(ctx, a.service, a.ruleType)
+}
+
+func (a casbDlpRuleAdapter) Get(ctx context.Context, id int) (*casb_dlp_rules.CasbDLPRules, error) {
+	return casb_dlp_rules.GetByRuleID(ctx, a.service, a.ruleType, id)
+}
+
+func (a casbDlpRuleAdapter) Create(ctx context.Context, rule *casb_dlp_rules.CasbDLPRules) (int, error) {
+	resp, err := casb_dlp_rules.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a casbDlpRuleAdapter) Update(ctx context.Context, id int, rule *casb_dlp_rules.CasbDLPRules) error {
+	_, err := casb_dlp_rules.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a casbDlpRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := casb_dlp_rules.Delete(ctx, a.service, a.ruleType, id)
+	return err
+}
+
+func (casbDlpRuleAdapter) Order(rule *casb_dlp_rules.CasbDLPRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (casbDlpRuleAdapter) SetOrder(rule *casb_dlp_rules.CasbDLPRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (casbDlpRuleAdapter) StripReadOnly(rule *casb_dlp_rules.CasbDLPRules) {
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.AccessControl = ""
+}
 
 func resourceCasbDlpRules() *schema.Resource {
-	return &schema.Resource{
+	return versionSchema(&schema.Resource{
 		CreateContext: resourceCasbDlpRulesCreate,
 		ReadContext:   resourceCasbDlpRulesRead,
 		UpdateContext: resourceCasbDlpRulesUpdate,
 		DeleteContext: resourceCasbDlpRulesDelete,
-		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		CustomizeDiff: customdiff.All(func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
 			contentLocation := d.Get("content_location").(string)
 			domains, domainsSet := d.GetOk("domains")
 
@@ -37,7 +83,7 @@ func resourceCasbDlpRules() *schema.Resource {
 			}
 
 			return nil
-		},
+		}, validateRuleOrder("zia_casb_dlp_rules")),
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
@@ -74,21 +120,19 @@ func resourceCasbDlpRules() *schema.Resource {
 					_ = d.Set("type", ruleType)
 				} else {
 					// If identifier is a name
-					resources, err := casb_dlp_rules.GetByRuleType(ctx, service, ruleType)
+					ruleID, err := resolveImportID(identifier, func() ([]importCandidate, error) {
+						resources, err := casb_dlp_rules.GetByRuleType(ctx, service, ruleType)
+						if err != nil {
+							return nil, err
+						}
+						return namedImportCandidates(resources), nil
+					})
 					if err != nil {
 						return nil, err
 					}
-					for _, r := range resources {
-						if r.Name == identifier {
-							d.SetId(strconv.Itoa(r.ID))
-							_ = d.Set("rule_id", r.ID)
-							_ = d.Set("type", ruleType)
-							break
-						}
-					}
-					if d.Id() == "" {
-						return nil, fmt.Errorf("couldn't find any cloud application rule with name '%s'", identifier)
-					}
+					d.SetId(strconv.Itoa(ruleID))
+					_ = d.Set("rule_id", ruleID)
+					_ = d.Set("type", ruleType)
 				}
 				return []*schema.ResourceData{d}, nil
 			},
@@ -278,7 +322,7 @@ func resourceCasbDlpRules() *schema.Resource {
 			"labels":                    setIDsSchemaTypeCustom(intPtr(1), "Name-ID pairs of rule labels associated with the rule"),
 			"dlp_engines":               setIDsSchemaTypeCustom(nil, "The list of DLP engines to which the DLP policy rule must be applied"),
 			"buckets":                   setIDsSchemaTypeCustom(nil, "The buckets for the Zscaler service to inspect for sensitive data"),
-			"groups":                    setIDsSchemaTypeCustom(nil, "Name-ID pairs of groups for which the rule is applied"),
+			"group_ids":                 setIntIDsSchemaType(nil, "IDs of groups for which the rule is applied"),
 			"departments":               setIDsSchemaTypeCustom(nil, "Name-ID pairs of departments for which rule must be applied"),
 			"users":                     setIDsSchemaTypeCustom(nil, "Name-ID pairs of users for which rule must be applied"),
 			"zscaler_incident_receiver": setSingleIDSchemaTypeCustom("The Zscaler Incident Receiver details"),
@@ -337,118 +381,18 @@ func resourceCasbDlpRules() *schema.Resource {
 				},
 			},
 		},
-	}
+	}, ruleIDBlocksV0)
 }
 
 func resourceCasbDlpRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandCasbDlpRules(d)
 	log.Printf("[INFO] Creating zia casb dlp rule\n%+v\n", req)
 
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-
-	for {
-		cloudCasbDlpRuleLock.Lock()
-		if cloudCasbDlpRuleStartingOrder == 0 {
-			rules, _ := casb_dlp_rules.GetByRuleType(ctx, service, req.Type)
-			for _, r := range rules {
-				if r.Order > cloudCasbDlpRuleStartingOrder {
-					cloudCasbDlpRuleStartingOrder = r.Order
-				}
-			}
-			if cloudCasbDlpRuleStartingOrder == 0 {
-				cloudCasbDlpRuleStartingOrder = 1
-			}
-		}
-		cloudCasbDlpRuleLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		order := req.Order
-		req.Order = cloudCasbDlpRuleStartingOrder
-
-		resp, err := casb_dlp_rules.Create(ctx, service, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				log.Printf("[INFO] Creating casb dlp rule name: %v, got INVALID_INPUT_ARGUMENT\n", req.Name)
-				if time.Since(start) < timeout {
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.Errorf("error creating resource: %v", err)
-		}
-
-		log.Printf("[INFO] Created zia casb dlp rule request. took:%s, without locking:%s,  ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-		reorderWithBeforeReorder(
-			OrderRule{Order: order, Rank: req.Rank},
-			resp.ID,
-			"casb_dlp_rules",
-			func() (map[int]OrderRule, error) {
-				list, err := casb_dlp_rules.GetByRuleType(ctx, service, req.Type)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(list))
-				for _, r := range list {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				rule, err := casb_dlp_rules.GetByRuleID(ctx, service, req.Type, id)
-				if err != nil {
-					return fmt.Errorf("failed to retrieve rule by ID: %v", err)
-				}
-				// Optional: avoid unnecessary updates if the current order is already correct
-				if rule.Order == order.Order {
-					return nil
-				}
-				// Strip read-only fields that cause "Request body is invalid" for predefined rules
-				rule.AccessControl = ""
-				rule.Order = order.Order
-				_, err = casb_dlp_rules.Update(ctx, service, id, rule)
-				if err != nil {
-					return fmt.Errorf("failed to update rule order: %v", err)
-				}
-				return nil
-			},
-			nil)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		if diags := resourceCasbDlpRulesRead(ctx, d, meta); diags.HasError() {
-			if time.Since(start) < timeout {
-				time.Sleep(10 * time.Second) // Wait before retrying
-				continue
-			}
-			return diags
-		}
-		markOrderRuleAsDone(resp.ID, "casb_dlp_rules")
-		waitForReorder("casb_dlp_rules")
-		break
-	}
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := createOrderedRule(ctx, d, zClient, casbDlpRuleAdapter{zClient.Service, req.Type}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
-
 	return resourceCasbDlpRulesRead(ctx, d, meta)
 }
 
@@ -464,7 +408,14 @@ func resourceCasbDlpRulesRead(ctx context.Context, d *schema.ResourceData, meta
 	if !ok || ruleType == "" {
 		return diag.FromErr(fmt.Errorf("no rule type is set"))
 	}
-	resp, err := casb_dlp_rules.GetByRuleID(ctx, service, ruleType, id)
+	resp, err := cachedRead(zClient, readCacheKind("zia_casb_dlp_rules", ruleType), id,
+		func() ([]casb_dlp_rules.CasbDLPRules, error) {
+			return casb_dlp_rules.GetByRuleType(ctx, service, ruleType)
+		},
+		func(r *casb_dlp_rules.CasbDLPRules) int { return r.ID },
+		func() (*casb_dlp_rules.CasbDLPRules, error) {
+			return casb_dlp_rules.GetByRuleID(ctx, service, ruleType, id)
+		})
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing casb dlp rule %s from state because it no longer exists in ZIA", d.Id())
@@ -502,7 +453,7 @@ func resourceCasbDlpRulesRead(ctx context.Context, d *schema.ResourceData, meta
 	_ = d.Set("without_content_inspection", resp.WithoutContentInspection)
 	_ = d.Set("include_entity_groups", resp.IncludeEntityGroups)
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -581,7 +532,6 @@ func resourceCasbDlpRulesRead(ctx context.Context, d *schema.ResourceData, meta
 
 func resourceCasbDlpRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -597,83 +547,9 @@ func resourceCasbDlpRulesUpdate(ctx context.Context, d *schema.ResourceData, met
 	log.Printf("[INFO] Updating zia cloud application control rule ID: %v\n", id)
 	req := expandCasbDlpRules(d)
 
-	if _, err := casb_dlp_rules.GetByRuleID(ctx, service, ruleType, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-
-	timeout := d.Timeout(schema.TimeoutUpdate)
-	start := time.Now()
-
-	for {
-		_, err := casb_dlp_rules.Update(ctx, service, id, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				log.Printf("[INFO] Updating casb dlp rule ID: %v, got INVALID_INPUT_ARGUMENT\n", id)
-				if time.Since(start) < timeout {
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.Errorf("error updating resource: %v", err)
-		}
-
-		reorderWithBeforeReorder(OrderRule{Order: req.Order, Rank: req.Rank}, req.ID, "casb_dlp_rules",
-			func() (map[int]OrderRule, error) {
-				list, err := casb_dlp_rules.GetByRuleType(ctx, service, req.Type)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(list))
-				for _, r := range list {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				rule, err := casb_dlp_rules.GetByRuleID(ctx, service, req.Type, id)
-				if err != nil {
-					return fmt.Errorf("failed to retrieve rule by ID: %v", err)
-				}
-				// Optional: avoid unnecessary updates if the current order is already correct
-				if rule.Order == order.Order {
-					return nil
-				}
-				// Strip read-only fields that cause "Request body is invalid" for predefined rules
-				rule.AccessControl = ""
-				rule.Order = order.Order
-				_, err = casb_dlp_rules.Update(ctx, service, id, rule)
-				if err != nil {
-					return fmt.Errorf("failed to update rule order: %v", err)
-				}
-				return nil
-			},
-			nil)
-
-		markOrderRuleAsDone(req.ID, "casb_dlp_rules")
-		waitForReorder("casb_dlp_rules")
-		break
+	if diags := updateOrderedRule(ctx, d, zClient, casbDlpRuleAdapter{zClient.Service, ruleType}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
 	return resourceCasbDlpRulesRead(ctx, d, meta)
 }
 
@@ -689,26 +565,8 @@ func resourceCasbDlpRulesDelete(ctx context.Context, d *schema.ResourceData, met
 	if !ok || ruleType == "" {
 		return diag.FromErr(fmt.Errorf("no rule type is set"))
 	}
-	log.Printf("[INFO] Deleting cloud application control rule ID: %v\n", (d.Id()))
 
-	if _, err := casb_dlp_rules.Delete(ctx, service, ruleType, id); err != nil {
-		return diag.FromErr(err)
-	}
-
-	d.SetId("")
-	log.Printf("[INFO] cloud application control rule deleted")
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, casbDlpRuleAdapter{service, ruleType}, id)
 }
 
 func expandCasbDlpRules(d *schema.ResourceData) casb_dlp_rules.CasbDLPRules {
@@ -746,7 +604,7 @@ func expandCasbDlpRules(d *schema.ResourceData) casb_dlp_rules.CasbDLPRules {
 		Domains:                      SetToStringList(d, "domains"),
 		FileTypes:                    SetToStringList(d, "file_types"),
 		Buckets:                      expandIDNameExtensionsSet(d, "buckets"),
-		Groups:                       expandIDNameExtensionsSet(d, "groups"),
+		Groups:                       expandIDNameExtensionsIDs(d, "group_ids"),
 		Departments:                  expandIDNameExtensionsSet(d, "departments"),
 		Users:                        expandIDNameExtensionsSet(d, "users"),
 		Labels:                       expandIDNameExtensionsSet(d, "labels"),
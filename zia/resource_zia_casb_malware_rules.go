@@ -6,20 +6,64 @@ import (
 	"log"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/saas_security_api/casb_malware_rules"
 )
 
-var (
-	cloudCasbMalwareRuleLock          sync.Mutex
-	cloudCasbMalwareRulestartingOrder int
-)
+// casbMalwareRuleAdapter is the OrderedRuleAdapter of SaaS Security API
+// malware rules of one rule type. Malware rules carry no admin rank.
+type casbMalwareRuleAdapter struct {
+	service  *zscaler.Service
+	ruleType string
+}
+
+func (a casbMalwareRuleAdapter) ResourceType() string { return "casb_malware_rules:" + a.ruleType }
+func (casbMalwareRuleAdapter) Ranked() bool           { return false }
+
+func (a casbMalwareRuleAdapter) GetAll(ctx context.Context) ([]casb_malware_rules.CasbMalwareRules, error) {
+	return casb_malware_rules.GetByRuleType(ctx, a.service, a.ruleType)
+}
+
+func (a casbMalwareRuleAdapter) Get(ctx context.Context, id int) (*casb_malware_rules.CasbMalwareRules, error) {
+	return casb_malware_rules.GetByRuleID(ctx, a.service, a.ruleType, id)
+}
+
+func (a casbMalwareRuleAdapter) Create(ctx context.Context, rule *casb_malware_rules.CasbMalwareRules) (int, error) {
+	resp, err := casb_malware_rules.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a casbMalwareRuleAdapter) Update(ctx context.Context, id int, rule *casb_malware_rules.CasbMalwareRules) error {
+	_, err := casb_malware_rules.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a casbMalwareRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := casb_malware_rules.Delete(ctx, a.service, a.ruleType, id)
+	return err
+}
+
+func (casbMalwareRuleAdapter) Order(rule *casb_malware_rules.CasbMalwareRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order}
+}
+
+func (casbMalwareRuleAdapter) SetOrder(rule *casb_malware_rules.CasbMalwareRules, order OrderRule) {
+	rule.Order = order.Order
+}
+
+func (casbMalwareRuleAdapter) StripReadOnly(rule *casb_malware_rules.CasbMalwareRules) {
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.AccessControl = ""
+}
 
 func resourceCasbMalwareRules() *schema.Resource {
 	return &schema.Resource{
@@ -27,6 +71,7 @@ func resourceCasbMalwareRules() *schema.Resource {
 		ReadContext:   resourceCasbMalwareRulesRead,
 		UpdateContext: resourceCasbMalwareRulesUpdate,
 		DeleteContext: resourceCasbMalwareRulesDelete,
+		CustomizeDiff: validateRuleOrder("zia_casb_malware_rules"),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
@@ -62,21 +107,19 @@ func resourceCasbMalwareRules() *schema.Resource {
 					_ = d.Set("type", ruleType)
 				} else {
 					// If identifier is a name
-					resources, err := casb_malware_rules.GetByRuleType(ctx, service, ruleType)
+					ruleID, err := resolveImportID(identifier, func() ([]importCandidate, error) {
+						resources, err := casb_malware_rules.GetByRuleType(ctx, service, ruleType)
+						if err != nil {
+							return nil, err
+						}
+						return namedImportCandidates(resources), nil
+					})
 					if err != nil {
 						return nil, err
 					}
-					for _, r := range resources {
-						if r.Name == identifier {
-							d.SetId(strconv.Itoa(r.ID))
-							_ = d.Set("rule_id", r.ID)
-							_ = d.Set("type", ruleType)
-							break
-						}
-					}
-					if d.Id() == "" {
-						return nil, fmt.Errorf("couldn't find any casb malware rule with name '%s'", identifier)
-					}
+					d.SetId(strconv.Itoa(ruleID))
+					_ = d.Set("rule_id", ruleID)
+					_ = d.Set("type", ruleType)
 				}
 				return []*schema.ResourceData{d}, nil
 			},
@@ -166,107 +209,13 @@ func resourceCasbMalwareRules() *schema.Resource {
 
 func resourceCasbMalwareRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandCasbMalwareRules(d)
 	log.Printf("[INFO] Creating zia casb malware rules\n%+v\n", req)
 
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-
-	for {
-		cloudCasbMalwareRuleLock.Lock()
-		if cloudCasbMalwareRulestartingOrder == 0 {
-			rules, _ := casb_malware_rules.GetByRuleType(ctx, service, req.Type)
-			for _, r := range rules {
-				if r.Order > cloudCasbMalwareRulestartingOrder {
-					cloudCasbMalwareRulestartingOrder = r.Order
-				}
-			}
-			if cloudCasbMalwareRulestartingOrder == 0 {
-				cloudCasbMalwareRulestartingOrder = 1
-			}
-		}
-		cloudCasbMalwareRuleLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		order := req.Order
-		req.Order = cloudCasbMalwareRulestartingOrder
-
-		resp, err := casb_malware_rules.Create(ctx, service, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				log.Printf("[INFO] Creating casb malware rules name: %v, got INVALID_INPUT_ARGUMENT\n", req.Name)
-				if time.Since(start) < timeout {
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.Errorf("error creating resource: %v", err)
-		}
-
-		log.Printf("[INFO] Created zia casb malware rules request. took:%s, without locking:%s,  ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-		reorder(OrderRule{Order: order, Rank: 0}, resp.ID, "casb_malware_rules", func() (map[int]OrderRule, error) {
-			list, err := casb_malware_rules.GetByRuleType(ctx, service, req.Type)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(list))
-			for _, r := range list {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: 0}
-			}
-			return m, nil
-		}, func(id int, order OrderRule) error {
-			rule, err := casb_malware_rules.GetByRuleID(ctx, service, req.Type, id)
-			if err != nil {
-				return fmt.Errorf("failed to retrieve rule by ID: %v", err)
-			}
-			// Optional: avoid unnecessary updates if the current order is already correct
-			if rule.Order == order.Order {
-				return nil
-			}
-			// Strip read-only fields that cause "Request body is invalid" for predefined rules
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			_, err = casb_malware_rules.Update(ctx, service, id, rule)
-			if err != nil {
-				return fmt.Errorf("failed to update rule order: %v", err)
-			}
-			return nil
-		})
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		if diags := resourceCasbMalwareRulesRead(ctx, d, meta); diags.HasError() {
-			if time.Since(start) < timeout {
-				time.Sleep(10 * time.Second)
-				continue
-			}
-			return diags
-		}
-		markOrderRuleAsDone(resp.ID, "casb_malware_rules")
-		waitForReorder("casb_malware_rules")
-		break
-	}
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := createOrderedRule(ctx, d, zClient, casbMalwareRuleAdapter{zClient.Service, req.Type}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
-
 	return resourceCasbMalwareRulesRead(ctx, d, meta)
 }
 
@@ -282,7 +231,14 @@ func resourceCasbMalwareRulesRead(ctx context.Context, d *schema.ResourceData, m
 	if !ok || ruleType == "" {
 		return diag.FromErr(fmt.Errorf("no rule type is set"))
 	}
-	resp, err := casb_malware_rules.GetByRuleID(ctx, service, ruleType, id)
+	resp, err := cachedRead(zClient, readCacheKind("zia_casb_malware_rules", ruleType), id,
+		func() ([]casb_malware_rules.CasbMalwareRules, error) {
+			return casb_malware_rules.GetByRuleType(ctx, service, ruleType)
+		},
+		func(r *casb_malware_rules.CasbMalwareRules) int { return r.ID },
+		func() (*casb_malware_rules.CasbMalwareRules, error) {
+			return casb_malware_rules.GetByRuleID(ctx, service, ruleType, id)
+		})
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing casb malware rules %s from state because it no longer exists in ZIA", d.Id())
@@ -326,7 +282,6 @@ func resourceCasbMalwareRulesRead(ctx context.Context, d *schema.ResourceData, m
 
 func resourceCasbMalwareRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -342,86 +297,14 @@ func resourceCasbMalwareRulesUpdate(ctx context.Context, d *schema.ResourceData,
 	log.Printf("[INFO] Updating zia casb malware rules ID: %v\n", id)
 	req := expandCasbMalwareRules(d)
 
-	if _, err := casb_malware_rules.GetByRuleID(ctx, service, ruleType, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
+	if diags := updateOrderedRule(ctx, d, zClient, casbMalwareRuleAdapter{zClient.Service, ruleType}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
-	timeout := d.Timeout(schema.TimeoutUpdate)
-	start := time.Now()
-
-	for {
-		_, err := casb_malware_rules.Update(ctx, service, id, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				log.Printf("[INFO] Updating casb malware rules ID: %v, got INVALID_INPUT_ARGUMENT\n", id)
-				if time.Since(start) < timeout {
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.Errorf("error updating resource: %v", err)
-		}
-
-		reorder(OrderRule{Order: req.Order, Rank: 0}, req.ID, "casb_malware_rules", func() (map[int]OrderRule, error) {
-			list, err := casb_malware_rules.GetByRuleType(ctx, service, req.Type)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(list))
-			for _, r := range list {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: 0}
-			}
-			return m, nil
-		}, func(id int, order OrderRule) error {
-			rule, err := casb_malware_rules.GetByRuleID(ctx, service, req.Type, id)
-			if err != nil {
-				return fmt.Errorf("failed to retrieve rule by ID: %v", err)
-			}
-			// Optional: avoid unnecessary updates if the current order is already correct
-			if rule.Order == order.Order {
-				return nil
-			}
-			// Strip read-only fields that cause "Request body is invalid" for predefined rules
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			_, err = casb_malware_rules.Update(ctx, service, id, rule)
-			if err != nil {
-				return fmt.Errorf("failed to update rule order: %v", err)
-			}
-			return nil
-		})
-
-		markOrderRuleAsDone(req.ID, "casb_malware_rules")
-		waitForReorder("casb_malware_rules")
-		break
-	}
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
 	return resourceCasbMalwareRulesRead(ctx, d, meta)
 }
 
 func resourceCasbMalwareRulesDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -431,26 +314,8 @@ func resourceCasbMalwareRulesDelete(ctx context.Context, d *schema.ResourceData,
 	if !ok || ruleType == "" {
 		return diag.FromErr(fmt.Errorf("no rule type is set"))
 	}
-	log.Printf("[INFO] Deleting casb malware rules ID: %v\n", (d.Id()))
-
-	if _, err := casb_malware_rules.Delete(ctx, service, ruleType, id); err != nil {
-		return diag.FromErr(err)
-	}
 
-	d.SetId("")
-	log.Printf("[INFO] casb malware rules deleted")
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, casbMalwareRuleAdapter{zClient.Service, ruleType}, id)
 }
 
 func expandCasbMalwareRules(d *schema.ResourceData) casb_malware_rules.CasbMalwareRules {
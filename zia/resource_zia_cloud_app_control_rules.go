@@ -4,26 +4,88 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/cloudappcontrol"
 )
 
-var (
-	cloudAppRuleLock sync.Mutex
-	// Cloud App Control rules are ordered independently per rule type
-	// (each type has its own 1..N order sequence in the API), so the
-	// starting-order seed must be tracked per type as well.
-	cloudAppRuleStartingOrder = map[string]int{}
-)
+// cloudAppRuleAdapter is the OrderedRuleAdapter of Cloud App Control rules of
+// one rule type. Each type has its own 1..N order sequence in the API, so the
+// starting order and the engine are scoped per type as well.
+type cloudAppRuleAdapter struct {
+	service  *zscaler.Service
+	ruleType string
+}
+
+func (a cloudAppRuleAdapter) ResourceType() string { return cloudAppRuleResourceType(a.ruleType) }
+func (cloudAppRuleAdapter) Ranked() bool           { return true }
+
+func (a cloudAppRuleAdapter) GetAll(ctx context.Context) ([]cloudappcontrol.WebApplicationRules, error) {
+	return cloudappcontrol.GetByRuleType(ctx, a.service, a.ruleType)
+}
+
+func (a cloudAppRuleAdapter) Get(ctx context.Context, id int) (*cloudappcontrol.WebApplicationRules, error) {
+	return cloudappcontrol.GetByRuleID(ctx, a.service, a.ruleType, id)
+}
+
+func (a cloudAppRuleAdapter) Create(ctx context.Context, rule *cloudappcontrol.WebApplicationRules) (int, error) {
+	resp, err := cloudappcontrol.Create(ctx, a.service, a.ruleType, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a cloudAppRuleAdapter) Update(ctx context.Context, id int, rule *cloudappcontrol.WebApplicationRules) error {
+	_, err := cloudappcontrol.Update(ctx, a.service, a.ruleType, id, rule)
+	return err
+}
+
+func (a cloudAppRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := cloudappcontrol.Delete(ctx, a.service, a.ruleType, id)
+	return err
+}
+
+func (cloudAppRuleAdapter) Order(rule *cloudappcontrol.WebApplicationRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (cloudAppRuleAdapter) SetOrder(rule *cloudappcontrol.WebApplicationRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (cloudAppRuleAdapter) StripReadOnly(rule *cloudappcontrol.WebApplicationRules) {
+	// to avoid the STALE_CONFIGURATION_ERROR
+	rule.LastModifiedTime = 0
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.Predefined = false
+	rule.AccessControl = ""
+}
+
+func (a cloudAppRuleAdapter) GetByName(ctx context.Context, name string) (*cloudappcontrol.WebApplicationRules, error) {
+	rules, err := cloudappcontrol.GetByRuleType(ctx, a.service, a.ruleType)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		if strings.EqualFold(rules[i].Name, name) {
+			return &rules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no %s rule named %q", a.ruleType, name)
+}
+
+func (cloudAppRuleAdapter) IsPredefined(rule *cloudappcontrol.WebApplicationRules) bool {
+	return rule.Predefined
+}
 
 // cloudAppRuleResourceType returns the reorder-registry key for a Cloud App
 // Control rule type. The key is type-scoped because the API orders rules
@@ -34,12 +96,12 @@ func cloudAppRuleResourceType(ruleType string) string {
 }
 
 func resourceCloudAppControlRules() *schema.Resource {
-	return &schema.Resource{
+	return withAdoptPredefined(versionSchema(&schema.Resource{
 		CreateContext: resourceCloudAppControlRulesCreate,
 		ReadContext:   resourceCloudAppControlRulesRead,
 		UpdateContext: resourceCloudAppControlRulesUpdate,
 		DeleteContext: resourceCloudAppControlRulesDelete,
-		CustomizeDiff: validateActionsCustomizeDiff,
+		CustomizeDiff: customdiff.All(validateActionsCustomizeDiff, validateRuleOrder("zia_cloud_app_control_rule")),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
@@ -75,21 +137,19 @@ func resourceCloudAppControlRules() *schema.Resource {
 					_ = d.Set("type", ruleType)
 				} else {
 					// If identifier is a name
-					resources, err := cloudappcontrol.GetByRuleType(ctx, service, ruleType)
+					ruleID, err := resolveImportID(identifier, func() ([]importCandidate, error) {
+						resources, err := cloudappcontrol.GetByRuleType(ctx, service, ruleType)
+						if err != nil {
+							return nil, err
+						}
+						return namedImportCandidates(resources), nil
+					})
 					if err != nil {
 						return nil, err
 					}
-					for _, r := range resources {
-						if r.Name == identifier {
-							d.SetId(strconv.Itoa(r.ID))
-							_ = d.Set("rule_id", r.ID)
-							_ = d.Set("type", ruleType)
-							break
-						}
-					}
-					if d.Id() == "" {
-						return nil, fmt.Errorf("couldn't find any cloud application rule with name '%s'", identifier)
-					}
+					d.SetId(strconv.Itoa(ruleID))
+					_ = d.Set("rule_id", ruleID)
+					_ = d.Set("type", ruleType)
 				}
 				return []*schema.ResourceData{d}, nil
 			},
@@ -230,9 +290,9 @@ func resourceCloudAppControlRules() *schema.Resource {
 			"devices":                setIDsSchemaTypeCustom(nil, "Name-ID pairs of devices for which rule must be applied."),
 			"time_windows":           setIDsSchemaTypeCustom(nil, "Name-ID pairs of time interval during which rule must be enforced."),
 			"labels":                 setIDsSchemaTypeCustom(nil, "The URL Filtering rule's label."),
-			"locations":              setIDsSchemaTypeCustom(nil, "Name-ID pairs of locations for which rule must be applied"),
+			"location_ids":           setIntIDsSchemaType(nil, "IDs of locations for which rule must be applied"),
 			"location_groups":        setIDsSchemaTypeCustom(nil, "Name-ID pairs of the location groups to which the rule must be applied."),
-			"groups":                 setIDsSchemaTypeCustom(nil, "Name-ID pairs of groups for which rule must be applied"),
+			"group_ids":              setIntIDsSchemaType(nil, "IDs of groups for which rule must be applied"),
 			"departments":            setIDsSchemaTypeCustom(nil, "Name-ID pairs of departments for which rule must be applied"),
 			"users":                  setIDsSchemaTypeCustom(nil, "Name-ID pairs of users for which rule must be applied"),
 			"tenancy_profile_ids":    setIDsSchemaTypeCustom(nil, "Name-ID pairs of groups for which rule must be applied"),
@@ -243,121 +303,19 @@ func resourceCloudAppControlRules() *schema.Resource {
 			"user_agent_types":       getUserAgentTypes(),
 			"type":                   getAppControlType(),
 		},
-	}
+	}, ruleIDBlocksV0))
 }
 
 func resourceCloudAppControlRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandCloudAppControlRules(d)
 	log.Printf("[INFO] Creating zia cloud app control rule\n%+v\n", req)
 
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-
-	for {
-		cloudAppRuleLock.Lock()
-		if cloudAppRuleStartingOrder[req.Type] == 0 {
-			rules, _ := cloudappcontrol.GetByRuleType(ctx, service, req.Type)
-			for _, r := range rules {
-				if r.Order > cloudAppRuleStartingOrder[req.Type] {
-					cloudAppRuleStartingOrder[req.Type] = r.Order
-				}
-			}
-			if cloudAppRuleStartingOrder[req.Type] == 0 {
-				cloudAppRuleStartingOrder[req.Type] = 1
-			}
-		}
-		cloudAppRuleLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		intendedOrder := req.Order
-		intendedRank := req.Rank
-		if intendedRank < 7 {
-			// always start rank 7 rules at the next available order after all ranked rules
-			req.Rank = 7
-		}
-		req.Order = cloudAppRuleStartingOrder[req.Type]
-
-		resp, err := cloudappcontrol.Create(ctx, service, req.Type, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				log.Printf("[INFO] Creating cloud app control rule name: %v, got INVALID_INPUT_ARGUMENT\n", req.Name)
-				if time.Since(start) < timeout {
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.Errorf("error creating resource: %v", err)
-		}
-
-		log.Printf("[INFO] Created zia cloud app control rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-		// Key the reorder registry per rule type: the getCurrent/updateOrder
-		// callbacks below are scoped to req.Type's endpoint, so rules of a
-		// different type registered under the same key would never be visible
-		// to this cycle's GetByRuleType and would be skipped forever.
-		resourceType := cloudAppRuleResourceType(req.Type)
-
-		reorderWithBeforeReorder(
-			OrderRule{Order: intendedOrder, Rank: intendedRank},
-			resp.ID,
-			resourceType,
-			func() (map[int]OrderRule, error) {
-				list, err := cloudappcontrol.GetByRuleType(ctx, service, req.Type)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(list))
-				for _, r := range list {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				// Custom updateOrder that handles predefined rules
-				rule, err := cloudappcontrol.GetByRuleID(ctx, service, req.Type, id)
-				if err != nil {
-					return err
-				}
-				// to avoid the STALE_CONFIGURATION_ERROR
-				rule.LastModifiedTime = 0
-				// Strip read-only fields that cause "Request body is invalid" for predefined rules
-				rule.Predefined = false
-				rule.AccessControl = ""
-				rule.Order = order.Order
-				rule.Rank = order.Rank
-				_, err = cloudappcontrol.Update(ctx, service, req.Type, id, rule)
-				return err
-			},
-			nil, // Remove beforeReorder function to avoid adding too many rules to the map
-		)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		markOrderRuleAsDone(resp.ID, resourceType)
-		waitForReorder(resourceType)
-
-		// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-		if shouldActivate() {
-			// Sleep for 2 seconds before potentially triggering the activation
-			time.Sleep(2 * time.Second)
-			if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-				return diag.FromErr(activationErr)
-			}
-		} else {
-			log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-		}
-
-		return resourceCloudAppControlRulesRead(ctx, d, meta)
+	if diags := createOrderedRule(ctx, d, zClient, cloudAppRuleAdapter{zClient.Service, req.Type}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
+	return resourceCloudAppControlRulesRead(ctx, d, meta)
 }
 
 func resourceCloudAppControlRulesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -372,7 +330,14 @@ func resourceCloudAppControlRulesRead(ctx context.Context, d *schema.ResourceDat
 	if !ok || ruleType == "" {
 		return diag.FromErr(fmt.Errorf("no rule type is set"))
 	}
-	resp, err := cloudappcontrol.GetByRuleID(ctx, service, ruleType, id)
+	resp, err := cachedRead(zClient, readCacheKind("zia_cloud_app_control_rule", ruleType), id,
+		func() ([]cloudappcontrol.WebApplicationRules, error) {
+			return cloudappcontrol.GetByRuleType(ctx, service, ruleType)
+		},
+		func(r *cloudappcontrol.WebApplicationRules) int { return r.ID },
+		func() (*cloudappcontrol.WebApplicationRules, error) {
+			return cloudappcontrol.GetByRuleID(ctx, service, ruleType, id)
+		})
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing cloud app control rule %s from state because it no longer exists in ZIA", d.Id())
@@ -436,7 +401,7 @@ func resourceCloudAppControlRulesRead(ctx context.Context, d *schema.ResourceDat
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -455,7 +420,7 @@ func resourceCloudAppControlRulesRead(ctx context.Context, d *schema.ResourceDat
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -463,7 +428,7 @@ func resourceCloudAppControlRulesRead(ctx context.Context, d *schema.ResourceDat
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -492,7 +457,6 @@ func resourceCloudAppControlRulesRead(ctx context.Context, d *schema.ResourceDat
 
 func resourceCloudAppControlRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -508,81 +472,9 @@ func resourceCloudAppControlRulesUpdate(ctx context.Context, d *schema.ResourceD
 	log.Printf("[INFO] Updating zia cloud application control rule ID: %v\n", id)
 	req := expandCloudAppControlRules(d)
 
-	if _, err := cloudappcontrol.GetByRuleID(ctx, service, ruleType, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-
-	existingRules, err := cloudappcontrol.GetByRuleType(ctx, service, req.Type)
-	if err != nil {
-		log.Printf("[ERROR] error getting all cloud app control rules: %v", err)
+	if diags := updateOrderedRule(ctx, d, zClient, cloudAppRuleAdapter{zClient.Service, ruleType}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-	sort.Slice(existingRules, func(i, j int) bool {
-		return existingRules[i].Rank < existingRules[j].Rank || (existingRules[i].Rank == existingRules[j].Rank && existingRules[i].Order < existingRules[j].Order)
-	})
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	nextAvailableOrder := existingRules[len(existingRules)-1].Order
-	// always start rank 7 rules at the next available order after all ranked rules
-	req.Rank = 7
-
-	req.Order = nextAvailableOrder
-
-	_, err = cloudappcontrol.Update(ctx, service, ruleType, id, &req)
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-	}
-
-	resourceType := cloudAppRuleResourceType(req.Type)
-	reorderWithBeforeReorder(OrderRule{Order: intendedOrder, Rank: intendedRank}, req.ID, resourceType,
-		func() (map[int]OrderRule, error) {
-			list, err := cloudappcontrol.GetByRuleType(ctx, service, req.Type)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(list))
-			for _, r := range list {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := cloudappcontrol.GetByRuleID(ctx, service, req.Type, id)
-			if err != nil {
-				return err
-			}
-			// to avoid the STALE_CONFIGURATION_ERROR
-			rule.LastModifiedTime = 0
-			// Strip read-only fields that cause "Request body is invalid" for predefined rules
-			rule.Predefined = false
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = cloudappcontrol.Update(ctx, service, req.Type, id, rule)
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	markOrderRuleAsDone(req.ID, resourceType)
-	waitForReorder(resourceType)
-
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
 	return resourceCloudAppControlRulesRead(ctx, d, meta)
 }
 
@@ -598,26 +490,8 @@ func resourceCloudAppControlRulesDelete(ctx context.Context, d *schema.ResourceD
 	if !ok || ruleType == "" {
 		return diag.FromErr(fmt.Errorf("no rule type is set"))
 	}
-	log.Printf("[INFO] Deleting cloud application control rule ID: %v\n", (d.Id()))
-
-	if _, err := cloudappcontrol.Delete(ctx, service, ruleType, id); err != nil {
-		return diag.FromErr(err)
-	}
-
-	d.SetId("")
-	log.Printf("[INFO] cloud application control rule deleted")
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
 
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, cloudAppRuleAdapter{service, ruleType}, id)
 }
 
 func expandCloudAppControlRules(d *schema.ResourceData) cloudappcontrol.WebApplicationRules {
@@ -692,8 +566,8 @@ func expandCloudAppControlRules(d *schema.ResourceData) cloudappcontrol.WebAppli
 		UserRiskScoreLevels:  SetToStringList(d, "user_risk_score_levels"),
 		DeviceTrustLevels:    SetToStringList(d, "device_trust_levels"),
 		UserAgentTypes:       SetToStringList(d, "user_agent_types"),
-		Locations:            expandIDNameExtensionsSet(d, "locations"),
-		Groups:               expandIDNameExtensionsSet(d, "groups"),
+		Locations:            expandIDNameExtensionsIDs(d, "location_ids"),
+		Groups:               expandIDNameExtensionsIDs(d, "group_ids"),
 		Departments:          expandIDNameExtensionsSet(d, "departments"),
 		Users:                expandIDNameExtensionsSet(d, "users"),
 		TimeWindows:          expandIDNameExtensionsSet(d, "time_windows"),
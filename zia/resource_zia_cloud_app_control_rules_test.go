@@ -25,9 +25,9 @@ func TestAccResourceCloudAppControlRules_Basic(t *testing.T) {
 	ruleLabelHCL := testAccCheckRuleLabelsConfigure(ruleLabelTypeAndName, "tf-acc-test-"+ruleLabelGeneratedName, variable.RuleLabelDescription)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckCloudAppControlRulesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckCloudAppControlRulesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckCloudAppControlRulesConfigure(resourceTypeAndName, generatedName, generatedName, variable.CloudAppControlRuleDescription, variable.CloudAppControlRuleState, ruleLabelTypeAndName, ruleLabelHCL),
@@ -209,9 +209,7 @@ resource "%s" "%s" {
 	location_groups {
 		id = [data.zia_location_groups.sdwan_can.id]
 	}
-	groups {
-		id = [data.zia_group_management.engineering.id]
-	}
+	group_ids = [data.zia_group_management.engineering.id]
 	departments {
 		id = [data.zia_department_management.engineering.id]
 	}
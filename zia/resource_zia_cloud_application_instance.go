@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/cloud_app_instances"
 )
@@ -19,27 +19,17 @@ func resourceCloudApplicationInstance() *schema.Resource {
 		ReadContext:   resourceCloudApplicationInstanceRead,
 		UpdateContext: resourceCloudApplicationInstanceUpdate,
 		DeleteContext: resourceCloudApplicationInstanceDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("instance_id", idInt)
-				} else {
-					resp, err := cloud_app_instances.GetInstanceByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.InstanceID))
-						_ = d.Set("instance_id", resp.InstanceID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByKey("instance_id", func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+			instances, err := cloud_app_instances.GetAll(ctx, service)
+			if err != nil {
+				return nil, err
+			}
+			candidates := make([]importCandidate, len(instances))
+			for i, instance := range instances {
+				candidates[i] = importCandidate{id: instance.InstanceID, name: instance.InstanceName}
+			}
+			return candidates, nil
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -114,9 +104,7 @@ func resourceCloudApplicationInstanceCreate(ctx context.Context, d *schema.Resou
 	_ = d.Set("instance_id", resp.InstanceID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -180,9 +168,7 @@ func resourceCloudApplicationInstanceUpdate(ctx context.Context, d *schema.Resou
 	}
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -210,9 +196,7 @@ func resourceCloudApplicationInstanceDelete(ctx context.Context, d *schema.Resou
 	log.Printf("[INFO] zia cloud application instance deleted")
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
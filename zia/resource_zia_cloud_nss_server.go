@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -21,26 +21,10 @@ func resourceCloudNSSFeed() *schema.Resource {
 		ReadContext:   resourceCloudNSSFeedRead,
 		UpdateContext: resourceCloudNSSFeedUpdate,
 		DeleteContext: resourceCloudNSSFeedDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("nss_id", idInt)
-				} else {
-					resp, err := cloudnss.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("nss_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
+		Importer:      importByName("nss_id", cloudnss.GetAll),
+		CustomizeDiff: validateCloudNSSFeedFormat,
+		ValidateRawResourceConfigFuncs: []schema.ValidateRawResourceConfigFunc{
+			warnCloudNSSFeedFields,
 		},
 
 		Schema: map[string]*schema.Schema{
@@ -125,9 +109,12 @@ func resourceCloudNSSFeed() *schema.Resource {
 				https://help.zscaler.com/zia/cloud-nanolog-streaming-service-nss#/nssFeeds-get`,
 			},
 			"custom_escaped_character": {
-				Type:        schema.TypeSet,
-				Optional:    true,
-				Elem:        &schema.Schema{Type: schema.TypeString},
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringMatch(nssEscapedCharacterPattern, "must be ASCII_<code> with a code from 0 to 127"),
+				},
 				Description: "Characters that need to be encoded using hex when they appear in URL, Host, or Referrer",
 			},
 			"eps_rate_limit": {
@@ -839,6 +826,42 @@ func resourceCloudNSSFeed() *schema.Resource {
 	}
 }
 
+// validateCloudNSSFeedFormat parses feed_output_format at plan time.
+func validateCloudNSSFeedFormat(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	format := d.Get("feed_output_format").(string)
+	if !d.NewValueKnown("feed_output_format") || format == "" {
+		return nil
+	}
+	if _, err := parseNSSFeedFormat(format); err != nil {
+		return fmt.Errorf("invalid feed_output_format: %w", err)
+	}
+	return nil
+}
+
+// warnCloudNSSFeedFields warns about the fields of feed_output_format that
+// the chosen nss_log_type is not known to have. CustomizeDiff cannot return
+// warnings, so this runs when Terraform validates the configuration.
+func warnCloudNSSFeedFields(_ context.Context, req schema.ValidateResourceConfigFuncRequest, resp *schema.ValidateResourceConfigFuncResponse) {
+	config := req.RawConfig
+	if config.IsNull() || !config.IsKnown() {
+		return
+	}
+	format, logType := config.GetAttr("feed_output_format"), config.GetAttr("nss_log_type")
+	if format.IsNull() || !format.IsKnown() || logType.IsNull() || !logType.IsKnown() {
+		return
+	}
+	tokens, err := parseNSSFeedFormat(format.AsString())
+	if err != nil {
+		// Reported by validateCloudNSSFeedFormat.
+		return
+	}
+	warnings := nssFeedFieldWarnings(tokens, logType.AsString())
+	for i := range warnings {
+		warnings[i].AttributePath = cty.GetAttrPath("feed_output_format")
+	}
+	resp.Diagnostics = append(resp.Diagnostics, warnings...)
+}
+
 func resourceCloudNSSFeedCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient, ok := meta.(*Client)
 	if !ok {
@@ -859,9 +882,7 @@ func resourceCloudNSSFeedCreate(ctx context.Context, d *schema.ResourceData, met
 	_ = d.Set("nss_id", resp.ID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -1086,9 +1107,7 @@ func resourceCloudNSSFeedUpdate(ctx context.Context, d *schema.ResourceData, met
 	}
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -1116,9 +1135,7 @@ func resourceCloudNSSFeedDelete(ctx context.Context, d *schema.ResourceData, met
 	log.Printf("[INFO] zia cloud nss feed deleted")
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/trafficforwarding/dc_exclusions"
 )
@@ -20,27 +20,20 @@ func resourceDCExclusions() *schema.Resource {
 		ReadContext:   resourceDCExclusionsRead,
 		UpdateContext: resourceDCExclusionsUpdate,
 		DeleteContext: resourceDCExclusionsDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, strconv.IntSize)
-				if parseIDErr == nil {
-					_ = d.Set("datacenter_id", int(idInt))
-				} else {
-					resp, err := dc_exclusions.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.DcID))
-						_ = d.Set("datacenter_id", resp.DcID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
+		Importer: importByKey("datacenter_id", func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+			exclusions, err := dc_exclusions.GetAll(ctx, service)
+			if err != nil {
+				return nil, err
+			}
+			candidates := make([]importCandidate, len(exclusions))
+			for i, exclusion := range exclusions {
+				candidates[i] = importCandidate{id: exclusion.DcID}
+				if exclusion.DcName != nil {
+					candidates[i].name = exclusion.DcName.Name
 				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+			}
+			return candidates, nil
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -119,9 +112,7 @@ func resourceDCExclusionsCreate(ctx context.Context, d *schema.ResourceData, met
 	_ = d.Set("datacenter_id", resp.DcID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -204,8 +195,7 @@ func resourceDCExclusionsUpdate(ctx context.Context, d *schema.ResourceData, met
 		return diag.FromErr(err)
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -236,8 +226,7 @@ func resourceDCExclusionsDelete(ctx context.Context, d *schema.ResourceData, met
 	d.SetId("")
 	log.Printf("[INFO] zia_dc_exclusions %d deleted", dcID)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -25,9 +25,9 @@ func TestAccResourceDCExclusions(t *testing.T) {
 	updatedDescription := variable.DCExclusionsDescription + "-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckDCExclusionsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckDCExclusionsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDCExclusionsConfigure(resourceTypeAndName, initialDescription),
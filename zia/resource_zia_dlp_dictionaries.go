@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/common"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/dlp/dlpdictionaries"
@@ -22,27 +22,7 @@ func resourceDLPDictionaries() *schema.Resource {
 		UpdateContext: resourceDLPDictionariesUpdate,
 		DeleteContext: resourceDLPDictionariesDelete,
 		// CustomizeDiff: validateDLPHierarchicalIdentifiersDiff,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("dictionary_id", idInt)
-				} else {
-					resp, err := dlpdictionaries.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("dictionary_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByKey("dictionary_id", dlpDictionaryImportCandidates),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -300,6 +280,24 @@ func resourceDLPDictionaries() *schema.Resource {
 	}
 }
 
+// dlpDictionaryImportCandidates lists dictionaries by name and by
+// "<dictionary_name>/<phrase>" for each of their phrases, which tells apart
+// dictionaries that share a name.
+func dlpDictionaryImportCandidates(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+	dictionaries, err := dlpdictionaries.GetAll(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]importCandidate, len(dictionaries))
+	for i, dictionary := range dictionaries {
+		candidates[i] = importCandidate{id: dictionary.ID, name: dictionary.Name}
+		for _, phrase := range dictionary.Phrases {
+			candidates[i].keys = append(candidates[i].keys, dictionary.Name+"/"+phrase.Phrase)
+		}
+	}
+	return candidates, nil
+}
+
 func resourceDLPDictionariesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
 	service := zClient.Service
@@ -318,9 +316,7 @@ func resourceDLPDictionariesCreate(ctx context.Context, d *schema.ResourceData,
 	d.SetId(strconv.Itoa(resp.ID))
 	_ = d.Set("dictionary_id", resp.ID)
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -415,9 +411,7 @@ func resourceDLPDictionariesUpdate(ctx context.Context, d *schema.ResourceData,
 		return diag.FromErr(err)
 	}
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -444,9 +438,7 @@ func resourceDLPDictionariesDelete(ctx context.Context, d *schema.ResourceData,
 	d.SetId("")
 	log.Printf("[INFO] dlp dictionary deleted")
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -24,9 +24,9 @@ func TestAccResourceDLPDictionariesBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckDLPDictionariesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckDLPDictionariesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDLPDictionariesConfigure(resourceTypeAndName, initialName, variable.DLPDictionaryDescription),
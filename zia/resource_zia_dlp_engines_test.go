@@ -24,9 +24,9 @@ func TestAccResourceDLPEnginesBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckDLPEnginesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckDLPEnginesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDLPEnginesConfigure(resourceTypeAndName, initialName, generatedName, variable.DLPCustomEngine),
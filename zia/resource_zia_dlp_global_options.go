@@ -17,16 +17,7 @@ func resourceDLPGlobalOptions() *schema.Resource {
 		CreateContext: resourceDLPGlobalOptionsCreate,
 		UpdateContext: resourceDLPGlobalOptionsUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceDLPGlobalOptionsRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("failed to read atp malware inspection import: %s", diags[0].Summary)
-				}
-				d.SetId("dlp_global_options")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("dlp_global_options", resourceDLPGlobalOptionsRead),
 		Schema: map[string]*schema.Schema{
 			"applications": {
 				Type:     schema.TypeSet,
@@ -113,8 +104,8 @@ func resourceDLPGlobalOptionsCreate(ctx context.Context, d *schema.ResourceData,
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -175,8 +166,8 @@ func resourceDLPGlobalOptionsUpdate(ctx context.Context, d *schema.ResourceData,
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
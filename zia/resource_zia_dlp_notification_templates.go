@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -20,27 +19,7 @@ func resourceDLPNotificationTemplates() *schema.Resource {
 		ReadContext:   resourceDLPNotificationTemplatesRead,
 		UpdateContext: resourceDLPNotificationTemplatesUpdate,
 		DeleteContext: resourceDLPNotificationTemplatesDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("template_id", idInt)
-				} else {
-					resp, err := dlp_notification_templates.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("template_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importByName("template_id", dlp_notification_templates.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -113,9 +92,7 @@ func resourceDLPNotificationTemplatesCreate(ctx context.Context, d *schema.Resou
 	_ = d.Set("template_id", resp.ID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -180,9 +157,7 @@ func resourceDLPNotificationTemplatesUpdate(ctx context.Context, d *schema.Resou
 		return diag.FromErr(err)
 	}
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -209,9 +184,7 @@ func resourceDLPNotificationTemplatesDelete(ctx context.Context, d *schema.Resou
 	d.SetId("")
 	log.Printf("[INFO] dlp notification template deleted")
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -24,9 +24,9 @@ func TestAccResourceDLPNotificationTemplatesBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckDLPNotificationTemplateDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckDLPNotificationTemplateDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDLPNotificationTemplateConfigure(resourceTypeAndName, initialName),
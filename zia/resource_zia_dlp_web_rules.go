@@ -6,30 +6,97 @@ import (
 	"log"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/common"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/dlp/dlp_web_rules"
 )
 
-var (
-	dlpWebRulesLock             sync.Mutex
-	dlpWebStartingOrder         int
-	dlpWebSubRulesStartingOrder map[int]int = make(map[int]int)
-)
+// dlpWebRuleAdapter is the OrderedRuleAdapter of web DLP rules. Sub-rules are
+// ordered among the sub-rules of their parent rule, so the starting order and
+// the engine are scoped per parent.
+type dlpWebRuleAdapter struct {
+	service    *zscaler.Service
+	parentRule int
+}
+
+func (a dlpWebRuleAdapter) ResourceType() string {
+	if a.parentRule != 0 {
+		return fmt.Sprintf("dlp_web_rules_sub_%d", a.parentRule)
+	}
+	return "dlp_web_rules"
+}
+
+func (dlpWebRuleAdapter) Ranked() bool { return false }
+
+func (a dlpWebRuleAdapter) GetAll(ctx context.Context) ([]dlp_web_rules.WebDLPRules, error) {
+	if a.parentRule == 0 {
+		return dlp_web_rules.GetAll(ctx, a.service)
+	}
+	parent, err := dlp_web_rules.Get(ctx, a.service, a.parentRule)
+	if err != nil {
+		return nil, err
+	}
+	return parent.SubRules, nil
+}
+
+func (a dlpWebRuleAdapter) Get(ctx context.Context, id int) (*dlp_web_rules.WebDLPRules, error) {
+	return dlp_web_rules.Get(ctx, a.service, id)
+}
+
+func (a dlpWebRuleAdapter) Create(ctx context.Context, rule *dlp_web_rules.WebDLPRules) (int, error) {
+	resp, err := dlp_web_rules.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a dlpWebRuleAdapter) Update(ctx context.Context, id int, rule *dlp_web_rules.WebDLPRules) error {
+	_, err := dlp_web_rules.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a dlpWebRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := dlp_web_rules.Delete(ctx, a.service, id)
+	if err != nil && strings.Contains(err.Error(), "RESOURCE_NOT_FOUND") {
+		log.Printf("[INFO] web dlp rule %d not found, skipping deletion", id)
+		return nil
+	}
+	return err
+}
+
+func (dlpWebRuleAdapter) Order(rule *dlp_web_rules.WebDLPRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (dlpWebRuleAdapter) SetOrder(rule *dlp_web_rules.WebDLPRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (dlpWebRuleAdapter) StripReadOnly(rule *dlp_web_rules.WebDLPRules) {
+	// to avoid the STALE_CONFIGURATION_ERROR
+	rule.LastModifiedTime = 0
+	rule.LastModifiedBy = nil
+	rule.AccessControl = ""
+	if len(rule.FileTypeCategories) > 0 {
+		rule.FileTypes = nil
+	}
+}
 
 func resourceDlpWebRules() *schema.Resource {
-	return &schema.Resource{
+	return versionSchema(&schema.Resource{
 		CreateContext: resourceDlpWebRulesCreate,
 		ReadContext:   resourceDlpWebRulesRead,
 		UpdateContext: resourceDlpWebRulesUpdate,
 		DeleteContext: resourceDlpWebRulesDelete,
-		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		CustomizeDiff: customdiff.All(func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
 			externalEmail, emailSet := d.GetOk("external_auditor_email")
 			auditorRaw, auditorSet := d.GetOk("auditor")
 			nt, ntSet := d.GetOk("notification_template")
@@ -60,33 +127,13 @@ func resourceDlpWebRules() *schema.Resource {
 
 			// Rule 4: If none are set, it's valid
 			return nil
-		},
+		}, validateRuleOrder("zia_dlp_web_rules")),
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := dlp_web_rules.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", dlp_web_rules.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -256,10 +303,10 @@ func resourceDlpWebRules() *schema.Resource {
 				Optional:    true,
 				Description: "Indicates whether a Zscaler Incident Receiver is associated to the DLP policy rule",
 			},
-			"locations":                setIDsSchemaTypeCustom(nil, "The Name-ID pairs of locations to which the DLP policy rule must be applied"),
+			"location_ids":             setIntIDsSchemaType(nil, "The IDs of locations to which the DLP policy rule must be applied"),
 			"location_groups":          setIDsSchemaTypeCustom(nil, "The Name-ID pairs of locations groups to which the DLP policy rule must be applied"),
 			"users":                    setIDsSchemaTypeCustom(nil, "The Name-ID pairs of users to which the DLP policy rule must be applied"),
-			"groups":                   setIDsSchemaTypeCustom(nil, "The Name-ID pairs of groups to which the DLP policy rule must be applied"),
+			"group_ids":                setIntIDsSchemaType(nil, "The IDs of groups to which the DLP policy rule must be applied"),
 			"departments":              setIDsSchemaTypeCustom(nil, "The Name-ID pairs of departments to which the DLP policy rule must be applied"),
 			"excluded_departments":     setIDsSchemaTypeCustom(intPtr(256), "The Name-ID pairs of departments which the DLP policy rule must exclude"),
 			"excluded_users":           setIDsSchemaTypeCustom(intPtr(256), "The Name-ID pairs of users which the DLP policy rule must exclude"),
@@ -322,12 +369,11 @@ func resourceDlpWebRules() *schema.Resource {
 				},
 			},
 		},
-	}
+	}, ruleIDBlocksV0)
 }
 
 func resourceDlpWebRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandDlpWebRules(d)
 
@@ -343,159 +389,10 @@ func resourceDlpWebRulesCreate(ctx context.Context, d *schema.ResourceData, meta
 
 	log.Printf("[INFO] Creating zia web dlp rule\n%+v\n", req)
 
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-	// Determine whether it's a sub-rule
-	isSubRule := req.ParentRule != 0
-	for {
-		dlpWebRulesLock.Lock()
-		if isSubRule {
-			if dlpWebSubRulesStartingOrder[req.ParentRule] == 0 {
-				list, _ := dlp_web_rules.Get(ctx, service, req.ParentRule)
-				for _, subRule := range list.SubRules {
-					r, err := dlp_web_rules.Get(ctx, service, subRule.ID)
-					if err != nil {
-						return diag.FromErr(err)
-					}
-					if r.Order > dlpWebSubRulesStartingOrder[req.ParentRule] {
-						dlpWebSubRulesStartingOrder[req.ParentRule] = r.Order
-					}
-				}
-				if dlpWebSubRulesStartingOrder[req.ParentRule] == 0 {
-					dlpWebSubRulesStartingOrder[req.ParentRule] = 1
-				}
-			}
-		} else {
-			if dlpWebStartingOrder == 0 {
-				list, _ := dlp_web_rules.GetAll(ctx, service)
-				for _, r := range list {
-					if r.Order > dlpWebStartingOrder {
-						dlpWebStartingOrder = r.Order
-					}
-				}
-				if dlpWebStartingOrder == 0 {
-					dlpWebStartingOrder = 1
-				}
-			}
-		}
-		dlpWebRulesLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		order := req.Order
-		if isSubRule {
-			req.Order = dlpWebSubRulesStartingOrder[req.ParentRule]
-		} else {
-			req.Order = dlpWebStartingOrder
-		}
-
-		resp, err := dlp_web_rules.Create(ctx, service, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") && !strings.Contains(err.Error(), "ICAP Receiver with id") {
-				if time.Since(start) < timeout {
-					time.Sleep(5 * time.Second)
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-		}
-
-		log.Printf("[INFO] Created zia web dlp rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-
-		resourceType := "dlp_web_rules"
-		if isSubRule {
-			resourceType = fmt.Sprintf("dlp_web_rules_sub_%d", req.ParentRule)
-		}
-
-		reorderWithBeforeReorder(
-			OrderRule{Order: order, Rank: req.Rank},
-			resp.ID,
-			resourceType,
-			func() (map[int]OrderRule, error) {
-				if isSubRule {
-					parent, err := dlp_web_rules.Get(ctx, service, req.ParentRule)
-					if err != nil {
-						return nil, err
-					}
-					m := make(map[int]OrderRule, len(parent.SubRules))
-					for _, r := range parent.SubRules {
-						m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-					}
-					return m, nil
-				}
-				allRules, err := dlp_web_rules.GetAll(ctx, service)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(allRules))
-				for _, r := range allRules {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				rule, err := dlp_web_rules.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-
-				rule.LastModifiedTime = 0
-				rule.LastModifiedBy = nil
-				rule.AccessControl = ""
-				rule.Order = order.Order
-				rule.Rank = order.Rank
-
-				if len(rule.FileTypeCategories) > 0 {
-					rule.FileTypes = nil
-				}
-
-				if rule.ParentRule != 0 {
-					log.Printf("[DEBUG] Updating sub-rule ID %d (parent ID: %d) to order %d", id, rule.ParentRule, order.Order)
-				} else {
-					log.Printf("[DEBUG] Updating parent rule ID %d to order %d", id, order.Order)
-				}
-
-				_, err = dlp_web_rules.Update(ctx, service, id, rule)
-				if err != nil {
-					log.Printf("[ERROR] Failed to update order for rule ID %d: %v", id, err)
-				}
-				return err
-			},
-			nil, // Remove beforeReorder function to avoid adding too many rules to the map
-		)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		if diags := resourceDlpWebRulesRead(ctx, d, meta); diags.HasError() {
-			if time.Since(start) < timeout {
-				time.Sleep(10 * time.Second)
-				continue
-			}
-			return diags
-		}
-
-		markOrderRuleAsDone(resp.ID, resourceType)
-		waitForReorder(resourceType)
-		break
+	if diags := createOrderedRule(ctx, d, zClient, dlpWebRuleAdapter{zClient.Service, req.ParentRule}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
-
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return resourceDlpWebRulesRead(ctx, d, meta)
 }
 
 func resourceDlpWebRulesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -506,7 +403,10 @@ func resourceDlpWebRulesRead(ctx context.Context, d *schema.ResourceData, meta i
 	if !ok {
 		return diag.FromErr(fmt.Errorf("no zia web dlp rule id is set"))
 	}
-	resp, err := dlp_web_rules.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_dlp_web_rules", id,
+		func() ([]dlp_web_rules.WebDLPRules, error) { return dlp_web_rules.GetAll(ctx, service) },
+		func(r *dlp_web_rules.WebDLPRules) int { return r.ID },
+		func() (*dlp_web_rules.WebDLPRules, error) { return dlp_web_rules.Get(ctx, service, id) })
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing web dlp rule %s from state because it no longer exists in ZIA", d.Id())
@@ -552,7 +452,7 @@ func resourceDlpWebRulesRead(ctx context.Context, d *schema.ResourceData, meta i
 		return diag.FromErr(fmt.Errorf("error setting sub_rules: %s", err))
 	}
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -560,7 +460,7 @@ func resourceDlpWebRulesRead(ctx context.Context, d *schema.ResourceData, meta i
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -635,7 +535,6 @@ func resourceDlpWebRulesRead(ctx context.Context, d *schema.ResourceData, meta i
 
 func resourceDlpWebRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -655,96 +554,9 @@ func resourceDlpWebRulesUpdate(ctx context.Context, d *schema.ResourceData, meta
 		return diag.FromErr(err)
 	}
 
-	timeout := d.Timeout(schema.TimeoutUpdate)
-	start := time.Now()
-
-	for {
-		_, err := dlp_web_rules.Update(ctx, service, id, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			log.Printf("[INFO] Retrying due to API error: %s", err)
-			if time.Since(start) < timeout {
-				time.Sleep(5 * time.Second)
-				continue
-			}
-			return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-		}
-
-		break
-	}
-
-	// Handle ordering update after a successful rule update
-	isSubRule := req.ParentRule != 0
-	resourceType := "dlp_web_rules"
-	if isSubRule {
-		resourceType = fmt.Sprintf("dlp_web_rules_sub_%d", req.ParentRule)
+	if diags := updateOrderedRule(ctx, d, zClient, dlpWebRuleAdapter{zClient.Service, req.ParentRule}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
-	reorderWithBeforeReorder(OrderRule{Order: req.Order, Rank: req.Rank}, id, resourceType,
-		func() (map[int]OrderRule, error) {
-			if isSubRule {
-				parent, err := dlp_web_rules.Get(ctx, service, req.ParentRule)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(parent.SubRules))
-				for _, r := range parent.SubRules {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			}
-			allRules, err := dlp_web_rules.GetAll(ctx, service)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := dlp_web_rules.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			if rule.Order == order.Order && rule.Rank == order.Rank {
-				return nil
-			}
-
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-
-			if len(rule.FileTypeCategories) > 0 {
-				rule.FileTypes = nil
-			}
-
-			if rule.ParentRule != 0 {
-				log.Printf("[DEBUG] Updating sub-rule ID %d (parent ID: %d) to order %d", id, rule.ParentRule, order.Order)
-			} else {
-				log.Printf("[DEBUG] Updating parent rule ID %d to order %d", id, order.Order)
-			}
-
-			_, err = dlp_web_rules.Update(ctx, service, id, rule)
-			if err != nil {
-				log.Printf("[ERROR] Failed to update order for rule ID %d: %v", id, err)
-			}
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	markOrderRuleAsDone(id, resourceType)
-	waitForReorder(resourceType)
-
 	return resourceDlpWebRulesRead(ctx, d, meta)
 }
 
@@ -756,30 +568,8 @@ func resourceDlpWebRulesDelete(ctx context.Context, d *schema.ResourceData, meta
 	if !ok {
 		log.Printf("[ERROR] web dlp rule not set: %v\n", id)
 	}
-	log.Printf("[INFO] Deleting dlp rule ID: %v\n", (d.Id()))
-
-	if _, err := dlp_web_rules.Delete(ctx, service, id); err != nil {
-		if strings.Contains(err.Error(), "RESOURCE_NOT_FOUND") {
-			log.Printf("[INFO] web dlp rule %d not found, skipping deletion", id)
-			return nil
-		}
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] web dlp rule deleted")
 
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, dlpWebRuleAdapter{service: service}, id)
 }
 
 func expandDlpWebRules(d *schema.ResourceData) dlp_web_rules.WebDLPRules {
@@ -820,9 +610,9 @@ func expandDlpWebRules(d *schema.ResourceData) dlp_web_rules.WebDLPRules {
 		NotificationTemplate:     expandIDNameExtensionsSetSingle(d, "notification_template"),
 		IcapServer:               expandIDNameExtensionsSetSingle(d, "icap_server"),
 		Receiver:                 expandReceiver(d, "receiver"),
-		Locations:                expandIDNameExtensionsSet(d, "locations"),
+		Locations:                expandIDNameExtensionsIDs(d, "location_ids"),
 		LocationGroups:           expandIDNameExtensionsSet(d, "location_groups"),
-		Groups:                   expandIDNameExtensionsSet(d, "groups"),
+		Groups:                   expandIDNameExtensionsIDs(d, "group_ids"),
 		Departments:              expandIDNameExtensionsSet(d, "departments"),
 		Users:                    expandIDNameExtensionsSet(d, "users"),
 		URLCategories:            expandIDNameExtensionsSet(d, "url_categories"),
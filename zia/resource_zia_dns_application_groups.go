@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -22,27 +21,7 @@ func resourceDNSApplicationGroups() *schema.Resource {
 		ReadContext:   resourceDNSApplicationGroupsRead,
 		UpdateContext: resourceDNSApplicationGroupsUpdate,
 		DeleteContext: resourceDNSApplicationGroupsDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("group_id", idInt)
-				} else {
-					resp, err := dns_application_groups.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("group_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importByName("group_id", dns_application_groups.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -90,9 +69,7 @@ func resourceDNSApplicationGroupsCreate(ctx context.Context, d *schema.ResourceD
 	_ = d.Set("group_id", resp.ID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -170,9 +147,7 @@ func resourceDNSApplicationGroupsUpdate(ctx context.Context, d *schema.ResourceD
 		return diag.FromErr(err)
 	}
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -213,9 +188,7 @@ func resourceDNSApplicationGroupsDelete(ctx context.Context, d *schema.ResourceD
 	d.SetId("")
 	log.Printf("[INFO] zia ip source groups deleted")
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
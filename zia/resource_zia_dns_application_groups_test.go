@@ -24,9 +24,9 @@ func TestAccResourceDNSApplicationGroupsBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckDNSApplicationGroupsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckDNSApplicationGroupsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckDNSApplicationGroupsConfigure(resourceTypeAndName, initialName, variable.DNSAppGroupDescription),
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/email_profiles"
 )
@@ -20,27 +20,9 @@ func resourceEmailProfile() *schema.Resource {
 		ReadContext:   resourceEmailProfileRead,
 		UpdateContext: resourceEmailProfileUpdate,
 		DeleteContext: resourceEmailProfileDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("email_profile_id", idInt)
-				} else {
-					resp, err := email_profiles.GetEmailProfileByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("email_profile_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("email_profile_id", func(ctx context.Context, service *zscaler.Service) ([]email_profiles.EmailProfiles, error) {
+			return email_profiles.GetAll(ctx, service, nil)
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -95,8 +77,7 @@ func resourceEmailProfileCreate(ctx context.Context, d *schema.ResourceData, met
 	d.SetId(strconv.Itoa(resp.ID))
 	_ = d.Set("email_profile_id", resp.ID)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -156,8 +137,7 @@ func resourceEmailProfileUpdate(ctx context.Context, d *schema.ResourceData, met
 		return diag.FromErr(err)
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -190,8 +170,7 @@ func resourceEmailProfileDelete(ctx context.Context, d *schema.ResourceData, met
 	d.SetId("")
 	log.Printf("[INFO] zia_email_profile %d deleted", id)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -24,9 +24,9 @@ func TestAccResourceEmailProfile(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckEmailProfileDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckEmailProfileDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckEmailProfileConfigure(resourceTypeAndName, initialName, variable.EmailProfileDescription),
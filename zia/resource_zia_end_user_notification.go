@@ -76,16 +76,7 @@ func resourceEndUserNotification() *schema.Resource {
 			return nil
 		},
 
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceEndUserNotificationRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("failed to read advanced settings during import: %s", diags[0].Summary)
-				}
-				d.SetId("enduser_notification")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importSingleton("enduser_notification", resourceEndUserNotificationRead),
 		Schema: map[string]*schema.Schema{
 			"aup_frequency": {
 				Type: schema.TypeString,
@@ -323,8 +314,8 @@ func resourceEndUserNotificationCreate(ctx context.Context, d *schema.ResourceDa
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -399,8 +390,8 @@ func resourceEndUserNotificationUpdate(ctx context.Context, d *schema.ResourceDa
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -13,9 +13,9 @@ func TestAccResourceEndUserNotificationBasic(t *testing.T) {
 	resourceName := "zia_end_user_notification.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckResourceEndUserNotificationDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckResourceEndUserNotificationDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create the resource with specific values
 			{
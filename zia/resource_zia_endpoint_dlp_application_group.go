@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/endpoint_dlp/endpoint_application_groups"
 )
 
@@ -26,27 +26,20 @@ func resourceEndpointDLPApplicationGroup() *schema.Resource {
 		DeleteContext: resourceEndpointDLPApplicationGroupDelete,
 		Importer: &schema.ResourceImporter{
 			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
 				// The channel is not returned by the list endpoint, so pin it to
 				// the only supported value so the Read that follows import works.
 				_ = d.Set("channel", applicationGroupChannel)
-
-				key := d.Id()
-				if idInt, parseErr := strconv.Atoi(key); parseErr == nil {
-					_ = d.Set("group_id", idInt)
-					d.SetId(strconv.Itoa(idInt))
-					return []*schema.ResourceData{d}, nil
-				}
-
-				group, err := endpoint_application_groups.GetByName(ctx, service, key)
-				if err != nil {
-					return nil, err
-				}
-				_ = d.Set("group_id", group.GroupID)
-				d.SetId(strconv.Itoa(group.GroupID))
-				return []*schema.ResourceData{d}, nil
+				return importByKey("group_id", func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+					groups, err := endpoint_application_groups.GetAll(ctx, service)
+					if err != nil {
+						return nil, err
+					}
+					candidates := make([]importCandidate, len(groups))
+					for i, group := range groups {
+						candidates[i] = importCandidate{id: group.GroupID, name: group.Name}
+					}
+					return candidates, nil
+				}).StateContext(ctx, d, meta)
 			},
 		},
 
@@ -107,8 +100,7 @@ func resourceEndpointDLPApplicationGroupCreate(ctx context.Context, d *schema.Re
 	d.SetId(strconv.Itoa(resp.ID))
 	_ = d.Set("group_id", resp.ID)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -188,8 +180,7 @@ func resourceEndpointDLPApplicationGroupUpdate(ctx context.Context, d *schema.Re
 		return diag.FromErr(err)
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -216,8 +207,7 @@ func resourceEndpointDLPApplicationGroupDelete(ctx context.Context, d *schema.Re
 	d.SetId("")
 	log.Printf("[INFO] zia endpoint application group deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
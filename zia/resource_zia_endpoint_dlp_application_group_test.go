@@ -23,9 +23,9 @@ func TestAccResourceEndpointDLPApplicationGroupBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckEndpointDLPApplicationGroupDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckEndpointDLPApplicationGroupDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckEndpointDLPApplicationGroupConfigure(resourceTypeAndName, initialName, variable.DLPEndpointApplicationGroupDescription),
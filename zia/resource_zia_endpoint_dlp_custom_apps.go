@@ -5,9 +5,7 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"strings"
 	"sync"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -29,36 +27,17 @@ func resourceEndpointDLPCustomApps() *schema.Resource {
 		ReadContext:   resourceEndpointDLPCustomAppsRead,
 		UpdateContext: resourceEndpointDLPCustomAppsUpdate,
 		DeleteContext: resourceEndpointDLPCustomAppsDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("custom_app_id", idInt)
-				} else {
-					apps, err := endpoint_custom_apps.GetCustomApps(ctx, service, &endpoint_custom_apps.GetCustomAppsFilterOptions{Search: id})
-					if err != nil {
-						return nil, err
-					}
-					found := false
-					for i := range apps {
-						if strings.EqualFold(apps[i].ApplicationName, id) {
-							d.SetId(strconv.Itoa(apps[i].ResourceID))
-							_ = d.Set("custom_app_id", apps[i].ResourceID)
-							found = true
-							break
-						}
-					}
-					if !found {
-						return nil, fmt.Errorf("couldn't find any endpoint dlp custom app with name %q", id)
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByKey("custom_app_id", func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+			apps, err := endpoint_custom_apps.GetCustomApps(ctx, service, nil)
+			if err != nil {
+				return nil, err
+			}
+			candidates := make([]importCandidate, len(apps))
+			for i, app := range apps {
+				candidates[i] = importCandidate{id: app.ResourceID, name: app.ApplicationName}
+			}
+			return candidates, nil
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -170,8 +149,7 @@ func resourceEndpointDLPCustomAppsCreate(ctx context.Context, d *schema.Resource
 	// invalidated). Re-reading here intermittently wiped just-created records.
 	setEndpointDLPCustomAppFromResource(d, resp)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -247,8 +225,7 @@ func resourceEndpointDLPCustomAppsUpdate(ctx context.Context, d *schema.Resource
 	}
 	setEndpointDLPCustomAppFromResource(d, resp)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -278,8 +255,7 @@ func resourceEndpointDLPCustomAppsDelete(ctx context.Context, d *schema.Resource
 	d.SetId("")
 	log.Printf("[INFO] zia endpoint dlp custom app deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -24,9 +24,9 @@ func TestAccResourceEndpointDLPCustomAppsBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckEndpointDLPCustomAppsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckEndpointDLPCustomAppsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckEndpointDLPCustomAppsConfigure(resourceTypeAndName, initialName, variable.DLPEndpointCustomAppDescription),
@@ -6,7 +6,6 @@ import (
 	"log"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -27,36 +26,28 @@ func resourceEndpointDLPResource() *schema.Resource {
 				zClient := meta.(*Client)
 				service := zClient.Service
 
-				// The Read path requires the channel, so imports must carry it.
-				// Accept "<CHANNEL>:<id>" or "<CHANNEL>:<name>".
+				// The Read path is channel-scoped, so imports must carry the
+				// channel. Accept "<CHANNEL>:<id>", "<CHANNEL>:<name>" or
+				// "<CHANNEL>:name:<name>".
 				parts := strings.SplitN(d.Id(), ":", 2)
 				if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
 					return nil, fmt.Errorf("invalid import id %q: expected format \"<CHANNEL>:<id>\" or \"<CHANNEL>:<name>\" (e.g. \"PRINTING:12345\")", d.Id())
 				}
-
 				channel := endpoint_resource_channel.Channel(parts[0])
-				key := parts[1]
-
-				if idInt, parseErr := strconv.Atoi(key); parseErr == nil {
-					_ = d.Set("channel", string(channel))
-					_ = d.Set("resource_id", idInt)
-					d.SetId(strconv.Itoa(idInt))
-					return []*schema.ResourceData{d}, nil
-				}
-
-				list, err := endpoint_resource_channel.GetChannelList(ctx, service, channel, &endpoint_resource_channel.GetChannelFilterOptions{Name: key})
-				if err != nil {
-					return nil, err
-				}
-				for i := range list {
-					if strings.EqualFold(list[i].Name, key) {
-						_ = d.Set("channel", string(channel))
-						_ = d.Set("resource_id", list[i].ID)
-						d.SetId(strconv.Itoa(list[i].ID))
-						return []*schema.ResourceData{d}, nil
+				id, err := resolveImportID(parts[1], func() ([]importCandidate, error) {
+					list, err := endpoint_resource_channel.GetChannelList(ctx, service, channel, nil)
+					if err != nil {
+						return nil, err
 					}
+					return namedImportCandidates(list), nil
+				})
+				if err != nil {
+					return nil, fmt.Errorf("dlp endpoint resource in channel %q: %w", channel, err)
 				}
-				return nil, fmt.Errorf("couldn't find any dlp endpoint resource with name %q in channel %q", key, channel)
+				_ = d.Set("channel", string(channel))
+				_ = d.Set("resource_id", id)
+				d.SetId(strconv.Itoa(id))
+				return []*schema.ResourceData{d}, nil
 			},
 		},
 
@@ -182,8 +173,7 @@ func resourceEndpointDLPResourceCreate(ctx context.Context, d *schema.ResourceDa
 	d.SetId(strconv.Itoa(resp.ID))
 	_ = d.Set("resource_id", resp.ID)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -258,8 +248,7 @@ func resourceEndpointDLPResourceUpdate(ctx context.Context, d *schema.ResourceDa
 		return diag.FromErr(err)
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -286,8 +275,7 @@ func resourceEndpointDLPResourceDelete(ctx context.Context, d *schema.ResourceDa
 	d.SetId("")
 	log.Printf("[INFO] zia dlp endpoint resource deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -6,7 +6,6 @@ import (
 	"log"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -27,35 +26,27 @@ func resourceEndpointDLPResourceGroup() *schema.Resource {
 				service := zClient.Service
 
 				// The Read path is channel-scoped, so imports must carry the
-				// channel. Accept "<CHANNEL>:<id>" or "<CHANNEL>:<name>".
+				// channel. Accept "<CHANNEL>:<id>", "<CHANNEL>:<name>" or
+				// "<CHANNEL>:name:<name>".
 				parts := strings.SplitN(d.Id(), ":", 2)
 				if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
 					return nil, fmt.Errorf("invalid import id %q: expected format \"<CHANNEL>:<id>\" or \"<CHANNEL>:<name>\" (e.g. \"PRINTING:367\")", d.Id())
 				}
-
 				channel := endpoint_resource_channel.Channel(parts[0])
-				key := parts[1]
-
-				if idInt, parseErr := strconv.Atoi(key); parseErr == nil {
-					_ = d.Set("channel", string(channel))
-					_ = d.Set("group_id", idInt)
-					d.SetId(strconv.Itoa(idInt))
-					return []*schema.ResourceData{d}, nil
-				}
-
-				list, err := endpoint_resource_group.GetResourceGroupTagsList(ctx, service, channel, nil)
-				if err != nil {
-					return nil, err
-				}
-				for i := range list {
-					if strings.EqualFold(list[i].Name, key) {
-						_ = d.Set("channel", string(channel))
-						_ = d.Set("group_id", list[i].ID)
-						d.SetId(strconv.Itoa(list[i].ID))
-						return []*schema.ResourceData{d}, nil
+				id, err := resolveImportID(parts[1], func() ([]importCandidate, error) {
+					list, err := endpoint_resource_group.GetResourceGroupTagsList(ctx, service, channel, nil)
+					if err != nil {
+						return nil, err
 					}
+					return namedImportCandidates(list), nil
+				})
+				if err != nil {
+					return nil, fmt.Errorf("dlp endpoint resource group in channel %q: %w", channel, err)
 				}
-				return nil, fmt.Errorf("couldn't find any dlp endpoint resource group with name %q in channel %q", key, channel)
+				_ = d.Set("channel", string(channel))
+				_ = d.Set("group_id", id)
+				d.SetId(strconv.Itoa(id))
+				return []*schema.ResourceData{d}, nil
 			},
 		},
 
@@ -130,8 +121,7 @@ func resourceEndpointDLPResourceGroupCreate(ctx context.Context, d *schema.Resou
 		}
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -244,8 +234,7 @@ func resourceEndpointDLPResourceGroupUpdate(ctx context.Context, d *schema.Resou
 		}
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -272,8 +261,7 @@ func resourceEndpointDLPResourceGroupDelete(ctx context.Context, d *schema.Resou
 	d.SetId("")
 	log.Printf("[INFO] zia dlp endpoint resource group deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -25,9 +25,9 @@ func TestAccResourceEndpointDLPResourceGroupBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckEndpointDLPResourceGroupDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckEndpointDLPResourceGroupDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckEndpointDLPResourceGroupConfigure(resourceTypeAndName, initialName, variable.DLPEndpointResourceGroupDescription),
@@ -25,9 +25,9 @@ func TestAccResourceEndpointDLPResourceBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckEndpointDLPResourceDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckEndpointDLPResourceDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckEndpointDLPResourceConfigure(resourceTypeAndName, initialName, variable.DLPEndpointResourceDescription),
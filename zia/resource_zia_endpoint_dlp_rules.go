@@ -4,15 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/common"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/dlp/dlp_web_rules"
@@ -23,18 +23,65 @@ import (
 // rule API. The user only supplies the receiver "id".
 const endpointReceiverType = "ZIR"
 
-var (
-	endpointDlpRulesLock     sync.Mutex
-	endpointDlpStartingOrder int
-)
+// endpointDlpRuleAdapter is the OrderedRuleAdapter of endpoint DLP rules.
+type endpointDlpRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (endpointDlpRuleAdapter) ResourceType() string { return "endpoint_dlp_rules" }
+func (endpointDlpRuleAdapter) Ranked() bool         { return false }
+
+func (a endpointDlpRuleAdapter) GetAll(ctx context.Context) ([]endpoint_dlp_rules.EndpointDlpRules, error) {
+	return endpoint_dlp_rules.GetAll(ctx, a.service)
+}
+
+func (a endpointDlpRuleAdapter) Get(ctx context.Context, id int) (*endpoint_dlp_rules.EndpointDlpRules, error) {
+	return endpoint_dlp_rules.Get(ctx, a.service, id)
+}
+
+func (a endpointDlpRuleAdapter) Create(ctx context.Context, rule *endpoint_dlp_rules.EndpointDlpRules) (int, error) {
+	resp, _, err := endpoint_dlp_rules.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a endpointDlpRuleAdapter) Update(ctx context.Context, id int, rule *endpoint_dlp_rules.EndpointDlpRules) error {
+	_, _, err := endpoint_dlp_rules.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a endpointDlpRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := endpoint_dlp_rules.Delete(ctx, a.service, id)
+	if err != nil && strings.Contains(err.Error(), "RESOURCE_NOT_FOUND") {
+		log.Printf("[INFO] endpoint dlp rule %d not found, skipping deletion", id)
+		return nil
+	}
+	return err
+}
+
+func (endpointDlpRuleAdapter) Order(rule *endpoint_dlp_rules.EndpointDlpRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (endpointDlpRuleAdapter) SetOrder(rule *endpoint_dlp_rules.EndpointDlpRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (endpointDlpRuleAdapter) StripReadOnly(rule *endpoint_dlp_rules.EndpointDlpRules) {
+	// to avoid the STALE_CONFIGURATION_ERROR
+	rule.LastModifiedTime = 0
+	rule.LastModifiedBy = nil
+}
 
 func resourceEndpointDLPRules() *schema.Resource {
-	return &schema.Resource{
+	return versionSchema(&schema.Resource{
 		CreateContext: resourceEndpointDLPRulesCreate,
 		ReadContext:   resourceEndpointDLPRulesRead,
 		UpdateContext: resourceEndpointDLPRulesUpdate,
 		DeleteContext: resourceEndpointDLPRulesDelete,
-		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		CustomizeDiff: customdiff.All(func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
 			// The endpoint application selectors are only valid when the data transfer
 			// method targets application file access.
 			hasEndpointApps := endpointBlockHasValues(d.Get("end_point_applications"), "zapp_id")
@@ -75,33 +122,13 @@ func resourceEndpointDLPRules() *schema.Resource {
 
 			// Rule 4: If none are set, it's valid
 			return nil
-		},
+		}, validateRuleOrder("zia_endpoint_dlp_rules")),
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := endpoint_dlp_rules.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", endpoint_dlp_rules.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -232,7 +259,7 @@ func resourceEndpointDLPRules() *schema.Resource {
 			"device_groups":                setIDsSchemaTypeCustom(nil, "This field is applicable for devices that are managed using Zscaler Client Connector."),
 			"devices":                      setIDsSchemaTypeCustom(nil, "Name-ID pairs of devices for which rule must be applied."),
 			"users":                        setIDsSchemaTypeCustom(nil, "The Name-ID pairs of users to which the DLP policy rule must be applied"),
-			"groups":                       setIDsSchemaTypeCustom(nil, "The Name-ID pairs of groups to which the DLP policy rule must be applied"),
+			"group_ids":                    setIntIDsSchemaType(nil, "The IDs of groups to which the DLP policy rule must be applied"),
 			"departments":                  setIDsSchemaTypeCustom(nil, "The Name-ID pairs of departments to which the DLP policy rule must be applied"),
 			"resources":                    setIDsSchemaTypeCustom(nil, "The Name-ID pairs of resources to which the DLP policy rule must be applied"),
 			"resource_groups":              setIDsSchemaTypeCustom(nil, "The Name-ID pairs of resource groups to which the DLP policy rule must be applied"),
@@ -258,123 +285,20 @@ func resourceEndpointDLPRules() *schema.Resource {
 				},
 			},
 		},
-	}
+	}, ruleIDBlocksV0)
 }
 
 func resourceEndpointDLPRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandEndpointDLPRules(d)
 
 	log.Printf("[INFO] Creating zia endpoint dlp rule\n%+v\n", req)
 
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-	for {
-		endpointDlpRulesLock.Lock()
-		if endpointDlpStartingOrder == 0 {
-			list, _ := endpoint_dlp_rules.GetAll(ctx, service)
-			for _, r := range list {
-				if r.Order > endpointDlpStartingOrder {
-					endpointDlpStartingOrder = r.Order
-				}
-			}
-			if endpointDlpStartingOrder == 0 {
-				endpointDlpStartingOrder = 1
-			}
-		}
-		endpointDlpRulesLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		order := req.Order
-		req.Order = endpointDlpStartingOrder
-
-		resp, _, err := endpoint_dlp_rules.Create(ctx, service, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") && !strings.Contains(err.Error(), "ICAP Receiver with id") {
-				if time.Since(start) < timeout {
-					time.Sleep(5 * time.Second)
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-		}
-
-		log.Printf("[INFO] Created zia endpoint dlp rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-
-		resourceType := "endpoint_dlp_rules"
-
-		reorderWithBeforeReorder(
-			OrderRule{Order: order, Rank: req.Rank},
-			resp.ID,
-			resourceType,
-			func() (map[int]OrderRule, error) {
-				allRules, err := endpoint_dlp_rules.GetAll(ctx, service)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(allRules))
-				for _, r := range allRules {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				rule, err := endpoint_dlp_rules.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-
-				rule.LastModifiedTime = 0
-				rule.LastModifiedBy = nil
-				rule.Order = order.Order
-				rule.Rank = order.Rank
-
-				log.Printf("[DEBUG] Updating parent rule ID %d to order %d", id, order.Order)
-
-				_, _, err = endpoint_dlp_rules.Update(ctx, service, id, rule)
-				if err != nil {
-					log.Printf("[ERROR] Failed to update order for rule ID %d: %v", id, err)
-				}
-				return err
-			},
-			nil, // Remove beforeReorder function to avoid adding too many rules to the map
-		)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		if diags := resourceEndpointDLPRulesRead(ctx, d, meta); diags.HasError() {
-			if time.Since(start) < timeout {
-				time.Sleep(10 * time.Second)
-				continue
-			}
-			return diags
-		}
-
-		markOrderRuleAsDone(resp.ID, resourceType)
-		waitForReorder(resourceType)
-		break
-	}
-
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := createOrderedRule(ctx, d, zClient, endpointDlpRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
-
-	return nil
+	return resourceEndpointDLPRulesRead(ctx, d, meta)
 }
 
 func resourceEndpointDLPRulesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -385,7 +309,10 @@ func resourceEndpointDLPRulesRead(ctx context.Context, d *schema.ResourceData, m
 	if !ok {
 		return diag.FromErr(fmt.Errorf("no zia endpoint dlp rule id is set"))
 	}
-	resp, err := endpoint_dlp_rules.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_endpoint_dlp_rules", id,
+		func() ([]endpoint_dlp_rules.EndpointDlpRules, error) { return endpoint_dlp_rules.GetAll(ctx, service) },
+		func(r *endpoint_dlp_rules.EndpointDlpRules) int { return r.ID },
+		func() (*endpoint_dlp_rules.EndpointDlpRules, error) { return endpoint_dlp_rules.Get(ctx, service, id) })
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing endpoint dlp rule %s from state because it no longer exists in ZIA", d.Id())
@@ -428,7 +355,7 @@ func resourceEndpointDLPRulesRead(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(fmt.Errorf("error setting sub_rules: %s", err))
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -489,7 +416,6 @@ func resourceEndpointDLPRulesRead(ctx context.Context, d *schema.ResourceData, m
 
 func resourceEndpointDLPRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -499,97 +425,9 @@ func resourceEndpointDLPRulesUpdate(ctx context.Context, d *schema.ResourceData,
 
 	req := expandEndpointDLPRules(d)
 
-	// Drop the resource from state if it no longer exists.
-	if _, err := endpoint_dlp_rules.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-
-	// Park the rule at the tail order before reordering. Writing the intended
-	// order directly makes parallel updates fight over positions and can leave
-	// the rules in a rotated order; the shared reorder loop then moves this rule
-	// to its intended position. Mirrors firewall_filtering / ssl_inspection.
-	existingRules, err := endpoint_dlp_rules.GetAll(ctx, service)
-	if err != nil {
-		log.Printf("[ERROR] error getting all endpoint dlp rules: %v", err)
-	}
-	sort.Slice(existingRules, func(i, j int) bool {
-		return existingRules[i].Rank < existingRules[j].Rank || (existingRules[i].Rank == existingRules[j].Rank && existingRules[i].Order < existingRules[j].Order)
-	})
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	if len(existingRules) > 0 {
-		req.Order = existingRules[len(existingRules)-1].Order
-	}
-
-	timeout := d.Timeout(schema.TimeoutUpdate)
-	start := time.Now()
-
-	for {
-		_, _, err := endpoint_dlp_rules.Update(ctx, service, id, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			log.Printf("[INFO] Retrying due to API error: %s", err)
-			if time.Since(start) < timeout {
-				time.Sleep(5 * time.Second)
-				continue
-			}
-			return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-		}
-
-		break
+	if diags := updateOrderedRule(ctx, d, zClient, endpointDlpRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
-	// Handle ordering update after a successful rule update
-	resourceType := "endpoint_dlp_rules"
-
-	reorderWithBeforeReorder(OrderRule{Order: intendedOrder, Rank: intendedRank}, id, resourceType,
-		func() (map[int]OrderRule, error) {
-			allRules, err := endpoint_dlp_rules.GetAll(ctx, service)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := endpoint_dlp_rules.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			if rule.Order == order.Order && rule.Rank == order.Rank {
-				return nil
-			}
-
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-
-			log.Printf("[DEBUG] Updating parent rule ID %d to order %d", id, order.Order)
-
-			_, _, err = endpoint_dlp_rules.Update(ctx, service, id, rule)
-			if err != nil {
-				log.Printf("[ERROR] Failed to update order for rule ID %d: %v", id, err)
-			}
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	markOrderRuleAsDone(id, resourceType)
-	waitForReorder(resourceType)
-
 	return resourceEndpointDLPRulesRead(ctx, d, meta)
 }
 
@@ -601,30 +439,8 @@ func resourceEndpointDLPRulesDelete(ctx context.Context, d *schema.ResourceData,
 	if !ok {
 		log.Printf("[ERROR] endpoint dlp rule not set: %v\n", id)
 	}
-	log.Printf("[INFO] Deleting endpoint dlp rule ID: %v\n", (d.Id()))
 
-	if _, err := endpoint_dlp_rules.Delete(ctx, service, id); err != nil {
-		if strings.Contains(err.Error(), "RESOURCE_NOT_FOUND") {
-			log.Printf("[INFO] endpoint dlp rule %d not found, skipping deletion", id)
-			return nil
-		}
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] endpoint dlp rule deleted")
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, endpointDlpRuleAdapter{service}, id)
 }
 
 func expandEndpointDLPRules(d *schema.ResourceData) endpoint_dlp_rules.EndpointDlpRules {
@@ -660,7 +476,7 @@ func expandEndpointDLPRules(d *schema.ResourceData) endpoint_dlp_rules.EndpointD
 		Receiver:                  expandEndpointReceiver(d, "receiver"),
 		Auditor:                   expandSingleIDNameExtensions(d, "auditor"),
 		NotificationTemplate:      expandSingleIDNameExtensions(d, "notification_template"),
-		Groups:                    expandIDNameExtensionsSet(d, "groups"),
+		Groups:                    expandIDNameExtensionsIDs(d, "group_ids"),
 		Departments:               expandIDNameExtensionsSet(d, "departments"),
 		Users:                     expandIDNameExtensionsSet(d, "users"),
 		Resources:                 expandIDNameExtensionsSet(d, "resources"),
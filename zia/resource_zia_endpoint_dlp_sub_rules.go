@@ -6,10 +6,10 @@ import (
 	"log"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
@@ -18,21 +18,106 @@ import (
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/endpoint_dlp/endpoint_dlp_sub_rules"
 )
 
-// Sub-rules are scoped to a parent rule. The reorder engine is keyed per parent
-// so the contiguous 1..N ordering is maintained independently for each parent's
-// exception list. The starting-order map is keyed by parent rule ID.
-var (
-	endpointDlpSubRulesLock          sync.Mutex
-	endpointDlpSubRulesStartingOrder = make(map[int]int)
-)
+// endpointDlpSubRuleAdapter orders the sub-rules of one parent rule. The rule
+// order engine is keyed per parent so the contiguous 1..N ordering is
+// maintained independently for each parent's exception list. Sub-rules are
+// only listed through their parent, so every read goes through a live read of
+// its subRules block.
+type endpointDlpSubRuleAdapter struct {
+	service  *zscaler.Service
+	parentID int
+}
+
+func (a endpointDlpSubRuleAdapter) ResourceType() string {
+	return fmt.Sprintf("endpoint_dlp_sub_rules_%d", a.parentID)
+}
+
+func (a endpointDlpSubRuleAdapter) Ranked() bool { return false }
+
+func (a endpointDlpSubRuleAdapter) GetAll(ctx context.Context) ([]endpoint_dlp_sub_rules.EndpointDlpSubRules, error) {
+	parent, err := readEndpointDLPParentRule(ctx, a.service, a.parentID)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]endpoint_dlp_sub_rules.EndpointDlpSubRules, len(parent.SubRules))
+	for i := range parent.SubRules {
+		rules[i] = *subRuleFromParentEntry(parent.SubRules[i])
+	}
+	return rules, nil
+}
+
+func (a endpointDlpSubRuleAdapter) Get(ctx context.Context, id int) (*endpoint_dlp_sub_rules.EndpointDlpSubRules, error) {
+	rules, err := a.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		if rules[i].ID == id {
+			return &rules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("sub-rule %d not found under parent rule %d", id, a.parentID)
+}
+
+// Create adopts a sub-rule that already exists upstream under the same name
+// (typically because an earlier apply created it but failed to persist it to
+// state) and reconciles it to the desired config instead of failing with
+// DUPLICATE_ITEM.
+func (a endpointDlpSubRuleAdapter) Create(ctx context.Context, rule *endpoint_dlp_sub_rules.EndpointDlpSubRules) (int, error) {
+	resp, _, err := endpoint_dlp_sub_rules.Create(ctx, a.service, a.parentID, rule)
+	if err == nil {
+		return resp.ID, nil
+	}
+	if !isDuplicateItemError(err) {
+		return 0, err
+	}
+	existing, found := findEndpointDLPSubRuleByName(ctx, a.service, a.parentID, rule.Name)
+	if !found {
+		return 0, err
+	}
+	log.Printf("[WARN] endpoint dlp sub-rule %q already exists under parent %d (id %d); adopting and reconciling", rule.Name, a.parentID, existing.ID)
+	rule.ID = existing.ID
+	if err := a.Update(ctx, existing.ID, rule); err != nil {
+		return 0, err
+	}
+	return existing.ID, nil
+}
+
+func (a endpointDlpSubRuleAdapter) Update(ctx context.Context, id int, rule *endpoint_dlp_sub_rules.EndpointDlpSubRules) error {
+	_, _, err := endpoint_dlp_sub_rules.Update(ctx, a.service, a.parentID, id, rule)
+	return err
+}
+
+func (a endpointDlpSubRuleAdapter) Delete(ctx context.Context, id int) error {
+	if _, err := endpoint_dlp_sub_rules.Delete(ctx, a.service, a.parentID, id); err != nil {
+		if strings.Contains(err.Error(), "RESOURCE_NOT_FOUND") {
+			log.Printf("[INFO] endpoint dlp sub-rule %d not found, skipping deletion", id)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (a endpointDlpSubRuleAdapter) Order(rule *endpoint_dlp_sub_rules.EndpointDlpSubRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (a endpointDlpSubRuleAdapter) SetOrder(rule *endpoint_dlp_sub_rules.EndpointDlpSubRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+// StripReadOnly has nothing to do: subRuleFromParentEntry already drops the
+// read-only stamps of the parent's subRules block.
+func (a endpointDlpSubRuleAdapter) StripReadOnly(*endpoint_dlp_sub_rules.EndpointDlpSubRules) {}
 
 func resourceEndpointDLPSubRules() *schema.Resource {
-	return &schema.Resource{
+	return versionSchema(&schema.Resource{
 		CreateContext: resourceEndpointDLPSubRulesCreate,
 		ReadContext:   resourceEndpointDLPSubRulesRead,
 		UpdateContext: resourceEndpointDLPSubRulesUpdate,
 		DeleteContext: resourceEndpointDLPSubRulesDelete,
-		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		CustomizeDiff: customdiff.All(func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
 			// The endpoint application selectors are only valid when the data transfer
 			// method targets application file access.
 			hasEndpointApps := endpointBlockHasValues(d.Get("end_point_applications"), "zapp_id")
@@ -69,7 +154,7 @@ func resourceEndpointDLPSubRules() *schema.Resource {
 			}
 
 			return nil
-		},
+		}, validateRuleOrder("zia_endpoint_dlp_sub_rules")),
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
@@ -81,17 +166,23 @@ func resourceEndpointDLPSubRules() *schema.Resource {
 				service := zClient.Service
 
 				// A sub-rule can only be located through its parent, so the import
-				// key must carry both: "<parentRuleID>:<subRuleID>" or
-				// "<parentRuleID>:<subRuleName>".
+				// key must carry both: "<parentRule>:<subRule>", where either is
+				// an ID or a name.
 				raw := d.Id()
 				parts := strings.Split(raw, ":")
 				if len(parts) != 2 {
-					return nil, fmt.Errorf("invalid import id %q, expected format <parentRuleID>:<subRuleID> or <parentRuleID>:<subRuleName>", raw)
+					return nil, fmt.Errorf("invalid import id %q, expected format <parentRuleID|parentRuleName>:<subRuleID|subRuleName>", raw)
 				}
 
-				parentID, err := strconv.Atoi(parts[0])
+				parentID, err := resolveImportID(parts[0], func() ([]importCandidate, error) {
+					rules, err := endpoint_dlp_rules.GetAll(ctx, service)
+					if err != nil {
+						return nil, err
+					}
+					return namedImportCandidates(rules), nil
+				})
 				if err != nil {
-					return nil, fmt.Errorf("invalid parent rule id %q: %w", parts[0], err)
+					return nil, fmt.Errorf("parent rule: %w", err)
 				}
 				_ = d.Set("parent_rule", parentID)
 
@@ -100,20 +191,15 @@ func resourceEndpointDLPSubRules() *schema.Resource {
 					return nil, fmt.Errorf("unable to read parent rule %d: %w", parentID, err)
 				}
 
-				if subID, convErr := strconv.Atoi(parts[1]); convErr == nil {
-					d.SetId(strconv.Itoa(subID))
-					_ = d.Set("sub_rule_id", subID)
-					return []*schema.ResourceData{d}, nil
-				}
-
-				for _, sr := range parent.SubRules {
-					if strings.EqualFold(sr.Name, parts[1]) {
-						d.SetId(strconv.Itoa(sr.ID))
-						_ = d.Set("sub_rule_id", sr.ID)
-						return []*schema.ResourceData{d}, nil
-					}
+				subID, err := resolveImportID(parts[1], func() ([]importCandidate, error) {
+					return namedImportCandidates(parent.SubRules), nil
+				})
+				if err != nil {
+					return nil, fmt.Errorf("sub-rule under parent rule %d: %w", parentID, err)
 				}
-				return nil, fmt.Errorf("sub-rule %q not found under parent rule %d", parts[1], parentID)
+				d.SetId(strconv.Itoa(subID))
+				_ = d.Set("sub_rule_id", subID)
+				return []*schema.ResourceData{d}, nil
 			},
 		},
 
@@ -252,7 +338,7 @@ func resourceEndpointDLPSubRules() *schema.Resource {
 			"device_groups":                setIDsSchemaTypeCustom(nil, "This field is applicable for devices that are managed using Zscaler Client Connector."),
 			"devices":                      setIDsSchemaTypeCustom(nil, "Name-ID pairs of devices for which rule must be applied."),
 			"users":                        setIDsSchemaTypeCustom(nil, "The Name-ID pairs of users to which the DLP policy rule must be applied"),
-			"groups":                       setIDsSchemaTypeCustom(nil, "The Name-ID pairs of groups to which the DLP policy rule must be applied"),
+			"group_ids":                    setIntIDsSchemaType(nil, "The IDs of groups to which the DLP policy rule must be applied"),
 			"departments":                  setIDsSchemaTypeCustom(nil, "The Name-ID pairs of departments to which the DLP policy rule must be applied"),
 			"resources":                    setIDsSchemaTypeCustom(nil, "The Name-ID pairs of resources to which the DLP policy rule must be applied"),
 			"resource_groups":              setIDsSchemaTypeCustom(nil, "The Name-ID pairs of resource groups to which the DLP policy rule must be applied"),
@@ -278,108 +364,20 @@ func resourceEndpointDLPSubRules() *schema.Resource {
 				},
 			},
 		},
-	}
+	}, ruleIDBlocksV0)
 }
 
 func resourceEndpointDLPSubRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	parentID := d.Get("parent_rule").(int)
 	req := expandEndpointDLPSubRules(d)
 
 	log.Printf("[INFO] Creating zia endpoint dlp sub-rule under parent %d\n%+v\n", parentID, req)
 
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-	resourceType := fmt.Sprintf("endpoint_dlp_sub_rules_%d", parentID)
-
-	for {
-		endpointDlpSubRulesLock.Lock()
-		if endpointDlpSubRulesStartingOrder[parentID] == 0 {
-			if parent, err := readEndpointDLPParentRule(ctx, service, parentID); err == nil && parent != nil {
-				for _, sr := range parent.SubRules {
-					if sr.Order > endpointDlpSubRulesStartingOrder[parentID] {
-						endpointDlpSubRulesStartingOrder[parentID] = sr.Order
-					}
-				}
-			}
-			if endpointDlpSubRulesStartingOrder[parentID] == 0 {
-				endpointDlpSubRulesStartingOrder[parentID] = 1
-			}
-		}
-		endpointDlpSubRulesLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		order := req.Order
-		req.Order = endpointDlpSubRulesStartingOrder[parentID]
-
-		resp, _, err := endpoint_dlp_sub_rules.Create(ctx, service, parentID, &req)
-
-		// If the sub-rule already exists upstream (typically because an earlier
-		// apply created it but failed to persist it to state), adopt the
-		// existing sub-rule by name and reconcile it to the desired config
-		// instead of failing with DUPLICATE_ITEM.
-		if err != nil && isDuplicateItemError(err) {
-			if existing, found := findEndpointDLPSubRuleByName(ctx, service, parentID, req.Name); found {
-				log.Printf("[WARN] endpoint dlp sub-rule %q already exists under parent %d (id %d); adopting and reconciling", req.Name, parentID, existing.ID)
-				req.ID = existing.ID
-				if _, _, uerr := endpoint_dlp_sub_rules.Update(ctx, service, parentID, existing.ID, &req); uerr == nil {
-					created := req
-					resp = &created
-					err = nil
-				} else {
-					err = uerr
-				}
-			}
-		}
-
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") && !strings.Contains(err.Error(), "ICAP Receiver with id") {
-				if time.Since(start) < timeout {
-					time.Sleep(5 * time.Second)
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-		}
-
-		log.Printf("[INFO] Created zia endpoint dlp sub-rule. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp.ID)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("sub_rule_id", resp.ID)
-
-		// Converge the sub-rule's position within the parent's subRules block.
-		// The engine reads the block (via a live parent read) and only moves
-		// sub-rules whose order/rank differs from the declared value — exactly
-		// the pattern used by the other rule-based resources, applied to the
-		// nested subRules list.
-		reorderWithBeforeReorder(
-			OrderRule{Order: order, Rank: req.Rank},
-			resp.ID,
-			resourceType,
-			endpointDLPSubRulesGetCurrent(ctx, service, parentID),
-			endpointDLPSubRulesUpdateOrder(ctx, service, parentID),
-			nil,
-		)
-
-		markOrderRuleAsDone(resp.ID, resourceType)
-		waitForReorder(resourceType)
-		break
-	}
-
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	a := endpointDlpSubRuleAdapter{service: zClient.Service, parentID: parentID}
+	if diags := createOrderedRule(ctx, d, zClient, a, &req, "sub_rule_id"); diags.HasError() {
+		return diags
 	}
 
 	// Record state from a live read of the parent's subRules block after the
@@ -454,7 +452,7 @@ func setEndpointDLPSubRuleState(d *schema.ResourceData, r *endpoint_dlp_sub_rule
 	_ = d.Set("eun_template_id", r.EunTemplateId)
 	_ = d.Set("uc_template_id", r.UcTemplateId)
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(r.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(r.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 	if err := d.Set("departments", flattenIDExtensionsListIDs(r.Departments)); err != nil {
@@ -527,7 +525,6 @@ func findEndpointDLPSubRuleByName(ctx context.Context, service *zscaler.Service,
 
 func resourceEndpointDLPSubRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	parentID := d.Get("parent_rule").(int)
 	subID, ok := getIntFromResourceData(d, "sub_rule_id")
@@ -539,43 +536,11 @@ func resourceEndpointDLPSubRulesUpdate(ctx context.Context, d *schema.ResourceDa
 	req := expandEndpointDLPSubRules(d)
 	req.ID = subID
 
-	timeout := d.Timeout(schema.TimeoutUpdate)
-	start := time.Now()
-
-	for {
-		_, _, err := endpoint_dlp_sub_rules.Update(ctx, service, parentID, subID, &req)
-
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			log.Printf("[INFO] Retrying due to API error: %s", err)
-			if time.Since(start) < timeout {
-				time.Sleep(5 * time.Second)
-				continue
-			}
-			return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-		}
-
-		break
+	a := endpointDlpSubRuleAdapter{service: zClient.Service, parentID: parentID}
+	if diags := updateOrderedRule(ctx, d, zClient, a, subID, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
 
-	// Converge the sub-rule's position within the parent's subRules block after
-	// the update, mirroring the other rule-based resources.
-	resourceType := fmt.Sprintf("endpoint_dlp_sub_rules_%d", parentID)
-	reorderWithBeforeReorder(
-		OrderRule{Order: req.Order, Rank: req.Rank},
-		subID,
-		resourceType,
-		endpointDLPSubRulesGetCurrent(ctx, service, parentID),
-		endpointDLPSubRulesUpdateOrder(ctx, service, parentID),
-		nil,
-	)
-
-	markOrderRuleAsDone(subID, resourceType)
-	waitForReorder(resourceType)
-
 	// Record state from a live read of the parent's subRules block after the
 	// reorder has converged.
 	return resourceEndpointDLPSubRulesRead(ctx, d, meta)
@@ -583,35 +548,14 @@ func resourceEndpointDLPSubRulesUpdate(ctx context.Context, d *schema.ResourceDa
 
 func resourceEndpointDLPSubRulesDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	parentID := d.Get("parent_rule").(int)
 	subID, ok := getIntFromResourceData(d, "sub_rule_id")
 	if !ok {
 		log.Printf("[ERROR] endpoint dlp sub-rule ID not set: %v\n", subID)
 	}
-	log.Printf("[INFO] Deleting endpoint dlp sub-rule ID: %v (parent %d)\n", d.Id(), parentID)
-
-	if _, err := endpoint_dlp_sub_rules.Delete(ctx, service, parentID, subID); err != nil {
-		if strings.Contains(err.Error(), "RESOURCE_NOT_FOUND") {
-			log.Printf("[INFO] endpoint dlp sub-rule %d not found, skipping deletion", subID)
-			return nil
-		}
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] endpoint dlp sub-rule deleted")
-
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
 
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, endpointDlpSubRuleAdapter{service: zClient.Service, parentID: parentID}, subID)
 }
 
 // readEndpointDLPParentRule returns the parent rule (including its subRules
@@ -637,62 +581,6 @@ func readEndpointDLPParentRule(ctx context.Context, service *zscaler.Service, pa
 	return &rule, nil
 }
 
-// endpointDLPSubRulesGetCurrent returns the reorder engine's snapshot callback:
-// the current Order/Rank of every sub-rule under the given parent.
-func endpointDLPSubRulesGetCurrent(ctx context.Context, service *zscaler.Service, parentID int) func() (map[int]OrderRule, error) {
-	return func() (map[int]OrderRule, error) {
-		parent, err := readEndpointDLPParentRule(ctx, service, parentID)
-		if err != nil {
-			return nil, err
-		}
-		m := make(map[int]OrderRule, len(parent.SubRules))
-		for _, r := range parent.SubRules {
-			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-		}
-		return m, nil
-	}
-}
-
-// endpointDLPSubRulesUpdateOrder returns the reorder engine's update callback.
-// It re-sends a sub-rule through the dedicated sub-rule endpoint with the new
-// order/rank, sourcing the full body from the parent's sub-rule list.
-func endpointDLPSubRulesUpdateOrder(ctx context.Context, service *zscaler.Service, parentID int) func(id int, order OrderRule) error {
-	return func(id int, order OrderRule) error {
-		parent, err := readEndpointDLPParentRule(ctx, service, parentID)
-		if err != nil {
-			return err
-		}
-		var entry *endpoint_dlp_rules.EndpointDlpRules
-		for i := range parent.SubRules {
-			if parent.SubRules[i].ID == id {
-				entry = &parent.SubRules[i]
-				break
-			}
-		}
-		if entry == nil {
-			return fmt.Errorf("sub-rule %d not found under parent rule %d during reorder", id, parentID)
-		}
-		if entry.Order == order.Order && entry.Rank == order.Rank {
-			return nil
-		}
-
-		// Re-send the full sub-rule body sourced from the parent's subRules
-		// block (a live read), changing only its position. Every field the API
-		// requires — including data_transfer_method — is present in the block.
-		sub := subRuleFromParentEntry(*entry)
-		sub.Order = order.Order
-		sub.Rank = order.Rank
-
-		log.Printf("[DEBUG] Updating sub-rule ID %d (parent ID: %d) to order %d", id, parentID, order.Order)
-
-		if _, _, err := endpoint_dlp_sub_rules.Update(ctx, service, parentID, id, sub); err != nil {
-			log.Printf("[ERROR] Failed to update order for sub-rule ID %d: %v", id, err)
-			return err
-		}
-		return nil
-	}
-}
-
 // subRuleFromParentEntry converts a sub-rule as returned inside a parent rule
 // (endpoint_dlp_rules.EndpointDlpRules) into the write payload used by the
 // dedicated sub-rule endpoint. Read-only stamps are dropped.
@@ -768,7 +656,7 @@ func expandEndpointDLPSubRules(d *schema.ResourceData) endpoint_dlp_sub_rules.En
 		Receiver:                  expandEndpointReceiver(d, "receiver"),
 		Auditor:                   expandSingleIDNameExtensions(d, "auditor"),
 		NotificationTemplate:      expandSingleIDNameExtensions(d, "notification_template"),
-		Groups:                    expandIDNameExtensionsSet(d, "groups"),
+		Groups:                    expandIDNameExtensionsIDs(d, "group_ids"),
 		Departments:               expandIDNameExtensionsSet(d, "departments"),
 		Users:                     expandIDNameExtensionsSet(d, "users"),
 		Resources:                 expandIDNameExtensionsSet(d, "resources"),
@@ -21,9 +21,9 @@ func TestAccResourceEndpointDLPSubRules_Basic(t *testing.T) {
 	resourceTypeAndName, _, generatedName := method.GenerateRandomSourcesTypeAndName(resourcetype.DLPEndpointSubRules)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckEndpointDLPSubRulesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckEndpointDLPSubRulesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckEndpointDLPSubRulesConfigure(resourceTypeAndName, generatedName, variable.DLPEndpointSubRuleDescription, variable.DLPEndpointSubRuleAction, variable.DLPEndpointSubRuleState),
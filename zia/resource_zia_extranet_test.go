@@ -24,9 +24,9 @@ func TestAccResourceExtranet(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckExtranetDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckExtranetDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckExtranetConfigure(resourceTypeAndName, initialName, variable.ExtranetDescription),
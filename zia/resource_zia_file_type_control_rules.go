@@ -4,32 +4,74 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
-	"sort"
-	"strconv"
-	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/filetypecontrol"
 )
 
-var (
-	fileTypeLock          sync.Mutex
-	fileTypeStartingOrder int
-)
+// fileTypeRuleAdapter is the OrderedRuleAdapter of file type control rules.
+type fileTypeRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (fileTypeRuleAdapter) ResourceType() string { return "file_type_control_rules" }
+func (fileTypeRuleAdapter) Ranked() bool         { return true }
+
+func (a fileTypeRuleAdapter) GetAll(ctx context.Context) ([]filetypecontrol.FileTypeRules, error) {
+	return filetypecontrol.GetAll(ctx, a.service)
+}
+
+func (a fileTypeRuleAdapter) Get(ctx context.Context, id int) (*filetypecontrol.FileTypeRules, error) {
+	return filetypecontrol.Get(ctx, a.service, id)
+}
+
+func (a fileTypeRuleAdapter) Create(ctx context.Context, rule *filetypecontrol.FileTypeRules) (int, error) {
+	resp, err := filetypecontrol.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a fileTypeRuleAdapter) Update(ctx context.Context, id int, rule *filetypecontrol.FileTypeRules) error {
+	_, err := filetypecontrol.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a fileTypeRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := filetypecontrol.Delete(ctx, a.service, id)
+	return err
+}
+
+func (fileTypeRuleAdapter) Order(rule *filetypecontrol.FileTypeRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (fileTypeRuleAdapter) SetOrder(rule *filetypecontrol.FileTypeRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (fileTypeRuleAdapter) StripReadOnly(rule *filetypecontrol.FileTypeRules) {
+	// to avoid the STALE_CONFIGURATION_ERROR
+	rule.LastModifiedTime = 0
+	rule.LastModifiedBy = nil
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.AccessControl = ""
+}
 
 func resourceFileTypeControlRules() *schema.Resource {
-	return &schema.Resource{
+	return versionSchema(&schema.Resource{
 		CreateContext: resourceFileTypeControlRulesCreate,
 		ReadContext:   resourceFileTypeControlRulesRead,
 		UpdateContext: resourceFileTypeControlRulesUpdate,
 		DeleteContext: resourceFileTypeControlRulesDelete,
-		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		CustomizeDiff: customdiff.All(func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
 			// Check if active_content is enabled
 			if activeContent, ok := d.GetOk("active_content"); ok && activeContent.(bool) {
 				// Validate file_types
@@ -67,33 +109,13 @@ func resourceFileTypeControlRules() *schema.Resource {
 			}
 
 			return nil
-		},
+		}, validateRuleOrder("zia_file_type_control_rules")),
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := filetypecontrol.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", filetypecontrol.GetAll),
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:     schema.TypeString,
@@ -228,10 +250,10 @@ func resourceFileTypeControlRules() *schema.Resource {
 			},
 			"device_groups":       setIDsSchemaTypeCustom(nil, "This field is applicable for devices that are managed using Zscaler Client Connector."),
 			"devices":             setIDsSchemaTypeCustom(nil, "Name-ID pairs of devices for which rule must be applied."),
-			"locations":           setIDsSchemaTypeCustom(nil, "Name-ID pairs of locations for the which policy must be applied. If not set, policy is applied for all locations."),
+			"location_ids":        setIntIDsSchemaType(nil, "IDs of locations for the which policy must be applied. If not set, policy is applied for all locations."),
 			"location_groups":     setIDsSchemaTypeCustom(nil, "Name-ID pairs of locations groups for which rule must be applied."),
 			"departments":         setIDsSchemaTypeCustom(nil, "The Name-ID pairs of departments to which the File Type Control rule must be applied."),
-			"groups":              setIDsSchemaTypeCustom(nil, "The Name-ID pairs of groups to which the File Type Control rule must be applied."),
+			"group_ids":           setIntIDsSchemaType(nil, "The IDs of groups to which the File Type Control rule must be applied."),
 			"users":               setIDsSchemaTypeCustom(nil, "The Name-ID pairs of users to which the File Type Control rule must be applied."),
 			"time_windows":        setIDsSchemaTypeCustom(intPtr(2), "list of time interval during which rule must be enforced."),
 			"labels":              setIDsSchemaTypeCustom(intPtr(1), "list of Labels that are applicable to the rule."),
@@ -239,104 +261,18 @@ func resourceFileTypeControlRules() *schema.Resource {
 			"device_trust_levels": getDeviceTrustLevels(),
 			"protocols":           getFileTypeProtocols(),
 		},
-	}
+	}, ruleIDBlocksV0)
 }
 
 func resourceFileTypeControlRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandFileTypeControlRules(d)
 	log.Printf("[INFO] Creating zia file type control rule rule\n%+v\n", req)
 
-	start := time.Now()
-
-	fileTypeLock.Lock()
-	if fileTypeStartingOrder == 0 {
-		list, _ := filetypecontrol.GetAll(ctx, service)
-		for _, r := range list {
-			if r.Order > fileTypeStartingOrder {
-				fileTypeStartingOrder = r.Order
-			}
-		}
-		if fileTypeStartingOrder == 0 {
-			fileTypeStartingOrder = 1
-		}
-	}
-	fileTypeLock.Unlock()
-	startWithoutLocking := time.Now()
-
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	if intendedRank < 7 {
-		req.Rank = 7
-	}
-	req.Order = fileTypeStartingOrder
-	resp, err := filetypecontrol.Create(ctx, service, &req)
-
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-
-	if err != nil {
-		reg := regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
-		if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-			if reg.MatchString(err.Error()) {
-				return diag.FromErr(fmt.Errorf("error creating resource: %s, please check the order %d vs rank %d, current rules:%s , err:%s", req.Name, intendedOrder, req.Rank, currentFileTypeControlOrderVsRankWording(ctx, zClient), err))
-			}
-		}
-		return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
+	if diags := createOrderedRule(ctx, d, zClient, fileTypeRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
-
-	log.Printf("[INFO] Created zia file type control rule rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-	resourceType := "file_type_control_rules"
-
-	reorderWithBeforeReorder(
-		OrderRule{Order: intendedOrder, Rank: intendedRank},
-		resp.ID,
-		resourceType,
-		func() (map[int]OrderRule, error) {
-			allRules, err := filetypecontrol.GetAll(ctx, service)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := filetypecontrol.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = filetypecontrol.Update(ctx, service, id, rule)
-			return err
-		},
-		nil,
-	)
-
-	d.SetId(strconv.Itoa(resp.ID))
-	_ = d.Set("rule_id", resp.ID)
-
-	markOrderRuleAsDone(resp.ID, resourceType)
-	waitForReorder(resourceType)
-
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
 	return resourceFileTypeControlRulesRead(ctx, d, meta)
 }
 
@@ -348,7 +284,10 @@ func resourceFileTypeControlRulesRead(ctx context.Context, d *schema.ResourceDat
 	if !ok {
 		return diag.FromErr(fmt.Errorf("no zia file type control rule id is set"))
 	}
-	resp, err := filetypecontrol.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_file_type_control_rules", id,
+		func() ([]filetypecontrol.FileTypeRules, error) { return filetypecontrol.GetAll(ctx, service) },
+		func(r *filetypecontrol.FileTypeRules) int { return r.ID },
+		func() (*filetypecontrol.FileTypeRules, error) { return filetypecontrol.Get(ctx, service, id) })
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing file type control rule %s from state because it no longer exists in ZIA", d.Id())
@@ -390,7 +329,7 @@ func resourceFileTypeControlRulesRead(ctx context.Context, d *schema.ResourceDat
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -398,7 +337,7 @@ func resourceFileTypeControlRulesRead(ctx context.Context, d *schema.ResourceDat
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -427,7 +366,6 @@ func resourceFileTypeControlRulesRead(ctx context.Context, d *schema.ResourceDat
 
 func resourceFileTypeControlRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -436,111 +374,21 @@ func resourceFileTypeControlRulesUpdate(ctx context.Context, d *schema.ResourceD
 	log.Printf("[INFO] Updating file type control rule ID: %v\n", id)
 	req := expandFileTypeControlRules(d)
 
-	if _, err := filetypecontrol.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-
-	existingRules, err := filetypecontrol.GetAll(ctx, service)
-	if err != nil {
-		log.Printf("[ERROR] error getting all file type control rules: %v", err)
-	}
-	sort.Slice(existingRules, func(i, j int) bool {
-		return existingRules[i].Rank < existingRules[j].Rank || (existingRules[i].Rank == existingRules[j].Rank && existingRules[i].Order < existingRules[j].Order)
-	})
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	nextAvailableOrder := existingRules[len(existingRules)-1].Order
-	// always start rank 7 rules at the next available order after all ranked rules
-	req.Rank = 7
-
-	req.Order = nextAvailableOrder
-
-	_, err = filetypecontrol.Update(ctx, service, id, &req)
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
+	if diags := updateOrderedRule(ctx, d, zClient, fileTypeRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
-	reorderWithBeforeReorder(OrderRule{Order: intendedOrder, Rank: intendedRank}, req.ID, "file_type_control_rules",
-		func() (map[int]OrderRule, error) {
-			allRules, err := filetypecontrol.GetAll(ctx, service)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := filetypecontrol.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			// to avoid the STALE_CONFIGURATION_ERROR
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			// Strip read-only fields that cause "Request body is invalid" for predefined rules
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = filetypecontrol.Update(ctx, service, id, rule)
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	markOrderRuleAsDone(req.ID, "file_type_control_rules")
-	waitForReorder("file_type_control_rules")
-
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
 	return resourceFileTypeControlRulesRead(ctx, d, meta)
 }
 
 func resourceFileTypeControlRulesDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
 		log.Printf("[ERROR] file type control rule not set: %v\n", id)
 	}
-	log.Printf("[INFO] Deleting file type control rule ID: %v\n", (d.Id()))
-
-	if _, err := filetypecontrol.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] file type control rule deleted")
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
 
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, fileTypeRuleAdapter{zClient.Service}, id)
 }
 
 func expandFileTypeControlRules(d *schema.ResourceData) filetypecontrol.FileTypeRules {
@@ -575,9 +423,9 @@ func expandFileTypeControlRules(d *schema.ResourceData) filetypecontrol.FileType
 		CloudApplications:    SetToStringList(d, "cloud_applications"),
 		DeviceGroups:         expandIDNameExtensionsSet(d, "device_groups"),
 		Devices:              expandIDNameExtensionsSet(d, "devices"),
-		Locations:            expandIDNameExtensionsSet(d, "locations"),
+		Locations:            expandIDNameExtensionsIDs(d, "location_ids"),
 		LocationGroups:       expandIDNameExtensionsSet(d, "location_groups"),
-		Groups:               expandIDNameExtensionsSet(d, "groups"),
+		Groups:               expandIDNameExtensionsIDs(d, "group_ids"),
 		Departments:          expandIDNameExtensionsSet(d, "departments"),
 		Users:                expandIDNameExtensionsSet(d, "users"),
 		TimeWindows:          expandIDNameExtensionsSet(d, "time_windows"),
@@ -586,21 +434,3 @@ func expandFileTypeControlRules(d *schema.ResourceData) filetypecontrol.FileType
 	}
 	return result
 }
-
-func currentFileTypeControlOrderVsRankWording(ctx context.Context, zClient *Client) string {
-	service := zClient.Service
-
-	list, err := filetypecontrol.GetAll(ctx, service)
-	if err != nil {
-		return ""
-	}
-	result := ""
-	for i, r := range list {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("Rank %d VS Order %d", r.Rank, r.Order)
-
-	}
-	return result
-}
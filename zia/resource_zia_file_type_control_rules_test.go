@@ -24,9 +24,9 @@ func TestAccResourceFileTypeControlRules_Basic(t *testing.T) {
 	ruleLabelHCL := testAccCheckRuleLabelsConfigure(ruleLabelTypeAndName, "tf-acc-test-"+ruleLabelGeneratedName, variable.RuleLabelDescription)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckFileTypeControlRulesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckFileTypeControlRulesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckFileTypeControlRulesConfigure(resourceTypeAndName, generatedName, generatedName, variable.FileTypeControlRuleDescription, variable.FileTypeControlRuleAction, variable.FileTypeControlRuleState, ruleLabelTypeAndName, ruleLabelHCL),
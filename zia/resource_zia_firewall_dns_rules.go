@@ -4,57 +4,91 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
-	"sort"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewalldnscontrolpolicies"
 )
 
-var (
-	firewallDNSLock          sync.Mutex
-	firewallDNSStartingOrder int
-)
+// firewallDNSRuleAdapter is the OrderedRuleAdapter of firewall DNS control
+// rules.
+type firewallDNSRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (firewallDNSRuleAdapter) ResourceType() string { return "firewall_dns_rule" }
+func (firewallDNSRuleAdapter) Ranked() bool         { return true }
+
+func (a firewallDNSRuleAdapter) GetAll(ctx context.Context) ([]firewalldnscontrolpolicies.FirewallDNSRules, error) {
+	return firewalldnscontrolpolicies.GetAll(ctx, a.service)
+}
+
+func (a firewallDNSRuleAdapter) Get(ctx context.Context, id int) (*firewalldnscontrolpolicies.FirewallDNSRules, error) {
+	return firewalldnscontrolpolicies.Get(ctx, a.service, id)
+}
+
+func (a firewallDNSRuleAdapter) Create(ctx context.Context, rule *firewalldnscontrolpolicies.FirewallDNSRules) (int, error) {
+	resp, err := firewalldnscontrolpolicies.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a firewallDNSRuleAdapter) Update(ctx context.Context, id int, rule *firewalldnscontrolpolicies.FirewallDNSRules) error {
+	_, err := firewalldnscontrolpolicies.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a firewallDNSRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := firewalldnscontrolpolicies.Delete(ctx, a.service, id)
+	return err
+}
+
+func (firewallDNSRuleAdapter) Order(rule *firewalldnscontrolpolicies.FirewallDNSRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (firewallDNSRuleAdapter) SetOrder(rule *firewalldnscontrolpolicies.FirewallDNSRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (firewallDNSRuleAdapter) StripReadOnly(rule *firewalldnscontrolpolicies.FirewallDNSRules) {
+	// to avoid the STALE_CONFIGURATION_ERROR
+	rule.LastModifiedTime = 0
+	rule.LastModifiedBy = nil
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.Predefined = false
+	rule.DefaultRule = false
+	rule.AccessControl = ""
+}
+
+func (a firewallDNSRuleAdapter) GetByName(ctx context.Context, name string) (*firewalldnscontrolpolicies.FirewallDNSRules, error) {
+	return firewalldnscontrolpolicies.GetByName(ctx, a.service, name)
+}
+
+func (firewallDNSRuleAdapter) IsPredefined(rule *firewalldnscontrolpolicies.FirewallDNSRules) bool {
+	return rule.Predefined || rule.DefaultRule
+}
 
 func resourceFirewallDNSRules() *schema.Resource {
-	return &schema.Resource{
+	return withAdoptPredefined(versionSchema(&schema.Resource{
 		CreateContext: resourceFirewallDNSRulesCreate,
 		ReadContext:   resourceFirewallDNSRulesRead,
 		UpdateContext: resourceFirewallDNSRulesUpdate,
 		DeleteContext: resourceFirewallDNSRulesDelete,
+		CustomizeDiff: validateRuleOrder("zia_firewall_dns_rule"),
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := firewalldnscontrolpolicies.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", firewalldnscontrolpolicies.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -206,10 +240,10 @@ func resourceFirewallDNSRules() *schema.Resource {
 				https://registry.terraform.io/providers/zscaler/zia/latest/docs/data-sources/zia_cloud_applications
 				`,
 			},
-			"locations":                    setIDsSchemaTypeCustom(nil, "list of locations for which rule must be applied"),
+			"location_ids":                 setIntIDsSchemaType(nil, "IDs of locations for which rule must be applied"),
 			"location_groups":              setIDsSchemaTypeCustom(nil, "list of locations groups"),
 			"users":                        setIDsSchemaTypeCustom(nil, "list of users for which rule must be applied"),
-			"groups":                       setIDsSchemaTypeCustom(nil, "list of groups for which rule must be applied"),
+			"group_ids":                    setIntIDsSchemaType(nil, "IDs of groups for which rule must be applied"),
 			"departments":                  setIDsSchemaTypeCustom(nil, "list of departments for which rule must be applied"),
 			"time_windows":                 setIDsSchemaTypeCustom(nil, "The time interval in which the Firewall Filtering policy rule applies"),
 			"labels":                       setIDsSchemaTypeCustom(intPtr(1), "list of Labels that are applicable to the rule."),
@@ -231,7 +265,7 @@ func resourceFirewallDNSRules() *schema.Resource {
 			"protocols":                    getDNSRuleProtocols(),
 		},
 		// CustomizeDiff: firewallDNSCategoriesMirrorCustomizeDiff,
-	}
+	}, ruleIDBlocksV0))
 }
 
 /*
@@ -297,7 +331,6 @@ func stringSlicesEqualAsSets(a, b []string) bool {
 
 func resourceFirewallDNSRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	// if err := firewallDNSCategorySetsMustMatch(
 	// 	SetToStringList(d, "res_categories"),
@@ -309,114 +342,10 @@ func resourceFirewallDNSRulesCreate(ctx context.Context, d *schema.ResourceData,
 	req := expandFirewallDNSRules(d)
 	log.Printf("[INFO] Creating zia firewall dns rule\n%+v\n", req)
 
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-
-	for {
-		firewallDNSLock.Lock()
-		if firewallDNSStartingOrder == 0 {
-			list, _ := firewalldnscontrolpolicies.GetAll(ctx, service)
-			for _, r := range list {
-				if r.Order > firewallDNSStartingOrder {
-					firewallDNSStartingOrder = r.Order
-				}
-			}
-			if firewallDNSStartingOrder == 0 {
-				firewallDNSStartingOrder = 1
-			}
-		}
-		firewallDNSLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		intendedOrder := req.Order
-		intendedRank := req.Rank
-		if intendedRank < 7 {
-			// always start rank 7 rules at the next available order after all ranked rules
-			req.Rank = 7
-		}
-		req.Order = firewallDNSStartingOrder
-
-		resp, err := firewalldnscontrolpolicies.Create(ctx, service, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			reg := regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				if reg.MatchString(err.Error()) {
-					return diag.FromErr(fmt.Errorf("error creating resource: %s, please check the order %d vs rank %d, current rules:%s , err:%s", req.Name, intendedOrder, req.Rank, currentFirewallDNSOrderVsRankWording(ctx, zClient), err))
-				}
-				if time.Since(start) < timeout {
-					log.Printf("[INFO] Creating firewall dns rule name: %v, got INVALID_INPUT_ARGUMENT\n", req.Name)
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-		}
-
-		log.Printf("[INFO] Created zia firewall dns rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-		// Use separate resource type for rank 7 rules to avoid mixing with ranked rules
-		resourceType := "firewall_dns_rule"
-
-		reorderWithBeforeReorder(
-			OrderRule{Order: intendedOrder, Rank: intendedRank},
-			resp.ID,
-			resourceType,
-			func() (map[int]OrderRule, error) {
-				allRules, err := firewalldnscontrolpolicies.GetAll(ctx, service)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(allRules))
-				for _, r := range allRules {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				// Custom updateOrder that handles predefined rules
-				rule, err := firewalldnscontrolpolicies.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-				// to avoid the STALE_CONFIGURATION_ERROR
-				rule.LastModifiedTime = 0
-				rule.LastModifiedBy = nil
-				// Strip read-only fields that cause "Request body is invalid" for predefined rules
-				rule.Predefined = false
-				rule.DefaultRule = false
-				rule.AccessControl = ""
-				rule.Order = order.Order
-				rule.Rank = order.Rank
-				_, err = firewalldnscontrolpolicies.Update(ctx, service, id, rule)
-				return err
-			},
-			nil, // Remove beforeReorder function to avoid adding too many rules to the map
-		)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		markOrderRuleAsDone(resp.ID, resourceType)
-		waitForReorder(resourceType)
-
-		// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-		if shouldActivate() {
-			// Sleep for 2 seconds before potentially triggering the activation
-			time.Sleep(2 * time.Second)
-			if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-				return diag.FromErr(activationErr)
-			}
-		} else {
-			log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-		}
-
-		return resourceFirewallDNSRulesRead(ctx, d, meta)
+	if diags := createOrderedRule(ctx, d, zClient, firewallDNSRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
+	return resourceFirewallDNSRulesRead(ctx, d, meta)
 }
 
 func resourceFirewallDNSRulesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -428,7 +357,14 @@ func resourceFirewallDNSRulesRead(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(fmt.Errorf("no zia firewall dns rule id is set"))
 	}
 
-	resp, err := firewalldnscontrolpolicies.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_firewall_dns_rule", id,
+		func() ([]firewalldnscontrolpolicies.FirewallDNSRules, error) {
+			return firewalldnscontrolpolicies.GetAll(ctx, service)
+		},
+		func(r *firewalldnscontrolpolicies.FirewallDNSRules) int { return r.ID },
+		func() (*firewalldnscontrolpolicies.FirewallDNSRules, error) {
+			return firewalldnscontrolpolicies.Get(ctx, service, id)
+		})
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing firewall dns rule %s from state because it no longer exists in ZIA", d.Id())
@@ -491,7 +427,7 @@ func resourceFirewallDNSRulesRead(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -503,7 +439,7 @@ func resourceFirewallDNSRulesRead(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -558,7 +494,6 @@ func resourceFirewallDNSRulesRead(ctx context.Context, d *schema.ResourceData, m
 
 func resourceFirewallDNSRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -576,82 +511,9 @@ func resourceFirewallDNSRulesUpdate(ctx context.Context, d *schema.ResourceData,
 
 	req := expandFirewallDNSRules(d)
 
-	if _, err := firewalldnscontrolpolicies.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-
-	existingRules, err := firewalldnscontrolpolicies.GetAll(ctx, service)
-	if err != nil {
-		log.Printf("[ERROR] error getting all firewall dns rules: %v", err)
-	}
-	sort.Slice(existingRules, func(i, j int) bool {
-		return existingRules[i].Rank < existingRules[j].Rank || (existingRules[i].Rank == existingRules[j].Rank && existingRules[i].Order < existingRules[j].Order)
-	})
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	nextAvailableOrder := existingRules[len(existingRules)-1].Order
-	// always start rank 7 rules at the next available order after all ranked rules
-	req.Rank = 7
-
-	req.Order = nextAvailableOrder
-
-	_, err = firewalldnscontrolpolicies.Update(ctx, service, id, &req)
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-	}
-
-	reorderWithBeforeReorder(OrderRule{Order: intendedOrder, Rank: intendedRank}, req.ID, "firewall_dns_rule",
-		func() (map[int]OrderRule, error) {
-			allRules, err := firewalldnscontrolpolicies.GetAll(ctx, service)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := firewalldnscontrolpolicies.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			// to avoid the STALE_CONFIGURATION_ERROR
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			// Strip read-only fields that cause "Request body is invalid" for predefined rules
-			rule.Predefined = false
-			rule.DefaultRule = false
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = firewalldnscontrolpolicies.Update(ctx, service, id, rule)
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	markOrderRuleAsDone(req.ID, "firewall_dns_rule")
-	waitForReorder("firewall_dns_rule")
-
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := updateOrderedRule(ctx, d, zClient, firewallDNSRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
 	return resourceFirewallDNSRulesRead(ctx, d, meta)
 }
 
@@ -664,36 +526,21 @@ func resourceFirewallDNSRulesDelete(ctx context.Context, d *schema.ResourceData,
 		log.Printf("[ERROR] firewall dns rule not set: %v\n", id)
 	}
 
-	// Retrieve the rule to check if it's predefined
-	rule, err := firewalldnscontrolpolicies.Get(ctx, service, id)
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error retrieving firewall DNS rule %d: %v", id, err))
-	}
-
-	// Prevent deletion if the rule is predefined
-	if rule.Predefined {
-		return diag.FromErr(fmt.Errorf("deletion of predefined rule '%s' is not allowed", rule.Name))
-	}
-
-	log.Printf("[INFO] Deleting firewall dns rule ID: %v\n", (d.Id()))
-	if _, err := firewalldnscontrolpolicies.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] firewall dns rule deleted")
+	// Adopted predefined rules are restored rather than deleted
+	if !adoptsPredefined(d) {
+		// Retrieve the rule to check if it's predefined
+		rule, err := firewalldnscontrolpolicies.Get(ctx, service, id)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error retrieving firewall DNS rule %d: %v", id, err))
+		}
 
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
+		// Prevent deletion if the rule is predefined
+		if rule.Predefined {
+			return diag.FromErr(fmt.Errorf("deletion of predefined rule '%s' is not allowed", rule.Name))
 		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
 	}
 
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, firewallDNSRuleAdapter{service}, id)
 }
 
 func expandFirewallDNSRules(d *schema.ResourceData) firewalldnscontrolpolicies.FirewallDNSRules {
@@ -737,10 +584,10 @@ func expandFirewallDNSRules(d *schema.ResourceData) firewalldnscontrolpolicies.F
 		DestCountries:                processedDestCountries,
 		SourceCountries:              processedSourceCountries,
 		ApplicationGroups:            expandIDNameExtensionsSet(d, "application_groups"),
-		Locations:                    expandIDNameExtensionsSet(d, "locations"),
+		Locations:                    expandIDNameExtensionsIDs(d, "location_ids"),
 		LocationsGroups:              expandIDNameExtensionsSet(d, "location_groups"),
 		Departments:                  expandIDNameExtensionsSet(d, "departments"),
-		Groups:                       expandIDNameExtensionsSet(d, "groups"),
+		Groups:                       expandIDNameExtensionsIDs(d, "group_ids"),
 		Users:                        expandIDNameExtensionsSet(d, "users"),
 		TimeWindows:                  expandIDNameExtensionsSet(d, "time_windows"),
 		SrcIpGroups:                  expandIDNameExtensionsSet(d, "src_ip_groups"),
@@ -758,21 +605,3 @@ func expandFirewallDNSRules(d *schema.ResourceData) firewalldnscontrolpolicies.F
 	}
 	return result
 }
-
-func currentFirewallDNSOrderVsRankWording(ctx context.Context, zClient *Client) string {
-	service := zClient.Service
-
-	list, err := firewalldnscontrolpolicies.GetAll(ctx, service)
-	if err != nil {
-		return ""
-	}
-	result := ""
-	for i, r := range list {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("Rank %d VS Order %d", r.Rank, r.Order)
-
-	}
-	return result
-}
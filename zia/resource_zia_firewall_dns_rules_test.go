@@ -28,9 +28,9 @@ func TestAccResourceFirewallDNSRules_Basic(t *testing.T) {
 	sourceIPGroupHCL := testAccCheckFWIPSourceGroupsConfigure(sourceIPGroupTypeAndName, "tf-acc-test-"+sourceIPGroupGeneratedName, variable.FWSRCGroupDescription)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckFirewallDNSRulesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckFirewallDNSRulesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckFirewallDNSRulesConfigure(resourceTypeAndName, generatedName, generatedName, variable.FWDNSRuleDescription, variable.FWDNSAction, variable.FWDNSState, ruleLabelTypeAndName, ruleLabelHCL, sourceIPGroupTypeAndName, sourceIPGroupHCL),
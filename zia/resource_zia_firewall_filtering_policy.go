@@ -0,0 +1,20 @@
+package zia
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/filteringrules"
+)
+
+func resourceFirewallFilteringPolicy() *schema.Resource {
+	return policySetResource(policySet[filteringrules.FirewallFilteringRules]{
+		id:       "firewall_filtering_policy",
+		ruleType: "zia_firewall_filtering_rule",
+		rule:     resourceFirewallFilteringRules,
+		adapter: func(service *zscaler.Service) OrderedRuleAdapter[filteringrules.FirewallFilteringRules] {
+			return firewallFilteringRuleAdapter{service}
+		},
+		expand: expandFirewallFilteringRules,
+		name:   func(rule *filteringrules.FirewallFilteringRules) string { return rule.Name },
+	})
+}
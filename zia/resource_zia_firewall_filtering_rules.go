@@ -4,56 +4,93 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
-	"sort"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/filteringrules"
 )
 
-var (
-	firewallFilteringLock          sync.Mutex
-	firewallFilteringStartingOrder int
-)
+// firewallFilteringRuleAdapter is the OrderedRuleAdapter of firewall filtering
+// rules, which start past the last rule.
+type firewallFilteringRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (firewallFilteringRuleAdapter) ResourceType() string { return "firewall_filtering_rules" }
+func (firewallFilteringRuleAdapter) Ranked() bool         { return true }
+func (firewallFilteringRuleAdapter) startAfterLastRule()  {}
+
+func (a firewallFilteringRuleAdapter) GetAll(ctx context.Context) ([]filteringrules.FirewallFilteringRules, error) {
+	return filteringrules.GetAll(ctx, a.service, nil)
+}
+
+func (a firewallFilteringRuleAdapter) Get(ctx context.Context, id int) (*filteringrules.FirewallFilteringRules, error) {
+	return filteringrules.Get(ctx, a.service, id)
+}
+
+func (a firewallFilteringRuleAdapter) Create(ctx context.Context, rule *filteringrules.FirewallFilteringRules) (int, error) {
+	resp, err := filteringrules.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a firewallFilteringRuleAdapter) Update(ctx context.Context, id int, rule *filteringrules.FirewallFilteringRules) error {
+	_, err := filteringrules.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a firewallFilteringRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := filteringrules.Delete(ctx, a.service, id)
+	return err
+}
+
+func (firewallFilteringRuleAdapter) Order(rule *filteringrules.FirewallFilteringRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (firewallFilteringRuleAdapter) SetOrder(rule *filteringrules.FirewallFilteringRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (firewallFilteringRuleAdapter) StripReadOnly(rule *filteringrules.FirewallFilteringRules) {
+	// to avoid the STALE_CONFIGURATION_ERROR
+	rule.LastModifiedTime = 0
+	rule.LastModifiedBy = nil
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.Predefined = false
+	rule.DefaultRule = false
+	rule.AccessControl = ""
+}
+
+func (a firewallFilteringRuleAdapter) GetByName(ctx context.Context, name string) (*filteringrules.FirewallFilteringRules, error) {
+	return filteringrules.GetByName(ctx, a.service, name)
+}
+
+func (firewallFilteringRuleAdapter) IsPredefined(rule *filteringrules.FirewallFilteringRules) bool {
+	return rule.Predefined || rule.DefaultRule
+}
 
 func resourceFirewallFilteringRules() *schema.Resource {
-	return &schema.Resource{
+	return withAdoptPredefined(versionSchema(&schema.Resource{
 		CreateContext: resourceFirewallFilteringRulesCreate,
 		ReadContext:   resourceFirewallFilteringRulesRead,
 		UpdateContext: resourceFirewallFilteringRulesUpdate,
 		DeleteContext: resourceFirewallFilteringRulesDelete,
+		CustomizeDiff: validateRuleOrder("zia_firewall_filtering_rule"),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := filteringrules.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", func(ctx context.Context, service *zscaler.Service) ([]filteringrules.FirewallFilteringRules, error) {
+			return filteringrules.GetAll(ctx, service, nil)
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -170,10 +207,10 @@ func resourceFirewallFilteringRules() *schema.Resource {
 				Optional:    true,
 				Description: "If set to true, the context shield end point is excluded from the rule",
 			},
-			"locations":                    setIDsSchemaTypeCustom(nil, "list of locations for which rule must be applied"),
+			"location_ids":                 setIntIDsSchemaType(nil, "IDs of locations for which rule must be applied"),
 			"location_groups":              setIDsSchemaTypeCustom(nil, "list of locations groups"),
 			"users":                        setIDsSchemaTypeCustom(nil, "list of users for which rule must be applied"),
-			"groups":                       setIDsSchemaTypeCustom(nil, "list of groups for which rule must be applied"),
+			"group_ids":                    setIntIDsSchemaType(nil, "IDs of groups for which rule must be applied"),
 			"departments":                  setIDsSchemaTypeCustom(nil, "list of departments for which rule must be applied"),
 			"time_windows":                 setIDsSchemaTypeCustom(intPtr(2), "The time interval in which the Firewall Filtering policy rule applies"),
 			"labels":                       setIDsSchemaTypeCustom(intPtr(1), "list of Labels that are applicable to the rule."),
@@ -194,7 +231,7 @@ func resourceFirewallFilteringRules() *schema.Resource {
 			"end_point_applications":       setCustomKeyIDsSchema("zapp_id", "The endpoint applications to which the DLP policy rule must be applied"),
 			"end_point_application_groups": setCustomKeyIDsSchema("group_id", "The endpoint application groups to which the DLP policy rule must be applied"),
 		},
-	}
+	}, ruleIDBlocksV0))
 }
 
 func validateFirewallRule(req filteringrules.FirewallFilteringRules) error {
@@ -212,111 +249,13 @@ func validateFirewallRule(req filteringrules.FirewallFilteringRules) error {
 
 func resourceFirewallFilteringRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandFirewallFilteringRules(d)
 	log.Printf("[INFO] Creating zia firewall filtering rule\n%+v\n", req)
 
-	start := time.Now()
-
-	firewallFilteringLock.Lock()
-	if firewallFilteringStartingOrder == 0 {
-		list, _ := filteringrules.GetAll(ctx, service, nil)
-		for _, r := range list {
-			if r.Order > firewallFilteringStartingOrder {
-				firewallFilteringStartingOrder = r.Order
-			}
-		}
-		if firewallFilteringStartingOrder == 0 {
-			firewallFilteringStartingOrder = 1
-		} else {
-			firewallFilteringStartingOrder++
-		}
-	}
-	firewallFilteringLock.Unlock()
-	startWithoutLocking := time.Now()
-
-	// Store the intended order from HCL
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	if intendedRank < 7 {
-		// always start rank 7 rules at the next available order after all ranked rules
-		req.Rank = 7
-	}
-	req.Order = firewallFilteringStartingOrder
-	resp, err := filteringrules.Create(ctx, service, &req)
-
-	// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-
-	if err != nil {
-		reg := regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
-		if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-			if reg.MatchString(err.Error()) {
-				return diag.FromErr(fmt.Errorf("error creating resource: %s, please check the order %d vs rank %d, current rules:%s , err:%s", req.Name, intendedOrder, req.Rank, currentFirewallOrderVsRankWording(ctx, zClient), err))
-			}
-		}
-		return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-	}
-
-	log.Printf("[INFO] Created zia firewall filtering rule request. took:%s, without locking:%s,  ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-	// Use separate resource type for rank 7 rules to avoid mixing with ranked rules
-	resourceType := "firewall_filtering_rules"
-
-	reorderWithBeforeReorder(
-		OrderRule{Order: intendedOrder, Rank: intendedRank},
-		resp.ID,
-		resourceType,
-		func() (map[int]OrderRule, error) {
-			allRules, err := filteringrules.GetAll(ctx, service, nil)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := filteringrules.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			// to avoid the STALE_CONFIGURATION_ERROR
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			// Strip read-only fields that cause "Request body is invalid" for predefined rules
-			rule.Predefined = false
-			rule.DefaultRule = false
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = filteringrules.Update(ctx, service, id, rule)
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	d.SetId(strconv.Itoa(resp.ID))
-	_ = d.Set("rule_id", resp.ID)
-
-	markOrderRuleAsDone(resp.ID, resourceType)
-	waitForReorder(resourceType)
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := createOrderedRule(ctx, d, zClient, firewallFilteringRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
-
 	return resourceFirewallFilteringRulesRead(ctx, d, meta)
 }
 
@@ -330,7 +269,9 @@ func resourceFirewallFilteringRulesRead(ctx context.Context, d *schema.ResourceD
 	}
 
 	// Use GetAll() instead of Get() to reduce API calls during terraform refresh
-	allRules, err := filteringrules.GetAll(ctx, service, nil)
+	allRules, err := cachedList(zClient, "zia_firewall_filtering_rule", func() ([]filteringrules.FirewallFilteringRules, error) {
+		return filteringrules.GetAll(ctx, service, nil)
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -393,7 +334,7 @@ func resourceFirewallFilteringRulesRead(ctx context.Context, d *schema.ResourceD
 	_ = d.Set("eun_template_id", resp.EUNTemplateID)
 	_ = d.Set("exclude_context_shield_end_point", resp.ExcludeContextShieldEndPoint)
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -405,7 +346,7 @@ func resourceFirewallFilteringRulesRead(ctx context.Context, d *schema.ResourceD
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -474,7 +415,6 @@ func resourceFirewallFilteringRulesRead(ctx context.Context, d *schema.ResourceD
 
 func resourceFirewallFilteringRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -484,81 +424,9 @@ func resourceFirewallFilteringRulesUpdate(ctx context.Context, d *schema.Resourc
 	log.Printf("[INFO] Updating firewall filtering rule ID: %v\n", id)
 	req := expandFirewallFilteringRules(d)
 
-	if _, err := filteringrules.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-	existingRules, err := filteringrules.GetAll(ctx, service, nil)
-	if err != nil {
-		log.Printf("[ERROR] error getting all filtering rules: %v", err)
-	}
-	sort.Slice(existingRules, func(i, j int) bool {
-		return existingRules[i].Rank < existingRules[j].Rank || (existingRules[i].Rank == existingRules[j].Rank && existingRules[i].Order < existingRules[j].Order)
-	})
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	nextAvailableOrder := existingRules[len(existingRules)-1].Order
-	// always start rank 7 rules at the next available order after all ranked rules
-	req.Rank = 7
-
-	req.Order = nextAvailableOrder
-
-	_, err = filteringrules.Update(ctx, service, id, &req)
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-	}
-
-	reorderWithBeforeReorder(OrderRule{Order: intendedOrder, Rank: intendedRank}, req.ID, "firewall_filtering_rules",
-		func() (map[int]OrderRule, error) {
-			allRules, err := filteringrules.GetAll(ctx, service, nil)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := filteringrules.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			// to avoid the STALE_CONFIGURATION_ERROR
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			// Strip read-only fields that cause "Request body is invalid" for predefined rules
-			rule.Predefined = false
-			rule.DefaultRule = false
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = filteringrules.Update(ctx, service, id, rule)
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	markOrderRuleAsDone(req.ID, "firewall_filtering_rules")
-	waitForReorder("firewall_filtering_rules")
-
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := updateOrderedRule(ctx, d, zClient, firewallFilteringRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
 	return resourceFirewallFilteringRulesRead(ctx, d, meta)
 }
 
@@ -571,41 +439,26 @@ func resourceFirewallFilteringRulesDelete(ctx context.Context, d *schema.Resourc
 		log.Printf("[ERROR] firewall filtering rule not set: %v\n", id)
 	}
 
-	// Retrieve the rule to check if it's a predefined one
-	rule, err := filteringrules.Get(ctx, service, id)
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error retrieving firewall filtering rule %d: %v", id, err))
-	}
-
-	// Validate if the rule can be deleted using the validateFirewallRule function
-	if err := validateFirewallRule(*rule); err != nil {
-		return diag.FromErr(err)
-	}
-
-	// Additional check for any predefined rule (backup validation)
-	if rule.Predefined {
-		return diag.FromErr(fmt.Errorf("deletion of predefined rule '%s' is not allowed", rule.Name))
-	}
+	// Adopted predefined rules are restored rather than deleted
+	if !adoptsPredefined(d) {
+		// Retrieve the rule to check if it's a predefined one
+		rule, err := filteringrules.Get(ctx, service, id)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error retrieving firewall filtering rule %d: %v", id, err))
+		}
 
-	log.Printf("[INFO] Deleting firewall filtering rule ID: %v\n", (d.Id()))
-	if _, err := filteringrules.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] firewall filtering rule deleted")
+		// Validate if the rule can be deleted using the validateFirewallRule function
+		if err := validateFirewallRule(*rule); err != nil {
+			return diag.FromErr(err)
+		}
 
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
+		// Additional check for any predefined rule (backup validation)
+		if rule.Predefined {
+			return diag.FromErr(fmt.Errorf("deletion of predefined rule '%s' is not allowed", rule.Name))
 		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
 	}
 
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, firewallFilteringRuleAdapter{service}, id)
 }
 
 func expandFirewallFilteringRules(d *schema.ResourceData) filteringrules.FirewallFilteringRules {
@@ -644,10 +497,10 @@ func expandFirewallFilteringRules(d *schema.ResourceData) filteringrules.Firewal
 		IsEUNEnabled:                 d.Get("is_eun_enabled").(bool),
 		EUNTemplateID:                d.Get("eun_template_id").(int),
 		ExcludeContextShieldEndPoint: d.Get("exclude_context_shield_end_point").(bool),
-		Locations:                    expandIDNameExtensionsSet(d, "locations"),
+		Locations:                    expandIDNameExtensionsIDs(d, "location_ids"),
 		LocationsGroups:              expandIDNameExtensionsSet(d, "location_groups"),
 		Departments:                  expandIDNameExtensionsSet(d, "departments"),
-		Groups:                       expandIDNameExtensionsSet(d, "groups"),
+		Groups:                       expandIDNameExtensionsIDs(d, "group_ids"),
 		Users:                        expandIDNameExtensionsSet(d, "users"),
 		TimeWindows:                  expandIDNameExtensionsSet(d, "time_windows"),
 		SrcIpGroups:                  expandIDNameExtensionsSet(d, "src_ip_groups"),
@@ -667,21 +520,3 @@ func expandFirewallFilteringRules(d *schema.ResourceData) filteringrules.Firewal
 	}
 	return result
 }
-
-func currentFirewallOrderVsRankWording(ctx context.Context, zClient *Client) string {
-	service := zClient.Service
-
-	list, err := filteringrules.GetAll(ctx, service, nil)
-	if err != nil {
-		return ""
-	}
-	result := ""
-	for i, r := range list {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("Rank %d VS Order %d", r.Rank, r.Order)
-
-	}
-	return result
-}
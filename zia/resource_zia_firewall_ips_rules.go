@@ -4,56 +4,90 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
-	"sort"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/ips_control_policies/ips_policies"
 )
 
-var (
-	firewallIPSLock          sync.Mutex
-	firewallIPSStartingOrder int
-)
+// firewallIPSRuleAdapter is the OrderedRuleAdapter of firewall IPS control
+// rules.
+type firewallIPSRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (firewallIPSRuleAdapter) ResourceType() string { return "firewall_ips_rule" }
+func (firewallIPSRuleAdapter) Ranked() bool         { return true }
+
+func (a firewallIPSRuleAdapter) GetAll(ctx context.Context) ([]ips_policies.FirewallIPSRules, error) {
+	return ips_policies.GetAll(ctx, a.service)
+}
+
+func (a firewallIPSRuleAdapter) Get(ctx context.Context, id int) (*ips_policies.FirewallIPSRules, error) {
+	return ips_policies.Get(ctx, a.service, id)
+}
+
+func (a firewallIPSRuleAdapter) Create(ctx context.Context, rule *ips_policies.FirewallIPSRules) (int, error) {
+	resp, err := ips_policies.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a firewallIPSRuleAdapter) Update(ctx context.Context, id int, rule *ips_policies.FirewallIPSRules) error {
+	_, err := ips_policies.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a firewallIPSRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := ips_policies.Delete(ctx, a.service, id)
+	return err
+}
+
+func (firewallIPSRuleAdapter) Order(rule *ips_policies.FirewallIPSRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (firewallIPSRuleAdapter) SetOrder(rule *ips_policies.FirewallIPSRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (firewallIPSRuleAdapter) StripReadOnly(rule *ips_policies.FirewallIPSRules) {
+	// to avoid the STALE_CONFIGURATION_ERROR
+	rule.LastModifiedTime = 0
+	rule.LastModifiedBy = nil
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.Predefined = false
+	rule.DefaultRule = false
+	rule.AccessControl = ""
+}
+
+func (a firewallIPSRuleAdapter) GetByName(ctx context.Context, name string) (*ips_policies.FirewallIPSRules, error) {
+	return ips_policies.GetByName(ctx, a.service, name)
+}
+
+func (firewallIPSRuleAdapter) IsPredefined(rule *ips_policies.FirewallIPSRules) bool {
+	return rule.Predefined || rule.DefaultRule
+}
 
 func resourceFirewallIPSRules() *schema.Resource {
-	return &schema.Resource{
+	return withAdoptPredefined(versionSchema(&schema.Resource{
 		CreateContext: resourceFirewallIPSRulesCreate,
 		ReadContext:   resourceFirewallIPSRulesRead,
 		UpdateContext: resourceFirewallIPSRulesUpdate,
 		DeleteContext: resourceFirewallIPSRulesDelete,
+		CustomizeDiff: validateRuleOrder("zia_firewall_ips_rule"),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := ips_policies.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", ips_policies.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -168,10 +202,10 @@ func resourceFirewallIPSRules() *schema.Resource {
 				Optional:    true,
 				Description: "The EUN template ID associated with the rule",
 			},
-			"locations":                    setIDsSchemaTypeCustom(nil, "list of locations for which rule must be applied"),
+			"location_ids":                 setIntIDsSchemaType(nil, "IDs of locations for which rule must be applied"),
 			"location_groups":              setIDsSchemaTypeCustom(nil, "list of locations groups"),
 			"users":                        setIDsSchemaTypeCustom(nil, "list of users for which rule must be applied"),
-			"groups":                       setIDsSchemaTypeCustom(nil, "list of groups for which rule must be applied"),
+			"group_ids":                    setIntIDsSchemaType(nil, "IDs of groups for which rule must be applied"),
 			"departments":                  setIDsSchemaTypeCustom(nil, "list of departments for which rule must be applied"),
 			"time_windows":                 setIDsSchemaTypeCustom(intPtr(2), "The time interval in which the Firewall Filtering policy rule applies"),
 			"labels":                       setIDsSchemaTypeCustom(intPtr(1), "list of Labels that are applicable to the rule."),
@@ -190,124 +224,19 @@ func resourceFirewallIPSRules() *schema.Resource {
 			"dest_countries":               getISOCountryCodes(),
 			"source_countries":             getISOCountryCodes(),
 		},
-	}
+	}, ruleIDBlocksV0))
 }
 
 func resourceFirewallIPSRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandFirewallIPSRules(d)
 	log.Printf("[INFO] Creating zia firewall ips rule\n%+v\n", req)
 
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-
-	for {
-		firewallIPSLock.Lock()
-		if firewallIPSStartingOrder == 0 {
-			list, _ := ips_policies.GetAll(ctx, service)
-			for _, r := range list {
-				if r.Order > firewallIPSStartingOrder {
-					firewallIPSStartingOrder = r.Order
-				}
-			}
-			if firewallIPSStartingOrder == 0 {
-				firewallIPSStartingOrder = 1
-			}
-		}
-		firewallIPSLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		intendedOrder := req.Order
-		intendedRank := req.Rank
-		if intendedRank < 7 {
-			// always start rank 7 rules at the next available order after all ranked rules
-			req.Rank = 7
-		}
-		req.Order = firewallIPSStartingOrder
-
-		resp, err := ips_policies.Create(ctx, service, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			reg := regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				if reg.MatchString(err.Error()) {
-					return diag.FromErr(fmt.Errorf("error creating resource: %s, please check the order %d vs rank %d, current rules:%s , err:%s", req.Name, intendedOrder, req.Rank, currentFirewallIPsOrderVsRankWording(ctx, zClient), err))
-				}
-				if time.Since(start) < timeout {
-					log.Printf("[INFO] Creating firewall ips rule name: %v, got INVALID_INPUT_ARGUMENT\n", req.Name)
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-		}
-
-		log.Printf("[INFO] Created zia firewall ips rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-		// Use separate resource type for rank 7 rules to avoid mixing with ranked rules
-		resourceType := "firewall_ips_rule"
-
-		reorderWithBeforeReorder(
-			OrderRule{Order: intendedOrder, Rank: intendedRank},
-			resp.ID,
-			resourceType,
-			func() (map[int]OrderRule, error) {
-				allRules, err := ips_policies.GetAll(ctx, service)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(allRules))
-				for _, r := range allRules {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				// Custom updateOrder that handles predefined rules
-				rule, err := ips_policies.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-				// to avoid the STALE_CONFIGURATION_ERROR
-				rule.LastModifiedTime = 0
-				rule.LastModifiedBy = nil
-				// Strip read-only fields that cause "Request body is invalid" for predefined rules
-				rule.Predefined = false
-				rule.DefaultRule = false
-				rule.AccessControl = ""
-				rule.Order = order.Order
-				rule.Rank = order.Rank
-				_, err = ips_policies.Update(ctx, service, id, rule)
-				return err
-			},
-			nil, // Remove beforeReorder function to avoid adding too many rules to the map
-		)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		markOrderRuleAsDone(resp.ID, resourceType)
-		waitForReorder(resourceType)
-
-		// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-		if shouldActivate() {
-			// Sleep for 2 seconds before potentially triggering the activation
-			time.Sleep(2 * time.Second)
-			if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-				return diag.FromErr(activationErr)
-			}
-		} else {
-			log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-		}
-
-		return resourceFirewallIPSRulesRead(ctx, d, meta)
+	if diags := createOrderedRule(ctx, d, zClient, firewallIPSRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
+	return resourceFirewallIPSRulesRead(ctx, d, meta)
 }
 
 func resourceFirewallIPSRulesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -319,7 +248,10 @@ func resourceFirewallIPSRulesRead(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(fmt.Errorf("no zia firewall ips rule id is set"))
 	}
 
-	resp, err := ips_policies.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_firewall_ips_rule", id,
+		func() ([]ips_policies.FirewallIPSRules, error) { return ips_policies.GetAll(ctx, service) },
+		func(r *ips_policies.FirewallIPSRules) int { return r.ID },
+		func() (*ips_policies.FirewallIPSRules, error) { return ips_policies.Get(ctx, service, id) })
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing firewall ips rule %s from state because it no longer exists in ZIA", d.Id())
@@ -362,7 +294,7 @@ func resourceFirewallIPSRulesRead(ctx context.Context, d *schema.ResourceData, m
 	_ = d.Set("is_eun_enabled", resp.IsEUNEnabled)
 	_ = d.Set("eun_template_id", resp.EUNTemplateID)
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -374,7 +306,7 @@ func resourceFirewallIPSRulesRead(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -435,7 +367,6 @@ func resourceFirewallIPSRulesRead(ctx context.Context, d *schema.ResourceData, m
 
 func resourceFirewallIPSRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -445,82 +376,9 @@ func resourceFirewallIPSRulesUpdate(ctx context.Context, d *schema.ResourceData,
 	log.Printf("[INFO] Updating firewall ips rule ID: %v\n", id)
 	req := expandFirewallIPSRules(d)
 
-	if _, err := ips_policies.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-
-	existingRules, err := ips_policies.GetAll(ctx, service)
-	if err != nil {
-		log.Printf("[ERROR] error getting all firewall ips rules: %v", err)
-	}
-	sort.Slice(existingRules, func(i, j int) bool {
-		return existingRules[i].Rank < existingRules[j].Rank || (existingRules[i].Rank == existingRules[j].Rank && existingRules[i].Order < existingRules[j].Order)
-	})
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	nextAvailableOrder := existingRules[len(existingRules)-1].Order
-	// always start rank 7 rules at the next available order after all ranked rules
-	req.Rank = 7
-
-	req.Order = nextAvailableOrder
-
-	_, err = ips_policies.Update(ctx, service, id, &req)
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-	}
-
-	reorderWithBeforeReorder(OrderRule{Order: intendedOrder, Rank: intendedRank}, req.ID, "firewall_ips_rule",
-		func() (map[int]OrderRule, error) {
-			allRules, err := ips_policies.GetAll(ctx, service)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := ips_policies.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			// to avoid the STALE_CONFIGURATION_ERROR
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			// Strip read-only fields that cause "Request body is invalid" for predefined rules
-			rule.Predefined = false
-			rule.DefaultRule = false
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = ips_policies.Update(ctx, service, id, rule)
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	markOrderRuleAsDone(req.ID, "firewall_ips_rule")
-	waitForReorder("firewall_ips_rule")
-
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := updateOrderedRule(ctx, d, zClient, firewallIPSRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
 	return resourceFirewallIPSRulesRead(ctx, d, meta)
 }
 
@@ -533,36 +391,21 @@ func resourceFirewallIPSRulesDelete(ctx context.Context, d *schema.ResourceData,
 		log.Printf("[ERROR] firewall ips rule not set: %v\n", id)
 	}
 
-	// Retrieve the rule to check if it's predefined
-	rule, err := ips_policies.Get(ctx, service, id)
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error retrieving firewall IPS rule %d: %v", id, err))
-	}
-
-	// Prevent deletion if the rule is predefined
-	if rule.Predefined {
-		return diag.FromErr(fmt.Errorf("deletion of predefined rule '%s' is not allowed", rule.Name))
-	}
+	// Adopted predefined rules are restored rather than deleted
+	if !adoptsPredefined(d) {
+		// Retrieve the rule to check if it's predefined
+		rule, err := ips_policies.Get(ctx, service, id)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error retrieving firewall IPS rule %d: %v", id, err))
+		}
 
-	log.Printf("[INFO] Deleting firewall ips rule ID: %v\n", (d.Id()))
-	if _, err := ips_policies.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] firewall ips rule deleted")
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
+		// Prevent deletion if the rule is predefined
+		if rule.Predefined {
+			return diag.FromErr(fmt.Errorf("deletion of predefined rule '%s' is not allowed", rule.Name))
 		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
 	}
 
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, firewallIPSRuleAdapter{service}, id)
 }
 
 func expandFirewallIPSRules(d *schema.ResourceData) ips_policies.FirewallIPSRules {
@@ -598,10 +441,10 @@ func expandFirewallIPSRules(d *schema.ResourceData) ips_policies.FirewallIPSRule
 		Predefined:        d.Get("predefined").(bool),
 		IsEUNEnabled:      d.Get("is_eun_enabled").(bool),
 		EUNTemplateID:     d.Get("eun_template_id").(int),
-		Locations:         expandIDNameExtensionsSet(d, "locations"),
+		Locations:         expandIDNameExtensionsIDs(d, "location_ids"),
 		LocationsGroups:   expandIDNameExtensionsSet(d, "location_groups"),
 		Departments:       expandIDNameExtensionsSet(d, "departments"),
-		Groups:            expandIDNameExtensionsSet(d, "groups"),
+		Groups:            expandIDNameExtensionsIDs(d, "group_ids"),
 		Users:             expandIDNameExtensionsSet(d, "users"),
 		TimeWindows:       expandIDNameExtensionsSet(d, "time_windows"),
 		SrcIpGroups:       expandIDNameExtensionsSet(d, "src_ip_groups"),
@@ -618,21 +461,3 @@ func expandFirewallIPSRules(d *schema.ResourceData) ips_policies.FirewallIPSRule
 	}
 	return result
 }
-
-func currentFirewallIPsOrderVsRankWording(ctx context.Context, zClient *Client) string {
-	service := zClient.Service
-
-	list, err := ips_policies.GetAll(ctx, service)
-	if err != nil {
-		return ""
-	}
-	result := ""
-	for i, r := range list {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("Rank %d VS Order %d", r.Rank, r.Order)
-
-	}
-	return result
-}
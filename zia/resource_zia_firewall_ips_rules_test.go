@@ -32,9 +32,9 @@ func TestAccResourceFirewallIPSRules_Basic(t *testing.T) {
 	dstIPGroupHCL := testAccCheckFWIPDestinationGroupsConfigure(dstIPGroupTypeAndName, "tf-acc-test-"+dstIPGroupGeneratedName, variable.FWDSTGroupDescription, variable.FWDSTGroupTypeDSTNFQDN)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckFirewallIPSRulesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckFirewallIPSRulesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckFirewallIPSRulesConfigure(resourceTypeAndName, generatedName, generatedName, variable.FWIPSRuleDescription, variable.FWIPSAction, variable.FWIPSState, variable.FWRuleEnableLogging, ruleLabelTypeAndName, ruleLabelHCL, sourceIPGroupTypeAndName, sourceIPGroupHCL, dstIPGroupTypeAndName, dstIPGroupHCL),
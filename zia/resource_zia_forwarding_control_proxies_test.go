@@ -24,9 +24,9 @@ func TestAccResourceForwardingControlProxies_Basic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckForwardingControlProxiesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckForwardingControlProxiesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckForwardingControlProxiesConfigure(resourceTypeAndName, initialName, variable.ProxyDescription, variable.ProxyType, variable.ProxyAddress, variable.ProxyPort, variable.ProxyInsertXauHeader, variable.ProxyBase64EncodeXauHeader),
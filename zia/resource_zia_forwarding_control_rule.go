@@ -4,32 +4,96 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
-	"sort"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/forwarding_control_policy/forwarding_rules"
 )
 
-var (
-	forwardingControlLock          sync.Mutex
-	forwardingControlStartingOrder int
-)
+// forwardingControlRuleAdapter is the OrderedRuleAdapter of forwarding control
+// rules.
+type forwardingControlRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (forwardingControlRuleAdapter) ResourceType() string { return "forwarding_control_rule" }
+func (forwardingControlRuleAdapter) Ranked() bool         { return true }
+
+func (a forwardingControlRuleAdapter) GetAll(ctx context.Context) ([]forwarding_rules.ForwardingRules, error) {
+	return forwarding_rules.GetAll(ctx, a.service)
+}
+
+func (a forwardingControlRuleAdapter) Get(ctx context.Context, id int) (*forwarding_rules.ForwardingRules, error) {
+	return forwarding_rules.Get(ctx, a.service, id)
+}
+
+func (a forwardingControlRuleAdapter) Create(ctx context.Context, rule *forwarding_rules.ForwardingRules) (int, error) {
+	var resp *forwarding_rules.ForwardingRules
+	err := retryInactiveZPASegment(rule, func() (err error) {
+		resp, err = forwarding_rules.Create(ctx, a.service, rule)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a forwardingControlRuleAdapter) Update(ctx context.Context, id int, rule *forwarding_rules.ForwardingRules) error {
+	return retryInactiveZPASegment(rule, func() error {
+		_, err := forwarding_rules.Update(ctx, a.service, id, rule)
+		return err
+	})
+}
+
+func (a forwardingControlRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := forwarding_rules.Delete(ctx, a.service, id)
+	return err
+}
+
+func (forwardingControlRuleAdapter) Order(rule *forwarding_rules.ForwardingRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (forwardingControlRuleAdapter) SetOrder(rule *forwarding_rules.ForwardingRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (forwardingControlRuleAdapter) StripReadOnly(rule *forwarding_rules.ForwardingRules) {}
+
+// retryInactiveZPASegment retries a write of a ZPA rule while the API still
+// reports its app segment as no longer an active Source IP Anchored App
+// Segment, which clears shortly after the segment is created.
+func retryInactiveZPASegment(rule *forwarding_rules.ForwardingRules, write func() error) error {
+	var err error
+	for i := 0; i < 3; i++ {
+		if err = write(); err == nil || rule.ForwardMethod != "ZPA" {
+			return err
+		}
+		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.Response.StatusCode == 400 &&
+			strings.Contains(respErr.Message, "is no longer an active Source IP Anchored App Segment") {
+			log.Printf("[WARN] Received error indicating resource is no longer active. Retrying...\n")
+			time.Sleep(30 * time.Second) // Wait for 30 seconds before retrying
+			continue
+		}
+		return err
+	}
+	return err
+}
 
 func resourceForwardingControlRule() *schema.Resource {
-	return &schema.Resource{
+	return versionSchema(&schema.Resource{
 		CreateContext: resourceForwardingControlRuleCreate,
 		ReadContext:   resourceForwardingControlRuleRead,
 		UpdateContext: resourceForwardingControlRuleUpdate,
 		DeleteContext: resourceForwardingControlRuleDelete,
-		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		CustomizeDiff: customdiff.All(func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
 			forwardMethod := d.Get("forward_method").(string)
 			ruleType := d.Get("type").(string)
 
@@ -81,32 +145,12 @@ func resourceForwardingControlRule() *schema.Resource {
 			}
 
 			return nil
-		},
+		}, validateRuleOrder("zia_forwarding_control_rule")),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := forwarding_rules.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", forwarding_rules.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -209,11 +253,11 @@ func resourceForwardingControlRule() *schema.Resource {
 				Elem:        &schema.Schema{Type: schema.TypeString},
 				Description: "List of destination domain categories to which the rule applies",
 			},
-			"locations":                      setIDsSchemaTypeCustom(nil, "Name-ID pairs of the locations to which the forwarding rule applies. If not set, the rule is applied to all locations."),
+			"location_ids":                   setIntIDsSchemaType(nil, "IDs of the locations to which the forwarding rule applies. If not set, the rule is applied to all locations."),
 			"location_groups":                setIDsSchemaTypeCustom(nil, "Name-ID pairs of the location groups to which the forwarding rule applies"),
 			"ec_groups":                      setIDsSchemaTypeCustom(intPtr(32), "Name-ID pairs of the Zscaler Cloud Connector groups to which the forwarding rule applies"),
 			"departments":                    setIDsSchemaTypeCustom(nil, "list of departments for which rule must be applied"),
-			"groups":                         setIDsSchemaTypeCustom(nil, "list of groups for which rule must be applied"),
+			"group_ids":                      setIntIDsSchemaType(nil, "IDs of groups for which rule must be applied"),
 			"users":                          setIDsSchemaTypeCustom(nil, "list of users for which rule must be applied"),
 			"device_groups":                  setIDsSchemaTypeCustom(nil, "This field is applicable for devices that are managed using Zscaler Client Connector."),
 			"src_ip_groups":                  setIDsSchemaTypeCustom(nil, "Source IP address groups for which the rule is applicable. If not set, the rule is not restricted to a specific source IP address group"),
@@ -233,7 +277,7 @@ func resourceForwardingControlRule() *schema.Resource {
 			"zpa_application_segment_groups": setIDsSchemaTypeCustom(intPtr(255), "List of ZPA Application Segment Groups for which this rule is applicable. This field is applicable only for the ECZPA forwarding method (used for Zscaler Cloud Connector)."),
 			"dest_countries":                 getISOCountryCodes(),
 		},
-	}
+	}, ruleIDBlocksV0)
 }
 
 func validatePredefinedRules(req forwarding_rules.ForwardingRules) error {
@@ -248,7 +292,6 @@ func validatePredefinedRules(req forwarding_rules.ForwardingRules) error {
 
 func resourceForwardingControlRuleCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandForwardingControlRule(d)
 	log.Printf("[INFO] Creating zia forwarding control rule\n%+v\n", req)
@@ -257,126 +300,15 @@ func resourceForwardingControlRuleCreate(ctx context.Context, d *schema.Resource
 		return diag.FromErr(err)
 	}
 
-	start := time.Now()
-
-	forwardMethod := d.Get("forward_method").(string)
-	if forwardMethod == "ZPA" {
+	if req.ForwardMethod == "ZPA" {
 		// Sleep for 60 seconds before invoking Create
 		time.Sleep(60 * time.Second)
 	}
 
-	for {
-		forwardingControlLock.Lock()
-		if forwardingControlStartingOrder == 0 {
-			list, _ := forwarding_rules.GetAll(ctx, service)
-			for _, r := range list {
-				if r.Order > forwardingControlStartingOrder {
-					forwardingControlStartingOrder = r.Order
-				}
-			}
-			if forwardingControlStartingOrder == 0 {
-				forwardingControlStartingOrder = 1
-			}
-		}
-		forwardingControlLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		intendedOrder := req.Order
-		intendedRank := req.Rank
-		if intendedRank < 7 {
-			// always start rank 7 rules at the next available order after all ranked rules
-			req.Rank = 7
-		}
-		req.Order = forwardingControlStartingOrder
-
-		// Retry logic in case of specific error
-		var resp *forwarding_rules.ForwardingRules
-		var err error
-		for i := 0; i < 3; i++ {
-			resp, err = forwarding_rules.Create(ctx, service, &req)
-			if err == nil {
-				break
-			}
-
-			if forwardMethod == "ZPA" {
-				if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.Response.StatusCode == 400 &&
-					strings.Contains(respErr.Message, "is no longer an active Source IP Anchored App Segment") {
-					log.Printf("[WARN] Received error indicating resource is no longer active. Retrying...\n")
-					time.Sleep(30 * time.Second) // Wait for 30 seconds before retrying
-					continue
-				}
-			}
-			return diag.FromErr(err)
-		}
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			reg := regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				if reg.MatchString(err.Error()) {
-					return diag.FromErr(fmt.Errorf("error creating resource: %s, please check the order %d vs rank %d, current rules:%s , err:%s", req.Name, intendedOrder, req.Rank, currentForwardingControlOrderVsRankWording(ctx, zClient), err))
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-		}
-
-		log.Printf("[INFO] Created zia forwarding control rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-		// Use separate resource type for rank 7 rules to avoid mixing with ranked rules
-		resourceType := "forwarding_control_rule"
-
-		reorderWithBeforeReorder(
-			OrderRule{Order: intendedOrder, Rank: intendedRank},
-			resp.ID,
-			resourceType,
-			func() (map[int]OrderRule, error) {
-				allRules, err := forwarding_rules.GetAll(ctx, service)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(allRules))
-				for _, r := range allRules {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				// Custom updateOrder that handles predefined rules
-				rule, err := forwarding_rules.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-
-				rule.Order = order.Order
-				rule.Rank = order.Rank
-				_, err = forwarding_rules.Update(ctx, service, id, rule)
-				return err
-			},
-			nil, // Remove beforeReorder function to avoid adding too many rules to the map
-		)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		markOrderRuleAsDone(resp.ID, resourceType)
-		waitForReorder(resourceType)
-
-		// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-		if shouldActivate() {
-			// Sleep for 2 seconds before potentially triggering the activation
-			time.Sleep(2 * time.Second)
-			if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-				return diag.FromErr(activationErr)
-			}
-		} else {
-			log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-		}
-
-		return resourceForwardingControlRuleRead(ctx, d, meta)
+	if diags := createOrderedRule(ctx, d, zClient, forwardingControlRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
+	return resourceForwardingControlRuleRead(ctx, d, meta)
 }
 
 func resourceForwardingControlRuleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -387,7 +319,10 @@ func resourceForwardingControlRuleRead(ctx context.Context, d *schema.ResourceDa
 	if !ok {
 		return diag.FromErr(fmt.Errorf("no zia forwarding control rule id is set"))
 	}
-	resp, err := forwarding_rules.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_forwarding_control_rule", id,
+		func() ([]forwarding_rules.ForwardingRules, error) { return forwarding_rules.GetAll(ctx, service) },
+		func(r *forwarding_rules.ForwardingRules) int { return r.ID },
+		func() (*forwarding_rules.ForwardingRules, error) { return forwarding_rules.Get(ctx, service, id) })
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing forwarding control rule %s from state because it no longer exists in ZIA", d.Id())
@@ -419,7 +354,7 @@ func resourceForwardingControlRuleRead(ctx context.Context, d *schema.ResourceDa
 	_ = d.Set("dest_countries", processedDestCountries)
 	_ = d.Set("res_categories", resp.ResCategories)
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -435,7 +370,7 @@ func resourceForwardingControlRuleRead(ctx context.Context, d *schema.ResourceDa
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -508,7 +443,6 @@ func resourceForwardingControlRuleRead(ctx context.Context, d *schema.ResourceDa
 
 func resourceForwardingControlRuleUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -522,103 +456,14 @@ func resourceForwardingControlRuleUpdate(ctx context.Context, d *schema.Resource
 		return diag.FromErr(err)
 	}
 
-	if _, err := forwarding_rules.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-
-	existingRules, err := forwarding_rules.GetAll(ctx, service)
-	if err != nil {
-		log.Printf("[ERROR] error getting all forwarding control rules: %v", err)
-	}
-	sort.Slice(existingRules, func(i, j int) bool {
-		return existingRules[i].Rank < existingRules[j].Rank || (existingRules[i].Rank == existingRules[j].Rank && existingRules[i].Order < existingRules[j].Order)
-	})
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	nextAvailableOrder := existingRules[len(existingRules)-1].Order
-	// always start rank 7 rules at the next available order after all ranked rules
-	req.Rank = 7
-
-	req.Order = nextAvailableOrder
-
-	forwardMethod := d.Get("forward_method").(string)
-	if forwardMethod == "ZPA" {
+	if req.ForwardMethod == "ZPA" {
 		// Sleep for 60 seconds before invoking Update
 		time.Sleep(60 * time.Second)
 	}
 
-	// Retry logic in case of specific error
-	for i := 0; i < 3; i++ {
-		_, err = forwarding_rules.Update(ctx, service, id, &req)
-		if err == nil {
-			break
-		}
-
-		if forwardMethod == "ZPA" {
-			if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.Response.StatusCode == 400 &&
-				strings.Contains(respErr.Message, "is no longer an active Source IP Anchored App Segment") {
-				log.Printf("[WARN] Received error indicating resource is no longer active. Retrying...\n")
-				time.Sleep(30 * time.Second) // Wait for 30 seconds before retrying
-				continue
-			}
-		}
-		return diag.FromErr(err)
-	}
-
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-	}
-
-	reorderWithBeforeReorder(OrderRule{Order: intendedOrder, Rank: intendedRank}, req.ID, "forwarding_control_rule",
-		func() (map[int]OrderRule, error) {
-			allRules, err := forwarding_rules.GetAll(ctx, service)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := forwarding_rules.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			// Optional: avoid unnecessary updates if the current order is already correct
-			if rule.Order == order.Order && rule.Rank == order.Rank {
-				return nil
-			}
-
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = forwarding_rules.Update(ctx, service, id, rule)
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	markOrderRuleAsDone(req.ID, "forwarding_control_rule")
-	waitForReorder("forwarding_control_rule")
-
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := updateOrderedRule(ctx, d, zClient, forwardingControlRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
 	return resourceForwardingControlRuleRead(ctx, d, meta)
 }
 
@@ -642,25 +487,7 @@ func resourceForwardingControlRuleDelete(ctx context.Context, d *schema.Resource
 		return diag.FromErr(err)
 	}
 
-	log.Printf("[INFO] Deleting forwarding control rule ID: %v", id)
-	if _, err := forwarding_rules.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(fmt.Errorf("error deleting forwarding control rule %d: %v", id, err))
-	}
-
-	d.SetId("")
-	log.Printf("[INFO] Forwarding control rule deleted")
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, forwardingControlRuleAdapter{service}, id)
 }
 
 func expandForwardingControlRule(d *schema.ResourceData) forwarding_rules.ForwardingRules {
@@ -698,10 +525,10 @@ func expandForwardingControlRule(d *schema.ResourceData) forwarding_rules.Forwar
 		DestAddresses:       SetToStringList(d, "dest_addresses"),
 		DestIpCategories:    SetToStringList(d, "dest_ip_categories"),
 		DestCountries:       processedDestCountries,
-		Locations:           expandIDNameExtensionsSet(d, "locations"),
+		Locations:           expandIDNameExtensionsIDs(d, "location_ids"),
 		LocationsGroups:     expandIDNameExtensionsSet(d, "location_groups"),
 		Departments:         expandIDNameExtensionsSet(d, "departments"),
-		Groups:              expandIDNameExtensionsSet(d, "groups"),
+		Groups:              expandIDNameExtensionsIDs(d, "group_ids"),
 		Users:               expandIDNameExtensionsSet(d, "users"),
 		SrcIpGroups:         expandIDNameExtensionsSet(d, "src_ip_groups"),
 		DestIpGroups:        expandIDNameExtensionsSet(d, "dest_ip_groups"),
@@ -720,21 +547,3 @@ func expandForwardingControlRule(d *schema.ResourceData) forwarding_rules.Forwar
 
 	return result
 }
-
-func currentForwardingControlOrderVsRankWording(ctx context.Context, zClient *Client) string {
-	service := zClient.Service
-
-	list, err := forwarding_rules.GetAll(ctx, service)
-	if err != nil {
-		return ""
-	}
-	result := ""
-	for i, r := range list {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("Rank %d VS Order %d", r.Rank, r.Order)
-
-	}
-	return result
-}
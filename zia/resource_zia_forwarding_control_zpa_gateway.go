@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -22,40 +20,7 @@ func resourceForwardingControlZPAGateway() *schema.Resource {
 		UpdateContext: resourceForwardingControlZPAGatewayUpdate,
 		DeleteContext: resourceForwardingControlZPAGatewayDelete,
 
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("gateway_id", idInt)
-				} else {
-					// Use GetAll to avoid API bug where GetByName returns incorrect app segments
-					allGateways, err := zpa_gateways.GetAll(ctx, service)
-					if err != nil {
-						return []*schema.ResourceData{d}, err
-					}
-
-					var foundGateway *zpa_gateways.ZPAGateways
-					for _, gateway := range allGateways {
-						if strings.EqualFold(gateway.Name, id) {
-							foundGateway = &gateway
-							break
-						}
-					}
-
-					if foundGateway != nil {
-						d.SetId(strconv.Itoa(foundGateway.ID))
-						_ = d.Set("gateway_id", foundGateway.ID)
-					} else {
-						return []*schema.ResourceData{d}, fmt.Errorf("no zpa gateway found with name: %s", id)
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("gateway_id", zpa_gateways.GetAll),
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:     schema.TypeString,
@@ -156,9 +121,7 @@ func resourceForwardingControlZPAGatewayCreate(ctx context.Context, d *schema.Re
 	d.SetId(strconv.Itoa(resp.ID))
 	_ = d.Set("gateway_id", resp.ID)
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -254,9 +217,7 @@ func resourceForwardingControlZPAGatewayUpdate(ctx context.Context, d *schema.Re
 		return diag.FromErr(err)
 	}
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -294,9 +255,7 @@ func resourceForwardingControlZPAGatewayDelete(ctx context.Context, d *schema.Re
 	d.SetId("")
 	log.Printf("[INFO] forwarding control zpa gateway deleted")
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -17,16 +17,7 @@ func resourceFTPControlPolicy() *schema.Resource {
 		CreateContext: resourceFTPControlPolicyCreate,
 		UpdateContext: resourceFTPControlPolicyUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceFTPControlPolicyRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("failed to read ftp control policy import: %s", diags[0].Summary)
-				}
-				d.SetId("ftp_control")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("ftp_control", resourceFTPControlPolicyRead),
 		Schema: map[string]*schema.Schema{
 			"ftp_over_http_enabled": {
 				Type:        schema.TypeBool,
@@ -77,8 +68,8 @@ func resourceFTPControlPolicyCreate(ctx context.Context, d *schema.ResourceData,
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -139,8 +130,8 @@ func resourceFTPControlPolicyUpdate(ctx context.Context, d *schema.ResourceData,
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -12,9 +12,9 @@ func TestAccResourceFTPControlPolicy_Basic(t *testing.T) {
 	resourceName := "zia_ftp_control_policy.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckResourceFTPControlPolicyDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckResourceFTPControlPolicyDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create the resource with specific values (disabled state)
 			{
@@ -17,6 +17,8 @@ import (
 )
 
 func TestAccResourceFWIPDestinationGroupsBasic(t *testing.T) {
+	testAccVCR(t)
+
 	var groups ipdestinationgroups.IPDestinationGroups
 	resourceTypeAndName, _, generatedName := method.GenerateRandomSourcesTypeAndName(resourcetype.FWFilteringDestinationGroup)
 
@@ -24,9 +26,9 @@ func TestAccResourceFWIPDestinationGroupsBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckFWIPDestinationGroupsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckFWIPDestinationGroupsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckFWIPDestinationGroupsConfigure(resourceTypeAndName, initialName, variable.FWDSTGroupDescription, variable.FWDSTGroupTypeDSTNFQDN),
@@ -24,9 +24,9 @@ func TestAccResourceFWIPSourceGroupsBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckFWIPSourceGroupsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckFWIPSourceGroupsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckFWIPSourceGroupsConfigure(resourceTypeAndName, initialName, variable.FWSRCGroupDescription),
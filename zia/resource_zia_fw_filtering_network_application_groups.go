@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -22,27 +21,7 @@ func resourceFWNetworkApplicationGroups() *schema.Resource {
 		ReadContext:   resourceFWNetworkApplicationGroupsRead,
 		UpdateContext: resourceFWNetworkApplicationGroupsUpdate,
 		DeleteContext: resourceFWNetworkApplicationGroupsDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("app_id", idInt)
-				} else {
-					resp, err := networkapplicationgroups.GetNetworkApplicationGroupsByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("app_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importByName("app_id", networkapplicationgroups.GetAllNetworkApplicationGroups),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -90,9 +69,7 @@ func resourceFWNetworkApplicationGroupsCreate(ctx context.Context, d *schema.Res
 	_ = d.Set("app_id", resp.ID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -153,9 +130,7 @@ func resourceFWNetworkApplicationGroupsUpdate(ctx context.Context, d *schema.Res
 		return diag.FromErr(err)
 	}
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -197,9 +172,7 @@ func resourceFWNetworkApplicationGroupsDelete(ctx context.Context, d *schema.Res
 	log.Printf("[INFO] network application groups deleted")
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
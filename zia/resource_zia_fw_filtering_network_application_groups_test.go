@@ -24,9 +24,9 @@ func TestAccResourceFWNetworkApplicationGroupsBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckFWNetworkApplicationGroupsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckFWNetworkApplicationGroupsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckFWNetworkApplicationGroupsConfigure(resourceTypeAndName, initialName, variable.FWAppGroupDescription),
@@ -24,9 +24,9 @@ func TestAccResourceFWNetworkServicesBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckFWNetworkServicesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckFWNetworkServicesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckFWNetworkServicesConfigure(resourceTypeAndName, initialName, variable.FWNetworkServicesDescription, variable.FWNetworkServicesType),
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -21,27 +20,7 @@ func resourceHttpHeaderActionProfile() *schema.Resource {
 		ReadContext:   resourceHttpHeaderActionProfileRead,
 		UpdateContext: resourceHttpHeaderActionProfileUpdate,
 		DeleteContext: resourceHttpHeaderActionProfileDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("header_action_profile_id", idInt)
-				} else {
-					resp, err := http_header_action_profile.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("header_action_profile_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importByName("header_action_profile_id", http_header_action_profile.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -118,8 +97,7 @@ func resourceHttpHeaderActionProfileCreate(ctx context.Context, d *schema.Resour
 	d.SetId(strconv.Itoa(resp.ID))
 	_ = d.Set("header_action_profile_id", resp.ID)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -189,8 +167,7 @@ func resourceHttpHeaderActionProfileUpdate(ctx context.Context, d *schema.Resour
 		return diag.FromErr(err)
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -231,8 +208,7 @@ func resourceHttpHeaderActionProfileDelete(ctx context.Context, d *schema.Resour
 	d.SetId("")
 	log.Printf("[INFO] ZIA HTTP header action profile deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
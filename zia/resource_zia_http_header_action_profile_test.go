@@ -22,9 +22,9 @@ func TestAccResourceHTTPHeaderActionProfileBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckHTTPHeaderActionProfileDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckHTTPHeaderActionProfileDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckHTTPHeaderActionProfileConfigure(resourceTypeAndName, initialName, variable.HTTPHeaderActionProfileDescription),
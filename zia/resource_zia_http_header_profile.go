@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -22,27 +21,7 @@ func resourceHttpHeaderProfile() *schema.Resource {
 		ReadContext:   resourceHttpHeaderProfileRead,
 		UpdateContext: resourceHttpHeaderProfileUpdate,
 		DeleteContext: resourceHttpHeaderProfileDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("header_profile_id", idInt)
-				} else {
-					resp, err := http_header_profile.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("header_profile_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importByName("header_profile_id", http_header_profile.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -169,8 +148,7 @@ func resourceHttpHeaderProfileCreate(ctx context.Context, d *schema.ResourceData
 	d.SetId(strconv.Itoa(resp.ID))
 	_ = d.Set("header_profile_id", resp.ID)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -240,8 +218,7 @@ func resourceHttpHeaderProfileUpdate(ctx context.Context, d *schema.ResourceData
 		return diag.FromErr(err)
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -284,8 +261,7 @@ func resourceHttpHeaderProfileDelete(ctx context.Context, d *schema.ResourceData
 	d.SetId("")
 	log.Printf("[INFO] ZIA HTTP header profile deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
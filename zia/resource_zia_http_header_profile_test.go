@@ -22,9 +22,9 @@ func TestAccResourceHTTPHeaderProfileBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckHTTPHeaderProfileDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckHTTPHeaderProfileDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckHTTPHeaderProfileConfigure(resourceTypeAndName, initialName, variable.HTTPHeaderProfileDescription),
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -21,27 +20,7 @@ func resourceIPSSignatureRules() *schema.Resource {
 		ReadContext:   resourceIPSSignatureRulesRead,
 		UpdateContext: resourceIPSSignatureRulesUpdate,
 		DeleteContext: resourceIPSSignatureRulesDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("signature_id", idInt)
-				} else {
-					resp, err := ips_signature_rules.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("signature_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importByName("signature_id", ips_signature_rules.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -174,8 +153,7 @@ func resourceIPSSignatureRulesCreate(ctx context.Context, d *schema.ResourceData
 	d.SetId(strconv.Itoa(resp.ID))
 	_ = d.Set("signature_id", resp.ID)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -256,8 +234,7 @@ func resourceIPSSignatureRulesUpdate(ctx context.Context, d *schema.ResourceData
 		return diag.FromErr(err)
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -284,8 +261,7 @@ func resourceIPSSignatureRulesDelete(ctx context.Context, d *schema.ResourceData
 	d.SetId("")
 	log.Printf("[INFO] zia IPS signature rule deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -24,9 +24,9 @@ func TestAccResourceIPSSignatureRulesBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckIPSSignatureRulesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckIPSSignatureRulesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckIPSSignatureRulesConfigure(
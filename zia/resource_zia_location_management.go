@@ -6,11 +6,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/common"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/filteringrules"
@@ -23,27 +23,7 @@ func resourceLocationManagement() *schema.Resource {
 		ReadContext:   resourceLocationManagementRead,
 		UpdateContext: resourceLocationManagementUpdate,
 		DeleteContext: resourceLocationManagementDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("location_id", idInt)
-				} else {
-					resp, err := locationmanagement.GetLocationOrSublocationByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("location_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importByKey("location_id", locationImportCandidates),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -430,6 +410,34 @@ func resourceLocationManagement() *schema.Resource {
 	}
 }
 
+// locationImportCandidates lists locations by name and sublocations by name
+// and by "<location_name>/<sublocation_name>", the only unambiguous key for
+// sublocations such as "other" that every location has.
+func locationImportCandidates(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+	locations, err := locationmanagement.GetAll(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	sublocations, err := locationmanagement.GetAllSublocations(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[int]string, len(locations))
+	candidates := make([]importCandidate, 0, len(locations)+len(sublocations))
+	for _, location := range locations {
+		parents[location.ID] = location.Name
+		candidates = append(candidates, importCandidate{id: location.ID, name: location.Name})
+	}
+	for _, sublocation := range sublocations {
+		candidate := importCandidate{id: sublocation.ID, name: sublocation.Name}
+		if parent, ok := parents[sublocation.ParentID]; ok {
+			candidate.keys = []string{parent + "/" + sublocation.Name}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
 func resourceLocationManagementCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
 	service := zClient.Service
@@ -458,9 +466,7 @@ func resourceLocationManagementCreate(ctx context.Context, d *schema.ResourceDat
 	_ = d.Set("location_id", resp.ID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -638,9 +644,7 @@ func resourceLocationManagementUpdate(ctx context.Context, d *schema.ResourceDat
 	}
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -682,9 +686,7 @@ func resourceLocationManagementDelete(ctx context.Context, d *schema.ResourceDat
 	log.Printf("[INFO] location deleted")
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
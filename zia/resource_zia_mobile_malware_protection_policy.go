@@ -17,16 +17,7 @@ func resourceMobileMalwareProtectionPolicy() *schema.Resource {
 		ReadContext:   resourceMobileMalwareProtectionPolicyRead,
 		UpdateContext: resourceMobileMalwareProtectionPolicyUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceMobileMalwareProtectionPolicyRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("failed to read mobile malware protection policy import: %s", diags[0].Summary)
-				}
-				d.SetId("mobile_settings")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("mobile_settings", resourceMobileMalwareProtectionPolicyRead),
 		Schema: map[string]*schema.Schema{
 			"block_apps_with_malicious_activity": {
 				Type:        schema.TypeBool,
@@ -96,8 +87,8 @@ func resourceMobileMalwareProtectionPolicyCreate(ctx context.Context, d *schema.
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -160,8 +151,8 @@ func resourceMobileMalwareProtectionPolicyUpdate(ctx context.Context, d *schema.
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -12,9 +12,9 @@ func TestAccResourceMobileMalwareProtectionPolicy_Basic(t *testing.T) {
 	resourceName := "zia_mobile_malware_protection_policy.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckResourceMobileMalwareProtectionPolicyDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckResourceMobileMalwareProtectionPolicyDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create the resource with specific values
 			{
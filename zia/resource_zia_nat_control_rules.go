@@ -4,55 +4,89 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/nat_control_policies"
 )
 
-var (
-	natControlRuleLock          sync.Mutex
-	natControlRuleStartingOrder int
-)
+// natControlRuleAdapter is the OrderedRuleAdapter of NAT control rules.
+type natControlRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (natControlRuleAdapter) ResourceType() string { return "nat_control_rules" }
+func (natControlRuleAdapter) Ranked() bool         { return true }
+
+func (a natControlRuleAdapter) GetAll(ctx context.Context) ([]nat_control_policies.NatControlPolicies, error) {
+	return nat_control_policies.GetAll(ctx, a.service)
+}
+
+func (a natControlRuleAdapter) Get(ctx context.Context, id int) (*nat_control_policies.NatControlPolicies, error) {
+	return nat_control_policies.Get(ctx, a.service, id)
+}
+
+func (a natControlRuleAdapter) Create(ctx context.Context, rule *nat_control_policies.NatControlPolicies) (int, error) {
+	resp, err := nat_control_policies.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a natControlRuleAdapter) Update(ctx context.Context, id int, rule *nat_control_policies.NatControlPolicies) error {
+	_, err := nat_control_policies.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a natControlRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := nat_control_policies.Delete(ctx, a.service, id)
+	return err
+}
+
+func (natControlRuleAdapter) Order(rule *nat_control_policies.NatControlPolicies) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (natControlRuleAdapter) SetOrder(rule *nat_control_policies.NatControlPolicies, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (natControlRuleAdapter) StripReadOnly(rule *nat_control_policies.NatControlPolicies) {
+	// to avoid the STALE_CONFIGURATION_ERROR
+	rule.LastModifiedTime = 0
+	rule.LastModifiedBy = nil
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.Predefined = false
+	rule.DefaultRule = false
+	rule.AccessControl = ""
+}
+
+func (a natControlRuleAdapter) GetByName(ctx context.Context, name string) (*nat_control_policies.NatControlPolicies, error) {
+	return nat_control_policies.GetByName(ctx, a.service, name)
+}
+
+func (natControlRuleAdapter) IsPredefined(rule *nat_control_policies.NatControlPolicies) bool {
+	return rule.Predefined || rule.DefaultRule
+}
 
 func resourceNatControlRules() *schema.Resource {
-	return &schema.Resource{
+	return withAdoptPredefined(versionSchema(&schema.Resource{
 		CreateContext: resourceNatControlRulesCreate,
 		ReadContext:   resourceNatControlRulesRead,
 		UpdateContext: resourceNatControlRulesUpdate,
 		DeleteContext: resourceNatControlRulesDelete,
+		CustomizeDiff: validateRuleOrder("zia_nat_control_rules"),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := nat_control_policies.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", nat_control_policies.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -153,10 +187,10 @@ func resourceNatControlRules() *schema.Resource {
 				Optional:    true,
 				Description: "If set to true, a predefined rule is applied",
 			},
-			"locations":         setIDsSchemaTypeCustom(nil, "list of locations for which rule must be applied"),
+			"location_ids":      setIntIDsSchemaType(nil, "IDs of locations for which rule must be applied"),
 			"location_groups":   setIDsSchemaTypeCustom(nil, "list of locations groups"),
 			"users":             setIDsSchemaTypeCustom(nil, "list of users for which rule must be applied"),
-			"groups":            setIDsSchemaTypeCustom(nil, "list of groups for which rule must be applied"),
+			"group_ids":         setIntIDsSchemaType(nil, "IDs of groups for which rule must be applied"),
 			"departments":       setIDsSchemaTypeCustom(nil, "list of departments for which rule must be applied"),
 			"time_windows":      setIDsSchemaTypeCustom(intPtr(2), "The time interval in which the nat control policy rule applies"),
 			"labels":            setIDsSchemaTypeCustom(intPtr(1), "list of Labels that are applicable to the rule."),
@@ -170,130 +204,19 @@ func resourceNatControlRules() *schema.Resource {
 			"nw_services":       setIDsSchemaTypeCustom(intPtr(1024), "list of nw services"),
 			"dest_countries":    getISOCountryCodes(),
 		},
-	}
+	}, ruleIDBlocksV0))
 }
 
 func resourceNatControlRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandNatControlRules(d)
 	log.Printf("[INFO] Creating zia nat control rule\n%+v\n", req)
 
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-
-	for {
-		natControlRuleLock.Lock()
-		if natControlRuleStartingOrder == 0 {
-			list, _ := nat_control_policies.GetAll(ctx, service)
-			for _, r := range list {
-				if r.Order > natControlRuleStartingOrder {
-					natControlRuleStartingOrder = r.Order
-				}
-			}
-			if natControlRuleStartingOrder == 0 {
-				natControlRuleStartingOrder = 1
-			}
-		}
-		natControlRuleLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		intendedOrder := req.Order
-		intendedRank := req.Rank
-		if intendedRank < 7 {
-			// always start rank 7 rules at the next available order after all ranked rules
-			req.Rank = 7
-		}
-		req.Order = natControlRuleStartingOrder
-
-		resp, err := nat_control_policies.Create(ctx, service, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			reg := regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				if reg.MatchString(err.Error()) {
-					return diag.FromErr(fmt.Errorf("error creating resource: %s, please check the order %d vs rank %d, current rules:%s , err:%s", req.Name, intendedOrder, req.Rank, currentNatControlOrderVsRankWording(ctx, zClient), err))
-				}
-				if time.Since(start) < timeout {
-					log.Printf("[INFO] Creating nat control rule name: %v, got INVALID_INPUT_ARGUMENT\n", req.Name)
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-		}
-
-		log.Printf("[INFO] Created zia nat control rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-		reorderWithBeforeReorder(
-			OrderRule{Order: intendedOrder, Rank: intendedRank},
-			resp.ID,
-			"nat_control_rules",
-			func() (map[int]OrderRule, error) {
-				allRules, err := nat_control_policies.GetAll(ctx, service)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(allRules))
-				for _, r := range allRules {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				// Custom updateOrder that handles predefined rules
-				rule, err := nat_control_policies.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-
-				// to avoid the STALE_CONFIGURATION_ERROR
-				rule.LastModifiedTime = 0
-				rule.LastModifiedBy = nil
-				// Strip read-only fields that cause "Request body is invalid" for predefined rules
-				rule.Predefined = false
-				rule.DefaultRule = false
-				rule.AccessControl = ""
-				rule.Order = order.Order
-				rule.Rank = order.Rank
-				_, err = nat_control_policies.Update(ctx, service, id, rule)
-				return err
-			},
-			nil, // Remove beforeReorder function to avoid adding too many rules to the map
-		)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		if diags := resourceNatControlRulesRead(ctx, d, meta); diags.HasError() {
-			if time.Since(start) < timeout {
-				time.Sleep(10 * time.Second) // Wait before retrying
-				continue
-			}
-			return diags
-		}
-		markOrderRuleAsDone(resp.ID, "nat_control_rules")
-		waitForReorder("nat_control_rules")
-		break
+	if diags := createOrderedRule(ctx, d, zClient, natControlRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return resourceNatControlRulesRead(ctx, d, meta)
 }
 
 func resourceNatControlRulesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -305,7 +228,14 @@ func resourceNatControlRulesRead(ctx context.Context, d *schema.ResourceData, me
 		return diag.FromErr(fmt.Errorf("no zia nat control rule id is set"))
 	}
 
-	resp, err := nat_control_policies.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_nat_control_rules", id,
+		func() ([]nat_control_policies.NatControlPolicies, error) {
+			return nat_control_policies.GetAll(ctx, service)
+		},
+		func(r *nat_control_policies.NatControlPolicies) int { return r.ID },
+		func() (*nat_control_policies.NatControlPolicies, error) {
+			return nat_control_policies.Get(ctx, service, id)
+		})
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing nat control rule %s from state because it no longer exists in ZIA", d.Id())
@@ -342,7 +272,7 @@ func resourceNatControlRulesRead(ctx context.Context, d *schema.ResourceData, me
 	_ = d.Set("predefined", resp.Predefined)
 	_ = d.Set("res_categories", resp.ResCategories)
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -354,7 +284,7 @@ func resourceNatControlRulesRead(ctx context.Context, d *schema.ResourceData, me
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -407,7 +337,6 @@ func resourceNatControlRulesRead(ctx context.Context, d *schema.ResourceData, me
 
 func resourceNatControlRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -417,89 +346,9 @@ func resourceNatControlRulesUpdate(ctx context.Context, d *schema.ResourceData,
 	log.Printf("[INFO] Updating nat control rule ID: %v\n", id)
 	req := expandNatControlRules(d)
 
-	if _, err := nat_control_policies.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-
-	timeout := d.Timeout(schema.TimeoutUpdate)
-	start := time.Now()
-
-	for {
-		_, err := nat_control_policies.Update(ctx, service, id, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				log.Printf("[INFO] Updating nat control rule ID: %v, got INVALID_INPUT_ARGUMENT\n", id)
-				if time.Since(start) < timeout {
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-		}
-
-		reorderWithBeforeReorder(OrderRule{Order: req.Order, Rank: req.Rank}, req.ID, "nat_control_rules",
-			func() (map[int]OrderRule, error) {
-				allRules, err := nat_control_policies.GetAll(ctx, service)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(allRules))
-				for _, r := range allRules {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				rule, err := nat_control_policies.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-
-				// Optional: avoid unnecessary updates if the current order is already correct
-				if rule.Order == order.Order && rule.Rank == order.Rank {
-					return nil
-				}
-
-				// to avoid the STALE_CONFIGURATION_ERROR
-				rule.LastModifiedTime = 0
-				rule.LastModifiedBy = nil
-				// Strip read-only fields that cause "Request body is invalid" for predefined rules
-				rule.Predefined = false
-				rule.DefaultRule = false
-				rule.AccessControl = ""
-				rule.Order = order.Order
-				rule.Rank = order.Rank
-				_, err = nat_control_policies.Update(ctx, service, id, rule)
-				return err
-			},
-			nil, // Remove beforeReorder function to avoid adding too many rules to the map
-		)
-
-		markOrderRuleAsDone(req.ID, "nat_control_rules")
-		waitForReorder("nat_control_rules")
-		break
-	}
-
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := updateOrderedRule(ctx, d, zClient, natControlRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
 	return resourceNatControlRulesRead(ctx, d, meta)
 }
 
@@ -512,37 +361,22 @@ func resourceNatControlRulesDelete(ctx context.Context, d *schema.ResourceData,
 		log.Printf("[ERROR] nat control rule not set: %v\n", id)
 	}
 
-	// Retrieve the rule to check if it's a predefined one
-	rule, err := nat_control_policies.Get(ctx, service, id)
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error retrieving nat control rule %d: %v", id, err))
-	}
-
-	// Validate if the rule can be deleted
-	// Prevent deletion if the rule is predefined
-	if rule.Predefined {
-		return diag.FromErr(fmt.Errorf("deletion of predefined rule '%s' is not allowed", rule.Name))
-	}
+	// Adopted predefined rules are restored rather than deleted
+	if !adoptsPredefined(d) {
+		// Retrieve the rule to check if it's a predefined one
+		rule, err := nat_control_policies.Get(ctx, service, id)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error retrieving nat control rule %d: %v", id, err))
+		}
 
-	log.Printf("[INFO] Deleting nat control rule ID: %v\n", (d.Id()))
-	if _, err := nat_control_policies.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] nat control rule deleted")
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
+		// Validate if the rule can be deleted
+		// Prevent deletion if the rule is predefined
+		if rule.Predefined {
+			return diag.FromErr(fmt.Errorf("deletion of predefined rule '%s' is not allowed", rule.Name))
 		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
 	}
 
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, natControlRuleAdapter{service}, id)
 }
 
 func expandNatControlRules(d *schema.ResourceData) nat_control_policies.NatControlPolicies {
@@ -575,10 +409,10 @@ func expandNatControlRules(d *schema.ResourceData) nat_control_policies.NatContr
 		EnableFullLogging: d.Get("enable_full_logging").(bool),
 		DefaultRule:       d.Get("default_rule").(bool),
 		Predefined:        d.Get("predefined").(bool),
-		Locations:         expandIDNameExtensionsSet(d, "locations"),
+		Locations:         expandIDNameExtensionsIDs(d, "location_ids"),
 		LocationGroups:    expandIDNameExtensionsSet(d, "location_groups"),
 		Departments:       expandIDNameExtensionsSet(d, "departments"),
-		Groups:            expandIDNameExtensionsSet(d, "groups"),
+		Groups:            expandIDNameExtensionsIDs(d, "group_ids"),
 		Users:             expandIDNameExtensionsSet(d, "users"),
 		TimeWindows:       expandIDNameExtensionsSet(d, "time_windows"),
 		SrcIpGroups:       expandIDNameExtensionsSet(d, "src_ip_groups"),
@@ -593,21 +427,3 @@ func expandNatControlRules(d *schema.ResourceData) nat_control_policies.NatContr
 	}
 	return result
 }
-
-func currentNatControlOrderVsRankWording(ctx context.Context, zClient *Client) string {
-	service := zClient.Service
-
-	list, err := nat_control_policies.GetAll(ctx, service)
-	if err != nil {
-		return ""
-	}
-	result := ""
-	for i, r := range list {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("Rank %d VS Order %d", r.Rank, r.Order)
-
-	}
-	return result
-}
@@ -10,6 +10,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/cloudnss/nss_servers"
 )
@@ -20,27 +21,9 @@ func resourceNSSServer() *schema.Resource {
 		ReadContext:   resourceNSSServerRead,
 		UpdateContext: resourceNSSServerUpdate,
 		DeleteContext: resourceNSSServerDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("nss_id", idInt)
-				} else {
-					resp, err := nss_servers.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("nss_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("nss_id", func(ctx context.Context, service *zscaler.Service) ([]nss_servers.NSSServers, error) {
+			return nss_servers.GetAll(ctx, service, nil)
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -104,7 +87,7 @@ func resourceNSSServerCreate(ctx context.Context, d *schema.ResourceData, meta i
 
 	time.Sleep(2 * time.Second)
 
-	if shouldActivate() {
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -168,7 +151,7 @@ func resourceNSSServerUpdate(ctx context.Context, d *schema.ResourceData, meta i
 
 	time.Sleep(2 * time.Second)
 
-	if shouldActivate() {
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -197,7 +180,7 @@ func resourceNSSServerDelete(ctx context.Context, d *schema.ResourceData, meta i
 
 	time.Sleep(2 * time.Second)
 
-	if shouldActivate() {
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
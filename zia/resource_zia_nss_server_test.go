@@ -23,9 +23,9 @@ func TestAccResourceNSSServer_Basic(t *testing.T) {
 	initialName := "tf-acc-test-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckNSSServerDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckNSSServerDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckNSSServerConfigure(resourceTypeAndName, initialName, variable.NSSStatus, variable.NSSType),
@@ -6,29 +6,91 @@ import (
 	"log"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/endpoint_dlp/outbound_email_dlp"
 )
 
-var (
-	outboundEmailDlpLock                  sync.Mutex
-	outboundEmailDlpStartingOrder         int
-	outboundEmailDlpSubRulesStartingOrder = make(map[int]int)
-)
+// outboundEmailDlpAdapter orders either the top-level outbound email DLP rules
+// or, when parentRule is set, the sub-rules of that parent. Outbound email DLP
+// rules carry no admin rank.
+type outboundEmailDlpAdapter struct {
+	service    *zscaler.Service
+	parentRule int
+}
+
+func (a outboundEmailDlpAdapter) ResourceType() string {
+	if a.parentRule != 0 {
+		return fmt.Sprintf("outbound_email_dlp_sub_%d", a.parentRule)
+	}
+	return "outbound_email_dlp"
+}
+
+func (outboundEmailDlpAdapter) Ranked() bool { return false }
+
+func (a outboundEmailDlpAdapter) GetAll(ctx context.Context) ([]outbound_email_dlp.OutboundEmailDlp, error) {
+	if a.parentRule == 0 {
+		return outbound_email_dlp.GetAll(ctx, a.service, nil)
+	}
+	parent, err := outbound_email_dlp.Get(ctx, a.service, a.parentRule)
+	if err != nil {
+		return nil, err
+	}
+	return parent.SubRules, nil
+}
+
+func (a outboundEmailDlpAdapter) Get(ctx context.Context, id int) (*outbound_email_dlp.OutboundEmailDlp, error) {
+	return outbound_email_dlp.Get(ctx, a.service, id)
+}
+
+func (a outboundEmailDlpAdapter) Create(ctx context.Context, rule *outbound_email_dlp.OutboundEmailDlp) (int, error) {
+	resp, _, err := outbound_email_dlp.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a outboundEmailDlpAdapter) Update(ctx context.Context, id int, rule *outbound_email_dlp.OutboundEmailDlp) error {
+	_, _, err := outbound_email_dlp.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a outboundEmailDlpAdapter) Delete(ctx context.Context, id int) error {
+	_, err := outbound_email_dlp.Delete(ctx, a.service, id)
+	if err != nil && strings.Contains(err.Error(), "RESOURCE_NOT_FOUND") {
+		log.Printf("[INFO] outbound email dlp rule %d not found, skipping deletion", id)
+		return nil
+	}
+	return err
+}
+
+func (outboundEmailDlpAdapter) Order(rule *outbound_email_dlp.OutboundEmailDlp) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order}
+}
+
+func (outboundEmailDlpAdapter) SetOrder(rule *outbound_email_dlp.OutboundEmailDlp, order OrderRule) {
+	rule.Order = order.Order
+}
+
+func (outboundEmailDlpAdapter) StripReadOnly(rule *outbound_email_dlp.OutboundEmailDlp) {
+	rule.LastModifiedTime = 0
+	rule.LastModifiedBy = nil
+}
 
 func resourceOutboundEmailDLP() *schema.Resource {
-	return &schema.Resource{
+	return versionSchema(&schema.Resource{
 		CreateContext: resourceOutboundEmailDLPCreate,
 		ReadContext:   resourceOutboundEmailDLPRead,
 		UpdateContext: resourceOutboundEmailDLPUpdate,
 		DeleteContext: resourceOutboundEmailDLPDelete,
-		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		CustomizeDiff: customdiff.All(func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
 			externalEmail, emailSet := d.GetOk("external_auditor_email")
 			auditorRaw, auditorSet := d.GetOk("auditor")
 			nt, ntSet := d.GetOk("notification_template")
@@ -59,32 +121,14 @@ func resourceOutboundEmailDLP() *schema.Resource {
 			}
 
 			return nil
-		},
+		}, validateRuleOrder("zia_outbound_email_dlp")),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := outbound_email_dlp.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", func(ctx context.Context, service *zscaler.Service) ([]outbound_email_dlp.OutboundEmailDlp, error) {
+			return outbound_email_dlp.GetAll(ctx, service, nil)
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -191,7 +235,7 @@ func resourceOutboundEmailDLP() *schema.Resource {
 				Description: "The list of exception rules added to a parent rule.",
 			},
 			"user_risk_score_levels":   getUserRiskScoreLevels(),
-			"groups":                   setIDsSchemaTypeCustom(nil, "The Name-ID pairs of groups to which the DLP policy rule must be applied"),
+			"group_ids":                setIntIDsSchemaType(nil, "The IDs of groups to which the DLP policy rule must be applied"),
 			"departments":              setIDsSchemaTypeCustom(nil, "The Name-ID pairs of departments to which the DLP policy rule must be applied"),
 			"users":                    setIDsSchemaTypeCustom(nil, "The Name-ID pairs of users to which the DLP policy rule must be applied"),
 			"excluded_groups":          setIDsSchemaTypeCustom(nil, "The Name-ID pairs of groups that are excluded from the DLP policy rule"),
@@ -221,162 +265,22 @@ func resourceOutboundEmailDLP() *schema.Resource {
 				},
 			},
 		},
-	}
+	}, ruleIDBlocksV0)
 }
 
 func resourceOutboundEmailDLPCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandOutboundEmailDLP(d)
 
 	log.Printf("[INFO] Creating zia outbound email dlp rule\n%+v\n", req)
 
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-	isSubRule := req.ParentRule != 0
-	for {
-		outboundEmailDlpLock.Lock()
-		if isSubRule {
-			if outboundEmailDlpSubRulesStartingOrder[req.ParentRule] == 0 {
-				list, _ := outbound_email_dlp.Get(ctx, service, req.ParentRule)
-				if list != nil {
-					for _, subRule := range list.SubRules {
-						r, err := outbound_email_dlp.Get(ctx, service, subRule.ID)
-						if err != nil {
-							outboundEmailDlpLock.Unlock()
-							return diag.FromErr(err)
-						}
-						if r.Order > outboundEmailDlpSubRulesStartingOrder[req.ParentRule] {
-							outboundEmailDlpSubRulesStartingOrder[req.ParentRule] = r.Order
-						}
-					}
-				}
-				if outboundEmailDlpSubRulesStartingOrder[req.ParentRule] == 0 {
-					outboundEmailDlpSubRulesStartingOrder[req.ParentRule] = 1
-				}
-			}
-		} else {
-			if outboundEmailDlpStartingOrder == 0 {
-				list, _ := outbound_email_dlp.GetAll(ctx, service, nil)
-				for _, r := range list {
-					if r.Order > outboundEmailDlpStartingOrder {
-						outboundEmailDlpStartingOrder = r.Order
-					}
-				}
-				if outboundEmailDlpStartingOrder == 0 {
-					outboundEmailDlpStartingOrder = 1
-				}
-			}
-		}
-		outboundEmailDlpLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		order := req.Order
-		if isSubRule {
-			req.Order = outboundEmailDlpSubRulesStartingOrder[req.ParentRule]
-		} else {
-			req.Order = outboundEmailDlpStartingOrder
-		}
-
-		resp, _, err := outbound_email_dlp.Create(ctx, service, &req)
-
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				if time.Since(start) < timeout {
-					time.Sleep(5 * time.Second)
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-		}
-
-		log.Printf("[INFO] Created zia outbound email dlp rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-
-		resourceType := "outbound_email_dlp"
-		if isSubRule {
-			resourceType = fmt.Sprintf("outbound_email_dlp_sub_%d", req.ParentRule)
-		}
-
-		reorderWithBeforeReorder(
-			OrderRule{Order: order, Rank: 0},
-			resp.ID,
-			resourceType,
-			func() (map[int]OrderRule, error) {
-				if isSubRule {
-					parent, err := outbound_email_dlp.Get(ctx, service, req.ParentRule)
-					if err != nil {
-						return nil, err
-					}
-					m := make(map[int]OrderRule, len(parent.SubRules))
-					for _, r := range parent.SubRules {
-						m[r.ID] = OrderRule{Order: r.Order, Rank: 0}
-					}
-					return m, nil
-				}
-				allRules, err := outbound_email_dlp.GetAll(ctx, service, nil)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(allRules))
-				for _, r := range allRules {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: 0}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				rule, err := outbound_email_dlp.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-				if rule.Order == order.Order {
-					return nil
-				}
-
-				rule.LastModifiedTime = 0
-				rule.LastModifiedBy = nil
-				rule.Order = order.Order
-
-				_, _, err = outbound_email_dlp.Update(ctx, service, id, rule)
-				if err != nil {
-					log.Printf("[ERROR] Failed to update order for rule ID %d: %v", id, err)
-				}
-				return err
-			},
-			nil,
-		)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		if diags := resourceOutboundEmailDLPRead(ctx, d, meta); diags.HasError() {
-			if time.Since(start) < timeout {
-				time.Sleep(10 * time.Second)
-				continue
-			}
-			return diags
-		}
-
-		markOrderRuleAsDone(resp.ID, resourceType)
-		waitForReorder(resourceType)
-		break
+	a := outboundEmailDlpAdapter{service: zClient.Service, parentRule: req.ParentRule}
+	if diags := createOrderedRule(ctx, d, zClient, a, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
 
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return resourceOutboundEmailDLPRead(ctx, d, meta)
 }
 
 func resourceOutboundEmailDLPRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -387,7 +291,12 @@ func resourceOutboundEmailDLPRead(ctx context.Context, d *schema.ResourceData, m
 	if !ok {
 		return diag.FromErr(fmt.Errorf("no zia outbound email dlp rule id is set"))
 	}
-	resp, err := outbound_email_dlp.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_outbound_email_dlp", id,
+		func() ([]outbound_email_dlp.OutboundEmailDlp, error) {
+			return outbound_email_dlp.GetAll(ctx, service, nil)
+		},
+		func(r *outbound_email_dlp.OutboundEmailDlp) int { return r.ID },
+		func() (*outbound_email_dlp.OutboundEmailDlp, error) { return outbound_email_dlp.Get(ctx, service, id) })
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing outbound email dlp rule %s from state because it no longer exists in ZIA", d.Id())
@@ -424,7 +333,7 @@ func resourceOutboundEmailDLPRead(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(fmt.Errorf("error setting sub_rules: %s", err))
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 	if err := d.Set("departments", flattenIDExtensionsListIDs(resp.Departments)); err != nil {
@@ -475,7 +384,6 @@ func resourceOutboundEmailDLPRead(ctx context.Context, d *schema.ResourceData, m
 
 func resourceOutboundEmailDLPUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -485,86 +393,9 @@ func resourceOutboundEmailDLPUpdate(ctx context.Context, d *schema.ResourceData,
 
 	req := expandOutboundEmailDLP(d)
 
-	timeout := d.Timeout(schema.TimeoutUpdate)
-	start := time.Now()
-
-	for {
-		_, _, err := outbound_email_dlp.Update(ctx, service, id, &req)
-
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			log.Printf("[INFO] Retrying due to API error: %s", err)
-			if time.Since(start) < timeout {
-				time.Sleep(5 * time.Second)
-				continue
-			}
-			return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-		}
-
-		break
-	}
-
-	isSubRule := req.ParentRule != 0
-	resourceType := "outbound_email_dlp"
-	if isSubRule {
-		resourceType = fmt.Sprintf("outbound_email_dlp_sub_%d", req.ParentRule)
-	}
-
-	reorderWithBeforeReorder(OrderRule{Order: req.Order, Rank: 0}, id, resourceType,
-		func() (map[int]OrderRule, error) {
-			if isSubRule {
-				parent, err := outbound_email_dlp.Get(ctx, service, req.ParentRule)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(parent.SubRules))
-				for _, r := range parent.SubRules {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: 0}
-				}
-				return m, nil
-			}
-			allRules, err := outbound_email_dlp.GetAll(ctx, service, nil)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: 0}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := outbound_email_dlp.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			if rule.Order == order.Order {
-				return nil
-			}
-
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			rule.Order = order.Order
-
-			_, _, err = outbound_email_dlp.Update(ctx, service, id, rule)
-			if err != nil {
-				log.Printf("[ERROR] Failed to update order for rule ID %d: %v", id, err)
-			}
-			return err
-		},
-		nil,
-	)
-
-	markOrderRuleAsDone(id, resourceType)
-	waitForReorder(resourceType)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
+	a := outboundEmailDlpAdapter{service: zClient.Service, parentRule: req.ParentRule}
+	if diags := updateOrderedRule(ctx, d, zClient, a, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
 
 	return resourceOutboundEmailDLPRead(ctx, d, meta)
@@ -572,34 +403,13 @@ func resourceOutboundEmailDLPUpdate(ctx context.Context, d *schema.ResourceData,
 
 func resourceOutboundEmailDLPDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
 		log.Printf("[ERROR] outbound email dlp rule not set: %v\n", id)
 	}
-	log.Printf("[INFO] Deleting outbound email dlp rule ID: %v\n", (d.Id()))
-
-	if _, err := outbound_email_dlp.Delete(ctx, service, id); err != nil {
-		if strings.Contains(err.Error(), "RESOURCE_NOT_FOUND") {
-			log.Printf("[INFO] outbound email dlp rule %d not found, skipping deletion", id)
-			return nil
-		}
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] outbound email dlp rule deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, outboundEmailDlpAdapter{service: zClient.Service}, id)
 }
 
 // expandOutboundEmailSubRules builds the list of child rules referenced by a
@@ -645,7 +455,7 @@ func expandOutboundEmailDLP(d *schema.ResourceData) outbound_email_dlp.OutboundE
 		FileTypes:                SetToStringList(d, "file_types"),
 		ContentLocations:         SetToStringList(d, "content_locations"),
 		UserRiskScoreLevels:      SetToStringList(d, "user_risk_score_levels"),
-		Groups:                   expandIDNameExtensionsSet(d, "groups"),
+		Groups:                   expandIDNameExtensionsIDs(d, "group_ids"),
 		Departments:              expandIDNameExtensionsSet(d, "departments"),
 		Users:                    expandIDNameExtensionsSet(d, "users"),
 		ExcludedGroups:           expandIDNameExtensionsSet(d, "excluded_groups"),
@@ -6,7 +6,6 @@ import (
 	"log"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -61,33 +60,25 @@ func resourcePacFiles() *schema.Resource {
 				zClient := meta.(*Client)
 				service := zClient.Service
 
-				id := d.Id()
-				// Parse with the platform's int size so the conversion to
-				// int below can never truncate; an oversized value fails
-				// parsing and is treated as a name lookup instead.
-				idInt, parseIDErr := strconv.ParseInt(id, 10, strconv.IntSize)
+				allFiles, err := pacfiles.GetPacFiles(ctx, service, "")
+				if err != nil {
+					return nil, err
+				}
+				id, err := resolveImportID(d.Id(), func() ([]importCandidate, error) {
+					return namedImportCandidates(allFiles), nil
+				})
+				if err != nil {
+					return nil, err
+				}
 				var foundFile *pacfiles.PACFileConfig
-				if parseIDErr == nil {
-					allFiles, err := pacfiles.GetPacFiles(ctx, service, "")
-					if err != nil {
-						return nil, err
+				for i := range allFiles {
+					if allFiles[i].ID == id {
+						foundFile = &allFiles[i]
+						break
 					}
-					for _, pacFile := range allFiles {
-						if pacFile.ID == int(idInt) {
-							f := pacFile
-							foundFile = &f
-							break
-						}
-					}
-					if foundFile == nil {
-						return nil, fmt.Errorf("no PAC file found with ID: %d", idInt)
-					}
-				} else {
-					resp, err := pacfiles.GetPacFileByName(ctx, service, id)
-					if err != nil {
-						return nil, err
-					}
-					foundFile = resp
+				}
+				if foundFile == nil {
+					return nil, fmt.Errorf("no PAC file found with ID: %d", id)
 				}
 
 				// Adopt the deployed version as the tracked version.
@@ -281,8 +272,7 @@ func resourcePacFilesCreate(ctx context.Context, d *schema.ResourceData, meta in
 	_ = d.Set("pac_id", resp.ID)
 	_ = d.Set("pac_version", resp.PACVersion)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -458,8 +448,7 @@ func resourcePacFilesUpdate(ctx context.Context, d *schema.ResourceData, meta in
 	// Record the targeted version only after the transition succeeded.
 	_ = d.Set("pac_version", trackedVersion)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -494,8 +483,7 @@ func resourcePacFilesDelete(ctx context.Context, d *schema.ResourceData, meta in
 	d.SetId("")
 	log.Printf("[INFO] PAC file deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -26,9 +26,9 @@ func TestAccResourcePacFilesBasic(t *testing.T) {
 	initialName := "tf-acc-test-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckPacFilesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckPacFilesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckPacFilesConfigure(resourceTypeAndName, initialName, variable.PacFileDescription, testAccPacContentV1),
@@ -5,178 +5,250 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/common"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/filteringrules"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/rule_labels"
 )
 
-func resourceRuleLabels() *schema.Resource {
-	return &schema.Resource{
-		CreateContext: resourceRuleLabelsCreate,
-		ReadContext:   resourceRuleLabelsRead,
-		UpdateContext: resourceRuleLabelsUpdate,
-		DeleteContext: resourceRuleLabelsDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_label_id", idInt)
-				} else {
-					resp, err := rule_labels.GetRuleLabelByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_label_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+// zia_rule_labels is served by the plugin framework. Its schema and state
+// layout are identical to the former SDKv2 implementation, so existing state
+// is read without an upgrade.
+
+var (
+	_ resource.Resource                = &ruleLabelsResource{}
+	_ resource.ResourceWithConfigure   = &ruleLabelsResource{}
+	_ resource.ResourceWithImportState = &ruleLabelsResource{}
+)
+
+func NewRuleLabelsResource() resource.Resource {
+	return &ruleLabelsResource{}
+}
+
+type ruleLabelsResource struct {
+	client *Client
+}
 
-		Schema: map[string]*schema.Schema{
-			"id": {
-				Type:     schema.TypeString,
+type ruleLabelsResourceModel struct {
+	ID          types.String         `tfsdk:"id"`
+	RuleLabelID types.Int64          `tfsdk:"rule_label_id"`
+	Name        types.String         `tfsdk:"name"`
+	Description multiLineStringValue `tfsdk:"description"`
+}
+
+func (r *ruleLabelsResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rule_labels"
+}
+
+func (r *ruleLabelsResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
 				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
-			"rule_label_id": {
-				Type:     schema.TypeInt,
+			"rule_label_id": schema.Int64Attribute{
 				Computed: true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
 			},
-			"name": {
-				Type:         schema.TypeString,
-				Optional:     true,
-				ValidateFunc: validation.StringLenBetween(0, 255),
+			"name": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(0, 255),
+				},
 			},
-			"description": {
-				Type:             schema.TypeString,
-				Optional:         true,
-				ValidateFunc:     validation.StringLenBetween(0, 10240),
-				StateFunc:        normalizeMultiLineString, // Ensures correct format before storing in Terraform state
-				DiffSuppressFunc: noChangeInMultiLineText,  // Prevents unnecessary Terraform diffs
+			"description": schema.StringAttribute{
+				Optional:   true,
+				CustomType: multiLineStringType{},
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(0, 10240),
+				},
 			},
 		},
 	}
 }
 
-func resourceRuleLabelsCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	zClient, ok := meta.(*Client)
-	if !ok {
-		return diag.Errorf("unexpected meta type: expected *Client, got %T", meta)
+func (r *ruleLabelsResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = frameworkClient(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *ruleLabelsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if frameworkInertClient(r.client, &resp.Diagnostics) {
+		return
+	}
+	defer trackFrameworkWrite(ctx, r.client, &resp.Diagnostics)()
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	service := zClient.Service
+	var plan ruleLabelsResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, write := r.client.journal.beginWrite(ctx, "zia_rule_labels", "create")
+	defer func() {
+		r.client.journal.commitWrite(write, plan.ID.ValueString(), plan.Name.ValueString(), ruleLabelsJournalDiff(ruleLabelsResourceModel{}, plan))
+	}()
 
-	req := expandRuleLabels(d)
-	log.Printf("[INFO] Creating ZIA rule labels\n%+v\n", req)
+	label := expandRuleLabels(plan)
+	log.Printf("[INFO] Creating ZIA rule labels\n%+v\n", label)
 
-	resp, _, err := rule_labels.Create(ctx, service, &req)
+	created, _, err := rule_labels.Create(ctx, r.client.Service, &label)
 	if err != nil {
-		return diag.FromErr(err)
-	}
-	log.Printf("[INFO] Created ZIA rule labels request. ID: %v\n", resp)
-	d.SetId(strconv.Itoa(resp.ID))
-	_ = d.Set("rule_label_id", resp.ID)
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+		resp.Diagnostics.AddError("error creating rule label", err.Error())
+		return
+	}
+	log.Printf("[INFO] Created ZIA rule labels request. ID: %v\n", created)
+	plan.ID = types.StringValue(strconv.Itoa(created.ID))
+	plan.RuleLabelID = types.Int64Value(int64(created.ID))
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+
+	frameworkActivate(ctx, r.client, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	return resourceRuleLabelsRead(ctx, d, meta)
+	r.read(ctx, &plan, true, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 }
 
-func resourceRuleLabelsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	zClient := meta.(*Client)
-	service := zClient.Service
+func (r *ruleLabelsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if frameworkInertClient(r.client, &resp.Diagnostics) {
+		return
+	}
+
+	var state ruleLabelsResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	id, ok := getIntFromResourceData(d, "rule_label_id")
+	if found := r.read(ctx, &state, false, &resp.Diagnostics); !found {
+		if !resp.Diagnostics.HasError() {
+			log.Printf("[WARN] Removing zia rule labels %s from state because it no longer exists in ZIA", state.ID.ValueString())
+			resp.State.RemoveResource(ctx)
+		}
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// read refreshes model from the API and reports whether the label exists.
+// After Create and Update (planned == true) an empty API value keeps the
+// planned value; on refresh it becomes null, which also converts the "" the
+// SDKv2 implementation stored for unset attributes.
+func (r *ruleLabelsResource) read(ctx context.Context, model *ruleLabelsResourceModel, planned bool, diags *diag.Diagnostics) bool {
+	id, ok := ruleLabelID(*model)
 	if !ok {
-		return diag.FromErr(fmt.Errorf("no rule labels id is set"))
+		diags.AddError("error reading rule label", "no rule labels id is set")
+		return false
 	}
-	resp, err := rule_labels.Get(ctx, service, id)
+
+	label, err := rule_labels.Get(ctx, r.client.Service, id)
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			log.Printf("[WARN] Removing zia rule labels %s from state because it no longer exists in ZIA", d.Id())
-			d.SetId("")
-			return nil
+			return false
 		}
-
-		return diag.FromErr(err)
+		diags.AddError("error reading rule label", err.Error())
+		return false
 	}
 
-	log.Printf("[INFO] Getting zia rule labels:\n%+v\n", resp)
-
-	d.SetId(fmt.Sprintf("%d", resp.ID))
-	_ = d.Set("rule_label_id", resp.ID)
-	_ = d.Set("name", resp.Name)
-	_ = d.Set("description", resp.Description)
+	log.Printf("[INFO] Getting zia rule labels:\n%+v\n", label)
 
-	return nil
+	model.ID = types.StringValue(fmt.Sprintf("%d", label.ID))
+	model.RuleLabelID = types.Int64Value(int64(label.ID))
+	model.Name = emptyStringValue(label.Name, model.Name, planned)
+	model.Description = multiLineStringValue{StringValue: emptyStringValue(label.Description, model.Description.StringValue, planned)}
+	return true
 }
 
-func resourceRuleLabelsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	zClient := meta.(*Client)
-	service := zClient.Service
+func (r *ruleLabelsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if frameworkInertClient(r.client, &resp.Diagnostics) {
+		return
+	}
+	defer trackFrameworkWrite(ctx, r.client, &resp.Diagnostics)()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan, state ruleLabelsResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, write := r.client.journal.beginWrite(ctx, "zia_rule_labels", "update")
+	defer r.client.journal.commitWrite(write, state.ID.ValueString(), plan.Name.ValueString(), ruleLabelsJournalDiff(state, plan))
 
-	id, ok := getIntFromResourceData(d, "rule_label_id")
+	id, ok := ruleLabelID(plan)
 	if !ok {
 		log.Printf("[ERROR] rule label ID not set: %v\n", id)
 	}
 	log.Printf("[INFO] Updating zia rule label ID: %v\n", id)
-	req := expandRuleLabels(d)
-	if _, err := rule_labels.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
+	label := expandRuleLabels(plan)
+	if _, _, err := rule_labels.Update(ctx, r.client.Service, id, &label); err != nil {
+		resp.Diagnostics.AddError("error updating rule label", err.Error())
+		return
 	}
-	if _, _, err := rule_labels.Update(ctx, service, id, &req); err != nil {
-		return diag.FromErr(err)
+
+	frameworkActivate(ctx, r.client, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
+	if found := r.read(ctx, &plan, true, &resp.Diagnostics); !found {
+		if !resp.Diagnostics.HasError() {
+			resp.Diagnostics.AddError("error updating rule label", fmt.Sprintf("rule label %d no longer exists in ZIA", id))
 		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+		return
 	}
-
-	return resourceRuleLabelsRead(ctx, d, meta)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 }
 
-func resourceRuleLabelsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	zClient := meta.(*Client)
-	service := zClient.Service
+func (r *ruleLabelsResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if frameworkInertClient(r.client, &resp.Diagnostics) {
+		return
+	}
+	defer trackFrameworkWrite(ctx, r.client, &resp.Diagnostics)()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state ruleLabelsResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, write := r.client.journal.beginWrite(ctx, "zia_rule_labels", "delete")
+	defer r.client.journal.commitWrite(write, state.ID.ValueString(), state.Name.ValueString(), ruleLabelsJournalDiff(state, ruleLabelsResourceModel{}))
 
-	id, ok := getIntFromResourceData(d, "rule_label_id")
+	id, ok := ruleLabelID(state)
 	if !ok {
 		log.Printf("[ERROR] rule label ID not set: %v\n", id)
 	}
-	log.Printf("[INFO] Deleting zia rule label ID: %v\n", (d.Id()))
+	log.Printf("[INFO] Deleting zia rule label ID: %v\n", state.ID.ValueString())
 	err := DetachRuleIDNameExtensions(
 		ctx,
-		zClient,
+		r.client,
 		id,
 		"Labels",
 		func(r *filteringrules.FirewallFilteringRules) []common.IDNameExtensions {
@@ -187,34 +259,83 @@ func resourceRuleLabelsDelete(ctx context.Context, d *schema.ResourceData, meta
 		},
 	)
 	if err != nil {
-		return diag.FromErr(err)
+		resp.Diagnostics.AddError("error detaching rule label", err.Error())
+		return
 	}
-	if _, err := rule_labels.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(err)
+	if _, err := rule_labels.Delete(ctx, r.client.Service, id); err != nil {
+		resp.Diagnostics.AddError("error deleting rule label", err.Error())
+		return
 	}
-	d.SetId("")
 	log.Printf("[INFO] zia rule label deleted")
 
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
+	frameworkActivate(ctx, r.client, &resp.Diagnostics)
+}
+
+// ruleLabelsJournalDiff is the journal diff between two rule label models;
+// the zero model stands for the absent resource.
+func ruleLabelsJournalDiff(before, after ruleLabelsResourceModel) map[string]journalChange {
+	diff := map[string]journalChange{}
+	value := func(s interface{ ValueString() string }) interface{} {
+		if v := s.ValueString(); v != "" {
+			return v
+		}
+		return nil
+	}
+	if value(before.Name) != value(after.Name) {
+		diff["name"] = journalChange{Old: value(before.Name), New: value(after.Name)}
+	}
+	if value(before.Description) != value(after.Description) {
+		diff["description"] = journalChange{Old: value(before.Description), New: value(after.Description)}
+	}
+	return diff
+}
+
+// ImportState accepts the numeric rule label ID, name:<name> or its name.
+func (r *ruleLabelsResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if frameworkInertClient(r.client, &resp.Diagnostics) {
+		return
+	}
+
+	labelID, err := resolveImportID(req.ID, func() ([]importCandidate, error) {
+		labels, err := rule_labels.GetAll(ctx, r.client.Service)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+		return namedImportCandidates(labels), nil
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("error importing rule label", err.Error())
+		return
 	}
+	id := int64(labelID)
 
-	return nil
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), strconv.FormatInt(id, 10))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("rule_label_id"), id)...)
 }
 
-func expandRuleLabels(d *schema.ResourceData) rule_labels.RuleLabels {
-	id, _ := getIntFromResourceData(d, "rule_label_id")
-	result := rule_labels.RuleLabels{
+func ruleLabelID(model ruleLabelsResourceModel) (int, bool) {
+	if id := model.RuleLabelID.ValueInt64(); id > 0 {
+		return int(id), true
+	}
+	id, err := strconv.Atoi(model.ID.ValueString())
+	return id, err == nil && id > 0
+}
+
+func expandRuleLabels(model ruleLabelsResourceModel) rule_labels.RuleLabels {
+	id, _ := ruleLabelID(model)
+	return rule_labels.RuleLabels{
 		ID:          id,
-		Name:        d.Get("name").(string),
-		Description: d.Get("description").(string),
+		Name:        model.Name.ValueString(),
+		Description: model.Description.ValueString(),
+	}
+}
+
+func emptyStringValue(value string, current types.String, planned bool) types.String {
+	if value != "" {
+		return types.StringValue(value)
+	}
+	if planned {
+		return current
 	}
-	return result
+	return types.StringNull()
 }
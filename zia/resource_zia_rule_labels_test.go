@@ -17,6 +17,8 @@ import (
 )
 
 func TestAccResourceRuleLabelsBasic(t *testing.T) {
+	testAccVCR(t)
+
 	var labels rule_labels.RuleLabels
 	resourceTypeAndName, _, generatedName := method.GenerateRandomSourcesTypeAndName(resourcetype.RuleLabels)
 
@@ -24,9 +26,9 @@ func TestAccResourceRuleLabelsBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckRuleLabelsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckRuleLabelsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckRuleLabelsConfigure(resourceTypeAndName, initialName, variable.RuleLabelDescription),
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"reflect"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -18,31 +17,7 @@ func resourceSandboxSettings() *schema.Resource {
 		ReadContext:   resourceSandboxSettingsRead,
 		UpdateContext: resourceSandboxSettingsUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				// Use the Get method from the SDK to fetch the MD5 file hashes
-				hashes, err := sandbox_settings.Get(ctx, service)
-				if err != nil {
-					return nil, fmt.Errorf("error fetching MD5 file hashes: %s", err)
-				}
-
-				// Assuming BaAdvancedSettings struct contains a slice of MD5 hashes under the attribute name "FileHashesToBeBlocked"
-				if hashes != nil {
-					if err := d.Set("file_hashes_to_be_blocked", hashes.FileHashesToBeBlocked); err != nil {
-						return nil, fmt.Errorf("error setting file_hashes_to_be_blocked: %s", err)
-					}
-				}
-
-				// Set an ID for the imported resource. Since this resource seems to encompass a global setting rather than individual items,
-				// a static ID like "sandbox_settings" could be used. Adjust if your use case requires.
-				d.SetId("sandbox_settings")
-
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("sandbox_settings", resourceSandboxSettingsRead),
 
 		Schema: map[string]*schema.Schema{
 			"file_hashes_to_be_blocked": {
@@ -74,9 +49,7 @@ func resourceSandboxSettingsCreate(ctx context.Context, d *schema.ResourceData,
 	}
 	d.SetId("sandbox_settings")
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -134,9 +107,7 @@ func resourceSandboxSettingsUpdate(ctx context.Context, d *schema.ResourceData,
 		}
 	}
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -41,8 +41,8 @@ func TestResourceSandboxSettings_basic(t *testing.T) {
 		"2c50efc0fef1601ce1b96b1b7cf991fb",
 	}
 	resource.Test(t, resource.TestCase{
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckSandboxSettingsDestroy,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckSandboxSettingsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccResourceAndDataSourceSandboxSettingsConfig(initialHashes),
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"sort"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -20,26 +19,7 @@ func resourceSandboxSettingsV2() *schema.Resource {
 		UpdateContext: resourceSandboxSettingsV2Update,
 		DeleteContext: resourceFuncNoOp,
 		CustomizeDiff: sandboxSettingsV2CustomizeDiff,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				payload, err := sandbox_settings.Getv2(ctx, service)
-				if err != nil {
-					return nil, fmt.Errorf("error fetching sandbox behavioral analysis advanced settings: %s", err)
-				}
-
-				if payload != nil && len(payload.Md5HashValueList) > 0 {
-					if err := d.Set("md5_hash_value_list", flattenMd5HashValueList(payload.Md5HashValueList)); err != nil {
-						return nil, fmt.Errorf("error setting md5_hash_value_list: %s", err)
-					}
-				}
-
-				d.SetId("sandbox_settings")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("sandbox_settings", resourceSandboxSettingsV2Read),
 
 		Schema: map[string]*schema.Schema{
 			"md5_hash_value_list": {
@@ -120,8 +100,7 @@ func resourceSandboxSettingsV2Create(ctx context.Context, d *schema.ResourceData
 	d.SetId("sandbox_settings")
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -181,8 +160,7 @@ func resourceSandboxSettingsV2Update(ctx context.Context, d *schema.ResourceData
 		}
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
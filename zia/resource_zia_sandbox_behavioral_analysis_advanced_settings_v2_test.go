@@ -35,8 +35,8 @@ func TestResourceSandboxSettingsV2_basic(t *testing.T) {
 	}
 
 	resource.Test(t, resource.TestCase{
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckSandboxSettingsV2Destroy,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckSandboxSettingsV2Destroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create with initial hashes
 			{
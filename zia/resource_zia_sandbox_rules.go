@@ -4,57 +4,93 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
-	"sort"
-	"strconv"
-	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/sandbox/sandbox_rules"
 )
 
-var (
-	sandboxLock          sync.Mutex
-	sandboxStartingOrder int
-)
+// sandboxRuleAdapter is the OrderedRuleAdapter of sandbox rules. The Default
+// BA Rule stays at order 127 and is left out of the order.
+type sandboxRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (sandboxRuleAdapter) ResourceType() string { return "sandbox_rules" }
+func (sandboxRuleAdapter) Ranked() bool         { return true }
+
+func (a sandboxRuleAdapter) GetAll(ctx context.Context) ([]sandbox_rules.SandboxRules, error) {
+	list, err := sandbox_rules.GetAll(ctx, a.service)
+	if err != nil {
+		return nil, err
+	}
+	return filterOutDefaultRule(list), nil
+}
+
+func (a sandboxRuleAdapter) Get(ctx context.Context, id int) (*sandbox_rules.SandboxRules, error) {
+	return sandbox_rules.Get(ctx, a.service, id)
+}
+
+func (a sandboxRuleAdapter) Create(ctx context.Context, rule *sandbox_rules.SandboxRules) (int, error) {
+	resp, err := sandbox_rules.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a sandboxRuleAdapter) Update(ctx context.Context, id int, rule *sandbox_rules.SandboxRules) error {
+	_, err := sandbox_rules.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a sandboxRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := sandbox_rules.Delete(ctx, a.service, id)
+	return err
+}
+
+func (sandboxRuleAdapter) Order(rule *sandbox_rules.SandboxRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (sandboxRuleAdapter) SetOrder(rule *sandbox_rules.SandboxRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (sandboxRuleAdapter) StripReadOnly(rule *sandbox_rules.SandboxRules) {
+	// to avoid the STALE_CONFIGURATION_ERROR
+	rule.LastModifiedTime = 0
+	rule.LastModifiedBy = nil
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.DefaultRule = false
+	rule.AccessControl = ""
+}
+
+func (a sandboxRuleAdapter) GetByName(ctx context.Context, name string) (*sandbox_rules.SandboxRules, error) {
+	return sandbox_rules.GetByName(ctx, a.service, name)
+}
+
+func (sandboxRuleAdapter) IsPredefined(rule *sandbox_rules.SandboxRules) bool {
+	return rule.DefaultRule
+}
 
 func resourceSandboxRules() *schema.Resource {
-	return &schema.Resource{
+	return withAdoptPredefined(versionSchema(&schema.Resource{
 		CreateContext: resourceSandboxRulesCreate,
 		ReadContext:   resourceSandboxRulesRead,
 		UpdateContext: resourceSandboxRulesUpdate,
 		DeleteContext: resourceSandboxRulesDelete,
+		CustomizeDiff: validateRuleOrder("zia_sandbox_rules"),
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := sandbox_rules.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", sandbox_rules.GetAll),
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:     schema.TypeString,
@@ -159,139 +195,30 @@ func resourceSandboxRules() *schema.Resource {
 				See the available file types API in:
 				https://automate.zscaler.com/docs/api-reference-and-guides/api-reference/zia/sandbox-policy-settings/ba-rule-resource-add-rule`,
 			},
-			"locations":            setIDsSchemaTypeCustom(nil, "Name-ID pairs of locations for the which policy must be applied. If not set, policy is applied for all locations."),
+			"location_ids":         setIntIDsSchemaType(nil, "IDs of locations for the which policy must be applied. If not set, policy is applied for all locations."),
 			"location_groups":      setIDsSchemaTypeCustom(nil, "Name-ID pairs of locations groups for which rule must be applied."),
 			"departments":          setIDsSchemaTypeCustom(nil, "The Name-ID pairs of departments to which the sandbox rules must be applied."),
-			"groups":               setIDsSchemaTypeCustom(nil, "The Name-ID pairs of groups to which the sandbox rules must be applied."),
+			"group_ids":            setIntIDsSchemaType(nil, "The IDs of groups to which the sandbox rules must be applied."),
 			"users":                setIDsSchemaTypeCustom(nil, "The Name-ID pairs of users to which the sandbox rules must be applied."),
 			"labels":               setIDsSchemaTypeCustom(intPtr(1), "list of Labels that are applicable to the rule."),
 			"zpa_app_segments":     setExtIDNameSchemaCustom(intPtr(255), "List of Source IP Anchoring-enabled ZPA Application Segments for which this rule is applicable"),
 			"ba_policy_categories": getBaPolicyCategories(),
 			"protocols":            getSandboxRuleProtocols(),
 		},
-	}
+	}, ruleIDBlocksV0))
 }
 
 func resourceSandboxRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandSandboxRules(d)
 	log.Printf("[INFO] Creating ZIA sandbox rule\n%+v\n", req)
 
 	// Create timeout for the operation
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-
-	for {
-		sandboxLock.Lock()
-		if sandboxStartingOrder == 0 {
-			list, _ := sandbox_rules.GetAll(ctx, service)
-			for _, r := range list {
-				// Ignore default rule
-				if r.Order == 127 || r.Name == "Default BA Rule" {
-					continue
-				}
-				if r.Order > sandboxStartingOrder {
-					sandboxStartingOrder = r.Order
-				}
-			}
-			if sandboxStartingOrder == 0 {
-				sandboxStartingOrder = 1
-			}
-		}
-		sandboxLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		intendedOrder := req.Order
-		intendedRank := req.Rank
-		if intendedRank < 7 {
-			// always start rank 7 rules at the next available order after all ranked rules
-			req.Rank = 7
-		}
-		req.Order = sandboxStartingOrder
-
-		resp, err := sandbox_rules.Create(ctx, service, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			reg := regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				if reg.MatchString(err.Error()) {
-					return diag.FromErr(fmt.Errorf("error creating resource: %s, please check the order %d vs rank %d, current rules:%s , err:%s", req.Name, intendedOrder, req.Rank, currentSandboxOrderVsRankWording(ctx, zClient), err))
-				}
-				if time.Since(start) < timeout {
-					log.Printf("[INFO] Creating sandbox rule name: %v, got INVALID_INPUT_ARGUMENT\n", req.Name)
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-		}
-
-		log.Printf("[INFO] Created zia sandbox rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-		// Use separate resource type for rank 7 rules to avoid mixing with ranked rules
-		resourceType := "sandbox_rules"
-
-		reorderWithBeforeReorder(
-			OrderRule{Order: intendedOrder, Rank: intendedRank},
-			resp.ID,
-			resourceType,
-			func() (map[int]OrderRule, error) {
-				list, err := sandbox_rules.GetAll(ctx, service)
-				if err != nil {
-					return nil, err
-				}
-				filteredList := filterOutDefaultRule(list)
-				m := make(map[int]OrderRule, len(filteredList))
-				for _, r := range filteredList {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				// Custom updateOrder that handles predefined rules
-				rule, err := sandbox_rules.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-				// to avoid the STALE_CONFIGURATION_ERROR
-				rule.LastModifiedTime = 0
-				rule.LastModifiedBy = nil
-				// Strip read-only fields that cause "Request body is invalid" for predefined rules
-				rule.DefaultRule = false
-				rule.AccessControl = ""
-				rule.Order = order.Order
-				rule.Rank = order.Rank
-				_, err = sandbox_rules.Update(ctx, service, id, rule)
-				return err
-			},
-			nil, // Remove beforeReorder function to avoid adding too many rules to the map
-		)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		markOrderRuleAsDone(resp.ID, resourceType)
-		waitForReorder(resourceType)
-
-		// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-		if shouldActivate() {
-			// Sleep for 2 seconds before potentially triggering the activation
-			time.Sleep(2 * time.Second)
-			if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-				return diag.FromErr(activationErr)
-			}
-		} else {
-			log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-		}
-
-		return resourceSandboxRulesRead(ctx, d, meta)
+	if diags := createOrderedRule(ctx, d, zClient, sandboxRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
+	return resourceSandboxRulesRead(ctx, d, meta)
 }
 
 func resourceSandboxRulesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -302,7 +229,10 @@ func resourceSandboxRulesRead(ctx context.Context, d *schema.ResourceData, meta
 	if !ok {
 		return diag.FromErr(fmt.Errorf("no zia sandbox rules id is set"))
 	}
-	resp, err := sandbox_rules.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_sandbox_rules", id,
+		func() ([]sandbox_rules.SandboxRules, error) { return sandbox_rules.GetAll(ctx, service) },
+		func(r *sandbox_rules.SandboxRules) int { return r.ID },
+		func() (*sandbox_rules.SandboxRules, error) { return sandbox_rules.Get(ctx, service, id) })
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing sandbox rules %s from state because it no longer exists in ZIA", d.Id())
@@ -339,7 +269,7 @@ func resourceSandboxRulesRead(ctx context.Context, d *schema.ResourceData, meta
 	_ = d.Set("protocols", resp.Protocols)
 	_ = d.Set("file_types", resp.FileTypes)
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -347,7 +277,7 @@ func resourceSandboxRulesRead(ctx context.Context, d *schema.ResourceData, meta
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -372,7 +302,6 @@ func resourceSandboxRulesRead(ctx context.Context, d *schema.ResourceData, meta
 
 func resourceSandboxRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -382,84 +311,9 @@ func resourceSandboxRulesUpdate(ctx context.Context, d *schema.ResourceData, met
 	log.Printf("[INFO] Updating sandbox rule ID: %v\n", id)
 	req := expandSandboxRules(d)
 
-	if _, err := sandbox_rules.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-
-	existingRules, err := sandbox_rules.GetAll(ctx, service)
-	if err != nil {
-		log.Printf("[ERROR] error getting all sandbox rules: %v", err)
-	}
-	// Filter out Default BA Rule before sorting
-	existingRules = filterOutDefaultRule(existingRules)
-	sort.Slice(existingRules, func(i, j int) bool {
-		return existingRules[i].Rank < existingRules[j].Rank || (existingRules[i].Rank == existingRules[j].Rank && existingRules[i].Order < existingRules[j].Order)
-	})
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	nextAvailableOrder := existingRules[len(existingRules)-1].Order
-	// always start rank 7 rules at the next available order after all ranked rules
-	req.Rank = 7
-
-	req.Order = nextAvailableOrder
-
-	_, err = sandbox_rules.Update(ctx, service, id, &req)
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-	}
-
-	reorderWithBeforeReorder(OrderRule{Order: intendedOrder, Rank: intendedRank}, req.ID, "sandbox_rules",
-		func() (map[int]OrderRule, error) {
-			list, err := sandbox_rules.GetAll(ctx, service)
-			if err != nil {
-				return nil, err
-			}
-			filteredList := filterOutDefaultRule(list)
-			m := make(map[int]OrderRule, len(filteredList))
-			for _, r := range filteredList {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := sandbox_rules.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			// to avoid the STALE_CONFIGURATION_ERROR
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			// Strip read-only fields that cause "Request body is invalid" for predefined rules
-			rule.DefaultRule = false
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = sandbox_rules.Update(ctx, service, id, rule)
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	markOrderRuleAsDone(req.ID, "sandbox_rules")
-	waitForReorder("sandbox_rules")
-
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := updateOrderedRule(ctx, d, zClient, sandboxRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
 	return resourceSandboxRulesRead(ctx, d, meta)
 }
 
@@ -471,26 +325,8 @@ func resourceSandboxRulesDelete(ctx context.Context, d *schema.ResourceData, met
 	if !ok {
 		log.Printf("[ERROR] sandbox rules not set: %v\n", id)
 	}
-	log.Printf("[INFO] Deleting sandbox rules ID: %v\n", (d.Id()))
-
-	if _, err := sandbox_rules.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] sandbox rules deleted")
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
 
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, sandboxRuleAdapter{service}, id)
 }
 
 func expandSandboxRules(d *schema.ResourceData) sandbox_rules.SandboxRules {
@@ -519,9 +355,9 @@ func expandSandboxRules(d *schema.ResourceData) sandbox_rules.SandboxRules {
 		BaPolicyCategories: SetToStringList(d, "ba_policy_categories"),
 		URLCategories:      SetToStringList(d, "url_categories"),
 		FileTypes:          SetToStringList(d, "file_types"),
-		Locations:          expandIDNameExtensionsSet(d, "locations"),
+		Locations:          expandIDNameExtensionsIDs(d, "location_ids"),
 		LocationGroups:     expandIDNameExtensionsSet(d, "location_groups"),
-		Groups:             expandIDNameExtensionsSet(d, "groups"),
+		Groups:             expandIDNameExtensionsIDs(d, "group_ids"),
 		Departments:        expandIDNameExtensionsSet(d, "departments"),
 		Users:              expandIDNameExtensionsSet(d, "users"),
 		Labels:             expandIDNameExtensionsSet(d, "labels"),
@@ -541,21 +377,3 @@ func filterOutDefaultRule(rules []sandbox_rules.SandboxRules) []sandbox_rules.Sa
 	}
 	return filteredRules
 }
-
-func currentSandboxOrderVsRankWording(ctx context.Context, zClient *Client) string {
-	service := zClient.Service
-
-	list, err := sandbox_rules.GetAll(ctx, service)
-	if err != nil {
-		return ""
-	}
-	result := ""
-	for i, r := range list {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("Rank %d VS Order %d", r.Rank, r.Order)
-
-	}
-	return result
-}
@@ -24,9 +24,9 @@ func TestAccResourceSandboxRules_Basic(t *testing.T) {
 	ruleLabelHCL := testAccCheckRuleLabelsConfigure(ruleLabelTypeAndName, "tf-acc-test-"+ruleLabelGeneratedName, variable.RuleLabelDescription)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckSandboxRulesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckSandboxRulesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckSandboxRulesConfigure(resourceTypeAndName, generatedName, generatedName, variable.SandboxRuleDescription, variable.SandboxState, variable.SandboxAction, ruleLabelTypeAndName, ruleLabelHCL),
@@ -22,7 +22,7 @@ func TestAccZiaSandboxFileSubmission_basic(t *testing.T) {
 
 		resource.Test(t, resource.TestCase{
 			PreCheck:     func() { testAccPreCheck(t) },
-			Providers:    testAccProviders,
+			ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 			CheckDestroy: testAccCheckSandboxSubmissionDestroy,
 			Steps: []resource.TestStep{
 				{
@@ -12,8 +12,8 @@ func TestAccResourceSecurityPolicySettings_basic(t *testing.T) {
 	resourceName := "zia_security_settings.test"
 
 	resource.Test(t, resource.TestCase{
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckSecurityPolicySettingsDestroy,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckSecurityPolicySettingsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccResourceSecurityPolicySettingsConfig([]string{".example.com"}, []string{".blockme.com"}),
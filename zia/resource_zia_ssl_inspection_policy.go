@@ -0,0 +1,20 @@
+package zia
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/sslinspection"
+)
+
+func resourceSSLInspectionPolicy() *schema.Resource {
+	return policySetResource(policySet[sslinspection.SSLInspectionRules]{
+		id:       "ssl_inspection_policy",
+		ruleType: "zia_ssl_inspection_rules",
+		rule:     resourceSSLInspectionRules,
+		adapter: func(service *zscaler.Service) OrderedRuleAdapter[sslinspection.SSLInspectionRules] {
+			return sslInspectionRuleAdapter{service}
+		},
+		expand: expandSSLInspectionRules,
+		name:   func(rule *sslinspection.SSLInspectionRules) string { return rule.Name },
+	})
+}
@@ -4,27 +4,79 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
-	"sort"
-	"strconv"
-	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/sslinspection"
 )
 
-var (
-	sslInspectionLock          sync.Mutex
-	sslInspectionStartingOrder int
-)
+// sslInspectionRuleAdapter is the OrderedRuleAdapter of SSL inspection rules.
+type sslInspectionRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (sslInspectionRuleAdapter) ResourceType() string { return "ssl_inspection_rules" }
+func (sslInspectionRuleAdapter) Ranked() bool         { return true }
+
+func (a sslInspectionRuleAdapter) GetAll(ctx context.Context) ([]sslinspection.SSLInspectionRules, error) {
+	return sslinspection.GetAll(ctx, a.service)
+}
+
+func (a sslInspectionRuleAdapter) Get(ctx context.Context, id int) (*sslinspection.SSLInspectionRules, error) {
+	return sslinspection.Get(ctx, a.service, id)
+}
+
+func (a sslInspectionRuleAdapter) Create(ctx context.Context, rule *sslinspection.SSLInspectionRules) (int, error) {
+	resp, err := sslinspection.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a sslInspectionRuleAdapter) Update(ctx context.Context, id int, rule *sslinspection.SSLInspectionRules) error {
+	_, err := sslinspection.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a sslInspectionRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := sslinspection.Delete(ctx, a.service, id)
+	return err
+}
+
+func (sslInspectionRuleAdapter) Order(rule *sslinspection.SSLInspectionRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (sslInspectionRuleAdapter) SetOrder(rule *sslinspection.SSLInspectionRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (sslInspectionRuleAdapter) StripReadOnly(rule *sslinspection.SSLInspectionRules) {
+	// to avoid the STALE_CONFIGURATION_ERROR
+	rule.LastModifiedTime = 0
+	rule.LastModifiedBy = nil
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.Predefined = false
+	rule.DefaultRule = false
+	rule.AccessControl = ""
+}
+
+func (a sslInspectionRuleAdapter) GetByName(ctx context.Context, name string) (*sslinspection.SSLInspectionRules, error) {
+	return sslinspection.GetByName(ctx, a.service, name)
+}
+
+func (sslInspectionRuleAdapter) IsPredefined(rule *sslinspection.SSLInspectionRules) bool {
+	return rule.Predefined || rule.DefaultRule
+}
 
 func resourceSSLInspectionRules() *schema.Resource {
-	return &schema.Resource{
+	return withAdoptPredefined(versionSchema(&schema.Resource{
 		CreateContext: resourceSSLInspectionRulesCreate,
 		ReadContext:   resourceSSLInspectionRulesRead,
 		UpdateContext: resourceSSLInspectionRulesUpdate,
@@ -33,7 +85,7 @@ func resourceSSLInspectionRules() *schema.Resource {
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		CustomizeDiff: customdiff.All(func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
 			// Extract the action block
 			actionList := d.Get("action").([]interface{})
 			if len(actionList) == 0 {
@@ -139,28 +191,8 @@ func resourceSSLInspectionRules() *schema.Resource {
 			}
 
 			return nil
-		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := sslinspection.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		}, validateRuleOrder("zia_ssl_inspection_rules")),
+		Importer: importByName("rule_id", sslinspection.GetAll),
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:     schema.TypeString,
@@ -347,10 +379,10 @@ func resourceSSLInspectionRules() *schema.Resource {
 					},
 				},
 			},
-			"locations":                    setIDsSchemaTypeCustom(nil, "list of locations for which rule must be applied"),
+			"location_ids":                 setIntIDsSchemaType(nil, "IDs of locations for which rule must be applied"),
 			"location_groups":              setIDsSchemaTypeCustom(nil, "list of locations groups"),
 			"users":                        setIDsSchemaTypeCustom(nil, "list of users for which rule must be applied"),
-			"groups":                       setIDsSchemaTypeCustom(nil, "list of groups for which rule must be applied"),
+			"group_ids":                    setIntIDsSchemaType(nil, "IDs of groups for which rule must be applied"),
 			"departments":                  setIDsSchemaTypeCustom(nil, "list of departments for which rule must be applied"),
 			"time_windows":                 setIDsSchemaTypeCustom(intPtr(2), "The time interval in which the Firewall Filtering policy rule applies"),
 			"labels":                       setIDsSchemaTypeCustom(intPtr(1), "list of Labels that are applicable to the rule."),
@@ -367,110 +399,18 @@ func resourceSSLInspectionRules() *schema.Resource {
 			"device_trust_levels":          getDeviceTrustLevels(),
 			"platforms":                    getSSLInspectionPlatforms(),
 		},
-	}
+	}, ruleIDBlocksV0))
 }
 
 func resourceSSLInspectionRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandSSLInspectionRules(d)
 	log.Printf("[INFO] Creating zia ssl inspection rule\n%+v\n", req)
 
-	start := time.Now()
-
-	sslInspectionLock.Lock()
-	if sslInspectionStartingOrder == 0 {
-		list, _ := sslinspection.GetAll(ctx, service)
-		for _, r := range list {
-			if r.Order > sslInspectionStartingOrder {
-				sslInspectionStartingOrder = r.Order
-			}
-		}
-		if sslInspectionStartingOrder == 0 {
-			sslInspectionStartingOrder = 1
-		}
-	}
-	sslInspectionLock.Unlock()
-	startWithoutLocking := time.Now()
-
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	if intendedRank < 7 {
-		req.Rank = 7
-	}
-	req.Order = sslInspectionStartingOrder
-	resp, err := sslinspection.Create(ctx, service, &req)
-
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-
-	if err != nil {
-		reg := regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
-		if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-			if reg.MatchString(err.Error()) {
-				return diag.FromErr(fmt.Errorf("error creating resource: %s, please check the order %d vs rank %d, current rules:%s , err:%s", req.Name, intendedOrder, req.Rank, currentSSLInspectionOrderVsRankWording(ctx, zClient), err))
-			}
-		}
-		return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-	}
-
-	log.Printf("[INFO] Created zia ssl inspection rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-	resourceType := "ssl_inspection_rules"
-
-	reorderWithBeforeReorder(
-		OrderRule{Order: intendedOrder, Rank: intendedRank},
-		resp.ID,
-		resourceType,
-		func() (map[int]OrderRule, error) {
-			allRules, err := sslinspection.GetAll(ctx, service)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := sslinspection.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			// Strip read-only/server-managed fields so PUTs against
-			// predefined rules don't get rejected with
-			// "Request body is invalid". See CLAUDE.md → Rule-Based
-			// Resources → Stripping Read-Only Fields in updateOrder.
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			rule.Predefined = false
-			rule.DefaultRule = false
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = sslinspection.Update(ctx, service, id, rule)
-			return err
-		},
-		nil,
-	)
-
-	d.SetId(strconv.Itoa(resp.ID))
-	_ = d.Set("rule_id", resp.ID)
-
-	markOrderRuleAsDone(resp.ID, resourceType)
-	waitForReorder(resourceType)
-
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := createOrderedRule(ctx, d, zClient, sslInspectionRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
-
 	return resourceSSLInspectionRulesRead(ctx, d, meta)
 }
 
@@ -482,7 +422,10 @@ func resourceSSLInspectionRulesRead(ctx context.Context, d *schema.ResourceData,
 	if !ok {
 		return diag.FromErr(fmt.Errorf("no zia ssl inspection rule id is set"))
 	}
-	resp, err := sslinspection.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_ssl_inspection_rules", id,
+		func() ([]sslinspection.SSLInspectionRules, error) { return sslinspection.GetAll(ctx, service) },
+		func(r *sslinspection.SSLInspectionRules) int { return r.ID },
+		func() (*sslinspection.SSLInspectionRules, error) { return sslinspection.Get(ctx, service, id) })
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing ssl inspection rule rule %s from state because it no longer exists in ZIA", d.Id())
@@ -521,7 +464,7 @@ func resourceSSLInspectionRulesRead(ctx context.Context, d *schema.ResourceData,
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -533,7 +476,7 @@ func resourceSSLInspectionRulesRead(ctx context.Context, d *schema.ResourceData,
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -576,7 +519,6 @@ func resourceSSLInspectionRulesRead(ctx context.Context, d *schema.ResourceData,
 
 func resourceSSLInspectionRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -586,83 +528,9 @@ func resourceSSLInspectionRulesUpdate(ctx context.Context, d *schema.ResourceDat
 	log.Printf("[INFO] Updating ssl inspection rule ID: %v\n", id)
 	req := expandSSLInspectionRules(d)
 
-	if _, err := sslinspection.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-
-	existingRules, err := sslinspection.GetAll(ctx, service)
-	if err != nil {
-		log.Printf("[ERROR] error getting all ssl inspection rules: %v", err)
-	}
-	sort.Slice(existingRules, func(i, j int) bool {
-		return existingRules[i].Rank < existingRules[j].Rank || (existingRules[i].Rank == existingRules[j].Rank && existingRules[i].Order < existingRules[j].Order)
-	})
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	nextAvailableOrder := existingRules[len(existingRules)-1].Order
-	// always start rank 7 rules at the next available order after all ranked rules
-	req.Rank = 7
-
-	req.Order = nextAvailableOrder
-
-	_, err = sslinspection.Update(ctx, service, id, &req)
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
+	if diags := updateOrderedRule(ctx, d, zClient, sslInspectionRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-	}
-
-	reorderWithBeforeReorder(
-		OrderRule{Order: intendedOrder, Rank: intendedRank},
-		req.ID,
-		"ssl_inspection_rules",
-		func() (map[int]OrderRule, error) {
-			allRules, err := sslinspection.GetAll(ctx, service)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := sslinspection.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			rule.Predefined = false
-			rule.DefaultRule = false
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = sslinspection.Update(ctx, service, id, rule)
-			return err
-		},
-		nil,
-	)
-
-	markOrderRuleAsDone(req.ID, "ssl_inspection_rules")
-	waitForReorder("ssl_inspection_rules")
-
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
 	return resourceSSLInspectionRulesRead(ctx, d, meta)
 }
 
@@ -674,26 +542,8 @@ func resourceSSLInspectionRulesDelete(ctx context.Context, d *schema.ResourceDat
 	if !ok {
 		log.Printf("[ERROR] ssl inspection rule not set: %v\n", id)
 	}
-	log.Printf("[INFO] Deleting ssl inspection rule ID: %v\n", (d.Id()))
-
-	if _, err := sslinspection.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] ssl inspection rule deleted")
 
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, sslInspectionRuleAdapter{service}, id)
 }
 
 func expandSSLInspectionRules(d *schema.ResourceData) sslinspection.SSLInspectionRules {
@@ -721,9 +571,9 @@ func expandSSLInspectionRules(d *schema.ResourceData) sslinspection.SSLInspectio
 		URLCategories:             SetToStringList(d, "url_categories"),
 		DeviceGroups:              expandIDNameExtensionsSet(d, "device_groups"),
 		Devices:                   expandIDNameExtensionsSet(d, "devices"),
-		Locations:                 expandIDNameExtensionsSet(d, "locations"),
+		Locations:                 expandIDNameExtensionsIDs(d, "location_ids"),
 		LocationGroups:            expandIDNameExtensionsSet(d, "location_groups"),
-		Groups:                    expandIDNameExtensionsSet(d, "groups"),
+		Groups:                    expandIDNameExtensionsIDs(d, "group_ids"),
 		Departments:               expandIDNameExtensionsSet(d, "departments"),
 		SourceIPGroups:            expandIDNameExtensionsSet(d, "source_ip_groups"),
 		DestIpGroups:              expandIDNameExtensionsSet(d, "dest_ip_groups"),
@@ -852,21 +702,3 @@ func expandSSLInspectionDoNotDecryptSubActions(m map[string]interface{}) *sslins
 
 	return sub
 }
-
-func currentSSLInspectionOrderVsRankWording(ctx context.Context, zClient *Client) string {
-	service := zClient.Service
-
-	list, err := sslinspection.GetAll(ctx, service)
-	if err != nil {
-		return ""
-	}
-	result := ""
-	for i, r := range list {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("Rank %d VS Order %d", r.Rank, r.Order)
-
-	}
-	return result
-}
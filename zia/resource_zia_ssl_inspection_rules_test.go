@@ -25,9 +25,9 @@ func TestAccResourceSSLInspectionRules_Basic(t *testing.T) {
 	ruleLabelHCL := testAccCheckRuleLabelsConfigure(ruleLabelTypeAndName, "tf-acc-test-"+ruleLabelGeneratedName, variable.RuleLabelDescription)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckSSLInspectionRulesDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckSSLInspectionRulesDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckSSLInspectionRulesConfigure(resourceTypeAndName, generatedName, generatedName, variable.SSLInspectionRuleDescription, variable.SSLInspectionRuleState, ruleLabelTypeAndName, ruleLabelHCL),
@@ -19,20 +19,7 @@ func resourceSubCloud() *schema.Resource {
 		CreateContext: resourceSubCloudCreate,
 		UpdateContext: resourceSubCloudUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("cloud_id", idInt)
-				}
-				diags := resourceSubCloudRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("failed to read subcloud import: %s", diags[0].Summary)
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importByName("cloud_id", sub_clouds.GetAll),
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:        schema.TypeString,
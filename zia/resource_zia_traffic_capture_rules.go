@@ -4,56 +4,89 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
-	"sort"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
-	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/traffic_capture"
 )
 
-var (
-	trafficCaptureLock          sync.Mutex
-	trafficCaptureStartingOrder int
-)
+// trafficCaptureRuleAdapter is the OrderedRuleAdapter of traffic capture
+// rules, which start past the last rule.
+type trafficCaptureRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (trafficCaptureRuleAdapter) ResourceType() string { return "traffic_capture_rules" }
+func (trafficCaptureRuleAdapter) Ranked() bool         { return true }
+func (trafficCaptureRuleAdapter) startAfterLastRule()  {}
+
+func (a trafficCaptureRuleAdapter) GetAll(ctx context.Context) ([]traffic_capture.TrafficCaptureRules, error) {
+	return traffic_capture.GetAll(ctx, a.service, nil)
+}
+
+func (a trafficCaptureRuleAdapter) Get(ctx context.Context, id int) (*traffic_capture.TrafficCaptureRules, error) {
+	return traffic_capture.Get(ctx, a.service, id)
+}
+
+func (a trafficCaptureRuleAdapter) Create(ctx context.Context, rule *traffic_capture.TrafficCaptureRules) (int, error) {
+	resp, err := traffic_capture.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a trafficCaptureRuleAdapter) Update(ctx context.Context, id int, rule *traffic_capture.TrafficCaptureRules) error {
+	_, err := traffic_capture.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a trafficCaptureRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := traffic_capture.Delete(ctx, a.service, id)
+	return err
+}
+
+func (trafficCaptureRuleAdapter) Order(rule *traffic_capture.TrafficCaptureRules) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (trafficCaptureRuleAdapter) SetOrder(rule *traffic_capture.TrafficCaptureRules, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (trafficCaptureRuleAdapter) StripReadOnly(rule *traffic_capture.TrafficCaptureRules) {
+	// Strip read-only fields that cause "Request body is invalid" for predefined rules
+	rule.Predefined = false
+	rule.DefaultRule = false
+	rule.AccessControl = ""
+}
+
+func (a trafficCaptureRuleAdapter) GetByName(ctx context.Context, name string) (*traffic_capture.TrafficCaptureRules, error) {
+	return traffic_capture.GetByName(ctx, a.service, name)
+}
+
+func (trafficCaptureRuleAdapter) IsPredefined(rule *traffic_capture.TrafficCaptureRules) bool {
+	return rule.Predefined || rule.DefaultRule
+}
 
 func resourceTrafficCaptureRules() *schema.Resource {
-	return &schema.Resource{
+	return withAdoptPredefined(versionSchema(&schema.Resource{
 		CreateContext: resourceFiresourceTrafficCaptureRulesCreate,
 		ReadContext:   resourceFiresourceTrafficCaptureRulesRead,
 		UpdateContext: resourceFiresourceTrafficCaptureRulesUpdate,
 		DeleteContext: resourceFiresourceTrafficCaptureRulesDelete,
+		CustomizeDiff: validateRuleOrder("zia_traffic_capture_rules"),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := traffic_capture.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", func(ctx context.Context, service *zscaler.Service) ([]traffic_capture.TrafficCaptureRules, error) {
+			return traffic_capture.GetAll(ctx, service, nil)
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -172,10 +205,10 @@ func resourceTrafficCaptureRules() *schema.Resource {
 					"HUNDRED_PERCENT",
 				}, false),
 			},
-			"locations":             setIDsSchemaTypeCustom(intPtr(8), "list of locations for which rule must be applied"),
+			"location_ids":          setIntIDsSchemaType(intPtr(8), "IDs of locations for which rule must be applied"),
 			"location_groups":       setIDsSchemaTypeCustom(intPtr(32), "list of locations groups"),
 			"users":                 setIDsSchemaTypeCustom(intPtr(4), "list of users for which rule must be applied"),
-			"groups":                setIDsSchemaTypeCustom(intPtr(8), "list of groups for which rule must be applied"),
+			"group_ids":             setIntIDsSchemaType(intPtr(8), "IDs of groups for which rule must be applied"),
 			"departments":           setIDsSchemaTypeCustom(intPtr(140000), "list of departments for which rule must be applied"),
 			"time_windows":          setIDsSchemaTypeCustom(intPtr(2), "The time interval in which the traffic capture rules policy rule applies"),
 			"labels":                setIDsSchemaTypeCustom(intPtr(1), "list of Labels that are applicable to the rule."),
@@ -192,115 +225,18 @@ func resourceTrafficCaptureRules() *schema.Resource {
 			"source_countries":      getISOCountryCodes(),
 			"device_trust_levels":   getDeviceTrustLevels(),
 		},
-	}
+	}, ruleIDBlocksV0))
 }
 
 func resourceFiresourceTrafficCaptureRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandFiresourceTrafficCaptureRules(d)
 	log.Printf("[INFO] Creating zia traffic capture rules rule\n%+v\n", req)
 
-	start := time.Now()
-
-	trafficCaptureLock.Lock()
-	if trafficCaptureStartingOrder == 0 {
-		list, _ := traffic_capture.GetAll(ctx, service, nil)
-		for _, r := range list {
-			if r.Order > trafficCaptureStartingOrder {
-				trafficCaptureStartingOrder = r.Order
-			}
-		}
-		if trafficCaptureStartingOrder == 0 {
-			trafficCaptureStartingOrder = 1
-		} else {
-			trafficCaptureStartingOrder++
-		}
-	}
-	trafficCaptureLock.Unlock()
-	startWithoutLocking := time.Now()
-
-	// Store the intended order from HCL
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	if intendedRank < 7 {
-		// always start rank 7 rules at the next available order after all ranked rules
-		req.Rank = 7
-	}
-	req.Order = trafficCaptureStartingOrder
-	resp, err := traffic_capture.Create(ctx, service, &req)
-
-	// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-
-	if err != nil {
-		reg := regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
-		if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-			if reg.MatchString(err.Error()) {
-				return diag.FromErr(fmt.Errorf("error creating resource: %s, please check the order %d vs rank %d, current rules:%s , err:%s", req.Name, intendedOrder, req.Rank, currentTrafficCaptureOrderVsRankWording(ctx, zClient), err))
-			}
-		}
-		return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
+	if diags := createOrderedRule(ctx, d, zClient, trafficCaptureRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
-
-	log.Printf("[INFO] Created zia traffic capture rules rule request. took:%s, without locking:%s,  ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-	// Use separate resource type for rank 7 rules to avoid mixing with ranked rules
-	resourceType := "firewall_filtering_rules"
-
-	reorderWithBeforeReorder(
-		OrderRule{Order: intendedOrder, Rank: intendedRank},
-		resp.ID,
-		resourceType,
-		func() (map[int]OrderRule, error) {
-			allRules, err := traffic_capture.GetAll(ctx, service, nil)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			// Custom updateOrder that handles predefined rules
-			rule, err := traffic_capture.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-
-			// Strip read-only fields that cause "Request body is invalid" for predefined rules
-			rule.Predefined = false
-			rule.DefaultRule = false
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = traffic_capture.Update(ctx, service, id, rule)
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	d.SetId(strconv.Itoa(resp.ID))
-	_ = d.Set("rule_id", resp.ID)
-
-	markOrderRuleAsDone(resp.ID, resourceType)
-	waitForReorder(resourceType)
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
 	return resourceFiresourceTrafficCaptureRulesRead(ctx, d, meta)
 }
 
@@ -314,7 +250,9 @@ func resourceFiresourceTrafficCaptureRulesRead(ctx context.Context, d *schema.Re
 	}
 
 	// Use GetAll() instead of Get() to reduce API calls during terraform refresh
-	allRules, err := traffic_capture.GetAll(ctx, service, nil)
+	allRules, err := cachedList(zClient, "zia_traffic_capture_rules", func() ([]traffic_capture.TrafficCaptureRules, error) {
+		return traffic_capture.GetAll(ctx, service, nil)
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -366,7 +304,7 @@ func resourceFiresourceTrafficCaptureRulesRead(ctx context.Context, d *schema.Re
 	_ = d.Set("txn_size_limit", resp.TxnSizeLimit)
 	_ = d.Set("txn_sampling", resp.TxnSampling)
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -378,7 +316,7 @@ func resourceFiresourceTrafficCaptureRulesRead(ctx context.Context, d *schema.Re
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -433,7 +371,6 @@ func resourceFiresourceTrafficCaptureRulesRead(ctx context.Context, d *schema.Re
 
 func resourceFiresourceTrafficCaptureRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -443,83 +380,9 @@ func resourceFiresourceTrafficCaptureRulesUpdate(ctx context.Context, d *schema.
 	log.Printf("[INFO] Updating traffic capture rules rule ID: %v\n", id)
 	req := expandFiresourceTrafficCaptureRules(d)
 
-	if _, err := traffic_capture.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-	existingRules, err := traffic_capture.GetAll(ctx, service, nil)
-	if err != nil {
-		log.Printf("[ERROR] error getting all filtering rules: %v", err)
-	}
-	sort.Slice(existingRules, func(i, j int) bool {
-		return existingRules[i].Rank < existingRules[j].Rank || (existingRules[i].Rank == existingRules[j].Rank && existingRules[i].Order < existingRules[j].Order)
-	})
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	nextAvailableOrder := existingRules[len(existingRules)-1].Order
-	// always start rank 7 rules at the next available order after all ranked rules
-	req.Rank = 7
-
-	req.Order = nextAvailableOrder
-
-	_, err = traffic_capture.Update(ctx, service, id, &req)
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
+	if diags := updateOrderedRule(ctx, d, zClient, trafficCaptureRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-	}
-
-	reorderWithBeforeReorder(OrderRule{Order: intendedOrder, Rank: intendedRank}, req.ID, "traffic_capture_rules",
-		func() (map[int]OrderRule, error) {
-			allRules, err := traffic_capture.GetAll(ctx, service, nil)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := traffic_capture.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			// Optional: avoid unnecessary updates if the current order is already correct
-			if rule.Order == order.Order && rule.Rank == order.Rank {
-				return nil
-			}
-
-			// Strip read-only fields that cause "Request body is invalid" for predefined rules
-			rule.Predefined = false
-			rule.DefaultRule = false
-			rule.AccessControl = ""
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = traffic_capture.Update(ctx, service, id, rule)
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	markOrderRuleAsDone(req.ID, "traffic_capture_rules")
-	waitForReorder("traffic_capture_rules")
-
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
-
 	return resourceFiresourceTrafficCaptureRulesRead(ctx, d, meta)
 }
 
@@ -532,36 +395,21 @@ func resourceFiresourceTrafficCaptureRulesDelete(ctx context.Context, d *schema.
 		log.Printf("[ERROR] traffic capture rules rule not set: %v\n", id)
 	}
 
-	// Retrieve the rule to check if it's a predefined one
-	rule, err := traffic_capture.Get(ctx, service, id)
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error retrieving traffic capture rules rule %d: %v", id, err))
-	}
-
-	// Additional check for any predefined rule (backup validation)
-	if rule.Predefined {
-		return diag.FromErr(fmt.Errorf("deletion of predefined rule '%s' is not allowed", rule.Name))
-	}
+	// Adopted predefined rules are restored rather than deleted
+	if !adoptsPredefined(d) {
+		// Retrieve the rule to check if it's a predefined one
+		rule, err := traffic_capture.Get(ctx, service, id)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error retrieving traffic capture rules rule %d: %v", id, err))
+		}
 
-	log.Printf("[INFO] Deleting traffic capture rules rule ID: %v\n", (d.Id()))
-	if _, err := traffic_capture.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(err)
-	}
-	d.SetId("")
-	log.Printf("[INFO] traffic capture rules rule deleted")
-
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
+		// Additional check for any predefined rule (backup validation)
+		if rule.Predefined {
+			return diag.FromErr(fmt.Errorf("deletion of predefined rule '%s' is not allowed", rule.Name))
 		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
 	}
 
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, trafficCaptureRuleAdapter{service}, id)
 }
 
 func expandFiresourceTrafficCaptureRules(d *schema.ResourceData) traffic_capture.TrafficCaptureRules {
@@ -597,10 +445,10 @@ func expandFiresourceTrafficCaptureRules(d *schema.ResourceData) traffic_capture
 		NwApplications:      SetToStringList(d, "nw_applications"),
 		DefaultRule:         d.Get("default_rule").(bool),
 		Predefined:          d.Get("predefined").(bool),
-		Locations:           expandIDNameExtensionsSet(d, "locations"),
+		Locations:           expandIDNameExtensionsIDs(d, "location_ids"),
 		LocationsGroups:     expandIDNameExtensionsSet(d, "location_groups"),
 		Departments:         expandIDNameExtensionsSet(d, "departments"),
-		Groups:              expandIDNameExtensionsSet(d, "groups"),
+		Groups:              expandIDNameExtensionsIDs(d, "group_ids"),
 		Users:               expandIDNameExtensionsSet(d, "users"),
 		TimeWindows:         expandIDNameExtensionsSet(d, "time_windows"),
 		SrcIpGroups:         expandIDNameExtensionsSet(d, "src_ip_groups"),
@@ -616,21 +464,3 @@ func expandFiresourceTrafficCaptureRules(d *schema.ResourceData) traffic_capture
 	}
 	return result
 }
-
-func currentTrafficCaptureOrderVsRankWording(ctx context.Context, zClient *Client) string {
-	service := zClient.Service
-
-	list, err := traffic_capture.GetAll(ctx, service, nil)
-	if err != nil {
-		return ""
-	}
-	result := ""
-	for i, r := range list {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("Rank %d VS Order %d", r.Rank, r.Order)
-
-	}
-	return result
-}
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/trafficforwarding/gretunnels"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/trafficforwarding/virtualipaddress"
@@ -21,27 +21,18 @@ func resourceTrafficForwardingGRETunnel() *schema.Resource {
 		ReadContext:   resourceTrafficForwardingGRETunnelRead,
 		UpdateContext: resourceTrafficForwardingGRETunnelUpdate,
 		DeleteContext: resourceTrafficForwardingGRETunnelDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("tunnel_id", idInt)
-				} else {
-					resp, err := gretunnels.GetByIPAddress(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("tunnel_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByKey("tunnel_id", func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+			tunnels, err := gretunnels.GetAll(ctx, service)
+			if err != nil {
+				return nil, err
+			}
+			// Tunnels have no name; they are imported by source IP.
+			candidates := make([]importCandidate, len(tunnels))
+			for i, tunnel := range tunnels {
+				candidates[i] = importCandidate{id: tunnel.ID, name: tunnel.SourceIP}
+			}
+			return candidates, nil
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"tunnel_id": {
@@ -170,11 +161,8 @@ func resourceTrafficForwardingGRETunnelCreate(ctx context.Context, d *schema.Res
 	d.SetId(strconv.Itoa(resp.ID))
 	_ = d.Set("tunnel_id", resp.ID)
 
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.Errorf("error triggering activation: %v", activationErr)
 		}
@@ -302,9 +290,7 @@ func resourceTrafficForwardingGRETunnelUpdate(ctx context.Context, d *schema.Res
 		return diag.FromErr(err)
 	}
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -331,9 +317,7 @@ func resourceTrafficForwardingGRETunnelDelete(ctx context.Context, d *schema.Res
 	d.SetId("")
 	log.Printf("[INFO] gre tunnel deleted")
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -23,9 +23,9 @@ func TestAccResourceTrafficForwardingGRETunnelBasic(t *testing.T) {
 	randomIP, _ := acctest.RandIpAddress("104.238.235.0/24")
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckTrafficForwardingGRETunnelDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckTrafficForwardingGRETunnelDestroy,
 		Steps: []resource.TestStep{
 			{
 				// create gree tunnel
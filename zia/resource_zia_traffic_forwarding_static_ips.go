@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/trafficforwarding/staticips"
 )
@@ -20,27 +20,17 @@ func resourceTrafficForwardingStaticIP() *schema.Resource {
 		ReadContext:   resourceTrafficForwardingStaticIPRead,
 		UpdateContext: resourceTrafficForwardingStaticIPUpdate,
 		DeleteContext: resourceTrafficForwardingStaticIPDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("static_ip_id", idInt)
-				} else {
-					resp, err := staticips.GetByIPAddress(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("static_ip_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByKey("static_ip_id", func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+			ips, err := staticips.GetAll(ctx, service)
+			if err != nil {
+				return nil, err
+			}
+			candidates := make([]importCandidate, len(ips))
+			for i, ip := range ips {
+				candidates[i] = importCandidate{id: ip.ID, name: ip.IpAddress}
+			}
+			return candidates, nil
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"static_ip_id": {
@@ -122,9 +112,7 @@ func resourceTrafficForwardingStaticIPCreate(ctx context.Context, d *schema.Reso
 	}
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -232,9 +220,7 @@ func resourceTrafficForwardingStaticIPUpdate(ctx context.Context, d *schema.Reso
 		return diag.FromErr(err)
 	}
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -353,9 +339,7 @@ func resourceTrafficForwardingStaticIPDelete(ctx context.Context, d *schema.Reso
 	log.Printf("[INFO] static ip deleted")
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
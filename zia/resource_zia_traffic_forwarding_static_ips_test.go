@@ -26,9 +26,9 @@ func TestAccResourceTrafficForwardingStaticIPBasic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckTrafficForwardingStaticIPDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckTrafficForwardingStaticIPDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckTrafficForwardingStaticIPConfigure(resourceTypeAndName, initialName, rIP, variable.StaticRoutableIP, variable.StaticGeoOverride),
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/trafficforwarding/vpncredentials"
 )
@@ -20,53 +20,22 @@ func resourceTrafficForwardingVPNCredentials() *schema.Resource {
 		ReadContext:   resourceTrafficForwardingVPNCredentialsRead,
 		UpdateContext: resourceTrafficForwardingVPNCredentialsUpdate,
 		DeleteContext: resourceTrafficForwardingVPNCredentialsDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-
-				// First, try to parse the ID as an integer. Parse with the
-				// platform's int size so the conversion to int below can
-				// never truncate.
-				idInt, parseIDErr := strconv.ParseInt(id, 10, strconv.IntSize)
-				if parseIDErr == nil {
-					d.SetId(strconv.Itoa(int(idInt)))
-					_ = d.Set("vpn_id", int(idInt))
-					return []*schema.ResourceData{d}, nil
-				}
-
-				// If the ID is not an integer, try to import by FQDN.
-				vpnCredential, err := vpncredentials.GetByFQDN(ctx, service, id)
-				if err == nil {
-					d.SetId(strconv.Itoa(vpnCredential.ID))
-					_ = d.Set("vpn_id", vpnCredential.ID)
-					return []*schema.ResourceData{d}, nil
-				}
-
-				// If not found by FQDN, try to import by IP.
-				vpnCredential, err = vpncredentials.GetByIP(ctx, service, id)
-				if err == nil {
-					d.SetId(strconv.Itoa(vpnCredential.ID))
-					_ = d.Set("vpn_id", vpnCredential.ID)
-					return []*schema.ResourceData{d}, nil
-				}
-
-				// Finally, try to import by VPN Type.
-				vpnCredentials, err := vpncredentials.GetVPNByType(ctx, service, id, nil, nil, nil)
-				if err == nil && len(vpnCredentials) > 0 {
-					// Assuming we want the first credential in the list.
-					vpnCredential := vpnCredentials[0] // Use the first match or apply additional filtering if needed.
-					d.SetId(strconv.Itoa(vpnCredential.ID))
-					_ = d.Set("vpn_id", vpnCredential.ID)
-					return []*schema.ResourceData{d}, nil
+		Importer: importByKey("vpn_id", func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+			credentials, err := vpncredentials.GetAll(ctx, service)
+			if err != nil {
+				return nil, err
+			}
+			// Credentials have no name; they are imported by FQDN, IP address or,
+			// when it is unique, type.
+			candidates := make([]importCandidate, len(credentials))
+			for i, credential := range credentials {
+				candidates[i] = importCandidate{id: credential.ID, name: credential.FQDN, keys: []string{credential.IPAddress, credential.Type}}
+				if credential.FQDN == "" {
+					candidates[i].name = credential.IPAddress
 				}
-
-				// If all methods fail, return an error indicating the vpn_id could not be found.
-				return nil, fmt.Errorf("unable to find vpn credentials with ID or attributes provided")
-			},
-		},
+			}
+			return candidates, nil
+		}),
 		Schema: map[string]*schema.Schema{
 			"vpn_id": {
 				Type:     schema.TypeInt,
@@ -95,10 +64,27 @@ func resourceTrafficForwardingVPNCredentials() *schema.Resource {
 				ForceNew:     true,
 			},
 			"pre_shared_key": {
-				Type:      schema.TypeString,
-				Optional:  true,
-				Sensitive: true,
-				ForceNew:  true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				ForceNew:      true,
+				ConflictsWith: []string{"pre_shared_key_wo"},
+			},
+			"pre_shared_key_wo": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				WriteOnly:     true,
+				ConflictsWith: []string{"pre_shared_key"},
+				RequiredWith:  []string{"pre_shared_key_wo_version"},
+				Description:   "Write-only pre-shared key. Never stored in the plan or state. Change pre_shared_key_wo_version to apply a new value.",
+			},
+			"pre_shared_key_wo_version": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{"pre_shared_key_wo"},
+				Description:  "Version of pre_shared_key_wo. Changing it replaces the VPN credential with the current pre_shared_key_wo value.",
 			},
 			"comments": {
 				Type:         schema.TypeString,
@@ -129,9 +115,7 @@ func resourceTrafficForwardingVPNCredentialsCreate(ctx context.Context, d *schem
 	_ = d.Set("vpn_id", resp.ID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -221,9 +205,7 @@ func resourceTrafficForwardingVPNCredentialsUpdate(ctx context.Context, d *schem
 		return diag.FromErr(err)
 	}
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -251,9 +233,7 @@ func resourceTrafficForwardingVPNCredentialsDelete(ctx context.Context, d *schem
 	log.Printf("[INFO] vpn credentials deleted")
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -271,7 +251,7 @@ func expandVPNCredentials(d *schema.ResourceData) vpncredentials.VPNCredentials
 		Type:         d.Get("type").(string),
 		FQDN:         d.Get("fqdn").(string),
 		IPAddress:    d.Get("ip_address").(string),
-		PreSharedKey: d.Get("pre_shared_key").(string),
+		PreSharedKey: getSecretFromResourceData(d, "pre_shared_key"),
 		Comments:     d.Get("comments").(string),
 	}
 
@@ -28,9 +28,9 @@ func TestAccResourceTrafficForwardingVPNCredentialsBasic(t *testing.T) {
 	staticIPResourceHCL := testAccCheckTrafficForwardingStaticIPConfigure(staticIPTypeAndName, staticIPGeneratedName, rIP, variable.StaticRoutableIP, variable.StaticGeoOverride)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckTrafficForwardingVPNCredentialsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckTrafficForwardingVPNCredentialsDestroy,
 		Steps: []resource.TestStep{
 			{
 				// creation vpn credential type ufqdn
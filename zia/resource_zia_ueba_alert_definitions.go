@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/security_ueba_alerts/alert_definitions"
 )
@@ -37,27 +37,17 @@ func resourceUEBAAlertDefinitions() *schema.Resource {
 		ReadContext:   resourceUEBAAlertDefinitionsRead,
 		UpdateContext: resourceUEBAAlertDefinitionsUpdate,
 		DeleteContext: resourceUEBAAlertDefinitionsDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("alert_definition_id", idInt)
-				} else {
-					resp, err := alert_definitions.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("alert_definition_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByKey("alert_definition_id", func(ctx context.Context, service *zscaler.Service) ([]importCandidate, error) {
+			definitions, err := alert_definitions.GetAll(ctx, service)
+			if err != nil {
+				return nil, err
+			}
+			candidates := make([]importCandidate, len(definitions))
+			for i, definition := range definitions {
+				candidates[i] = importCandidate{id: definition.ID, name: definition.AlertName}
+			}
+			return candidates, nil
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -134,8 +124,7 @@ func resourceUEBAAlertDefinitionsCreate(ctx context.Context, d *schema.ResourceD
 	d.SetId(strconv.Itoa(resp.ID))
 	_ = d.Set("alert_definition_id", resp.ID)
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -204,8 +193,7 @@ func resourceUEBAAlertDefinitionsUpdate(ctx context.Context, d *schema.ResourceD
 		return diag.FromErr(err)
 	}
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -232,8 +220,7 @@ func resourceUEBAAlertDefinitionsDelete(ctx context.Context, d *schema.ResourceD
 	d.SetId("")
 	log.Printf("[INFO] zia UEBA alert definition deleted")
 
-	if shouldActivate() {
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -21,9 +21,9 @@ func TestAccResourceUEBAAlertDefinitionsBasic(t *testing.T) {
 	resourceTypeAndName, dataSourceTypeAndName, generatedName := method.GenerateRandomSourcesTypeAndName(resourcetype.UEBAAlertDefinitions)
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckUEBAAlertDefinitionsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckUEBAAlertDefinitionsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckUEBAAlertDefinitionsConfigure(resourceTypeAndName, generatedName, variable.UEBAAlertSeverity, variable.UEBAAlertComments),
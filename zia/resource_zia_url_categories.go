@@ -6,7 +6,6 @@ import (
 	"log"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -266,9 +265,7 @@ func resourceURLCategoriesCreate(ctx context.Context, d *schema.ResourceData, me
 	_ = d.Set("category_id", resp.ID)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -396,6 +393,12 @@ func resourceURLCategoriesUpdate(ctx context.Context, d *schema.ResourceData, me
 	// Get desired state from config
 	desiredCategory := expandURLCategory(d)
 
+	// Keep the live URLs when the plan does not change them, such as under
+	// ignore_changes = [urls] while zia_url_category_urls manages the list.
+	if !d.HasChange("urls") {
+		desiredCategory.Urls = currentCategory.Urls
+	}
+
 	// Calculate URL differences
 	currentUrls := stringSliceToMap(currentCategory.Urls)
 	desiredUrls := stringSliceToMap(desiredCategory.Urls)
@@ -473,9 +476,7 @@ func resourceURLCategoriesUpdate(ctx context.Context, d *schema.ResourceData, me
 	}
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
@@ -509,9 +510,7 @@ func resourceURLCategoriesDelete(ctx context.Context, d *schema.ResourceData, me
 	log.Printf("[INFO] custom url category deleted")
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
+	if shouldActivate(zClient) {
 		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
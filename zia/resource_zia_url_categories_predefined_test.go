@@ -14,9 +14,9 @@ func TestAccResourceURLCategoriesPredefinedBasic(t *testing.T) {
 	resourceName := "zia_url_categories_predefined.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckURLCategoriesPredefinedDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckURLCategoriesPredefinedDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccURLCategoriesPredefinedConfig_initial(),
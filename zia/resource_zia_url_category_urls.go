@@ -0,0 +1,344 @@
+package zia
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/urlcategories"
+)
+
+// resourceURLCategoryURLs owns the URL list of a custom URL category. The
+// list is kept out of state: urls_sha256 is the digest of the normalized
+// list, planned from configuration and read from the live category, so a
+// change on either side shows as a diff.
+func resourceURLCategoryURLs() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceURLCategoryURLsCreate,
+		ReadContext:   resourceURLCategoryURLsRead,
+		UpdateContext: resourceURLCategoryURLsUpdate,
+		DeleteContext: resourceURLCategoryURLsDelete,
+		CustomizeDiff: urlCategoryURLsCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"category_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the custom URL category, such as CUSTOM_01",
+			},
+			"urls": {
+				Type:         schema.TypeSet,
+				Optional:     true,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				AtLeastOneOf: []string{"urls", "urls_file"},
+				Description:  "URLs of the category",
+			},
+			"urls_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path of a file listing URLs of the category, one per line. Blank lines and lines starting with # are ignored",
+			},
+			"batch_size": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1000,
+				ValidateFunc: validation.IntBetween(1, 25000),
+				Description:  "Maximum number of URLs added or removed per API call",
+			},
+			"urls_sha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA-256 digest of the normalized URL list",
+			},
+			"url_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of URLs in the category",
+			},
+		},
+	}
+}
+
+// urlCategoryURLsCustomizeDiff plans the digest of the configured URLs, so
+// that a change in urls_file alone is an update.
+func urlCategoryURLsCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("urls") || !d.NewValueKnown("urls_file") {
+		_ = d.SetNewComputed("urls_sha256")
+		_ = d.SetNewComputed("url_count")
+		return nil
+	}
+	urls, err := desiredCategoryURLs(d.Get("urls").(*schema.Set).List(), d.Get("urls_file").(string))
+	if err != nil {
+		return err
+	}
+	if digest := urlListDigest(urls); digest != d.Get("urls_sha256").(string) {
+		if err := d.SetNew("urls_sha256", digest); err != nil {
+			return err
+		}
+		return d.SetNew("url_count", len(urls))
+	}
+	return nil
+}
+
+func resourceURLCategoryURLsCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	id := d.Get("category_id").(string)
+	log.Printf("[INFO] Taking over the URLs of custom url category ID: %v\n", id)
+
+	if _, err := urlcategories.Get(ctx, meta.(*Client).Service, id); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to get url category %s: %w", id, err))
+	}
+	d.SetId(id)
+	diags := applyCategoryURLs(ctx, d, meta)
+	return append(diags, resourceURLCategoryURLsRead(ctx, d, meta)...)
+}
+
+func resourceURLCategoryURLsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zClient := meta.(*Client)
+
+	category, err := urlcategories.Get(ctx, zClient.Service, d.Id())
+	if err != nil {
+		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
+			log.Printf("[WARN] Removing url category urls %s from state because it no longer exists in ZIA", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	live := sortedCategoryURLs(category.Urls)
+	_ = d.Set("category_id", d.Id())
+	_ = d.Set("urls_sha256", urlListDigest(live))
+	_ = d.Set("url_count", len(live))
+	return nil
+}
+
+func resourceURLCategoryURLsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	log.Printf("[INFO] Updating the URLs of custom url category ID: %v\n", d.Id())
+	diags := applyCategoryURLs(ctx, d, meta)
+	return append(diags, resourceURLCategoryURLsRead(ctx, d, meta)...)
+}
+
+// resourceURLCategoryURLsDelete empties the URL list of the category. The
+// category itself is left in place.
+func resourceURLCategoryURLsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zClient := meta.(*Client)
+	service := zClient.Service
+
+	category, err := urlcategories.Get(ctx, service, d.Id())
+	if err != nil {
+		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	log.Printf("[INFO] Removing the %d URLs of custom url category ID: %v\n", len(category.Urls), d.Id())
+
+	update := categoryURLUpdater(ctx, zClient, category)
+	failed := applyURLBatches(update, nil, category.Urls, d.Get("batch_size").(int))
+	if diags := urlBatchDiagnostics(d.Id(), failed); diags.HasError() {
+		return diags
+	}
+	d.SetId("")
+	return activateIfEnabled(ctx, zClient)
+}
+
+// applyCategoryURLs brings the live URL list of the category to the
+// configured one, removing then adding the difference in batches.
+func applyCategoryURLs(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zClient := meta.(*Client)
+	id := d.Get("category_id").(string)
+
+	desired, err := desiredCategoryURLs(d.Get("urls").(*schema.Set).List(), d.Get("urls_file").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	category, err := urlcategories.Get(ctx, zClient.Service, id)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to get url category %s: %w", id, err))
+	}
+
+	add, remove := categoryURLDelta(category.Urls, desired)
+	log.Printf("[INFO] url category %s: adding %d and removing %d of %d URLs in batches of %d", id, len(add), len(remove), len(category.Urls), d.Get("batch_size").(int))
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+
+	failed := applyURLBatches(categoryURLUpdater(ctx, zClient, category), add, remove, d.Get("batch_size").(int))
+	diags := urlBatchDiagnostics(id, failed)
+	if !diags.HasError() {
+		diags = append(diags, activateIfEnabled(ctx, zClient)...)
+	}
+	return diags
+}
+
+// categoryURLUpdater returns a function sending one ADD_TO_LIST or
+// REMOVE_FROM_LIST call for the category. Only the fields identifying the
+// category are sent, so its other lists are left alone.
+func categoryURLUpdater(ctx context.Context, zClient *Client, category *urlcategories.URLCategory) func(action string, urls []string) error {
+	return func(action string, urls []string) error {
+		payload := urlcategories.URLCategory{
+			ID:             category.ID,
+			ConfiguredName: category.ConfiguredName,
+			SuperCategory:  category.SuperCategory,
+			CustomCategory: category.CustomCategory,
+			Type:           category.Type,
+			Urls:           urls,
+		}
+		_, _, err := urlcategories.UpdateURLCategories(ctx, zClient.Service, category.ID, &payload, action)
+		return err
+	}
+}
+
+// urlBatchError is a batch the API rejected.
+type urlBatchError struct {
+	action string
+	batch  int
+	urls   []string
+	err    error
+}
+
+// applyURLBatches removes then adds URLs in batches of size, going on past a
+// rejected batch so that one bad URL does not hold up the rest.
+func applyURLBatches(update func(action string, urls []string) error, add, remove []string, size int) []urlBatchError {
+	var failed []urlBatchError
+	for _, step := range []struct {
+		action string
+		urls   []string
+	}{{"REMOVE_FROM_LIST", remove}, {"ADD_TO_LIST", add}} {
+		for i, batch := range chunkStrings(step.urls, size) {
+			log.Printf("[INFO] %s batch %d: %d URLs", step.action, i+1, len(batch))
+			if err := update(step.action, batch); err != nil {
+				failed = append(failed, urlBatchError{action: step.action, batch: i + 1, urls: batch, err: err})
+			}
+		}
+	}
+	return failed
+}
+
+func urlBatchDiagnostics(id string, failed []urlBatchError) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, f := range failed {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("url category %s: %s batch %d failed", id, f.action, f.batch),
+			Detail:   fmt.Sprintf("%v\nThe batch held %d URLs, from %s to %s. The other batches were applied.", f.err, len(f.urls), f.urls[0], f.urls[len(f.urls)-1]),
+		})
+	}
+	return diags
+}
+
+func chunkStrings(list []string, size int) [][]string {
+	var chunks [][]string
+	for len(list) > size {
+		chunks = append(chunks, list[:size])
+		list = list[size:]
+	}
+	if len(list) > 0 {
+		chunks = append(chunks, list)
+	}
+	return chunks
+}
+
+// desiredCategoryURLs merges the configured URLs with the URLs of file,
+// de-duplicated and sorted by sortedCategoryURLs.
+func desiredCategoryURLs(configured []interface{}, file string) ([]string, error) {
+	urls := make([]string, 0, len(configured))
+	for _, u := range configured {
+		urls = append(urls, u.(string))
+	}
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read urls_file: %w", err)
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				urls = append(urls, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read urls_file: %w", err)
+		}
+	}
+	return sortedCategoryURLs(urls), nil
+}
+
+// sortedCategoryURLs trims urls, drops the entries that normalize like an
+// earlier one and sorts the rest by their normalized form. Entries are kept
+// as written, so that they are sent to the API that way.
+func sortedCategoryURLs(urls []string) []string {
+	entries := make(map[string]string, len(urls))
+	keys := make([]string, 0, len(urls))
+	for _, u := range urls {
+		u = strings.TrimSpace(u)
+		n := normalizeURLCategoryURL(u)
+		if _, seen := entries[n]; n != "" && !seen {
+			entries[n] = u
+			keys = append(keys, n)
+		}
+	}
+	sort.Strings(keys)
+	sorted := make([]string, len(keys))
+	for i, n := range keys {
+		sorted[i] = entries[n]
+	}
+	return sorted
+}
+
+// categoryURLDelta returns the desired URLs the category lacks, and the live
+// URLs, as the API stores them, that are not desired. URLs are matched by
+// their normalized form. A desired URL stored more than once is left alone:
+// REMOVE_FROM_LIST would drop every copy of it.
+func categoryURLDelta(live, desired []string) (add, remove []string) {
+	wanted := make(map[string]bool, len(desired))
+	for _, u := range desired {
+		wanted[normalizeURLCategoryURL(u)] = true
+	}
+	present := make(map[string]bool, len(live))
+	removed := map[string]bool{}
+	for _, u := range live {
+		n := normalizeURLCategoryURL(u)
+		if wanted[n] {
+			present[n] = true
+			continue
+		}
+		if !removed[u] {
+			removed[u] = true
+			remove = append(remove, u)
+		}
+	}
+	for _, u := range desired {
+		if !present[normalizeURLCategoryURL(u)] {
+			add = append(add, u)
+		}
+	}
+	return add, remove
+}
+
+// urlListDigest is the digest of the normalized forms of urls, as sorted by
+// sortedCategoryURLs.
+func urlListDigest(urls []string) string {
+	normalized := make([]string, len(urls))
+	for i, u := range urls {
+		normalized[i] = normalizeURLCategoryURL(u)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(normalized, "\n")))
+	return hex.EncodeToString(sum[:])
+}
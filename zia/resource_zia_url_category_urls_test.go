@@ -0,0 +1,116 @@
+package zia
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestDesiredCategoryURLs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "feed.txt")
+	content := "# threat intel feed\nBad.Example.com/\n\n  https://evil.test/path  \nbad.example.com\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	urls, err := desiredCategoryURLs([]interface{}{"HTTP://Extra.test", "evil.test/path"}, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Bad.Example.com/", "evil.test/path", "HTTP://Extra.test"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("expected %v, got %v", want, urls)
+	}
+	if live := sortedCategoryURLs([]string{"extra.test", "bad.example.com", "evil.test/path"}); urlListDigest(urls) != urlListDigest(live) {
+		t.Errorf("expected %v and %v to have the same digest", urls, live)
+	}
+
+	if _, err := desiredCategoryURLs(nil, filepath.Join(t.TempDir(), "missing.txt")); err == nil || !strings.Contains(err.Error(), "failed to read urls_file") {
+		t.Errorf("expected a missing file to fail, got %v", err)
+	}
+}
+
+func TestCategoryURLDelta(t *testing.T) {
+	live := []string{"Keep.test", "drop.test", ".dup.test", ".dup.test", "drop.test"}
+	desired := sortedCategoryURLs([]string{"keep.test", "HTTPS://New.test/", ".dup.test"})
+
+	add, remove := categoryURLDelta(live, desired)
+	if !reflect.DeepEqual(add, []string{"HTTPS://New.test/"}) {
+		t.Errorf("expected only the new URL added as written, got %v", add)
+	}
+	// Removing the duplicate would remove every copy of a desired URL.
+	if !reflect.DeepEqual(remove, []string{"drop.test"}) {
+		t.Errorf("expected only drop.test removed, got %v", remove)
+	}
+}
+
+func TestApplyURLBatches(t *testing.T) {
+	var calls []string
+	update := func(action string, urls []string) error {
+		calls = append(calls, action+":"+strings.Join(urls, ","))
+		if action == "ADD_TO_LIST" && urls[0] == "c.test" {
+			return errors.New("INVALID_INPUT_ARGUMENT")
+		}
+		return nil
+	}
+
+	failed := applyURLBatches(update, []string{"a.test", "b.test", "c.test", "d.test", "e.test"}, []string{"x.test"}, 2)
+	want := []string{
+		"REMOVE_FROM_LIST:x.test",
+		"ADD_TO_LIST:a.test,b.test",
+		"ADD_TO_LIST:c.test,d.test",
+		"ADD_TO_LIST:e.test",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected removes first then every add batch, got %v", calls)
+	}
+	if len(failed) != 1 || failed[0].batch != 2 || failed[0].action != "ADD_TO_LIST" {
+		t.Fatalf("expected the second add batch reported, got %+v", failed)
+	}
+	diags := urlBatchDiagnostics("CUSTOM_01", failed)
+	if len(diags) != 1 || diags[0].Summary != "url category CUSTOM_01: ADD_TO_LIST batch 2 failed" ||
+		!strings.Contains(diags[0].Detail, "2 URLs, from c.test to d.test") {
+		t.Errorf("unexpected report %+v", diags)
+	}
+}
+
+func TestURLCategoryURLsCustomizeDiff(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "feed.txt")
+	if err := os.WriteFile(file, []byte("a.test\nb.test\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r := resourceURLCategoryURLs()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{"category_id": "CUSTOM_01", "urls_file": file})
+	state := &terraform.InstanceState{ID: "CUSTOM_01", Attributes: map[string]string{
+		"category_id": "CUSTOM_01",
+		"urls_file":   file,
+		"batch_size":  "1000",
+		"urls_sha256": urlListDigest([]string{"a.test", "b.test"}),
+		"url_count":   "2",
+	}}
+
+	diff, err := r.Diff(context.Background(), state, config, &Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && !diff.Empty() {
+		t.Fatalf("expected no diff for an unchanged file, got %v", diff.Attributes)
+	}
+
+	if err := os.WriteFile(file, []byte("a.test\nb.test\nc.test\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = r.Diff(context.Background(), state, config, &Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff == nil || diff.Attributes["url_count"] == nil || diff.Attributes["url_count"].New != "3" {
+		t.Errorf("expected a change in the file to plan an update, got %v", diff)
+	}
+}
@@ -17,16 +17,7 @@ func resourceURLFilteringCloludAppSettings() *schema.Resource {
 		CreateContext: resourceURLFilteringCloludAppSettingsCreate,
 		UpdateContext: resourceURLFilteringCloludAppSettingsUpdate,
 		DeleteContext: resourceFuncNoOp,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				diags := resourceURLFilteringCloludAppSettingsRead(ctx, d, meta)
-				if diags.HasError() {
-					return nil, fmt.Errorf("failed to read url filtering and cloud app settings import: %s", diags[0].Summary)
-				}
-				d.SetId("app_setting")
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer:      importSingleton("app_setting", resourceURLFilteringCloludAppSettingsRead),
 		Schema: map[string]*schema.Schema{
 			"enable_dynamic_content_cat": {
 				Type:        schema.TypeBool,
@@ -196,8 +187,8 @@ func resourceURLFilteringCloludAppSettingsCreate(ctx context.Context, d *schema.
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -272,8 +263,8 @@ func resourceURLFilteringCloludAppSettingsUpdate(ctx context.Context, d *schema.
 	time.Sleep(1 * time.Second)
 
 	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -12,9 +12,9 @@ func TestAccResourceURLFilteringCloudAppSettings_Basic(t *testing.T) {
 	resourceName := "zia_url_filtering_and_cloud_app_settings.test"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckResourceURLFilteringCloludAppSettingsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckResourceURLFilteringCloludAppSettingsDestroy,
 		Steps: []resource.TestStep{
 			// Step 1: Create the resource with specific values
 			{
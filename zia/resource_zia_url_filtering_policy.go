@@ -0,0 +1,20 @@
+package zia
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/urlfilteringpolicies"
+)
+
+func resourceURLFilteringPolicy() *schema.Resource {
+	return policySetResource(policySet[urlfilteringpolicies.URLFilteringRule]{
+		id:       "url_filtering_policy",
+		ruleType: "zia_url_filtering_rules",
+		rule:     resourceURLFilteringRules,
+		adapter: func(service *zscaler.Service) OrderedRuleAdapter[urlfilteringpolicies.URLFilteringRule] {
+			return urlFilteringRuleAdapter{service}
+		},
+		expand: expandURLFilteringRules,
+		name:   func(rule *urlfilteringpolicies.URLFilteringRule) string { return rule.Name },
+	})
+}
@@ -5,33 +5,74 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"regexp"
-	"sort"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/common"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/urlfilteringpolicies"
 )
 
-var (
-	urlFilteringLock          sync.Mutex
-	urlFilteringStartingOrder int
-)
+// urlFilteringRuleAdapter is the OrderedRuleAdapter of URL filtering rules.
+type urlFilteringRuleAdapter struct {
+	service *zscaler.Service
+}
+
+func (urlFilteringRuleAdapter) ResourceType() string { return "url_filtering_rules" }
+func (urlFilteringRuleAdapter) Ranked() bool         { return true }
+
+func (a urlFilteringRuleAdapter) GetAll(ctx context.Context) ([]urlfilteringpolicies.URLFilteringRule, error) {
+	return urlfilteringpolicies.GetAll(ctx, a.service)
+}
+
+func (a urlFilteringRuleAdapter) Get(ctx context.Context, id int) (*urlfilteringpolicies.URLFilteringRule, error) {
+	return urlfilteringpolicies.Get(ctx, a.service, id)
+}
+
+func (a urlFilteringRuleAdapter) Create(ctx context.Context, rule *urlfilteringpolicies.URLFilteringRule) (int, error) {
+	resp, err := urlfilteringpolicies.Create(ctx, a.service, rule)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+func (a urlFilteringRuleAdapter) Update(ctx context.Context, id int, rule *urlfilteringpolicies.URLFilteringRule) error {
+	_, err := urlfilteringpolicies.Update(ctx, a.service, id, rule)
+	return err
+}
+
+func (a urlFilteringRuleAdapter) Delete(ctx context.Context, id int) error {
+	_, err := urlfilteringpolicies.Delete(ctx, a.service, id)
+	return err
+}
+
+func (urlFilteringRuleAdapter) Order(rule *urlfilteringpolicies.URLFilteringRule) (int, OrderRule) {
+	return rule.ID, OrderRule{Order: rule.Order, Rank: rule.Rank}
+}
+
+func (urlFilteringRuleAdapter) SetOrder(rule *urlfilteringpolicies.URLFilteringRule, order OrderRule) {
+	rule.Order, rule.Rank = order.Order, order.Rank
+}
+
+func (urlFilteringRuleAdapter) StripReadOnly(rule *urlfilteringpolicies.URLFilteringRule) {
+	// to avoid the STALE_CONFIGURATION_ERROR
+	rule.LastModifiedTime = 0
+	rule.LastModifiedBy = nil
+}
 
 func resourceURLFilteringRules() *schema.Resource {
-	return &schema.Resource{
+	return versionSchema(&schema.Resource{
 		CreateContext: resourceURLFilteringRulesCreate,
 		ReadContext:   resourceURLFilteringRulesRead,
 		UpdateContext: resourceURLFilteringRulesUpdate,
 		DeleteContext: resourceURLFilteringRulesDelete,
-		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		CustomizeDiff: customdiff.All(func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
 			action := d.Get("action").(string)
 
 			// Common validation for actions other than BLOCK
@@ -149,32 +190,12 @@ func resourceURLFilteringRules() *schema.Resource {
 				}
 			}
 			return nil
-		},
+		}, validateRuleOrder("zia_url_filtering_rules")),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
 			Update: schema.DefaultTimeout(60 * time.Minute),
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("rule_id", idInt)
-				} else {
-					resp, err := urlfilteringpolicies.GetByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("rule_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("rule_id", urlfilteringpolicies.GetAll),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -319,8 +340,8 @@ func resourceURLFilteringRules() *schema.Resource {
 				See the URL Categories API for the list of available categories:
 				https://help.zscaler.com/zia/url-categories#/urlCategories-get`,
 			},
-			"locations":                   setIDsSchemaTypeCustom(nil, "Name-ID pairs of locations for which rule must be applied"),
-			"groups":                      setIDsSchemaTypeCustom(nil, "Name-ID pairs of groups for which rule must be applied"),
+			"location_ids":                setIntIDsSchemaType(nil, "IDs of locations for which rule must be applied"),
+			"group_ids":                   setIntIDsSchemaType(nil, "IDs of groups for which rule must be applied"),
 			"departments":                 setIDsSchemaTypeCustom(nil, "Name-ID pairs of departments for which rule must be applied"),
 			"users":                       setIDsSchemaTypeCustom(nil, "Name-ID pairs of users for which rule must be applied"),
 			"time_windows":                setIDsSchemaTypeCustom(intPtr(2), "Name-ID pairs of time interval during which rule must be enforced."),
@@ -341,12 +362,11 @@ func resourceURLFilteringRules() *schema.Resource {
 			"user_agent_types":            getUserAgentTypes(),
 			"source_countries":            getISOCountryCodes(),
 		},
-	}
+	}, ruleIDBlocksV0)
 }
 
 func resourceURLFilteringRulesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	req := expandURLFilteringRules(d)
 	log.Printf("[INFO] Creating url filtering rule\n%+v\n", req)
@@ -356,110 +376,10 @@ func resourceURLFilteringRulesCreate(ctx context.Context, d *schema.ResourceData
 		return diag.FromErr(err)
 	}
 
-	timeout := d.Timeout(schema.TimeoutCreate)
-	start := time.Now()
-
-	for {
-		urlFilteringLock.Lock()
-		if urlFilteringStartingOrder == 0 {
-			list, _ := urlfilteringpolicies.GetAll(ctx, service)
-			for _, r := range list {
-				if r.Order > urlFilteringStartingOrder {
-					urlFilteringStartingOrder = r.Order
-				}
-			}
-			if urlFilteringStartingOrder == 0 {
-				urlFilteringStartingOrder = 1
-			}
-		}
-		urlFilteringLock.Unlock()
-		startWithoutLocking := time.Now()
-
-		intendedOrder := req.Order
-		intendedRank := req.Rank
-		if intendedRank < 7 {
-			// always start rank 7 rules at the next available order after all ranked rules
-			req.Rank = 7
-		}
-		req.Order = urlFilteringStartingOrder
-		resp, err := urlfilteringpolicies.Create(ctx, service, &req)
-
-		// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-		if customErr := failFastOnErrorCodes(err); customErr != nil {
-			return diag.Errorf("%v", customErr)
-		}
-
-		if err != nil {
-			reg := regexp.MustCompile("Rule with rank [0-9]+ is not allowed at order [0-9]+")
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") && reg.MatchString(err.Error()) {
-				return diag.FromErr(fmt.Errorf("error creating resource: %s, please check the order %d vs rank %d, current rules:%s , err:%s", req.Name, intendedOrder, req.Rank, currentOrderVsRankWording(ctx, zClient), err))
-			}
-
-			if strings.Contains(err.Error(), "INVALID_INPUT_ARGUMENT") {
-				log.Printf("[INFO] Creating url filtering rule name: %v, got INVALID_INPUT_ARGUMENT\n", req.Name)
-				if time.Since(start) < timeout {
-					time.Sleep(10 * time.Second) // Wait before retrying
-					continue
-				}
-			}
-			return diag.FromErr(fmt.Errorf("error creating resource: %s", err))
-		}
-
-		log.Printf("[INFO] Created url filtering rule request. Took: %s, without locking: %s, ID: %v\n", time.Since(start), time.Since(startWithoutLocking), resp)
-		// Use separate resource type for rank 7 rules to avoid mixing with ranked rules
-		resourceType := "url_filtering_rules"
-
-		reorderWithBeforeReorder(
-			OrderRule{Order: intendedOrder, Rank: intendedRank},
-			resp.ID,
-			resourceType,
-			func() (map[int]OrderRule, error) {
-				allRules, err := urlfilteringpolicies.GetAll(ctx, service)
-				if err != nil {
-					return nil, err
-				}
-				m := make(map[int]OrderRule, len(allRules))
-				for _, r := range allRules {
-					m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-				}
-				return m, nil
-			},
-			func(id int, order OrderRule) error {
-				// Custom updateOrder that handles predefined rules
-				rule, err := urlfilteringpolicies.Get(ctx, service, id)
-				if err != nil {
-					return err
-				}
-				// to avoid the STALE_CONFIGURATION_ERROR
-				rule.LastModifiedTime = 0
-				rule.LastModifiedBy = nil
-				rule.Order = order.Order
-				rule.Rank = order.Rank
-				_, err = urlfilteringpolicies.Update(ctx, service, id, rule)
-				return err
-			},
-			nil, // Remove beforeReorder function to avoid adding too many rules to the map
-		)
-
-		d.SetId(strconv.Itoa(resp.ID))
-		_ = d.Set("rule_id", resp.ID)
-
-		markOrderRuleAsDone(resp.ID, resourceType)
-		waitForReorder(resourceType)
-
-		// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-		if shouldActivate() {
-			// Sleep for 2 seconds before potentially triggering the activation
-			time.Sleep(2 * time.Second)
-			if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-				return diag.FromErr(activationErr)
-			}
-		} else {
-			log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-		}
-
-		return resourceURLFilteringRulesRead(ctx, d, meta)
+	if diags := createOrderedRule(ctx, d, zClient, urlFilteringRuleAdapter{zClient.Service}, &req, "rule_id"); diags.HasError() {
+		return diags
 	}
+	return resourceURLFilteringRulesRead(ctx, d, meta)
 }
 
 func resourceURLFilteringRulesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -470,7 +390,14 @@ func resourceURLFilteringRulesRead(ctx context.Context, d *schema.ResourceData,
 	if !ok {
 		return diag.FromErr(fmt.Errorf("no url filtering rule id is set"))
 	}
-	resp, err := urlfilteringpolicies.Get(ctx, service, id)
+	resp, err := cachedRead(zClient, "zia_url_filtering_rules", id,
+		func() ([]urlfilteringpolicies.URLFilteringRule, error) {
+			return urlfilteringpolicies.GetAll(ctx, service)
+		},
+		func(r *urlfilteringpolicies.URLFilteringRule) int { return r.ID },
+		func() (*urlfilteringpolicies.URLFilteringRule, error) {
+			return urlfilteringpolicies.Get(ctx, service, id)
+		})
 	if err != nil {
 		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
 			log.Printf("[WARN] Removing zia url filtering rule %s from state because it no longer exists in ZIA", d.Id())
@@ -543,11 +470,11 @@ func resourceURLFilteringRulesRead(ctx context.Context, d *schema.ResourceData,
 		}
 	}
 
-	if err := d.Set("locations", flattenIDExtensionsListIDs(resp.Locations)); err != nil {
+	if err := d.Set("location_ids", flattenIDExtensionsIDs(resp.Locations)); err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := d.Set("groups", flattenIDExtensionsListIDs(resp.Groups)); err != nil {
+	if err := d.Set("group_ids", flattenIDExtensionsIDs(resp.Groups)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -609,7 +536,6 @@ func resourceURLFilteringRulesRead(ctx context.Context, d *schema.ResourceData,
 
 func resourceURLFilteringRulesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	zClient := meta.(*Client)
-	service := zClient.Service
 
 	id, ok := getIntFromResourceData(d, "rule_id")
 	if !ok {
@@ -624,81 +550,9 @@ func resourceURLFilteringRulesUpdate(ctx context.Context, d *schema.ResourceData
 		return diag.FromErr(err)
 	}
 
-	if _, err := urlfilteringpolicies.Get(ctx, service, id); err != nil {
-		if respErr, ok := err.(*errorx.ErrorResponse); ok && respErr.IsObjectNotFound() {
-			d.SetId("")
-			return nil
-		}
-	}
-
-	existingRules, err := urlfilteringpolicies.GetAll(ctx, service)
-	if err != nil {
-		log.Printf("[ERROR] error getting all url filtering rules: %v", err)
-	}
-	sort.Slice(existingRules, func(i, j int) bool {
-		return existingRules[i].Rank < existingRules[j].Rank || (existingRules[i].Rank == existingRules[j].Rank && existingRules[i].Order < existingRules[j].Order)
-	})
-	intendedOrder := req.Order
-	intendedRank := req.Rank
-	nextAvailableOrder := existingRules[len(existingRules)-1].Order
-	// always start rank 7 rules at the next available order after all ranked rules
-	req.Rank = 7
-
-	req.Order = nextAvailableOrder
-
-	_, err = urlfilteringpolicies.Update(ctx, service, id, &req)
-
-	// Fail immediately if INVALID_INPUT_ARGUMENT is detected
-	if customErr := failFastOnErrorCodes(err); customErr != nil {
-		return diag.Errorf("%v", customErr)
-	}
-
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error updating resource: %s", err))
-	}
-
-	reorderWithBeforeReorder(OrderRule{Order: intendedOrder, Rank: intendedRank}, req.ID, "url_filtering_rules",
-		func() (map[int]OrderRule, error) {
-			allRules, err := urlfilteringpolicies.GetAll(ctx, service)
-			if err != nil {
-				return nil, err
-			}
-			m := make(map[int]OrderRule, len(allRules))
-			for _, r := range allRules {
-				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
-			}
-			return m, nil
-		},
-		func(id int, order OrderRule) error {
-			rule, err := urlfilteringpolicies.Get(ctx, service, id)
-			if err != nil {
-				return err
-			}
-			// to avoid the STALE_CONFIGURATION_ERROR
-			rule.LastModifiedTime = 0
-			rule.LastModifiedBy = nil
-			rule.Order = order.Order
-			rule.Rank = order.Rank
-			_, err = urlfilteringpolicies.Update(ctx, service, id, rule)
-			return err
-		},
-		nil, // Remove beforeReorder function to avoid adding too many rules to the map
-	)
-
-	markOrderRuleAsDone(req.ID, "url_filtering_rules")
-	waitForReorder("url_filtering_rules")
-
-	// Sleep for 2 seconds before potentially triggering the activation
-	time.Sleep(2 * time.Second)
-
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
+	if diags := updateOrderedRule(ctx, d, zClient, urlFilteringRuleAdapter{zClient.Service}, id, &req); diags.HasError() || d.Id() == "" {
+		return diags
 	}
-
 	return resourceURLFilteringRulesRead(ctx, d, meta)
 }
 
@@ -710,26 +564,8 @@ func resourceURLFilteringRulesDelete(ctx context.Context, d *schema.ResourceData
 	if !ok {
 		log.Printf("[ERROR] url filtering rule not set: %v\n", id)
 	}
-	log.Printf("[INFO] Deleting url filtering rule ID: %v\n", (d.Id()))
-
-	if _, err := urlfilteringpolicies.Delete(ctx, service, id); err != nil {
-		return diag.FromErr(err)
-	}
-
-	d.SetId("")
-	log.Printf("[INFO] url filtering rule deleted")
-	// Check if ZIA_ACTIVATION is set to a truthy value before triggering activation
-	if shouldActivate() {
-		// Sleep for 2 seconds before potentially triggering the activation
-		time.Sleep(2 * time.Second)
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
-			return diag.FromErr(activationErr)
-		}
-	} else {
-		log.Printf("[INFO] Skipping configuration activation due to ZIA_ACTIVATION env var not being set to true.")
-	}
 
-	return nil
+	return deleteOrderedRule(ctx, d, zClient, urlFilteringRuleAdapter{service}, id)
 }
 
 func expandURLFilteringRules(d *schema.ResourceData) urlfilteringpolicies.URLFilteringRule {
@@ -808,8 +644,8 @@ func expandURLFilteringRules(d *schema.ResourceData) urlfilteringpolicies.URLFil
 		EnforceTimeValidity:      d.Get("enforce_time_validity").(bool),
 		Action:                   d.Get("action").(string),
 		Ciparule:                 d.Get("ciparule").(bool),
-		Locations:                expandIDNameExtensionsSet(d, "locations"),
-		Groups:                   expandIDNameExtensionsSet(d, "groups"),
+		Locations:                expandIDNameExtensionsIDs(d, "location_ids"),
+		Groups:                   expandIDNameExtensionsIDs(d, "group_ids"),
 		Departments:              expandIDNameExtensionsSet(d, "departments"),
 		Users:                    expandIDNameExtensionsSet(d, "users"),
 		TimeWindows:              expandIDNameExtensionsSet(d, "time_windows"),
@@ -869,21 +705,3 @@ func flattenCBIProfileSimple(cbiProfile *common.CBIProfile) []interface{} {
 		},
 	}
 }
-
-func currentOrderVsRankWording(ctx context.Context, zClient *Client) string {
-	service := zClient.Service
-
-	list, err := urlfilteringpolicies.GetAll(ctx, service)
-	if err != nil {
-		return ""
-	}
-	result := ""
-	for i, r := range list {
-		if i > 0 {
-			result += ", "
-		}
-		result += fmt.Sprintf("Rank %d VS Order %d", r.Rank, r.Order)
-
-	}
-	return result
-}
@@ -23,27 +23,9 @@ func resourceUserManagement() *schema.Resource {
 		ReadContext:   resourceUserManagementRead,
 		UpdateContext: resourceUserManagementUpdate,
 		DeleteContext: resourceUserManagementDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				zClient := meta.(*Client)
-				service := zClient.Service
-
-				id := d.Id()
-				idInt, parseIDErr := strconv.ParseInt(id, 10, 64)
-				if parseIDErr == nil {
-					_ = d.Set("user_id", idInt)
-				} else {
-					resp, err := users.GetUserByName(ctx, service, id)
-					if err == nil {
-						d.SetId(strconv.Itoa(resp.ID))
-						_ = d.Set("user_id", resp.ID)
-					} else {
-						return []*schema.ResourceData{d}, err
-					}
-				}
-				return []*schema.ResourceData{d}, nil
-			},
-		},
+		Importer: importByName("user_id", func(ctx context.Context, service *zscaler.Service) ([]users.Users, error) {
+			return users.GetAllUsers(ctx, service, nil)
+		}),
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -89,10 +71,26 @@ func resourceUserManagement() *schema.Resource {
 				},
 			},
 			"password": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Sensitive:   true,
-				Description: "User's password. Applicable only when authentication type is Hosted DB. Password strength must follow what is defined in the auth settings.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"password", "password_wo"},
+				Description:  "User's password. Applicable only when authentication type is Hosted DB. Password strength must follow what is defined in the auth settings.",
+			},
+			"password_wo": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				WriteOnly:    true,
+				ExactlyOneOf: []string{"password", "password_wo"},
+				RequiredWith: []string{"password_wo_version"},
+				Description:  "Write-only variant of password. Never stored in the plan or state. Change password_wo_version to apply a new value.",
+			},
+			"password_wo_version": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				RequiredWith: []string{"password_wo"},
+				Description:  "Version of password_wo. Changing it sends the current password_wo value to ZIA and re-enrolls the user.",
 			},
 			"groups": setIDsSchemaTypeCustom(nil, "List of Groups a user belongs to. Groups are used in policies."),
 			"department": {
@@ -150,8 +148,8 @@ func resourceUserManagementCreate(ctx context.Context, d *schema.ResourceData, m
 	time.Sleep(5 * time.Second)
 
 	// Trigger activation after creating the rule label
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -175,8 +173,8 @@ func resourceUserManagementCreate(ctx context.Context, d *schema.ResourceData, m
 	time.Sleep(5 * time.Second)
 
 	// Trigger activation after creating the rule label
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -296,8 +294,8 @@ func resourceUserManagementUpdate(ctx context.Context, d *schema.ResourceData, m
 	time.Sleep(5 * time.Second)
 
 	// Trigger activation after creating the rule label
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -305,7 +303,7 @@ func resourceUserManagementUpdate(ctx context.Context, d *schema.ResourceData, m
 	}
 
 	authMethods := SetToStringList(d, "auth_methods")
-	if (d.HasChange("password") || d.HasChange("auth_methods")) && len(authMethods) > 0 {
+	if (d.HasChanges("password", "password_wo_version") || d.HasChange("auth_methods")) && len(authMethods) > 0 {
 		_, err := users.EnrollUser(ctx, service, id, users.EnrollUserRequest{
 			AuthMethods: authMethods,
 			Password:    req.Password,
@@ -319,8 +317,8 @@ func resourceUserManagementUpdate(ctx context.Context, d *schema.ResourceData, m
 	time.Sleep(5 * time.Second)
 
 	// Trigger activation after creating the rule label
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -366,8 +364,8 @@ func resourceUserManagementDelete(ctx context.Context, d *schema.ResourceData, m
 	time.Sleep(5 * time.Second)
 
 	// Trigger activation after creating the rule label
-	if shouldActivate() {
-		if activationErr := triggerActivation(ctx, zClient); activationErr != nil {
+	if shouldActivate(zClient) {
+		if activationErr := triggerActivationNow(ctx, zClient); activationErr != nil {
 			return diag.FromErr(activationErr)
 		}
 	} else {
@@ -385,7 +383,7 @@ func expandUsers(d *schema.ResourceData) users.Users {
 		Email:         d.Get("email").(string),
 		Comments:      d.Get("comments").(string),
 		TempAuthEmail: d.Get("temp_auth_email").(string),
-		Password:      d.Get("password").(string),
+		Password:      getSecretFromResourceData(d, "password"),
 		Groups:        expandUserGroups(d, "groups"),
 	}
 
@@ -28,7 +28,7 @@ func TestAccResourceUserManagementBasic(t *testing.T) {
 	name := "tf-acc-test " + generatedName
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckUserManagementDestroy,
 		Steps: []resource.TestStep{
 			{
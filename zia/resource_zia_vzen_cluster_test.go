@@ -24,9 +24,9 @@ func TestAccResourceVZENCluster_Basic(t *testing.T) {
 	updatedName := "tf-updated-" + generatedName
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckVZENClusterDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckVZENClusterDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckVZENClusterConfigure(
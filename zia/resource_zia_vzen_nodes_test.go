@@ -10,7 +10,7 @@ func TestAccResourceVZENNodeBasic(t *testing.T) {
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
 		CheckDestroy: testAccCheckVZENNodeDestroy,
 		Steps: []resource.TestStep{
 			{
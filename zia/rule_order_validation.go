@@ -0,0 +1,550 @@
+package zia
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/bandwidth_control/bandwidth_control_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/cloudappcontrol"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/dlp/dlp_web_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/endpoint_dlp/endpoint_dlp_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/endpoint_dlp/outbound_email_dlp"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/filetypecontrol"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewalldnscontrolpolicies"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/firewallpolicies/filteringrules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/forwarding_control_policy/forwarding_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/ips_control_policies/ips_policies"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/nat_control_policies"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/saas_security_api/casb_dlp_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/saas_security_api/casb_malware_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/sandbox/sandbox_rules"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/sslinspection"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/traffic_capture"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/urlfilteringpolicies"
+)
+
+// The ordering engine in common.go places rules at apply time, after their
+// writes, and the API rejects a rank that decreases with order only once a
+// rule is created. validateRuleOrder runs the same checks at plan time: the
+// CustomizeDiff of every ordered rule registers its declared order and rank
+// in the client's plan registry and fails the plan when the declarations of
+// a policy can never be satisfied together:
+//
+//   - two rules declare the same order;
+//   - a rule with a lower rank number is declared below one with a higher
+//     rank number (the API requires rank to be non-decreasing with order);
+//   - the orders below a rule leave more positions open than there are
+//     rules not managed by Terraform to fill them.
+//
+// CustomizeDiff only sees one resource at a time. A rule that is new, or
+// whose order or rank changes, waits until no rule of its policy has
+// registered for ruleOrderSettleInterval before passing, so every such rule
+// involved in a conflict reports it. Unchanged rules do not wait, which
+// keeps plans without order changes fast; a conflict between a changed and
+// an unchanged rule is reported on the changed one. A gap may still be
+// filled by a rule Terraform has not planned yet, so a rule that sees one
+// waits for the policy to settle before failing. The SDK does not expose
+// resource addresses to CustomizeDiff, so rules are identified by their
+// resource type and name.
+
+// ruleOrderSettleInterval is how long a policy's plan registry must be quiet
+// before a gap is reported or a changed rule passes. Tests override it.
+var ruleOrderSettleInterval = 2 * time.Second
+
+// ruleOrderPolicy describes how the rules of one resource type are ordered.
+type ruleOrderPolicy struct {
+	// scopeKey is the attribute that splits the rules of the type into
+	// separately ordered policies: the rule type for CASB and Cloud App
+	// Control rules, the parent rule for DLP sub-rules. Empty when the
+	// tenant has a single policy of the type.
+	scopeKey string
+	// ranked is false for rule types without an admin rank.
+	ranked bool
+	// list returns the order and rank of every rule of the policy, managed
+	// or not. It feeds the gap check.
+	list func(ctx context.Context, service *zscaler.Service, scope string) (map[int]OrderRule, error)
+}
+
+// ruleOrderPolicies are the ordered rule resources, keyed by resource type.
+var ruleOrderPolicies = map[string]ruleOrderPolicy{
+	"zia_bandwidth_control_rule": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := bandwidth_control_rules.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range filterOutBandwidthDefaultRule(list) {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_casb_dlp_rules": {scopeKey: "type", ranked: true, list: func(ctx context.Context, service *zscaler.Service, ruleType string) (map[int]OrderRule, error) {
+		list, err := casb_dlp_rules.GetByRuleType(ctx, service, ruleType)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_casb_malware_rules": {scopeKey: "type", list: func(ctx context.Context, service *zscaler.Service, ruleType string) (map[int]OrderRule, error) {
+		list, err := casb_malware_rules.GetByRuleType(ctx, service, ruleType)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order}
+		}
+		return m, nil
+	}},
+	"zia_cloud_app_control_rule": {scopeKey: "type", ranked: true, list: func(ctx context.Context, service *zscaler.Service, ruleType string) (map[int]OrderRule, error) {
+		list, err := cloudappcontrol.GetByRuleType(ctx, service, ruleType)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_dlp_web_rules": {scopeKey: "parent_rule", ranked: true, list: func(ctx context.Context, service *zscaler.Service, parent string) (map[int]OrderRule, error) {
+		m := map[int]OrderRule{}
+		if parentID, _ := strconv.Atoi(parent); parentID != 0 {
+			rule, err := dlp_web_rules.Get(ctx, service, parentID)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rule.SubRules {
+				m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+			}
+			return m, nil
+		}
+		list, err := dlp_web_rules.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_endpoint_dlp_rules": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := endpoint_dlp_rules.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_endpoint_dlp_sub_rules": {scopeKey: "parent_rule", ranked: true, list: func(ctx context.Context, service *zscaler.Service, parent string) (map[int]OrderRule, error) {
+		parentID, err := strconv.Atoi(parent)
+		if err != nil {
+			return nil, err
+		}
+		list, err := endpointDlpSubRuleAdapter{service: service, parentID: parentID}.GetAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_file_type_control_rules": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := filetypecontrol.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_firewall_dns_rule": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := firewalldnscontrolpolicies.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_firewall_filtering_rule": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := filteringrules.GetAll(ctx, service, nil)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_firewall_ips_rule": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := ips_policies.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_forwarding_control_rule": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := forwarding_rules.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_nat_control_rules": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := nat_control_policies.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_outbound_email_dlp": {scopeKey: "parent_rule", list: func(ctx context.Context, service *zscaler.Service, parent string) (map[int]OrderRule, error) {
+		m := map[int]OrderRule{}
+		if parentID, _ := strconv.Atoi(parent); parentID != 0 {
+			rule, err := outbound_email_dlp.Get(ctx, service, parentID)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rule.SubRules {
+				m[r.ID] = OrderRule{Order: r.Order}
+			}
+			return m, nil
+		}
+		list, err := outbound_email_dlp.GetAll(ctx, service, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order}
+		}
+		return m, nil
+	}},
+	"zia_sandbox_rules": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := sandbox_rules.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range filterOutDefaultRule(list) {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_ssl_inspection_rules": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := sslinspection.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_traffic_capture_rules": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := traffic_capture.GetAll(ctx, service, nil)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+	"zia_url_filtering_rules": {ranked: true, list: func(ctx context.Context, service *zscaler.Service, _ string) (map[int]OrderRule, error) {
+		list, err := urlfilteringpolicies.GetAll(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[int]OrderRule, len(list))
+		for _, r := range list {
+			m[r.ID] = OrderRule{Order: r.Order, Rank: r.Rank}
+		}
+		return m, nil
+	}},
+}
+
+// declaredRuleOrder is the position one rule declares in the plan.
+type declaredRuleOrder struct {
+	name  string
+	label string // resource type and name, as reported in errors
+	id    int    // 0 until the rule is created
+	order int
+	rank  int
+}
+
+// plannedRuleOrders is a client's plan registry. It is reset every time the
+// provider is configured, which Terraform does once per plan or apply.
+type plannedRuleOrders struct {
+	// policies holds the declared rules per policy, keyed by rule ID, or
+	// by name for rules that are not created yet.
+	policies map[string]map[string]declaredRuleOrder
+	// orderable caches, per policy, the listing of the rules the API
+	// orders. Concurrent callers share one listing.
+	orderable map[string]*orderableListing
+	// lastChange is when a rule of the policy last registered.
+	lastChange map[string]time.Time
+	sync.Mutex
+}
+
+func (p *plannedRuleOrders) reset() {
+	p.Lock()
+	defer p.Unlock()
+	p.policies = nil
+	p.orderable = nil
+	p.lastChange = nil
+}
+
+// register records r in policy.
+func (p *plannedRuleOrders) register(policy, key string, r declaredRuleOrder) {
+	p.Lock()
+	defer p.Unlock()
+	if p.policies == nil {
+		p.policies = map[string]map[string]declaredRuleOrder{}
+		p.lastChange = map[string]time.Time{}
+	}
+	if p.policies[policy] == nil {
+		p.policies[policy] = map[string]declaredRuleOrder{}
+	}
+	p.policies[policy][key] = r
+	p.lastChange[policy] = time.Now()
+}
+
+// others returns the rules of policy other than key and whether the policy
+// has been quiet for ruleOrderSettleInterval.
+func (p *plannedRuleOrders) others(policy, key string) ([]declaredRuleOrder, bool) {
+	p.Lock()
+	defer p.Unlock()
+	return p.othersLocked(policy, key), time.Since(p.lastChange[policy]) >= ruleOrderSettleInterval
+}
+
+func (p *plannedRuleOrders) othersLocked(policy, key string) []declaredRuleOrder {
+	others := make([]declaredRuleOrder, 0, len(p.policies[policy]))
+	for k, r := range p.policies[policy] {
+		if k != key {
+			others = append(others, r)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		if others[i].order != others[j].order {
+			return others[i].order < others[j].order
+		}
+		return others[i].name < others[j].name
+	})
+	return others
+}
+
+// orderableListing is one listing of a policy's orderable rules. done is
+// closed once ids and err are set.
+type orderableListing struct {
+	done chan struct{}
+	ids  []int
+	err  error
+}
+
+// orderableIDs returns the IDs of the rules of policy the API orders. The
+// first caller lists them; the others wait for its result, which is kept,
+// failure included, for the rest of the plan.
+func (p *plannedRuleOrders) orderableIDs(ctx context.Context, policy string, list func() (map[int]OrderRule, error)) ([]int, error) {
+	p.Lock()
+	listing, ok := p.orderable[policy]
+	if !ok {
+		if p.orderable == nil {
+			p.orderable = map[string]*orderableListing{}
+		}
+		listing = &orderableListing{done: make(chan struct{})}
+		p.orderable[policy] = listing
+	}
+	p.Unlock()
+
+	if ok {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-listing.done:
+			return listing.ids, listing.err
+		}
+	}
+
+	defer close(listing.done)
+	current, err := list()
+	if err != nil {
+		listing.err = err
+		return nil, err
+	}
+	listing.ids = []int{}
+	for id, r := range current {
+		if r.Order >= 1 {
+			listing.ids = append(listing.ids, id)
+		}
+	}
+	return listing.ids, nil
+}
+
+// validateRuleOrder is the CustomizeDiff of the ordered rule resources.
+func validateRuleOrder(resourceType string) schema.CustomizeDiffFunc {
+	policy, ok := ruleOrderPolicies[resourceType]
+	if !ok {
+		panic(fmt.Sprintf("no rule order policy for %s", resourceType))
+	}
+	return func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		zClient, ok := meta.(*Client)
+		if !ok || !d.NewValueKnown("order") || !d.NewValueKnown("name") {
+			return nil
+		}
+		if adoptsPredefined(d) {
+			// An adopted predefined rule keeps its place; its order is not declared.
+			return nil
+		}
+		r := declaredRuleOrder{name: d.Get("name").(string), order: d.Get("order").(int)}
+		r.label = fmt.Sprintf("%s %q", resourceType, r.name)
+		if r.order < 1 {
+			// Predefined and default rules sit outside the ordered range.
+			return nil
+		}
+		if policy.ranked {
+			if !d.NewValueKnown("rank") {
+				return nil
+			}
+			r.rank = d.Get("rank").(int)
+		}
+		key := "name:" + r.name
+		if id, err := strconv.Atoi(d.Id()); err == nil {
+			r.id = id
+			key = "id:" + d.Id()
+		}
+		scope := ""
+		name := resourceType
+		if policy.scopeKey != "" {
+			if !d.NewValueKnown(policy.scopeKey) && !ruleOrderScopeUnset(d, policy.scopeKey) {
+				return nil
+			}
+			scope = fmt.Sprint(d.Get(policy.scopeKey))
+			name = fmt.Sprintf("%s %s %s", resourceType, policy.scopeKey, scope)
+		}
+		changed := d.Id() == "" || d.HasChange("order") || policy.ranked && d.HasChange("rank")
+		registry := &zClient.ruleOrders
+
+		registry.register(name, key, r)
+		var orderable []int
+		if zClient.Service != nil && policy.list != nil {
+			ids, err := registry.orderableIDs(ctx, name, func() (map[int]OrderRule, error) {
+				return policy.list(ctx, zClient.Service, scope)
+			})
+			if err != nil {
+				log.Printf("[WARN] rule order: skipping the gap check for %s: %v", name, err)
+			} else {
+				orderable = ids
+			}
+		}
+		for {
+			others, settled := registry.others(name, key)
+			if conflicts := ruleOrderConflicts(r, others, policy.ranked); len(conflicts) > 0 {
+				return fmt.Errorf("conflicting rule orders in %s:\n  - %s", name, strings.Join(conflicts, "\n  - "))
+			}
+			gap := ""
+			if orderable != nil {
+				gap = ruleOrderGap(r, others, orderable)
+			}
+			if gap == "" && (settled || !changed) {
+				return nil
+			}
+			if settled {
+				return fmt.Errorf("conflicting rule orders in %s:\n  - %s", name, gap)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(ruleOrderSettleInterval / 5):
+			}
+		}
+	}
+}
+
+// ruleOrderScopeUnset reports whether the configuration leaves the optional
+// and computed scope attribute key unset, as a top-level DLP rule leaves
+// parent_rule. The rule then belongs to the top-level policy.
+func ruleOrderScopeUnset(d *schema.ResourceDiff, key string) bool {
+	raw := d.GetRawConfig()
+	if raw.IsNull() || !raw.IsKnown() || !raw.Type().IsObjectType() || !raw.Type().HasAttribute(key) {
+		return false
+	}
+	return raw.GetAttr(key).IsNull()
+}
+
+// ruleOrderConflicts returns the duplicate orders and rank conflicts between
+// r and the other declared rules of its policy.
+func ruleOrderConflicts(r declaredRuleOrder, others []declaredRuleOrder, ranked bool) []string {
+	var conflicts []string
+	for _, o := range others {
+		switch {
+		case o.order == r.order:
+			conflicts = append(conflicts, fmt.Sprintf("%s and %s both declare order %d", o.label, r.label, r.order))
+		case ranked && o.order < r.order && o.rank > r.rank:
+			conflicts = append(conflicts, fmt.Sprintf("%s (order %d, rank %d) is declared below %s (order %d, rank %d); rank must not decrease as order increases", r.label, r.order, r.rank, o.label, o.order, o.rank))
+		case ranked && o.order > r.order && o.rank < r.rank:
+			conflicts = append(conflicts, fmt.Sprintf("%s (order %d, rank %d) is declared below %s (order %d, rank %d); rank must not decrease as order increases", o.label, o.order, o.rank, r.label, r.order, r.rank))
+		}
+	}
+	return conflicts
+}
+
+// ruleOrderGap describes the positions below r that neither a declared rule
+// nor a rule unmanaged by Terraform can fill, or returns "". orderable are
+// the IDs of the rules the API orders; those not declared in the plan are
+// the unmanaged ones.
+func ruleOrderGap(r declaredRuleOrder, others []declaredRuleOrder, orderable []int) string {
+	declared := map[int]bool{r.id: true}
+	above := 0
+	for _, o := range others {
+		declared[o.id] = true
+		if o.order < r.order {
+			above++
+		}
+	}
+	unmanaged := 0
+	for _, id := range orderable {
+		if !declared[id] {
+			unmanaged++
+		}
+	}
+	if open := r.order - 1 - above; open > unmanaged {
+		return fmt.Sprintf("%s declares order %d, but only %d declared rule(s) and %d rule(s) not managed by Terraform can fill orders 1-%d", r.label, r.order, above, unmanaged, r.order-1)
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package zia
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestRuleOrderConflicts(t *testing.T) {
+	others := []declaredRuleOrder{
+		{name: "first", label: `rule "first"`, order: 1, rank: 0},
+		{name: "second", label: `rule "second"`, order: 2, rank: 7},
+	}
+	cases := []struct {
+		rule   declaredRuleOrder
+		ranked bool
+		want   string
+	}{
+		{declaredRuleOrder{name: "third", label: `rule "third"`, order: 3, rank: 7}, true, ""},
+		{declaredRuleOrder{name: "dup", label: `rule "dup"`, order: 2, rank: 7}, true, `rule "second" and rule "dup" both declare order 2`},
+		{declaredRuleOrder{name: "late", label: `rule "late"`, order: 3, rank: 3}, true, `rule "late" (order 3, rank 3) is declared below rule "second" (order 2, rank 7)`},
+		{declaredRuleOrder{name: "late", label: `rule "late"`, order: 3, rank: 3}, false, ""},
+	}
+	for _, c := range cases {
+		got := strings.Join(ruleOrderConflicts(c.rule, others, c.ranked), "\n")
+		if c.want == "" && got != "" || !strings.Contains(got, c.want) {
+			t.Errorf("ruleOrderConflicts(%+v, ranked=%v) = %q, want %q", c.rule, c.ranked, got, c.want)
+		}
+	}
+}
+
+func TestRuleOrderGap(t *testing.T) {
+	others := []declaredRuleOrder{{name: "managed", id: 10, order: 1}}
+	rule := declaredRuleOrder{name: "gap", label: `rule "gap"`, order: 4}
+
+	// Orders 2 and 3 need two unmanaged rules; rule 10 is managed.
+	if gap := ruleOrderGap(rule, others, []int{10, 20}); !strings.Contains(gap, `rule "gap" declares order 4, but only 1 declared rule(s) and 1 rule(s) not managed by Terraform can fill orders 1-3`) {
+		t.Errorf("unexpected gap %q", gap)
+	}
+	if gap := ruleOrderGap(rule, others, []int{10, 20, 30}); gap != "" {
+		t.Errorf("expected two unmanaged rules to fill the gap, got %q", gap)
+	}
+}
+
+func TestValidateRuleOrder_FailsPlan(t *testing.T) {
+	server, client := configureFakeZIA(t)
+	settle := ruleOrderSettleInterval
+	ruleOrderSettleInterval = 50 * time.Millisecond
+	t.Cleanup(func() { ruleOrderSettleInterval = settle })
+	server.Seed("firewallFilteringRules", map[string]interface{}{"name": "unmanaged", "order": 1, "rank": 7})
+
+	r := resourceFirewallFilteringRules()
+	plan := func(name string, order int) error {
+		t.Helper()
+		_, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":   name,
+			"order":  order,
+			"action": "ALLOW",
+			"state":  "ENABLED",
+		}), client)
+		return err
+	}
+
+	if err := plan("first", 1); err != nil {
+		t.Fatalf("plan first: %v", err)
+	}
+	if err := plan("dup", 1); err == nil || !strings.Contains(err.Error(), `zia_firewall_filtering_rule "first" and zia_firewall_filtering_rule "dup" both declare order 1`) {
+		t.Fatalf("expected a duplicate order error, got %v", err)
+	}
+	// The unmanaged rule fills order 2.
+	if err := plan("third", 3); err != nil {
+		t.Fatalf("plan third: %v", err)
+	}
+	if err := plan("far", 6); err == nil || !strings.Contains(err.Error(), `zia_firewall_filtering_rule "far" declares order 6`) {
+		t.Fatalf("expected a gap error, got %v", err)
+	}
+
+	// Configuring the provider again starts a new plan.
+	client.ruleOrders.reset()
+	if err := plan("dup", 1); err != nil {
+		t.Fatalf("expected a fresh registry after reset, got %v", err)
+	}
+}
+
+func TestValidateRuleOrder_ReportsDuplicateOnEveryRule(t *testing.T) {
+	server, client := configureFakeZIA(t)
+	settle := ruleOrderSettleInterval
+	ruleOrderSettleInterval = 200 * time.Millisecond
+	t.Cleanup(func() { ruleOrderSettleInterval = settle })
+
+	r := resourceFirewallFilteringRules()
+	errs := make(chan error, 2)
+	for _, name := range []string{"left", "right"} {
+		go func(name string) {
+			_, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name":   name,
+				"order":  1,
+				"action": "ALLOW",
+				"state":  "ENABLED",
+			}), client)
+			errs <- err
+		}(name)
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err == nil || !strings.Contains(err.Error(), "both declare order 1") {
+			t.Errorf("expected both rules to report the duplicate order, got %v", err)
+		}
+	}
+	if n := countRequests(server, http.MethodGet, "firewallFilteringRules"); n != 1 {
+		t.Errorf("expected the policy to be listed once per plan, got %d listings", n)
+	}
+}
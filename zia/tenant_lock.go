@@ -0,0 +1,463 @@
+package zia
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tenantLockBackendFile = "file"
+	tenantLockBackendHTTP = "http"
+
+	defaultTenantLockTimeout = 3600
+	defaultTenantLockLease   = 120
+
+	// tenantLockStopTimeout bounds the release of the tenant locks once the
+	// provider server has stopped, leaving the rest of go-plugin's two
+	// second kill window to the last activation attempt.
+	tenantLockStopTimeout = 500 * time.Millisecond
+)
+
+// tenantLockPollInterval is how often a waiting provider retries a lock held
+// by another run.
+var tenantLockPollInterval = 10 * time.Second
+
+// tenantLockSettings holds the tenant_lock settings.
+type tenantLockSettings struct {
+	backend string
+	path    string
+	url     string
+	token   string
+	key     string
+	timeout int // seconds
+	lease   int // seconds
+}
+
+// readTenantLockSettings reads the tenant_lock block. Every setting falls back
+// to its ZSCALER_TENANT_LOCK_* environment variable when the block leaves it
+// unset; the lock is disabled when no backend is configured.
+func readTenantLockSettings(d configValues) tenantLockSettings {
+	s := tenantLockSettings{
+		timeout: defaultTenantLockTimeout,
+		lease:   defaultTenantLockLease,
+	}
+	var block map[string]interface{}
+	if val, ok := d.GetOk("tenant_lock"); ok {
+		if blocks := val.([]interface{}); len(blocks) > 0 && blocks[0] != nil {
+			block = blocks[0].(map[string]interface{})
+		}
+	}
+
+	settingString(block, "backend", "ZSCALER_TENANT_LOCK_BACKEND", &s.backend)
+	settingString(block, "path", "ZSCALER_TENANT_LOCK_PATH", &s.path)
+	settingString(block, "url", "ZSCALER_TENANT_LOCK_URL", &s.url)
+	settingString(block, "token", "ZSCALER_TENANT_LOCK_TOKEN", &s.token)
+	settingString(block, "key", "ZSCALER_TENANT_LOCK_KEY", &s.key)
+	settingInt(block, "timeout_seconds", "ZSCALER_TENANT_LOCK_TIMEOUT_SECONDS", &s.timeout)
+	settingInt(block, "lease_seconds", "ZSCALER_TENANT_LOCK_LEASE_SECONDS", &s.lease)
+	s.backend = strings.ToLower(s.backend)
+	return s
+}
+
+// tenantLockKey returns the lock key of the tenant a Config talks to: the
+// vanity domain and cloud for OneAPI, the admin domain and ZIA cloud for the
+// legacy client.
+func (c *Config) tenantLockKey() string {
+	if c.tenantLock.key != "" {
+		return c.tenantLock.key
+	}
+	tenant, cloud := c.vanityDomain, c.cloud
+	if c.useLegacyClient || tenant == "" {
+		_, tenant, _ = strings.Cut(c.Username, "@")
+		cloud = c.ZIABaseURL
+	}
+	if cloud == "" {
+		cloud = "production"
+	}
+	return tenant + "-" + cloud
+}
+
+// newTenantLock builds the tenant lock of a Config, or returns nil when no
+// backend is configured.
+func (c *Config) newTenantLock() (*tenantLock, error) {
+	s := c.tenantLock
+	var backend tenantLockBackend
+	switch s.backend {
+	case "":
+		return nil, nil
+	case tenantLockBackendFile:
+		if s.path == "" {
+			return nil, fmt.Errorf("tenant_lock: the file backend requires path")
+		}
+		backend = fileTenantLock{dir: s.path}
+	case tenantLockBackendHTTP:
+		if s.url == "" {
+			return nil, fmt.Errorf("tenant_lock: the http backend requires url")
+		}
+		if _, err := url.Parse(s.url); err != nil {
+			return nil, fmt.Errorf("tenant_lock: invalid url: %v", err)
+		}
+		backend = httpTenantLock{url: strings.TrimSuffix(s.url, "/"), token: s.token, client: &http.Client{Timeout: 30 * time.Second}}
+	default:
+		return nil, fmt.Errorf("tenant_lock: unknown backend %q, expected %q or %q", s.backend, tenantLockBackendFile, tenantLockBackendHTTP)
+	}
+	return newTenantLock(backend, c.tenantLockKey(), time.Duration(s.timeout)*time.Second, time.Duration(s.lease)*time.Second), nil
+}
+
+// tenantLockBackend stores leases. A lease names its holder and expires
+// unless renewed, so a run that dies without releasing it blocks others for
+// at most one lease period.
+type tenantLockBackend interface {
+	// acquire takes the lease of key for holder, or renews it when holder
+	// already has it. It returns false and the current holder when another
+	// holder has an unexpired lease.
+	acquire(ctx context.Context, key, holder string, lease time.Duration) (bool, string, error)
+	// release gives up the lease of key if holder has it.
+	release(ctx context.Context, key, holder string) error
+}
+
+// tenantLock serializes the writes of concurrent Terraform runs against one
+// tenant. The lock is taken before the first write of the run, renewed while
+// held, and released once the deferred activation has succeeded or the
+// provider stops, so a second run queues behind the first instead of failing
+// with EDIT_LOCK_NOT_AVAILABLE or interleaving its rule reorders.
+type tenantLock struct {
+	backend tenantLockBackend
+	key     string
+	holder  string
+	timeout time.Duration
+	lease   time.Duration
+
+	mu   sync.Mutex
+	stop chan struct{} // non-nil while the lock is held
+}
+
+func newTenantLock(backend tenantLockBackend, key string, timeout, lease time.Duration) *tenantLock {
+	hostname, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return &tenantLock{
+		backend: backend,
+		key:     key,
+		holder:  fmt.Sprintf("%s/%d/%s", hostname, os.Getpid(), hex.EncodeToString(suffix)),
+		timeout: timeout,
+		lease:   lease,
+	}
+}
+
+// acquire blocks until this run holds the lock, the timeout elapses or ctx
+// is done. It returns immediately when the lock is already held.
+func (l *tenantLock) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop != nil {
+		return nil
+	}
+
+	deadline := time.Now().Add(l.timeout)
+	logged := ""
+	for {
+		held, owner, err := l.backend.acquire(ctx, l.key, l.holder, l.lease)
+		if err != nil {
+			return fmt.Errorf("acquiring tenant lock %s: %w", l.key, err)
+		}
+		if held {
+			break
+		}
+		if owner != logged {
+			log.Printf("[INFO] Tenant lock %s is held by %s; waiting up to %s", l.key, owner, time.Until(deadline).Round(time.Second))
+			logged = owner
+		}
+		if time.Now().Add(tenantLockPollInterval).After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for tenant lock %s, held by %s", l.timeout, l.key, owner)
+		}
+		select {
+		case <-time.After(tenantLockPollInterval):
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for tenant lock %s: %w", l.key, ctx.Err())
+		}
+	}
+
+	log.Printf("[INFO] Acquired tenant lock %s as %s", l.key, l.holder)
+	l.stop = make(chan struct{})
+	go l.renew(l.stop)
+	return nil
+}
+
+// renew extends the lease until stop is closed.
+func (l *tenantLock) renew(stop chan struct{}) {
+	ticker := time.NewTicker(l.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		held, owner, err := l.backend.acquire(context.Background(), l.key, l.holder, l.lease)
+		switch {
+		case err != nil:
+			log.Printf("[WARN] Renewing tenant lock %s failed: %v", l.key, err)
+		case !held:
+			log.Printf("[WARN] Tenant lock %s was lost to %s; concurrent runs may now interleave", l.key, owner)
+		}
+	}
+}
+
+// release gives up the lock if this run holds it.
+func (l *tenantLock) release(ctx context.Context) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop == nil {
+		return
+	}
+	close(l.stop)
+	l.stop = nil
+	if err := l.backend.release(ctx, l.key, l.holder); err != nil {
+		log.Printf("[WARN] Releasing tenant lock %s failed, it expires after %s: %v", l.key, l.lease, err)
+		return
+	}
+	log.Printf("[INFO] Released tenant lock %s", l.key)
+}
+
+// ReleaseTenantLocks releases the tenant locks held by every configured
+// client. main calls it once the provider server has stopped, ahead of the
+// last activation attempt, and it gives up after tenantLockStopTimeout. A
+// lock that is not released, because the release failed or the plugin was
+// killed first, blocks other runs until its lease expires.
+func ReleaseTenantLocks() {
+	ctx, cancel := context.WithTimeout(context.Background(), tenantLockStopTimeout)
+	defer cancel()
+	configuredClients.Lock()
+	defer configuredClients.Unlock()
+	for _, client := range configuredClients.m {
+		client.tenantLock.release(ctx)
+	}
+}
+
+// tenantLockFile is the content of a file lease.
+type tenantLockFile struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// fileTenantLock keeps leases as files in a directory shared by the runs,
+// e.g. on a network filesystem. A lease is created with O_EXCL, so only one
+// run can take a free lock.
+type fileTenantLock struct {
+	dir string
+}
+
+func (f fileTenantLock) file(key string) string {
+	return filepath.Join(f.dir, sanitizeTenantLockKey(key)+".lock")
+}
+
+func (f fileTenantLock) read(path string) (tenantLockFile, []byte, error) {
+	var lease tenantLockFile
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return lease, nil, err
+	}
+	if err := json.Unmarshal(raw, &lease); err != nil {
+		// A lease being written is briefly empty; treat it as held.
+		lease = tenantLockFile{Holder: "unknown", Expires: time.Now().Add(time.Minute)}
+	}
+	return lease, raw, nil
+}
+
+func (f fileTenantLock) acquire(_ context.Context, key, holder string, lease time.Duration) (bool, string, error) {
+	path := f.file(key)
+	content, err := json.Marshal(tenantLockFile{Holder: holder, Expires: time.Now().Add(lease)})
+	if err != nil {
+		return false, "", err
+	}
+
+	current, raw, err := f.read(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return false, "", err
+	case current.Holder == holder:
+		// Renew through a temporary file so readers never see a partial
+		// lease.
+		tmp := path + "." + sanitizeTenantLockKey(holder)
+		if err := os.WriteFile(tmp, content, 0o644); err != nil {
+			return false, "", err
+		}
+		return true, holder, os.Rename(tmp, path)
+	case time.Now().Before(current.Expires):
+		return false, current.Holder, nil
+	default:
+		if err := f.breakExpired(key, path, raw, current); err != nil {
+			return false, "", err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		current, _, _ := f.read(path)
+		return false, current.Holder, nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err == nil, holder, err
+}
+
+// tenantLockBreakerTimeout is how old a breaker file must be before it is
+// treated as left behind by a run that died while breaking a lease.
+var tenantLockBreakerTimeout = time.Minute
+
+// breakExpired removes the expired lease at path, whose content was raw.
+// Runs that find the same expired lease race to break it, so the removal is
+// guarded by an O_EXCL breaker file: only the run that creates the breaker
+// removes the lease, and only if it is still the one read, so a lease
+// another run took in the meantime survives. A run that loses the race
+// leaves the lease alone and competes for the free lock through O_EXCL.
+func (f fileTenantLock) breakExpired(key, path string, raw []byte, current tenantLockFile) error {
+	breaker := path + ".break"
+	file, err := os.OpenFile(breaker, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		if info, err := os.Stat(breaker); err == nil && time.Since(info.ModTime()) > tenantLockBreakerTimeout {
+			log.Printf("[WARN] Removing tenant lock breaker %s, left behind since %s", breaker, info.ModTime().Format(time.RFC3339))
+			_ = os.Remove(breaker)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_ = file.Close()
+	defer os.Remove(breaker)
+
+	again, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || err == nil && !bytes.Equal(again, raw) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("[WARN] Breaking tenant lock %s of %s, expired at %s", key, current.Holder, current.Expires.Format(time.RFC3339))
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (f fileTenantLock) release(_ context.Context, key, holder string) error {
+	path := f.file(key)
+	current, _, err := f.read(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.Holder != holder {
+		return fmt.Errorf("lease is held by %s", current.Holder)
+	}
+	return os.Remove(path)
+}
+
+// sanitizeTenantLockKey makes a key safe to use as a file name.
+func sanitizeTenantLockKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// httpTenantLock keeps leases in a lease service:
+//
+//	PUT    <url>/<key>           {"holder": "...", "lease_seconds": 120}
+//	DELETE <url>/<key>?holder=...
+//
+// PUT answers 200, 201 or 204 when holder has the lease, and 409 or 423 with
+// {"holder": "..."} when another holder has it. DELETE answers 2xx, or 404
+// when there is no lease.
+type httpTenantLock struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (h httpTenantLock) do(ctx context.Context, method, target string, body []byte) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	return resp, respBody, err
+}
+
+func (h httpTenantLock) acquire(ctx context.Context, key, holder string, lease time.Duration) (bool, string, error) {
+	body, err := json.Marshal(map[string]interface{}{"holder": holder, "lease_seconds": int(lease.Seconds())})
+	if err != nil {
+		return false, "", err
+	}
+	resp, respBody, err := h.do(ctx, http.MethodPut, h.url+"/"+url.PathEscape(key), body)
+	if err != nil {
+		return false, "", err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return true, holder, nil
+	case http.StatusConflict, http.StatusLocked:
+		var current struct {
+			Holder string `json:"holder"`
+		}
+		_ = json.Unmarshal(respBody, &current)
+		if current.Holder == "" {
+			current.Holder = "another run"
+		}
+		return false, current.Holder, nil
+	}
+	return false, "", fmt.Errorf("lease service answered %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+}
+
+func (h httpTenantLock) release(ctx context.Context, key, holder string) error {
+	resp, respBody, err := h.do(ctx, http.MethodDelete, h.url+"/"+url.PathEscape(key)+"?holder="+url.QueryEscape(holder), nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 == 2 || resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return fmt.Errorf("lease service answered %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+}
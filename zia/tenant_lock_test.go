@@ -0,0 +1,271 @@
+package zia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func shortTenantLockPolls(t *testing.T) {
+	t.Helper()
+	poll := tenantLockPollInterval
+	tenantLockPollInterval = 20 * time.Millisecond
+	t.Cleanup(func() { tenantLockPollInterval = poll })
+}
+
+// testTenantLockQueues checks that a second run waits for the first to
+// release the lock and then takes it.
+func testTenantLockQueues(t *testing.T, backend tenantLockBackend) {
+	ctx := context.Background()
+	first := newTenantLock(backend, "acme.zscalerbeta.net-beta", time.Minute, time.Minute)
+	second := newTenantLock(backend, "acme.zscalerbeta.net-beta", time.Minute, time.Minute)
+
+	if err := first.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	acquired := make(chan error, 1)
+	go func() { acquired <- second.acquire(ctx) }()
+	select {
+	case err := <-acquired:
+		t.Fatalf("expected the second run to wait, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	first.release(ctx)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the second run to take the released lock")
+	}
+	second.release(ctx)
+
+	third := newTenantLock(backend, "acme.zscalerbeta.net-beta", 50*time.Millisecond, time.Minute)
+	if err := first.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := third.acquire(ctx); err == nil || !strings.Contains(err.Error(), "timed out after 50ms waiting for tenant lock acme.zscalerbeta.net-beta, held by "+first.holder) {
+		t.Fatalf("expected a timeout naming the holder, got %v", err)
+	}
+	first.release(ctx)
+}
+
+func TestTenantLock_File(t *testing.T) {
+	shortTenantLockPolls(t)
+	dir := t.TempDir()
+	testTenantLockQueues(t, fileTenantLock{dir: dir})
+
+	// A lease left behind by a run that died is broken once it expires.
+	backend := fileTenantLock{dir: dir}
+	stale, _ := json.Marshal(tenantLockFile{Holder: "crashed", Expires: time.Now().Add(-time.Second)})
+	if err := os.WriteFile(backend.file("acme"), stale, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	held, _, err := backend.acquire(context.Background(), "acme", "next", time.Minute)
+	if err != nil || !held {
+		t.Fatalf("expected the expired lease to be taken over, got %v, %v", held, err)
+	}
+}
+
+func TestTenantLock_FileBreaksExpiredLeaseOnce(t *testing.T) {
+	backend := fileTenantLock{dir: t.TempDir()}
+	stale, _ := json.Marshal(tenantLockFile{Holder: "crashed", Expires: time.Now().Add(-time.Second)})
+
+	for round := 0; round < 20; round++ {
+		key := fmt.Sprintf("acme-%d", round)
+		if err := os.WriteFile(backend.file(key), stale, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Every run sees the expired lease; only one may end up holding it.
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var winners []string
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(holder string) {
+				defer wg.Done()
+				for attempt := 0; attempt < 50; attempt++ {
+					held, current, err := backend.acquire(context.Background(), key, holder, time.Minute)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if held {
+						mu.Lock()
+						winners = append(winners, holder)
+						mu.Unlock()
+						return
+					}
+					if current != "crashed" {
+						return
+					}
+					time.Sleep(time.Millisecond)
+				}
+			}(fmt.Sprintf("run-%d", i))
+		}
+		wg.Wait()
+		if len(winners) != 1 {
+			t.Fatalf("round %d: expected exactly one run to take over the expired lease, got %v", round, winners)
+		}
+		if _, err := os.Stat(backend.file(key) + ".break"); !os.IsNotExist(err) {
+			t.Fatalf("round %d: expected the breaker to be removed, got %v", round, err)
+		}
+	}
+
+	// A breaker left behind by a run that died is ignored once it is stale.
+	breaker := tenantLockBreakerTimeout
+	tenantLockBreakerTimeout = 0
+	t.Cleanup(func() { tenantLockBreakerTimeout = breaker })
+	if err := os.WriteFile(backend.file("acme"), stale, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(backend.file("acme")+".break", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if held, _, _ := backend.acquire(context.Background(), "acme", "next", time.Minute); held {
+		t.Fatal("expected the lease to stay while another run holds the breaker")
+	}
+	if held, _, err := backend.acquire(context.Background(), "acme", "next", time.Minute); err != nil || !held {
+		t.Fatalf("expected the stale breaker to be cleared and the lease taken over, got %v, %v", held, err)
+	}
+}
+
+func TestTenantLock_HTTP(t *testing.T) {
+	shortTenantLockPolls(t)
+	var mu sync.Mutex
+	leases := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/locks/")
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			var req struct {
+				Holder string `json:"holder"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if current, ok := leases[key]; ok && current != req.Holder {
+				w.WriteHeader(http.StatusConflict)
+				_ = json.NewEncoder(w).Encode(map[string]string{"holder": current})
+				return
+			}
+			leases[key] = req.Holder
+		case http.MethodDelete:
+			if leases[key] == r.URL.Query().Get("holder") {
+				delete(leases, key)
+			}
+		}
+	}))
+	defer server.Close()
+
+	testTenantLockQueues(t, httpTenantLock{url: server.URL + "/locks", token: "secret", client: server.Client()})
+
+	_, _, err := httpTenantLock{url: server.URL + "/locks", client: server.Client()}.acquire(context.Background(), "acme", "me", time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Fatalf("expected the lease service error, got %v", err)
+	}
+}
+
+func TestTenantLockSettings(t *testing.T) {
+	t.Setenv("ZSCALER_TENANT_LOCK_BACKEND", "FILE")
+	t.Setenv("ZSCALER_TENANT_LOCK_PATH", "/mnt/locks")
+	config := NewConfig(frameworkConfigValues{
+		"vanity_domain": "acme",
+		"zscaler_cloud": "beta",
+		"tenant_lock":   []interface{}{map[string]interface{}{"lease_seconds": 30}},
+	})
+	want := tenantLockSettings{backend: "file", path: "/mnt/locks", timeout: defaultTenantLockTimeout, lease: 30}
+	if config.tenantLock != want {
+		t.Errorf("got %+v, want %+v", config.tenantLock, want)
+	}
+	if got := config.tenantLockKey(); got != "acme-beta" {
+		t.Errorf("expected the key to name the vanity domain and cloud, got %q", got)
+	}
+
+	config.tenantLock.backend = "http"
+	if _, err := config.newTenantLock(); err == nil || !strings.Contains(err.Error(), "the http backend requires url") {
+		t.Errorf("expected a missing url to fail, got %v", err)
+	}
+}
+
+func TestTenantLock_HeldFromFirstWrite(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ZSCALER_TENANT_LOCK_BACKEND", "file")
+	t.Setenv("ZSCALER_TENANT_LOCK_PATH", dir)
+	server, client := configureFakeZIA(t)
+	seeded := server.Seed("urlFilteringRules", map[string]interface{}{"name": "one", "order": 1, "rank": 7, "state": "ENABLED", "action": "ALLOW"})
+
+	backend := fileTenantLock{dir: dir}
+	lockFile := backend.file("fakezia.test-zscalertest")
+	if _, err := os.Stat(lockFile); !os.IsNotExist(err) {
+		t.Fatalf("expected no lock before the first write, got %v", err)
+	}
+
+	r := ZIAProvider().ResourcesMap["zia_url_filtering_rules"]
+	d := r.TestResourceData()
+	id, _ := strconv.Atoi(fmt.Sprint(seeded[0]["id"]))
+	d.SetId(strconv.Itoa(id))
+	_ = d.Set("rule_id", id)
+	if diags := r.DeleteContext(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("delete: %v", diags)
+	}
+	lease, _, err := backend.read(lockFile)
+	if err != nil || lease.Holder != client.tenantLock.holder {
+		t.Fatalf("expected the write to take the lock, got %+v, %v", lease, err)
+	}
+
+	client.tenantLock.release(context.Background())
+	if _, err := os.Stat(lockFile); !os.IsNotExist(err) {
+		t.Fatalf("expected the release to remove the lease, got %v", err)
+	}
+}
+
+// stuckReleaseBackend grants every lease and never completes a release.
+type stuckReleaseBackend struct{}
+
+func (stuckReleaseBackend) acquire(context.Context, string, string, time.Duration) (bool, string, error) {
+	return true, "", nil
+}
+
+func (stuckReleaseBackend) release(ctx context.Context, _, _ string) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestReleaseTenantLocks_LeavesRoomForTheLastActivation(t *testing.T) {
+	lock := newTenantLock(stuckReleaseBackend{}, "acme", time.Minute, time.Minute)
+	if err := lock.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	key := &schema.Provider{}
+	configuredClients.Lock()
+	configuredClients.m[key] = &Client{tenantLock: lock}
+	configuredClients.Unlock()
+	t.Cleanup(func() {
+		configuredClients.Lock()
+		delete(configuredClients.m, key)
+		configuredClients.Unlock()
+	})
+
+	start := time.Now()
+	ReleaseTenantLocks()
+	if elapsed := time.Since(start); elapsed+stopActivationTimeout >= 2*time.Second {
+		t.Fatalf("expected the release and the last activation to fit the kill window, the release took %s", elapsed)
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zscaler/zscaler-sdk-go/v3/zscaler"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/errorx"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/activation"
 	"github.com/zscaler/zscaler-sdk-go/v3/zscaler/zia/services/bandwidth_control/bandwidth_classes"
@@ -109,6 +111,23 @@ func getStringFromResourceData(d *schema.ResourceData, key string) (string, bool
 	return val, isSet && isStr && val != ""
 }
 
+// getSecretFromResourceData returns the value of a secret argument, preferring
+// its write-only variant key+"_wo". Write-only values are never stored in
+// state or plan, so they are read from the raw configuration and are only
+// available during Create and Update.
+func getSecretFromResourceData(d *schema.ResourceData, key string) string {
+	woValue, diags := d.GetRawConfigAt(cty.GetAttrPath(key + "_wo"))
+	if !diags.HasError() && woValue.Type() == cty.String && woValue.IsKnown() && !woValue.IsNull() {
+		if v := woValue.AsString(); v != "" {
+			return v
+		}
+	}
+	if v, ok := d.Get(key).(string); ok {
+		return v
+	}
+	return ""
+}
+
 func getBoolFromResourceData(d *schema.ResourceData, key string) bool {
 	obj, isSet := d.GetOk(key)
 	if !isSet {
@@ -594,10 +613,35 @@ func contains(slice []string, element string) bool {
 	return false
 }
 
-// Helper function to trigger configuration activation
+// Helper function to trigger configuration activation. When the provider is
+// configured with activation mode "end_of_apply" the write is only recorded on
+// the client; the single activation is issued once the apply goes quiet (see
+// deferredActivation).
 func triggerActivation(ctx context.Context, zClient *Client) error {
-	service := zClient.Service
+	if zClient.activation != nil {
+		zClient.activation.markDirty()
+		log.Printf("[INFO] Deferring configuration activation until the end of the apply")
+		return nil
+	}
 
+	// Sleep for 2 seconds before triggering the activation
+	time.Sleep(2 * time.Second)
+	return activateConfiguration(ctx, zClient.Service)
+}
+
+// triggerActivationNow is triggerActivation without the 2 second sleep, for
+// writes that already waited or never did.
+func triggerActivationNow(ctx context.Context, zClient *Client) error {
+	if zClient.activation != nil {
+		zClient.activation.markDirty()
+		log.Printf("[INFO] Deferring configuration activation until the end of the apply")
+		return nil
+	}
+	return activateConfiguration(ctx, zClient.Service)
+}
+
+// activateConfiguration issues the activation call itself.
+func activateConfiguration(ctx context.Context, service *zscaler.Service) error {
 	// Assuming the activation request doesn't need specific details from the rule labels
 	req := activation.Activation{Status: "ACTIVE"}
 	log.Printf("[INFO] Triggering configuration activation\n%+v\n", req)
@@ -611,8 +655,13 @@ func triggerActivation(ctx context.Context, zClient *Client) error {
 	return nil
 }
 
-// Helper function to check if we should activate based on the ZIA_ACTIVATION environment variable
-func shouldActivate() bool {
+// Helper function to check if we should activate, either because the provider
+// defers activation to the end of the apply or because the ZIA_ACTIVATION
+// environment variable is set.
+func shouldActivate(zClient *Client) bool {
+	if zClient != nil && zClient.activation != nil {
+		return true
+	}
 	activationEnv, exists := os.LookupEnv("ZIA_ACTIVATION")
 	if !exists {
 		return false
@@ -626,27 +675,33 @@ func shouldActivate() bool {
 }
 
 func validateTimeZone(v interface{}, k string) (ws []string, errors []error) {
-	tzStr := v.(string)
+	if err := checkTimeZone(v.(string)); err != nil {
+		errors = append(errors, err)
+	}
+	return
+}
 
+// checkTimeZone is the check behind validateTimeZone, shared with the
+// time_zone provider function.
+func checkTimeZone(tzStr string) error {
 	// Try IANA timezone validation
 	_, err := time.LoadLocation(tzStr)
-	if err != nil {
-		// Check if it's a common timezone that should work
-		commonTimezones := []string{"UTC", "GMT", "US/Pacific", "US/Eastern", "US/Central", "US/Mountain", "Europe/London", "Europe/Paris", "Europe/Berlin", "Europe/Vilnius", "Asia/Tokyo", "Asia/Shanghai"}
-
-		for _, common := range commonTimezones {
-			if tzStr == common {
-				// This is a known valid timezone, but system might not have it
-				errors = append(errors, fmt.Errorf("%q is a valid IANA timezone but your system's timezone database may be incomplete. Please ensure your system has the IANA timezone database installed. Alternatively, you can use Zscaler-specific timezone format like 'LITHUANIA_EUROPE_VILNIUS' for Lithuania", tzStr))
-				return
-			}
-		}
+	if err == nil {
+		return nil
+	}
 
-		// For other timezones, provide a more helpful error message
-		errors = append(errors, fmt.Errorf("%q is not a valid IANA timezone. Please use IANA format (e.g., 'Europe/Vilnius', 'US/Pacific', 'UTC', 'GMT'). Visit https://nodatime.org/TimeZones for the complete IANA timezone list. If you need to use Zscaler-specific timezone format, please use the 'LITHUANIA_EUROPE_VILNIUS' format instead", tzStr))
+	// Check if it's a common timezone that should work
+	commonTimezones := []string{"UTC", "GMT", "US/Pacific", "US/Eastern", "US/Central", "US/Mountain", "Europe/London", "Europe/Paris", "Europe/Berlin", "Europe/Vilnius", "Asia/Tokyo", "Asia/Shanghai"}
+
+	for _, common := range commonTimezones {
+		if tzStr == common {
+			// This is a known valid timezone, but system might not have it
+			return fmt.Errorf("%q is a valid IANA timezone but your system's timezone database may be incomplete. Please ensure your system has the IANA timezone database installed. Alternatively, you can use Zscaler-specific timezone format like 'LITHUANIA_EUROPE_VILNIUS' for Lithuania", tzStr)
+		}
 	}
 
-	return
+	// For other timezones, provide a more helpful error message
+	return fmt.Errorf("%q is not a valid IANA timezone. Please use IANA format (e.g., 'Europe/Vilnius', 'US/Pacific', 'UTC', 'GMT'). Visit https://nodatime.org/TimeZones for the complete IANA timezone list. If you need to use Zscaler-specific timezone format, please use the 'LITHUANIA_EUROPE_VILNIUS' format instead", tzStr)
 }
 
 func ConvertRFC1123ToEpoch(timeStr string) (int, error) {
@@ -733,15 +788,21 @@ func isSingleDigitDay(timeStr string) bool {
 func processCountries(countries []string) []string {
 	processedCountries := make([]string, len(countries))
 	for i, country := range countries {
-		if country != "ANY" && country != "NONE" && len(country) == 2 { // Assuming the 2 letter code is an ISO Alpha-2 Code
-			processedCountries[i] = "COUNTRY_" + country
-		} else {
-			processedCountries[i] = country
-		}
+		processedCountries[i] = processCountry(country)
 	}
 	return processedCountries
 }
 
+// processCountry returns the API form of a single country code, e.g. "US"
+// becomes "COUNTRY_US". ANY, NONE and anything that is not a two letter code
+// are returned unchanged.
+func processCountry(country string) string {
+	if country != "ANY" && country != "NONE" && len(country) == 2 { // Assuming the 2 letter code is an ISO Alpha-2 Code
+		return "COUNTRY_" + country
+	}
+	return country
+}
+
 // func handleInvalidInputError(err error) error {
 // 	if err == nil {
 // 		return nil
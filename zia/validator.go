@@ -1515,18 +1515,26 @@ func validateISOCountryCodes(value interface{}, key string) ([]string, []error)
 		return warnings, errors
 	}
 
+	if err := checkISOCountryCode(code); err != nil {
+		errors = append(errors, err)
+	}
+
+	return warnings, errors
+}
+
+// checkISOCountryCode accepts an ISO-3166 Alpha-2 code or one of the special
+// values ANY and NONE. Shared with the country_code provider function.
+func checkISOCountryCode(code string) error {
 	// Special values
 	if code == "ANY" || code == "NONE" {
-		return warnings, errors
+		return nil
 	}
 
 	// Check against ISO 3166 Alpha-2
 	if !iso3166.ExistsIso3166ByAlpha2Code(code) {
-		errors = append(errors, fmt.Errorf("'%s' is not a valid ISO-3166 Alpha-2 country code. Please visit the following site for reference: https://en.wikipedia.org/wiki/List_of_ISO_3166_country_codes", code))
-		return warnings, errors
+		return fmt.Errorf("'%s' is not a valid ISO-3166 Alpha-2 country code. Please visit the following site for reference: https://en.wikipedia.org/wiki/List_of_ISO_3166_country_codes", code)
 	}
-
-	return warnings, errors
+	return nil
 }
 
 var supportedCloudApplications = []string{